@@ -0,0 +1,7 @@
+// Package e2e holds the end-to-end tests: they build envdrift-agent and the
+// stub dotenvx in stubdotenvx, run `envdrift-agent start` against temporary
+// projects and check what it does to their env files. They are behind the
+// e2e build tag:
+//
+//	go test -tags e2e ./e2e/
+package e2e
@@ -0,0 +1,404 @@
+//go:build e2e
+
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jainal09/envdrift-agent/internal/encrypt"
+)
+
+// waitTimeout bounds every wait on the daemon. The idle timeout is 2s and
+// checks run every second, so a file is normally encrypted within 5s.
+const waitTimeout = 30 * time.Second
+
+// binDir holds the envdrift-agent build and the stub, installed as both
+// envdrift and dotenvx.
+var binDir string
+
+// TestMain builds the binaries once for the whole suite.
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "envdrift-e2e-bin-")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	binDir = dir
+	err = build()
+	code := 1
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	} else {
+		code = m.Run()
+	}
+	_ = os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// build compiles envdrift-agent and the stub into binDir.
+func build() error {
+	for _, b := range []struct{ pkg, name string }{
+		{"../cmd/envdrift-agent", "envdrift-agent"},
+		{"./stubdotenvx", "envdrift"},
+		{"./stubdotenvx", "dotenvx"},
+	} {
+		out, err := exec.Command("go", "build", "-o", exe(filepath.Join(binDir, b.name)), b.pkg).CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("go build %s: %v\n%s", b.pkg, err, out)
+		}
+	}
+	return nil
+}
+
+// exe adds the platform's executable suffix to path.
+func exe(path string) string {
+	if runtime.GOOS == "windows" {
+		return path + ".exe"
+	}
+	return path
+}
+
+// env is one test's isolated home: its own registry, agent state and
+// project, and a webhook recording the agent's notifications.
+type env struct {
+	t       *testing.T
+	home    string
+	project string
+	vars    []string
+	hook    *webhook
+}
+
+// newEnv sets up a home with one registered project under the guardian and
+// a guardian.toml that encrypts after 2s idle and sends every notification
+// to a webhook.
+func newEnv(t *testing.T) *env {
+	t.Helper()
+	home := t.TempDir()
+	e := &env{
+		t:       t,
+		home:    home,
+		project: filepath.Join(home, "project"),
+		hook:    newWebhook(t),
+	}
+	state := filepath.Join(home, "envdrift")
+	e.write(filepath.Join(e.project, "envdrift.toml"), "[guardian]\nenabled = true\n")
+	registry, err := json.Marshal(map[string]any{
+		"projects": []map[string]string{{"path": e.project, "added": time.Now().Format(time.RFC3339)}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.write(filepath.Join(home, ".envdrift", "projects.json"), string(registry))
+	e.write(filepath.Join(state, "guardian.toml"), fmt.Sprintf(`[guardian]
+idle_timeout = "2s"
+debounce = "100ms"
+
+[keys]
+resolution = ["env", "dotenv_keys"]
+
+[notifications]
+encrypted = ["webhook"]
+failure = ["webhook"]
+warning = ["webhook"]
+info = ["webhook"]
+webhook = %q
+respect_dnd = false
+`, e.hook.URL))
+
+	for _, v := range os.Environ() {
+		name, _, _ := strings.Cut(v, "=")
+		switch strings.ToUpper(name) {
+		case "HOME", "USERPROFILE", "ENVDRIFT_HOME", "PATH", "ENVDRIFT_STUB_LOG":
+			continue
+		}
+		if strings.HasPrefix(name, "ENVDRIFT_") || strings.HasPrefix(name, "DOTENV_") {
+			continue
+		}
+		e.vars = append(e.vars, v)
+	}
+	e.vars = append(e.vars,
+		"HOME="+home,
+		"USERPROFILE="+home,
+		"ENVDRIFT_HOME="+state,
+		"ENVDRIFT_STUB_LOG="+filepath.Join(home, "stub.log"),
+		"PATH="+binDir+string(os.PathListSeparator)+os.Getenv("PATH"),
+	)
+	return e
+}
+
+// write creates the file at path, and its directory, with content.
+func (e *env) write(path, content string) {
+	e.t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		e.t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		e.t.Fatal(err)
+	}
+}
+
+// read returns the content of the file at path.
+func (e *env) read(path string) string {
+	e.t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		e.t.Fatal(err)
+	}
+	return string(data)
+}
+
+// agent runs envdrift-agent with args to completion and returns its output.
+func (e *env) agent(args ...string) (string, error) {
+	cmd := exec.Command(exe(filepath.Join(binDir, "envdrift-agent")), args...)
+	cmd.Env = e.vars
+	cmd.Dir = e.project
+	out, err := cmd.CombinedOutput()
+	return string(out), err
+}
+
+// start runs `envdrift-agent start` until the test ends and waits for it to
+// watch the project.
+func (e *env) start() *daemon {
+	e.t.Helper()
+	d := &daemon{done: make(chan struct{})}
+	d.cmd = exec.Command(exe(filepath.Join(binDir, "envdrift-agent")), "start")
+	d.cmd.Env = e.vars
+	d.cmd.Dir = e.home
+	d.cmd.Stdout = &d.out
+	d.cmd.Stderr = &d.out
+	if err := d.cmd.Start(); err != nil {
+		e.t.Fatal(err)
+	}
+	go func() {
+		_ = d.cmd.Wait()
+		close(d.done)
+	}()
+	e.t.Cleanup(func() {
+		d.stop()
+		if e.t.Failed() {
+			e.t.Logf("envdrift-agent start output:\n%s", d.out.String())
+		}
+	})
+	e.waitFor("the daemon to watch the project", func() bool {
+		return strings.Contains(d.out.String(), "Watching project: ")
+	})
+	return d
+}
+
+// waitFor polls cond until it holds, failing the test after waitTimeout.
+func (e *env) waitFor(what string, cond func() bool) {
+	e.t.Helper()
+	deadline := time.Now().Add(waitTimeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			e.t.Fatalf("timed out after %v waiting for %s", waitTimeout, what)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// waitEncrypted waits for the guardian to encrypt the file at path.
+func (e *env) waitEncrypted(path string) {
+	e.t.Helper()
+	e.waitFor(filepath.Base(path)+" to be encrypted", func() bool {
+		ok, err := encrypt.IsEncrypted(path)
+		return err == nil && ok
+	})
+}
+
+// daemon is a running `envdrift-agent start`.
+type daemon struct {
+	cmd  *exec.Cmd
+	out  syncBuffer
+	done chan struct{}
+}
+
+// stop interrupts the daemon, as Ctrl-C does, and waits for it to exit.
+// Windows cannot deliver an interrupt to another process, so there it is
+// killed.
+func (d *daemon) stop() {
+	if runtime.GOOS == "windows" || d.cmd.Process.Signal(os.Interrupt) != nil {
+		_ = d.cmd.Process.Kill()
+	}
+	select {
+	case <-d.done:
+	case <-time.After(waitTimeout):
+		_ = d.cmd.Process.Kill()
+		<-d.done
+	}
+}
+
+// syncBuffer is a bytes.Buffer safe for the daemon to write while the test
+// reads it.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// notification is a webhook payload the agent sent.
+type notification struct {
+	Event   string `json:"event"`
+	Title   string `json:"title"`
+	Message string `json:"message"`
+}
+
+// webhook is an HTTP server recording the notifications POSTed to it.
+type webhook struct {
+	*httptest.Server
+	mu   sync.Mutex
+	sent []notification
+}
+
+func newWebhook(t *testing.T) *webhook {
+	h := &webhook{}
+	h.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var n notification
+		if err := json.NewDecoder(r.Body).Decode(&n); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		h.mu.Lock()
+		h.sent = append(h.sent, n)
+		h.mu.Unlock()
+	}))
+	t.Cleanup(h.Close)
+	return h
+}
+
+// events returns the notifications received so far.
+func (h *webhook) events() []notification {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]notification(nil), h.sent...)
+}
+
+// TestEncryptsIdleFile creates a plaintext .env in a watched project and
+// checks that the daemon encrypts it once idle, writes its key, notifies the
+// webhook, and leaves an excluded .env.example alone.
+func TestEncryptsIdleFile(t *testing.T) {
+	e := newEnv(t)
+	e.start()
+
+	example := filepath.Join(e.project, ".env.example")
+	e.write(example, "API_KEY=\n")
+	path := filepath.Join(e.project, ".env")
+	e.write(path, "API_KEY=sk-live-1234\nDEBUG=true\n")
+	e.waitEncrypted(path)
+
+	if content := e.read(path); strings.Contains(content, "sk-live-1234") {
+		t.Errorf("encrypted .env still holds the plaintext value:\n%s", content)
+	}
+	if keys := e.read(filepath.Join(e.project, ".env.keys")); !strings.Contains(keys, "DOTENV_PRIVATE_KEY=") {
+		t.Errorf(".env.keys = %q, want DOTENV_PRIVATE_KEY", keys)
+	}
+	if content := e.read(example); content != "API_KEY=\n" {
+		t.Errorf(".env.example = %q, want it untouched", content)
+	}
+
+	e.waitFor("the encrypted notification", func() bool {
+		for _, n := range e.hook.events() {
+			if n.Event == "encrypted" && strings.Contains(n.Message, ".env") {
+				return true
+			}
+		}
+		return false
+	})
+	for _, n := range e.hook.events() {
+		if n.Event == "failure" {
+			t.Errorf("unexpected failure notification: %s", n.Message)
+		}
+	}
+
+	log := e.read(filepath.Join(e.home, "stub.log"))
+	if want := "envdrift encrypt .env"; !strings.Contains(log, want) {
+		t.Errorf("stub log = %q, want an invocation %q", log, want)
+	}
+}
+
+// TestReencryptsEdit checks that a plaintext value added to an encrypted
+// file is encrypted again, and that the file's other values survive.
+func TestReencryptsEdit(t *testing.T) {
+	e := newEnv(t)
+	e.start()
+
+	path := filepath.Join(e.project, ".env.production")
+	e.write(path, "DB_PASSWORD=hunter2\n")
+	e.waitEncrypted(path)
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteString("STRIPE_KEY=sk-test-5678\n"); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	e.waitEncrypted(path)
+
+	out, err := e.agent("reveal", path)
+	if err != nil {
+		t.Fatalf("reveal: %v\n%s", err, out)
+	}
+	for _, want := range []string{`DB_PASSWORD="hunter2"`, `STRIPE_KEY="sk-test-5678"`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("reveal output = %q, want %s", out, want)
+		}
+	}
+}
+
+// TestRevealRoundTrip checks that reveal decrypts what the daemon encrypted,
+// through dotenvx and the project's .env.keys, without touching the file.
+func TestRevealRoundTrip(t *testing.T) {
+	e := newEnv(t)
+	d := e.start()
+
+	path := filepath.Join(e.project, ".env")
+	e.write(path, "TOKEN=abc def\n")
+	e.waitEncrypted(path)
+	d.stop()
+	encrypted := e.read(path)
+
+	out, err := e.agent("reveal", path, "--key", "TOKEN")
+	if err != nil {
+		t.Fatalf("reveal: %v\n%s", err, out)
+	}
+	if got := strings.TrimSpace(out); got != "abc def" {
+		t.Errorf("reveal --key TOKEN = %q, want %q", got, "abc def")
+	}
+	if e.read(path) != encrypted {
+		t.Error("reveal changed the encrypted file")
+	}
+
+	if err := os.Remove(filepath.Join(e.project, ".env.keys")); err != nil {
+		t.Fatal(err)
+	}
+	if out, err := e.agent("reveal", path); err == nil {
+		t.Errorf("reveal without a key succeeded:\n%s", out)
+	}
+}
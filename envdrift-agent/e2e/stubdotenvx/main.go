@@ -0,0 +1,215 @@
+// Command stubdotenvx stands in for dotenvx and the envdrift CLI in the
+// end-to-end tests. It acts as whichever it is installed as:
+//
+//	envdrift encrypt <file>
+//	envdrift --version
+//	dotenvx encrypt -f <file> -fk <keys file>
+//	dotenvx decrypt [--stdout] -f <file> -fk <keys file>
+//	dotenvx --version
+//
+// Encryption is deterministic and not encryption at all: a value v becomes
+// "encrypted:" + base64("stub:" + v), which the agent counts as dotenvx
+// ciphertext. The file's DOTENV_PUBLIC_KEY_<ENV> is written at the top and
+// its DOTENV_PRIVATE_KEY_<ENV> to the keys file; decrypting needs that
+// private key, from the environment or the keys file, as dotenvx does.
+// Comments are not kept.
+//
+// When ENVDRIFT_STUB_LOG names a file, every invocation appends its
+// arguments to it, one line each.
+package main
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jainal09/envdrift-agent/internal/dotenv"
+	"github.com/jainal09/envdrift-agent/internal/keys"
+)
+
+const (
+	ciphertextPrefix = "encrypted:"
+	plaintextPrefix  = "stub:"
+	// privateKey is the one private key the stub hands out and accepts.
+	privateKey = "stub-private-key"
+	publicKey  = "stub-public-key"
+	version    = "1.0.0-stub"
+)
+
+// main dispatches on the name the stub was invoked as.
+func main() {
+	name := strings.TrimSuffix(filepath.Base(os.Args[0]), ".exe")
+	args := os.Args[1:]
+	logInvocation(name, args)
+
+	var err error
+	switch name {
+	case "envdrift":
+		err = runEnvdrift(args)
+	default:
+		err = runDotenvx(args)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", name, err)
+		os.Exit(1)
+	}
+}
+
+// logInvocation appends the invocation to ENVDRIFT_STUB_LOG, if set.
+func logInvocation(name string, args []string) {
+	path := os.Getenv("ENVDRIFT_STUB_LOG")
+	if path == "" {
+		return
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return
+	}
+	defer func() { _ = f.Close() }()
+	_, _ = fmt.Fprintln(f, strings.Join(append([]string{name}, args...), " "))
+}
+
+// runEnvdrift is the envdrift CLI: `encrypt <file>` encrypts the file with
+// its sibling .env.keys.
+func runEnvdrift(args []string) error {
+	if len(args) == 1 && args[0] == "--version" {
+		fmt.Println("envdrift " + version)
+		return nil
+	}
+	if len(args) != 2 || args[0] != "encrypt" {
+		return fmt.Errorf("unsupported arguments %q", args)
+	}
+	return encryptFile(args[1], filepath.Join(filepath.Dir(args[1]), ".env.keys"))
+}
+
+// runDotenvx is dotenvx's encrypt, decrypt and --version.
+func runDotenvx(args []string) error {
+	if len(args) == 1 && args[0] == "--version" {
+		fmt.Println(version)
+		return nil
+	}
+	if len(args) == 0 {
+		return errors.New("no command")
+	}
+	var file, keysFile string
+	stdout := false
+	for i := 1; i < len(args); i++ {
+		switch args[i] {
+		case "--stdout":
+			stdout = true
+		case "-f", "-fk":
+			if i+1 == len(args) {
+				return fmt.Errorf("%s needs a value", args[i])
+			}
+			if args[i] == "-f" {
+				file = args[i+1]
+			} else {
+				keysFile = args[i+1]
+			}
+			i++
+		default:
+			return fmt.Errorf("unsupported argument %q", args[i])
+		}
+	}
+	if file == "" {
+		file = ".env"
+	}
+	if keysFile == "" {
+		keysFile = filepath.Join(filepath.Dir(file), ".env.keys")
+	}
+
+	switch args[0] {
+	case "encrypt":
+		return encryptFile(file, keysFile)
+	case "decrypt":
+		return decryptFile(file, keysFile, stdout)
+	}
+	return fmt.Errorf("unsupported command %q", args[0])
+}
+
+// encryptFile encrypts the plaintext values of file in place and records its
+// private key in keysFile.
+func encryptFile(file, keysFile string) error {
+	entries, err := readEntries(file)
+	if err != nil {
+		return err
+	}
+	privateName := keys.DotenvxKeyName(file)
+	publicName := strings.Replace(privateName, "PRIVATE", "PUBLIC", 1)
+
+	out := []dotenv.Entry{{Key: publicName, Value: publicKey}}
+	for _, e := range entries {
+		if e.Key == publicName {
+			continue
+		}
+		if !strings.HasPrefix(e.Value, ciphertextPrefix) && e.Value != "" {
+			e.Value = ciphertextPrefix + base64.StdEncoding.EncodeToString([]byte(plaintextPrefix+e.Value))
+		}
+		out = append(out, e)
+	}
+	if err := setKey(keysFile, privateName); err != nil {
+		return err
+	}
+	return os.WriteFile(file, dotenv.Marshal(out), 0o600)
+}
+
+// decryptFile decrypts file to stdout or in place.
+func decryptFile(file, keysFile string, stdout bool) error {
+	entries, err := readEntries(file)
+	if err != nil {
+		return err
+	}
+	privateName := keys.DotenvxKeyName(file)
+	key, ok := os.LookupEnv(privateName)
+	if !ok {
+		stored, err := readEntries(keysFile)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		key = dotenv.Map(stored)[privateName]
+	}
+	if key != privateKey {
+		return fmt.Errorf("[MISSING_PRIVATE_KEY] could not decrypt %s: %s not set", file, privateName)
+	}
+
+	for i, e := range entries {
+		if !strings.HasPrefix(e.Value, ciphertextPrefix) {
+			continue
+		}
+		raw, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(e.Value, ciphertextPrefix))
+		if err != nil || !strings.HasPrefix(string(raw), plaintextPrefix) {
+			return fmt.Errorf("could not decrypt %s in %s", e.Key, file)
+		}
+		entries[i].Value = strings.TrimPrefix(string(raw), plaintextPrefix)
+	}
+	if stdout {
+		_, err := os.Stdout.Write(dotenv.Marshal(entries))
+		return err
+	}
+	return os.WriteFile(file, dotenv.Marshal(entries), 0o600)
+}
+
+// setKey adds the private key named name to keysFile unless it is there.
+func setKey(keysFile, name string) error {
+	stored, err := readEntries(keysFile)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if _, ok := dotenv.Map(stored)[name]; ok {
+		return nil
+	}
+	stored = append(stored, dotenv.Entry{Key: name, Value: privateKey})
+	return os.WriteFile(keysFile, dotenv.Marshal(stored), 0o600)
+}
+
+// readEntries parses the dotenv file at path.
+func readEntries(path string) ([]dotenv.Entry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return dotenv.Parse(data)
+}
@@ -0,0 +1,292 @@
+// Package backups keeps copies of env files taken just before the guardian
+// encrypts them, so an unwanted encryption can be undone.
+//
+// The copies are plaintext: the store (backups in the state directory) is created 0700
+// with 0600 files, is off by default ([backups] enabled), and is pruned by a
+// retention policy after every backup. Each source file gets a directory
+// named by a hash of its absolute path, holding a "source" file with that
+// path and one file per backup named by its UTC timestamp.
+package backups
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jainal09/envdrift-agent/internal/clock"
+	"github.com/jainal09/envdrift-agent/internal/fsys"
+	"github.com/jainal09/envdrift-agent/internal/paths"
+)
+
+// stampLayout names backup files; it sorts chronologically.
+const stampLayout = "20060102T150405.000000000Z"
+
+// sourceFile holds the original path inside each per-file directory.
+const sourceFile = "source"
+
+// Backup is one stored copy.
+type Backup struct {
+	// ID identifies the backup for restore: "<dir>/<stamp>".
+	ID string
+	// Path is the file the copy was taken from.
+	Path string
+	// Time is when it was taken.
+	Time time.Time
+	// Size is the copy's size in bytes.
+	Size int64
+
+	file string
+}
+
+// Policy is the retention policy applied by Prune.
+type Policy struct {
+	// Keep is how many backups to keep per file; 0 keeps any number.
+	Keep int
+	// MaxAge drops backups older than this; 0 keeps them regardless of age.
+	MaxAge time.Duration
+	// Trash moves pruned and purged backups to the OS trash instead of
+	// deleting them.
+	Trash bool
+}
+
+// Store is a backup directory.
+type Store struct {
+	Dir string
+	// FS holds the store and the files backed up; nil is the disk. Pruning
+	// to the trash always moves files on the disk.
+	FS fsys.FS
+	// Clock stamps new backups; nil is the system clock.
+	Clock clock.Clock
+}
+
+// DefaultDir returns backups in the state directory (see paths.StateDir).
+func DefaultDir() string {
+	return filepath.Join(paths.StateDir(), "backups")
+}
+
+// Create copies path into the store.
+func (s *Store) Create(path string) (Backup, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return Backup{}, err
+	}
+	fs := fsys.Or(s.FS)
+	src, err := fs.Open(path)
+	if err != nil {
+		return Backup{}, err
+	}
+	defer src.Close()
+
+	dir := filepath.Join(s.Dir, pathKey(path))
+	if err := fs.MkdirAll(dir, 0o700); err != nil {
+		return Backup{}, err
+	}
+	if err := fs.Chmod(s.Dir, 0o700); err != nil {
+		return Backup{}, err
+	}
+	if err := fs.WriteFile(filepath.Join(dir, sourceFile), []byte(path), 0o600); err != nil {
+		return Backup{}, err
+	}
+
+	now := clock.Or(s.Clock).Now().UTC()
+	file := filepath.Join(dir, now.Format(stampLayout))
+	dst, err := fs.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return Backup{}, err
+	}
+	size, err := io.Copy(dst, src)
+	if cerr := dst.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		// A truncated backup is worse than none.
+		_ = fs.Remove(file)
+		return Backup{}, err
+	}
+	return Backup{ID: filepath.Base(dir) + "/" + filepath.Base(file), Path: path, Time: now, Size: size, file: file}, nil
+}
+
+// List returns the backups of path (every file when path is ""), newest
+// first.
+func (s *Store) List(path string) ([]Backup, error) {
+	var dirs []string
+	if path != "" {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return nil, err
+		}
+		dirs = []string{filepath.Join(s.Dir, pathKey(abs))}
+	} else {
+		entries, err := fsys.Or(s.FS).ReadDir(s.Dir)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			if e.IsDir() {
+				dirs = append(dirs, filepath.Join(s.Dir, e.Name()))
+			}
+		}
+	}
+
+	var all []Backup
+	for _, dir := range dirs {
+		found, err := s.listDir(dir)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, found...)
+	}
+	sort.SliceStable(all, func(i, j int) bool { return all[i].Time.After(all[j].Time) })
+	return all, nil
+}
+
+// listDir reads one per-file directory.
+func (s *Store) listDir(dir string) ([]Backup, error) {
+	fs := fsys.Or(s.FS)
+	source, err := fs.ReadFile(filepath.Join(dir, sourceFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	entries, err := fs.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var out []Backup
+	for _, e := range entries {
+		t, err := time.Parse(stampLayout, e.Name())
+		if err != nil || e.IsDir() {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		out = append(out, Backup{
+			ID:   filepath.Base(dir) + "/" + e.Name(),
+			Path: string(source),
+			Time: t,
+			Size: info.Size(),
+			file: filepath.Join(dir, e.Name()),
+		})
+	}
+	return out, nil
+}
+
+// Get returns the backup with id.
+func (s *Store) Get(id string) (Backup, error) {
+	dir, stamp, ok := strings.Cut(id, "/")
+	if !ok || strings.ContainsAny(dir+stamp, `/\`) || dir == ".." || stamp == ".." {
+		return Backup{}, fmt.Errorf("invalid backup id %q", id)
+	}
+	found, err := s.listDir(filepath.Join(s.Dir, dir))
+	if err != nil {
+		return Backup{}, err
+	}
+	for _, b := range found {
+		if b.ID == id {
+			return b, nil
+		}
+	}
+	return Backup{}, fmt.Errorf("no backup %s", id)
+}
+
+// Restore writes b's content back over its source file (mode 0600), through
+// a temporary file so a failure never leaves the original half-written.
+func (s *Store) Restore(b Backup) error {
+	fs := fsys.Or(s.FS)
+	data, err := fs.ReadFile(b.file)
+	if err != nil {
+		return err
+	}
+	tmp, err := fs.CreateTemp(filepath.Dir(b.Path), "."+filepath.Base(b.Path)+".restore-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = fs.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = fs.Remove(tmp.Name())
+		return err
+	}
+	if err := fs.Rename(tmp.Name(), b.Path); err != nil {
+		_ = fs.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
+// Prune applies policy to every file's backups and returns the ones removed.
+func (s *Store) Prune(policy Policy, now time.Time) ([]Backup, error) {
+	all, err := s.List("")
+	if err != nil {
+		return nil, err
+	}
+	kept := map[string]int{}
+	var doomed []Backup
+	for _, b := range all { // newest first
+		tooOld := policy.MaxAge > 0 && now.Sub(b.Time) > policy.MaxAge
+		tooMany := policy.Keep > 0 && kept[b.Path] >= policy.Keep
+		if tooOld || tooMany {
+			doomed = append(doomed, b)
+			continue
+		}
+		kept[b.Path]++
+	}
+	return s.remove(doomed, policy.Trash)
+}
+
+// Purge removes every backup of path (all files when path is "").
+func (s *Store) Purge(path string, trash bool) ([]Backup, error) {
+	all, err := s.List(path)
+	if err != nil {
+		return nil, err
+	}
+	return s.remove(all, trash)
+}
+
+// remove deletes or trashes bs, dropping per-file directories left empty.
+func (s *Store) remove(bs []Backup, trash bool) ([]Backup, error) {
+	fs := fsys.Or(s.FS)
+	var removed []Backup
+	var errs []error
+	for _, b := range bs {
+		var err error
+		if trash {
+			err = moveToTrash(b.file, filepath.Base(b.Path)+"."+b.Time.Format(stampLayout))
+		} else {
+			err = fs.Remove(b.file)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("remove backup %s: %w", b.ID, err))
+			continue
+		}
+		removed = append(removed, b)
+		dir := filepath.Dir(b.file)
+		if left, _ := s.listDir(dir); len(left) == 0 {
+			_ = fs.RemoveAll(dir)
+		}
+	}
+	return removed, errors.Join(errs...)
+}
+
+// pathKey names a source file's directory.
+func pathKey(path string) string {
+	sum := sha256.Sum256([]byte(filepath.Clean(path)))
+	return hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,206 @@
+package backups
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jainal09/envdrift-agent/internal/clock"
+	"github.com/jainal09/envdrift-agent/internal/fsys"
+)
+
+// newFixture returns a store in a temp dir and an env file beside it.
+func newFixture(t *testing.T) (*Store, string) {
+	t.Helper()
+	store := &Store{Dir: filepath.Join(t.TempDir(), "backups")}
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(path, []byte("SECRET=one\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return store, path
+}
+
+// backdate shifts b's timestamp by renaming its file, so retention can be
+// tested without sleeping.
+func backdate(t *testing.T, b Backup, age time.Duration) Backup {
+	t.Helper()
+	b.Time = b.Time.Add(-age)
+	file := filepath.Join(filepath.Dir(b.file), b.Time.Format(stampLayout))
+	if err := os.Rename(b.file, file); err != nil {
+		t.Fatal(err)
+	}
+	b.file = file
+	b.ID = filepath.Base(filepath.Dir(file)) + "/" + filepath.Base(file)
+	return b
+}
+
+func TestCreateListRestore(t *testing.T) {
+	store, path := newFixture(t)
+
+	b, err := store.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runtime.GOOS != "windows" {
+		for p, want := range map[string]os.FileMode{store.Dir: 0o700, b.file: 0o600} {
+			if info, err := os.Stat(p); err != nil || info.Mode().Perm() != want {
+				t.Errorf("%s mode = %v, %v; want %v", p, info.Mode().Perm(), err, want)
+			}
+		}
+	}
+
+	if err := os.WriteFile(path, []byte("encrypted:abc\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	list, err := store.List(path)
+	if err != nil || len(list) != 1 || list[0].ID != b.ID || list[0].Size != int64(len("SECRET=one\n")) {
+		t.Fatalf("List = %+v, %v", list, err)
+	}
+	got, err := store.Get(b.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Restore(got); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "SECRET=one\n" {
+		t.Errorf("restored %q", data)
+	}
+}
+
+func TestGetRejectsTraversal(t *testing.T) {
+	store, _ := newFixture(t)
+	for _, id := range []string{"../x", "abc", "a/../../b", `a\b/c`} {
+		if _, err := store.Get(id); err == nil {
+			t.Errorf("Get(%q) succeeded", id)
+		}
+	}
+}
+
+func TestPrune(t *testing.T) {
+	store, path := newFixture(t)
+	now := time.Now()
+	var made []Backup
+	for _, age := range []time.Duration{10 * 24 * time.Hour, 2 * time.Hour, time.Hour, 0} {
+		b, err := store.Create(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		made = append(made, backdate(t, b, age+time.Duration(len(made))*time.Millisecond))
+	}
+
+	removed, err := store.Prune(Policy{Keep: 2, MaxAge: 7 * 24 * time.Hour}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(removed) != 2 {
+		t.Fatalf("removed %d backups; want the 10-day-old one and the third newest", len(removed))
+	}
+	list, _ := store.List(path)
+	if len(list) != 2 || list[0].ID != made[3].ID || list[1].ID != made[2].ID {
+		t.Errorf("kept %+v; want the two newest", list)
+	}
+}
+
+// TestPruneInMemory runs the store on an in-memory filesystem with a fake
+// clock, so backup times are exact and nothing touches the disk.
+func TestPruneInMemory(t *testing.T) {
+	fake := clock.NewFake(time.Date(2026, 1, 2, 15, 4, 0, 0, time.UTC))
+	mem := fsys.NewMem(fake)
+	// Absolute on every platform; nothing is created there.
+	root := t.TempDir()
+	store := &Store{Dir: filepath.Join(root, "backups"), FS: mem, Clock: fake}
+	path := filepath.Join(root, "app", ".env")
+	if err := mem.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	var made []Backup
+	for i := range 3 {
+		if err := mem.WriteFile(path, []byte("SECRET="+strconv.Itoa(i)+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		b, err := store.Create(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		made = append(made, b)
+		fake.Advance(24 * time.Hour)
+	}
+
+	removed, err := store.Prune(Policy{MaxAge: 36 * time.Hour}, fake.Now())
+	if err != nil || len(removed) != 2 || removed[0].ID != made[1].ID || removed[1].ID != made[0].ID {
+		t.Fatalf("Prune = %+v, %v; want the two backups older than 36h", removed, err)
+	}
+	if err := store.Restore(made[2]); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := mem.ReadFile(path); string(data) != "SECRET=2\n" {
+		t.Errorf("restored %q", data)
+	}
+	if entries, _ := mem.ReadDir(filepath.Dir(path)); len(entries) != 1 {
+		t.Errorf("restore left %d files beside the env file", len(entries)-1)
+	}
+}
+
+func TestPurgeToTrash(t *testing.T) {
+	store, path := newFixture(t)
+	if _, err := store.Create(path); err != nil {
+		t.Fatal(err)
+	}
+
+	var trashed []string
+	orig := moveToTrash
+	moveToTrash = func(file, name string) error {
+		trashed = append(trashed, name)
+		return os.Remove(file)
+	}
+	t.Cleanup(func() { moveToTrash = orig })
+
+	removed, err := store.Purge("", true)
+	if err != nil || len(removed) != 1 {
+		t.Fatalf("Purge = %v, %v", removed, err)
+	}
+	if len(trashed) != 1 || !strings.HasPrefix(trashed[0], ".env.") {
+		t.Errorf("trashed %v", trashed)
+	}
+	if entries, _ := os.ReadDir(store.Dir); len(entries) != 0 {
+		t.Errorf("emptied file directory left behind: %v", entries)
+	}
+}
+
+func TestTrashFreedesktop(t *testing.T) {
+	dataHome := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", dataHome)
+	dir := t.TempDir()
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.Local)
+
+	for i := 0; i < 2; i++ {
+		file := filepath.Join(dir, "my backup")
+		if err := os.WriteFile(file, []byte("x"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := trashFreedesktop(file, ".env.stamp", now); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	trash := filepath.Join(dataHome, "Trash")
+	for _, name := range []string{".env.stamp", ".env.stamp.2"} {
+		if _, err := os.Stat(filepath.Join(trash, "files", name)); err != nil {
+			t.Errorf("trashed file %s: %v", name, err)
+		}
+	}
+	info, err := os.ReadFile(filepath.Join(trash, "info", ".env.stamp.trashinfo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Path=" + filepath.ToSlash(dir) + "/my%20backup\nDeletionDate=2026-01-02T03:04:05\n"
+	if !strings.Contains(string(info), want) {
+		t.Errorf("trashinfo = %q; want it to contain %q", info, want)
+	}
+}
@@ -0,0 +1,122 @@
+package backups
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"time"
+)
+
+// moveToTrash moves file to the OS trash under name. It is a seam so tests do
+// not fill the real trash.
+var moveToTrash = trashFile
+
+// trashFile dispatches on the platform: ~/.Trash on macOS, the FreeDesktop
+// trash on Linux, the Recycle Bin on Windows.
+func trashFile(file, name string) error {
+	switch runtime.GOOS {
+	case "darwin":
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return err
+		}
+		return moveUnique(file, filepath.Join(homeDir, ".Trash"), name)
+	case "linux":
+		return trashFreedesktop(file, name, time.Now())
+	case "windows":
+		return trashWindows(file)
+	default:
+		return fmt.Errorf("no trash on %s", runtime.GOOS)
+	}
+}
+
+// moveUnique renames file into dir as name, adding " 2", " 3"… if taken, the
+// way Finder names clashing items in the Trash.
+func moveUnique(file, dir, name string) error {
+	for i := 1; i < 1000; i++ {
+		candidate := name
+		if i > 1 {
+			candidate = name + " " + strconv.Itoa(i)
+		}
+		dst := filepath.Join(dir, candidate)
+		if _, err := os.Lstat(dst); err == nil {
+			continue
+		}
+		return os.Rename(file, dst)
+	}
+	return fmt.Errorf("no free name for %s in %s", name, dir)
+}
+
+// trashFreedesktop follows the FreeDesktop.org trash spec: the file goes to
+// $XDG_DATA_HOME/Trash/files and a .trashinfo with its original path and
+// deletion time to Trash/info, so file managers can restore it. The store
+// lives under $HOME, so the home trash is always the right one.
+func trashFreedesktop(file, name string, now time.Time) error {
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return err
+		}
+		dataHome = filepath.Join(homeDir, ".local", "share")
+	}
+	trash := filepath.Join(dataHome, "Trash")
+	filesDir, infoDir := filepath.Join(trash, "files"), filepath.Join(trash, "info")
+	for _, d := range []string{filesDir, infoDir} {
+		if err := os.MkdirAll(d, 0o700); err != nil {
+			return err
+		}
+	}
+
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return err
+	}
+	// Reserve the info file first (the spec's O_EXCL handshake), then move.
+	for i := 1; i < 1000; i++ {
+		candidate := name
+		if i > 1 {
+			candidate = name + "." + strconv.Itoa(i)
+		}
+		info, err := os.OpenFile(filepath.Join(infoDir, candidate+".trashinfo"), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+		if os.IsExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		_, werr := fmt.Fprintf(info, "[Trash Info]\nPath=%s\nDeletionDate=%s\n",
+			(&url.URL{Path: abs}).EscapedPath(), now.Format("2006-01-02T15:04:05"))
+		if cerr := info.Close(); werr == nil {
+			werr = cerr
+		}
+		if werr == nil {
+			werr = os.Rename(file, filepath.Join(filesDir, candidate))
+		}
+		if werr != nil {
+			_ = os.Remove(info.Name())
+		}
+		return werr
+	}
+	return fmt.Errorf("no free trash name for %s", name)
+}
+
+// trashWindows sends file to the Recycle Bin through the VisualBasic
+// FileSystem API; the path travels in the environment, not the script.
+func trashWindows(file string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "powershell", "-NoProfile", "-NonInteractive", "-Command",
+		"Add-Type -AssemblyName Microsoft.VisualBasic; "+
+			"[Microsoft.VisualBasic.FileIO.FileSystem]::DeleteFile($env:ENVDRIFT_TRASH_PATH, 'OnlyErrorDialogs', 'SendToRecycleBin')")
+	cmd.Env = append(os.Environ(), "ENVDRIFT_TRASH_PATH="+file)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("recycle bin: %w: %s", err, out)
+	}
+	return nil
+}
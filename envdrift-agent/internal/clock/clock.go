@@ -0,0 +1,155 @@
+// Package clock abstracts the time sources the agent's timing decisions
+// depend on — idle timeouts, debounce windows, backup stamps, history
+// entries — so tests can drive them with a Fake instead of sleeping.
+package clock
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock tells the time and schedules functions after a delay.
+type Clock interface {
+	Now() time.Time
+	// AfterFunc calls f once d has passed. Real runs f in its own
+	// goroutine, as time.AfterFunc does; Fake runs it in the call that
+	// moves the clock.
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+// Timer is a pending AfterFunc call; *time.Timer implements it.
+type Timer interface {
+	Stop() bool
+	Reset(d time.Duration) bool
+}
+
+// Real is the system clock.
+type Real struct{}
+
+// Now returns time.Now().
+func (Real) Now() time.Time { return time.Now() }
+
+// AfterFunc returns time.AfterFunc(d, f).
+func (Real) AfterFunc(d time.Duration, f func()) Timer { return time.AfterFunc(d, f) }
+
+// Or returns c, or Real when c is nil, so a zero-value field means the
+// system clock.
+func Or(c Clock) Clock {
+	if c == nil {
+		return Real{}
+	}
+	return c
+}
+
+// Fake is a Clock that moves only when Advance or Set moves it. Its timers
+// fire during the call that moves the clock past them, in the order they are
+// due, each run to completion before that call returns.
+type Fake struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+}
+
+// NewFake returns a Fake reading t.
+func NewFake(t time.Time) *Fake {
+	return &Fake{now: t}
+}
+
+// Now returns the fake time.
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// AfterFunc schedules fn for when the fake time reaches d from now.
+func (f *Fake) AfterFunc(d time.Duration, fn func()) Timer {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	t := &fakeTimer{clock: f, fn: fn}
+	t.schedule(d)
+	return t
+}
+
+// Advance moves the fake time forward by d, firing the timers that come due.
+func (f *Fake) Advance(d time.Duration) {
+	f.Set(f.Now().Add(d))
+}
+
+// Set moves the fake time to t, firing the timers due by then. Setting it
+// back fires nothing.
+func (f *Fake) Set(t time.Time) {
+	for {
+		f.mu.Lock()
+		due := f.nextDue(t)
+		if due == nil {
+			f.now = t
+			f.mu.Unlock()
+			return
+		}
+		if due.when.After(f.now) {
+			f.now = due.when
+		}
+		due.stop()
+		f.mu.Unlock()
+		// Outside the lock: fn may read the clock or schedule timers.
+		due.fn()
+	}
+}
+
+// Pending returns how many timers are scheduled.
+func (f *Fake) Pending() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.timers)
+}
+
+// nextDue returns the earliest timer due by t. Callers hold f.mu.
+func (f *Fake) nextDue(t time.Time) *fakeTimer {
+	sort.SliceStable(f.timers, func(i, j int) bool { return f.timers[i].when.Before(f.timers[j].when) })
+	if len(f.timers) == 0 || f.timers[0].when.After(t) {
+		return nil
+	}
+	return f.timers[0]
+}
+
+// fakeTimer is a Fake's scheduled call.
+type fakeTimer struct {
+	clock *Fake
+	fn    func()
+	when  time.Time
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	return t.stop()
+}
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	active := t.stop()
+	t.schedule(d)
+	return active
+}
+
+// schedule adds t to its clock's timers, due d from now. Callers hold the
+// clock's mu.
+func (t *fakeTimer) schedule(d time.Duration) {
+	t.when = t.clock.now.Add(d)
+	t.clock.timers = append(t.clock.timers, t)
+}
+
+// stop removes t from its clock's timers and reports whether it was there.
+// Callers hold the clock's mu.
+func (t *fakeTimer) stop() bool {
+	for i, other := range t.clock.timers {
+		if other == t {
+			t.clock.timers = append(t.clock.timers[:i], t.clock.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,48 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFake(t *testing.T) {
+	start := time.Date(2026, 1, 2, 15, 4, 0, 0, time.UTC)
+	c := NewFake(start)
+
+	var fired []string
+	c.AfterFunc(2*time.Second, func() { fired = append(fired, "late") })
+	early := c.AfterFunc(time.Second, func() { fired = append(fired, "early:"+c.Now().Sub(start).String()) })
+	stopped := c.AfterFunc(time.Second, func() { fired = append(fired, "stopped") })
+	if !stopped.Stop() {
+		t.Error("Stop of a pending timer reported false")
+	}
+
+	c.Advance(1500 * time.Millisecond)
+	if len(fired) != 1 || fired[0] != "early:1s" {
+		t.Fatalf("fired %v after 1.5s; want the 1s timer, run at its due time", fired)
+	}
+	if early.Reset(time.Second) {
+		t.Error("Reset of a fired timer reported it active")
+	}
+
+	c.Advance(time.Second)
+	if len(fired) != 3 || fired[1] != "late" || fired[2] != "early:2.5s" {
+		t.Errorf("fired %v after 2.5s; want both timers in order", fired)
+	}
+	if got := c.Now(); !got.Equal(start.Add(2500 * time.Millisecond)) {
+		t.Errorf("Now = %v", got)
+	}
+	if c.Pending() != 0 {
+		t.Errorf("%d timers pending", c.Pending())
+	}
+}
+
+func TestOr(t *testing.T) {
+	if _, ok := Or(nil).(Real); !ok {
+		t.Error("Or(nil) is not the system clock")
+	}
+	fake := NewFake(time.Time{})
+	if Or(fake) != Clock(fake) {
+		t.Error("Or replaced a clock")
+	}
+}
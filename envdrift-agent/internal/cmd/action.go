@@ -0,0 +1,208 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jainal09/envdrift-agent/internal/compliance"
+	"github.com/jainal09/envdrift-agent/internal/config"
+	"github.com/jainal09/envdrift-agent/internal/guardian"
+	"github.com/jainal09/envdrift-agent/internal/paths"
+	"github.com/jainal09/envdrift-agent/internal/severity"
+)
+
+var actionCmd = &cobra.Command{
+	Use:   "action [dir...]",
+	Short: "Check a repository's env files in GitHub Actions",
+	Long: `Scans the directories (default: $GITHUB_WORKSPACE, or the current directory) as
+projects with the rules the agent applies locally — the project's envdrift.toml
+patterns, excludes and ignore_generated — and fails when an env file is in
+plaintext, so a pull request is gated by the same rules developers see.
+
+Each plaintext file gets an error annotation on the pull request, naming its
+severity and the variable that set it (a warning with --warn-only, or when the
+file is less severe than [severity] fail, which then does not fail the check),
+the step summary gets a table of them, and these outputs are
+written to $GITHUB_OUTPUT:
+
+  compliant        true or false
+  env-files        how many env files were found
+  encrypted        how many of them are encrypted
+  plaintext        how many are in plaintext
+  plaintext-files  the plaintext files as a JSON array of paths
+
+File permissions are not checked: git does not record them. Outside GitHub
+Actions the annotations are printed all the same and the outputs skipped.`,
+	SilenceUsage: true,
+	RunE:         runAction,
+}
+
+// actionWarnOnly is the --warn-only flag of action.
+var actionWarnOnly bool
+
+// init registers the action command with rootCmd.
+func init() {
+	actionCmd.Flags().BoolVar(&actionWarnOnly, "warn-only", false, "annotate plaintext files as warnings and exit zero")
+	rootCmd.AddCommand(actionCmd)
+}
+
+// runAction scans the directories and reports for GitHub Actions.
+func runAction(cmd *cobra.Command, args []string) error {
+	dirs := args
+	if len(dirs) == 0 {
+		dir := os.Getenv("GITHUB_WORKSPACE")
+		if dir == "" {
+			dir = "."
+		}
+		dirs = []string{dir}
+	}
+	roots := make([]string, len(dirs))
+	for i, dir := range dirs {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return err
+		}
+		if info, err := os.Stat(abs); err != nil || !info.IsDir() {
+			return fmt.Errorf("%s is not a directory", dir)
+		}
+		roots[i] = abs
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	g, err := guardian.New(cfg.Effective())
+	if err != nil {
+		return err
+	}
+	r, err := g.ComplianceOf(context.Background(), roots)
+	if err != nil {
+		return err
+	}
+	// Permissions are whatever the checkout gave the files.
+	r.Permissions = nil
+
+	w := cmd.OutOrStdout()
+	fail := cfg.Severity.FailLevel()
+	files := make([]string, len(r.Plaintext))
+	for i, f := range r.Plaintext {
+		files[i] = workspacePath(f.Path)
+		level := "error"
+		if actionWarnOnly || f.Level() < fail {
+			level = "warning"
+		}
+		msg := files[i] + " is not encrypted"
+		if f.Severity != "" {
+			msg += " (" + f.Severity + ": " + f.Reason + ")"
+		}
+		fmt.Fprintf(w, "::%s file=%s,title=%s::%s\n", level, escapeProperty(files[i]), escapeProperty("Plaintext env file"),
+			escapeData(msg+". Encrypt it with 'envdrift encrypt "+files[i]+"' before merging."))
+	}
+	fmt.Fprintln(w, actionSummary(r))
+
+	if err := writeActionOutputs(r, files, fail); err != nil {
+		return err
+	}
+	if err := writeActionSummary(r, files); err != nil {
+		return err
+	}
+	if !r.CompliantAt(fail) && !actionWarnOnly {
+		return errNotCompliant
+	}
+	return nil
+}
+
+// actionSummary describes r in one line. Unlike Report.Summary it leaves
+// out how long files have been in plaintext: a checkout stamps every file
+// with the time it ran.
+func actionSummary(r compliance.Report) string {
+	if r.EnvFiles == 0 {
+		return "No env files found"
+	}
+	s := fmt.Sprintf("%d of %d env files encrypted", r.Encrypted, r.EnvFiles)
+	if n := len(r.Plaintext); n > 0 {
+		s += fmt.Sprintf(", %d in plaintext", n)
+	}
+	return s
+}
+
+// workspacePath names path relative to $GITHUB_WORKSPACE (or the current
+// directory), the form annotations need, with forward slashes.
+func workspacePath(path string) string {
+	base := os.Getenv("GITHUB_WORKSPACE")
+	if base == "" {
+		base, _ = os.Getwd()
+	}
+	if paths.Within(base, path) {
+		path, _ = filepath.Rel(base, path)
+	}
+	return filepath.ToSlash(path)
+}
+
+// writeActionOutputs appends the step outputs to $GITHUB_OUTPUT, when set;
+// r is compliant when no plaintext file is of severity fail or above.
+func writeActionOutputs(r compliance.Report, files []string, fail severity.Level) error {
+	list, err := json.Marshal(files)
+	if err != nil {
+		return err
+	}
+	return appendGitHubFile("GITHUB_OUTPUT", func(w io.Writer) {
+		fmt.Fprintf(w, "compliant=%v\n", r.CompliantAt(fail))
+		fmt.Fprintf(w, "env-files=%d\n", r.EnvFiles)
+		fmt.Fprintf(w, "encrypted=%d\n", r.Encrypted)
+		fmt.Fprintf(w, "plaintext=%d\n", len(r.Plaintext))
+		fmt.Fprintf(w, "plaintext-files=%s\n", list)
+	})
+}
+
+// writeActionSummary appends a Markdown report to $GITHUB_STEP_SUMMARY, when
+// set.
+func writeActionSummary(r compliance.Report, files []string) error {
+	return appendGitHubFile("GITHUB_STEP_SUMMARY", func(w io.Writer) {
+		fmt.Fprintf(w, "### envdrift\n\n%s\n", actionSummary(r))
+		if len(files) == 0 {
+			return
+		}
+		fmt.Fprint(w, "\n| Plaintext env file | Severity |\n| --- | --- |\n")
+		for i, f := range files {
+			sev := r.Plaintext[i].Severity
+			if sev != "" {
+				sev += " — " + r.Plaintext[i].Reason
+			}
+			fmt.Fprintf(w, "| `%s` | %s |\n", strings.ReplaceAll(f, "|", `\|`), strings.ReplaceAll(sev, "|", `\|`))
+		}
+	})
+}
+
+// appendGitHubFile appends what write writes to the file named by the
+// environment variable env, doing nothing when it is unset.
+func appendGitHubFile(env string, write func(io.Writer)) error {
+	path := os.Getenv(env)
+	if path == "" {
+		return nil
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("open $%s: %w", env, err)
+	}
+	write(f)
+	return f.Close()
+}
+
+// escapeData escapes the message of a workflow command.
+func escapeData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapeProperty escapes a property value of a workflow command.
+func escapeProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
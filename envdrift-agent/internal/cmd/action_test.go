@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestActionReportsPlaintext(t *testing.T) {
+	t.Setenv("ENVDRIFT_HOME", t.TempDir())
+	ws := t.TempDir()
+	t.Setenv("GITHUB_WORKSPACE", ws)
+	outputs := filepath.Join(t.TempDir(), "output")
+	summary := filepath.Join(t.TempDir(), "summary")
+	t.Setenv("GITHUB_OUTPUT", outputs)
+	t.Setenv("GITHUB_STEP_SUMMARY", summary)
+
+	for name, data := range map[string]string{
+		".env":                  "A=1\n",
+		".env.production":       "DOTENV_PUBLIC_KEY=\"pk\"\nA=\"encrypted:a\"\n",
+		".env.example":          "A=\n",
+		"node_modules/pkg/.env": "A=1\n",
+		"api/.env":              "B=2\n",
+	} {
+		path := filepath.Join(ws, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var out bytes.Buffer
+	actionCmd.SetOut(&out)
+	t.Cleanup(func() { actionCmd.SetOut(nil) })
+
+	if err := runAction(actionCmd, nil); !errors.Is(err, errNotCompliant) {
+		t.Fatalf("action = %v; want errNotCompliant", err)
+	}
+	for _, want := range []string{
+		"::error file=.env,title=Plaintext env file::.env is not encrypted (low: A: plain config).",
+		"::error file=api/.env,title=Plaintext env file::api/.env is not encrypted (low: B: plain config).",
+		"1 of 3 env files encrypted, 2 in plaintext\n",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output lacks %q:\n%s", want, out.String())
+		}
+	}
+	data, err := os.ReadFile(outputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "compliant=false\nenv-files=3\nencrypted=1\nplaintext=2\nplaintext-files=[\".env\",\"api/.env\"]\n"
+	if string(data) != want {
+		t.Errorf("$GITHUB_OUTPUT = %q; want %q", data, want)
+	}
+	if data, err := os.ReadFile(summary); err != nil || !strings.Contains(string(data), "| `api/.env` | low — B: plain config |") {
+		t.Errorf("$GITHUB_STEP_SUMMARY = %q, %v", data, err)
+	}
+
+	actionWarnOnly = true
+	t.Cleanup(func() { actionWarnOnly = false })
+	out.Reset()
+	if err := runAction(actionCmd, nil); err != nil {
+		t.Fatalf("action --warn-only = %v", err)
+	}
+	if !strings.Contains(out.String(), "::warning file=.env,") {
+		t.Errorf("--warn-only output:\n%s", out.String())
+	}
+}
+
+func TestActionFailThreshold(t *testing.T) {
+	t.Setenv("ENVDRIFT_HOME", t.TempDir())
+	ws := t.TempDir()
+	t.Setenv("GITHUB_WORKSPACE", ws)
+	t.Setenv("GITHUB_OUTPUT", "")
+	t.Setenv("GITHUB_STEP_SUMMARY", "")
+	t.Setenv("ENVDRIFT_SEVERITY_FAIL", "high")
+	if err := os.WriteFile(filepath.Join(ws, ".env"), []byte("LOG_LEVEL=debug\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	actionCmd.SetOut(&out)
+	t.Cleanup(func() { actionCmd.SetOut(nil) })
+
+	if err := runAction(actionCmd, nil); err != nil {
+		t.Fatalf("action with plain config below fail = %v", err)
+	}
+	if !strings.Contains(out.String(), "::warning file=.env,") {
+		t.Errorf("output:\n%s", out.String())
+	}
+
+	token := "ghp_" + strings.Repeat("a1B2", 9)
+	if err := os.WriteFile(filepath.Join(ws, ".env"), []byte("GH="+token+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	out.Reset()
+	if err := runAction(actionCmd, nil); !errors.Is(err, errNotCompliant) {
+		t.Fatalf("action with a GitHub token = %v; want errNotCompliant", err)
+	}
+	if !strings.Contains(out.String(), "::error file=.env,title=Plaintext env file::.env is not encrypted (high: GH: GitHub token).") {
+		t.Errorf("output:\n%s", out.String())
+	}
+	if strings.Contains(out.String(), token) {
+		t.Errorf("output holds the token:\n%s", out.String())
+	}
+}
+
+func TestEscapeProperty(t *testing.T) {
+	if got := escapeProperty("a,b:c%\n"); got != "a%2Cb%3Ac%25%0A" {
+		t.Errorf("escapeProperty = %q", got)
+	}
+	if got := escapeData("50%: a,b\r\n"); got != "50%25: a,b%0D%0A" {
+		t.Errorf("escapeData = %q", got)
+	}
+}
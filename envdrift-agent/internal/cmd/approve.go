@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jainal09/envdrift-agent/internal/config"
+	"github.com/jainal09/envdrift-agent/internal/guardian"
+	"github.com/jainal09/envdrift-agent/internal/history"
+	"github.com/jainal09/envdrift-agent/internal/onboarding"
+)
+
+var approveCmd = &cobra.Command{
+	Use:   "approve [project]",
+	Short: "End a new project's grace period so the agent starts encrypting there",
+	Long: `With [guardian] grace_period set, the agent only observes a newly registered
+project for that long: it reports the plaintext env files it would encrypt and
+leaves them alone. 'approve <project>' starts enforcement there now; without
+a project, the projects still in their grace period are listed with the files
+that would be encrypted.`,
+	Args:         cobra.MaximumNArgs(1),
+	SilenceUsage: true,
+	RunE:         runApprove,
+}
+
+// init registers the approve command with rootCmd.
+func init() {
+	rootCmd.AddCommand(approveCmd)
+}
+
+// runApprove approves args[0], or lists the observed projects.
+func runApprove(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	g, err := guardian.New(cfg.Effective())
+	if err != nil {
+		return err
+	}
+	w := cmd.OutOrStdout()
+	if len(args) == 0 {
+		return listObserved(w, g, cfg.Guardian.GracePeriod)
+	}
+
+	root, err := filepath.Abs(args[0])
+	if err != nil {
+		return err
+	}
+	r, found, err := onboarding.Approve(root, time.Now())
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("%s is not in a grace period (see 'envdrift-agent approve' for the ones that are)", root)
+	}
+	files, err := g.WouldEncrypt(r.Path)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Approved %s: %d plaintext env files will be encrypted once idle.\n", r.Path, len(files))
+	return history.Record(history.Entry{Action: history.ActionApprove, Path: r.Path})
+}
+
+// listObserved prints the projects in their grace period and what the agent
+// would encrypt in each.
+func listObserved(w io.Writer, g *guardian.Guardian, grace time.Duration) error {
+	if grace <= 0 {
+		fmt.Fprintln(w, "Onboarding is off ([guardian] grace_period = 0): new projects are enforced at once.")
+		return nil
+	}
+	roots, err := onboarding.Observing(time.Now())
+	if err != nil {
+		return err
+	}
+	if len(roots) == 0 {
+		fmt.Fprintf(w, "No project is in its grace period (%v for new projects).\n", grace)
+		return nil
+	}
+	for _, r := range roots {
+		files, err := g.WouldEncrypt(r.Path)
+		if err != nil {
+			fmt.Fprintf(w, "%s  (observed until %s): %v\n", r.Path, r.Until.Local().Format("2006-01-02 15:04"), err)
+			continue
+		}
+		fmt.Fprintf(w, "%s  (observed until %s): %d plaintext env files would be encrypted\n",
+			r.Path, r.Until.Local().Format("2006-01-02 15:04"), len(files))
+		for _, f := range files {
+			fmt.Fprintf(w, "  %s\n", f)
+		}
+	}
+	return nil
+}
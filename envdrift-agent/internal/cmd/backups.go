@@ -0,0 +1,179 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jainal09/envdrift-agent/internal/backups"
+	"github.com/jainal09/envdrift-agent/internal/config"
+	"github.com/jainal09/envdrift-agent/internal/history"
+)
+
+var backupsCmd = &cobra.Command{
+	Use:   "backups",
+	Short: "Manage the pre-encryption backup store",
+	Long: `With [backups] enabled = true the agent copies each file into
+the backups store just before encrypting it, keeping the newest
+[backups] keep copies per file for at most max_age. The copies are plaintext.`,
+}
+
+var backupsListCmd = &cobra.Command{
+	Use:   "list [path]",
+	Short: "List backups, newest first",
+	Args:  cobra.MaximumNArgs(1),
+	RunE:  runBackupsList,
+}
+
+var backupsRestoreCmd = &cobra.Command{
+	Use:   "restore <path>",
+	Short: "Write a backup back over its file",
+	Long: `Replaces the file with its newest backup (or the one named by --id, as shown
+by 'backups list'). The restored file is plaintext until the agent encrypts
+it again; the restore is recorded in the history log.`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE:         runBackupsRestore,
+}
+
+var backupsPurgeCmd = &cobra.Command{
+	Use:   "purge [path]",
+	Short: "Remove the backups of one file, or all of them",
+	Long: `Removes backups after confirmation (--yes skips it). With [backups] trash =
+true or --trash they go to the OS trash (Trash, Recycle Bin) instead of being
+deleted.`,
+	Args:         cobra.MaximumNArgs(1),
+	SilenceUsage: true,
+	RunE:         runBackupsPurge,
+}
+
+var (
+	backupsID    string
+	backupsYes   bool
+	backupsTrash bool
+)
+
+// init registers the backups command group with rootCmd.
+func init() {
+	backupsRestoreCmd.Flags().StringVar(&backupsID, "id", "", "backup to restore (default: the newest)")
+	backupsRestoreCmd.Flags().BoolVar(&backupsYes, "yes", false, "skip the confirmation prompt")
+	backupsPurgeCmd.Flags().BoolVar(&backupsYes, "yes", false, "skip the confirmation prompt")
+	backupsPurgeCmd.Flags().BoolVar(&backupsTrash, "trash", false, "move to the OS trash even if [backups] trash is false")
+	backupsCmd.AddCommand(backupsListCmd, backupsRestoreCmd, backupsPurgeCmd)
+	rootCmd.AddCommand(backupsCmd)
+}
+
+// backupStore is the store the commands manage.
+func backupStore() *backups.Store {
+	return &backups.Store{Dir: backups.DefaultDir()}
+}
+
+// runBackupsList prints the backups of one file or of every file.
+func runBackupsList(cmd *cobra.Command, args []string) error {
+	path := ""
+	if len(args) == 1 {
+		path = args[0]
+	}
+	list, err := backupStore().List(path)
+	if err != nil {
+		return err
+	}
+	writeBackups(cmd.OutOrStdout(), list)
+	return nil
+}
+
+// writeBackups renders one line per backup.
+func writeBackups(w io.Writer, list []backups.Backup) {
+	if len(list) == 0 {
+		fmt.Fprintln(w, "No backups")
+		return
+	}
+	for _, b := range list {
+		fmt.Fprintf(w, "%s  %s  %6d B  %s\n", b.ID, b.Time.Local().Format("2006-01-02 15:04:05"), b.Size, b.Path)
+	}
+}
+
+// runBackupsRestore confirms, records the restore in history and writes the
+// backup back.
+func runBackupsRestore(cmd *cobra.Command, args []string) error {
+	path, err := filepath.Abs(args[0])
+	if err != nil {
+		return err
+	}
+	store := backupStore()
+	var b backups.Backup
+	if backupsID != "" {
+		if b, err = store.Get(backupsID); err != nil {
+			return err
+		}
+		if b.Path != path {
+			return fmt.Errorf("backup %s is of %s, not %s", backupsID, b.Path, path)
+		}
+	} else {
+		list, err := store.List(path)
+		if err != nil {
+			return err
+		}
+		if len(list) == 0 {
+			return fmt.Errorf("no backups of %s", path)
+		}
+		b = list[0]
+	}
+
+	w := cmd.OutOrStdout()
+	if !backupsYes {
+		question := fmt.Sprintf("Replace %s with the plaintext backup from %s?", path, b.Time.Local().Format(time.DateTime))
+		if !askYesNo(promptInput(cmd), w, question) {
+			return errors.New("restore cancelled (--yes skips the prompt)")
+		}
+	}
+	if err := history.Record(history.Entry{Action: history.ActionRestore, Path: path, Detail: "backup=" + b.ID}); err != nil {
+		return fmt.Errorf("refusing to restore: could not record access in history: %w", err)
+	}
+	if err := store.Restore(b); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Restored %s from backup %s\n", path, b.ID)
+	return nil
+}
+
+// runBackupsPurge confirms and removes backups, to the trash if configured.
+func runBackupsPurge(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	path, what := "", "all backups"
+	if len(args) == 1 {
+		if path, err = filepath.Abs(args[0]); err != nil {
+			return err
+		}
+		what = "the backups of " + path
+	}
+	store := backupStore()
+	list, err := store.List(path)
+	if err != nil {
+		return err
+	}
+	w := cmd.OutOrStdout()
+	if len(list) == 0 {
+		fmt.Fprintln(w, "No backups")
+		return nil
+	}
+
+	trash := cfg.Backups.Trash || backupsTrash
+	verb := "Delete"
+	if trash {
+		verb = "Move to the trash"
+	}
+	if !backupsYes && !askYesNo(promptInput(cmd), w, fmt.Sprintf("%s %s (%d files)?", verb, what, len(list))) {
+		return errors.New("purge cancelled (--yes skips the prompt)")
+	}
+	removed, err := store.Purge(path, trash)
+	fmt.Fprintf(w, "Removed %d backup(s)\n", len(removed))
+	return err
+}
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jainal09/envdrift-agent/internal/history"
+)
+
+func TestBackupsRestoreAndPurge(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	origTerm := stdinIsTerminal
+	stdinIsTerminal = func() bool { return false }
+	t.Cleanup(func() {
+		stdinIsTerminal = origTerm
+		backupsID, backupsYes, backupsTrash = "", false, false
+	})
+
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(path, []byte("SECRET=plain\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	b, err := backupStore().Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("SECRET=encrypted:x\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	backupsListCmd.SetOut(&out)
+	backupsRestoreCmd.SetOut(&out)
+	backupsPurgeCmd.SetOut(&out)
+	t.Cleanup(func() {
+		backupsListCmd.SetOut(nil)
+		backupsRestoreCmd.SetOut(nil)
+		backupsPurgeCmd.SetOut(nil)
+	})
+
+	if err := runBackupsList(backupsListCmd, []string{path}); err != nil || !strings.Contains(out.String(), b.ID) {
+		t.Fatalf("list = %q, %v", out.String(), err)
+	}
+
+	// Without a terminal or --yes nothing is restored.
+	if err := runBackupsRestore(backupsRestoreCmd, []string{path}); err == nil {
+		t.Fatal("restore without confirmation succeeded")
+	}
+	backupsYes = true
+	if err := runBackupsRestore(backupsRestoreCmd, []string{path}); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "SECRET=plain\n" {
+		t.Errorf("restored %q", data)
+	}
+	entries, err := history.Read()
+	if err != nil || len(entries) != 1 || entries[0].Action != history.ActionRestore {
+		t.Errorf("history = %+v, %v", entries, err)
+	}
+
+	if err := runBackupsPurge(backupsPurgeCmd, nil); err != nil {
+		t.Fatal(err)
+	}
+	if list, _ := backupStore().List(""); len(list) != 0 {
+		t.Errorf("purge left %d backups", len(list))
+	}
+}
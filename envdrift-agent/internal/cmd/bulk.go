@@ -0,0 +1,271 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jainal09/envdrift-agent/internal/config"
+	"github.com/jainal09/envdrift-agent/internal/daemon"
+	"github.com/jainal09/envdrift-agent/internal/encrypt"
+	"github.com/jainal09/envdrift-agent/internal/exports"
+	"github.com/jainal09/envdrift-agent/internal/guardian"
+	"github.com/jainal09/envdrift-agent/internal/history"
+	"github.com/jainal09/envdrift-agent/internal/ui"
+)
+
+var encryptAllCmd = &cobra.Command{
+	Use:   "encrypt-all",
+	Short: "Encrypt every plaintext env file of the watched set now",
+	Long: `Lists the plaintext env files of the registered projects that the filters
+keep, asks for confirmation and encrypts them, --jobs at a time, then prints
+a summary. Unlike run-once it also encrypts files in a project's onboarding
+grace period and files decrypted by edit or decrypt-all, ending their
+session; files open in another process or in a repository git is writing are
+skipped.
+
+  envdrift-agent encrypt-all --root ~/src/api --env production
+  envdrift-agent encrypt-all --exclude '.env.local' --yes
+
+--dry-run only lists the files. Without a terminal, --yes is required.`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runEncryptAll,
+}
+
+var decryptAllCmd = &cobra.Command{
+	Use:   "decrypt-all",
+	Short: "Decrypt the encrypted env files of the watched set for a limited time",
+	Long: `Lists the encrypted env files of the registered projects that the filters
+keep and, once you type the number of files to confirm, decrypts each in
+place with its key from the [keys] resolution chain, for migrations and
+incident response. Like edit, each file is recorded in the history log and
+held in plaintext for --for (default 1h); the running agent then encrypts
+it again. encrypt-all ends the sessions early.
+
+  envdrift-agent decrypt-all --root ~/src/api --env production --for 30m
+
+--dry-run only lists the files. Without a terminal, --yes is required.`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runDecryptAll,
+}
+
+// Flags shared by encrypt-all and decrypt-all, and their own.
+var (
+	bulkRoot    string
+	bulkEnvs    []string
+	bulkInclude []string
+	bulkExclude []string
+	bulkYes     bool
+	bulkDryRun  bool
+	bulkJobs    int
+	bulkFor     time.Duration
+)
+
+// init registers encrypt-all and decrypt-all with rootCmd.
+func init() {
+	for _, c := range []*cobra.Command{encryptAllCmd, decryptAllCmd} {
+		c.Flags().StringVar(&bulkRoot, "root", "", "only files under this directory")
+		c.Flags().StringSliceVar(&bulkEnvs, "env", nil, "only files of these environments, e.g. production (repeatable)")
+		c.Flags().StringSliceVar(&bulkInclude, "include", nil, "only file names matching these patterns (repeatable)")
+		c.Flags().StringSliceVar(&bulkExclude, "exclude", nil, "skip file names matching these patterns (repeatable)")
+		c.Flags().BoolVarP(&bulkYes, "yes", "y", false, "do not ask for confirmation")
+		c.Flags().BoolVar(&bulkDryRun, "dry-run", false, "list the files and exit")
+		rootCmd.AddCommand(c)
+	}
+	encryptAllCmd.Flags().IntVarP(&bulkJobs, "jobs", "j", guardian.DefaultRunOnceWorkers(), "files to encrypt at a time")
+	decryptAllCmd.Flags().DurationVar(&bulkFor, "for", time.Hour, "how long the files stay decrypted")
+}
+
+// bulkFilter builds the filter from the flags.
+func bulkFilter() (guardian.BulkFilter, error) {
+	f := guardian.BulkFilter{Envs: bulkEnvs, Include: bulkInclude, Exclude: bulkExclude}
+	if bulkRoot != "" {
+		root, err := filepath.Abs(bulkRoot)
+		if err != nil {
+			return f, err
+		}
+		f.Root = root
+	}
+	return f, nil
+}
+
+// bulkFiles returns a guardian and the watched files the flags keep whose
+// state want selects.
+func bulkFiles(want func(encrypt.State) bool) (*guardian.Guardian, []guardian.WatchedFile, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, nil, err
+	}
+	g, err := guardian.New(cfg.Effective())
+	if err != nil {
+		return nil, nil, err
+	}
+	filter, err := bulkFilter()
+	if err != nil {
+		return nil, nil, err
+	}
+	files, err := g.WatchedFiles(filter)
+	if err != nil {
+		return nil, nil, err
+	}
+	var selected []guardian.WatchedFile
+	for _, f := range files {
+		if fs, err := encrypt.Classify(f.Path); err == nil && want(fs.State) {
+			selected = append(selected, f)
+		}
+	}
+	return g, selected, nil
+}
+
+// listBulk prints what a bulk operation will do to files.
+func listBulk(w io.Writer, what string, files []guardian.WatchedFile) {
+	fmt.Fprintf(w, "%s %d file(s):\n", what, len(files))
+	for _, f := range files {
+		fmt.Fprintf(w, "  %s\n", f.Path)
+	}
+}
+
+// runEncryptAll encrypts the plaintext files the filters keep.
+func runEncryptAll(cmd *cobra.Command, args []string) error {
+	w := cmd.OutOrStdout()
+	g, files, err := bulkFiles(func(s encrypt.State) bool {
+		return s != encrypt.Encrypted && s != encrypt.Binary
+	})
+	if err != nil {
+		return err
+	}
+	g.Version = Version
+	if len(files) == 0 {
+		fmt.Fprintln(w, "No plaintext env files match.")
+		return nil
+	}
+	listBulk(w, "Encrypt", files)
+	if bulkDryRun {
+		return nil
+	}
+	if !bulkYes {
+		in := promptInput(cmd)
+		if in == nil {
+			return errors.New("no terminal to confirm on: pass --yes")
+		}
+		if !askYesNo(in, w, fmt.Sprintf("Encrypt these %d files?", len(files))) {
+			return errors.New("aborted")
+		}
+	}
+
+	var bar *ui.Bar
+	res, err := g.EncryptAll(context.Background(), files, bulkJobs, func(p guardian.Progress) {
+		if bar == nil {
+			bar = ui.New(w).Bar("Encrypting", p.Total)
+		}
+		bar.Set(p.Done)
+	})
+	if bar != nil {
+		bar.Finish()
+	}
+	if err != nil {
+		return err
+	}
+	for _, e := range res.Failed {
+		fmt.Fprintf(cmd.ErrOrStderr(), "Failed: %v\n", e)
+	}
+	fmt.Fprintf(w, "Encrypted %d, already encrypted %d, skipped %d, failed %d\n",
+		res.Encrypted, res.Already, res.Skipped, len(res.Failed))
+	if len(res.Failed) > 0 {
+		return fmt.Errorf("%d file(s) could not be encrypted", len(res.Failed))
+	}
+	return nil
+}
+
+// bulkDecrypt decrypts one file in place; tests replace it.
+var bulkDecrypt = encrypt.DecryptInPlace
+
+// runDecryptAll decrypts the encrypted files the filters keep for --for.
+func runDecryptAll(cmd *cobra.Command, args []string) error {
+	w := cmd.OutOrStdout()
+	if bulkFor <= 0 {
+		return errors.New("--for must be positive")
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	if err := refuseReadOnly(cfg, "decrypt"); err != nil {
+		return err
+	}
+	_, files, err := bulkFiles(func(s encrypt.State) bool {
+		return s == encrypt.Encrypted || s == encrypt.Partial
+	})
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		fmt.Fprintln(w, "No encrypted env files match.")
+		return nil
+	}
+	listBulk(w, "Decrypt", files)
+	if bulkDryRun {
+		return nil
+	}
+	if !bulkYes {
+		in := promptInput(cmd)
+		if in == nil {
+			return errors.New("no terminal to confirm on: pass --yes")
+		}
+		if !askCount(in, w, len(files)) {
+			return errors.New("aborted")
+		}
+	}
+	resolver, err := loadKeyResolver()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	expires := time.Now().Add(bulkFor)
+	decrypted := 0
+	var failed []error
+	for _, f := range files {
+		keyEnv, err := resolvedKeyEnv(ctx, resolver, f.Path)
+		if err != nil {
+			failed = append(failed, fmt.Errorf("%s: %w", f.Path, err))
+			continue
+		}
+		if err := history.Record(history.Entry{Action: history.ActionDecryptAll, Path: f.Path, Detail: "for=" + bulkFor.String()}); err != nil {
+			return fmt.Errorf("refusing to decrypt: could not record access in history: %w", err)
+		}
+		// Register the session first so the agent never sees the plaintext
+		// file without it.
+		if err := exports.Add(exports.Export{Path: f.Path, Source: f.Path, Expires: expires, Action: exports.ActionEncrypt, Edit: true}); err != nil {
+			return err
+		}
+		if err := bulkDecrypt(ctx, f.Path, keyEnv); err != nil {
+			failed = append(failed, errors.Join(fmt.Errorf("%s: %w", f.Path, err), exports.Remove(f.Path)))
+			continue
+		}
+		decrypted++
+	}
+
+	for _, e := range failed {
+		fmt.Fprintf(cmd.ErrOrStderr(), "Failed: %v\n", e)
+	}
+	fmt.Fprintf(w, "Decrypted %d, failed %d\n", decrypted, len(failed))
+	if decrypted > 0 {
+		if daemon.IsRunning() {
+			fmt.Fprintf(w, "The agent encrypts them again at %s; 'envdrift-agent encrypt-all' does it now.\n", expires.Format(time.Kitchen))
+		} else {
+			fmt.Fprintln(w, "The agent is not running: encrypt them again with 'envdrift-agent encrypt-all'.")
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%d file(s) could not be decrypted", len(failed))
+	}
+	return nil
+}
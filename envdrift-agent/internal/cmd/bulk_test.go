@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jainal09/envdrift-agent/internal/exports"
+	"github.com/jainal09/envdrift-agent/internal/registry"
+)
+
+func TestDecryptAll(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	origTerm, origDecrypt := stdinIsTerminal, bulkDecrypt
+	stdinIsTerminal = func() bool { return false }
+	var decrypted []string
+	bulkDecrypt = func(_ context.Context, path string, _ []string) error {
+		decrypted = append(decrypted, path)
+		return os.WriteFile(path, []byte("SECRET=plaintext\n"), 0o600)
+	}
+	t.Cleanup(func() {
+		stdinIsTerminal, bulkDecrypt = origTerm, origDecrypt
+		bulkYes, bulkEnvs = false, nil
+	})
+
+	proj := t.TempDir()
+	if err := os.WriteFile(filepath.Join(proj, "envdrift.toml"), []byte("[guardian]\nenabled = true\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	prod := filepath.Join(proj, ".env.production")
+	for path, content := range map[string]string{
+		prod:                            "SECRET=\"encrypted:abc\"\n",
+		filepath.Join(proj, ".env.dev"): "SECRET=\"encrypted:def\"\n",
+		filepath.Join(proj, ".env"):     "SECRET=plaintext\n",
+	} {
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	data, err := json.Marshal(registry.Registry{Projects: []registry.ProjectEntry{{Path: proj, Added: "now"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(home, ".envdrift"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(home, ".envdrift", "projects.json"), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	decryptAllCmd.SetOut(&out)
+	t.Cleanup(func() { decryptAllCmd.SetOut(nil) })
+	bulkEnvs = []string{"production"}
+
+	// Without a terminal or --yes nothing is decrypted.
+	if err := runDecryptAll(decryptAllCmd, nil); err == nil || len(decrypted) != 0 {
+		t.Fatalf("decrypt-all without confirmation = %v, decrypted %v", err, decrypted)
+	}
+	bulkYes = true
+	out.Reset()
+	if err := runDecryptAll(decryptAllCmd, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(decrypted) != 1 || decrypted[0] != prod {
+		t.Errorf("decrypted %v; want only %s", decrypted, prod)
+	}
+	if !strings.Contains(out.String(), "Decrypted 1, failed 0") {
+		t.Errorf("output = %q", out.String())
+	}
+	if _, held := exports.Pending(prod, time.Now()); !held {
+		t.Error("a decrypted file must be held by a session until --for ends")
+	}
+}
+
+func TestDecryptAllRefusedInReadOnlyMode(t *testing.T) {
+	t.Setenv("ENVDRIFT_HOME", t.TempDir())
+	t.Setenv("ENVDRIFT_COMPLIANCE_READ_ONLY", "true")
+	origDecrypt := bulkDecrypt
+	bulkDecrypt = func(context.Context, string, []string) error {
+		t.Error("nothing may be decrypted in read-only mode")
+		return nil
+	}
+	t.Cleanup(func() { bulkDecrypt, bulkYes = origDecrypt, false })
+	bulkYes = true
+
+	if err := runDecryptAll(decryptAllCmd, nil); err == nil || !strings.Contains(err.Error(), "read_only") {
+		t.Errorf("decrypt-all in read-only mode = %v", err)
+	}
+}
+
+func TestAskCount(t *testing.T) {
+	for answer, want := range map[string]bool{"3\n": true, " 3 \n": true, "y\n": false, "yes\n": false, "": false} {
+		var out bytes.Buffer
+		if got := askCount(bufio.NewReader(strings.NewReader(answer)), &out, 3); got != want {
+			t.Errorf("askCount(%q) = %v; want %v", answer, got, want)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jainal09/envdrift-agent/internal/compliance"
+	"github.com/jainal09/envdrift-agent/internal/config"
+	"github.com/jainal09/envdrift-agent/internal/guardian"
+)
+
+var complianceCmd = &cobra.Command{
+	Use:   "compliance",
+	Short: "Report plaintext env files and exposed permissions without changing anything",
+	Long: `Scans the env files of the enabled registered projects, as run-once would, and
+reports how many are encrypted, which are in plaintext and for how long, and
+which env or .env.keys files other users can read. Nothing is modified.
+
+With [compliance] read_only = true the running agent never encrypts and
+refreshes this report every [compliance] interval instead; 'status' shows the
+latest one. --check exits non-zero when anything is out of compliance, for CI
+and monitoring scripts; plaintext files less severe than [severity] fail are
+listed but do not fail it.`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runCompliance,
+}
+
+// Flags for compliance.
+var (
+	complianceJSON  bool
+	complianceCheck bool
+)
+
+// errNotCompliant is returned by compliance --check.
+var errNotCompliant = errors.New("not compliant")
+
+// init registers the compliance command with rootCmd.
+func init() {
+	complianceCmd.Flags().BoolVar(&complianceJSON, "json", false, "print the report as JSON")
+	complianceCmd.Flags().BoolVar(&complianceCheck, "check", false, "exit non-zero when any env file is plaintext or exposed")
+	rootCmd.AddCommand(complianceCmd)
+}
+
+// runCompliance scans now and prints the report.
+func runCompliance(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	g, err := guardian.New(cfg.Effective())
+	if err != nil {
+		return err
+	}
+	r, err := g.Compliance(context.Background())
+	if err != nil {
+		return err
+	}
+	w := cmd.OutOrStdout()
+	if complianceJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(r); err != nil {
+			return err
+		}
+	} else {
+		writeCompliance(w, r)
+	}
+	if complianceCheck && !r.CompliantAt(cfg.Severity.FailLevel()) {
+		return errNotCompliant
+	}
+	return nil
+}
+
+// writeCompliance prints the summary, then each plaintext file and
+// permission issue.
+func writeCompliance(w io.Writer, r compliance.Report) {
+	fmt.Fprintf(w, "%d projects: %s\n", r.Projects, r.Summary())
+	if len(r.Plaintext) > 0 {
+		fmt.Fprintln(w, "\nPlaintext:")
+		for _, f := range r.Plaintext {
+			fmt.Fprintf(w, "  %s  (unchanged since %s)\n", f.Path, f.Modified.Local().Format("2006-01-02 15:04"))
+			if f.Severity != "" {
+				fmt.Fprintf(w, "    %s — %s\n", f.Severity, f.Reason)
+			}
+		}
+	}
+	if len(r.Permissions) > 0 {
+		fmt.Fprintln(w, "\nReadable by other users (chmod 600):")
+		for _, p := range r.Permissions {
+			fmt.Fprintf(w, "  %s  %s\n", p.Mode, p.Path)
+		}
+	}
+}
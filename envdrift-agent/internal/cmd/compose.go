@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jainal09/envdrift-agent/internal/dotenv"
+	"github.com/jainal09/envdrift-agent/internal/history"
+)
+
+// composeFiles are the file names Docker Compose looks for, in its order.
+var composeFiles = []string{"compose.yaml", "compose.yml", "docker-compose.yaml", "docker-compose.yml"}
+
+var composeCmd = &cobra.Command{
+	Use:   "compose",
+	Short: "Run Docker Compose with decrypted env files",
+}
+
+var composeRunCmd = &cobra.Command{
+	Use:   "run [--file <path>] -- <command> [args...]",
+	Short: "Run a Docker Compose command with the project's env files decrypted into its environment",
+	Long: `Decrypts the Compose project's env files in memory and runs the command with
+their variables in its environment, so ${VAR} interpolation and
+'environment: [VAR]' pass-through see the plaintext values while .env next to
+the compose file stays encrypted. The project is the nearest directory from the
+current one holding a compose file; its .env is decrypted unless --file names
+other files. Compose is told not to read that .env itself. Variables already
+set in the environment win unless --overload is given. The run is recorded in
+the history log.
+
+Services must take the values through 'environment:' rather than 'env_file:',
+which Compose reads from disk as is.
+
+  envdrift-agent compose run -- docker compose up -d
+  envdrift-agent compose run --file .env.production -- docker compose config`,
+	Args:         cobra.MinimumNArgs(1),
+	SilenceUsage: true,
+	RunE:         runComposeRun,
+}
+
+var (
+	composeEnvFiles   []string
+	composeProjectDir string
+	composeOverload   bool
+)
+
+// init registers the compose commands and their flags with rootCmd.
+func init() {
+	composeRunCmd.Flags().StringArrayVarP(&composeEnvFiles, "file", "f", nil,
+		"env file to decrypt (repeatable; later files override earlier ones; default: the project's .env)")
+	composeRunCmd.Flags().StringVar(&composeProjectDir, "project-directory", "",
+		"the Compose project directory (default: the nearest one holding a compose file)")
+	composeRunCmd.Flags().BoolVar(&composeOverload, "overload", false,
+		"let env file values override variables already set in the environment")
+	composeCmd.AddCommand(composeRunCmd)
+	rootCmd.AddCommand(composeCmd)
+}
+
+// findComposeProject returns the nearest directory from dir upward that
+// holds a compose file, the way Compose finds its project.
+func findComposeProject(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for d := dir; ; {
+		for _, name := range composeFiles {
+			if _, err := os.Stat(filepath.Join(d, name)); err == nil {
+				return d, nil
+			}
+		}
+		parent := filepath.Dir(d)
+		if parent == d {
+			return "", fmt.Errorf("no compose file in %s or any parent directory", dir)
+		}
+		d = parent
+	}
+}
+
+// runComposeRun decrypts the project's env files, records the access, and
+// runs args with the merged environment, exiting with the child's exit
+// status.
+func runComposeRun(cmd *cobra.Command, args []string) error {
+	project := composeProjectDir
+	if project == "" {
+		found, err := findComposeProject(".")
+		if err != nil {
+			return err
+		}
+		project = found
+	}
+	project, err := filepath.Abs(project)
+	if err != nil {
+		return err
+	}
+	dotEnv := filepath.Join(project, ".env")
+	files := composeEnvFiles
+	if len(files) == 0 {
+		files = []string{dotEnv}
+	}
+
+	entries, paths, err := decryptFiles(context.Background(), files)
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		if err := history.Record(history.Entry{
+			Action: history.ActionExec,
+			Path:   path,
+			Detail: filepath.Base(args[0]),
+		}); err != nil {
+			return fmt.Errorf("refusing to run: could not record access in history: %w", err)
+		}
+	}
+
+	code, err := runChild(args, composeEnv(os.Environ(), entries, paths, dotEnv, composeOverload))
+	if err != nil {
+		return err
+	}
+	if code != 0 {
+		osExit(code)
+	}
+	return nil
+}
+
+// composeEnv is the child environment: base merged with entries and, when
+// the project's .env was among the decrypted paths, COMPOSE_DISABLE_ENV_FILE
+// so Compose does not read its ciphertext over the decrypted values.
+func composeEnv(base []string, entries []dotenv.Entry, paths []string, dotEnv string, overload bool) []string {
+	env := mergeEnv(base, entries, overload)
+	for _, path := range paths {
+		if path == dotEnv {
+			return mergeEnv(env, []dotenv.Entry{{Key: "COMPOSE_DISABLE_ENV_FILE", Value: "true"}}, true)
+		}
+	}
+	return env
+}
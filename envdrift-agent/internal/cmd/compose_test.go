@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/jainal09/envdrift-agent/internal/dotenv"
+)
+
+func TestFindComposeProject(t *testing.T) {
+	root := t.TempDir()
+	sub := filepath.Join(root, "services", "api")
+	if err := os.MkdirAll(sub, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := findComposeProject(sub); err == nil {
+		t.Error("findComposeProject without a compose file must fail")
+	}
+	if err := os.WriteFile(filepath.Join(root, "docker-compose.yml"), []byte("services: {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := findComposeProject(sub); err != nil || got != root {
+		t.Errorf("findComposeProject(%s) = %q, %v; want %q", sub, got, err, root)
+	}
+}
+
+// TestComposeEnv pins that Compose is told to skip the project's .env only
+// when that file was decrypted into the environment.
+func TestComposeEnv(t *testing.T) {
+	entries := []dotenv.Entry{{Key: "DB_PASSWORD", Value: "s3cret"}}
+	dotEnv := filepath.Join("/src/app", ".env")
+
+	got := composeEnv([]string{"PATH=/bin"}, entries, []string{dotEnv}, dotEnv, false)
+	want := []string{"PATH=/bin", "DB_PASSWORD=s3cret", "COMPOSE_DISABLE_ENV_FILE=true"}
+	if !slices.Equal(got, want) {
+		t.Errorf("composeEnv with the project .env = %v; want %v", got, want)
+	}
+
+	got = composeEnv([]string{"PATH=/bin"}, entries, []string{filepath.Join("/src/app", ".env.production")}, dotEnv, false)
+	if want := []string{"PATH=/bin", "DB_PASSWORD=s3cret"}; !slices.Equal(got, want) {
+		t.Errorf("composeEnv with another file = %v; want %v", got, want)
+	}
+}
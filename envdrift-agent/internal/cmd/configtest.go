@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jainal09/envdrift-agent/internal/config"
+	"github.com/jainal09/envdrift-agent/internal/guardian"
+	"github.com/jainal09/envdrift-agent/internal/ui"
+)
+
+var configTestCmd = &cobra.Command{
+	Use:   "test [candidate.toml]",
+	Short: "Show which sample paths a config would watch and encrypt",
+	Long: `Evaluates a candidate guardian.toml (the current one when omitted) against
+sample paths, one per line, read from --sample-paths or stdin, and prints
+whether each would be watched and encrypted and which check decides. With a
+candidate, the current config's verdict is shown next to it, so a policy
+change can be validated before rollout:
+
+  envdrift-agent config test new-guardian.toml --sample-paths paths.txt
+  find ~/src -name '.env*' | envdrift-agent config test new-guardian.toml
+
+Paths are judged by name and configuration only; they need not exist. A path
+outside the registered projects is judged by the [guardian] defaults, as if
+its project had opted in.`,
+	Args:         cobra.MaximumNArgs(1),
+	SilenceUsage: true,
+	RunE:         runConfigTest,
+}
+
+// configTestSamples is the --sample-paths flag.
+var configTestSamples string
+
+// init registers config test under configCmd.
+func init() {
+	configTestCmd.Flags().StringVar(&configTestSamples, "sample-paths", "",
+		"file listing one path per line (default: stdin)")
+	configCmd.AddCommand(configTestCmd)
+}
+
+// runConfigTest simulates the candidate (and, with one, the current) config
+// for every sample path.
+func runConfigTest(cmd *cobra.Command, args []string) error {
+	current, err := config.Load()
+	if err != nil {
+		return err
+	}
+	candidate := current
+	if len(args) == 1 {
+		if candidate, err = config.LoadFile(args[0]); err != nil {
+			return err
+		}
+	}
+	paths, err := readSamplePaths(cmd.InOrStdin())
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		return fmt.Errorf("no sample paths given")
+	}
+
+	g, err := guardian.New(candidate.Effective())
+	if err != nil {
+		return err
+	}
+	var now *guardian.Guardian
+	if len(args) == 1 {
+		if now, err = guardian.New(current.Effective()); err != nil {
+			return err
+		}
+	}
+
+	header := []string{"PATH", "VERDICT", "REASON"}
+	if now != nil {
+		header = append(header, "CURRENT")
+	}
+	rows := make([][]string, 0, len(paths))
+	watched, changed := 0, 0
+	for _, p := range paths {
+		ex, err := g.Simulate(p)
+		if err != nil {
+			return err
+		}
+		row := []string{p, ex.Verdict, deciding(ex)}
+		if strings.HasPrefix(ex.Verdict, "watched") {
+			watched++
+		}
+		if now != nil {
+			was, err := now.Simulate(p)
+			if err != nil {
+				return err
+			}
+			if was.Verdict == ex.Verdict {
+				row = append(row, "same")
+			} else {
+				row = append(row, was.Verdict)
+				changed++
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	w := cmd.OutOrStdout()
+	ui.New(w).Table(header, rows)
+	fmt.Fprintf(w, "\n%d of %d paths watched", watched, len(paths))
+	if now != nil {
+		fmt.Fprintf(w, "; %d changed from the current config", changed)
+	}
+	fmt.Fprintln(w)
+	return nil
+}
+
+// readSamplePaths reads the non-empty lines of --sample-paths, or of stdin
+// when it is unset or "-", as absolute paths.
+func readSamplePaths(stdin io.Reader) ([]string, error) {
+	r := stdin
+	if configTestSamples != "" && configTestSamples != "-" {
+		f, err := os.Open(configTestSamples)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+	var paths []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		abs, err := filepath.Abs(line)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, abs)
+	}
+	return paths, scanner.Err()
+}
+
+// deciding returns the result of the check that blocked the file or, for a
+// watched file, of the pattern that selected it.
+func deciding(ex guardian.Explanation) string {
+	for _, c := range ex.Checks {
+		if c.Blocks || (c.Name == "pattern" && !strings.HasPrefix(ex.Verdict, "not")) {
+			return c.Result
+		}
+	}
+	return ex.Checks[len(ex.Checks)-1].Result
+}
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConfigTestComparesCandidate(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	candidate := filepath.Join(t.TempDir(), "candidate.toml")
+	toml := "[guardian]\nexclude = [\".env.example\", \".env.local\"]\n"
+	if err := os.WriteFile(candidate, []byte(toml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	samples := strings.Join([]string{
+		"/src/api/.env",
+		"/src/api/.env.local",
+		"# comments and blank lines are skipped",
+		"",
+		"/src/api/README.md",
+	}, "\n")
+
+	var out bytes.Buffer
+	configTestCmd.SetIn(strings.NewReader(samples))
+	configTestCmd.SetOut(&out)
+	t.Cleanup(func() {
+		configTestCmd.SetIn(nil)
+		configTestCmd.SetOut(nil)
+	})
+
+	if err := runConfigTest(configTestCmd, []string{candidate}); err != nil {
+		t.Fatal(err)
+	}
+	got := out.String()
+	for _, want := range []string{
+		`matches exclude ".env.local", which wins over ".env*"`,
+		"watched; encrypted once idle for 5m0s",
+		`/src/api/.env        watched; encrypted once idle for 5m0s  matches ".env*"`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output missing %q:\n%s", want, got)
+		}
+	}
+	if !strings.Contains(got, "1 of 3 paths watched; 1 changed from the current config") {
+		t.Errorf("summary:\n%s", got)
+	}
+}
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jainal09/envdrift-agent/internal/dotenv"
+	"github.com/jainal09/envdrift-agent/internal/importer"
+)
+
+var createCmd = &cobra.Command{
+	Use:   "create <path> --from <values-file>",
+	Short: "Create an encrypted env file from JSON or YAML config",
+	Long: `Converts structured config into the env file at path and encrypts it with
+dotenvx at once, which writes the DOTENV_PRIVATE_KEY_<ENV> to the folder's
+.env.keys (an existing key there is reused). The plaintext is on disk, mode
+0600, only until dotenvx has encrypted it, and is removed if that fails.
+
+Nested keys are joined with "_" (db: {host: h} becomes db_host); numbers and
+booleans keep their text and null becomes an empty value. Lists are not
+supported. The format follows the --from file's extension (.json, .yaml,
+.yml, otherwise dotenv) unless --format is given. The --from file is left in
+place.
+
+  envdrift-agent create .env.production --from values.yaml`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE:         runCreate,
+}
+
+var (
+	createFrom   string
+	createFormat string
+	createForce  bool
+)
+
+// init registers the create command and its flags with rootCmd.
+func init() {
+	createCmd.Flags().StringVar(&createFrom, "from", "", "JSON, YAML or dotenv file holding the values (required)")
+	createCmd.Flags().StringVar(&createFormat, "format", "",
+		fmt.Sprintf("format of --from: %s, %s or %s (default: from its extension)", dotenv.FormatJSON, dotenv.FormatYAML, dotenv.FormatDotenv))
+	createCmd.Flags().BoolVar(&createForce, "force", false, "overwrite an existing env file")
+	_ = createCmd.MarkFlagRequired("from")
+	rootCmd.AddCommand(createCmd)
+}
+
+// runCreate converts and encrypts the values and reports the new file.
+func runCreate(cmd *cobra.Command, args []string) error {
+	target, err := filepath.Abs(args[0])
+	if err != nil {
+		return err
+	}
+	source, err := filepath.Abs(createFrom)
+	if err != nil {
+		return err
+	}
+	format := createFormat
+	if format == "" {
+		format = dotenv.FormatOf(source)
+	}
+	res, err := importer.FromValues(context.Background(), source, target, format, importer.Options{Force: createForce})
+	if err != nil {
+		return err
+	}
+	w := cmd.OutOrStdout()
+	fmt.Fprintf(w, "Created %s (%d variables, key %s)\n", target, res.Vars, res.KeyName)
+	fmt.Fprintf(w, "%s still holds the values in plaintext; delete it once verified\n", source)
+	return nil
+}
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jainal09/envdrift-agent/internal/journal"
+)
+
+var debugCmd = &cobra.Command{
+	Use:   "debug",
+	Short: "Troubleshoot the guardian",
+}
+
+var debugReplayCmd = &cobra.Command{
+	Use:   "replay [file]",
+	Short: "Replay the guardian's journal to explain what it did with each file",
+	Long: `Re-runs the watcher events and idle-check decisions recorded in
+the journal through the guardian's idle rule and prints each one
+with what the guardian knew at the time: when a file was due, how long it had
+been idle, why a check deferred it. It ends with the files the journal leaves
+in plaintext and the reason.
+
+  envdrift-agent debug replay ~/src/api/.env --since 24h
+
+Recording is on unless guardian.journal is false.`,
+	Args:         cobra.MaximumNArgs(1),
+	SilenceUsage: true,
+	RunE:         runDebugReplay,
+}
+
+// debugReplaySince is the --since flag.
+var debugReplaySince time.Duration
+
+// init registers the debug command group with rootCmd.
+func init() {
+	debugReplayCmd.Flags().DurationVar(&debugReplaySince, "since", 0, "only replay events newer than this (e.g. 24h)")
+	debugCmd.AddCommand(debugReplayCmd)
+	rootCmd.AddCommand(debugCmd)
+}
+
+// runDebugReplay replays the journal, limited to one file when given.
+func runDebugReplay(cmd *cobra.Command, args []string) error {
+	var path string
+	if len(args) == 1 {
+		abs, err := filepath.Abs(args[0])
+		if err != nil {
+			return err
+		}
+		path = abs
+	}
+	events, err := journal.Read()
+	if err != nil {
+		return err
+	}
+	if debugReplaySince > 0 {
+		// Older watch events still carry the idle timeouts in effect.
+		cutoff := time.Now().Add(-debugReplaySince)
+		recent := events[:0]
+		for _, e := range events {
+			if e.Kind == journal.KindWatch || !e.Time.Before(cutoff) {
+				recent = append(recent, e)
+			}
+		}
+		events = recent
+	}
+	if len(events) == 0 {
+		fmt.Fprintf(cmd.OutOrStdout(), "The journal (%s) is empty.\n", journal.Path())
+		return nil
+	}
+	journal.Replay(cmd.OutOrStdout(), events, path)
+	return nil
+}
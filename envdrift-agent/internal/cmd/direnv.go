@@ -0,0 +1,249 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jainal09/envdrift-agent/internal/dotenv"
+	"github.com/jainal09/envdrift-agent/internal/history"
+	"github.com/jainal09/envdrift-agent/internal/output"
+)
+
+// direnvMarker identifies the extension direnv setup wrote, so it never
+// replaces a file it did not.
+const direnvMarker = "# envdrift-agent direnv extension"
+
+var direnvCmd = &cobra.Command{
+	Use:   "direnv",
+	Short: "Load decrypted env files into the shell with direnv",
+	Long: `Integrates with direnv (https://direnv.net): entering a project directory
+decrypts its env files in memory into the shell's environment, and leaving it
+unloads them again. No plaintext is written to disk; every load is recorded in
+the history log.`,
+}
+
+var direnvSetupCmd = &cobra.Command{
+	Use:   "setup [dir]",
+	Short: "Install the direnv extension and add 'use envdrift' to a project's .envrc",
+	Long: `Writes the 'use envdrift' extension to direnv's lib directory and adds a
+'use envdrift' line for the --file env files to the .envrc of the directory
+(default: the current one). Run 'direnv allow' afterwards. With --print the
+extension and the line are printed as a self-contained .envrc snippet instead.
+
+  envdrift-agent direnv setup
+  envdrift-agent direnv setup --file .env --file .env.local
+  envdrift-agent direnv setup --print >> .envrc`,
+	Args:         cobra.MaximumNArgs(1),
+	SilenceUsage: true,
+	RunE:         runDirenvSetup,
+}
+
+var direnvExportCmd = &cobra.Command{
+	Use:   "export --file <path>",
+	Short: "Print decrypted env file values as export statements for direnv",
+	Long: `Decrypts the env files in memory and prints them as shell export statements.
+The 'use envdrift' extension evaluates the output; it is rarely run by hand.
+Variables already set in the environment win unless --overload is given, as
+with exec.`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runDirenvExport,
+}
+
+var (
+	direnvFiles    []string
+	direnvPrint    bool
+	direnvOverload bool
+)
+
+// init registers the direnv commands and their flags with rootCmd.
+func init() {
+	direnvSetupCmd.Flags().StringArrayVarP(&direnvFiles, "file", "f", []string{".env"},
+		"env file to load, relative to the directory (repeatable)")
+	direnvSetupCmd.Flags().BoolVar(&direnvPrint, "print", false,
+		"print a self-contained .envrc snippet instead of writing files")
+	direnvExportCmd.Flags().StringArrayVarP(&direnvFiles, "file", "f", []string{".env"},
+		"env file to decrypt (repeatable; later files override earlier ones)")
+	direnvExportCmd.Flags().BoolVar(&direnvOverload, "overload", false,
+		"let env file values override variables already set in the environment")
+	direnvCmd.AddCommand(direnvSetupCmd, direnvExportCmd)
+	rootCmd.AddCommand(direnvCmd)
+}
+
+// direnvLibDir returns the directory direnv sources extensions from:
+// $DIRENV_CONFIG/lib, else direnv/lib under the XDG config directory, which
+// direnv uses on macOS too.
+func direnvLibDir() (string, error) {
+	if dir := os.Getenv("DIRENV_CONFIG"); dir != "" {
+		return filepath.Join(dir, "lib"), nil
+	}
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "direnv", "lib"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config", "direnv", "lib"), nil
+}
+
+// direnvExtension is the extension: `use envdrift [--overload] [file...]`
+// watches the files (default .env), so direnv reloads when they change, and
+// evaluates their decrypted exports. direnv itself unloads the variables on
+// leaving the directory.
+func direnvExtension(exe string) string {
+	exe = strings.ReplaceAll(filepath.ToSlash(exe), "'", `'\''`)
+	return fmt.Sprintf(`%s: 'use envdrift [file...]' in an .envrc
+# decrypts the env files (default .env) in memory into the environment.
+use_envdrift() {
+  local arg exports
+  local -a args=() files=()
+  for arg in "$@"; do
+    case "$arg" in
+      -*) args+=("$arg") ;;
+      *) files+=("$arg") ;;
+    esac
+  done
+  [[ ${#files[@]} -gt 0 ]] || files=(.env)
+  for arg in "${files[@]}"; do
+    watch_file "$arg"
+    args+=(--file "$arg")
+  done
+  exports=$('%s' direnv export "${args[@]}") || return
+  eval "$exports"
+}
+`, direnvMarker, exe)
+}
+
+// envrcLine is the .envrc line loading files; the default .env needs no
+// argument.
+func envrcLine(files []string) string {
+	line := "use envdrift"
+	if len(files) == 1 && files[0] == ".env" {
+		return line
+	}
+	for _, f := range files {
+		if strings.ContainsAny(f, " \t\n'\"$`\\;&|<>*?()[]{}#~!") {
+			f = shellQuote(f)
+		}
+		line += " " + f
+	}
+	return line
+}
+
+// usesEnvdrift reports whether an .envrc already has a 'use envdrift' line.
+func usesEnvdrift(envrc string) bool {
+	for _, line := range strings.Split(envrc, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && fields[0] == "use" && fields[1] == "envdrift" {
+			return true
+		}
+	}
+	return false
+}
+
+// runDirenvSetup installs the extension, refusing to replace a file it did
+// not write, and adds the 'use envdrift' line to the directory's .envrc
+// unless one is there already.
+func runDirenvSetup(cmd *cobra.Command, args []string) error {
+	dir := "."
+	if len(args) == 1 {
+		dir = args[0]
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	w := cmd.OutOrStdout()
+	if direnvPrint {
+		fmt.Fprintf(w, "%s%s\n", direnvExtension(exe), envrcLine(direnvFiles))
+		return nil
+	}
+
+	libDir, err := direnvLibDir()
+	if err != nil {
+		return err
+	}
+	lib := filepath.Join(libDir, "envdrift.sh")
+	if data, err := os.ReadFile(lib); err == nil && !strings.Contains(string(data), direnvMarker) {
+		return fmt.Errorf("%s already exists and was not written by envdrift-agent", lib)
+	}
+	if err := os.MkdirAll(libDir, 0o755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(lib, []byte(direnvExtension(exe)), 0o644); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Installed %s\n", lib)
+
+	envrc := filepath.Join(dir, ".envrc")
+	data, err := os.ReadFile(envrc)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if usesEnvdrift(string(data)) {
+		fmt.Fprintf(w, "%s already uses envdrift\n", envrc)
+	} else {
+		content := string(data)
+		if content != "" && !strings.HasSuffix(content, "\n") {
+			content += "\n"
+		}
+		content += envrcLine(direnvFiles) + "\n"
+		if err := os.WriteFile(envrc, []byte(content), 0o644); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "Added '%s' to %s\n", envrcLine(direnvFiles), envrc)
+	}
+	fmt.Fprintf(w, "Run 'direnv allow %s' to load it\n", dir)
+	return nil
+}
+
+// runDirenvExport decrypts every --file, records the access, and prints the
+// export statements. Like reveal, it fails closed when the access cannot be
+// recorded.
+func runDirenvExport(cmd *cobra.Command, _ []string) error {
+	entries, paths, err := decryptFiles(context.Background(), direnvFiles)
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		if err := history.Record(history.Entry{Action: history.ActionDirenv, Path: path}); err != nil {
+			return fmt.Errorf("refusing to load: could not record access in history: %w", err)
+		}
+	}
+
+	// Values are data: plain output mode must not strip them.
+	return writeReveal(output.Raw(cmd.OutOrStdout()), cmd.ErrOrStderr(), direnvExports(entries, os.LookupEnv, direnvOverload), false, "shell")
+}
+
+// direnvExports returns the entries to export, one per key with the last
+// file's value, leaving out names a shell cannot export and keys lookup finds
+// set unless overload is given.
+func direnvExports(entries []dotenv.Entry, lookup func(string) (string, bool), overload bool) []dotenv.Entry {
+	last := make(map[string]int, len(entries))
+	for i, e := range entries {
+		last[e.Key] = i
+	}
+	var out []dotenv.Entry
+	for i, e := range entries {
+		if last[e.Key] != i || !shellName.MatchString(e.Key) {
+			continue
+		}
+		if _, set := lookup(e.Key); set && !overload {
+			continue
+		}
+		out = append(out, e)
+	}
+	return out
+}
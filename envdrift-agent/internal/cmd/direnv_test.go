@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jainal09/envdrift-agent/internal/dotenv"
+)
+
+func TestDirenvSetup(t *testing.T) {
+	config := t.TempDir()
+	t.Setenv("DIRENV_CONFIG", config)
+	dir := t.TempDir()
+	envrc := filepath.Join(dir, ".envrc")
+	if err := os.WriteFile(envrc, []byte("export FOO=bar"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	direnvSetupCmd.SetOut(&out)
+	t.Cleanup(func() {
+		direnvSetupCmd.SetOut(nil)
+		direnvFiles = []string{".env"}
+	})
+	direnvFiles = []string{".env", ".env.local"}
+
+	for i := 0; i < 2; i++ {
+		if err := runDirenvSetup(direnvSetupCmd, []string{dir}); err != nil {
+			t.Fatalf("setup %d: %v", i+1, err)
+		}
+	}
+	data, err := os.ReadFile(filepath.Join(config, "lib", "envdrift.sh"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "use_envdrift()") || !strings.Contains(string(data), " direnv export ") {
+		t.Errorf("extension = %q", data)
+	}
+	data, err = os.ReadFile(envrc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "export FOO=bar\nuse envdrift .env .env.local\n"; string(data) != want {
+		t.Errorf(".envrc after two setups = %q; want %q", data, want)
+	}
+
+	if err := os.WriteFile(filepath.Join(config, "lib", "envdrift.sh"), []byte("# mine\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := runDirenvSetup(direnvSetupCmd, []string{dir}); err == nil {
+		t.Error("setup must not replace an extension it did not write")
+	}
+}
+
+func TestEnvrcLine(t *testing.T) {
+	tests := []struct {
+		files []string
+		want  string
+	}{
+		{[]string{".env"}, "use envdrift"},
+		{[]string{".env.production"}, "use envdrift .env.production"},
+		{[]string{"my env", ".env"}, "use envdrift 'my env' .env"},
+	}
+	for _, tt := range tests {
+		if got := envrcLine(tt.files); got != tt.want {
+			t.Errorf("envrcLine(%q) = %q; want %q", tt.files, got, tt.want)
+		}
+	}
+}
+
+// TestDirenvExports pins that later files win and variables already set in
+// the environment are kept unless overloaded, and names a shell cannot
+// export are left out.
+func TestDirenvExports(t *testing.T) {
+	entries := []dotenv.Entry{{Key: "A", Value: "1"}, {Key: "HOME", Value: "/x"}, {Key: "A", Value: "2"}, {Key: "a.b", Value: "3"}}
+	lookup := func(k string) (string, bool) { return "", k == "HOME" }
+
+	got := direnvExports(entries, lookup, false)
+	if len(got) != 1 || got[0] != (dotenv.Entry{Key: "A", Value: "2"}) {
+		t.Errorf("direnvExports = %+v; want only A=2", got)
+	}
+	if got := direnvExports(entries, lookup, true); len(got) != 2 {
+		t.Errorf("direnvExports with overload = %+v; want HOME and A", got)
+	}
+}
@@ -0,0 +1,203 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jainal09/envdrift-agent/internal/config"
+	"github.com/jainal09/envdrift-agent/internal/daemon"
+	"github.com/jainal09/envdrift-agent/internal/encrypt"
+	"github.com/jainal09/envdrift-agent/internal/exports"
+	"github.com/jainal09/envdrift-agent/internal/history"
+)
+
+var editCmd = &cobra.Command{
+	Use:   "edit <path>",
+	Short: "Decrypt an env file in place for a limited time",
+	Long: `Decrypts an encrypted env file in place and opens it in an editor ([edit]
+editor, $VISUAL, $EDITOR, or the platform default); the file is re-encrypted
+when the editor exits. GUI editors need their wait flag, e.g.
+EDITOR="code --wait".
+
+With --no-open (or [edit] auto_open = false) the file is left decrypted and
+the running agent leaves it alone for --for (default 15m), then re-encrypts
+it — or sooner, as soon as an editor it saw holding the file open closes it.
+--done re-encrypts right away.
+
+Every edit is recorded in the history log.
+
+  envdrift-agent edit .env.production --for 15m
+  envdrift-agent edit .env.production --done`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE:         runEdit,
+}
+
+var (
+	editFor    time.Duration
+	editDone   bool
+	editNoOpen bool
+)
+
+// init registers the edit command and its flags with rootCmd.
+func init() {
+	editCmd.Flags().DurationVar(&editFor, "for", 15*time.Minute, "how long the file stays decrypted")
+	editCmd.Flags().BoolVar(&editDone, "done", false, "re-encrypt the file now and end the edit")
+	editCmd.Flags().BoolVar(&editNoOpen, "no-open", false, "do not open an editor; rely on the --for timer")
+	rootCmd.AddCommand(editCmd)
+}
+
+// editEncrypt re-encrypts a finished edit; tests replace it.
+var editEncrypt = encrypt.EncryptSilentContext
+
+// reencrypt returns how a finished edit is re-encrypted: with [guardian]
+// incremental on, the values the edit left alone keep their ciphertext, and
+// with normalize_output on the file is laid out in its normalized form.
+func reencrypt(gc config.GuardianConfig) func(context.Context, string) error {
+	return func(ctx context.Context, path string) error {
+		var err error
+		if gc.Incremental {
+			_, err = encrypt.EncryptIncremental(ctx, path, editEncrypt)
+		} else {
+			err = editEncrypt(ctx, path)
+		}
+		if err == nil && gc.NormalizeOutput {
+			// Best effort: the file is encrypted either way.
+			_, _ = encrypt.Normalize(path)
+		}
+		return err
+	}
+}
+
+// runEdit starts (or, with --done, ends) an edit session.
+func runEdit(cmd *cobra.Command, args []string) error {
+	path, err := filepath.Abs(args[0])
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	w := cmd.OutOrStdout()
+
+	if editDone {
+		// A broken guardian.toml must not keep the file decrypted.
+		var gc config.GuardianConfig
+		if cfg, err := config.Load(); err == nil {
+			gc = cfg.Guardian
+		}
+		_, found, err := exports.Finish(ctx, path, reencrypt(gc))
+		if err != nil {
+			return err
+		}
+		if !found {
+			return fmt.Errorf("%s is not being edited", path)
+		}
+		fmt.Fprintf(w, "Re-encrypted %s\n", path)
+		return nil
+	}
+
+	if editFor <= 0 {
+		return errors.New("--for must be positive")
+	}
+	encrypted, err := encrypt.IsEncrypted(path)
+	if err != nil {
+		return err
+	}
+	if !encrypted {
+		return fmt.Errorf("%s is not encrypted", path)
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	if err := refuseReadOnly(cfg, "decrypt"); err != nil {
+		return err
+	}
+	var editor []string
+	if cfg.Edit.AutoOpen && !editNoOpen {
+		if editor, err = editorCommand(cfg.Edit.Editor, path); err != nil {
+			return fmt.Errorf("%w (or use --no-open)", err)
+		}
+	}
+	resolver, err := loadKeyResolver()
+	if err != nil {
+		return err
+	}
+	keyEnv, err := resolvedKeyEnv(ctx, resolver, path)
+	if err != nil {
+		return err
+	}
+
+	if err := history.Record(history.Entry{Action: history.ActionEdit, Path: path, Detail: "for=" + editFor.String()}); err != nil {
+		return fmt.Errorf("refusing to decrypt: could not record access in history: %w", err)
+	}
+	// Register the session before decrypting so the agent never sees the
+	// plaintext file without it.
+	expires := time.Now().Add(editFor)
+	if err := exports.Add(exports.Export{Path: path, Source: path, Expires: expires, Action: exports.ActionEncrypt, Edit: true}); err != nil {
+		return err
+	}
+	ciphertext, err := os.ReadFile(path)
+	if err != nil {
+		return errors.Join(err, exports.Remove(path))
+	}
+	if err := encrypt.DecryptInPlace(ctx, path, keyEnv); err != nil {
+		return errors.Join(err, exports.Remove(path))
+	}
+	if cfg.Guardian.Incremental {
+		// Best effort: without it every value gets new ciphertext.
+		if plain, err := os.ReadFile(path); err == nil {
+			_ = encrypt.RememberValues(path, ciphertext, plain)
+		}
+	}
+
+	finish := reencrypt(cfg.Guardian)
+	if editor != nil {
+		return editInEditor(ctx, w, path, editor, finish)
+	}
+
+	fmt.Fprintf(w, "Decrypted %s until %s\n", path, expires.Format(time.Kitchen))
+	if daemon.IsRunning() {
+		fmt.Fprintln(w, "The agent re-encrypts it then, or when your editor closes it.")
+	} else {
+		fmt.Fprintf(w, "The agent is not running: re-encrypt with 'envdrift-agent edit %s --done'.\n", args[0])
+	}
+	return nil
+}
+
+// editInEditor opens the decrypted file and re-encrypts it when the editor
+// exits, whatever its exit status. If the editor cannot be started the
+// session's timer stays in charge.
+func editInEditor(ctx context.Context, w io.Writer, path string, editor []string, finish func(context.Context, string) error) error {
+	fmt.Fprintf(w, "Opening %s in %s; it is re-encrypted when the editor exits.\n", path, editor[0])
+	err := runEditor(editor)
+	var execErr *exec.Error
+	if errors.As(err, &execErr) {
+		return fmt.Errorf("start editor: %w; %s stays decrypted until the --for timer ends or 'edit --done'", err, path)
+	}
+	if _, _, encErr := exports.Finish(ctx, path, finish); encErr != nil {
+		return errors.Join(encErr, fmt.Errorf("%s is still decrypted; the agent retries when the --for timer ends", path))
+	}
+	fmt.Fprintf(w, "Re-encrypted %s\n", path)
+	if err != nil {
+		return fmt.Errorf("editor: %w", err)
+	}
+	return nil
+}
+
+// refuseReadOnly returns an error, naming what was refused, when
+// [compliance] read_only is on: the agent then ends no session, so a file
+// decrypted or exported for a limited time would stay in plaintext.
+func refuseReadOnly(cfg *config.Config, what string) error {
+	if cfg.Compliance.ReadOnly {
+		return fmt.Errorf("refusing to %s: in read-only compliance mode ([compliance] read_only) the agent never re-encrypts or removes the plaintext", what)
+	}
+	return nil
+}
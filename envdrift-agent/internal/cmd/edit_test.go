@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jainal09/envdrift-agent/internal/exports"
+)
+
+func TestEditRejectsPlaintextFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(path, []byte("A=1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := runEdit(editCmd, []string{path}); err == nil || !strings.Contains(err.Error(), "not encrypted") {
+		t.Errorf("expected a not-encrypted error, got %v", err)
+	}
+}
+
+func TestEditDoneReencrypts(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(path, []byte("A=1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := exports.Add(exports.Export{Path: path, Expires: time.Now().Add(time.Hour), Action: exports.ActionEncrypt, Edit: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	var encrypted []string
+	origEncrypt, origDone := editEncrypt, editDone
+	editEncrypt = func(_ context.Context, p string) error {
+		encrypted = append(encrypted, p)
+		return nil
+	}
+	editDone = true
+	t.Cleanup(func() { editEncrypt, editDone = origEncrypt, origDone })
+
+	var out bytes.Buffer
+	editCmd.SetOut(&out)
+	t.Cleanup(func() { editCmd.SetOut(nil) })
+	if err := runEdit(editCmd, []string{path}); err != nil {
+		t.Fatal(err)
+	}
+	if len(encrypted) != 1 || encrypted[0] != path {
+		t.Errorf("encrypted = %v", encrypted)
+	}
+	if err := runEdit(editCmd, []string{path}); err == nil || !strings.Contains(err.Error(), "not being edited") {
+		t.Errorf("second --done: got %v", err)
+	}
+}
+
+func TestEditRefusedInReadOnlyMode(t *testing.T) {
+	t.Setenv("ENVDRIFT_HOME", t.TempDir())
+	t.Setenv("ENVDRIFT_COMPLIANCE_READ_ONLY", "true")
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(path, []byte("SECRET=\"encrypted:abc\"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := runEdit(editCmd, []string{path}); err == nil || !strings.Contains(err.Error(), "read_only") {
+		t.Errorf("edit in read-only mode = %v", err)
+	}
+	if _, held := exports.Pending(path, time.Now()); held {
+		t.Error("a refused edit must not start a session")
+	}
+}
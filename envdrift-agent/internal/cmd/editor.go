@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// lookPath finds the fallback editors; tests replace it.
+var lookPath = exec.LookPath
+
+// editorCommand returns the argv that opens path and returns only when the
+// editor exits: the [edit] editor setting, then $VISUAL, then $EDITOR, then
+// the platform default. Settings are split on whitespace, so GUI editors need
+// their wait flag ("code --wait", "subl -w") — otherwise the file is
+// re-encrypted as soon as the launcher returns.
+func editorCommand(configured, path string) ([]string, error) {
+	for _, v := range []string{configured, os.Getenv("VISUAL"), os.Getenv("EDITOR")} {
+		if fields := strings.Fields(v); len(fields) > 0 {
+			return append(fields, path), nil
+		}
+	}
+
+	switch runtime.GOOS {
+	case "darwin":
+		// -W waits for TextEdit to quit; -n starts a fresh instance so an
+		// already-running one doesn't return immediately.
+		return []string{"open", "-W", "-n", "-t", path}, nil
+	case "windows":
+		return []string{"notepad.exe", path}, nil
+	}
+	for _, name := range []string{"sensible-editor", "nano", "vi"} {
+		if p, err := lookPath(name); err == nil {
+			return []string{p, path}, nil
+		}
+	}
+	return nil, errors.New("no editor found: set $EDITOR or [edit] editor")
+}
+
+// runEditor runs the editor attached to the terminal and waits for it; tests
+// replace it.
+var runEditor = func(argv []string) error {
+	c := exec.Command(argv[0], argv[1:]...)
+	c.Stdin, c.Stdout, c.Stderr = os.Stdin, os.Stdout, os.Stderr
+	return c.Run()
+}
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/jainal09/envdrift-agent/internal/config"
+	"github.com/jainal09/envdrift-agent/internal/exports"
+)
+
+func TestEditorCommandOrder(t *testing.T) {
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", "code --wait")
+	if got, _ := editorCommand("", "/p/.env"); len(got) != 3 || got[0] != "code" || got[1] != "--wait" || got[2] != "/p/.env" {
+		t.Errorf("$EDITOR = %v", got)
+	}
+	t.Setenv("VISUAL", "subl -w")
+	if got, _ := editorCommand("", "/p/.env"); got[0] != "subl" {
+		t.Errorf("$VISUAL should win over $EDITOR: %v", got)
+	}
+	if got, _ := editorCommand("vim", "/p/.env"); got[0] != "vim" {
+		t.Errorf("[edit] editor should win: %v", got)
+	}
+
+	if runtime.GOOS == "darwin" || runtime.GOOS == "windows" {
+		return
+	}
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", "")
+	orig := lookPath
+	t.Cleanup(func() { lookPath = orig })
+	lookPath = func(name string) (string, error) {
+		if name == "nano" {
+			return "/usr/bin/nano", nil
+		}
+		return "", exec.ErrNotFound
+	}
+	if got, err := editorCommand("", "/p/.env"); err != nil || got[0] != "/usr/bin/nano" {
+		t.Errorf("fallback = %v, %v", got, err)
+	}
+	lookPath = func(string) (string, error) { return "", exec.ErrNotFound }
+	if _, err := editorCommand("", "/p/.env"); err == nil {
+		t.Error("no editor at all should be an error")
+	}
+}
+
+func TestEditInEditorReencryptsOnExit(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(path, []byte("A=1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := exports.Add(exports.Export{Path: path, Expires: time.Now().Add(time.Hour), Action: exports.ActionEncrypt, Edit: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	var events []string
+	origRun, origEncrypt := runEditor, editEncrypt
+	t.Cleanup(func() { runEditor, editEncrypt = origRun, origEncrypt })
+	runEditor = func(argv []string) error {
+		events = append(events, "edit "+argv[len(argv)-1])
+		return errors.New("exit status 1")
+	}
+	editEncrypt = func(_ context.Context, p string) error {
+		events = append(events, "encrypt "+p)
+		return nil
+	}
+
+	err := editInEditor(context.Background(), &bytes.Buffer{}, path, []string{"vi", path}, reencrypt(config.GuardianConfig{}))
+	if err == nil {
+		t.Error("a failing editor should still be reported")
+	}
+	if len(events) != 2 || events[1] != "encrypt "+path {
+		t.Errorf("events = %v; want the file re-encrypted after the editor exits", events)
+	}
+	if _, held := exports.Pending(path, time.Now()); held {
+		t.Error("session should end when the editor exits")
+	}
+}
@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jainal09/envdrift-agent/internal/dotenv"
+	"github.com/jainal09/envdrift-agent/internal/encrypt"
+	"github.com/jainal09/envdrift-agent/internal/keys"
+	"github.com/jainal09/envdrift-agent/internal/output"
+)
+
+var encryptCmd = &cobra.Command{
+	Use:   "encrypt --stdin (--output <path> | --stdout)",
+	Short: "Encrypt dotenv data read from stdin, for pipelines",
+	Long: `Reads dotenv data from stdin and encrypts it with dotenvx as the env file
+--output names: its name selects the environment's key, which comes from (or,
+for a new environment, is added to) the .env.keys next to it. The result is
+written to --output, or with --stdout printed instead (--output then only
+names the environment and defaults to .env).
+
+The plaintext never reaches --output: dotenvx encrypts a copy in a private
+temporary directory (memory-backed /dev/shm on Linux) removed as soon as it
+returns, so CI jobs need no plaintext path of their own.
+
+  vault-read | envdrift-agent encrypt --stdin --output .env.production
+  envdrift-agent encrypt --stdin --output .env.ci --stdout < values.env`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runEncrypt,
+}
+
+var (
+	encryptStdin  bool
+	encryptOutput string
+	encryptStdout bool
+	encryptForce  bool
+)
+
+// encryptData encrypts stdin's data for runEncrypt; tests replace it.
+var encryptData = encrypt.EncryptData
+
+// init registers the encrypt command and its flags with rootCmd.
+func init() {
+	encryptCmd.Flags().BoolVar(&encryptStdin, "stdin", false, "read the dotenv data from stdin (required)")
+	encryptCmd.Flags().StringVar(&encryptOutput, "output", "", "env file to write; its name selects the key")
+	encryptCmd.Flags().BoolVar(&encryptStdout, "stdout", false, "print the encrypted file instead of writing --output")
+	encryptCmd.Flags().BoolVar(&encryptForce, "force", false, "overwrite an existing --output")
+	_ = encryptCmd.MarkFlagRequired("stdin")
+	rootCmd.AddCommand(encryptCmd)
+}
+
+// runEncrypt validates stdin as dotenv, encrypts it and writes or prints the
+// ciphertext.
+func runEncrypt(cmd *cobra.Command, _ []string) error {
+	if encryptOutput == "" && !encryptStdout {
+		return errors.New("--output or --stdout is required")
+	}
+	name := encryptOutput
+	if name == "" {
+		name = ".env"
+	}
+	target, err := filepath.Abs(name)
+	if err != nil {
+		return err
+	}
+	if !encryptStdout && !encryptForce {
+		if _, err := os.Lstat(target); err == nil {
+			return fmt.Errorf("%s already exists (use --force to overwrite)", target)
+		}
+	}
+
+	data, err := io.ReadAll(cmd.InOrStdin())
+	if err != nil {
+		return fmt.Errorf("read stdin: %w", err)
+	}
+	entries, err := dotenv.Parse(data)
+	if err != nil {
+		return fmt.Errorf("stdin: %w", err)
+	}
+	if len(entries) == 0 {
+		return errors.New("stdin defines no variables")
+	}
+	ciphertext, err := encryptData(context.Background(), target, data)
+	if err != nil {
+		return err
+	}
+
+	if encryptStdout {
+		// Ciphertext is data: plain output mode must not strip it.
+		_, err := output.Raw(cmd.OutOrStdout()).Write(ciphertext)
+		return err
+	}
+	if err := writeReplacing(target, ciphertext); err != nil {
+		return err
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Encrypted %d variables into %s (key %s)\n", len(entries), target, keys.KeyNameForFile(target))
+	return nil
+}
+
+// writeReplacing writes data to path through a temporary file renamed over
+// it, so a reader never sees a partial file.
+func writeReplacing(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEncryptStdin(t *testing.T) {
+	orig := encryptData
+	var gotTarget string
+	encryptData = func(_ context.Context, target string, data []byte) ([]byte, error) {
+		gotTarget = target
+		return []byte("SECRET=\"encrypted:" + strings.TrimSpace(string(data)) + "\"\n"), nil
+	}
+	var out bytes.Buffer
+	encryptCmd.SetOut(&out)
+	t.Cleanup(func() {
+		encryptData = orig
+		encryptCmd.SetOut(nil)
+		encryptCmd.SetIn(nil)
+		encryptOutput, encryptStdout, encryptForce = "", false, false
+	})
+	run := func(stdin string) error {
+		out.Reset()
+		encryptCmd.SetIn(strings.NewReader(stdin))
+		return runEncrypt(encryptCmd, nil)
+	}
+
+	target := filepath.Join(t.TempDir(), ".env.production")
+	encryptOutput = target
+	if err := run("SECRET=1\n"); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(target); string(data) != "SECRET=\"encrypted:SECRET=1\"\n" {
+		t.Errorf("output file = %q", data)
+	}
+	if gotTarget != target || !strings.Contains(out.String(), "DOTENV_PRIVATE_KEY_PRODUCTION") {
+		t.Errorf("target %q, report %q", gotTarget, out.String())
+	}
+	if err := run("SECRET=2\n"); err == nil {
+		t.Error("an existing --output must not be replaced without --force")
+	}
+
+	encryptStdout = true
+	if err := run("SECRET=3\n"); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "SECRET=\"encrypted:SECRET=3\"\n" {
+		t.Errorf("--stdout printed %q", out.String())
+	}
+	if data, _ := os.ReadFile(target); !strings.Contains(string(data), "SECRET=1") {
+		t.Error("--stdout must leave --output untouched")
+	}
+
+	for _, bad := range []string{"", "# nothing\n", "1BAD=x\n"} {
+		if err := run(bad); err == nil {
+			t.Errorf("stdin %q: want an error", bad)
+		}
+	}
+	encryptOutput, encryptStdout = "", false
+	if err := run("SECRET=1\n"); err == nil {
+		t.Error("neither --output nor --stdout: want an error")
+	}
+}
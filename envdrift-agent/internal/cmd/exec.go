@@ -0,0 +1,186 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jainal09/envdrift-agent/internal/dotenv"
+	"github.com/jainal09/envdrift-agent/internal/encrypt"
+	"github.com/jainal09/envdrift-agent/internal/history"
+)
+
+var execCmd = &cobra.Command{
+	Use:   "exec --file <path> -- <command> [args...]",
+	Short: "Run a command with decrypted env file values in its environment",
+	Long: `Decrypts one or more env files in memory and runs the command with their
+variables added to its environment, like 'dotenvx run'. Private keys come
+from the configured [keys] resolution chain and plaintext never touches disk.
+Variables already set in the environment win unless --overload is given.
+The run is recorded in the history log.
+
+  envdrift-agent exec --file .env.production -- ./manage.py migrate`,
+	Args:         cobra.MinimumNArgs(1),
+	SilenceUsage: true,
+	RunE:         runExec,
+}
+
+var (
+	execFiles    []string
+	execOverload bool
+)
+
+// osExit is the process-exit seam runExec uses to propagate the child's exit
+// status; tests replace it.
+var osExit = os.Exit
+
+// init registers the exec command and its flags with rootCmd.
+func init() {
+	execCmd.Flags().StringArrayVarP(&execFiles, "file", "f", []string{".env"},
+		"env file to decrypt (repeatable; later files override earlier ones)")
+	execCmd.Flags().BoolVar(&execOverload, "overload", false,
+		"let env file values override variables already set in the environment")
+	rootCmd.AddCommand(execCmd)
+}
+
+// runExec decrypts every --file, records the access, and runs args with the
+// merged environment, exiting with the child's exit status.
+func runExec(cmd *cobra.Command, args []string) error {
+	entries, paths, err := decryptFiles(context.Background(), execFiles)
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		if err := history.Record(history.Entry{
+			Action: history.ActionExec,
+			Path:   path,
+			Detail: filepath.Base(args[0]),
+		}); err != nil {
+			return fmt.Errorf("refusing to run: could not record access in history: %w", err)
+		}
+	}
+
+	code, err := runChild(args, mergeEnv(os.Environ(), entries, execOverload))
+	if err != nil {
+		return err
+	}
+	if code != 0 {
+		osExit(code)
+	}
+	return nil
+}
+
+// decryptFiles decrypts files in memory with keys from the configured
+// resolution chain, returning their entries in order and their absolute
+// paths.
+func decryptFiles(ctx context.Context, files []string) ([]dotenv.Entry, []string, error) {
+	resolver, err := loadKeyResolver()
+	if err != nil {
+		return nil, nil, err
+	}
+	var entries []dotenv.Entry
+	var paths []string
+	for _, f := range files {
+		path, err := filepath.Abs(f)
+		if err != nil {
+			return nil, nil, err
+		}
+		keyEnv, err := resolvedKeyEnv(ctx, resolver, path)
+		if err != nil {
+			return nil, nil, err
+		}
+		decrypted, err := encrypt.DecryptEntries(ctx, path, keyEnv)
+		if err != nil {
+			return nil, nil, err
+		}
+		entries = append(entries, decrypted...)
+		paths = append(paths, path)
+	}
+	return entries, paths, nil
+}
+
+// runChild runs argv with env attached to this process's stdio, forwarding
+// SIGINT/SIGTERM so the child can shut down on its own terms. It returns the
+// child's exit code, or 128 plus the signal number when a signal killed it, as
+// shells report it; err is non-nil only when the child could not be started.
+func runChild(argv []string, env []string) (int, error) {
+	child := exec.Command(argv[0], argv[1:]...)
+	child.Env = env
+	child.Stdin = os.Stdin
+	child.Stdout = os.Stdout
+	child.Stderr = os.Stderr
+
+	if err := child.Start(); err != nil {
+		return 0, err
+	}
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case sig := <-sigCh:
+				_ = child.Process.Signal(sig)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	err := child.Wait()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		if ws, ok := exitErr.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+			return 128 + int(ws.Signal()), nil
+		}
+		return exitErr.ExitCode(), nil
+	}
+	return 0, err
+}
+
+// mergeEnv adds entries to base (KEY=VALUE pairs). A key already present in
+// base keeps its value unless overload is set, matching `dotenvx run`.
+func mergeEnv(base []string, entries []dotenv.Entry, overload bool) []string {
+	merged := make(map[string]string, len(base)+len(entries))
+	var order []string
+	set := func(k, v string) {
+		if _, ok := merged[k]; !ok {
+			order = append(order, k)
+		}
+		merged[k] = v
+	}
+	for _, kv := range base {
+		if k, v, ok := strings.Cut(kv, "="); ok {
+			set(k, v)
+		}
+	}
+
+	existing := make(map[string]bool, len(merged))
+	for k := range merged {
+		existing[k] = true
+	}
+	for _, e := range entries {
+		if existing[e.Key] && !overload {
+			continue
+		}
+		set(e.Key, e.Value)
+	}
+
+	out := make([]string, 0, len(order))
+	for _, k := range order {
+		out = append(out, k+"="+merged[k])
+	}
+	return out
+}
@@ -0,0 +1,78 @@
+// Package cmd tests for the exec command.
+package cmd
+
+import (
+	"os/exec"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/jainal09/envdrift-agent/internal/dotenv"
+)
+
+func TestMergeEnv(t *testing.T) {
+	base := []string{"PATH=/bin", "API_KEY=from-shell"}
+	entries := []dotenv.Entry{{Key: "API_KEY", Value: "from-file"}, {Key: "DB", Value: "a=b"}}
+
+	got := strings.Join(mergeEnv(base, entries, false), "\n")
+	if want := "PATH=/bin\nAPI_KEY=from-shell\nDB=a=b"; got != want {
+		t.Errorf("mergeEnv without overload =\n%s\nwant\n%s", got, want)
+	}
+
+	got = strings.Join(mergeEnv(base, entries, true), "\n")
+	if want := "PATH=/bin\nAPI_KEY=from-file\nDB=a=b"; got != want {
+		t.Errorf("mergeEnv with overload =\n%s\nwant\n%s", got, want)
+	}
+}
+
+// TestMergeEnvLaterFileWins pins that a key repeated across --file inputs
+// takes the last file's value.
+func TestMergeEnvLaterFileWins(t *testing.T) {
+	entries := []dotenv.Entry{{Key: "X", Value: "1"}, {Key: "X", Value: "2"}}
+	got := mergeEnv(nil, entries, false)
+	if len(got) != 1 || got[0] != "X=2" {
+		t.Errorf("mergeEnv = %v, want [X=2]", got)
+	}
+}
+
+func TestRunChildPropagatesExitCodeAndEnv(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses /bin/sh")
+	}
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh not available")
+	}
+
+	code, err := runChild([]string{sh, "-c", `test "$SECRET" = s3cret && exit 7`}, []string{"SECRET=s3cret"})
+	if err != nil {
+		t.Fatalf("runChild: %v", err)
+	}
+	if code != 7 {
+		t.Errorf("exit code = %d, want 7 (child must see the injected env)", code)
+	}
+}
+
+func TestRunChildReportsSignalLikeShell(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses /bin/sh")
+	}
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh not available")
+	}
+
+	code, err := runChild([]string{sh, "-c", `kill -TERM $$`}, nil)
+	if err != nil {
+		t.Fatalf("runChild: %v", err)
+	}
+	if code != 143 {
+		t.Errorf("exit code = %d, want 143 (128+SIGTERM)", code)
+	}
+}
+
+func TestRunChildMissingCommandErrors(t *testing.T) {
+	if _, err := runChild([]string{"envdrift-agent-no-such-command"}, nil); err == nil {
+		t.Fatal("runChild should fail to start a missing command")
+	}
+}
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jainal09/envdrift-agent/internal/config"
+	"github.com/jainal09/envdrift-agent/internal/guardian"
+	"github.com/jainal09/envdrift-agent/internal/ui"
+)
+
+var explainCmd = &cobra.Command{
+	Use:   "explain <path>",
+	Short: "Explain whether and when the agent encrypts a file",
+	Long: `Evaluates the current configuration against path and prints each check the
+agent applies, in order, up to the one that decides: the registered project
+and profile, which pattern matched and which exclude won, the symlink
+policy, and whether the file is already encrypted, snoozed by an edit or
+export session, in a repository git is writing, or open in another process.
+
+  envdrift-agent explain .env.example
+  # CHECK     RESULT
+  # project   /home/me/src/api
+  # enabled   yes
+  # pattern   matches ".env*"
+  # exclude   matches exclude ".env.example", which wins over ".env*"; a template, kept in plaintext
+  #
+  # Verdict: not watched`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE:         runExplain,
+}
+
+// init registers the explain command with rootCmd.
+func init() {
+	rootCmd.AddCommand(explainCmd)
+}
+
+// runExplain prints the guardian's checks for one file and the verdict.
+func runExplain(cmd *cobra.Command, args []string) error {
+	path, err := filepath.Abs(args[0])
+	if err != nil {
+		return err
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	g, err := guardian.New(cfg.Effective())
+	if err != nil {
+		return err
+	}
+	ex, err := g.Explain(context.Background(), path)
+	if err != nil {
+		return err
+	}
+
+	w := cmd.OutOrStdout()
+	out := ui.New(w)
+	rows := make([][]string, 0, len(ex.Checks))
+	blocked := false
+	for _, c := range ex.Checks {
+		rows = append(rows, []string{c.Name, c.Result})
+		blocked = blocked || c.Blocks
+	}
+	out.Table([]string{"CHECK", "RESULT"}, rows)
+	color := ui.Green
+	if blocked {
+		color = ui.Yellow
+	}
+	fmt.Fprintf(w, "\nVerdict: %s\n", out.Paint(color, ex.Verdict))
+	return nil
+}
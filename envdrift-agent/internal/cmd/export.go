@@ -0,0 +1,188 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jainal09/envdrift-agent/internal/config"
+	"github.com/jainal09/envdrift-agent/internal/dotenv"
+	"github.com/jainal09/envdrift-agent/internal/encrypt"
+	"github.com/jainal09/envdrift-agent/internal/exports"
+	"github.com/jainal09/envdrift-agent/internal/history"
+	"github.com/jainal09/envdrift-agent/internal/output"
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export <path> [--format <format>] [--output <file>]",
+	Short: "Decrypt an env file to stdout or a plaintext file, optionally in another format",
+	Long: `Decrypts an encrypted env file for tools that cannot read it, converted with
+--format to dotenv (the default), json, yaml or tfvars (Terraform variable
+definitions; a TF_VAR_ prefix is dropped). Every value is a string.
+
+Without --output the result goes to stdout only. With --output it is written
+to that file (mode 0600), which must not exist yet; the command asks for
+confirmation first, and --yes skips the prompt and is required when stdin is
+not a terminal. Every export is recorded in the history log before any
+plaintext is written.
+
+With --expire the running agent deletes the output file (or, with
+--expire-action encrypt, re-encrypts it) once the duration has passed.
+
+  envdrift-agent export .env.production --format tfvars > secrets.auto.tfvars
+  envdrift-agent export .env.production --output /tmp/prod.env --expire 15m`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE:         runExport,
+}
+
+var (
+	exportOutput       string
+	exportFormat       string
+	exportYes          bool
+	exportExpire       time.Duration
+	exportExpireAction string
+)
+
+// init registers the export command and its flags with rootCmd.
+func init() {
+	exportCmd.Flags().StringVar(&exportOutput, "output", "", "plaintext file to write (default: stdout)")
+	exportCmd.Flags().StringVar(&exportFormat, "format", dotenv.FormatDotenv,
+		"output format: "+strings.Join(dotenv.Formats, ", "))
+	exportCmd.Flags().BoolVar(&exportYes, "yes", false, "skip the confirmation prompt")
+	exportCmd.Flags().DurationVar(&exportExpire, "expire", 0, "delete or re-encrypt the output after this long (needs the running agent)")
+	exportCmd.Flags().StringVar(&exportExpireAction, "expire-action", exports.ActionDelete, "what --expire does: delete or encrypt")
+	rootCmd.AddCommand(exportCmd)
+}
+
+// runExport decrypts and converts the file, confirms a file output, records
+// the export in history and only then writes the plaintext (failing closed
+// like reveal).
+func runExport(cmd *cobra.Command, args []string) error {
+	if !slices.Contains(dotenv.Formats, exportFormat) {
+		return fmt.Errorf("unsupported --format %q (want %s)", exportFormat, strings.Join(dotenv.Formats, ", "))
+	}
+	if exportExpireAction != exports.ActionDelete && exportExpireAction != exports.ActionEncrypt {
+		return fmt.Errorf("unsupported --expire-action %q (want delete or encrypt)", exportExpireAction)
+	}
+	if exportExpire < 0 {
+		return errors.New("--expire must be positive")
+	}
+	if exportExpire > 0 && exportOutput == "" {
+		return errors.New("--expire needs --output")
+	}
+	if exportExpire > 0 {
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+		if err := refuseReadOnly(cfg, "export with --expire"); err != nil {
+			return err
+		}
+	}
+	source, err := filepath.Abs(args[0])
+	if err != nil {
+		return err
+	}
+	var dest string
+	if exportOutput != "" {
+		if dest, err = filepath.Abs(exportOutput); err != nil {
+			return err
+		}
+		if dest == source {
+			return errors.New("--output must differ from the encrypted file")
+		}
+		if _, err := os.Lstat(dest); err == nil {
+			return fmt.Errorf("%s already exists", dest)
+		}
+	}
+
+	ctx := context.Background()
+	resolver, err := loadKeyResolver()
+	if err != nil {
+		return err
+	}
+	keyEnv, err := resolvedKeyEnv(ctx, resolver, source)
+	if err != nil {
+		return err
+	}
+	entries, err := encrypt.DecryptEntries(ctx, source, keyEnv)
+	if err != nil {
+		return err
+	}
+
+	data, err := dotenv.Convert(entries, exportFormat)
+	if err != nil {
+		return err
+	}
+
+	w := cmd.OutOrStdout()
+	if dest == "" {
+		detail := "stdout"
+		if exportFormat != dotenv.FormatDotenv {
+			detail += " format=" + exportFormat
+		}
+		if err := history.Record(history.Entry{Action: history.ActionExport, Path: source, Detail: detail}); err != nil {
+			return fmt.Errorf("refusing to export: could not record access in history: %w", err)
+		}
+		// Values are data: plain output mode must not strip them.
+		_, err := output.Raw(w).Write(data)
+		return err
+	}
+	if !exportYes {
+		in := promptInput(cmd)
+		if in == nil {
+			return errors.New("refusing to export without confirmation: rerun with --yes")
+		}
+		if !askYesNo(in, w, fmt.Sprintf("Write %d decrypted variables from %s to %s in plaintext?", len(entries), source, dest)) {
+			return errors.New("export cancelled")
+		}
+	}
+
+	detail := "output=" + dest
+	if exportFormat != dotenv.FormatDotenv {
+		detail += " format=" + exportFormat
+	}
+	if exportExpire > 0 {
+		detail += fmt.Sprintf(" expire=%s action=%s", exportExpire, exportExpireAction)
+	}
+	if err := history.Record(history.Entry{Action: history.ActionExport, Path: source, Detail: detail}); err != nil {
+		return fmt.Errorf("refusing to export: could not record access in history: %w", err)
+	}
+	if err := writePlaintext(dest, data); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Exported %d variables to %s\n", len(entries), dest)
+
+	if exportExpire > 0 {
+		expires := time.Now().Add(exportExpire)
+		err := exports.Add(exports.Export{Path: dest, Source: source, Expires: expires, Action: exportExpireAction})
+		if err != nil {
+			return fmt.Errorf("exported, but the self-destruct timer was not set: %w", err)
+		}
+		fmt.Fprintf(w, "The running agent will %s it at %s\n", exportExpireAction, expires.Format(time.Kitchen))
+	}
+	return nil
+}
+
+// writePlaintext creates path with mode 0600, refusing to follow or replace
+// an existing file.
+func writePlaintext(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		_ = os.Remove(path)
+		return err
+	}
+	return f.Close()
+}
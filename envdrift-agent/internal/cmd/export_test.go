@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExportValidatesBeforeDecrypting(t *testing.T) {
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "plain.env")
+	if err := os.WriteFile(existing, []byte("A=1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	orig := [3]string{exportOutput, exportExpireAction, exportFormat}
+	t.Cleanup(func() {
+		exportOutput, exportExpireAction, exportFormat = orig[0], orig[1], orig[2]
+		exportExpire = 0
+	})
+
+	exportOutput, exportExpireAction = existing, "delete"
+	if err := runExport(exportCmd, []string{filepath.Join(dir, ".env")}); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("existing output: got %v", err)
+	}
+	exportOutput, exportExpireAction = filepath.Join(dir, "new.env"), "shred"
+	if err := runExport(exportCmd, []string{filepath.Join(dir, ".env")}); err == nil || !strings.Contains(err.Error(), "--expire-action") {
+		t.Errorf("bad action: got %v", err)
+	}
+	exportOutput, exportExpireAction, exportFormat = "", "delete", "toml"
+	if err := runExport(exportCmd, []string{filepath.Join(dir, ".env")}); err == nil || !strings.Contains(err.Error(), "--format") {
+		t.Errorf("bad format: got %v", err)
+	}
+	exportFormat, exportExpire = "json", time.Minute
+	if err := runExport(exportCmd, []string{filepath.Join(dir, ".env")}); err == nil || !strings.Contains(err.Error(), "--output") {
+		t.Errorf("--expire to stdout: got %v", err)
+	}
+}
+
+func TestExportExpireRefusedInReadOnlyMode(t *testing.T) {
+	t.Setenv("ENVDRIFT_HOME", t.TempDir())
+	t.Setenv("ENVDRIFT_COMPLIANCE_READ_ONLY", "true")
+	dir := t.TempDir()
+	orig := [2]string{exportOutput, exportExpireAction}
+	t.Cleanup(func() {
+		exportOutput, exportExpireAction = orig[0], orig[1]
+		exportExpire = 0
+	})
+
+	exportOutput, exportExpireAction, exportExpire = filepath.Join(dir, "plain.env"), "delete", time.Minute
+	if err := runExport(exportCmd, []string{filepath.Join(dir, ".env")}); err == nil || !strings.Contains(err.Error(), "read_only") {
+		t.Errorf("export --expire in read-only mode = %v", err)
+	}
+	if _, err := os.Stat(exportOutput); !os.IsNotExist(err) {
+		t.Errorf("refused export wrote %s", exportOutput)
+	}
+}
+
+func TestWritePlaintext(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plain.env")
+	if err := writePlaintext(path, []byte("A=\"1\"\n")); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm() != 0o600 {
+		t.Errorf("plaintext export mode = %v, want 0600", info.Mode().Perm())
+	}
+	if err := writePlaintext(path, []byte("B=2\n")); err == nil {
+		t.Error("writePlaintext must not replace an existing file")
+	}
+}
+
+func TestAskYesNo(t *testing.T) {
+	var out bytes.Buffer
+	if !askYesNo(bufio.NewReader(strings.NewReader("Yes\n")), &out, "Go?") || out.String() != "Go? [y/N]: " {
+		t.Errorf("yes answer: prompt %q", out.String())
+	}
+	if askYesNo(bufio.NewReader(strings.NewReader("\n")), &out, "Go?") {
+		t.Error("empty answer should default to no")
+	}
+	if askYesNo(nil, &out, "Go?") {
+		t.Error("no input should be a no")
+	}
+}
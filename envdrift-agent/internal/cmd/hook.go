@@ -0,0 +1,330 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jainal09/envdrift-agent/internal/gitstate"
+	"github.com/jainal09/envdrift-agent/internal/journal"
+	"github.com/jainal09/envdrift-agent/internal/recheck"
+)
+
+// hookMarker identifies a hook this command wrote, so uninstall never removes
+// one it did not.
+const hookMarker = "# envdrift-agent hook"
+
+// gitHook is one hook hook install writes and the envdrift-agent command
+// line it runs.
+type gitHook struct {
+	name string
+	run  string
+}
+
+// gitHooks are the hooks installed: both can bring a plaintext env file into
+// the working tree.
+var gitHooks = []gitHook{
+	{"post-checkout", `recheck "$(git rev-parse --show-toplevel)"`},
+	{"post-merge", `recheck "$(git rev-parse --show-toplevel)"`},
+}
+
+// annotateHook is installed with --annotate-commits: it notes in the commit
+// message the staged files the agent encrypted.
+var annotateHook = gitHook{"prepare-commit-msg", `hook annotate "$1" "$2"`}
+
+// hookAnnotateCommits is the --annotate-commits flag of hook install.
+var hookAnnotateCommits bool
+
+var hookCmd = &cobra.Command{
+	Use:   "hook",
+	Short: "Manage the git hooks that recheck env files after a checkout",
+	Long: `Installs post-checkout and post-merge hooks in a git repository. After a branch
+switch or merge they ask the running agent to verify the repository's env files
+at once, so a plaintext .env the other branch brings in is encrypted right away
+instead of after idle_timeout. With --annotate-commits a prepare-commit-msg
+hook also adds an "EnvDrift: re-encrypted <file>" trailer to commits staging
+files the agent encrypted. With core.hooksPath set, call 'envdrift-agent
+recheck' and 'envdrift-agent hook annotate' from your own hooks instead.`,
+}
+
+var hookInstallCmd = &cobra.Command{
+	Use:          "install [repo]",
+	Short:        "Install the hooks in a repository (default: the current one)",
+	Args:         cobra.MaximumNArgs(1),
+	SilenceUsage: true,
+	RunE:         runHookInstall,
+}
+
+var hookUninstallCmd = &cobra.Command{
+	Use:          "uninstall [repo]",
+	Short:        "Remove the hooks installed by 'hook install'",
+	Args:         cobra.MaximumNArgs(1),
+	SilenceUsage: true,
+	RunE:         runHookUninstall,
+}
+
+var hookAnnotateCmd = &cobra.Command{
+	Use:   "annotate <message-file> [source]",
+	Short: "Add EnvDrift trailers for staged files the agent encrypted",
+	Long: `Run from a prepare-commit-msg hook with the hook's arguments. For each staged
+env file the agent encrypted (per its journal) and nobody has written since, it
+adds a trailer to the commit message so reviewers know the change was
+automated:
+
+  EnvDrift: re-encrypted .env.production
+
+A file new to the repository is "encrypted" rather than "re-encrypted". Merge
+and squash messages are left alone, and so is every message when the journal
+is off ([guardian] journal = false).`,
+	Args:         cobra.RangeArgs(1, 3),
+	SilenceUsage: true,
+	RunE:         runHookAnnotate,
+}
+
+var recheckCmd = &cobra.Command{
+	Use:   "recheck [dir]",
+	Short: "Ask the running agent to verify the env files under a directory now",
+	Long: `Files a request the running agent acts on at once: every env file under the
+directory (default: the current one) in a registered project is checked and,
+if plaintext and not open, encrypted without waiting for idle_timeout. The git
+hooks from 'hook install' run it.`,
+	Args:         cobra.MaximumNArgs(1),
+	SilenceUsage: true,
+	RunE:         runRecheck,
+}
+
+// init registers the hook and recheck commands with rootCmd.
+func init() {
+	hookInstallCmd.Flags().BoolVar(&hookAnnotateCommits, "annotate-commits", false, "Also install a prepare-commit-msg hook adding EnvDrift trailers")
+	hookCmd.AddCommand(hookInstallCmd, hookUninstallCmd, hookAnnotateCmd)
+	rootCmd.AddCommand(hookCmd, recheckCmd)
+}
+
+// hooksDir returns the hooks directory of the repository named by args, or
+// of the current directory.
+func hooksDir(args []string) (string, error) {
+	repo := "."
+	if len(args) == 1 {
+		repo = args[0]
+	}
+	dir := gitstate.HooksDir(repo)
+	if dir == "" {
+		return "", fmt.Errorf("%s is not in a git repository", repo)
+	}
+	return dir, nil
+}
+
+// hookScript is the body of hook h: it runs h's command line and never
+// fails the git command.
+func hookScript(h gitHook, exe string) string {
+	exe = strings.ReplaceAll(filepath.ToSlash(exe), "'", `'\''`)
+	return fmt.Sprintf(`#!/bin/sh
+%s: %s.
+'%s' %s >/dev/null 2>&1 || true
+`, hookMarker, h.purpose(), exe, h.run)
+}
+
+// purpose is the comment in h's script saying what it is for.
+func (h gitHook) purpose() string {
+	if h == annotateHook {
+		return "note the env files the agent encrypted in the commit message"
+	}
+	return "ask the agent to verify env files after a checkout or merge"
+}
+
+// ownHook reports whether the hook at path was written by hook install; a
+// missing hook is not.
+func ownHook(path string) (exists, own bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false, false
+	}
+	return true, strings.Contains(string(data), hookMarker)
+}
+
+// runHookInstall writes the hooks, refusing to replace hooks it did not
+// write.
+func runHookInstall(cmd *cobra.Command, args []string) error {
+	dir, err := hooksDir(args)
+	if err != nil {
+		return err
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	install := gitHooks
+	if hookAnnotateCommits {
+		install = append(slices.Clip(install), annotateHook)
+	}
+	for _, h := range install {
+		path := filepath.Join(dir, h.name)
+		if exists, own := ownHook(path); exists && !own {
+			return fmt.Errorf("%s already exists; add this line to it instead:\n  envdrift-agent %s", path, h.run)
+		}
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	w := cmd.OutOrStdout()
+	for _, h := range install {
+		path := filepath.Join(dir, h.name)
+		if err := os.WriteFile(path, []byte(hookScript(h, exe)), 0o755); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "Installed %s\n", path)
+	}
+	return nil
+}
+
+// runHookUninstall removes the hooks hook install wrote.
+func runHookUninstall(cmd *cobra.Command, args []string) error {
+	dir, err := hooksDir(args)
+	if err != nil {
+		return err
+	}
+	w := cmd.OutOrStdout()
+	removed := 0
+	for _, h := range append(slices.Clip(gitHooks), annotateHook) {
+		path := filepath.Join(dir, h.name)
+		exists, own := ownHook(path)
+		if !exists {
+			continue
+		}
+		if !own {
+			fmt.Fprintf(w, "Left %s: not installed by envdrift-agent\n", path)
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "Removed %s\n", path)
+		removed++
+	}
+	if removed == 0 {
+		fmt.Fprintln(w, "No envdrift-agent hooks installed")
+	}
+	return nil
+}
+
+// runRecheck files a recheck request for the directory.
+func runRecheck(cmd *cobra.Command, args []string) error {
+	dir := "."
+	if len(args) == 1 {
+		dir = args[0]
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	if err := recheck.Request(abs); err != nil {
+		return err
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Recheck requested for %s\n", abs)
+	return nil
+}
+
+// runHookAnnotate adds an EnvDrift trailer to the commit message in args[0]
+// for each staged file the agent encrypted. args[1] is the message's source
+// as git passes it to prepare-commit-msg.
+func runHookAnnotate(cmd *cobra.Command, args []string) error {
+	if len(args) > 1 && (args[1] == "merge" || args[1] == "squash") {
+		return nil
+	}
+	msgFile, err := filepath.Abs(args[0])
+	if err != nil {
+		return err
+	}
+	root := gitstate.Root(".")
+	if root == "" {
+		return errors.New("not in a git repository")
+	}
+	ctx := context.Background()
+	staged, err := runGit(ctx, root, "diff", "--cached", "--name-status", "--no-renames", "-z")
+	if err != nil {
+		return err
+	}
+	trailers, err := encryptedTrailers(root, staged)
+	if err != nil || len(trailers) == 0 {
+		return err
+	}
+	gitArgs := []string{"interpret-trailers", "--in-place", "--if-exists", "addIfDifferent"}
+	for _, t := range trailers {
+		gitArgs = append(gitArgs, "--trailer", "EnvDrift: "+t)
+	}
+	_, err = runGit(ctx, root, append(gitArgs, msgFile)...)
+	return err
+}
+
+// encryptedTrailers returns a trailer value for each file in staged, the
+// output of `git diff --cached --name-status -z`, that the journal records
+// the agent encrypting after it was last written.
+func encryptedTrailers(root string, staged []byte) ([]string, error) {
+	events, err := journal.Read()
+	if err != nil {
+		return nil, err
+	}
+	encryptedAt := make(map[string]time.Time)
+	for _, e := range events {
+		if e.Kind == journal.KindEncrypted {
+			encryptedAt[resolvedPath(e.Path)] = e.Time
+		}
+	}
+
+	var trailers []string
+	fields := strings.Split(strings.TrimSuffix(string(staged), "\x00"), "\x00")
+	for i := 0; i+1 < len(fields); i += 2 {
+		status, name := fields[i], fields[i+1]
+		var verb string
+		switch status {
+		case "A":
+			verb = "encrypted"
+		case "M":
+			verb = "re-encrypted"
+		default:
+			continue
+		}
+		path := filepath.Join(root, filepath.FromSlash(name))
+		at, ok := encryptedAt[resolvedPath(path)]
+		info, err := os.Stat(path)
+		if !ok || err != nil || info.ModTime().After(at) {
+			continue
+		}
+		trailers = append(trailers, verb+" "+name)
+	}
+	return trailers, nil
+}
+
+// resolvedPath is path with symlinks resolved when it exists, so the
+// journal's spelling of a file and git's match.
+func resolvedPath(path string) string {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		return resolved
+	}
+	return filepath.Clean(path)
+}
+
+// runGit runs git in dir and returns its standard output.
+func runGit(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("git %s: %w: %s", args[0], err, msg)
+		}
+		return nil, fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,205 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jainal09/envdrift-agent/internal/journal"
+	"github.com/jainal09/envdrift-agent/internal/recheck"
+)
+
+func TestHookInstallUninstall(t *testing.T) {
+	repo := t.TempDir()
+	hooks := filepath.Join(repo, ".git", "hooks")
+	if err := os.MkdirAll(hooks, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	hookInstallCmd.SetOut(&out)
+	hookUninstallCmd.SetOut(&out)
+	t.Cleanup(func() {
+		hookInstallCmd.SetOut(nil)
+		hookUninstallCmd.SetOut(nil)
+	})
+
+	if err := runHookInstall(hookInstallCmd, []string{repo}); err != nil {
+		t.Fatal(err)
+	}
+	for _, h := range gitHooks {
+		data, err := os.ReadFile(filepath.Join(hooks, h.name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(string(data), "#!/bin/sh\n") || !strings.Contains(string(data), " recheck ") {
+			t.Errorf("%s = %q", h.name, data)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(hooks, annotateHook.name)); !os.IsNotExist(err) {
+		t.Errorf("%s installed without --annotate-commits: %v", annotateHook.name, err)
+	}
+	// Reinstalling over its own hooks is fine.
+	if err := runHookInstall(hookInstallCmd, []string{repo}); err != nil {
+		t.Errorf("reinstall: %v", err)
+	}
+
+	if err := runHookUninstall(hookUninstallCmd, []string{repo}); err != nil {
+		t.Fatal(err)
+	}
+	if entries, _ := os.ReadDir(hooks); len(entries) != 0 {
+		t.Errorf("%d hooks left after uninstall", len(entries))
+	}
+
+	foreign := filepath.Join(hooks, "post-merge")
+	if err := os.WriteFile(foreign, []byte("#!/bin/sh\nmake deps\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := runHookInstall(hookInstallCmd, []string{repo}); err == nil {
+		t.Error("install replaced a foreign post-merge hook")
+	}
+	if err := runHookUninstall(hookUninstallCmd, []string{repo}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(foreign); err != nil {
+		t.Error("uninstall removed a foreign hook")
+	}
+
+	if err := runHookInstall(hookInstallCmd, []string{t.TempDir()}); err == nil {
+		t.Error("install outside a repository succeeded")
+	}
+}
+
+func TestRecheckFilesRequest(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	var out bytes.Buffer
+	recheckCmd.SetOut(&out)
+	t.Cleanup(func() { recheckCmd.SetOut(nil) })
+
+	repo := filepath.Join(home, "repo")
+	if err := runRecheck(recheckCmd, []string{repo}); err != nil {
+		t.Fatal(err)
+	}
+	dirs, err := recheck.Take()
+	if err != nil || len(dirs) != 1 || dirs[0] != repo {
+		t.Errorf("requests = %v, %v; want [%s]", dirs, err, repo)
+	}
+}
+
+func TestHookInstallAnnotateCommits(t *testing.T) {
+	repo := t.TempDir()
+	hooks := filepath.Join(repo, ".git", "hooks")
+	if err := os.MkdirAll(hooks, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	hookInstallCmd.SetOut(&out)
+	hookUninstallCmd.SetOut(&out)
+	hookAnnotateCommits = true
+	t.Cleanup(func() {
+		hookInstallCmd.SetOut(nil)
+		hookUninstallCmd.SetOut(nil)
+		hookAnnotateCommits = false
+	})
+
+	if err := runHookInstall(hookInstallCmd, []string{repo}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(hooks, "prepare-commit-msg"))
+	if err != nil || !strings.Contains(string(data), ` hook annotate "$1" "$2" `) {
+		t.Errorf("prepare-commit-msg = %q, %v", data, err)
+	}
+	if err := runHookUninstall(hookUninstallCmd, []string{repo}); err != nil {
+		t.Fatal(err)
+	}
+	if entries, _ := os.ReadDir(hooks); len(entries) != 0 {
+		t.Errorf("%d hooks left after uninstall", len(entries))
+	}
+}
+
+// TestHookAnnotate checks the trailers: a tracked file the agent encrypted
+// is re-encrypted, a new one encrypted, and one written after the agent
+// encrypted it, or never encrypted by it, gets none.
+func TestHookAnnotate(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Setenv("ENVDRIFT_HOME", t.TempDir())
+	repo := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		if _, err := runGit(context.Background(), repo, args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write := func(name, data string) string {
+		t.Helper()
+		path := filepath.Join(repo, name)
+		if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	git("init", "-q")
+	git("config", "user.email", "dev@example.com")
+	git("config", "user.name", "Dev")
+	write(".env", "A=1\n")
+	write(".env.edited", "B=1\n")
+	git("add", ".")
+	git("commit", "-q", "-m", "init")
+
+	past := time.Now().Add(-time.Minute)
+	for _, name := range []string{".env", ".env.new", ".env.edited"} {
+		path := write(name, "X=\"encrypted:x\"\n")
+		if err := os.Chtimes(path, past, past); err != nil {
+			t.Fatal(err)
+		}
+		if err := journal.Append(journal.Event{Kind: journal.KindEncrypted, Path: path}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(".env.edited", "X=\"encrypted:y\"\n")
+	write(".env.other", "X=\"encrypted:x\"\n")
+	git("add", ".")
+
+	msg := write("MSG", "Update env files\n")
+	prev, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(repo); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(prev) })
+
+	for range 2 {
+		if err := runHookAnnotate(hookAnnotateCmd, []string{msg, "message"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	data, err := os.ReadFile(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Update env files\n\nEnvDrift: re-encrypted .env\nEnvDrift: encrypted .env.new\n"
+	if string(data) != want {
+		t.Errorf("message = %q; want %q", data, want)
+	}
+
+	// A merge message is left alone.
+	merge := write("MERGE_MSG", "Merge branch\n")
+	if err := runHookAnnotate(hookAnnotateCmd, []string{merge, "merge"}); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(merge); string(data) != "Merge branch\n" {
+		t.Errorf("merge message = %q", data)
+	}
+}
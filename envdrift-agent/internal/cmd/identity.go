@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jainal09/envdrift-agent/internal/config"
+	"github.com/jainal09/envdrift-agent/internal/history"
+	"github.com/jainal09/envdrift-agent/internal/identity"
+)
+
+var identityCmd = &cobra.Command{
+	Use:   "identity",
+	Short: "Show or rotate this install's agent ID and registration keypair",
+	Long: `Each install has a random agent ID, sent with every compliance report so a fleet
+server can tell machines apart. With
+
+  envdrift-agent config set identity.keypair true
+
+it also gets an ed25519 registration keypair: register the public key 'identity
+show' prints with the server, and the reports are signed with the private key
+(X-EnvDrift-Agent-ID, X-EnvDrift-Timestamp and X-EnvDrift-Signature headers).
+Both live in identity.json in the state directory.`,
+}
+
+var identityShowCmd = &cobra.Command{
+	Use:          "show",
+	Short:        "Print the agent ID and the public key to register",
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runIdentityShow,
+}
+
+var identityRotateCmd = &cobra.Command{
+	Use:   "rotate",
+	Short: "Replace the registration keypair, or the whole identity",
+	Long: `Replaces the registration keypair, e.g. after the machine was compromised or
+handed over; with --id the agent ID too, and the fleet sees a new machine. The
+new public key has to be registered before the server accepts the reports
+again. The rotation is recorded in the history log.`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runIdentityRotate,
+}
+
+// Flags for identity.
+var (
+	identityJSON bool
+	identityNew  bool
+	identityYes  bool
+)
+
+// init registers the identity command group with rootCmd.
+func init() {
+	identityShowCmd.Flags().BoolVar(&identityJSON, "json", false, "print as JSON")
+	identityRotateCmd.Flags().BoolVar(&identityNew, "id", false, "replace the agent ID as well")
+	identityRotateCmd.Flags().BoolVar(&identityYes, "yes", false, "skip the confirmation prompt")
+	identityCmd.AddCommand(identityShowCmd, identityRotateCmd)
+	rootCmd.AddCommand(identityCmd)
+}
+
+// identityView is what show prints as JSON: never the private key.
+type identityView struct {
+	AgentID     string `json:"agent_id"`
+	Created     string `json:"created"`
+	PublicKey   string `json:"public_key,omitempty"`
+	Fingerprint string `json:"fingerprint,omitempty"`
+	KeyCreated  string `json:"key_created,omitempty"`
+	File        string `json:"file"`
+}
+
+// runIdentityShow prints the identity, creating it on first use.
+func runIdentityShow(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	id, err := identity.Ensure(identity.DefaultPath(), cfg.Identity.Keypair)
+	if err != nil {
+		return err
+	}
+	return printIdentity(cmd, id)
+}
+
+// runIdentityRotate replaces the keypair, and with --id the agent ID.
+func runIdentityRotate(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	if !cfg.Identity.Keypair && !identityNew {
+		return errors.New("there is no keypair to rotate: set identity.keypair = true, or pass --id for a new agent ID")
+	}
+	what := "the registration keypair"
+	detail := "key"
+	switch {
+	case identityNew && cfg.Identity.Keypair:
+		what, detail = "the agent ID and the registration keypair", "id,key"
+	case identityNew:
+		what, detail = "the agent ID", "id"
+	}
+	w := cmd.OutOrStdout()
+	if !identityYes && !askYesNo(promptInput(cmd), w, fmt.Sprintf("Replace %s? The fleet server must register it again.", what)) {
+		return errors.New("rotation cancelled (--yes skips the prompt)")
+	}
+	old, err := identity.Ensure(identity.DefaultPath(), false)
+	if err != nil {
+		return err
+	}
+	id, err := identity.Rotate(identity.DefaultPath(), identityNew, cfg.Identity.Keypair)
+	if err != nil {
+		return err
+	}
+	if err := history.Record(history.Entry{Action: history.ActionIdentityRotate, Path: identity.DefaultPath(),
+		Detail: detail + " was=" + old.ID}); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Replaced %s\n\n", what)
+	return printIdentity(cmd, id)
+}
+
+// printIdentity writes id as show prints it.
+func printIdentity(cmd *cobra.Command, id *identity.Identity) error {
+	const layout = "2006-01-02 15:04:05 MST"
+	v := identityView{
+		AgentID:     id.ID,
+		Created:     id.Created.Local().Format(layout),
+		PublicKey:   id.PublicKey(),
+		Fingerprint: id.Fingerprint(),
+		File:        identity.DefaultPath(),
+	}
+	if id.HasKey() {
+		v.KeyCreated = id.KeyCreated.Local().Format(layout)
+	}
+	w := cmd.OutOrStdout()
+	if identityJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+	fmt.Fprintf(w, "Agent ID:     %s\n", v.AgentID)
+	fmt.Fprintf(w, "Created:      %s\n", v.Created)
+	if v.PublicKey == "" {
+		fmt.Fprintln(w, "Keypair:      off (identity.keypair)")
+	} else {
+		fmt.Fprintf(w, "Public key:   %s\n", v.PublicKey)
+		fmt.Fprintf(w, "Fingerprint:  %s\n", v.Fingerprint)
+		fmt.Fprintf(w, "Key created:  %s\n", v.KeyCreated)
+	}
+	fmt.Fprintf(w, "File:         %s\n", v.File)
+	return nil
+}
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jainal09/envdrift-agent/internal/config"
+	"github.com/jainal09/envdrift-agent/internal/history"
+)
+
+func TestIdentity(t *testing.T) {
+	t.Setenv("ENVDRIFT_HOME", t.TempDir())
+	t.Cleanup(func() { identityJSON, identityNew, identityYes = false, false, false })
+	var out bytes.Buffer
+	for _, c := range []*cobra.Command{identityShowCmd, identityRotateCmd} {
+		c.SetOut(&out)
+	}
+	t.Cleanup(func() {
+		identityShowCmd.SetOut(nil)
+		identityRotateCmd.SetOut(nil)
+	})
+
+	if err := runIdentityShow(identityShowCmd, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "Keypair:      off") {
+		t.Errorf("show without a keypair:\n%s", out.String())
+	}
+	identityYes = true
+	if err := runIdentityRotate(identityRotateCmd, nil); err == nil || !strings.Contains(err.Error(), "identity.keypair") {
+		t.Errorf("rotate without a keypair = %v", err)
+	}
+
+	if err := config.Set("identity.keypair", "true"); err != nil {
+		t.Fatal(err)
+	}
+	identityJSON = true
+	show := func() identityView {
+		t.Helper()
+		out.Reset()
+		if err := runIdentityShow(identityShowCmd, nil); err != nil {
+			t.Fatal(err)
+		}
+		var v identityView
+		if err := json.Unmarshal(out.Bytes(), &v); err != nil {
+			t.Fatalf("%v:\n%s", err, out.String())
+		}
+		return v
+	}
+	before := show()
+	if !strings.HasPrefix(before.PublicKey, "ed25519:") || strings.Contains(out.String(), "seed") {
+		t.Errorf("show --json:\n%s", out.String())
+	}
+
+	identityJSON = false
+	if err := runIdentityRotate(identityRotateCmd, nil); err != nil {
+		t.Fatal(err)
+	}
+	identityJSON = true
+	after := show()
+	if after.AgentID != before.AgentID || after.PublicKey == before.PublicKey {
+		t.Errorf("rotate: %+v -> %+v; want a new key for the same ID", before, after)
+	}
+	entries, err := history.Read()
+	if err != nil || len(entries) != 1 || entries[0].Action != history.ActionIdentityRotate || entries[0].Detail != "key was="+before.AgentID {
+		t.Errorf("history = %+v, %v", entries, err)
+	}
+}
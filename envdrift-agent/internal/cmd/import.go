@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jainal09/envdrift-agent/internal/importer"
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import <dir>",
+	Short: "Convert a dotenv-vault or SOPS project to dotenvx encryption",
+	Long: `Decrypts the env files another tool encrypted and re-encrypts them with dotenvx,
+which writes the new DOTENV_PRIVATE_KEY_<ENV> keys to the folder's .env.keys
+(an existing key there is reused).
+
+  --from dotenv-vault  Decrypts each DOTENV_VAULT_<ENV> in .env.vault with its
+                       DOTENV_KEY ($DOTENV_KEY or DOTENV_KEY_<ENV> in .env.keys)
+                       into .env (development) or .env.<env>.
+  --from sops          Decrypts every SOPS .env* file with sops and replaces it
+                       in place, keeping the original as <file>.sops.
+
+The source files are left in place; delete them once the import is verified.
+
+  envdrift-agent import --from sops services/api`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE:         runImport,
+}
+
+var (
+	importFrom  string
+	importForce bool
+)
+
+// init registers the import command and its flags with rootCmd.
+func init() {
+	importCmd.Flags().StringVar(&importFrom, "from", "", "source format: dotenv-vault or sops (required)")
+	importCmd.Flags().BoolVar(&importForce, "force", false, "overwrite existing target files (dotenv-vault)")
+	_ = importCmd.MarkFlagRequired("from")
+	rootCmd.AddCommand(importCmd)
+}
+
+// runImport converts the directory and reports each file.
+func runImport(cmd *cobra.Command, args []string) error {
+	dir, err := filepath.Abs(args[0])
+	if err != nil {
+		return err
+	}
+	results, err := importer.Import(context.Background(), importFrom, dir, importer.Options{Force: importForce})
+	writeImportResults(cmd.OutOrStdout(), dir, results)
+	return err
+}
+
+// writeImportResults prints one line per converted or skipped file.
+func writeImportResults(w io.Writer, dir string, results []importer.Result) {
+	rel := func(path string) string {
+		if r, err := filepath.Rel(dir, path); err == nil {
+			return r
+		}
+		return path
+	}
+	imported := 0
+	for _, r := range results {
+		if r.Skipped != "" {
+			fmt.Fprintf(w, "Skipped  %s: %s\n", rel(r.Target), r.Skipped)
+			continue
+		}
+		imported++
+		line := fmt.Sprintf("Imported %s (%d variables, key %s)", rel(r.Target), r.Vars, r.KeyName)
+		if r.Backup != "" {
+			line += ", original kept as " + rel(r.Backup)
+		}
+		fmt.Fprintln(w, line)
+	}
+	if imported > 0 {
+		fmt.Fprintln(w, "Keys are in .env.keys; run 'envdrift-agent vault push' to share them through the project's vault.")
+	}
+}
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/jainal09/envdrift-agent/internal/config"
+	"github.com/jainal09/envdrift-agent/internal/daemon"
+	"github.com/jainal09/envdrift-agent/internal/encrypt"
+)
+
+var (
+	installConfig         string
+	installNonInteractive bool
+)
+
+// installService is a seam for tests: the service registration.
+var installService = daemon.Install
+
+// installResult is what `install --non-interactive` prints, as one JSON
+// object, for MDM and configuration-management tools to check.
+type installResult struct {
+	OK bool `json:"ok"`
+	// Config is the guardian.toml the agent reads.
+	Config string `json:"config"`
+	// ConfigSeeded is set when --config replaced Config.
+	ConfigSeeded bool `json:"config_seeded"`
+	// Backup is where the replaced config was kept, if it differed.
+	Backup           string `json:"backup,omitempty"`
+	ServiceInstalled bool   `json:"service_installed"`
+	// EnvdriftAvailable is false when the envdrift CLI is not on PATH; the
+	// agent installs but cannot encrypt until it is.
+	EnvdriftAvailable bool   `json:"envdrift_available"`
+	Error             string `json:"error,omitempty"`
+}
+
+// seedConfig validates the managed config at src and copies it verbatim to
+// config.ConfigPath(), atomically. A different existing config is kept at
+// guardian.toml.bak; backup names it.
+func seedConfig(src string) (backup string, err error) {
+	if _, err := config.LoadFile(src); err != nil {
+		return "", fmt.Errorf("managed config %s: %w", src, err)
+	}
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return "", err
+	}
+	dst := config.ConfigPath()
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return "", err
+	}
+	if old, err := os.ReadFile(dst); err == nil && !bytes.Equal(old, data) {
+		backup = dst + ".bak"
+		if err := os.WriteFile(backup, old, 0o644); err != nil {
+			return "", fmt.Errorf("back up %s: %w", dst, err)
+		}
+	}
+	tmp := dst + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp, dst); err != nil {
+		_ = os.Remove(tmp)
+		return "", err
+	}
+	return backup, nil
+}
+
+// runInstallUnattended is install --non-interactive: it seeds --config when
+// given, installs the service and reports the outcome as JSON on w. It never
+// prompts; any failure is in the JSON and the returned error, so the exit
+// status is non-zero.
+func runInstallUnattended(w io.Writer) error {
+	res := installResult{Config: config.ConfigPath(), EnvdriftAvailable: encrypt.IsEnvdriftAvailable()}
+	err := func() error {
+		if installConfig != "" {
+			backup, err := seedConfig(installConfig)
+			if err != nil {
+				return err
+			}
+			res.ConfigSeeded, res.Backup = true, backup
+		} else {
+			cfg, err := config.LoadWithoutEnv()
+			if err != nil {
+				return err
+			}
+			if err := config.Save(cfg); err != nil {
+				return err
+			}
+		}
+		if err := installService(); err != nil {
+			return fmt.Errorf("failed to install: %w", err)
+		}
+		res.ServiceInstalled = true
+		return nil
+	}()
+	res.OK = err == nil
+	if err != nil {
+		res.Error = err.Error()
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if encErr := enc.Encode(res); encErr != nil && err == nil {
+		return encErr
+	}
+	return err
+}
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jainal09/envdrift-agent/internal/config"
+)
+
+func TestInstallNonInteractive(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	origInstall := installService
+	t.Cleanup(func() {
+		installService = origInstall
+		installConfig, installNonInteractive = "", false
+		installCmd.SetOut(nil)
+	})
+	installs := 0
+	installService = func() error { installs++; return nil }
+	installNonInteractive = true
+
+	run := func() (installResult, error) {
+		t.Helper()
+		var out bytes.Buffer
+		installCmd.SetOut(&out)
+		err := runInstall(installCmd, nil)
+		var res installResult
+		if jerr := json.Unmarshal(out.Bytes(), &res); jerr != nil {
+			t.Fatalf("output is not JSON: %v\n%s", jerr, out.String())
+		}
+		return res, err
+	}
+
+	// A previous config that differs is backed up; the managed one is copied verbatim.
+	if err := os.MkdirAll(filepath.Dir(config.ConfigPath()), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(config.ConfigPath(), []byte("[guardian]\nenabled = false\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	managed := filepath.Join(t.TempDir(), "managed.toml")
+	body := "# managed by IT\n[guardian]\nenabled = true\nidle_timeout = \"10m\"\n"
+	if err := os.WriteFile(managed, []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	installConfig = managed
+	res, err := run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.OK || !res.ConfigSeeded || !res.ServiceInstalled || res.Backup == "" || installs != 1 {
+		t.Errorf("result = %+v, installs = %d", res, installs)
+	}
+	if got, _ := os.ReadFile(config.ConfigPath()); string(got) != body {
+		t.Errorf("seeded config = %q; want %q", got, body)
+	}
+	if got, _ := os.ReadFile(res.Backup); string(got) != "[guardian]\nenabled = false\n" {
+		t.Errorf("backup = %q", got)
+	}
+
+	// An invalid managed config changes nothing and is reported.
+	if err := os.WriteFile(managed, []byte("[guardian]\nidle_timeout = \"soon\"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	res, err = run()
+	if err == nil || res.OK || res.Error == "" || res.ConfigSeeded || installs != 1 {
+		t.Errorf("invalid config: result = %+v, err = %v, installs = %d", res, err, installs)
+	}
+	if got, _ := os.ReadFile(config.ConfigPath()); string(got) != body {
+		t.Errorf("invalid managed config replaced the current one: %q", got)
+	}
+
+	// A failing service install is reported after the config is seeded.
+	installConfig = ""
+	installService = func() error { return errors.New("launchctl: permission denied") }
+	res, err = run()
+	if err == nil || res.OK || res.ServiceInstalled || res.Error != "failed to install: launchctl: permission denied" {
+		t.Errorf("install failure: result = %+v, err = %v", res, err)
+	}
+}
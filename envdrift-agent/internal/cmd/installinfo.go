@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jainal09/envdrift-agent/internal/config"
+	"github.com/jainal09/envdrift-agent/internal/paths"
+	"github.com/jainal09/envdrift-agent/internal/update"
+)
+
+var installInfoCmd = &cobra.Command{
+	Use:   "install-info",
+	Short: "Show how this binary was installed and how to update it",
+	Long: `Prints the version, the executable path and the install method: the one
+stamped in at build time by a package (brew, scoop, deb), otherwise detected
+from where the binary lives. Managed installs are updated through their
+package manager; manual ones with 'envdrift-agent self-update'. The
+directories holding the agent's config, state, cache and logs follow.`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runInstallInfo,
+}
+
+// init registers the install-info command with rootCmd.
+func init() {
+	rootCmd.AddCommand(installInfoCmd)
+}
+
+// runInstallInfo prints the install metadata.
+func runInstallInfo(cmd *cobra.Command, args []string) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	method := update.DetectMethod(InstallMethod, exe)
+	source := "detected"
+	if InstallMethod != "" {
+		source = "build"
+	}
+	upgrade := update.UpgradeCommand(method)
+	if upgrade == "" {
+		upgrade = "envdrift-agent self-update"
+	}
+
+	w := cmd.OutOrStdout()
+	fmt.Fprintf(w, "Version:     %s\n", Version)
+	fmt.Fprintf(w, "Executable:  %s\n", exe)
+	fmt.Fprintf(w, "Installed:   %s (%s)\n", method, source)
+	fmt.Fprintf(w, "Channel:     %s\n", cfg.Update.Channel)
+	fmt.Fprintf(w, "Update with: %s\n", upgrade)
+	fmt.Fprintf(w, "Config:      %s\n", paths.ConfigDir())
+	fmt.Fprintf(w, "State:       %s\n", paths.StateDir())
+	fmt.Fprintf(w, "Cache:       %s\n", paths.CacheDir())
+	fmt.Fprintf(w, "Logs:        %s\n", paths.LogDir())
+	return nil
+}
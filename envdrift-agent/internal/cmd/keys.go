@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jainal09/envdrift-agent/internal/config"
+	"github.com/jainal09/envdrift-agent/internal/keys"
+	"github.com/jainal09/envdrift-agent/internal/paths"
+	"github.com/jainal09/envdrift-agent/internal/vault"
+)
+
+var keysCmd = &cobra.Command{
+	Use:   "keys",
+	Short: "Inspect dotenvx private key resolution",
+}
+
+var keysWhereisCmd = &cobra.Command{
+	Use:   "whereis [env]",
+	Short: "Show where the private key for an environment would be resolved from",
+	Long: `Walks the configured [keys] resolution chain (default: env, dotenv_keys,
+keychain, vault) for DOTENV_PRIVATE_KEY_<ENV> and reports what each source
+holds. Key values are never printed. Omit env for the default DOTENV_PRIVATE_KEY.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runKeysWhereis,
+}
+
+// keysDir is the --dir flag: the directory whose .env.keys is consulted.
+var keysDir string
+
+// init registers the keys command group with rootCmd.
+func init() {
+	keysWhereisCmd.Flags().StringVar(&keysDir, "dir", ".", "project directory holding the env file and .env.keys")
+	keysCmd.AddCommand(keysWhereisCmd)
+	rootCmd.AddCommand(keysCmd)
+}
+
+// runKeysWhereis prints the per-source resolution report for one key.
+func runKeysWhereis(cmd *cobra.Command, args []string) error {
+	env := ""
+	if len(args) == 1 {
+		env = args[0]
+	}
+	dir, err := filepath.Abs(keysDir)
+	if err != nil {
+		return err
+	}
+
+	resolver, err := loadKeyResolver()
+	if err != nil {
+		return err
+	}
+	req := keys.Request{KeyName: keys.KeyName(env), Dir: dir}
+	writeWhereis(cmd.OutOrStdout(), req.KeyName, resolver.Explain(context.Background(), req))
+	return nil
+}
+
+// writeWhereis renders the Explain steps, marking the source that wins.
+func writeWhereis(w io.Writer, keyName string, steps []keys.Step) {
+	fmt.Fprintln(w, keyName)
+	selected := false
+	for i, s := range steps {
+		status := "not found"
+		switch {
+		case s.Err != nil:
+			status = "unavailable: " + s.Err.Error()
+		case s.Found:
+			status = "found"
+		}
+		if s.Location != "" {
+			status += " (" + s.Location + ")"
+		}
+		marker := ""
+		if s.Found && !selected {
+			marker = "  <- selected"
+			selected = true
+		}
+		fmt.Fprintf(w, "  %d. %-12s %s%s\n", i+1, s.Source, status, marker)
+	}
+	if !selected {
+		fmt.Fprintln(w, "  no source holds this key")
+	}
+}
+
+// noVaultCache is the --no-cache flag: key resolution reads the vault
+// instead of [vault_cache].
+var noVaultCache bool
+
+// loadKeyResolver builds the resolver for the configured chain, its vault
+// source reading through the [vault_cache] unless --no-cache is given.
+func loadKeyResolver() (*keys.Resolver, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+	resolver, err := keys.NewResolver(cfg.Keys.Resolution)
+	if err != nil {
+		return nil, err
+	}
+	if c := newVaultCache(cfg); c != nil && !noVaultCache {
+		resolver.CacheVault(c)
+	}
+	return resolver, nil
+}
+
+// newVaultCache returns the cache [vault_cache] configures, or nil when its
+// ttl is 0. The disk cache lives in the cache directory; its key is in the
+// config directory, so a copied or synced cache directory alone reveals
+// nothing.
+func newVaultCache(cfg *config.Config) *vault.Cache {
+	if cfg.VaultCache.TTL <= 0 {
+		return nil
+	}
+	c := &vault.Cache{TTL: cfg.VaultCache.TTL}
+	if cfg.VaultCache.Disk {
+		c.Dir = filepath.Join(paths.CacheDir(), "vault")
+		c.KeyPath = filepath.Join(paths.ConfigDir(), "vault-cache.key")
+	}
+	return c
+}
+
+// resolvedKeyEnv resolves the private key for the env file at path through
+// the configured chain and returns it as KEY=VALUE entries for the dotenvx
+// child's environment: under its name, and under dotenvx's own name for the
+// file when a [naming] convention makes that differ. It returns nil when no
+// source holds the key, leaving dotenvx to report the missing key.
+func resolvedKeyEnv(ctx context.Context, resolver *keys.Resolver, path string) ([]string, error) {
+	req := keys.Request{KeyName: keys.KeyNameForFile(path), Dir: filepath.Dir(path)}
+	res, found, err := resolver.Resolve(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("resolve %s: %w", req.KeyName, err)
+	}
+	if !found {
+		fmt.Fprintf(os.Stderr, "envdrift-agent: no source in the key chain holds %s\n", req.KeyName)
+		return nil, nil
+	}
+	env := []string{res.KeyName + "=" + res.Value}
+	// dotenvx looks the key up under the name it derives from the file name,
+	// which a [naming] convention can make differ.
+	if name := keys.DotenvxKeyName(path); name != res.KeyName {
+		env = append(env, name+"="+res.Value)
+	}
+	return env, nil
+}
@@ -0,0 +1,43 @@
+// Package cmd tests for the keys command.
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jainal09/envdrift-agent/internal/keys"
+)
+
+func TestWriteWhereisMarksFirstHit(t *testing.T) {
+	steps := []keys.Step{
+		{Source: "env", Location: "$K"},
+		{Source: "dotenv_keys", Location: "/p/.env.keys", Found: true},
+		{Source: "keychain", Found: true},
+		{Source: "vault", Err: errors.New("no vault provider configured")},
+	}
+	var buf bytes.Buffer
+	writeWhereis(&buf, "K", steps)
+	out := buf.String()
+
+	if strings.Count(out, "<- selected") != 1 {
+		t.Fatalf("exactly one source must be selected:\n%s", out)
+	}
+	for _, line := range strings.Split(out, "\n") {
+		if strings.Contains(line, "<- selected") && !strings.Contains(line, "dotenv_keys") {
+			t.Errorf("first hit should be selected, got line %q", line)
+		}
+	}
+	if !strings.Contains(out, "unavailable: no vault provider configured") {
+		t.Errorf("unavailable source not reported:\n%s", out)
+	}
+}
+
+func TestWriteWhereisNoSource(t *testing.T) {
+	var buf bytes.Buffer
+	writeWhereis(&buf, "K", []keys.Step{{Source: "env"}})
+	if !strings.Contains(buf.String(), "no source holds this key") {
+		t.Errorf("missing not-found summary:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,223 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jainal09/envdrift-agent/internal/config"
+	"github.com/jainal09/envdrift-agent/internal/dotenv"
+	"github.com/jainal09/envdrift-agent/internal/history"
+	"github.com/jainal09/envdrift-agent/internal/keys"
+	"github.com/jainal09/envdrift-agent/internal/keyshare"
+)
+
+var keysShareCmd = &cobra.Command{
+	Use:   "share [env...] --to <recipient>",
+	Short: "Encrypt private keys to teammates' SSH or age public keys",
+	Long: `Encrypts the project's dotenvx private keys with age to each --to recipient
+and writes an armored bundle that only they can open, to paste into chat or
+attach anywhere. A recipient is:
+
+  github:alice                  the SSH keys GitHub publishes for alice
+  age1...                       an age public key
+  "ssh-ed25519 AAAA..."         an SSH public key (ssh-ed25519 or ssh-rsa)
+  alice                         a name from [keys] team
+
+Name environments to share just their keys, resolved through the [keys]
+chain; without any, every key in the project's .env.keys is shared. Needs
+the age CLI.`,
+	SilenceUsage: true,
+	RunE:         runKeysShare,
+}
+
+var keysAcceptCmd = &cobra.Command{
+	Use:   "accept [bundle]",
+	Short: "Decrypt a key share and add its keys to .env.keys",
+	Long: `Decrypts a bundle from 'keys share' (a file, or stdin when omitted or -) with
+your SSH or age private key and writes its keys into the project's .env.keys.
+Without --identity it tries ~/.ssh/id_ed25519, ~/.ssh/id_rsa and the SOPS age
+key file. A key that already exists with a different value is only replaced
+after confirmation, or with --force.`,
+	Args:         cobra.MaximumNArgs(1),
+	SilenceUsage: true,
+	RunE:         runKeysAccept,
+}
+
+// Flags for keys share and keys accept.
+var (
+	keysShareTo          []string
+	keysShareOutput      string
+	keysAcceptIdentities []string
+	keysAcceptForce      bool
+)
+
+// init registers the share and accept commands with keysCmd.
+func init() {
+	keysShareCmd.Flags().StringArrayVar(&keysShareTo, "to", nil, "recipient: github:<user>, age1..., an SSH public key or a [keys] team name (repeatable)")
+	keysShareCmd.Flags().StringVarP(&keysShareOutput, "output", "o", "-", "file to write the bundle to (- for stdout)")
+	keysShareCmd.Flags().StringVar(&keysDir, "dir", ".", "project directory holding the env file and .env.keys")
+	keysAcceptCmd.Flags().StringArrayVarP(&keysAcceptIdentities, "identity", "i", nil, "SSH or age private key to decrypt with (repeatable)")
+	keysAcceptCmd.Flags().BoolVar(&keysAcceptForce, "force", false, "replace existing keys with different values without asking")
+	keysAcceptCmd.Flags().StringVar(&keysDir, "dir", ".", "project directory holding the env file and .env.keys")
+	keysCmd.AddCommand(keysShareCmd, keysAcceptCmd)
+}
+
+// runKeysShare encrypts the selected keys to the recipients.
+func runKeysShare(cmd *cobra.Command, args []string) error {
+	if len(keysShareTo) == 0 {
+		return errors.New("name at least one recipient with --to (e.g. --to github:alice)")
+	}
+	dir, err := filepath.Abs(keysDir)
+	if err != nil {
+		return err
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	var recipients []string
+	for _, spec := range keysShareTo {
+		r, err := keyshare.Resolve(ctx, spec, cfg.Keys.Team)
+		if err != nil {
+			return err
+		}
+		recipients = append(recipients, r...)
+	}
+	shared, err := keysToShare(ctx, cfg, dir, args)
+	if err != nil {
+		return err
+	}
+
+	share := keyshare.Share{From: keyshare.Sender(), Project: filepath.Base(dir), Created: time.Now().UTC(), Keys: shared}
+	bundle, err := keyshare.Seal(ctx, share, recipients)
+	if err != nil {
+		return err
+	}
+	if keysShareOutput == "-" {
+		if _, err := cmd.OutOrStdout().Write(bundle); err != nil {
+			return err
+		}
+	} else {
+		if err := os.WriteFile(keysShareOutput, bundle, 0644); err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.ErrOrStderr(), "Wrote %s: %s for %s\n", keysShareOutput,
+			strings.Join(share.KeyNames(), ", "), strings.Join(keysShareTo, ", "))
+	}
+	return history.Record(history.Entry{Action: history.ActionKeyShare, Path: dir,
+		Detail: "keys=" + strings.Join(share.KeyNames(), ",") + " to=" + strings.Join(keysShareTo, ",")})
+}
+
+// keysToShare returns the keys named by envs, resolved through the [keys]
+// chain, or every private key in dir's .env.keys when envs is empty.
+func keysToShare(ctx context.Context, cfg *config.Config, dir string, envs []string) (map[string]string, error) {
+	shared := map[string]string{}
+	if len(envs) == 0 {
+		data, err := os.ReadFile(filepath.Join(dir, ".env.keys"))
+		if err != nil {
+			return nil, fmt.Errorf("nothing to share: %w", err)
+		}
+		entries, err := dotenv.Parse(data)
+		if err != nil {
+			return nil, err
+		}
+		for name, value := range dotenv.Map(entries) {
+			if strings.HasPrefix(name, keys.KeyName("")) && value != "" {
+				shared[name] = value
+			}
+		}
+		if len(shared) == 0 {
+			return nil, fmt.Errorf("nothing to share: %s holds no DOTENV_PRIVATE_KEY entries", filepath.Join(dir, ".env.keys"))
+		}
+		return shared, nil
+	}
+	resolver, err := keys.NewResolver(cfg.Keys.Resolution)
+	if err != nil {
+		return nil, err
+	}
+	for _, env := range envs {
+		req := keys.Request{KeyName: keys.KeyName(env), Dir: dir}
+		res, found, err := resolver.Resolve(ctx, req)
+		if err != nil {
+			return nil, fmt.Errorf("resolve %s: %w", req.KeyName, err)
+		}
+		if !found {
+			return nil, fmt.Errorf("no source in the key chain holds %s (see 'envdrift-agent keys whereis %s')", req.KeyName, env)
+		}
+		shared[res.KeyName] = res.Value
+	}
+	return shared, nil
+}
+
+// runKeysAccept decrypts a share and writes its keys.
+func runKeysAccept(cmd *cobra.Command, args []string) error {
+	dir, err := filepath.Abs(keysDir)
+	if err != nil {
+		return err
+	}
+	var data []byte
+	if len(args) == 0 || args[0] == "-" {
+		data, err = io.ReadAll(cmd.InOrStdin())
+	} else {
+		data, err = os.ReadFile(args[0])
+	}
+	if err != nil {
+		return err
+	}
+	identities := keysAcceptIdentities
+	if len(identities) == 0 {
+		identities = keyshare.DefaultIdentities()
+	}
+	ctx := context.Background()
+	share, err := keyshare.Open(ctx, data, identities)
+	if err != nil {
+		return err
+	}
+
+	w := cmd.OutOrStdout()
+	fmt.Fprintf(w, "Keys from %s (project %s, shared %s):\n", share.From, share.Project, share.Created.Local().Format(time.RFC822))
+	in := promptInput(cmd)
+	var written, kept []string
+	for _, name := range share.KeyNames() {
+		value := share.Keys[name]
+		local, found, err := keys.LocalKey(ctx, keys.SourceDotenvKeys, dir, name)
+		if err != nil {
+			return err
+		}
+		switch {
+		case found && local == value:
+			fmt.Fprintf(w, "  %s: already in .env.keys\n", name)
+			continue
+		case found && !keysAcceptForce &&
+			!askYesNo(in, w, fmt.Sprintf("  %s differs from the key in .env.keys; replace it?", name)):
+			fmt.Fprintf(w, "  %s: kept the existing key\n", name)
+			kept = append(kept, name)
+			continue
+		}
+		if err := keys.WriteDotenvKey(dir, name, value); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "  %s: written to %s\n", name, filepath.Join(dir, ".env.keys"))
+		written = append(written, name)
+	}
+	if len(written) > 0 {
+		if err := history.Record(history.Entry{Action: history.ActionKeyAccept, Path: dir,
+			Detail: "keys=" + strings.Join(written, ",") + " from=" + share.From}); err != nil {
+			return err
+		}
+	}
+	if len(kept) > 0 {
+		return fmt.Errorf("%d key(s) differ from the shared ones and were kept (pass --force to replace them)", len(kept))
+	}
+	return nil
+}
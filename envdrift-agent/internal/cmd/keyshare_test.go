@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// fakeAge puts an age stand-in on PATH that "encrypts" with base64 under the
+// armor lines and decrypts with any identity.
+func fakeAge(t *testing.T) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("needs a POSIX shell")
+	}
+	dir := t.TempDir()
+	script := `#!/bin/sh
+case "$1" in
+--encrypt) echo "-----BEGIN AGE ENCRYPTED FILE-----"; base64 | tr -d '\n'; echo; echo "-----END AGE ENCRYPTED FILE-----" ;;
+--decrypt) sed -n 2p | base64 -d ;;
+esac
+`
+	if err := os.WriteFile(filepath.Join(dir, "age"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func TestKeysShareAccept(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	t.Setenv("ENVDRIFT_HOME", filepath.Join(home, ".envdrift-home"))
+	fakeAge(t)
+	origDir, origTo, origOut, origID, origForce := keysDir, keysShareTo, keysShareOutput, keysAcceptIdentities, keysAcceptForce
+	t.Cleanup(func() {
+		keysDir, keysShareTo, keysShareOutput, keysAcceptIdentities, keysAcceptForce = origDir, origTo, origOut, origID, origForce
+	})
+
+	sender := filepath.Join(home, "alice", "api")
+	receiver := filepath.Join(home, "bob", "api")
+	for _, d := range []string{sender, receiver} {
+		if err := os.MkdirAll(d, 0o700); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(sender, ".env.keys"), []byte("DOTENV_PRIVATE_KEY_PRODUCTION=prod\nDOTENV_PRIVATE_KEY=dev\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(receiver, ".env.keys"), []byte("DOTENV_PRIVATE_KEY=mine\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	keysDir, keysShareOutput = sender, "-"
+	keysShareTo = nil
+	if err := runKeysShare(keysShareCmd, nil); err == nil || !strings.Contains(err.Error(), "--to") {
+		t.Fatalf("share without --to: err = %v", err)
+	}
+	keysShareTo = []string{"age1bob"}
+	var bundle bytes.Buffer
+	keysShareCmd.SetOut(&bundle)
+	t.Cleanup(func() { keysShareCmd.SetOut(nil); keysAcceptCmd.SetOut(nil); keysAcceptCmd.SetIn(nil) })
+	if err := runKeysShare(keysShareCmd, []string{"production"}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(bundle.String(), "-----BEGIN AGE ENCRYPTED FILE-----") || strings.Contains(bundle.String(), "prod") {
+		t.Fatalf("bundle = %q", bundle.String())
+	}
+	file := filepath.Join(home, "share.age")
+	keysShareOutput = file
+	keysShareCmd.SetErr(&bytes.Buffer{})
+	t.Cleanup(func() { keysShareCmd.SetErr(nil) })
+	if err := runKeysShare(keysShareCmd, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// The env-scoped share from stdin adds only the production key.
+	keysDir, keysAcceptIdentities = receiver, []string{filepath.Join(home, "id")}
+	var out bytes.Buffer
+	keysAcceptCmd.SetOut(&out)
+	keysAcceptCmd.SetIn(&bundle)
+	if err := runKeysAccept(keysAcceptCmd, nil); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(receiver, ".env.keys")); string(data) != "DOTENV_PRIVATE_KEY=mine\nDOTENV_PRIVATE_KEY_PRODUCTION=prod\n" {
+		t.Fatalf(".env.keys after accept = %q", data)
+	}
+
+	// The full share conflicts on DOTENV_PRIVATE_KEY: kept without --force.
+	if err := runKeysAccept(keysAcceptCmd, []string{file}); err == nil || !strings.Contains(err.Error(), "--force") {
+		t.Fatalf("conflicting accept: err = %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(receiver, ".env.keys")); !strings.Contains(string(data), "DOTENV_PRIVATE_KEY=mine") {
+		t.Fatalf("existing key replaced without --force: %q", data)
+	}
+	keysAcceptForce = true
+	if err := runKeysAccept(keysAcceptCmd, []string{file}); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(receiver, ".env.keys")); string(data) != "DOTENV_PRIVATE_KEY=dev\nDOTENV_PRIVATE_KEY_PRODUCTION=prod\n" {
+		t.Fatalf(".env.keys after forced accept = %q", data)
+	}
+	if !strings.Contains(out.String(), "DOTENV_PRIVATE_KEY_PRODUCTION: already in .env.keys") {
+		t.Errorf("accept output:\n%s", out.String())
+	}
+}
@@ -0,0 +1,230 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jainal09/envdrift-agent/internal/config"
+	"github.com/jainal09/envdrift-agent/internal/keysync"
+	"github.com/jainal09/envdrift-agent/internal/registry"
+)
+
+var keysSyncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Sync .env.keys between your devices through an end-to-end-encrypted store",
+	Long: `Merges the private keys in the .env.keys files of the registered projects and
+watched directories with an encrypted bundle in [keys] sync_store, so a new
+device can decrypt the env files it clones:
+
+  [keys]
+  sync_store = "~/Dropbox/envdrift"          # a folder a sync client replicates
+  sync_store = "s3://my-bucket/envdrift/keys" # via the aws CLI
+  sync_store = "https://cloud.example.com/remote.php/dav/files/me/envdrift/"  # WebDAV
+
+The bundle is sealed with the sync code from 'keys sync init', which never
+leaves your devices; WebDAV credentials come from ENVDRIFT_KEYSYNC_USER and
+ENVDRIFT_KEYSYNC_PASSWORD. Projects match across devices by their path under
+the home directory, and keys are written only where that directory exists.
+
+A key changed both here and on another device since the last sync is a
+conflict and left alone; --prefer local or --prefer remote settles it.`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runKeysSync,
+}
+
+var keysSyncInitCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Create the sync code on your first device",
+	Long: `Creates a random sync code for this device and prints it. Enter it on each
+other device with 'keys sync join'. Run again to show the code.`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runKeysSyncInit,
+}
+
+var keysSyncJoinCmd = &cobra.Command{
+	Use:          "join <code>",
+	Short:        "Join a key sync with the code from your first device",
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE:         runKeysSyncJoin,
+}
+
+// Flags for keys sync.
+var (
+	keysSyncStore  string
+	keysSyncPrefer string
+	keysSyncDryRun bool
+	keysSyncForce  bool
+)
+
+// init registers the keys sync commands with keysCmd.
+func init() {
+	keysSyncCmd.Flags().StringVar(&keysSyncStore, "store", "", "sync store location (overrides [keys] sync_store)")
+	keysSyncCmd.Flags().StringVar(&keysSyncPrefer, "prefer", "", "settle conflicts with the local or remote key")
+	keysSyncCmd.Flags().BoolVar(&keysSyncDryRun, "dry-run", false, "show what would change without writing anything")
+	keysSyncJoinCmd.Flags().BoolVar(&keysSyncForce, "force", false, "replace this device's existing sync code")
+	keysSyncCmd.AddCommand(keysSyncInitCmd, keysSyncJoinCmd)
+	keysCmd.AddCommand(keysSyncCmd)
+}
+
+// runKeysSync runs one sync and prints a line per key that changed.
+func runKeysSync(cmd *cobra.Command, args []string) error {
+	prefer := keysync.Prefer(keysSyncPrefer)
+	if prefer != keysync.PreferNone && prefer != keysync.PreferLocal && prefer != keysync.PreferRemote {
+		return fmt.Errorf("--prefer: want local or remote, not %q", keysSyncPrefer)
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	location := cfg.Keys.SyncStore
+	if keysSyncStore != "" {
+		location = keysSyncStore
+	}
+	store, err := keysync.OpenStore(location)
+	if err != nil {
+		return err
+	}
+	key, err := keysync.LoadKey(keysync.KeyPath())
+	if err != nil {
+		return err
+	}
+	projects, err := keySyncProjects(cfg)
+	if err != nil {
+		return err
+	}
+
+	s := &keysync.Syncer{
+		Store:     store,
+		Key:       key,
+		StatePath: keysync.DefaultStatePath(),
+		Prefer:    prefer,
+		DryRun:    keysSyncDryRun,
+	}
+	results, err := s.Sync(context.Background(), projects)
+	writeKeySyncResults(cmd.OutOrStdout(), store.String(), results, keysSyncDryRun)
+	if err != nil {
+		return err
+	}
+	var conflicts, failed int
+	for _, r := range results {
+		switch r.Outcome {
+		case keysync.Conflict:
+			conflicts++
+		case keysync.Failed:
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d key(s) could not be written", failed)
+	}
+	if conflicts > 0 {
+		return fmt.Errorf("%d key(s) changed on this and another device: rerun with --prefer local or --prefer remote", conflicts)
+	}
+	return nil
+}
+
+// writeKeySyncResults prints what changed and a summary. Key values are
+// never printed.
+func writeKeySyncResults(w io.Writer, location string, results []keysync.Result, dryRun bool) {
+	counts := map[keysync.Outcome]int{}
+	for _, r := range results {
+		counts[r.Outcome]++
+		switch r.Outcome {
+		case keysync.Unchanged:
+			continue
+		case keysync.Conflict:
+			fmt.Fprintf(w, "  conflict    %s in %s (also changed on %s)\n", r.KeyName, r.Dir, r.Device)
+		case keysync.Failed:
+			fmt.Fprintf(w, "  failed      %s in %s: %v\n", r.KeyName, r.Dir, r.Err)
+		default:
+			fmt.Fprintf(w, "  %-11s %s in %s\n", r.Outcome, r.KeyName, r.Dir)
+		}
+	}
+	prefix := "Synced with"
+	if dryRun {
+		prefix = "Dry run against"
+	}
+	fmt.Fprintf(w, "%s %s: %d uploaded, %d downloaded, %d unchanged, %d skipped, %d conflicts\n",
+		prefix, location, counts[keysync.Uploaded], counts[keysync.Downloaded], counts[keysync.Unchanged],
+		counts[keysync.Skipped], counts[keysync.Conflict])
+}
+
+// keySyncProjects returns the registered projects and watched directories,
+// the places whose .env.keys files are synced.
+func keySyncProjects(cfg *config.Config) ([]string, error) {
+	reg, err := registry.Load()
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	var dirs []string
+	for _, p := range append(reg.GetProjectPaths(), cfg.Directories.Watch...) {
+		p = filepath.Clean(p)
+		if info, err := os.Stat(p); err != nil || !info.IsDir() || seen[p] {
+			continue
+		}
+		seen[p] = true
+		dirs = append(dirs, p)
+	}
+	sort.Strings(dirs)
+	return dirs, nil
+}
+
+// runKeysSyncInit creates the sync code, or shows the existing one.
+func runKeysSyncInit(cmd *cobra.Command, args []string) error {
+	w := cmd.OutOrStdout()
+	path := keysync.KeyPath()
+	key, err := keysync.LoadKey(path)
+	switch {
+	case err == nil:
+		fmt.Fprintln(w, "This device already has a sync code:")
+	case errors.Is(err, keysync.ErrNoKey):
+		if key, err = keysync.NewKey(); err != nil {
+			return err
+		}
+		if err := keysync.SaveKey(path, key); err != nil {
+			return err
+		}
+		fmt.Fprintln(w, "Created a sync code:")
+	default:
+		return err
+	}
+	fmt.Fprintf(w, "\n  %s\n\n", keysync.FormatCode(key))
+	fmt.Fprintln(w, "On each other device run 'envdrift-agent keys sync join <code>'. Anyone with the")
+	fmt.Fprintln(w, "code and access to the sync store can read your keys: keep it in a password manager.")
+	return nil
+}
+
+// runKeysSyncJoin stores the code from another device.
+func runKeysSyncJoin(cmd *cobra.Command, args []string) error {
+	key, err := keysync.ParseCode(args[0])
+	if err != nil {
+		return err
+	}
+	path := keysync.KeyPath()
+	existing, err := keysync.LoadKey(path)
+	switch {
+	case err == nil && string(existing) == string(key):
+		fmt.Fprintln(cmd.OutOrStdout(), "This device already uses that sync code.")
+		return nil
+	case err == nil && !keysSyncForce:
+		return fmt.Errorf("this device already has a different sync code (%s): pass --force to replace it", path)
+	case err != nil && !errors.Is(err, keysync.ErrNoKey) && !keysSyncForce:
+		return err
+	}
+	if err := keysync.SaveKey(path, key); err != nil {
+		return err
+	}
+	fmt.Fprintln(cmd.OutOrStdout(), "Joined. Run 'envdrift-agent keys sync' to fetch your keys.")
+	return nil
+}
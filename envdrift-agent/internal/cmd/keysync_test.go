@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jainal09/envdrift-agent/internal/keysync"
+)
+
+func TestKeysSyncInitJoinAndSync(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	t.Setenv("ENVDRIFT_HOME", filepath.Join(home, ".envdrift-home"))
+	t.Cleanup(func() { keysSyncStore, keysSyncForce, keysSyncPrefer = "", false, "" })
+
+	var out bytes.Buffer
+	keysSyncCmd.SetOut(&out)
+	keysSyncInitCmd.SetOut(&out)
+	keysSyncJoinCmd.SetOut(&out)
+	t.Cleanup(func() { keysSyncCmd.SetOut(nil); keysSyncInitCmd.SetOut(nil); keysSyncJoinCmd.SetOut(nil) })
+
+	if err := runKeysSync(keysSyncCmd, nil); err == nil || !strings.Contains(err.Error(), "sync_store") {
+		t.Fatalf("sync without a store: err = %v", err)
+	}
+	keysSyncStore = filepath.Join(home, "Dropbox")
+	if err := runKeysSync(keysSyncCmd, nil); err == nil || !strings.Contains(err.Error(), "keys sync init") {
+		t.Fatalf("sync without a code: err = %v", err)
+	}
+
+	if err := runKeysSyncInit(keysSyncInitCmd, nil); err != nil {
+		t.Fatal(err)
+	}
+	key, err := keysync.LoadKey(keysync.KeyPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := keysync.FormatCode(key)
+	if !strings.Contains(out.String(), code) {
+		t.Fatalf("init did not print the code:\n%s", out.String())
+	}
+	if err := runKeysSyncJoin(keysSyncJoinCmd, []string{code}); err != nil {
+		t.Fatalf("joining with the same code: %v", err)
+	}
+	other, _ := keysync.NewKey()
+	if err := runKeysSyncJoin(keysSyncJoinCmd, []string{keysync.FormatCode(other)}); err == nil {
+		t.Fatal("join replaced a different code without --force")
+	}
+
+	project := filepath.Join(home, "projects", "api")
+	if err := os.MkdirAll(project, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(project, ".env.keys"), []byte("DOTENV_PRIVATE_KEY=abc\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	out.Reset()
+	if err := runKeysSync(keysSyncCmd, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "uploaded    DOTENV_PRIVATE_KEY in "+project) ||
+		!strings.Contains(out.String(), "1 uploaded, 0 downloaded") {
+		t.Errorf("sync output:\n%s", out.String())
+	}
+	if strings.Contains(out.String(), "abc") {
+		t.Error("sync printed a key value")
+	}
+}
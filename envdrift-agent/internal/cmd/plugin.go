@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jainal09/envdrift-agent/internal/keys"
+	"github.com/jainal09/envdrift-agent/internal/plugins"
+)
+
+var pluginCmd = &cobra.Command{
+	Use:   "plugin",
+	Short: "List, install and run plugins",
+	Long: `Plugins are executables in the plugins directory that add key sources,
+notifiers and commands without changing the agent. The agent runs a plugin
+with a request type as its argument and the request as JSON on stdin: it
+describes itself, receives the encryption events it subscribes to, answers
+key lookups for the [keys] source "plugin:<name>", and runs its commands
+through 'envdrift-agent plugin run'.`,
+}
+
+var pluginListCmd = &cobra.Command{
+	Use:          "list",
+	Short:        "List installed plugins and what they handle",
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runPluginList,
+}
+
+var pluginInstallCmd = &cobra.Command{
+	Use:   "install <path|https-url>",
+	Short: "Install a plugin executable",
+	Long: `Copies a plugin executable from a local path or an https:// URL into the
+plugins directory and checks that it answers describe; one that does not is
+not installed. --sha256 pins the content, e.g. of a plugin downloaded from a
+URL.`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE:         runPluginInstall,
+}
+
+var pluginRunCmd = &cobra.Command{
+	Use:          "run <plugin> <command> [args...]",
+	Short:        "Run a plugin's command",
+	Args:         cobra.MinimumNArgs(2),
+	SilenceUsage: true,
+	RunE:         runPluginRun,
+}
+
+// Flags for plugin install.
+var (
+	pluginInstallName   string
+	pluginInstallSHA256 string
+	pluginInstallForce  bool
+)
+
+// init registers the plugin command group with rootCmd.
+func init() {
+	pluginInstallCmd.Flags().StringVar(&pluginInstallName, "name", "", "install under this name (default: the file name)")
+	pluginInstallCmd.Flags().StringVar(&pluginInstallSHA256, "sha256", "", "install only if the plugin's SHA-256 (hex) is this")
+	pluginInstallCmd.Flags().BoolVar(&pluginInstallForce, "force", false, "replace an installed plugin of the same name")
+	// Everything after the command belongs to the plugin.
+	pluginRunCmd.Flags().SetInterspersed(false)
+	pluginCmd.AddCommand(pluginListCmd, pluginInstallCmd, pluginRunCmd)
+	rootCmd.AddCommand(pluginCmd)
+}
+
+// runPluginList prints each plugin with its manifest, or why it is unusable.
+func runPluginList(cmd *cobra.Command, _ []string) error {
+	list, err := plugins.List(context.Background())
+	if err != nil {
+		return err
+	}
+	w := cmd.OutOrStdout()
+	if len(list) == 0 {
+		fmt.Fprintf(w, "No plugins installed in %s\n", plugins.Dir())
+		return nil
+	}
+	for _, p := range list {
+		if p.Err != nil {
+			fmt.Fprintf(w, "%s  BROKEN: %v\n", p.Name, p.Err)
+			continue
+		}
+		fmt.Fprintf(w, "%s %s", p.Name, p.Manifest.Version)
+		if p.Manifest.Description != "" {
+			fmt.Fprintf(w, "  %s", p.Manifest.Description)
+		}
+		fmt.Fprintln(w)
+		writePluginCapabilities(w, p)
+	}
+	return nil
+}
+
+// writePluginCapabilities prints what a plugin handles, one line each.
+func writePluginCapabilities(w io.Writer, p plugins.Plugin) {
+	if len(p.Manifest.Events) > 0 {
+		fmt.Fprintf(w, "  events:     %s\n", strings.Join(p.Manifest.Events, ", "))
+	}
+	if p.Manifest.KeySource {
+		fmt.Fprintf(w, "  key source: %s%s\n", keys.SourcePlugin, p.Name)
+	}
+	if len(p.Manifest.Commands) > 0 {
+		fmt.Fprintf(w, "  commands:   %s\n", strings.Join(p.Manifest.Commands, ", "))
+	}
+}
+
+// runPluginInstall installs args[0] and prints what it handles.
+func runPluginInstall(cmd *cobra.Command, args []string) error {
+	p, err := plugins.Install(context.Background(), args[0], plugins.InstallOptions{
+		Name: pluginInstallName, SHA256: pluginInstallSHA256, Overwrite: pluginInstallForce})
+	if err != nil {
+		return err
+	}
+	w := cmd.OutOrStdout()
+	fmt.Fprintf(w, "Installed %s %s to %s\n", p.Name, p.Manifest.Version, p.Path)
+	writePluginCapabilities(w, p)
+	if len(p.Manifest.Events) > 0 {
+		fmt.Fprintln(w, "Restart the agent for it to receive events.")
+	}
+	return nil
+}
+
+// runPluginRun runs a plugin command attached to the terminal.
+func runPluginRun(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+	p, err := plugins.Find(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	return p.Run(ctx, args[1], args[2:], os.Stdin, cmd.OutOrStdout(), cmd.ErrOrStderr())
+}
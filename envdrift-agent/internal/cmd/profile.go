@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jainal09/envdrift-agent/internal/config"
+)
+
+var profileCmd = &cobra.Command{
+	Use:   "profile",
+	Short: "Switch between [profiles.<name>] configurations",
+	Long: `Profiles are [profiles.<name>] tables in guardian.toml (e.g. work and
+personal), each with its own watch roots, vault sync and notification settings.
+One is active when guardian.profile pins it or, otherwise, when its hosts list
+names this machine. The running agent picks a change up on its next start.`,
+}
+
+var profileListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List profiles and show which one is active",
+	Args:  cobra.NoArgs,
+	RunE:  runProfileList,
+}
+
+var profileUseCmd = &cobra.Command{
+	Use:          "use <name>",
+	Short:        "Pin a profile as the active one",
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE:         runProfileUse,
+}
+
+var profileClearCmd = &cobra.Command{
+	Use:          "clear",
+	Short:        "Unpin the profile so hosts lists select it again",
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runProfileClear,
+}
+
+// init registers the profile command group with rootCmd.
+func init() {
+	profileCmd.AddCommand(profileListCmd, profileUseCmd, profileClearCmd)
+	rootCmd.AddCommand(profileCmd)
+}
+
+// runProfileList prints every profile, marking the active one.
+func runProfileList(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	w := cmd.OutOrStdout()
+	if len(cfg.Profiles) == 0 {
+		fmt.Fprintf(w, "No profiles; add [profiles.<name>] tables to %s\n", config.ConfigPath())
+		return nil
+	}
+	active, reason := cfg.ProfileName()
+	names := make([]string, 0, len(cfg.Profiles))
+	for name := range cfg.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		p := cfg.Profiles[name]
+		marker := " "
+		note := ""
+		if name == active {
+			marker, note = "*", " ("+reason+")"
+		}
+		fmt.Fprintf(w, "%s %s%s  watch=%v hosts=%v\n", marker, name, note, p.Watch, p.Hosts)
+	}
+	return nil
+}
+
+// runProfileUse pins the named profile.
+func runProfileUse(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	name := args[0]
+	if _, ok := cfg.Profiles[name]; !ok {
+		return fmt.Errorf("no profile %q in %s", name, config.ConfigPath())
+	}
+	if err := config.Set("guardian.profile", strconv.Quote(name)); err != nil {
+		return err
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Active profile: %s (restart the agent to apply)\n", name)
+	return nil
+}
+
+// runProfileClear removes the pin.
+func runProfileClear(cmd *cobra.Command, args []string) error {
+	if err := config.Set("guardian.profile", `""`); err != nil {
+		return err
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	w := cmd.OutOrStdout()
+	if name, _ := cfg.ProfileName(); name != "" {
+		fmt.Fprintf(w, "Profile unpinned; %s is selected by host\n", name)
+	} else {
+		fmt.Fprintln(w, "Profile unpinned; no profile matches this host")
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jainal09/envdrift-agent/internal/config"
+)
+
+func TestProfileUseAndClear(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	if err := os.MkdirAll(filepath.Dir(config.ConfigPath()), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	toml := "[profiles.work]\nwatch = [\"~/work\"]\n\n[profiles.personal]\nnotify = false\n"
+	if err := os.WriteFile(config.ConfigPath(), []byte(toml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	for _, c := range []*cobra.Command{profileListCmd, profileUseCmd, profileClearCmd} {
+		c.SetOut(&out)
+	}
+	t.Cleanup(func() {
+		for _, c := range []*cobra.Command{profileListCmd, profileUseCmd, profileClearCmd} {
+			c.SetOut(nil)
+		}
+	})
+
+	if err := runProfileUse(profileUseCmd, []string{"nope"}); err == nil {
+		t.Error("use of an unknown profile succeeded")
+	}
+	if err := runProfileUse(profileUseCmd, []string{"work"}); err != nil {
+		t.Fatal(err)
+	}
+	out.Reset()
+	if err := runProfileList(profileListCmd, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "* work (pinned)") || !strings.Contains(out.String(), "  personal") {
+		t.Errorf("list = %q", out.String())
+	}
+
+	if err := runProfileClear(profileClearCmd, nil); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Guardian.Profile != "" || len(cfg.Profiles) != 2 {
+		t.Errorf("after clear: pin=%q profiles=%v", cfg.Guardian.Profile, cfg.Profiles)
+	}
+}
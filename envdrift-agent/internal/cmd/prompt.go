@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// stdinIsTerminal reports whether a prompt can be answered interactively;
+// tests replace it.
+var stdinIsTerminal = func() bool {
+	fi, err := os.Stdin.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// promptInput returns a reader over the command's stdin when a user can
+// answer prompts, or nil (scripts, CI).
+func promptInput(cmd *cobra.Command) *bufio.Reader {
+	if !stdinIsTerminal() {
+		return nil
+	}
+	return bufio.NewReader(cmd.InOrStdin())
+}
+
+// askYesNo asks question on out and reports whether the answer was yes. A
+// nil in (nobody to answer) is a no.
+func askYesNo(in *bufio.Reader, out io.Writer, question string) bool {
+	if in == nil {
+		return false
+	}
+	fmt.Fprintf(out, "%s [y/N]: ", question)
+	answer, _ := in.ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// askCount asks for the number of files as confirmation, which a reflexive
+// "y" cannot give, and reports whether it was typed. A nil in is a no.
+func askCount(in *bufio.Reader, out io.Writer, n int) bool {
+	if in == nil {
+		return false
+	}
+	fmt.Fprintf(out, "This leaves secrets in plaintext on disk. Type the number of files (%d) to decrypt them: ", n)
+	answer, _ := in.ReadString('\n')
+	return strings.TrimSpace(answer) == strconv.Itoa(n)
+}
@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jainal09/envdrift-agent/internal/config"
+	"github.com/jainal09/envdrift-agent/internal/history"
+	"github.com/jainal09/envdrift-agent/internal/retention"
+)
+
+var purgeCmd = &cobra.Command{
+	Use:   "purge [--older-than <duration> | --all] [--history] [--logs]",
+	Short: "Drop old history entries, journal events and rotated logs",
+	Long: `Applies the retention the agent applies hourly — history entries older than
+[history] keep, journal events and rotated agent logs older than [logs] keep —
+right away:
+
+  envdrift-agent purge                          # the configured retention
+  envdrift-agent purge --older-than 7d          # anything older than 7 days
+  envdrift-agent purge --all --history --yes    # the whole history log
+
+--history or --logs limits it to that part. Dropping records the configured
+retention keeps asks for confirmation (--yes skips it) and is itself recorded
+in the history log. For pre-encryption backups see 'backups purge'.`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runPurge,
+}
+
+// Flags for purge.
+var (
+	purgeOlderThan string
+	purgeAll       bool
+	purgeHistory   bool
+	purgeLogs      bool
+	purgeYes       bool
+)
+
+// init registers the purge command with rootCmd.
+func init() {
+	purgeCmd.Flags().StringVar(&purgeOlderThan, "older-than", "", "drop records older than this (e.g. 7d) instead of the configured keep")
+	purgeCmd.Flags().BoolVar(&purgeAll, "all", false, "drop every record")
+	purgeCmd.Flags().BoolVar(&purgeHistory, "history", false, "only the history log")
+	purgeCmd.Flags().BoolVar(&purgeLogs, "logs", false, "only the journal and rotated agent logs")
+	purgeCmd.Flags().BoolVar(&purgeYes, "yes", false, "skip the confirmation prompt")
+	rootCmd.AddCommand(purgeCmd)
+}
+
+// runPurge drops the selected records and reports how many went.
+func runPurge(cmd *cobra.Command, args []string) error {
+	if purgeAll && purgeOlderThan != "" {
+		return errors.New("--all drops every record: leave out --older-than")
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	c := retention.Policy(cfg, now)
+	what := "the records past their retention"
+	switch {
+	case purgeAll:
+		c = retention.Cutoff{History: now, Logs: now}
+		what = "every record"
+	case purgeOlderThan != "":
+		d, err := config.ParseDuration(purgeOlderThan)
+		if err != nil {
+			return fmt.Errorf("--older-than: %w", err)
+		}
+		if d <= 0 {
+			return errors.New("--older-than must be positive")
+		}
+		c = retention.Cutoff{History: now.Add(-d), Logs: now.Add(-d)}
+		what = "the records older than " + purgeOlderThan
+	}
+	if purgeHistory || purgeLogs {
+		if !purgeHistory {
+			c.History = time.Time{}
+		}
+		if !purgeLogs {
+			c.Logs = time.Time{}
+		}
+	}
+
+	w := cmd.OutOrStdout()
+	explicit := purgeAll || purgeOlderThan != ""
+	if explicit && !purgeYes && !askYesNo(promptInput(cmd), w, fmt.Sprintf("Drop %s (%s)?", what, purgeParts(c))) {
+		return errors.New("purge cancelled (--yes skips the prompt)")
+	}
+	r, err := retention.Prune(c)
+	fmt.Fprintf(w, "Dropped %d history entries, %d journal events and %d rotated log files\n",
+		r.History, r.Journal, r.LogFiles)
+	if explicit && r.History > 0 {
+		// The audit trail shows it was cut short.
+		if rerr := history.Record(history.Entry{Action: history.ActionPurge, Path: history.Path(),
+			Detail: "before=" + c.History.UTC().Format(time.RFC3339)}); rerr != nil {
+			err = errors.Join(err, rerr)
+		}
+	}
+	return err
+}
+
+// purgeParts names the parts c applies to.
+func purgeParts(c retention.Cutoff) string {
+	switch {
+	case c.History.IsZero():
+		return "journal and rotated logs"
+	case c.Logs.IsZero():
+		return "history log"
+	}
+	return "history log, journal and rotated logs"
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jainal09/envdrift-agent/internal/history"
+)
+
+func TestPurge(t *testing.T) {
+	t.Setenv("ENVDRIFT_HOME", t.TempDir())
+	orig := stdinIsTerminal
+	stdinIsTerminal = func() bool { return false }
+	t.Cleanup(func() {
+		stdinIsTerminal = orig
+		purgeOlderThan, purgeAll, purgeHistory, purgeLogs, purgeYes = "", false, false, false, false
+	})
+	now := time.Now()
+	for _, age := range []time.Duration{200 * 24 * time.Hour, 10 * 24 * time.Hour, time.Minute} {
+		if err := history.Record(history.Entry{Time: now.Add(-age), Action: history.ActionReveal, Path: "/p/.env"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var out bytes.Buffer
+	purgeCmd.SetOut(&out)
+	t.Cleanup(func() { purgeCmd.SetOut(nil) })
+
+	// The configured retention (90d) needs no confirmation.
+	if err := runPurge(purgeCmd, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "Dropped 1 history entries, 0 journal events") {
+		t.Errorf("output = %q", out.String())
+	}
+
+	// A stricter cutoff does, and nobody is there to give it.
+	purgeOlderThan, purgeHistory = "7d", true
+	if err := runPurge(purgeCmd, nil); err == nil || !strings.Contains(err.Error(), "cancelled") {
+		t.Fatalf("unconfirmed purge = %v", err)
+	}
+	purgeYes = true
+	if err := runPurge(purgeCmd, nil); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := history.Read()
+	if err != nil || len(entries) != 2 || entries[1].Action != history.ActionPurge || !strings.HasPrefix(entries[1].Detail, "before=") {
+		t.Fatalf("history after purge = %+v, %v; want the recent entry and the purge", entries, err)
+	}
+
+	purgeAll = true
+	if err := runPurge(purgeCmd, nil); err == nil || !strings.Contains(err.Error(), "--older-than") {
+		t.Errorf("--all with --older-than = %v", err)
+	}
+	purgeOlderThan = ""
+	if err := runPurge(purgeCmd, nil); err != nil {
+		t.Fatal(err)
+	}
+	if entries, _ := history.Read(); len(entries) != 1 || entries[0].Action != history.ActionPurge {
+		t.Errorf("history after --all = %+v; want the purge alone", entries)
+	}
+}
@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jainal09/envdrift-agent/internal/history"
+	"github.com/jainal09/envdrift-agent/internal/recipients"
+)
+
+var recipientsCmd = &cobra.Command{
+	Use:   "recipients",
+	Short: "Show and change who an encrypted env file is encrypted for",
+	Long: `SOPS files can be encrypted for several recipients — age keys, PGP
+fingerprints, AWS/GCP KMS or Azure Key Vault keys — listed in their metadata.
+add and remove re-encrypt the file with 'sops --rotate', so a removed recipient
+cannot read later versions. A dotenvx file has one keypair, shown as its
+public key; share the private key to grant access instead.
+
+Recipients can be named (--name alice) for list; names are kept in
+recipients.json in the config directory. Changes are recorded in the history log.`,
+}
+
+var recipientsListCmd = &cobra.Command{
+	Use:          "list <file>",
+	Short:        "List the recipients a file is encrypted for",
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE:         runRecipientsList,
+}
+
+var recipientsAddCmd = &cobra.Command{
+	Use:          "add <file> <recipient>",
+	Short:        "Encrypt a SOPS file for another recipient",
+	Args:         cobra.ExactArgs(2),
+	SilenceUsage: true,
+	RunE:         runRecipientsAdd,
+}
+
+var recipientsRemoveCmd = &cobra.Command{
+	Use:          "remove <file> <recipient>",
+	Short:        "Stop encrypting a SOPS file for a recipient",
+	Args:         cobra.ExactArgs(2),
+	SilenceUsage: true,
+	RunE:         runRecipientsRemove,
+}
+
+var (
+	recipientType string
+	recipientName string
+)
+
+// addRecipient and removeRecipient re-encrypt the file; tests replace them.
+var (
+	addRecipient    = recipients.Add
+	removeRecipient = recipients.Remove
+)
+
+// init registers the recipients command group with rootCmd.
+func init() {
+	for _, c := range []*cobra.Command{recipientsAddCmd, recipientsRemoveCmd} {
+		c.Flags().StringVar(&recipientType, "type", "", "recipient type: age, pgp, kms, gcp_kms or azure_kv (default: detected)")
+	}
+	recipientsAddCmd.Flags().StringVar(&recipientName, "name", "", "name to show for the recipient in list")
+	recipientsCmd.AddCommand(recipientsListCmd, recipientsAddCmd, recipientsRemoveCmd)
+	rootCmd.AddCommand(recipientsCmd)
+}
+
+// runRecipientsList prints the file's recipients with their names.
+func runRecipientsList(cmd *cobra.Command, args []string) error {
+	format, list, err := recipients.Read(args[0])
+	if err != nil {
+		return err
+	}
+	if format == "" {
+		return fmt.Errorf("%s is not encrypted", args[0])
+	}
+	names, err := recipients.Names()
+	if err != nil {
+		return err
+	}
+	w := cmd.OutOrStdout()
+	for _, r := range list {
+		line := fmt.Sprintf("%-9s %s", r.Type, r.ID)
+		if name := names[r.ID]; name != "" {
+			line += "  (" + name + ")"
+		}
+		fmt.Fprintln(w, line)
+	}
+	if format == recipients.FormatDotenvx {
+		fmt.Fprintln(w, "dotenvx files have a single keypair; anyone with its private key can decrypt")
+	}
+	return nil
+}
+
+// runRecipientsAdd adds a recipient and names it when --name is given.
+func runRecipientsAdd(cmd *cobra.Command, args []string) error {
+	r, err := recipientArg(args[1])
+	if err != nil {
+		return err
+	}
+	if err := changeRecipients(args[0], r, history.ActionRecipientAdd, addRecipient); err != nil {
+		return err
+	}
+	if recipientName != "" {
+		if err := recipients.SetName(r.ID, recipientName); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "%s is now encrypted for %s %s\n", args[0], r.Type, r.ID)
+	return nil
+}
+
+// runRecipientsRemove removes a recipient.
+func runRecipientsRemove(cmd *cobra.Command, args []string) error {
+	r, err := recipientArg(args[1])
+	if err != nil {
+		return err
+	}
+	if err := changeRecipients(args[0], r, history.ActionRecipientRemove, removeRecipient); err != nil {
+		return err
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "%s is no longer encrypted for %s %s\n", args[0], r.Type, r.ID)
+	return nil
+}
+
+// recipientArg builds the recipient from its ID and --type, detecting the
+// type when the flag is unset.
+func recipientArg(id string) (recipients.Recipient, error) {
+	typ := recipientType
+	if typ == "" {
+		var err error
+		if typ, err = recipients.DetectType(id); err != nil {
+			return recipients.Recipient{}, err
+		}
+	}
+	return recipients.Recipient{Type: typ, ID: id}, nil
+}
+
+// changeRecipients applies change to the file and records it in history.
+func changeRecipients(file string, r recipients.Recipient, action string,
+	change func(context.Context, string, recipients.Recipient) error) error {
+	path, err := filepath.Abs(file)
+	if err != nil {
+		return err
+	}
+	if err := change(context.Background(), path, r); err != nil {
+		return err
+	}
+	return history.Record(history.Entry{Action: action, Path: path, Detail: r.Type + ":" + r.ID})
+}
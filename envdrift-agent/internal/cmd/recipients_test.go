@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jainal09/envdrift-agent/internal/history"
+	"github.com/jainal09/envdrift-agent/internal/recipients"
+)
+
+func TestRecipientsAddList(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	path := filepath.Join(home, ".env")
+	sops := "A=ENC[AES256_GCM,data:x,type:str]\nsops_age__list_0__map_recipient=age1alice\nsops_version=3.9.0\n"
+	if err := os.WriteFile(path, []byte(sops), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var added []recipients.Recipient
+	prevAdd := addRecipient
+	addRecipient = func(_ context.Context, _ string, r recipients.Recipient) error {
+		added = append(added, r)
+		return nil
+	}
+	var out bytes.Buffer
+	recipientsAddCmd.SetOut(&out)
+	recipientsListCmd.SetOut(&out)
+	t.Cleanup(func() {
+		addRecipient = prevAdd
+		recipientName, recipientType = "", ""
+		recipientsAddCmd.SetOut(nil)
+		recipientsListCmd.SetOut(nil)
+	})
+
+	recipientName = "alice"
+	if err := runRecipientsAdd(recipientsAddCmd, []string{path, "age1alice"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(added) != 1 || added[0] != (recipients.Recipient{Type: recipients.TypeAge, ID: "age1alice"}) {
+		t.Errorf("added = %v", added)
+	}
+	entries, err := history.Read()
+	if err != nil || len(entries) != 1 || entries[0].Action != history.ActionRecipientAdd {
+		t.Errorf("history = %v, %v", entries, err)
+	}
+
+	out.Reset()
+	if err := runRecipientsList(recipientsListCmd, []string{path}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "age1alice  (alice)") {
+		t.Errorf("list = %q", out.String())
+	}
+
+	if err := runRecipientsAdd(recipientsAddCmd, []string{path, "alice"}); err == nil {
+		t.Error("add of an untyped, undetectable recipient succeeded")
+	}
+}
@@ -0,0 +1,227 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jainal09/envdrift-agent/internal/config"
+)
+
+var remoteCmd = &cobra.Command{
+	Use:   "remote",
+	Short: "Query or drive the agent on remote dev boxes over ssh",
+	Long: `Runs envdrift-agent on other machines through the system ssh client, for .env
+files edited on a remote host (VS Code Remote, JetBrains Gateway, plain ssh).
+Hosts are ssh destinations, so aliases, keys, jump hosts and agent forwarding
+all come from ~/.ssh/config; without hosts on the command line the commands
+use [remote] hosts:
+
+  [remote]
+  hosts = ["devbox", "me@gpu-01"]
+  agent = "envdrift-agent"   # or a path such as ~/.local/bin/envdrift-agent
+
+The remote host needs a POSIX shell and envdrift-agent on its PATH,
+~/.local/bin or /usr/local/bin (or at [remote] agent).`,
+}
+
+var remoteStatusCmd = &cobra.Command{
+	Use:   "status [host...]",
+	Short: "Show whether the agent is installed and running on each host",
+	Long: `Runs 'envdrift-agent status' on each host in turn and prints its report under
+the host's name. The exit status is non-zero when a host could not be reached
+or its status failed.`,
+	SilenceUsage: true,
+	RunE:         runRemoteStatus,
+}
+
+var remoteRunOnceCmd = &cobra.Command{
+	Use:   "run-once [host...]",
+	Short: "Encrypt every plaintext env file of the registered projects on each host",
+	Long: `Runs 'envdrift-agent run-once' on each host in turn, encrypting the plaintext
+env files of the projects registered there without waiting for them to go
+idle. --quiet prints failures only.`,
+	SilenceUsage: true,
+	RunE:         runRemoteRunOnce,
+}
+
+var remoteExecCmd = &cobra.Command{
+	Use:   "exec <host> -- <command> [args...]",
+	Short: "Run any envdrift-agent command on a host",
+	Long: `Runs one envdrift-agent command on host with this terminal's input and output
+and exits with its status, e.g. to ask the agent there to recheck a project:
+
+  envdrift-agent remote exec devbox -- hook recheck /home/me/code/api`,
+	Args:         cobra.MinimumNArgs(2),
+	SilenceUsage: true,
+	RunE:         runRemoteExec,
+}
+
+// Flags for remote.
+var (
+	remoteSSHOptions []string
+	remoteAgent      string
+	remoteQuiet      bool
+)
+
+// sshBinary is the ssh client remote runs; tests replace it.
+var sshBinary = "ssh"
+
+// init registers the remote command group with rootCmd.
+func init() {
+	remoteCmd.PersistentFlags().StringArrayVarP(&remoteSSHOptions, "ssh-option", "o", nil,
+		"ssh -o option, e.g. -o ConnectTimeout=5 (repeatable)")
+	remoteCmd.PersistentFlags().StringVar(&remoteAgent, "agent", "", "envdrift-agent command on the host (overrides [remote] agent)")
+	remoteRunOnceCmd.Flags().BoolVarP(&remoteQuiet, "quiet", "q", false, "print failures only")
+	remoteCmd.AddCommand(remoteStatusCmd, remoteRunOnceCmd, remoteExecCmd)
+	rootCmd.AddCommand(remoteCmd)
+}
+
+// runRemoteStatus runs status on each host.
+func runRemoteStatus(cmd *cobra.Command, args []string) error {
+	return runOnHosts(cmd, args, []string{"status"})
+}
+
+// runRemoteRunOnce runs run-once on each host.
+func runRemoteRunOnce(cmd *cobra.Command, args []string) error {
+	agentArgs := []string{"run-once"}
+	if remoteQuiet {
+		agentArgs = append(agentArgs, "--quiet")
+	}
+	return runOnHosts(cmd, args, agentArgs)
+}
+
+// runRemoteExec runs the command after -- on one host, attached to this
+// process's stdio, and exits with its status as exec does.
+func runRemoteExec(cmd *cobra.Command, args []string) error {
+	if cmd.ArgsLenAtDash() != 1 {
+		return fmt.Errorf("usage: envdrift-agent remote exec <host> -- <command> [args...]")
+	}
+	host := args[0]
+	if err := config.ValidateRemoteHost(host); err != nil {
+		return err
+	}
+	agent, err := remoteAgentCommand()
+	if err != nil {
+		return err
+	}
+	code, err := runChild(append([]string{sshBinary}, sshArgs(host, agent, args[1:], true)...), os.Environ())
+	if err != nil {
+		return err
+	}
+	if code != 0 {
+		osExit(code)
+	}
+	return nil
+}
+
+// runOnHosts runs the agent with agentArgs on each host, or each of [remote]
+// hosts when none is given, printing every host's output under its name. A
+// host that fails does not stop the others; the failures are summed up in
+// the returned error.
+func runOnHosts(cmd *cobra.Command, hosts, agentArgs []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	if len(hosts) == 0 {
+		hosts = cfg.Remote.Hosts
+	}
+	if len(hosts) == 0 {
+		return fmt.Errorf("name a host, or list them in [remote] hosts (envdrift-agent config set remote.hosts '[\"devbox\"]')")
+	}
+	for _, h := range hosts {
+		if err := config.ValidateRemoteHost(h); err != nil {
+			return err
+		}
+	}
+	agent := cfg.Remote.Agent
+	if remoteAgent != "" {
+		agent = remoteAgent
+	}
+
+	w := cmd.OutOrStdout()
+	var failed []string
+	for i, host := range hosts {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "== %s ==\n", host)
+		if err := runSSH(w, cmd.ErrOrStderr(), host, agent, agentArgs); err != nil {
+			fmt.Fprintf(cmd.ErrOrStderr(), "%s: %v\n", host, err)
+			failed = append(failed, host)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%s failed on %d of %d hosts: %s", agentArgs[0], len(failed), len(hosts), strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// runSSH runs the agent on host without a terminal, copying its output to
+// stdout and stderr. ssh's own exit status 255 means the host was not
+// reached; anything else non-zero is the agent's.
+func runSSH(stdout, stderr io.Writer, host, agent string, agentArgs []string) error {
+	c := exec.Command(sshBinary, sshArgs(host, agent, agentArgs, false)...)
+	c.Stdout = stdout
+	c.Stderr = stderr
+	err := c.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		if exitErr.ExitCode() == 255 {
+			return fmt.Errorf("ssh could not connect (exit 255)")
+		}
+		return fmt.Errorf("envdrift-agent exited with status %d", exitErr.ExitCode())
+	}
+	return err
+}
+
+// remoteAgentCommand returns --agent, or [remote] agent.
+func remoteAgentCommand() (string, error) {
+	if remoteAgent != "" {
+		return remoteAgent, nil
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return "", err
+	}
+	return cfg.Remote.Agent, nil
+}
+
+// sshArgs returns the ssh arguments running agent with agentArgs on host:
+// the -o options, -t for a terminal when tty is set (and this one is a
+// terminal) or -T otherwise, "--" so host can never be read as an option,
+// and the remote command line.
+func sshArgs(host, agent string, agentArgs []string, tty bool) []string {
+	var argv []string
+	if tty && stdinIsTerminal() {
+		argv = append(argv, "-t")
+	} else {
+		argv = append(argv, "-T")
+	}
+	for _, o := range remoteSSHOptions {
+		argv = append(argv, "-o", o)
+	}
+	return append(argv, "--", host, remoteCommandLine(agent, agentArgs))
+}
+
+// remoteCommandLine returns the command line ssh hands the remote user's
+// shell. Non-interactive ssh sessions often lack the directories pip and
+// package managers install to, so they are added to PATH; every argument is
+// single-quoted, and a leading ~/ in agent is left for the remote $HOME.
+func remoteCommandLine(agent string, agentArgs []string) string {
+	bin := shellQuote(agent)
+	if rest, ok := strings.CutPrefix(agent, "~/"); ok {
+		bin = `"$HOME"/` + shellQuote(rest)
+	}
+	words := []string{`PATH="$PATH:$HOME/.local/bin:/usr/local/bin"`, "exec", bin}
+	for _, a := range agentArgs {
+		words = append(words, shellQuote(a))
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/jainal09/envdrift-agent/internal/config"
+)
+
+// fakeSSH installs an ssh stand-in that runs the remote command line with
+// sh, as sshd would, except for host "down", which it cannot reach. The
+// agent on the "remote" host prints its arguments.
+func fakeSSH(t *testing.T) (agent string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("needs a POSIX shell")
+	}
+	dir := t.TempDir()
+	ssh := filepath.Join(dir, "ssh")
+	script := `#!/bin/sh
+while [ "$1" != "--" ]; do shift; done
+shift
+[ "$1" = down ] && { echo "ssh: connect to host down: Connection refused" >&2; exit 255; }
+echo "host=$1"
+exec sh -c "$2"
+`
+	agent = filepath.Join(dir, "my agent")
+	for path, body := range map[string]string{
+		ssh:   script,
+		agent: "#!/bin/sh\nfor a in \"$@\"; do echo \"arg=$a\"; done\n",
+	} {
+		if err := os.WriteFile(path, []byte(body), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	origSSH, origAgent, origOpts := sshBinary, remoteAgent, remoteSSHOptions
+	t.Cleanup(func() { sshBinary, remoteAgent, remoteSSHOptions = origSSH, origAgent, origOpts })
+	sshBinary = ssh
+	remoteAgent = agent
+	remoteSSHOptions = nil
+	return agent
+}
+
+func TestRemoteStatus(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	fakeSSH(t)
+
+	var out, errOut bytes.Buffer
+	remoteStatusCmd.SetOut(&out)
+	remoteStatusCmd.SetErr(&errOut)
+	t.Cleanup(func() { remoteStatusCmd.SetOut(nil); remoteStatusCmd.SetErr(nil) })
+
+	err := runRemoteStatus(remoteStatusCmd, []string{"devbox", "down"})
+	if err == nil || !strings.Contains(err.Error(), "status failed on 1 of 2 hosts: down") {
+		t.Errorf("err = %v", err)
+	}
+	if want := "== devbox ==\nhost=devbox\narg=status\n\n== down ==\n"; out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+	if !strings.Contains(errOut.String(), "down: ssh could not connect") {
+		t.Errorf("stderr = %q", errOut.String())
+	}
+
+	if err := runRemoteStatus(remoteStatusCmd, nil); err == nil || !strings.Contains(err.Error(), "[remote] hosts") {
+		t.Errorf("no hosts: err = %v", err)
+	}
+	if err := runRemoteStatus(remoteStatusCmd, []string{"-oProxyCommand=touch pwned"}); err == nil {
+		t.Error("a host starting with '-' was accepted")
+	}
+}
+
+// TestRemoteCommandLineQuoting runs arguments full of shell syntax through
+// the remote shell: each must arrive as one unchanged argument.
+func TestRemoteCommandLineQuoting(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	fakeSSH(t)
+	if err := config.Set("remote.hosts", `["devbox"]`); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	remoteRunOnceCmd.SetOut(&out)
+	t.Cleanup(func() { remoteRunOnceCmd.SetOut(nil) })
+	hostile := []string{"it's", "$(touch pwned)", "a b;c", "`id`"}
+	if err := runOnHosts(remoteRunOnceCmd, nil, append([]string{"run-once"}, hostile...)); err != nil {
+		t.Fatal(err)
+	}
+	want := "== devbox ==\nhost=devbox\narg=run-once\n"
+	for _, a := range hostile {
+		want += "arg=" + a + "\n"
+	}
+	if out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+	if _, err := os.Stat("pwned"); err == nil {
+		t.Error("a remote argument ran as a command")
+	}
+
+	if got := remoteCommandLine("~/.local/bin/envdrift-agent", []string{"status"}); !strings.Contains(got, `exec "$HOME"/'.local/bin/envdrift-agent' 'status'`) {
+		t.Errorf("remoteCommandLine(~/...) = %s", got)
+	}
+}
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jainal09/envdrift-agent/internal/history"
+)
+
+func TestReport(t *testing.T) {
+	t.Setenv("ENVDRIFT_HOME", t.TempDir())
+	orig := [3]string{reportFormat, reportPeriod, reportOutput}
+	t.Cleanup(func() { reportFormat, reportPeriod, reportOutput = orig[0], orig[1], orig[2] })
+
+	reportFormat = "pdf"
+	if err := runReport(reportCmd, nil); err == nil || !strings.Contains(err.Error(), "--format") {
+		t.Errorf("bad format: got %v", err)
+	}
+	reportFormat, reportPeriod = "json", "0d"
+	if err := runReport(reportCmd, nil); err == nil || !strings.Contains(err.Error(), "--period") {
+		t.Errorf("zero period: got %v", err)
+	}
+
+	if err := history.Record(history.Entry{Action: history.ActionReveal, Path: "/p/.env"}); err != nil {
+		t.Fatal(err)
+	}
+	reportPeriod = "7d"
+	reportOutput = filepath.Join(t.TempDir(), "report.json")
+	var out bytes.Buffer
+	reportCmd.SetOut(&out)
+	t.Cleanup(func() { reportCmd.SetOut(nil) })
+	if err := runReport(reportCmd, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "Wrote "+reportOutput) {
+		t.Errorf("output = %q", out.String())
+	}
+	data, err := os.ReadFile(reportOutput)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var r struct {
+		Accesses []struct{ Action, Path string }
+	}
+	if err := json.Unmarshal(data, &r); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Accesses) != 1 || r.Accesses[0].Action != history.ActionReveal {
+		t.Errorf("report accesses = %+v", r.Accesses)
+	}
+}
@@ -0,0 +1,251 @@
+package cmd
+
+import (
+	"archive/zip"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jainal09/envdrift-agent/internal/config"
+	"github.com/jainal09/envdrift-agent/internal/crash"
+	"github.com/jainal09/envdrift-agent/internal/daemon"
+	"github.com/jainal09/envdrift-agent/internal/encrypt"
+	"github.com/jainal09/envdrift-agent/internal/registry"
+	"github.com/jainal09/envdrift-agent/internal/update"
+	"github.com/jainal09/envdrift-agent/internal/wsl"
+)
+
+// issuesURL is where report-bug points; the repository's issue tracker.
+const issuesURL = "https://github.com/jainal09/envdrift/issues/new"
+
+const (
+	// maxIssueBody keeps the prefilled URL within what browsers and GitHub
+	// accept; the zip has no such limit.
+	maxIssueBody = 6000
+	// bundleLogLines and issueLogLines bound the log tail in each form.
+	bundleLogLines = 500
+	issueLogLines  = 30
+	// bundleCrashes is how many recent crash reports go into the zip.
+	bundleCrashes = 5
+)
+
+var reportBugCmd = &cobra.Command{
+	Use:   "report-bug",
+	Short: "Collect diagnostics into a prefilled GitHub issue or a zip",
+	Long: `Gathers what a bug report needs: version, install method and platform, the
+service and dependency status, a summary of guardian.toml, recent agent logs
+and recent crash reports. Everything is redacted: the home
+directory becomes ~, NAME=value pairs lose their value and key-shaped strings
+are masked.
+
+By default it prints a link to a new GitHub issue prefilled with the
+diagnostics, the last crash and the last log lines (--open opens it). --zip
+writes the full bundle to a file to attach instead. Nothing is sent until you
+submit the issue.`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runReportBug,
+}
+
+var (
+	reportBugOpen bool
+	reportBugZip  string
+)
+
+// openURL opens a link in the default browser; tests replace it.
+var openURL = func(link string) error {
+	var c *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		c = exec.Command("open", link)
+	case "windows":
+		c = exec.Command("rundll32", "url.dll,FileProtocolHandler", link)
+	default:
+		c = exec.Command("xdg-open", link)
+	}
+	return c.Run()
+}
+
+// recentLogs reads the agent's log tail; tests replace it.
+var recentLogs = daemon.RecentLogs
+
+// init registers the report-bug command with rootCmd.
+func init() {
+	reportBugCmd.Flags().BoolVar(&reportBugOpen, "open", false, "open the issue in the default browser")
+	reportBugCmd.Flags().StringVar(&reportBugZip, "zip", "", "write the full diagnostics bundle to this zip file instead")
+	rootCmd.AddCommand(reportBugCmd)
+}
+
+// bugReport is the redacted diagnostics report-bug collects.
+type bugReport struct {
+	diagnostics string
+	config      string
+	logs        string
+	// crashes are crash report paths, newest first.
+	crashes []string
+}
+
+// runReportBug writes the bundle or prints (and optionally opens) the
+// prefilled issue link.
+func runReportBug(cmd *cobra.Command, args []string) error {
+	report, err := collectBugReport(bundleLogLines)
+	if err != nil {
+		return err
+	}
+	w := cmd.OutOrStdout()
+
+	if reportBugZip != "" {
+		if err := writeBugBundle(reportBugZip, report); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "Wrote %s; attach it to an issue at %s\n", reportBugZip, issuesURL)
+		return nil
+	}
+
+	title, body, err := issueBody(report)
+	if err != nil {
+		return err
+	}
+	link := issuesURL + "?" + url.Values{"title": {title}, "body": {body}}.Encode()
+	if len(report.crashes) > 0 {
+		fmt.Fprintf(w, "Including crash report %s\n", report.crashes[0])
+	}
+	fmt.Fprintf(w, "Open this link to file the issue:\n%s\n", link)
+	if reportBugOpen {
+		if err := openURL(link); err != nil {
+			return fmt.Errorf("open browser: %w", err)
+		}
+	}
+	return nil
+}
+
+// collectBugReport gathers the diagnostics. Problems reading any one part are
+// reported inside it rather than failing the command: a broken config or
+// missing journal is exactly what a bug report should show.
+func collectBugReport(logLines int) (*bugReport, error) {
+	r := &bugReport{}
+
+	exe, err := os.Executable()
+	if err == nil {
+		if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+			exe = resolved
+		}
+	}
+	var d strings.Builder
+	commit, built := buildInfo()
+	fmt.Fprintf(&d, "Version:    %s (commit %s, built %s)\n", Version, orUnknown(commit), orUnknown(built))
+	fmt.Fprintf(&d, "Installed:  %s (%s)\n", update.DetectMethod(InstallMethod, exe), exe)
+	fmt.Fprintf(&d, "Platform:   %s/%s (%s)\n", runtime.GOOS, runtime.GOARCH, runtime.Version())
+	if wsl.Detect() {
+		fmt.Fprintf(&d, "WSL:        %s (systemd=%v, powershell.exe=%v)\n", wsl.Distro(), wsl.Systemd(), wsl.PowerShell() != "")
+	}
+	fmt.Fprintf(&d, "Service:    installed=%v running=%v\n", daemon.IsInstalled(), daemon.IsRunning())
+	fmt.Fprintf(&d, "envdrift:   %v\n", encrypt.IsEnvdriftAvailable())
+	fmt.Fprintf(&d, "dotenvx:    %v\n", encrypt.IsDotenvxAvailable())
+	if reg, err := registry.Load(); err != nil {
+		fmt.Fprintf(&d, "Projects:   error: %v\n", err)
+	} else {
+		fmt.Fprintf(&d, "Projects:   %d registered\n", len(reg.Projects))
+	}
+	r.diagnostics = crash.Redact(d.String())
+
+	if cfg, err := config.Load(); err != nil {
+		r.config = crash.Redact(fmt.Sprintf("%s: error: %v\n", config.ConfigPath(), err))
+	} else {
+		r.config = crash.Redact(crash.ConfigSummary(cfg))
+	}
+
+	if logs, err := recentLogs(logLines); err != nil {
+		r.logs = fmt.Sprintf("(could not read logs: %v)", err)
+	} else {
+		r.logs = crash.Redact(logs)
+	}
+
+	r.crashes, err = crash.List(crash.Dir())
+	return r, err
+}
+
+// issueBody renders r as a Markdown issue, trimmed to maxIssueBody.
+func issueBody(r *bugReport) (title, body string, err error) {
+	title = "envdrift-agent bug report"
+	var b strings.Builder
+	b.WriteString("**Describe the bug**\n\n\n")
+	fmt.Fprintf(&b, "**Diagnostics**\n\n```text\n%s```\n\n", r.diagnostics)
+	fmt.Fprintf(&b, "**Config**\n\n```text\n%s```\n", r.config)
+
+	if logs := lastLogLines(r.logs, issueLogLines); logs != "" {
+		fmt.Fprintf(&b, "\n**Recent logs**\n\n```text\n%s\n```\n", logs)
+	}
+	if len(r.crashes) > 0 {
+		report, err := os.ReadFile(r.crashes[0])
+		if err != nil {
+			return "", "", err
+		}
+		title = "envdrift-agent crash"
+		text := strings.TrimSpace(string(report))
+		if room := maxIssueBody - b.Len() - 200; len(text) > room {
+			text = text[:max(room, 0)] + "\n... (truncated; full report in " + r.crashes[0] + ")"
+		}
+		fmt.Fprintf(&b, "\n**Crash report**\n\n```text\n%s\n```\n", text)
+	}
+	return title, b.String(), nil
+}
+
+// lastLogLines returns the final n lines of logs.
+func lastLogLines(logs string, n int) string {
+	lines := strings.Split(strings.TrimSpace(logs), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// writeBugBundle writes r to a new zip at path: diagnostics.txt, config.txt,
+// logs.txt and the newest crash reports under crashes/.
+func writeBugBundle(path string, r *bugReport) (err error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			_ = os.Remove(path)
+		}
+	}()
+
+	zw := zip.NewWriter(f)
+	files := map[string]string{
+		"diagnostics.txt": r.diagnostics,
+		"config.txt":      r.config,
+		"logs.txt":        r.logs,
+	}
+	for i, p := range r.crashes {
+		if i == bundleCrashes {
+			break
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		files["crashes/"+filepath.Base(p)] = crash.Redact(string(data))
+	}
+	for name, content := range files {
+		fw, err := zw.Create(name)
+		if err != nil {
+			return err
+		}
+		if _, err := fw.Write([]byte(content)); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
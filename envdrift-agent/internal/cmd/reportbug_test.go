@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jainal09/envdrift-agent/internal/crash"
+)
+
+// setupReportBug isolates HOME, stubs the browser and the log source, and
+// leaves one crash report behind.
+func setupReportBug(t *testing.T) (opened *string) {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	origOpen, origLogs := openURL, recentLogs
+	t.Cleanup(func() {
+		openURL, recentLogs = origOpen, origLogs
+		reportBugOpen, reportBugZip = false, ""
+		reportBugCmd.SetOut(nil)
+	})
+	opened = new(string)
+	openURL = func(link string) error {
+		*opened = link
+		return nil
+	}
+	recentLogs = func(n int) (string, error) {
+		return "2026-03-01 encrypting " + filepath.Join(home, "app", ".env") + "\nAPI_TOKEN=leaked", nil
+	}
+	if _, err := crash.Write(crash.Dir(), crash.Report{Version: "1.1.4", Panic: "nil map write"}); err != nil {
+		t.Fatal(err)
+	}
+	return opened
+}
+
+func TestReportBugPrefillsIssue(t *testing.T) {
+	opened := setupReportBug(t)
+
+	var out bytes.Buffer
+	reportBugCmd.SetOut(&out)
+	reportBugOpen = true
+	if err := runReportBug(reportBugCmd, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), issuesURL+"?") || *opened == "" {
+		t.Fatalf("output = %q, opened %q", out.String(), *opened)
+	}
+	u, err := url.Parse(*opened)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := u.Query()
+	body := q.Get("body")
+	if q.Get("title") != "envdrift-agent crash" {
+		t.Errorf("title = %q", q.Get("title"))
+	}
+	for _, want := range []string{"Panic:    nil map write", "Version:", "guardian: enabled=", "encrypting ~", "API_TOKEN=[redacted]"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("issue body missing %q:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, "leaked") {
+		t.Errorf("issue body leaks a log value:\n%s", body)
+	}
+}
+
+func TestReportBugWritesZip(t *testing.T) {
+	opened := setupReportBug(t)
+
+	var out bytes.Buffer
+	reportBugCmd.SetOut(&out)
+	reportBugZip = filepath.Join(t.TempDir(), "report.zip")
+	if err := runReportBug(reportBugCmd, nil); err != nil {
+		t.Fatal(err)
+	}
+	if *opened != "" {
+		t.Error("--zip opened the browser")
+	}
+
+	zr, err := zip.OpenReader(reportBugZip)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = zr.Close() }()
+	contents := map[string]string{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(rc)
+		_ = rc.Close()
+		contents[f.Name] = string(data)
+	}
+	if !strings.Contains(contents["diagnostics.txt"], "dotenvx:") ||
+		!strings.Contains(contents["config.txt"], "update: channel=stable") ||
+		!strings.Contains(contents["logs.txt"], "API_TOKEN=[redacted]") {
+		t.Errorf("bundle = %v", contents)
+	}
+	crashes := 0
+	for name := range contents {
+		if strings.HasPrefix(name, "crashes/crash-") {
+			crashes++
+		}
+	}
+	if crashes != 1 {
+		t.Errorf("bundle has %d crash reports; want 1", crashes)
+	}
+
+	// An existing file is never overwritten.
+	if err := runReportBug(reportBugCmd, nil); err == nil {
+		t.Error("--zip overwrote an existing file")
+	}
+}
@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jainal09/envdrift-agent/internal/dotenv"
+	"github.com/jainal09/envdrift-agent/internal/encrypt"
+	"github.com/jainal09/envdrift-agent/internal/history"
+	"github.com/jainal09/envdrift-agent/internal/output"
+)
+
+var revealCmd = &cobra.Command{
+	Use:   "reveal <path>",
+	Short: "Decrypt an env file (or one variable) to stdout without writing plaintext to disk",
+	Long: `Decrypts an encrypted env file in memory and writes the plaintext to stdout only.
+Every reveal is recorded in the history log (file and variable name, never the value).
+
+  envdrift-agent reveal .env.production --key DATABASE_URL
+  source <(envdrift-agent reveal .env --format shell)`,
+	Args: cobra.ExactArgs(1),
+	RunE: runReveal,
+}
+
+var (
+	revealKey    string
+	revealFormat string
+)
+
+// init registers the reveal command and its flags with rootCmd.
+func init() {
+	revealCmd.Flags().StringVar(&revealKey, "key", "", "reveal only this variable's value")
+	revealCmd.Flags().StringVar(&revealFormat, "format", "dotenv", "output format: dotenv or shell (export statements)")
+	rootCmd.AddCommand(revealCmd)
+}
+
+// runReveal decrypts the file in memory and prints it. The access is recorded
+// in history BEFORE any plaintext is written: if the audit record cannot be
+// written the reveal fails closed.
+func runReveal(cmd *cobra.Command, args []string) error {
+	if revealFormat != "dotenv" && revealFormat != "shell" {
+		return fmt.Errorf("unsupported --format %q (want dotenv or shell)", revealFormat)
+	}
+	path, err := filepath.Abs(args[0])
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	resolver, err := loadKeyResolver()
+	if err != nil {
+		return err
+	}
+	keyEnv, err := resolvedKeyEnv(ctx, resolver, path)
+	if err != nil {
+		return err
+	}
+	entries, err := encrypt.DecryptEntries(ctx, path, keyEnv)
+	if err != nil {
+		return err
+	}
+	if revealKey != "" {
+		entries = filterEntry(entries, revealKey)
+		if len(entries) == 0 {
+			return fmt.Errorf("%s is not defined in %s", revealKey, path)
+		}
+	}
+
+	if err := history.Record(history.Entry{Action: history.ActionReveal, Path: path, Detail: revealKey}); err != nil {
+		return fmt.Errorf("refusing to reveal: could not record access in history: %w", err)
+	}
+
+	// Values are data: plain output mode must not strip them.
+	return writeReveal(output.Raw(cmd.OutOrStdout()), cmd.ErrOrStderr(), entries, revealKey != "", revealFormat)
+}
+
+// filterEntry returns the single entry named key, or nil.
+func filterEntry(entries []dotenv.Entry, key string) []dotenv.Entry {
+	for _, e := range entries {
+		if e.Key == key {
+			return []dotenv.Entry{e}
+		}
+	}
+	return nil
+}
+
+// writeReveal renders entries to w. A single --key reveal in dotenv format
+// prints the bare value so it composes with $(...) substitution. The shell
+// format skips, with a warning to errw, names a shell cannot export.
+func writeReveal(w, errw io.Writer, entries []dotenv.Entry, single bool, format string) error {
+	if format == "shell" {
+		for _, e := range entries {
+			if !shellName.MatchString(e.Key) {
+				fmt.Fprintf(errw, "Warning: skipping %s: not a valid shell variable name\n", e.Key)
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "export %s=%s\n", e.Key, shellQuote(e.Value)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if single {
+		_, err := fmt.Fprintln(w, entries[0].Value)
+		return err
+	}
+	_, err := w.Write(dotenv.Marshal(entries))
+	return err
+}
+
+// shellName matches the variable names a POSIX shell can export; dotenv also
+// allows dots and dashes, which would make the whole eval fail.
+var shellName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// shellQuote single-quotes s for POSIX shells, closing and reopening the
+// quotes around each embedded single quote.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,65 @@
+// Package cmd tests for the reveal command.
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/jainal09/envdrift-agent/internal/dotenv"
+)
+
+func TestWriteRevealFormats(t *testing.T) {
+	entries := []dotenv.Entry{{Key: "A", Value: "it's"}, {Key: "B", Value: "x y"}}
+
+	tests := []struct {
+		name   string
+		single bool
+		format string
+		want   string
+	}{
+		{"dotenv", false, "dotenv", "A=\"it's\"\nB=\"x y\"\n"},
+		{"shell", false, "shell", "export A='it'\\''s'\nexport B='x y'\n"},
+		{"single value", true, "dotenv", "it's\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := entries
+			if tt.single {
+				in = entries[:1]
+			}
+			var buf bytes.Buffer
+			if err := writeReveal(&buf, io.Discard, in, tt.single, tt.format); err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("writeReveal = %q, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteRevealShellSkipsInvalidNames(t *testing.T) {
+	entries := []dotenv.Entry{{Key: "app.name", Value: "x"}, {Key: "A", Value: "1"}, {Key: "MY-VAR", Value: "y"}}
+	var out, errOut bytes.Buffer
+	if err := writeReveal(&out, &errOut, entries, false, "shell"); err != nil {
+		t.Fatal(err)
+	}
+	if want := "export A='1'\n"; out.String() != want {
+		t.Errorf("writeReveal = %q, want %q", out.String(), want)
+	}
+	for _, key := range []string{"app.name", "MY-VAR"} {
+		if !strings.Contains(errOut.String(), key) {
+			t.Errorf("no warning for %s in %q", key, errOut.String())
+		}
+	}
+}
+
+func TestRevealRejectsUnknownFormat(t *testing.T) {
+	revealFormat = "yaml"
+	t.Cleanup(func() { revealFormat = "dotenv" })
+	if err := runReveal(revealCmd, []string{".env"}); err == nil {
+		t.Fatal("reveal should reject an unsupported --format")
+	}
+}
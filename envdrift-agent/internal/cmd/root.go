@@ -3,25 +3,49 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"os"
 	"os/signal"
+	"runtime"
+	"strings"
 	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
 
+	"github.com/jainal09/envdrift-agent/internal/compliance"
 	"github.com/jainal09/envdrift-agent/internal/config"
 	"github.com/jainal09/envdrift-agent/internal/daemon"
 	"github.com/jainal09/envdrift-agent/internal/encrypt"
 	"github.com/jainal09/envdrift-agent/internal/guardian"
+	"github.com/jainal09/envdrift-agent/internal/i18n"
 	"github.com/jainal09/envdrift-agent/internal/logging"
+	"github.com/jainal09/envdrift-agent/internal/netstate"
+	"github.com/jainal09/envdrift-agent/internal/offline"
+	"github.com/jainal09/envdrift-agent/internal/onboarding"
+	"github.com/jainal09/envdrift-agent/internal/output"
+	"github.com/jainal09/envdrift-agent/internal/paths"
+	"github.com/jainal09/envdrift-agent/internal/pending"
+	"github.com/jainal09/envdrift-agent/internal/power"
+	"github.com/jainal09/envdrift-agent/internal/severity"
+	"github.com/jainal09/envdrift-agent/internal/systemlog"
+	"github.com/jainal09/envdrift-agent/internal/ui"
+	"github.com/jainal09/envdrift-agent/internal/wsl"
 )
 
 var (
 	// Version is set at build time
 	Version = "dev"
+	// InstallMethod is set at build time by packagers (brew, scoop, deb) so
+	// self-update defers to the package manager; empty means detect it.
+	InstallMethod = ""
+	// Commit and BuildDate are set at build time; empty falls back to the
+	// VCS stamp the go command embeds (see buildInfo).
+	Commit    = ""
+	BuildDate = ""
 )
 
 var rootCmd = &cobra.Command{
@@ -31,21 +55,53 @@ var rootCmd = &cobra.Command{
 and automatically encrypts them when they're not being actively edited.
 
 Install once with 'envdrift-agent install' and it runs automatically on startup.`,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		if noEmoji {
+			output.SetPlain(true)
+		}
+		migratePaths()
+	},
+}
+
+// migratePaths moves the files an older version kept in ~/.envdrift to the
+// current locations before any command reads them. A file that cannot be
+// moved is left in place and logged; its command then starts from the
+// default.
+func migratePaths() {
+	moves, err := paths.Migrate()
+	for _, m := range moves {
+		log.Printf("Moved %s to %s", m.From, m.To)
+	}
+	if err != nil {
+		log.Printf("%v (set %s=%s to keep using it)", err, paths.HomeEnv, paths.Legacy())
+	}
 }
 
+// noEmoji is the --no-emoji flag: plain output for screen readers and log
+// processors (see output.Plain).
+var noEmoji bool
+
 var versionCmd = &cobra.Command{
 	Use:   "version",
 	Short: "Print version information",
-	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Printf("envdrift-agent %s\n", Version)
-	},
+	Long: `Prints the agent version. --json adds the commit, build date, Go version,
+platform and the dotenvx binary in use, for scripts and fleet reporting.`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runVersion,
 }
 
 var installCmd = &cobra.Command{
 	Use:   "install",
 	Short: "Install agent to run at system startup",
-	Long:  `Installs the agent as a system service that starts automatically on boot.`,
-	RunE:  runInstall,
+	Long: `Installs the agent as a system service that starts automatically on boot.
+
+For MDM and configuration-management rollouts (Jamf, Intune, Ansible),
+--config seeds guardian.toml from a managed file, validated
+first, and --non-interactive prints the outcome as JSON and exits non-zero
+on failure.`,
+	SilenceUsage: true,
+	RunE:         runInstall,
 }
 
 var uninstallCmd = &cobra.Command{
@@ -84,10 +140,37 @@ var configCmd = &cobra.Command{
 	RunE:  runConfig,
 }
 
+var configSetCmd = &cobra.Command{
+	Use:   "set <section.name> <value>",
+	Short: "Change one setting in the config file",
+	Long: `Sets one guardian.toml setting, e.g.
+
+  envdrift-agent config set telemetry.enabled true
+  envdrift-agent config set guardian.idle_timeout 10m
+
+The value is read as TOML (true, 5, "5m", [".env*"]); a bare word is a string.
+The running agent picks the change up on its next start.`,
+	Args:         cobra.ExactArgs(2),
+	SilenceUsage: true,
+	RunE:         runConfigSet,
+}
+
 // init registers all subcommands with rootCmd: version, install, uninstall, status, start, stop, and config.
 func init() {
+	rootCmd.PersistentFlags().BoolVar(&noEmoji, "no-emoji", false,
+		"strip emoji and color from output and notifications (also ENVDRIFT_PLAIN_OUTPUT=1)")
+	rootCmd.PersistentFlags().BoolVar(&config.Strict, "strict", false,
+		"reject unknown keys in guardian.toml (also strict = true in the file)")
+	rootCmd.PersistentFlags().BoolVar(&noVaultCache, "no-cache", false,
+		"read private keys from the vault instead of [vault_cache]")
+	versionCmd.Flags().BoolVar(&versionJSON, "json", false,
+		"print version and build information as JSON")
 	startCmd.Flags().StringVar(&startLogFile, "log-file", "",
-		"write agent logs to this file with size-based rotation (5 MiB, 3 backups)")
+		"write agent logs to this file with size-based rotation (logs.max_size, logs.backups)")
+	installCmd.Flags().StringVar(&installConfig, "config", "",
+		"seed guardian.toml from this managed config (kept verbatim; a different existing one is backed up)")
+	installCmd.Flags().BoolVar(&installNonInteractive, "non-interactive", false,
+		"never prompt; print the result as JSON and exit non-zero on failure")
 
 	rootCmd.AddCommand(versionCmd)
 	rootCmd.AddCommand(installCmd)
@@ -95,43 +178,79 @@ func init() {
 	rootCmd.AddCommand(statusCmd)
 	rootCmd.AddCommand(startCmd)
 	rootCmd.AddCommand(stopCmd)
+	configCmd.AddCommand(configSetCmd)
 	rootCmd.AddCommand(configCmd)
 }
 
-// Execute runs the root command
+// Execute runs the root command in the configured guardian.language; without
+// one (or a readable config) messages follow the locale environment. Command
+// output goes through output.Writer, so guardian.plain_output, --no-emoji
+// and ENVDRIFT_PLAIN_OUTPUT strip it alike. guardian.plaintext_allowed
+// applies to every command that tells encrypted files from plaintext ones,
+// and the severity.gitleaks rules to every one that ranks plaintext values.
 func Execute() error {
+	if cfg, err := config.Load(); err == nil {
+		i18n.SetLanguage(cfg.Guardian.Language)
+		output.SetPlain(cfg.Guardian.PlainOutput)
+		encrypt.SetPlaintextAllowed(cfg.Guardian.PlaintextAllowed)
+		if rules, err := cfg.Severity.GitleaksRules(); err == nil {
+			severity.SetRules(rules)
+		}
+	}
+	rootCmd.SetOut(output.Writer(os.Stdout))
+	rootCmd.SetErr(output.Writer(os.Stderr))
 	return rootCmd.Execute()
 }
 
 // runInstall installs the envdrift-agent as a system service and prints progress and status messages.
 //
-// It checks for dotenvx availability, ensures a configuration file exists (creating/saving a default if needed),
-// reports the config path, and invokes daemon.Install. It returns any error encountered during loading or
-// installing the agent; non-fatal failures to save the config are reported to stdout but do not stop installation.
+// It checks for dotenvx availability, ensures a configuration file exists (creating/saving a default if needed,
+// or seeding it from --config), reports the config path, and invokes daemon.Install. It returns any error
+// encountered during loading or installing the agent; non-fatal failures to save the config are reported to
+// stdout but do not stop installation. With --non-interactive it reports through runInstallUnattended instead.
 func runInstall(cmd *cobra.Command, args []string) error {
-	fmt.Println("Installing envdrift-agent...")
+	w := cmd.OutOrStdout()
+	if installNonInteractive {
+		return runInstallUnattended(w)
+	}
+	fmt.Fprintln(w, i18n.T("cli.installing"))
 
 	// Check envdrift first
 	if !encrypt.IsEnvdriftAvailable() {
-		fmt.Println("⚠️  Warning: envdrift not found. Install it: pip install envdrift")
+		fmt.Fprintln(w, i18n.T("cli.envdrift_missing"))
 	}
 
-	// Create default config if none exists
-	cfg, err := config.Load()
-	if err != nil {
-		return err
-	}
-	if err := config.Save(cfg); err != nil {
-		fmt.Printf("⚠️  Could not save config: %v\n", err)
+	if installConfig != "" {
+		backup, err := seedConfig(installConfig)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w, i18n.T("cli.config_file", config.ConfigPath()))
+		if backup != "" {
+			fmt.Fprintf(w, "   Previous config kept at %s\n", backup)
+		}
 	} else {
-		fmt.Printf("📝 Config file: %s\n", config.ConfigPath())
+		// Create default config if none exists
+		cfg, err := config.LoadWithoutEnv()
+		if err != nil {
+			return err
+		}
+		if err := config.Save(cfg); err != nil {
+			fmt.Fprintln(w, i18n.T("cli.config_save_failed", err))
+		} else {
+			fmt.Fprintln(w, i18n.T("cli.config_file", config.ConfigPath()))
+		}
 	}
 
-	if err := daemon.Install(); err != nil {
+	if err := installService(); err != nil {
 		return fmt.Errorf("failed to install: %w", err)
 	}
 
-	fmt.Println("✅ Agent installed and will start on system boot")
+	if daemon.StartsAtLogin() {
+		fmt.Fprintln(w, i18n.T("cli.installed_autostart"))
+		return nil
+	}
+	fmt.Fprintln(w, i18n.T("cli.installed"))
 	return nil
 }
 
@@ -139,61 +258,216 @@ func runInstall(cmd *cobra.Command, args []string) error {
 //
 // It performs the uninstallation and returns an error if the removal fails.
 func runUninstall(cmd *cobra.Command, args []string) error {
-	fmt.Println("Uninstalling envdrift-agent...")
+	w := cmd.OutOrStdout()
+	fmt.Fprintln(w, i18n.T("cli.uninstalling"))
 
 	if err := daemon.Uninstall(); err != nil {
 		return fmt.Errorf("failed to uninstall: %w", err)
 	}
 
-	fmt.Println("✅ Agent removed from system startup")
+	fmt.Fprintln(w, i18n.T("cli.uninstalled"))
 	return nil
 }
 
 // runStatus reports whether the agent is installed and running and prints
 // the configured paths for the config file and dotenvx.
 //
-// It writes four status lines to stdout: Installed, Running, Config, and dotenvx, and always returns nil.
+// It writes four status lines to stdout: Installed, Running, Config, and dotenvx, plus the detected
+// power source, an offline agent's queued operations, the network and matching policies when
+// [[policies]] are configured, each watch root's enforce or observe mode when [directories.roots]
+// sets any, a running agent's pending plaintext files with why each waits and when it is
+// encrypted, and WSL's limitations inside WSL, and always returns nil.
 func runStatus(cmd *cobra.Command, args []string) error {
+	w := cmd.OutOrStdout()
+	out := ui.New(w)
 	installed := daemon.IsInstalled()
 	running := daemon.IsRunning()
 
-	fmt.Printf("Installed: %v\n", installed)
-	fmt.Printf("Running:   %v\n", running)
-	fmt.Printf("Config:    %s\n", config.ConfigPath())
-	fmt.Printf("envdrift:  %v\n", encrypt.IsEnvdriftAvailable())
+	fmt.Fprintf(w, "Installed: %s\n", out.Paint(stateColor(installed), fmt.Sprint(installed)))
+	fmt.Fprintf(w, "Running:   %s\n", out.Paint(stateColor(running), fmt.Sprint(running)))
+	fmt.Fprintf(w, "Config:    %s\n", config.ConfigPath())
+	fmt.Fprintf(w, "envdrift:  %v\n", encrypt.IsEnvdriftAvailable())
+
+	pw := power.Detect()
+	if cfg, err := config.Load(); err == nil && pw.Low(cfg.Power.DeferBelow) {
+		fmt.Fprintf(w, "Power:     %s\n", out.Paint(ui.Yellow, pw.String()+" (deferring vault sync and telemetry)"))
+	} else {
+		fmt.Fprintf(w, "Power:     %s\n", pw)
+	}
+	if cfg, err := config.Load(); err == nil {
+		writeSchedules(w, out, cfg, time.Now())
+	}
+
+	// While the agent is offline, show how much work waits for the network.
+	if st, ok, err := offline.Read(); running && err == nil && ok {
+		fmt.Fprintf(w, "Network:   %s\n", out.Paint(ui.Yellow, fmt.Sprintf("offline — %d operations queued", len(st.Queued))))
+	}
+
+	// With [[policies]] configured, show which apply on this network.
+	if cfg, err := config.Load(); err == nil && len(cfg.AllPolicies()) > 0 {
+		state := netstate.Detect(cmd.Context())
+		names := config.MatchPolicies(cfg.AllPolicies(), state).Names
+		if len(names) == 0 {
+			names = []string{"none"}
+		}
+		fmt.Fprintf(w, "Network:   ssid=%q vpn=%v domain=%v\n", state.SSID, state.VPN, state.DomainJoined)
+		fmt.Fprintf(w, "Policies:  %s\n", strings.Join(names, ", "))
+	}
+
+	// With compliance reporting on, show the latest report.
+	if cfg, err := config.Load(); err == nil && cfg.Compliance.Reporting() {
+		mode := "reporting"
+		if cfg.Compliance.ReadOnly {
+			mode = "read-only"
+		}
+		if r, ok, err := compliance.Load(compliance.DefaultPath()); err == nil && ok {
+			color := ui.Green
+			if !r.Compliant() {
+				color = ui.Yellow
+			}
+			fmt.Fprintf(w, "Compliance: %s — %s (as of %s)\n", mode,
+				out.Paint(color, r.Summary()), r.Generated.Local().Format("2006-01-02 15:04"))
+		} else {
+			fmt.Fprintf(w, "Compliance: %s — no report yet\n", mode)
+		}
+	}
+
+	// With [directories.roots] set, show each root's mode.
+	if cfg, err := config.Load(); err == nil && len(cfg.Directories.Roots) > 0 {
+		roots := cfg.Effective().WatchRoots()
+		width := 0
+		for _, r := range roots {
+			width = max(width, len(r.Path))
+		}
+		for i, r := range roots {
+			label := "Roots:     "
+			if i > 0 {
+				label = "           "
+			}
+			mode := out.Paint(ui.Green, "enforce (encrypted once idle)")
+			switch {
+			case r.Mode == config.ModeObserve:
+				mode = out.Paint(ui.Yellow, "observe (reported, never encrypted)")
+			case cfg.Compliance.ReadOnly:
+				mode = out.Paint(ui.Yellow, "enforce, but read-only compliance mode")
+			}
+			fmt.Fprintf(w, "%s%-*s  %s\n", label, width, r.Path, mode)
+		}
+	}
+
+	// With onboarding on, list the new projects still only observed.
+	if cfg, err := config.Load(); err == nil && cfg.Guardian.GracePeriod > 0 {
+		if roots, err := onboarding.Observing(time.Now()); err == nil && len(roots) > 0 {
+			fmt.Fprintf(w, "Onboarding: %s\n", out.Paint(ui.Yellow,
+				fmt.Sprintf("%d new projects observed, not encrypted ('envdrift-agent approve' to review)", len(roots))))
+		}
+	}
+
+	// While the agent runs, show each plaintext file it has yet to encrypt.
+	if files, err := pending.Read(); running && err == nil && len(files) > 0 {
+		fmt.Fprintf(w, "Pending:   %s\n", out.Paint(ui.Yellow, fmt.Sprintf("%d plaintext files not yet encrypted", len(files))))
+		now := time.Now()
+		for _, f := range files {
+			fmt.Fprintf(w, "           - %s: %s; first seen %s, %s\n", f.Path, f.Reason,
+				statusTime(f.FirstSeen, now), projectedEncryption(f.Due, now))
+		}
+	}
+
+	// Under WSL, say what works differently there.
+	if limits := wsl.Limitations(); len(limits) > 0 {
+		fmt.Fprintf(w, "WSL:       %s\n", wsl.Distro())
+		for _, l := range limits {
+			fmt.Fprintf(w, "           - %s\n", l)
+		}
+	}
 
 	return nil
 }
 
+// statusTime formats t as a clock time, with the date unless it is today.
+func statusTime(t, now time.Time) string {
+	t, now = t.Local(), now.Local()
+	if t.YearDay() == now.YearDay() && t.Year() == now.Year() {
+		return t.Format("15:04")
+	}
+	return t.Format("2006-01-02 15:04")
+}
+
+// projectedEncryption says when a pending file due at due is encrypted.
+func projectedEncryption(due, now time.Time) string {
+	switch {
+	case due.IsZero():
+		return "encrypted once that clears"
+	case !due.After(now):
+		return "encrypted on the next check"
+	}
+	return "encrypted at " + statusTime(due, now)
+}
+
+// observedRoots lists the watch roots cfg puts in observe mode.
+func observedRoots(cfg *config.Config) []string {
+	var observed []string
+	for _, r := range cfg.WatchRoots() {
+		if r.Mode == config.ModeObserve {
+			observed = append(observed, r.Path)
+		}
+	}
+	return observed
+}
+
+// stateColor is green for a healthy yes, red for a no.
+func stateColor(ok bool) ui.Color {
+	if ok {
+		return ui.Green
+	}
+	return ui.Red
+}
+
 // runStart starts the agent in the foreground and runs the guardian until interrupted.
 // It loads the configuration, creates and starts a guardian, and cancels execution when a SIGINT or SIGTERM is received; returns any error encountered while loading the config, creating the guardian, or starting it.
 func runStart(cmd *cobra.Command, args []string) error {
-	fmt.Println("Starting envdrift-agent in foreground...")
-	fmt.Println("Press Ctrl+C to stop")
+	w := cmd.OutOrStdout()
+	fmt.Fprintln(w, i18n.T("cli.starting"))
+	fmt.Fprintln(w, i18n.T("cli.press_ctrl_c"))
+
+	cfg, err := config.Load()
+	if err != nil {
+		var policyErr *config.PolicyError
+		if errors.As(err, &policyErr) {
+			systemlog.Emit(systemlog.Event{Level: systemlog.Error, Kind: systemlog.KindPolicyViolation,
+				Message: "managed policy rejected; guardian not started: " + err.Error()})
+		}
+		return err
+	}
 
 	if startLogFile != "" {
-		closer, err := configureLogOutput(startLogFile)
+		closer, err := configureLogOutput(startLogFile, cfg.Logs)
 		if err != nil {
 			return err
 		}
 		defer func() { _ = closer.Close() }()
 	}
 
-	cfg, err := config.Load()
-	if err != nil {
-		return err
+	if cfg.Guardian.Journald {
+		closer, err := useJournald(startLogFile == "")
+		if err != nil {
+			log.Printf("guardian.journald: %v; logging to stdout", err)
+		} else {
+			defer func() { _ = closer.Close() }()
+		}
 	}
 
 	// Honor the global guardian switch (#348 G3): when disabled, no-op.
 	if !cfg.Guardian.Enabled {
-		fmt.Println("Guardian is disabled in config (guardian.enabled = false); nothing to do.")
+		fmt.Fprintln(w, i18n.T("cli.guardian_disabled"))
 		return nil
 	}
 
-	g, err := guardian.New(cfg)
+	g, err := guardian.New(cfg.Effective())
 	if err != nil {
 		return err
 	}
+	g.Version = Version
 
 	// Handle graceful shutdown
 	ctx, cancel := context.WithCancel(context.Background())
@@ -203,7 +477,7 @@ func runStart(cmd *cobra.Command, args []string) error {
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
 	go func() {
 		<-sigCh
-		fmt.Println("\nShutting down...")
+		fmt.Fprintln(w, "\n"+i18n.T("cli.shutting_down"))
 		cancel()
 	}()
 
@@ -223,10 +497,11 @@ func runStart(cmd *cobra.Command, args []string) error {
 // harmless no-op), so attempting it unconditionally is safe. It returns a
 // non-nil error (non-zero exit) if stopping fails.
 func runStop(cmd *cobra.Command, args []string) error {
-	fmt.Println("Stopping envdrift-agent...")
+	w := cmd.OutOrStdout()
+	fmt.Fprintln(w, i18n.T("cli.stopping"))
 
 	if !daemon.IsInstalled() {
-		fmt.Println("Agent is not installed")
+		fmt.Fprintln(w, i18n.T("cli.not_installed"))
 		return nil
 	}
 
@@ -234,7 +509,7 @@ func runStop(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to stop agent: %w", err)
 	}
 
-	fmt.Println("✅ Agent stopped (still installed; run 'envdrift-agent uninstall' to remove)")
+	fmt.Fprintln(w, i18n.T("cli.stopped"))
 	return nil
 }
 
@@ -242,6 +517,7 @@ func runStop(cmd *cobra.Command, args []string) error {
 // configuration if the file is missing, and prints the current configuration
 // settings to stdout. It returns an error if saving or loading the configuration fails.
 func runConfig(cmd *cobra.Command, args []string) error {
+	w := cmd.OutOrStdout()
 	configPath := config.ConfigPath()
 
 	if _, err := os.Stat(configPath); os.IsNotExist(err) {
@@ -249,9 +525,9 @@ func runConfig(cmd *cobra.Command, args []string) error {
 		if err := config.Save(cfg); err != nil {
 			return err
 		}
-		fmt.Printf("Created config file: %s\n", configPath)
+		fmt.Fprintf(w, "Created config file: %s\n", configPath)
 	} else {
-		fmt.Printf("Config file: %s\n", configPath)
+		fmt.Fprintf(w, "Config file: %s\n", configPath)
 	}
 
 	// Print current config
@@ -260,26 +536,161 @@ func runConfig(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	fmt.Printf("\nCurrent settings:\n")
-	fmt.Printf("  Enabled:      %v\n", cfg.Guardian.Enabled)
-	fmt.Printf("  Idle timeout: %v\n", cfg.Guardian.IdleTimeout)
-	fmt.Printf("  Patterns:     %v\n", cfg.Guardian.Patterns)
-	fmt.Printf("  Exclude:      %v\n", cfg.Guardian.Exclude)
-	fmt.Printf("  Notify:       %v\n", cfg.Guardian.Notify)
-	fmt.Printf("  Directories:  %v\n", cfg.Directories.Watch)
+	fmt.Fprintf(w, "\nCurrent settings:\n")
+	fmt.Fprintf(w, "  Enabled:      %v\n", cfg.Guardian.Enabled)
+	fmt.Fprintf(w, "  Idle timeout: %v\n", cfg.Guardian.IdleTimeout)
+	fmt.Fprintf(w, "  Patterns:     %v\n", cfg.Guardian.Patterns)
+	fmt.Fprintf(w, "  Exclude:      %v\n", cfg.Guardian.Exclude)
+	fmt.Fprintf(w, "  Notify:       %v\n", cfg.Guardian.Notify)
+	fmt.Fprintf(w, "  Symlinks:     %s\n", cfg.Guardian.Symlinks)
+	fmt.Fprintf(w, "  Ignore generated: %v\n", cfg.Guardian.IgnoreGenerated)
+	fmt.Fprintf(w, "  Incremental:  %v\n", cfg.Guardian.Incremental)
+	fmt.Fprintf(w, "  Normalize output: %v\n", cfg.Guardian.NormalizeOutput)
+	fmt.Fprintf(w, "  Debounce:     %v\n", cfg.Guardian.Debounce)
+	fmt.Fprintf(w, "  Language:     %s\n", i18n.Language())
+	fmt.Fprintf(w, "  Plain output: %v\n", output.Plain())
+	fmt.Fprintf(w, "  Journal:      %v\n", cfg.Guardian.Journal)
+	fmt.Fprintf(w, "  Journald:     %v\n", cfg.Guardian.Journald)
+	fmt.Fprintf(w, "  Grace period: %v\n", cfg.Guardian.GracePeriod)
+	if cfg.Guardian.EncryptWhen != "" {
+		fmt.Fprintf(w, "  Encrypt when: %s\n", cfg.Guardian.EncryptWhen)
+	}
+	if cfg.Guardian.RescanInterval > 0 {
+		fmt.Fprintf(w, "  Rescan every: %v\n", cfg.Guardian.RescanInterval)
+	}
+	if len(cfg.Guardian.DeepWorkHours) > 0 {
+		fmt.Fprintf(w, "  Deep work:    %s (no encryption unless the screen is locked)\n", strings.Join(cfg.Guardian.DeepWorkHours, ", "))
+	}
+	if cfg.Guardian.ConfigScanInterval > 0 {
+		fmt.Fprintf(w, "  Config scan:  every %v\n", cfg.Guardian.ConfigScanInterval)
+	}
+	if len(cfg.Guardian.PlaintextAllowed) > 0 {
+		fmt.Fprintf(w, "  Plaintext ok: %v\n", cfg.Guardian.PlaintextAllowed)
+	}
+	fmt.Fprintf(w, "  Directories:  %v\n", cfg.Directories.Watch)
+	if observed := observedRoots(cfg); len(observed) > 0 {
+		fmt.Fprintf(w, "  Observe only: %v\n", observed)
+	}
+	keySync := cfg.Keys.SyncStore
+	if keySync == "" {
+		keySync = "off"
+	}
+	fmt.Fprintf(w, "  Key sync:     %s\n", keySync)
+	fmt.Fprintf(w, "  Vault sync:   %v (every %v, into %s)\n",
+		cfg.VaultSync.Enabled, cfg.VaultSync.Interval, cfg.VaultSync.Target)
+	fmt.Fprintf(w, "  Edit opens:   %v\n", cfg.Edit.AutoOpen)
+	fmt.Fprintf(w, "  Backups:      %v (keep %d, max age %v, trash %v)\n",
+		cfg.Backups.Enabled, cfg.Backups.Keep, cfg.Backups.MaxAge, cfg.Backups.Trash)
+	fmt.Fprintf(w, "  Telemetry:    %v (endpoint %q)\n", cfg.Telemetry.Enabled, cfg.Telemetry.Endpoint)
+	fmt.Fprintf(w, "  Update:       %s channel\n", cfg.Update.Channel)
+	fmt.Fprintf(w, "  Policies:     %d network-conditioned\n", len(cfg.Policies))
+	if mp := cfg.ManagedPolicies; mp.File != "" {
+		signed := "unsigned"
+		if len(mp.TrustedKeys) > 0 {
+			signed = fmt.Sprintf("signature verified, %d trusted key(s)", len(mp.TrustedKeys))
+		}
+		fmt.Fprintf(w, "  Managed:      %d from %s (%s)\n", len(cfg.Managed), mp.File, signed)
+	}
+	fmt.Fprintf(w, "  Power:        defer background work below %d%% battery\n", cfg.Power.DeferBelow)
+	fmt.Fprintf(w, "  Logs:         rotate at %s, keep %d, prune %s\n",
+		config.FormatByteSize(cfg.Logs.MaxSize), cfg.Logs.Backups, retentionString(cfg.Logs.Keep))
+	fmt.Fprintf(w, "  History:      prune %s\n", retentionString(cfg.History.Keep))
+	fmt.Fprintf(w, "  Identity:     keypair %v\n", cfg.Identity.Keypair)
+	fmt.Fprintf(w, "  Remote:       %v (agent %s)\n", cfg.Remote.Hosts, cfg.Remote.Agent)
+	fmt.Fprintf(w, "  Compliance:   read-only %v (report every %v)\n", cfg.Compliance.ReadOnly, cfg.Compliance.Interval)
+	fmt.Fprintf(w, "  Severity:     notify from %s, fail from %s\n", cfg.Severity.NotifyLevel(), cfg.Severity.FailLevel())
+	if len(cfg.Severity.Gitleaks) > 0 {
+		fmt.Fprintf(w, "  Gitleaks:     %v\n", cfg.Severity.Gitleaks)
+	}
+	var hookNames []string
+	for _, h := range []struct{ name, command string }{
+		{"pre_encrypt", cfg.Hooks.PreEncrypt}, {"post_encrypt", cfg.Hooks.PostEncrypt}, {"on_failure", cfg.Hooks.OnFailure},
+	} {
+		if h.command != "" {
+			hookNames = append(hookNames, h.name)
+		}
+	}
+	if len(hookNames) == 0 {
+		hookNames = []string{"none"}
+	}
+	fmt.Fprintf(w, "  Hooks:        %s (timeout %v)\n", strings.Join(hookNames, ", "), cfg.Hooks.Timeout)
+	fmt.Fprintf(w, "  Routing:      encrypted %v, failure %v, warning %v, info %v\n",
+		cfg.Notifications.Encrypted, cfg.Notifications.Failure, cfg.Notifications.Warning, cfg.Notifications.Info)
+	if len(cfg.Notifications.QuietHours) > 0 {
+		fmt.Fprintf(w, "  Quiet hours:  %s\n", strings.Join(cfg.Notifications.QuietHours, ", "))
+	}
+	if name, reason := cfg.ProfileName(); name != "" {
+		fmt.Fprintf(w, "  Profile:      %s (%s; see 'envdrift-agent profile list')\n", name, reason)
+	}
+	if names := config.EnvOverrides(); len(names) > 0 {
+		fmt.Fprintf(w, "  Overridden:   %s\n", strings.Join(names, ", "))
+	}
+
+	return nil
+}
+
+// writeSchedules prints, for status, the quiet and deep work hours now is
+// in, with when they end.
+func writeSchedules(w io.Writer, out *ui.UI, cfg *config.Config, now time.Time) {
+	if quiet := cfg.Notifications.QuietWindows(); quiet.Contains(now) {
+		fmt.Fprintf(w, "Quiet:     %s\n", out.Paint(ui.Yellow, "holding notifications until "+quiet.End(now).Format("15:04")))
+	}
+	if deep := cfg.Guardian.DeepWorkWindows(); deep.Contains(now) {
+		fmt.Fprintf(w, "Deep work: %s\n", out.Paint(ui.Yellow,
+			"holding encryption until "+deep.End(now).Format("15:04")+" unless the screen is locked"))
+	}
+}
 
+// retentionString describes a keep setting: "after 90d", or "never" for 0.
+func retentionString(keep time.Duration) string {
+	switch {
+	case keep == 0:
+		return "never"
+	case keep%(24*time.Hour) == 0:
+		return fmt.Sprintf("after %dd", keep/(24*time.Hour))
+	}
+	return "after " + keep.String()
+}
+
+// runConfigSet writes one setting to the config file.
+func runConfigSet(cmd *cobra.Command, args []string) error {
+	if err := config.Set(args[0], args[1]); err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Set %s = %s in %s\n", args[0], args[1], config.ConfigPath())
 	return nil
 }
 
-// configureLogOutput routes the stdlib logger to a size-rotated file (#494):
-// the launchd plist passes --log-file because StandardOutPath cannot rotate
-// and /tmp/envdrift-agent.log previously grew without bound. It returns the
-// writer for the caller to close on shutdown.
-func configureLogOutput(path string) (io.Closer, error) {
-	w, err := logging.NewRotatingWriter(path, logging.DefaultMaxBytes, logging.DefaultBackups)
+// configureLogOutput routes the stdlib logger to a file rotated at the
+// configured [logs] size (#494): the launchd plist passes --log-file because
+// StandardOutPath cannot rotate and /tmp/envdrift-agent.log previously grew
+// without bound. It returns the writer for the caller to close on shutdown.
+func configureLogOutput(path string, logs config.LogsConfig) (io.Closer, error) {
+	w, err := logging.NewRotatingWriter(path, logs.MaxSize, logs.Backups)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open log file %s: %w", path, err)
 	}
 	log.SetOutput(w)
 	return w, nil
 }
+
+// useJournald sends the guardian's file decisions and significant events to
+// the systemd journal as structured entries and, with logLines (no
+// --log-file), the log as well, in place of stdout. journald stamps each
+// entry, so the logger's own timestamp is dropped. It returns the journal
+// connection for the caller to close on shutdown.
+func useJournald(logLines bool) (io.Closer, error) {
+	if runtime.GOOS != "linux" {
+		return nil, errors.New("the systemd journal is only available on Linux")
+	}
+	j, err := systemlog.OpenJournald()
+	if err != nil {
+		return nil, fmt.Errorf("connect to the systemd journal: %w", err)
+	}
+	systemlog.UseJournald(j)
+	if logLines {
+		log.SetOutput(j)
+		log.SetFlags(0)
+	}
+	return j, nil
+}
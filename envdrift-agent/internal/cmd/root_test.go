@@ -10,6 +10,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/jainal09/envdrift-agent/internal/config"
 	"github.com/jainal09/envdrift-agent/internal/daemon"
 )
 
@@ -98,7 +99,7 @@ func TestConfigureLogOutput(t *testing.T) {
 	t.Cleanup(func() { log.SetOutput(prev) })
 
 	path := filepath.Join(t.TempDir(), "logs", "agent.log")
-	closer, err := configureLogOutput(path)
+	closer, err := configureLogOutput(path, config.DefaultConfig().Logs)
 	if err != nil {
 		t.Fatalf("configureLogOutput: %v", err)
 	}
@@ -126,7 +127,7 @@ func TestConfigureLogOutput_BadPathErrors(t *testing.T) {
 	if err := os.WriteFile(parent, []byte("x"), 0o644); err != nil {
 		t.Fatal(err)
 	}
-	if _, err := configureLogOutput(filepath.Join(parent, "agent.log")); err == nil {
+	if _, err := configureLogOutput(filepath.Join(parent, "agent.log"), config.DefaultConfig().Logs); err == nil {
 		t.Fatal("configureLogOutput must fail when the log path cannot be created")
 	}
 }
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jainal09/envdrift-agent/internal/config"
+	"github.com/jainal09/envdrift-agent/internal/guardian"
+	"github.com/jainal09/envdrift-agent/internal/ui"
+)
+
+var runOnceCmd = &cobra.Command{
+	Use:   "run-once",
+	Short: "Encrypt every plaintext env file of the registered projects, then exit",
+	Long: `Sweeps the enabled registered projects once and encrypts each plaintext env
+file their patterns select, without waiting for it to go idle. Files held by
+an edit or export session, open in another process, or in a repository git is
+writing are skipped. Independent files are encrypted in parallel, --jobs at
+a time. For cron and machines without the agent installed:
+
+  */30 * * * * envdrift-agent run-once --quiet
+
+Progress is a bar on a terminal and a line every few seconds otherwise;
+--quiet prints failures only. The exit status is non-zero when a file could
+not be encrypted.`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runRunOnce,
+}
+
+// Flags for run-once.
+var (
+	runOnceQuiet bool
+	runOnceJobs  int
+)
+
+// init registers the run-once command with rootCmd.
+func init() {
+	runOnceCmd.Flags().BoolVarP(&runOnceQuiet, "quiet", "q", false, "print failures only (for cron)")
+	runOnceCmd.Flags().IntVarP(&runOnceJobs, "jobs", "j", guardian.DefaultRunOnceWorkers(), "files to encrypt at a time")
+	rootCmd.AddCommand(runOnceCmd)
+}
+
+// runRunOnce runs one guardian sweep, reporting progress and a summary.
+func runRunOnce(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	g, err := guardian.New(cfg.Effective())
+	if err != nil {
+		return err
+	}
+	g.Version = Version
+
+	w := cmd.OutOrStdout()
+	var bar *ui.Bar
+	var progress func(guardian.Progress)
+	if !runOnceQuiet {
+		progress = func(p guardian.Progress) {
+			if bar == nil {
+				bar = ui.New(w).Bar("Encrypting", p.Total)
+			}
+			bar.Set(p.Done)
+		}
+	}
+	res, err := g.RunOnce(context.Background(), runOnceJobs, progress)
+	if bar != nil {
+		bar.Finish()
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, e := range res.Failed {
+		fmt.Fprintf(cmd.ErrOrStderr(), "Failed: %v\n", e)
+	}
+	if !runOnceQuiet {
+		fmt.Fprintf(w, "Encrypted %d, already encrypted %d, skipped %d, failed %d\n",
+			res.Encrypted, res.Already, res.Skipped, len(res.Failed))
+	}
+	if len(res.Failed) > 0 {
+		return fmt.Errorf("%d file(s) could not be encrypted", len(res.Failed))
+	}
+	return nil
+}
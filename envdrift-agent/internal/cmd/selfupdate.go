@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jainal09/envdrift-agent/internal/config"
+	"github.com/jainal09/envdrift-agent/internal/daemon"
+	"github.com/jainal09/envdrift-agent/internal/update"
+)
+
+var selfUpdateCmd = &cobra.Command{
+	Use:   "self-update",
+	Short: "Replace this binary with the latest release",
+	Long: `Downloads the newest agent release on the update channel, verifies it
+against the release checksums and replaces this binary after showing the
+release notes and asking for confirmation (--yes skips it).
+
+The channel is [update] channel in guardian.toml ("stable" by default) or
+--channel. "beta" also offers pre-releases. A release older than the running
+binary is never installed, so leaving beta waits for the next stable release
+instead of downgrading.
+
+A binary installed by Homebrew, Scoop or apt is left to its package manager;
+'envdrift-agent install-info' shows the command to use.`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runSelfUpdate,
+}
+
+var (
+	selfUpdateChannel string
+	selfUpdateYes     bool
+)
+
+// latestRelease and applyRelease reach GitHub; tests replace them.
+var (
+	latestRelease = update.Latest
+	applyRelease  = update.Apply
+)
+
+// init registers the self-update command with rootCmd.
+func init() {
+	selfUpdateCmd.Flags().StringVar(&selfUpdateChannel, "channel", "", `"stable" or "beta" (default: [update] channel)`)
+	selfUpdateCmd.Flags().BoolVar(&selfUpdateYes, "yes", false, "skip the confirmation prompt")
+	rootCmd.AddCommand(selfUpdateCmd)
+}
+
+// runSelfUpdate installs the latest release on the channel when it is newer
+// than the running binary.
+func runSelfUpdate(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	channel := cfg.Update.Channel
+	if selfUpdateChannel != "" {
+		if err := update.ValidateChannel(selfUpdateChannel); err != nil {
+			return err
+		}
+		channel = selfUpdateChannel
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	if exe, err = filepath.EvalSymlinks(exe); err != nil {
+		return err
+	}
+	if method := update.DetectMethod(InstallMethod, exe); method != update.MethodManual {
+		return fmt.Errorf("envdrift-agent was installed with %s; update it with: %s", method, update.UpgradeCommand(method))
+	}
+	if _, err := update.Compare(Version, Version); err != nil {
+		return fmt.Errorf("cannot self-update a development build (version %q); install a release instead", Version)
+	}
+
+	rel, err := latestRelease(cmd.Context(), channel)
+	if err != nil {
+		return err
+	}
+	w := cmd.OutOrStdout()
+	newer, err := update.Compare(rel.Version, Version)
+	if err != nil {
+		return err
+	}
+	switch {
+	case newer == 0:
+		fmt.Fprintf(w, "envdrift-agent %s is the latest %s release\n", Version, channel)
+		return nil
+	case newer < 0:
+		fmt.Fprintf(w, "envdrift-agent %s is newer than the latest %s release (%s); not downgrading\n",
+			Version, channel, rel.Version)
+		return nil
+	}
+
+	fmt.Fprintf(w, "Update available on the %s channel: %s -> %s\n", channel, Version, rel.Version)
+	if rel.Notes != "" {
+		fmt.Fprintf(w, "\nRelease notes:\n%s\n\n", rel.Notes)
+	}
+	if !selfUpdateYes && !askYesNo(promptInput(cmd), w, "Install "+rel.Version+"?") {
+		return errors.New("update cancelled (--yes skips the prompt)")
+	}
+
+	if err := applyRelease(cmd.Context(), rel, exe); err != nil {
+		return fmt.Errorf("update failed (%s unchanged): %w", exe, err)
+	}
+	fmt.Fprintf(w, "✅ Updated %s to %s\n", exe, rel.Version)
+	if daemon.IsRunning() {
+		fmt.Fprintln(w, "The running agent keeps the old version until it restarts (next login or reboot).")
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/jainal09/envdrift-agent/internal/update"
+)
+
+func TestSelfUpdateChannelAndDowngradeProtection(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	origVersion, origLatest, origApply, origTerm := Version, latestRelease, applyRelease, stdinIsTerminal
+	t.Cleanup(func() {
+		Version, latestRelease, applyRelease, stdinIsTerminal = origVersion, origLatest, origApply, origTerm
+		selfUpdateChannel, selfUpdateYes = "", false
+		selfUpdateCmd.SetOut(nil)
+	})
+	stdinIsTerminal = func() bool { return false }
+
+	releases := map[string]*update.Release{
+		update.ChannelStable: {Version: "1.1.4", Notes: "stable fixes"},
+		update.ChannelBeta:   {Version: "1.2.0-beta.1", Prerelease: true, Notes: "beta features"},
+	}
+	var asked []string
+	latestRelease = func(ctx context.Context, channel string) (*update.Release, error) {
+		asked = append(asked, channel)
+		return releases[channel], nil
+	}
+	var applied []string
+	applyRelease = func(ctx context.Context, rel *update.Release, exe string) error {
+		applied = append(applied, rel.Version)
+		return nil
+	}
+	var out bytes.Buffer
+	selfUpdateCmd.SetOut(&out)
+	selfUpdateCmd.SetContext(context.Background())
+
+	Version = "dev"
+	if err := runSelfUpdate(selfUpdateCmd, nil); err == nil || !strings.Contains(err.Error(), "development build") {
+		t.Errorf("dev build error = %v", err)
+	}
+
+	// Beta shows the notes and, without --yes or a terminal, installs nothing.
+	Version = "1.1.4"
+	selfUpdateChannel = "beta"
+	if err := runSelfUpdate(selfUpdateCmd, nil); err == nil {
+		t.Error("update without confirmation succeeded")
+	}
+	if !strings.Contains(out.String(), "1.1.4 -> 1.2.0-beta.1") || !strings.Contains(out.String(), "beta features") {
+		t.Errorf("output = %q; want the version change and release notes", out.String())
+	}
+	selfUpdateYes = true
+	if err := runSelfUpdate(selfUpdateCmd, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// Back on stable, the older stable release is not installed over the beta.
+	Version = "1.2.0-beta.1"
+	selfUpdateChannel = ""
+	out.Reset()
+	if err := runSelfUpdate(selfUpdateCmd, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "not downgrading") {
+		t.Errorf("output = %q; want downgrade protection", out.String())
+	}
+	if len(applied) != 1 || applied[0] != "1.2.0-beta.1" {
+		t.Errorf("applied %v; want only the beta", applied)
+	}
+	if got := strings.Join(asked, ","); got != "beta,beta,stable" {
+		t.Errorf("channels queried = %s", got)
+	}
+
+	selfUpdateChannel = "nightly"
+	if err := runSelfUpdate(selfUpdateCmd, nil); err == nil {
+		t.Error("--channel nightly accepted")
+	}
+}
+
+func TestSelfUpdateDefersToPackageManager(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	origMethod, origVersion, origLatest := InstallMethod, Version, latestRelease
+	t.Cleanup(func() {
+		InstallMethod, Version, latestRelease = origMethod, origVersion, origLatest
+		installInfoCmd.SetOut(nil)
+	})
+	InstallMethod, Version = update.MethodBrew, "1.1.4"
+	latestRelease = func(ctx context.Context, channel string) (*update.Release, error) {
+		t.Error("a managed install queried GitHub")
+		return nil, nil
+	}
+
+	err := runSelfUpdate(selfUpdateCmd, nil)
+	if err == nil || !strings.Contains(err.Error(), "brew upgrade envdrift-agent") {
+		t.Errorf("self-update on a brew install = %v; want the brew command", err)
+	}
+
+	var out bytes.Buffer
+	installInfoCmd.SetOut(&out)
+	if err := runInstallInfo(installInfoCmd, nil); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"Version:     1.1.4", "Installed:   brew (build)", "Update with: brew upgrade"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("install-info = %q; missing %q", out.String(), want)
+		}
+	}
+}
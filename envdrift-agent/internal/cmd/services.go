@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jainal09/envdrift-agent/internal/encrypt"
+	"github.com/jainal09/envdrift-agent/internal/project"
+	"github.com/jainal09/envdrift-agent/internal/ui"
+)
+
+var servicesCmd = &cobra.Command{
+	Use:   "services",
+	Short: "Report each monorepo service's env files by environment",
+	Long: `Lists the [[services]] of the envdrift.toml governing --dir. For each env file in
+a service's directory it shows the environment (.env.<env>, or the service's
+environment for a bare .env), whether the file is encrypted and which keys of
+the service's .env.example (or .env.sample) it lacks. Only key names are
+read; nothing is decrypted.
+
+  [[services]]
+  name = "payments-service"
+  path = "services/payments"`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runServices,
+}
+
+// servicesDir is the --dir flag.
+var servicesDir string
+
+// schemaFiles are the files listing the keys a service's env files need, in
+// order of preference.
+var schemaFiles = []string{".env.example", ".env.sample"}
+
+// init registers the services command with rootCmd.
+func init() {
+	servicesCmd.Flags().StringVar(&servicesDir, "dir", ".", "project directory")
+	rootCmd.AddCommand(servicesCmd)
+}
+
+// runServices prints one row per service env file, with a spinner while the
+// services are read on a terminal.
+func runServices(cmd *cobra.Command, args []string) error {
+	dir, err := filepath.Abs(servicesDir)
+	if err != nil {
+		return err
+	}
+	services, err := project.LoadServices(dir)
+	if err != nil {
+		return err
+	}
+	w := cmd.OutOrStdout()
+	if len(services) == 0 {
+		fmt.Fprintln(w, "No [[services]] configured in envdrift.toml")
+		return nil
+	}
+	sort.Slice(services, func(i, j int) bool { return services[i].Name < services[j].Name })
+	out := ui.New(w)
+	spin := out.Spinner(fmt.Sprintf("Reading %d services...", len(services)))
+	var rows [][]string
+	for _, s := range services {
+		r, err := serviceRows(s)
+		if err != nil {
+			spin.Stop()
+			return err
+		}
+		rows = append(rows, r...)
+	}
+	spin.Stop()
+	out.Table([]string{"SERVICE", "ENVIRONMENT", "STATE", "KEYS"}, rows)
+	return nil
+}
+
+// serviceRows returns the report rows of one service's env files.
+func serviceRows(s project.Service) ([][]string, error) {
+	if _, err := os.Stat(s.Path); err != nil {
+		return [][]string{{s.Name, "-", "-", err.Error()}}, nil
+	}
+	var rows [][]string
+	schema, schemaName := serviceSchema(s.Path)
+	for _, path := range s.EnvFiles() {
+		state := "plaintext"
+		if encrypted, err := encrypt.IsEncrypted(path); err != nil {
+			return nil, err
+		} else if encrypted {
+			state = "encrypted"
+		}
+		rows = append(rows, []string{s.Name, s.EnvironmentOf(path), state, missingKeys(path, schema, schemaName)})
+	}
+	if len(rows) == 0 {
+		rows = append(rows, []string{s.Name, "-", "-", "no env files in " + s.Path})
+	}
+	return rows, nil
+}
+
+// serviceSchema returns the key names of the service's schema file and its
+// name, or nil when it has none.
+func serviceSchema(dir string) ([]string, string) {
+	for _, name := range schemaFiles {
+		if keys, err := encrypt.KeyNames(filepath.Join(dir, name)); err == nil {
+			return keys, name
+		}
+	}
+	return nil, ""
+}
+
+// missingKeys describes which schema keys the env file at path lacks.
+func missingKeys(path string, schema []string, schemaName string) string {
+	if schemaName == "" {
+		return "no .env.example to compare"
+	}
+	names, err := encrypt.KeyNames(path)
+	if err != nil {
+		return err.Error()
+	}
+	have := make(map[string]bool, len(names))
+	for _, n := range names {
+		have[n] = true
+	}
+	var missing []string
+	for _, k := range schema {
+		if !have[k] {
+			missing = append(missing, k)
+		}
+	}
+	switch len(missing) {
+	case 0:
+		return "all " + schemaName + " keys present"
+	case 1:
+		return "missing 1 key: " + missing[0]
+	default:
+		return fmt.Sprintf("missing %d keys: %s", len(missing), strings.Join(missing, ", "))
+	}
+}
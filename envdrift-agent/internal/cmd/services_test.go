@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestServicesReport(t *testing.T) {
+	root := t.TempDir()
+	payments := filepath.Join(root, "services", "payments")
+	if err := os.MkdirAll(payments, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		filepath.Join(root, "envdrift.toml"):     "[[services]]\nname = \"payments-service\"\npath = \"services/payments\"\n",
+		filepath.Join(payments, ".env.example"):  "DB_URL=\nSTRIPE_KEY=\nPORT=\n",
+		filepath.Join(payments, ".env.staging"):  "DB_URL=\"encrypted:abc\"\n",
+		filepath.Join(payments, ".env"):          "DB_URL=x\nSTRIPE_KEY=y\nPORT=1\n",
+		filepath.Join(payments, ".env.keys"):     "DOTENV_PRIVATE_KEY_STAGING=k\n",
+		filepath.Join(payments, ".env.staging~"): "stale\n",
+		filepath.Join(payments, "README.md"):     "docs\n",
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	prev := servicesDir
+	servicesDir = filepath.Join(root, "services")
+	var out bytes.Buffer
+	servicesCmd.SetOut(&out)
+	t.Cleanup(func() {
+		servicesDir = prev
+		servicesCmd.SetOut(nil)
+	})
+
+	if err := runServices(servicesCmd, nil); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("report = %q", out.String())
+	}
+	if f := strings.Fields(lines[0]); f[0] != "payments-service" || f[1] != "production" || f[2] != "plaintext" {
+		t.Errorf(".env line = %q", lines[0])
+	}
+	if !strings.Contains(lines[1], "staging") || !strings.Contains(lines[1], "encrypted") ||
+		!strings.Contains(lines[1], "missing 2 keys: STRIPE_KEY, PORT") {
+		t.Errorf(".env.staging line = %q", lines[1])
+	}
+}
@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jainal09/envdrift-agent/internal/config"
+	"github.com/jainal09/envdrift-agent/internal/telemetry"
+)
+
+var telemetryCmd = &cobra.Command{
+	Use:   "telemetry",
+	Short: "Inspect opt-in usage telemetry",
+	Long: `Telemetry is off unless enabled with
+
+  envdrift-agent config set telemetry.enabled true
+
+Counts are kept in telemetry.json in the state directory and are only sent when
+[telemetry] endpoint is set. They never include paths, names or values.`,
+}
+
+var telemetryShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Print the telemetry buffered on this machine",
+	Long: `Prints the telemetry status and the buffered counts exactly as they would be
+sent. Only completed days are sent; today's counts are shown but held back.`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runTelemetryShow,
+}
+
+// init registers the telemetry command group with rootCmd.
+func init() {
+	telemetryCmd.AddCommand(telemetryShowCmd)
+	rootCmd.AddCommand(telemetryCmd)
+}
+
+// runTelemetryShow prints the status and the unsent report.
+func runTelemetryShow(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	store := &telemetry.Store{Path: telemetry.DefaultPath()}
+	report, err := store.Unsent(Version, time.Now().AddDate(0, 0, 1))
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	endpoint := cfg.Telemetry.Endpoint
+	if endpoint == "" {
+		endpoint = "none (kept local)"
+	}
+	_, _ = fmt.Fprintf(out, "Enabled:  %v\n", cfg.Telemetry.Enabled)
+	_, _ = fmt.Fprintf(out, "Endpoint: %s\n", endpoint)
+	_, _ = fmt.Fprintf(out, "Buffer:   %s\n\n", store.Path)
+	_, _ = fmt.Fprintf(out, "%s\n", data)
+	return nil
+}
@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/jainal09/envdrift-agent/internal/config"
+)
+
+func TestConfigSetEnablesTelemetryShow(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	var out bytes.Buffer
+	configSetCmd.SetOut(&out)
+	telemetryShowCmd.SetOut(&out)
+	t.Cleanup(func() {
+		configSetCmd.SetOut(nil)
+		telemetryShowCmd.SetOut(nil)
+	})
+
+	if err := runTelemetryShow(telemetryShowCmd, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "Enabled:  false") || !strings.Contains(out.String(), "kept local") {
+		t.Errorf("default show = %q", out.String())
+	}
+
+	if err := runConfigSet(configSetCmd, []string{"telemetry.enabled", "true"}); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.Load()
+	if err != nil || !cfg.Telemetry.Enabled {
+		t.Fatalf("telemetry.enabled after config set = %v, %v", cfg.Telemetry.Enabled, err)
+	}
+
+	out.Reset()
+	if err := runTelemetryShow(telemetryShowCmd, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "Enabled:  true") || !strings.Contains(out.String(), `"days": {}`) {
+		t.Errorf("show after enabling = %q", out.String())
+	}
+}
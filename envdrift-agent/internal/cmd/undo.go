@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jainal09/envdrift-agent/internal/encrypt"
+	"github.com/jainal09/envdrift-agent/internal/exports"
+	"github.com/jainal09/envdrift-agent/internal/history"
+)
+
+var undoCmd = &cobra.Command{
+	Use:   "undo <path>",
+	Short: "Restore the plaintext a file had before the agent encrypted it",
+	Long: `Writes the newest pre-encryption backup back over the file and snoozes it, so
+the agent leaves it in plaintext for --for before encrypting it again: for
+when the agent encrypted a file you were still working on. Needs a backup,
+so [backups] enabled must have been on when the file was encrypted.
+
+If the encrypted file changed after the backup was taken, undo asks before
+overwriting it (--yes skips the question). 'envdrift-agent edit --done <path>'
+re-encrypts early; the restore is recorded in the history log.`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE:         runUndo,
+}
+
+// Flags for undo.
+var (
+	undoFor time.Duration
+	undoYes bool
+)
+
+// init registers the undo command with rootCmd.
+func init() {
+	undoCmd.Flags().DurationVar(&undoFor, "for", 15*time.Minute, "how long the agent leaves the restored file in plaintext")
+	undoCmd.Flags().BoolVar(&undoYes, "yes", false, "restore even if the file changed after it was encrypted")
+	rootCmd.AddCommand(undoCmd)
+}
+
+// runUndo restores path's newest backup and snoozes it.
+func runUndo(cmd *cobra.Command, args []string) error {
+	path, err := filepath.Abs(args[0])
+	if err != nil {
+		return err
+	}
+	if undoFor <= 0 {
+		return errors.New("--for must be positive")
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	encrypted, err := encrypt.IsEncrypted(path)
+	if err != nil {
+		return err
+	}
+	if !encrypted {
+		return fmt.Errorf("%s is not encrypted; nothing to undo", path)
+	}
+	store := backupStore()
+	list, err := store.List(path)
+	if err != nil {
+		return err
+	}
+	if len(list) == 0 {
+		return fmt.Errorf("no backups of %s ([backups] enabled keeps one before each encryption)", path)
+	}
+	b := list[0]
+
+	w := cmd.OutOrStdout()
+	// The backup is taken just before encrypting, so a file modified well
+	// after it has changed since, and restoring would lose that change.
+	if info.ModTime().After(b.Time.Add(time.Minute)) && !undoYes {
+		question := fmt.Sprintf("%s changed after it was encrypted at %s; replace it with the plaintext from then?",
+			path, b.Time.Local().Format(time.DateTime))
+		if !askYesNo(promptInput(cmd), w, question) {
+			return errors.New("undo cancelled (--yes skips the question)")
+		}
+	}
+	if err := history.Record(history.Entry{Action: history.ActionRestore, Path: path,
+		Detail: "backup=" + b.ID + " undo for=" + undoFor.String()}); err != nil {
+		return fmt.Errorf("refusing to restore: could not record access in history: %w", err)
+	}
+	// Snooze before restoring so the agent never sees the plaintext file
+	// without it.
+	until := time.Now().Add(undoFor)
+	if err := exports.Add(exports.Export{Path: path, Source: path, Expires: until, Action: exports.ActionEncrypt}); err != nil {
+		return err
+	}
+	if err := store.Restore(b); err != nil {
+		return errors.Join(err, exports.Remove(path))
+	}
+	fmt.Fprintf(w, "Restored %s as it was before %s; the agent re-encrypts it at %s\n",
+		path, b.Time.Local().Format(time.DateTime), until.Local().Format(time.Kitchen))
+	return nil
+}
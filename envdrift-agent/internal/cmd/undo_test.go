@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jainal09/envdrift-agent/internal/exports"
+	"github.com/jainal09/envdrift-agent/internal/history"
+)
+
+func TestUndo(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	origTerm := stdinIsTerminal
+	stdinIsTerminal = func() bool { return false }
+	t.Cleanup(func() {
+		stdinIsTerminal = origTerm
+		undoYes = false
+		undoCmd.SetOut(nil)
+	})
+	var out bytes.Buffer
+	undoCmd.SetOut(&out)
+
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(path, []byte("SECRET=plain\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := runUndo(undoCmd, []string{path}); err == nil || !strings.Contains(err.Error(), "not encrypted") {
+		t.Fatalf("undo of a plaintext file = %v; want a not-encrypted error", err)
+	}
+	if _, err := backupStore().Create(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("SECRET=\"encrypted:x\"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// Changed well after the backup: not restored without confirmation.
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if err := runUndo(undoCmd, []string{path}); err == nil || !strings.Contains(err.Error(), "cancelled") {
+		t.Fatalf("undo of a file changed since = %v; want it cancelled", err)
+	}
+
+	undoYes = true
+	if err := runUndo(undoCmd, []string{path}); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "SECRET=plain\n" {
+		t.Errorf("restored %q", data)
+	}
+	if e, held := exports.Pending(path, time.Now()); !held || e.Action != exports.ActionEncrypt || e.Edit {
+		t.Errorf("pending = %+v, %v; want a snooze that re-encrypts", e, held)
+	}
+	entries, err := history.Read()
+	if err != nil || len(entries) != 1 || entries[0].Action != history.ActionRestore || !strings.Contains(entries[0].Detail, "undo") {
+		t.Errorf("history = %+v, %v", entries, err)
+	}
+}
+
+func TestUndoWithoutBackups(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(path, []byte("SECRET=\"encrypted:x\"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := runUndo(undoCmd, []string{path}); err == nil || !strings.Contains(err.Error(), "no backups") {
+		t.Errorf("undo without backups = %v; want a no-backups error", err)
+	}
+}
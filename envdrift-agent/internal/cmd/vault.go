@@ -0,0 +1,402 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jainal09/envdrift-agent/internal/config"
+	"github.com/jainal09/envdrift-agent/internal/keys"
+	"github.com/jainal09/envdrift-agent/internal/project"
+	"github.com/jainal09/envdrift-agent/internal/vault"
+	"github.com/jainal09/envdrift-agent/internal/vaultsync"
+)
+
+var vaultCmd = &cobra.Command{
+	Use:   "vault",
+	Short: "Sync dotenvx private keys with the project's configured vault",
+}
+
+var vaultPullCmd = &cobra.Command{
+	Use:   "pull",
+	Short: "Fetch private keys from the vault into the project's .env.keys",
+	Long: `Reads the [[vault.sync.mappings]] for --dir and the folders below it from
+envdrift.toml, fetches each mapped secret, and writes DOTENV_PRIVATE_KEY_<ENV>
+into the folder's .env.keys. Up to --jobs secrets are fetched at a time, within
+the provider's request rate; a key that fails does not stop the others, and
+every failure is reported at the end.
+
+AWS credentials come from the AWS CLI's default chain (environment, SSO
+sessions, assumed-role profiles, web identity, instance metadata); --profile
+and --region override [vault.aws] profile and region. Azure tokens follow
+DefaultAzureCredential's order (environment, workload identity, managed
+identity, az login), with [vault.azure] tenant_id/client_id per project.
+HashiCorp Vault reads VAULT_TOKEN (or AppRole VAULT_ROLE_ID/VAULT_SECRET_ID);
+GCP uses Application Default Credentials.
+
+A local key that differs from the vault and has changed since the last pull
+or sync is a conflict: the vault's version and timestamps are shown and the
+key is only replaced after confirmation, or with --force.
+
+  envdrift-agent vault pull --profile corp-sso --region eu-west-1`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runVaultPull,
+}
+
+var vaultPushCmd = &cobra.Command{
+	Use:   "push",
+	Short: "Upload the project's private keys from .env.keys to the vault",
+	Long: `Reads the [[vault.sync.mappings]] for --dir and the folders below it from
+envdrift.toml and stores each folder's DOTENV_PRIVATE_KEY_<ENV> from .env.keys
+in its mapped secret, up to --jobs at a time as for pull.
+
+A vault secret that differs from the local key and has changed since the last
+pull or sync (for example a key a teammate just rotated) is a conflict: its
+version and timestamps are shown and it is only overwritten after
+confirmation, or with --force. Credentials and --profile/--region work as for
+pull.`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runVaultPush,
+}
+
+var vaultSyncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Run one vault key sync pass for a project",
+	Long: `Runs the same reconciliation the agent performs every [vault_sync] interval:
+keys missing locally are written, keys rotated in the vault replace local keys
+that have not changed since the last sync, and keys changed on both sides are
+reported as conflicts and left untouched — unless confirmed interactively or
+overridden with --force, which lets the vault key win. Up to --jobs mappings
+are reconciled at a time.`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runVaultSync,
+}
+
+var (
+	vaultDir     string
+	vaultProfile string
+	vaultRegion  string
+	vaultForce   bool
+	vaultJobs    int
+)
+
+// init registers the vault command group with rootCmd.
+func init() {
+	vaultPullCmd.Flags().StringVar(&vaultDir, "dir", ".", "project folder whose mappings are pulled")
+	vaultPushCmd.Flags().StringVar(&vaultDir, "dir", ".", "project folder whose mappings are pushed")
+	for _, c := range []*cobra.Command{vaultPullCmd, vaultPushCmd} {
+		c.Flags().IntVarP(&vaultJobs, "jobs", "j", vaultsync.DefaultWorkers, "keys to transfer at a time")
+		c.Flags().StringVar(&vaultProfile, "profile", "", "AWS profile (overrides [vault.aws] profile)")
+		c.Flags().StringVar(&vaultRegion, "region", "", "AWS region (overrides [vault.aws] region)")
+	}
+	vaultPullCmd.Flags().BoolVar(&vaultForce, "force", false, "replace conflicting local keys without asking")
+	vaultPushCmd.Flags().BoolVar(&vaultForce, "force", false, "overwrite conflicting vault secrets without asking")
+	vaultSyncCmd.Flags().StringVar(&vaultDir, "dir", ".", "project directory to sync")
+	vaultSyncCmd.Flags().BoolVar(&vaultForce, "force", false, "let the vault key win every conflict")
+	vaultSyncCmd.Flags().IntVarP(&vaultJobs, "jobs", "j", vaultsync.DefaultWorkers, "keys to sync at a time")
+	vaultCmd.AddCommand(vaultPullCmd, vaultPushCmd, vaultSyncCmd)
+	rootCmd.AddCommand(vaultCmd)
+}
+
+// newVaultProvider is the provider constructor the vault commands use; tests
+// replace it.
+var newVaultProvider = vault.New
+
+// runVaultPull writes every key mapped to --dir into its .env.keys.
+func runVaultPull(cmd *cobra.Command, _ []string) error {
+	dir, provider, mappings, err := openProjectVault()
+	if err != nil {
+		return err
+	}
+	return pullKeys(context.Background(), cmd.OutOrStdout(), provider, mappings, dir, newConflictPolicy(cmd, vaultForce))
+}
+
+// runVaultPush uploads every key mapped to --dir from its .env.keys.
+func runVaultPush(cmd *cobra.Command, _ []string) error {
+	dir, provider, mappings, err := openProjectVault()
+	if err != nil {
+		return err
+	}
+	return pushKeys(context.Background(), cmd.OutOrStdout(), provider, mappings, dir, newConflictPolicy(cmd, vaultForce))
+}
+
+// openProjectVault loads the vault settings for --dir, applies the AWS
+// --profile/--region overrides, and builds the provider, limited to the
+// provider's request rate. Reads refresh the [vault_cache] and writes drop
+// the secret from it, so later cached key lookups see what was pulled or
+// pushed.
+func openProjectVault() (string, vault.Provider, []project.VaultMapping, error) {
+	dir, err := filepath.Abs(vaultDir)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return "", nil, nil, err
+	}
+	settings, found, err := project.LoadVaultSettings(dir)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	if !found {
+		return "", nil, nil, fmt.Errorf("no [vault] configuration found for %s", dir)
+	}
+	if vaultProfile != "" || vaultRegion != "" {
+		if settings.Config.Provider != "aws" {
+			return "", nil, nil, fmt.Errorf("--profile and --region apply to the aws provider, not %s", settings.Config.Provider)
+		}
+		if vaultProfile != "" {
+			settings.Config.AWS.Profile = vaultProfile
+		}
+		if vaultRegion != "" {
+			settings.Config.AWS.Region = vaultRegion
+		}
+	}
+
+	provider, err := newVaultProvider(settings.Config)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	provider = vault.Limit(provider)
+	if c := newVaultCache(cfg); c != nil {
+		provider = c.Refresh(settings.Config, provider)
+	}
+	return filepath.Clean(dir), provider, settings.Mappings, nil
+}
+
+// pullKeys fetches the mappings under dir, vaultJobs at a time, and writes
+// their keys to .env.keys. A differing local key that changed since the last
+// sync is a conflict and is only replaced when policy allows it. Every
+// mapping is tried; the failures are returned together.
+func pullKeys(ctx context.Context, w io.Writer, provider vault.Provider, mappings []project.VaultMapping, dir string, policy *conflictPolicy) error {
+	syncer := newVaultSyncer(keys.SourceDotenvKeys)
+	var local sync.Mutex
+	return eachMapping(ctx, w, mappings, dir, func(m project.VaultMapping) (string, error) {
+		keyName := keys.KeyName(m.Environment)
+		raw, err := provider.GetSecret(ctx, m.SecretName)
+		if errors.Is(err, vault.ErrNotFound) {
+			return "", fmt.Errorf("%s secret %s does not exist", provider.Name(), m.SecretName)
+		}
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", m.SecretName, err)
+		}
+		value, err := vault.KeyMaterial(raw, keyName)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", m.SecretName, err)
+		}
+
+		local.Lock()
+		defer local.Unlock()
+		current, haveLocal, err := keys.LocalKey(ctx, keys.SourceDotenvKeys, m.FolderPath, keyName)
+		if err != nil {
+			return "", err
+		}
+		if haveLocal && current == value {
+			return fmt.Sprintf("%s already matches %s secret %s", keyName, provider.Name(), m.SecretName), nil
+		}
+		if haveLocal && !syncer.Synced(m.FolderPath, keyName, current) {
+			fmt.Fprintf(w, "Conflict: local %s differs from %s secret %s\n", keyName, provider.Name(), m.SecretName)
+			if !policy.allow(ctx, provider, m.SecretName, m.FolderPath, "Replace the local key with the vault's?") {
+				return "", conflictError(keyName, "local key")
+			}
+		}
+		if err := keys.WriteDotenvKey(m.FolderPath, keyName, value); err != nil {
+			return "", err
+		}
+		if err := syncer.Record(m.FolderPath, keyName, value); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Pulled %s from %s secret %s", keyName, provider.Name(), m.SecretName), nil
+	})
+}
+
+// pushKeys stores the .env.keys key of every mapping under dir in its vault
+// secret as a DOTENV_PRIVATE_KEY_<ENV>=<key> line, vaultJobs at a time. A
+// differing vault key that changed since the last sync is a conflict and is
+// only overwritten when policy allows it. Every mapping is tried; the
+// failures are returned together.
+func pushKeys(ctx context.Context, w io.Writer, provider vault.Provider, mappings []project.VaultMapping, dir string, policy *conflictPolicy) error {
+	syncer := newVaultSyncer(keys.SourceDotenvKeys)
+	return eachMapping(ctx, w, mappings, dir, func(m project.VaultMapping) (string, error) {
+		keyName := keys.KeyName(m.Environment)
+		local, haveLocal, err := keys.LocalKey(ctx, keys.SourceDotenvKeys, m.FolderPath, keyName)
+		if err != nil {
+			return "", err
+		}
+		if !haveLocal {
+			return "", fmt.Errorf("%s not found in %s", keyName, filepath.Join(m.FolderPath, ".env.keys"))
+		}
+
+		raw, err := provider.GetSecret(ctx, m.SecretName)
+		switch {
+		case errors.Is(err, vault.ErrNotFound):
+		case err != nil:
+			return "", fmt.Errorf("%s: %w", m.SecretName, err)
+		default:
+			remote, err := vault.KeyMaterial(raw, keyName)
+			if err != nil {
+				return "", fmt.Errorf("%s: %w", m.SecretName, err)
+			}
+			if remote == local {
+				return fmt.Sprintf("%s secret %s already holds %s", provider.Name(), m.SecretName, keyName), nil
+			}
+			if !syncer.Synced(m.FolderPath, keyName, remote) {
+				fmt.Fprintf(w, "Conflict: %s secret %s holds a different %s\n", provider.Name(), m.SecretName, keyName)
+				if !policy.allow(ctx, provider, m.SecretName, m.FolderPath, "Overwrite the vault secret with the local key?") {
+					return "", conflictError(keyName, "vault secret")
+				}
+			}
+		}
+
+		if err := provider.SetSecret(ctx, m.SecretName, keyName+"="+local); err != nil {
+			return "", fmt.Errorf("%s: %w", m.SecretName, err)
+		}
+		if err := syncer.Record(m.FolderPath, keyName, local); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Pushed %s to %s secret %s", keyName, provider.Name(), m.SecretName), nil
+	})
+}
+
+// eachMapping runs fn for every mapping under dir, vaultJobs at a time, then
+// prints the successes in mapping order. It fails when no mapping is under
+// dir, and returns the one failure, or all of them with a count.
+func eachMapping(ctx context.Context, w io.Writer, mappings []project.VaultMapping, dir string, fn func(project.VaultMapping) (string, error)) error {
+	mappings = vaultsync.Under(dir, mappings)
+	if len(mappings) == 0 {
+		return fmt.Errorf("no [[vault.sync.mappings]] entry under %s", dir)
+	}
+	lines := make([]string, len(mappings))
+	errs := make([]error, len(mappings))
+	for i := range errs {
+		errs[i] = ctx.Err()
+	}
+	vaultsync.ForEach(ctx, mappings, vaultJobs, func(i int, m project.VaultMapping) {
+		lines[i], errs[i] = fn(m)
+	})
+
+	var failed []error
+	for i, line := range lines {
+		if errs[i] != nil {
+			failed = append(failed, errs[i])
+			continue
+		}
+		fmt.Fprintln(w, line)
+	}
+	switch len(failed) {
+	case 0:
+		return nil
+	case 1:
+		return failed[0]
+	}
+	return fmt.Errorf("%d of %d keys failed:\n%w", len(failed), len(mappings), errors.Join(failed...))
+}
+
+func conflictError(keyName, kept string) error {
+	return fmt.Errorf("%s conflict: %s left unchanged; rerun with --force to overwrite it", keyName, kept)
+}
+
+// conflictPolicy decides whether a conflicting key may be overwritten:
+// --force always may, a user at a terminal is asked, anything else (scripts,
+// CI) is refused.
+type conflictPolicy struct {
+	force bool
+	in    *bufio.Reader // nil when nobody can answer
+	out   io.Writer
+
+	mu sync.Mutex // one conflict is shown and asked about at a time
+}
+
+func newConflictPolicy(cmd *cobra.Command, force bool) *conflictPolicy {
+	return &conflictPolicy{force: force, in: promptInput(cmd), out: cmd.OutOrStdout()}
+}
+
+// allow prints the non-secret metadata of both sides — the vault secret's
+// version and timestamps and the .env.keys modification time — and reports
+// whether the conflict may be overwritten.
+func (p *conflictPolicy) allow(ctx context.Context, provider vault.Provider, secretName, dir, question string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	fmt.Fprintf(p.out, "  vault: %s\n", vault.Describe(ctx, provider, secretName))
+	if fi, err := os.Stat(filepath.Join(dir, ".env.keys")); err == nil {
+		fmt.Fprintf(p.out, "  local: .env.keys modified %s\n", fi.ModTime().UTC().Format("2006-01-02 15:04 MST"))
+	}
+	return p.confirm(question)
+}
+
+// confirm asks question unless --force already answered it.
+func (p *conflictPolicy) confirm(question string) bool {
+	if p.force {
+		return true
+	}
+	return askYesNo(p.in, p.out, question)
+}
+
+// newVaultSyncer returns a syncer writing into target with the default state
+// file and the commands' provider constructor.
+func newVaultSyncer(target string) *vaultsync.Syncer {
+	return &vaultsync.Syncer{
+		Target:      target,
+		StatePath:   vaultsync.DefaultStatePath(),
+		NewProvider: newVaultProvider,
+	}
+}
+
+// runVaultSync runs one sync pass for --dir and prints each key's outcome.
+func runVaultSync(cmd *cobra.Command, _ []string) error {
+	dir, err := filepath.Abs(vaultDir)
+	if err != nil {
+		return err
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	w := cmd.OutOrStdout()
+	policy := newConflictPolicy(cmd, vaultForce)
+	syncer := newVaultSyncer(cfg.Effective().VaultSync.Target)
+	syncer.Workers = vaultJobs
+	if c := newVaultCache(cfg); c != nil {
+		syncer.NewProvider = func(vc vault.Config) (vault.Provider, error) {
+			p, err := newVaultProvider(vc)
+			if err != nil {
+				return nil, err
+			}
+			return c.Refresh(vc, p), nil
+		}
+	}
+	syncer.Resolve = func(r vaultsync.Result) bool {
+		fmt.Fprintf(w, "Conflict: %s (%s) differs from %s and changed locally\n  vault: %s\n", r.KeyName, r.Dir, r.SecretName, r.Remote)
+		return policy.confirm("Replace the local key with the vault's?")
+	}
+	results, err := syncer.SyncProject(context.Background(), dir)
+	if err != nil {
+		return err
+	}
+	if len(results) == 0 {
+		return fmt.Errorf("no [[vault.sync.mappings]] entry under %s", dir)
+	}
+	failed := 0
+	for _, r := range results {
+		line := fmt.Sprintf("%-10s %s (%s) <- %s", r.Outcome, r.KeyName, r.Dir, r.SecretName)
+		if r.Err != nil {
+			line += ": " + r.Err.Error()
+			failed++
+		}
+		fmt.Fprintln(w, line)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d key(s) failed to sync", failed)
+	}
+	return nil
+}
@@ -0,0 +1,231 @@
+// Package cmd tests for the vault command.
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/jainal09/envdrift-agent/internal/project"
+	"github.com/jainal09/envdrift-agent/internal/vault"
+)
+
+// fakeVault is an in-memory vault.Provider.
+type fakeVault map[string]string
+
+func (fakeVault) Name() string { return "aws" }
+
+func (f fakeVault) GetSecret(_ context.Context, name string) (string, error) {
+	v, ok := f[name]
+	if !ok {
+		return "", vault.ErrNotFound
+	}
+	return v, nil
+}
+
+func (f fakeVault) SetSecret(_ context.Context, name, value string) error {
+	f[name] = value
+	return nil
+}
+
+func TestVaultPullWritesKeysAndAppliesOverrides(t *testing.T) {
+	// pull records sync state in the state directory.
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "envdrift.toml"), []byte(`
+[vault.aws]
+region = "us-east-1"
+profile = "default-profile"
+
+[[vault.sync.mappings]]
+secret_name = "myapp/prod"
+folder_path = "."
+`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var gotCfg vault.Config
+	origNew := newVaultProvider
+	newVaultProvider = func(cfg vault.Config) (vault.Provider, error) {
+		gotCfg = cfg
+		return fakeVault{"myapp/prod": `{"DOTENV_PRIVATE_KEY_PRODUCTION": "abc123"}`}, nil
+	}
+	origDir, origProfile, origRegion := vaultDir, vaultProfile, vaultRegion
+	vaultDir, vaultProfile, vaultRegion = dir, "corp-sso", ""
+	t.Cleanup(func() {
+		newVaultProvider = origNew
+		vaultDir, vaultProfile, vaultRegion = origDir, origProfile, origRegion
+	})
+
+	var out bytes.Buffer
+	vaultPullCmd.SetOut(&out)
+	t.Cleanup(func() { vaultPullCmd.SetOut(nil) })
+	if err := runVaultPull(vaultPullCmd, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if gotCfg.AWS.Profile != "corp-sso" || gotCfg.AWS.Region != "us-east-1" {
+		t.Errorf("AWS config = %+v; want --profile override and config region", gotCfg.AWS)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, ".env.keys"))
+	if err != nil || string(data) != "DOTENV_PRIVATE_KEY_PRODUCTION=abc123\n" {
+		t.Errorf(".env.keys = %q, %v", data, err)
+	}
+	if !strings.Contains(out.String(), "Pulled DOTENV_PRIVATE_KEY_PRODUCTION from aws secret myapp/prod") {
+		t.Errorf("output = %q", out.String())
+	}
+}
+
+// lockedVault is a fakeVault safe for the concurrent calls pull and push make.
+type lockedVault struct {
+	mu    sync.Mutex
+	store fakeVault
+}
+
+func (*lockedVault) Name() string { return "aws" }
+
+func (l *lockedVault) GetSecret(ctx context.Context, name string) (string, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.store.GetSecret(ctx, name)
+}
+
+func (l *lockedVault) SetSecret(ctx context.Context, name, value string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.store.SetSecret(ctx, name, value)
+}
+
+func TestPullKeysEveryFolderUnderDir(t *testing.T) {
+	dir, _ := conflictFixture(t, "")
+	outside := t.TempDir()
+	var mappings []project.VaultMapping
+	store := &lockedVault{store: fakeVault{}}
+	for _, name := range []string{"api", "web", "worker", "jobs"} {
+		folder := filepath.Join(dir, "services", name)
+		if err := os.MkdirAll(folder, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		mappings = append(mappings, project.VaultMapping{SecretName: name + "/prod", FolderPath: folder, Environment: "production"})
+		if name != "worker" {
+			store.store[name+"/prod"] = "key-" + name
+		}
+	}
+	mappings = append(mappings, project.VaultMapping{SecretName: "other/prod", FolderPath: outside, Environment: "production"})
+	store.store["other/prod"] = "key-other"
+
+	var out bytes.Buffer
+	err := pullKeys(context.Background(), &out, store, mappings, dir, &conflictPolicy{out: &out})
+	if err == nil || !strings.Contains(err.Error(), "worker/prod") {
+		t.Fatalf("pull with a missing secret = %v; want it named", err)
+	}
+	for _, name := range []string{"api", "web", "jobs"} {
+		data, _ := os.ReadFile(filepath.Join(dir, "services", name, ".env.keys"))
+		if string(data) != "DOTENV_PRIVATE_KEY_PRODUCTION=key-"+name+"\n" {
+			t.Errorf("%s/.env.keys = %q; a failing key must not stop the others", name, data)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(outside, ".env.keys")); err == nil {
+		t.Error("pull wrote a key for a folder outside --dir")
+	}
+	if got := out.String(); strings.Index(got, "api/prod") > strings.Index(got, "jobs/prod") {
+		t.Errorf("results are not in mapping order:\n%s", got)
+	}
+}
+
+func TestPullKeysNoMapping(t *testing.T) {
+	err := pullKeys(context.Background(), &bytes.Buffer{}, fakeVault{}, nil, t.TempDir(), &conflictPolicy{})
+	if err == nil || !strings.Contains(err.Error(), "no [[vault.sync.mappings]] entry") {
+		t.Errorf("expected a no-mapping error, got %v", err)
+	}
+}
+
+// conflictFixture isolates sync state in a temp HOME and returns a project
+// dir with one production mapping.
+func conflictFixture(t *testing.T, keysFile string) (string, []project.VaultMapping) {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	dir := t.TempDir()
+	if keysFile != "" {
+		if err := os.WriteFile(filepath.Join(dir, ".env.keys"), []byte(keysFile), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir, []project.VaultMapping{{SecretName: "myapp/prod", FolderPath: dir, Environment: "production"}}
+}
+
+func TestPullKeysConflict(t *testing.T) {
+	dir, mappings := conflictFixture(t, "DOTENV_PRIVATE_KEY_PRODUCTION=local\n")
+	store := fakeVault{"myapp/prod": "remote"}
+	var out bytes.Buffer
+
+	err := pullKeys(context.Background(), &out, store, mappings, dir, &conflictPolicy{out: &out})
+	if err == nil || !strings.Contains(err.Error(), "--force") {
+		t.Fatalf("non-interactive conflict should fail with a --force hint, got %v", err)
+	}
+	if !strings.Contains(out.String(), "vault: no metadata available") || !strings.Contains(out.String(), "local: .env.keys modified") {
+		t.Errorf("conflict output lacks metadata: %q", out.String())
+	}
+
+	// Declining at the prompt keeps the local key; accepting replaces it.
+	declined := &conflictPolicy{in: bufio.NewReader(strings.NewReader("n\n")), out: &out}
+	if err := pullKeys(context.Background(), &out, store, mappings, dir, declined); err == nil {
+		t.Fatal("declined conflict should fail")
+	}
+	accepted := &conflictPolicy{in: bufio.NewReader(strings.NewReader("y\n")), out: &out}
+	if err := pullKeys(context.Background(), &out, store, mappings, dir, accepted); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, ".env.keys")); string(data) != "DOTENV_PRIVATE_KEY_PRODUCTION=remote\n" {
+		t.Errorf(".env.keys = %q", data)
+	}
+
+	// The pulled key is now the synced one, so a later rotation is no conflict.
+	store["myapp/prod"] = "rotated"
+	if err := pullKeys(context.Background(), &out, store, mappings, dir, &conflictPolicy{out: &out}); err != nil {
+		t.Errorf("pulling a rotation over an unchanged local key: %v", err)
+	}
+}
+
+func TestPushKeys(t *testing.T) {
+	dir, mappings := conflictFixture(t, "DOTENV_PRIVATE_KEY_PRODUCTION=local\n")
+	store := fakeVault{}
+	var out bytes.Buffer
+
+	if err := pushKeys(context.Background(), &out, store, mappings, dir, &conflictPolicy{out: &out}); err != nil {
+		t.Fatal(err)
+	}
+	if store["myapp/prod"] != "DOTENV_PRIVATE_KEY_PRODUCTION=local" {
+		t.Fatalf("vault = %q", store["myapp/prod"])
+	}
+
+	// A teammate rotates the key: pushing the stale local key is a conflict.
+	store["myapp/prod"] = "rotated"
+	err := pushKeys(context.Background(), &out, store, mappings, dir, &conflictPolicy{out: &out})
+	if err == nil || store["myapp/prod"] != "rotated" {
+		t.Fatalf("push over a rotated key = %v, vault %q", err, store["myapp/prod"])
+	}
+	if err := pushKeys(context.Background(), &out, store, mappings, dir, &conflictPolicy{force: true, out: &out}); err != nil {
+		t.Fatal(err)
+	}
+	if store["myapp/prod"] != "DOTENV_PRIVATE_KEY_PRODUCTION=local" {
+		t.Errorf("--force did not overwrite: %q", store["myapp/prod"])
+	}
+
+	// A key changed only locally since the last sync pushes without asking.
+	if err := os.WriteFile(filepath.Join(dir, ".env.keys"), []byte("DOTENV_PRIVATE_KEY_PRODUCTION=new\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := pushKeys(context.Background(), &out, store, mappings, dir, &conflictPolicy{out: &out}); err != nil {
+		t.Errorf("push of a local-only change: %v", err)
+	}
+}
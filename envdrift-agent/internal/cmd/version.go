@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jainal09/envdrift-agent/internal/encrypt"
+)
+
+var versionJSON bool
+
+// dotenvxVersion is a seam for tests.
+var dotenvxVersion = encrypt.DotenvxVersion
+
+// dotenvxProbeTimeout bounds `dotenvx --version`, a node start-up.
+const dotenvxProbeTimeout = 10 * time.Second
+
+// versionInfo is what `version --json` prints.
+type versionInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	BuildDate string `json:"build_date,omitempty"`
+	GoVersion string `json:"go_version"`
+	Platform  string `json:"platform"`
+	// Dotenvx is nil when no dotenvx binary is found.
+	Dotenvx *dotenvxInfo `json:"dotenvx"`
+}
+
+type dotenvxInfo struct {
+	Path string `json:"path"`
+	// Version is empty when the binary did not answer --version.
+	Version string `json:"version,omitempty"`
+}
+
+// buildInfo returns the commit and build date stamped in with -ldflags,
+// falling back to the VCS revision and commit time the go command embeds
+// when building from a checkout. A revision with local changes is suffixed
+// "-dirty".
+func buildInfo() (commit, date string) {
+	commit, date = Commit, BuildDate
+	bi, ok := debug.ReadBuildInfo()
+	if !ok || (commit != "" && date != "") {
+		return commit, date
+	}
+	var revision, vcsTime string
+	dirty := false
+	for _, s := range bi.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			revision = s.Value
+		case "vcs.time":
+			vcsTime = s.Value
+		case "vcs.modified":
+			dirty = s.Value == "true"
+		}
+	}
+	if commit == "" && revision != "" {
+		commit = revision
+		if dirty {
+			commit += "-dirty"
+		}
+	}
+	if date == "" {
+		date = vcsTime
+	}
+	return commit, date
+}
+
+// collectVersionInfo gathers the build metadata and looks up dotenvx.
+func collectVersionInfo(ctx context.Context) versionInfo {
+	commit, date := buildInfo()
+	info := versionInfo{
+		Version:   Version,
+		Commit:    commit,
+		BuildDate: date,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+	ctx, cancel := context.WithTimeout(ctx, dotenvxProbeTimeout)
+	defer cancel()
+	if path, version, err := dotenvxVersion(ctx); path != "" {
+		info.Dotenvx = &dotenvxInfo{Path: path}
+		if err == nil {
+			info.Dotenvx.Version = version
+		}
+	}
+	return info
+}
+
+// runVersion prints the version, or with --json the full build information.
+func runVersion(cmd *cobra.Command, args []string) error {
+	w := cmd.OutOrStdout()
+	if !versionJSON {
+		fmt.Fprintf(w, "envdrift-agent %s\n", Version)
+		return nil
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(collectVersionInfo(cmd.Context()))
+}
+
+// orUnknown is s, or "unknown" when it is empty.
+func orUnknown(s string) string {
+	if s == "" {
+		return "unknown"
+	}
+	return s
+}
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"runtime"
+	"testing"
+)
+
+func TestVersionJSON(t *testing.T) {
+	origVersion, origCommit, origDate, origDotenvx := Version, Commit, BuildDate, dotenvxVersion
+	t.Cleanup(func() {
+		Version, Commit, BuildDate, dotenvxVersion = origVersion, origCommit, origDate, origDotenvx
+		versionJSON = false
+		versionCmd.SetOut(nil)
+	})
+	Version, Commit, BuildDate = "1.4.0", "0a1b2c3", "2026-03-01T09:00:00Z"
+	dotenvxVersion = func(ctx context.Context) (string, string, error) {
+		return "/usr/local/bin/dotenvx", "1.44.0", nil
+	}
+	run := func() versionInfo {
+		t.Helper()
+		var out bytes.Buffer
+		versionCmd.SetOut(&out)
+		versionCmd.SetContext(context.Background())
+		if err := runVersion(versionCmd, nil); err != nil {
+			t.Fatal(err)
+		}
+		var info versionInfo
+		if err := json.Unmarshal(out.Bytes(), &info); err != nil {
+			t.Fatalf("output is not JSON: %v\n%s", err, out.String())
+		}
+		return info
+	}
+
+	versionJSON = true
+	info := run()
+	want := versionInfo{
+		Version: "1.4.0", Commit: "0a1b2c3", BuildDate: "2026-03-01T09:00:00Z",
+		GoVersion: runtime.Version(), Platform: runtime.GOOS + "/" + runtime.GOARCH,
+	}
+	if info.Dotenvx == nil || *info.Dotenvx != (dotenvxInfo{Path: "/usr/local/bin/dotenvx", Version: "1.44.0"}) {
+		t.Errorf("dotenvx = %+v", info.Dotenvx)
+	}
+	info.Dotenvx = nil
+	if info != want {
+		t.Errorf("info = %+v; want %+v", info, want)
+	}
+
+	// Without dotenvx the field is null rather than missing.
+	dotenvxVersion = func(ctx context.Context) (string, string, error) { return "", "", errors.New("not found") }
+	if info := run(); info.Dotenvx != nil {
+		t.Errorf("dotenvx = %+v; want null", info.Dotenvx)
+	}
+}
@@ -0,0 +1,240 @@
+// Package compliance reports how far the watched projects are from having
+// every env file encrypted, for organizations that want visibility before —
+// or instead of — letting the agent enforce it. A Report counts the env files,
+// lists the plaintext ones with how long they have sat unencrypted, and flags
+// env and key files other users can read.
+//
+// The running agent saves the latest report as compliance.json in the state
+// directory (for `status`), and can write it as Prometheus metrics and post it
+// to a webhook.
+package compliance
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/jainal09/envdrift-agent/internal/identity"
+	"github.com/jainal09/envdrift-agent/internal/paths"
+	"github.com/jainal09/envdrift-agent/internal/severity"
+)
+
+// File is a plaintext env file.
+type File struct {
+	Path    string `json:"path"`
+	Project string `json:"project"`
+	// Modified is when the file last changed, which is how long it has been
+	// in plaintext at most.
+	Modified time.Time `json:"modified"`
+	// Severity is the most severe of its plaintext values (see package
+	// severity), and Reason what made it so, naming the variable.
+	Severity string `json:"severity,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// Level returns f's Severity as a level; a report from before severities
+// were recorded counts every file as severity.Low.
+func (f File) Level() severity.Level {
+	if l, err := severity.Parse(f.Severity); err == nil {
+		return l
+	}
+	return severity.Low
+}
+
+// Issue is a file whose permissions expose it to other users.
+type Issue struct {
+	Path string `json:"path"`
+	Mode string `json:"mode"`
+}
+
+// Report is one compliance scan.
+type Report struct {
+	Generated time.Time `json:"generated"`
+	Host      string    `json:"host"`
+	// AgentID names the install that sent the report (see identity).
+	AgentID string `json:"agent_id,omitempty"`
+	// ReadOnly records whether the agent was in read-only mode.
+	ReadOnly    bool    `json:"read_only"`
+	Projects    int     `json:"projects"`
+	EnvFiles    int     `json:"env_files"`
+	Encrypted   int     `json:"encrypted"`
+	Plaintext   []File  `json:"plaintext"`
+	Permissions []Issue `json:"permissions"`
+}
+
+// Compliant reports whether every env file is encrypted and none is exposed.
+func (r Report) Compliant() bool {
+	return len(r.Plaintext) == 0 && len(r.Permissions) == 0
+}
+
+// Failing returns the plaintext files of severity min or above.
+func (r Report) Failing(min severity.Level) []File {
+	var files []File
+	for _, f := range r.Plaintext {
+		if f.Level() >= min {
+			files = append(files, f)
+		}
+	}
+	return files
+}
+
+// CompliantAt is Compliant with the plaintext files below severity min
+// tolerated.
+func (r Report) CompliantAt(min severity.Level) bool {
+	return len(r.Failing(min)) == 0 && len(r.Permissions) == 0
+}
+
+// OldestPlaintext returns how long the oldest plaintext file has been
+// unchanged at r.Generated, or 0 when there is none.
+func (r Report) OldestPlaintext() time.Duration {
+	var oldest time.Duration
+	for _, f := range r.Plaintext {
+		oldest = max(oldest, r.Generated.Sub(f.Modified))
+	}
+	return oldest
+}
+
+// Summary describes r in one line.
+func (r Report) Summary() string {
+	if r.EnvFiles == 0 {
+		return fmt.Sprintf("no env files in %d projects", r.Projects)
+	}
+	s := fmt.Sprintf("%d of %d env files encrypted", r.Encrypted, r.EnvFiles)
+	if n := len(r.Plaintext); n > 0 {
+		s += fmt.Sprintf(", %d plaintext (oldest %s)", n, formatAge(r.OldestPlaintext()))
+	}
+	if n := len(r.Permissions); n > 0 {
+		s += fmt.Sprintf(", %d readable by other users", n)
+	}
+	return s
+}
+
+// formatAge rounds d to whole days, hours or minutes.
+func formatAge(d time.Duration) string {
+	switch {
+	case d >= 48*time.Hour:
+		return fmt.Sprintf("%dd", int(d/(24*time.Hour)))
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh", int(d/time.Hour))
+	}
+	return fmt.Sprintf("%dm", int(d/time.Minute))
+}
+
+// goos is a seam for tests.
+var goos = runtime.GOOS
+
+// CheckPermissions returns the issue with path's permissions: on Unix, any
+// group or other access. Windows ACLs are not inspected.
+func CheckPermissions(path string) (Issue, bool) {
+	if goos == "windows" {
+		return Issue{}, false
+	}
+	info, err := os.Stat(path)
+	if err != nil || info.Mode().Perm()&0o077 == 0 {
+		return Issue{}, false
+	}
+	return Issue{Path: path, Mode: fmt.Sprintf("%04o", info.Mode().Perm())}, true
+}
+
+// DefaultPath returns compliance.json in the state directory.
+func DefaultPath() string {
+	return filepath.Join(paths.StateDir(), "compliance.json")
+}
+
+// Save writes r to path.
+func Save(path string, r Report) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeAtomic(path, data)
+}
+
+// Load reads the report at path; ok is false when there is none.
+func Load(path string) (r Report, ok bool, err error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return r, false, nil
+	}
+	if err != nil {
+		return r, false, err
+	}
+	if err := json.Unmarshal(data, &r); err != nil {
+		return r, false, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return r, true, nil
+}
+
+// Metrics renders r in the Prometheus text exposition format.
+func Metrics(r Report) string {
+	var b strings.Builder
+	gauge := func(name, help string, value float64) {
+		fmt.Fprintf(&b, "# HELP envdrift_compliance_%s %s\n# TYPE envdrift_compliance_%s gauge\nenvdrift_compliance_%s %g\n",
+			name, help, name, name, value)
+	}
+	readOnly := 0.0
+	if r.ReadOnly {
+		readOnly = 1
+	}
+	gauge("projects", "Registered projects scanned.", float64(r.Projects))
+	gauge("env_files", "Env files found.", float64(r.EnvFiles))
+	gauge("encrypted_files", "Env files that are encrypted.", float64(r.Encrypted))
+	gauge("plaintext_files", "Env files in plaintext.", float64(len(r.Plaintext)))
+	gauge("plaintext_oldest_seconds", "Seconds the oldest plaintext env file has been unchanged.", r.OldestPlaintext().Seconds())
+	gauge("permission_issues", "Env and key files readable by other users.", float64(len(r.Permissions)))
+	gauge("read_only", "1 when the agent reports without encrypting.", readOnly)
+	gauge("last_scan_timestamp_seconds", "Unix time of the scan.", float64(r.Generated.Unix()))
+	return b.String()
+}
+
+// WriteMetrics writes Metrics(r) to path, replacing it atomically so the
+// textfile collector never reads half a file.
+func WriteMetrics(path string, r Report) error {
+	return writeAtomic(path, []byte(Metrics(r)))
+}
+
+// httpClient is a seam for tests.
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+// Send posts r as JSON to url, signed by id when it is not nil.
+func Send(ctx context.Context, url string, r Report, id *identity.Identity) error {
+	body, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if id != nil {
+		id.Sign(req, body, time.Now())
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("compliance webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+func writeAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
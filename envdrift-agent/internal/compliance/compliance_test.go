@@ -0,0 +1,213 @@
+package compliance
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jainal09/envdrift-agent/internal/identity"
+	"github.com/jainal09/envdrift-agent/internal/severity"
+)
+
+func testReport() Report {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	return Report{
+		Generated: now,
+		Host:      "laptop",
+		Projects:  2,
+		EnvFiles:  3,
+		Encrypted: 1,
+		Plaintext: []File{
+			{Path: "/p/a/.env", Project: "/p/a", Modified: now.Add(-3 * time.Hour)},
+			{Path: "/p/b/.env", Project: "/p/b", Modified: now.Add(-72 * time.Hour)},
+		},
+		Permissions: []Issue{{Path: "/p/a/.env.keys", Mode: "0644"}},
+	}
+}
+
+func TestSummary(t *testing.T) {
+	r := testReport()
+	want := "1 of 3 env files encrypted, 2 plaintext (oldest 3d), 1 readable by other users"
+	if got := r.Summary(); got != want {
+		t.Errorf("Summary() = %q, want %q", got, want)
+	}
+	if r.Compliant() {
+		t.Error("a report with plaintext files must not be compliant")
+	}
+
+	clean := Report{Projects: 1, EnvFiles: 2, Encrypted: 2}
+	if got := clean.Summary(); got != "2 of 2 env files encrypted" {
+		t.Errorf("Summary() = %q", got)
+	}
+	if !clean.Compliant() {
+		t.Error("a fully encrypted report must be compliant")
+	}
+	if got := (Report{Projects: 4}).Summary(); got != "no env files in 4 projects" {
+		t.Errorf("Summary() = %q", got)
+	}
+}
+
+func TestCompliantAt(t *testing.T) {
+	r := testReport()
+	r.Permissions = nil
+	r.Plaintext[1].Severity, r.Plaintext[1].Reason = "high", "TOKEN: named as a credential"
+	if r.CompliantAt(severity.Low) {
+		t.Error("CompliantAt(low) with plaintext files")
+	}
+	if !r.CompliantAt(severity.Critical) {
+		t.Error("CompliantAt(critical) with nothing above high")
+	}
+	// A file from before severities counts as low.
+	if got := r.Failing(severity.Medium); len(got) != 1 || got[0].Path != "/p/b/.env" {
+		t.Errorf("Failing(medium) = %v", got)
+	}
+	r.Permissions = []Issue{{Path: "/p/a/.env.keys", Mode: "0644"}}
+	if r.CompliantAt(severity.Critical) {
+		t.Error("CompliantAt ignores permission issues")
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	r := testReport()
+	r.ReadOnly = true
+	m := Metrics(r)
+	for _, line := range []string{
+		"envdrift_compliance_projects 2\n",
+		"envdrift_compliance_env_files 3\n",
+		"envdrift_compliance_encrypted_files 1\n",
+		"envdrift_compliance_plaintext_files 2\n",
+		"envdrift_compliance_plaintext_oldest_seconds 259200\n",
+		"envdrift_compliance_permission_issues 1\n",
+		"envdrift_compliance_read_only 1\n",
+		"# TYPE envdrift_compliance_read_only gauge\n",
+	} {
+		if !strings.Contains(m, line) {
+			t.Errorf("metrics missing %q:\n%s", line, m)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "textfile", "envdrift.prom")
+	if err := WriteMetrics(path, r); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != m {
+		t.Errorf("WriteMetrics wrote %q, %v", data, err)
+	}
+}
+
+func TestCheckPermissions(t *testing.T) {
+	prev := goos
+	t.Cleanup(func() { goos = prev })
+	goos = "linux"
+
+	dir := t.TempDir()
+	private := filepath.Join(dir, ".env")
+	shared := filepath.Join(dir, ".env.keys")
+	if err := os.WriteFile(private, []byte("A=1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(shared, []byte("A=1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	// Chmod after writing so the umask does not interfere.
+	if err := os.Chmod(shared, 0o640); err != nil {
+		t.Fatal(err)
+	}
+
+	if issue, ok := CheckPermissions(private); ok {
+		t.Errorf("0600 file reported: %+v", issue)
+	}
+	if _, ok := CheckPermissions(filepath.Join(dir, "missing")); ok {
+		t.Error("a missing file must not be reported")
+	}
+	issue, ok := CheckPermissions(shared)
+	if !ok || issue.Mode != "0640" || issue.Path != shared {
+		t.Errorf("CheckPermissions(0640) = %+v, %v", issue, ok)
+	}
+
+	goos = "windows"
+	if _, ok := CheckPermissions(shared); ok {
+		t.Error("permissions must not be checked on Windows")
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "compliance.json")
+	if _, ok, err := Load(path); ok || err != nil {
+		t.Fatalf("Load(missing) = %v, %v", ok, err)
+	}
+	want := testReport()
+	if err := Save(path, want); err != nil {
+		t.Fatal(err)
+	}
+	got, ok, err := Load(path)
+	if err != nil || !ok {
+		t.Fatalf("Load = %v, %v", ok, err)
+	}
+	if got.Summary() != want.Summary() || !got.Generated.Equal(want.Generated) {
+		t.Errorf("Load = %+v, want %+v", got, want)
+	}
+}
+
+func TestSend(t *testing.T) {
+	var got Report
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("got %s with Content-Type %q", r.Method, r.Header.Get("Content-Type"))
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Error(err)
+		}
+		if got.Host == "fail" {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer srv.Close()
+
+	r := testReport()
+	if err := Send(context.Background(), srv.URL, r, nil); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if got.EnvFiles != 3 || len(got.Plaintext) != 2 {
+		t.Errorf("webhook received %+v", got)
+	}
+
+	r.Host = "fail"
+	if err := Send(context.Background(), srv.URL, r, nil); err == nil || !strings.Contains(err.Error(), "502") {
+		t.Errorf("Send to a failing webhook = %v, want a 502 error", err)
+	}
+}
+
+func TestSendSigned(t *testing.T) {
+	id, err := identity.Rotate(filepath.Join(t.TempDir(), "identity.json"), true, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var agentID string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var verr error
+		if agentID, verr = identity.Verify(id.PublicKey(), r, body, time.Now()); verr != nil {
+			t.Errorf("Verify: %v", verr)
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer srv.Close()
+
+	r := testReport()
+	r.AgentID = id.ID
+	if err := Send(context.Background(), srv.URL+"/fleet/reports", r, id); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if agentID != id.ID {
+		t.Errorf("server saw agent %q; want %q", agentID, id.ID)
+	}
+}
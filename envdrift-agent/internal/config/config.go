@@ -4,18 +4,63 @@ package config
 import (
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
 	"time"
+	"unicode"
 
 	"github.com/pelletier/go-toml/v2"
 
+	"github.com/jainal09/envdrift-agent/internal/keyshare"
+	"github.com/jainal09/envdrift-agent/internal/logging"
+	"github.com/jainal09/envdrift-agent/internal/notify"
+	"github.com/jainal09/envdrift-agent/internal/paths"
 	"github.com/jainal09/envdrift-agent/internal/project"
+	"github.com/jainal09/envdrift-agent/internal/rule"
+	"github.com/jainal09/envdrift-agent/internal/schedule"
+	"github.com/jainal09/envdrift-agent/internal/severity"
+	"github.com/jainal09/envdrift-agent/internal/update"
 )
 
 // Config holds the agent configuration
 type Config struct {
+	// Strict rejects unknown keys in the file, so a typo such as
+	// idle_timout fails to load instead of leaving the default in place.
+	Strict      bool              `toml:"strict"`
 	Guardian    GuardianConfig    `toml:"guardian"`
 	Directories DirectoriesConfig `toml:"directories"`
+	Keys        KeysConfig        `toml:"keys"`
+	VaultSync   VaultSyncConfig   `toml:"vault_sync"`
+	VaultCache  VaultCacheConfig  `toml:"vault_cache"`
+	Edit        EditConfig        `toml:"edit"`
+	Backups     BackupsConfig     `toml:"backups"`
+	Telemetry   TelemetryConfig   `toml:"telemetry"`
+	Update      UpdateConfig      `toml:"update"`
+	Power       PowerConfig       `toml:"power"`
+	Logs        LogsConfig        `toml:"logs"`
+	History     HistoryConfig     `toml:"history"`
+	Identity    IdentityConfig    `toml:"identity"`
+	Remote      RemoteConfig      `toml:"remote"`
+	Compliance  ComplianceConfig  `toml:"compliance"`
+	Severity    SeverityConfig    `toml:"severity"`
+	Hooks       HooksConfig       `toml:"hooks"`
+	// Notifications routes notification events to channels.
+	Notifications NotificationsConfig `toml:"notifications"`
+	// Profiles are the [profiles.<name>] tables; see Effective.
+	Profiles map[string]ProfileConfig `toml:"profiles"`
+	// Policies are the network-conditioned [[policies]]; see MatchPolicies.
+	Policies []PolicyConfig `toml:"policies"`
+	// ManagedPolicies points at an MDM-distributed policy file and pins the
+	// keys it must be signed with.
+	ManagedPolicies ManagedPoliciesConfig `toml:"managed_policies"`
+	// Managed holds the [[policies]] read from ManagedPolicies.File; it is
+	// never written to guardian.toml. See AllPolicies.
+	Managed []PolicyConfig `toml:"-"`
+
+	// ActiveProfile names the profile Effective applied; it is never read
+	// from or written to the file.
+	ActiveProfile string `toml:"-"`
 }
 
 // GuardianConfig holds encryption behavior settings
@@ -25,12 +70,302 @@ type GuardianConfig struct {
 	Patterns    []string      `toml:"patterns"`
 	Exclude     []string      `toml:"exclude"`
 	Notify      bool          `toml:"notify"`
+	// Symlinks is the policy for symlinked env files: follow or skip.
+	Symlinks string `toml:"symlinks"`
+	// IgnoreGenerated skips dependency and test fixture directories and
+	// framework-generated files such as .env.local.php.
+	IgnoreGenerated bool `toml:"ignore_generated"`
+	// Incremental keeps the ciphertext of the values a re-encryption did not
+	// change (dotenvx files only), so git diffs show only the changed ones.
+	// Off by default: it keeps a keyed hash of every value on disk.
+	Incremental bool `toml:"incremental"`
+	// NormalizeOutput rewrites encrypted dotenvx files in a stable layout
+	// (variables sorted, one per line, no timestamp comments) so re-encrypting
+	// one produces a minimal diff.
+	NormalizeOutput bool `toml:"normalize_output"`
+	// Profile pins the active [profiles.<name>]; empty selects one by host.
+	Profile string `toml:"profile"`
+	// Debounce is the per-file window in which watcher events are coalesced
+	// into one; 0 reports every event.
+	Debounce time.Duration `toml:"debounce"`
+	// Language pins the language of notifications and CLI messages (e.g.
+	// "de"); empty follows ENVDRIFT_LANG and the locale environment.
+	Language string `toml:"language"`
+	// PlainOutput strips emoji and color from CLI output and notifications,
+	// like --no-emoji and ENVDRIFT_PLAIN_OUTPUT.
+	PlainOutput bool `toml:"plain_output"`
+	// Journal records watcher events and idle-check decisions for
+	// `envdrift-agent debug replay`.
+	Journal bool `toml:"journal"`
+	// Journald sends the agent's log to the systemd journal (Linux), with
+	// FILE=, EVENT= and RESULT= fields on each file decision, instead of
+	// plain lines on stdout.
+	Journald bool `toml:"journald"`
+	// GracePeriod is how long a newly registered project is only observed:
+	// its plaintext env files are reported, not encrypted, until the period
+	// ends or `envdrift-agent approve` ends it early. 0 enforces at once.
+	GracePeriod time.Duration `toml:"grace_period"`
+	// EncryptWhen is a CEL expression (see package rule) an idle plaintext
+	// file must satisfy to be encrypted; until it does, the file is deferred.
+	// Empty encrypts every idle file.
+	EncryptWhen string `toml:"encrypt_when"`
+	// RescanInterval re-walks every watched project this often to catch
+	// changes the event watcher missed (a dropped event, an exhausted watch
+	// limit) and logs each discrepancy. 0 turns it off.
+	RescanInterval time.Duration `toml:"rescan_interval"`
+	// ConfigScanInterval walks every watched project this often for YAML,
+	// JSON and TOML files holding what looks like a secret, and warns of
+	// each one; those files are never encrypted. 0 turns it off.
+	ConfigScanInterval time.Duration `toml:"config_scan_interval"`
+	// DeepWorkHours are daily local-time windows, e.g. "09:00-12:00", during
+	// which idle plaintext files are not encrypted unless the screen is
+	// locked; they are encrypted once a window ends.
+	DeepWorkHours []string `toml:"deep_work_hours"`
+	// PlaintextAllowed names the variables meant to stay plaintext, such as
+	// LOG_LEVEL, as names or globs (FEATURE_*): they do not make a file count
+	// as partially encrypted, and encryption leaves them readable.
+	PlaintextAllowed []string `toml:"plaintext_allowed"`
+}
+
+// DeepWorkWindows returns DeepWorkHours parsed.
+func (c GuardianConfig) DeepWorkWindows() schedule.Windows {
+	ws, _ := schedule.ParseAll(c.DeepWorkHours)
+	return ws
 }
 
 // DirectoriesConfig holds directory watch settings
 type DirectoriesConfig struct {
 	Watch     []string `toml:"watch"`
 	Recursive bool     `toml:"recursive"`
+	// Roots sets a mode per directory, [directories.roots."~/scratch"]; see
+	// RootMode.
+	Roots map[string]RootConfig `toml:"roots,omitempty"`
+}
+
+// KeysConfig holds private-key resolution settings
+type KeysConfig struct {
+	// Resolution is the ordered list of sources consulted for a dotenvx
+	// private key: env, dotenv_keys, keychain, vault.
+	Resolution []string `toml:"resolution"`
+	// SyncStore is where `keys sync` keeps the encrypted key bundle: a
+	// path, file://, s3:// or https:// (WebDAV) location. Empty turns it off.
+	SyncStore string `toml:"sync_store"`
+	// Team names recipients for `keys share --to`: name = "github:<user>",
+	// an age1... key or an SSH public key.
+	Team map[string]string `toml:"team"`
+}
+
+// VaultSyncConfig holds the background vault key sync settings
+type VaultSyncConfig struct {
+	Enabled  bool          `toml:"enabled"`
+	Interval time.Duration `toml:"interval"`
+	// Target is where pulled keys are written: dotenv_keys or keychain.
+	Target string `toml:"target"`
+}
+
+// VaultCacheConfig holds the settings for caching vault secret reads made
+// while resolving keys
+type VaultCacheConfig struct {
+	// TTL is how long a secret read from the vault is reused; 0 turns the
+	// cache off.
+	TTL time.Duration `toml:"ttl"`
+	// Disk also keeps cached secrets, encrypted, in the cache directory so
+	// they outlive one command.
+	Disk bool `toml:"disk"`
+}
+
+// EditConfig holds the `edit` command settings
+type EditConfig struct {
+	// AutoOpen opens the decrypted file in an editor and re-encrypts it when
+	// the editor exits.
+	AutoOpen bool `toml:"auto_open"`
+	// Editor overrides $VISUAL/$EDITOR and the platform default.
+	Editor string `toml:"editor"`
+}
+
+// BackupsConfig holds the pre-encryption backup settings
+type BackupsConfig struct {
+	// Enabled copies each file into the backups store before the guardian
+	// encrypts it. The copies are plaintext, so this is opt-in.
+	Enabled bool `toml:"enabled"`
+	// Keep is how many backups to keep per file; 0 keeps any number.
+	Keep int `toml:"keep"`
+	// MaxAge drops backups older than this; 0 keeps them regardless of age.
+	MaxAge time.Duration `toml:"max_age"`
+	// Trash moves pruned backups to the OS trash instead of deleting them.
+	Trash bool `toml:"trash"`
+}
+
+// TelemetryConfig holds the opt-in usage telemetry settings
+type TelemetryConfig struct {
+	// Enabled counts usage locally (telemetry.json); nothing is
+	// collected unless it is true.
+	Enabled bool `toml:"enabled"`
+	// Endpoint receives the counts of completed days; empty keeps them local.
+	Endpoint string `toml:"endpoint"`
+}
+
+// UpdateConfig holds the self-update settings
+type UpdateConfig struct {
+	// Channel is "stable" (full releases) or "beta" (pre-releases too).
+	Channel string `toml:"channel"`
+}
+
+// PowerConfig holds the battery-aware scheduling settings
+type PowerConfig struct {
+	// DeferBelow defers background work (vault sync, telemetry) while on
+	// battery at or below this percentage; 0 never defers. Idle encryption
+	// is never deferred.
+	DeferBelow int `toml:"defer_below"`
+}
+
+// LogsConfig sizes the rotated log file `start --log-file` writes.
+type LogsConfig struct {
+	// MaxSize is the size in bytes at which the log rotates.
+	MaxSize int64 `toml:"max_size"`
+	// Backups is how many rotated files are kept.
+	Backups int `toml:"backups"`
+	// Keep drops journal events and rotated log files older than this; 0
+	// keeps them until rotation drops them.
+	Keep time.Duration `toml:"keep"`
+}
+
+// HistoryConfig holds the retention of the history (audit) log
+type HistoryConfig struct {
+	// Keep drops history entries older than this; 0 keeps them forever.
+	Keep time.Duration `toml:"keep"`
+}
+
+// IdentityConfig holds the agent identity settings
+type IdentityConfig struct {
+	// Keypair gives the install a machine registration keypair that signs
+	// the compliance reports it sends; without it they carry the agent ID
+	// only.
+	Keypair bool `toml:"keypair"`
+}
+
+// RemoteConfig holds the settings of the remote commands, which run the
+// agent on other machines over ssh.
+type RemoteConfig struct {
+	// Hosts are the ssh destinations (host aliases from ~/.ssh/config, or
+	// user@host) remote commands use when none is named.
+	Hosts []string `toml:"hosts"`
+	// Agent is the envdrift-agent command on the remote hosts.
+	Agent string `toml:"agent"`
+}
+
+// ComplianceConfig holds the read-only compliance reporting settings
+type ComplianceConfig struct {
+	// ReadOnly stops the agent from modifying any file: plaintext env files
+	// are reported instead of encrypted.
+	ReadOnly bool `toml:"read_only"`
+	// Interval is how often the compliance report is refreshed.
+	Interval time.Duration `toml:"interval"`
+	// Webhook receives each report as JSON; empty sends none.
+	Webhook string `toml:"webhook"`
+	// MetricsFile is written with the report in the Prometheus text format,
+	// for node_exporter's textfile collector; empty writes none.
+	MetricsFile string `toml:"metrics_file"`
+}
+
+// Reporting reports whether the agent refreshes the compliance report.
+func (c ComplianceConfig) Reporting() bool {
+	return c.ReadOnly || c.Webhook != "" || c.MetricsFile != ""
+}
+
+// SeverityConfig holds the thresholds plaintext findings must reach, by
+// severity (low, medium, high, critical; see package severity), before they
+// are acted on.
+type SeverityConfig struct {
+	// Notify is the least severe plaintext an encryption notification is
+	// sent for.
+	Notify string `toml:"notify"`
+	// Fail is the least severe plaintext that makes `compliance --check` and
+	// `action` fail; less severe files are reported as warnings.
+	Fail string `toml:"fail"`
+	// Gitleaks lists gitleaks rule files (TOML) whose rules classify values
+	// alongside the built-in ones; ~ is the home directory.
+	Gitleaks []string `toml:"gitleaks"`
+}
+
+// GitleaksRules loads the rules of the Gitleaks files.
+func (c SeverityConfig) GitleaksRules() ([]severity.Rule, error) {
+	paths := make([]string, len(c.Gitleaks))
+	for i, p := range c.Gitleaks {
+		paths[i] = expandHome(p)
+	}
+	return severity.LoadGitleaks(paths...)
+}
+
+// NotifyLevel returns Notify as a level; unset is severity.Low.
+func (c SeverityConfig) NotifyLevel() severity.Level {
+	return levelOr(c.Notify, severity.Low)
+}
+
+// FailLevel returns Fail as a level; unset is severity.Low.
+func (c SeverityConfig) FailLevel() severity.Level {
+	return levelOr(c.Fail, severity.Low)
+}
+
+// levelOr parses name, returning def for an empty or unknown one.
+func levelOr(name string, def severity.Level) severity.Level {
+	if l, err := severity.Parse(name); err == nil {
+		return l
+	}
+	return def
+}
+
+// HooksConfig holds the commands run around encryptions. Each runs through
+// the shell (sh, or cmd on Windows) in the project directory, with the event
+// described in ENVDRIFT_* environment variables; empty runs none.
+type HooksConfig struct {
+	// PreEncrypt runs before a file is encrypted; a non-zero exit defers the
+	// encryption to a later check.
+	PreEncrypt string `toml:"pre_encrypt"`
+	// PostEncrypt runs after a file was encrypted.
+	PostEncrypt string `toml:"post_encrypt"`
+	// OnFailure runs when encrypting a file failed.
+	OnFailure string `toml:"on_failure"`
+	// Timeout bounds each hook; it is killed after that long.
+	Timeout time.Duration `toml:"timeout"`
+}
+
+// NotificationsConfig routes each notification event type to channels
+// (desktop, webhook); guardian.notify still switches notifications off.
+type NotificationsConfig struct {
+	// Encrypted, Failure, Warning and Info list the channels each event type
+	// is sent to; an empty list silences it.
+	Encrypted []string `toml:"encrypted"`
+	Failure   []string `toml:"failure"`
+	Warning   []string `toml:"warning"`
+	Info      []string `toml:"info"`
+	// Webhook is the URL the webhook channel POSTs a JSON event to.
+	Webhook string `toml:"webhook"`
+	// RespectDND holds desktop notifications while the OS is in
+	// do-not-disturb and delivers them, batched, once it ends.
+	RespectDND bool `toml:"respect_dnd"`
+	// FailuresBreakDND still shows failure notifications during
+	// do-not-disturb and quiet hours.
+	FailuresBreakDND bool `toml:"failures_break_dnd"`
+	// QuietHours are daily local-time windows, e.g. "22:00-08:00", during
+	// which desktop notifications are held like during do-not-disturb.
+	QuietHours []string `toml:"quiet_hours"`
+}
+
+// QuietWindows returns QuietHours parsed.
+func (n NotificationsConfig) QuietWindows() schedule.Windows {
+	ws, _ := schedule.ParseAll(n.QuietHours)
+	return ws
+}
+
+// Routes returns the routing table for notify.Notifier.
+func (n NotificationsConfig) Routes() notify.Routes {
+	return notify.Routes{
+		notify.EventEncrypted: n.Encrypted,
+		notify.EventFailure:   n.Failure,
+		notify.EventWarning:   n.Warning,
+		notify.EventInfo:      n.Info,
+	}
 }
 
 // rawConfig mirrors Config for TOML decoding. idle_timeout is accepted as
@@ -41,8 +376,32 @@ type DirectoriesConfig struct {
 // became a perpetual crash-respawn loop. Absent fields stay nil/empty so
 // defaults survive partial configs.
 type rawConfig struct {
-	Guardian    rawGuardianConfig    `toml:"guardian"`
-	Directories rawDirectoriesConfig `toml:"directories"`
+	// SchemaVersion is only read by upgrade; it is here so strict decoding
+	// knows it.
+	SchemaVersion *int                     `toml:"schema_version"`
+	Strict        *bool                    `toml:"strict"`
+	Guardian      rawGuardianConfig        `toml:"guardian"`
+	Directories   rawDirectoriesConfig     `toml:"directories"`
+	Keys          rawKeysConfig            `toml:"keys"`
+	VaultSync     rawVaultSyncConfig       `toml:"vault_sync"`
+	VaultCache    rawVaultCacheConfig      `toml:"vault_cache"`
+	Edit          rawEditConfig            `toml:"edit"`
+	Backups       rawBackupsConfig         `toml:"backups"`
+	Telemetry     rawTelemetryConfig       `toml:"telemetry"`
+	Update        rawUpdateConfig          `toml:"update"`
+	Power         rawPowerConfig           `toml:"power"`
+	Logs          rawLogsConfig            `toml:"logs"`
+	History       rawHistoryConfig         `toml:"history"`
+	Identity      rawIdentityConfig        `toml:"identity"`
+	Remote        rawRemoteConfig          `toml:"remote"`
+	Compliance    rawComplianceConfig      `toml:"compliance"`
+	Severity      rawSeverityConfig        `toml:"severity"`
+	Hooks         rawHooksConfig           `toml:"hooks"`
+	Notifications rawNotificationsConfig   `toml:"notifications"`
+	Profiles      map[string]ProfileConfig `toml:"profiles"`
+	Policies      []rawPolicyConfig        `toml:"policies"`
+	// ManagedPolicies is decoded as-is: both fields default to empty.
+	ManagedPolicies ManagedPoliciesConfig `toml:"managed_policies"`
 }
 
 // Slice fields are pointers so an explicit empty array in the TOML
@@ -51,38 +410,253 @@ type rawConfig struct {
 // the key was absent (keep the default), a non-nil pointer to an empty slice
 // means the user deliberately cleared it.
 type rawGuardianConfig struct {
-	Enabled     *bool     `toml:"enabled"`
-	IdleTimeout any       `toml:"idle_timeout"`
-	Patterns    *[]string `toml:"patterns"`
-	Exclude     *[]string `toml:"exclude"`
-	Notify      *bool     `toml:"notify"`
+	Enabled          *bool     `toml:"enabled"`
+	IdleTimeout      *Duration `toml:"idle_timeout"`
+	Patterns         *[]string `toml:"patterns"`
+	Exclude          *[]string `toml:"exclude"`
+	Notify           *bool     `toml:"notify"`
+	Symlinks         *string   `toml:"symlinks"`
+	IgnoreGenerated  *bool     `toml:"ignore_generated"`
+	Incremental      *bool     `toml:"incremental"`
+	NormalizeOutput  *bool     `toml:"normalize_output"`
+	Profile          *string   `toml:"profile"`
+	Debounce         *Duration `toml:"debounce"`
+	Language         *string   `toml:"language"`
+	PlainOutput      *bool     `toml:"plain_output"`
+	Journal          *bool     `toml:"journal"`
+	Journald         *bool     `toml:"journald"`
+	GracePeriod      *Duration `toml:"grace_period"`
+	EncryptWhen      *string   `toml:"encrypt_when"`
+	RescanInterval   *Duration `toml:"rescan_interval"`
+	PlaintextAllowed *[]string `toml:"plaintext_allowed"`
+	ConfigScan       *Duration `toml:"config_scan_interval"`
+	DeepWorkHours    *[]string `toml:"deep_work_hours"`
 }
 
 type rawDirectoriesConfig struct {
-	Watch     *[]string `toml:"watch"`
-	Recursive *bool     `toml:"recursive"`
+	Watch     *[]string             `toml:"watch"`
+	Recursive *bool                 `toml:"recursive"`
+	Roots     map[string]RootConfig `toml:"roots"`
+}
+
+type rawKeysConfig struct {
+	Resolution *[]string          `toml:"resolution"`
+	SyncStore  *string            `toml:"sync_store"`
+	Team       *map[string]string `toml:"team"`
+}
+
+type rawVaultSyncConfig struct {
+	Enabled  *bool     `toml:"enabled"`
+	Interval *Duration `toml:"interval"`
+	Target   *string   `toml:"target"`
+}
+
+type rawVaultCacheConfig struct {
+	TTL  *Duration `toml:"ttl"`
+	Disk *bool     `toml:"disk"`
+}
+
+type rawEditConfig struct {
+	AutoOpen *bool   `toml:"auto_open"`
+	Editor   *string `toml:"editor"`
+}
+
+type rawBackupsConfig struct {
+	Enabled *bool     `toml:"enabled"`
+	Keep    *int      `toml:"keep"`
+	MaxAge  *Duration `toml:"max_age"`
+	Trash   *bool     `toml:"trash"`
+}
+
+type rawTelemetryConfig struct {
+	Enabled  *bool   `toml:"enabled"`
+	Endpoint *string `toml:"endpoint"`
+}
+
+type rawUpdateConfig struct {
+	Channel *string `toml:"channel"`
+}
+
+type rawPowerConfig struct {
+	DeferBelow *int `toml:"defer_below"`
+}
+
+type rawLogsConfig struct {
+	MaxSize *ByteSize `toml:"max_size"`
+	Backups *int      `toml:"backups"`
+	Keep    *Duration `toml:"keep"`
+}
+
+type rawHistoryConfig struct {
+	Keep *Duration `toml:"keep"`
+}
+
+type rawIdentityConfig struct {
+	Keypair *bool `toml:"keypair"`
+}
+
+type rawRemoteConfig struct {
+	Hosts *[]string `toml:"hosts"`
+	Agent *string   `toml:"agent"`
+}
+
+type rawComplianceConfig struct {
+	ReadOnly    *bool     `toml:"read_only"`
+	Interval    *Duration `toml:"interval"`
+	Webhook     *string   `toml:"webhook"`
+	MetricsFile *string   `toml:"metrics_file"`
+}
+
+type rawSeverityConfig struct {
+	Notify   *string   `toml:"notify"`
+	Fail     *string   `toml:"fail"`
+	Gitleaks *[]string `toml:"gitleaks"`
+}
+
+type rawHooksConfig struct {
+	PreEncrypt  *string   `toml:"pre_encrypt"`
+	PostEncrypt *string   `toml:"post_encrypt"`
+	OnFailure   *string   `toml:"on_failure"`
+	Timeout     *Duration `toml:"timeout"`
+}
+
+type rawNotificationsConfig struct {
+	Encrypted *[]string `toml:"encrypted"`
+	Failure   *[]string `toml:"failure"`
+	Warning   *[]string `toml:"warning"`
+	Info      *[]string `toml:"info"`
+	Webhook   *string   `toml:"webhook"`
+
+	RespectDND       *bool     `toml:"respect_dnd"`
+	FailuresBreakDND *bool     `toml:"failures_break_dnd"`
+	QuietHours       *[]string `toml:"quiet_hours"`
 }
 
 // savedConfig is the shape Save serializes: idle_timeout goes out as the
 // documented duration string, never as raw nanoseconds.
 type savedConfig struct {
-	Guardian    savedGuardianConfig `toml:"guardian"`
-	Directories DirectoriesConfig   `toml:"directories"`
+	SchemaVersion int                      `toml:"schema_version"`
+	Strict        bool                     `toml:"strict,omitempty"`
+	Guardian      savedGuardianConfig      `toml:"guardian"`
+	Directories   DirectoriesConfig        `toml:"directories"`
+	Keys          KeysConfig               `toml:"keys"`
+	VaultSync     savedVaultSyncConfig     `toml:"vault_sync"`
+	VaultCache    savedVaultCacheConfig    `toml:"vault_cache"`
+	Edit          EditConfig               `toml:"edit"`
+	Backups       savedBackupsConfig       `toml:"backups"`
+	Telemetry     TelemetryConfig          `toml:"telemetry"`
+	Update        UpdateConfig             `toml:"update"`
+	Power         PowerConfig              `toml:"power"`
+	Logs          savedLogsConfig          `toml:"logs"`
+	History       savedHistoryConfig       `toml:"history"`
+	Identity      IdentityConfig           `toml:"identity"`
+	Remote        RemoteConfig             `toml:"remote"`
+	Compliance    savedComplianceConfig    `toml:"compliance"`
+	Severity      SeverityConfig           `toml:"severity"`
+	Hooks         savedHooksConfig         `toml:"hooks"`
+	Notifications NotificationsConfig      `toml:"notifications"`
+	Profiles      map[string]ProfileConfig `toml:"profiles,omitempty"`
+	Policies      []savedPolicyConfig      `toml:"policies,omitempty"`
+	// ManagedPolicies is omitted while unset.
+	ManagedPolicies *ManagedPoliciesConfig `toml:"managed_policies,omitempty"`
+}
+
+type savedLogsConfig struct {
+	MaxSize string `toml:"max_size"`
+	Backups int    `toml:"backups"`
+	Keep    string `toml:"keep"`
+}
+
+type savedHistoryConfig struct {
+	Keep string `toml:"keep"`
+}
+
+type savedComplianceConfig struct {
+	ReadOnly    bool   `toml:"read_only"`
+	Interval    string `toml:"interval"`
+	Webhook     string `toml:"webhook"`
+	MetricsFile string `toml:"metrics_file"`
+}
+
+type savedHooksConfig struct {
+	PreEncrypt  string `toml:"pre_encrypt"`
+	PostEncrypt string `toml:"post_encrypt"`
+	OnFailure   string `toml:"on_failure"`
+	Timeout     string `toml:"timeout"`
+}
+
+type savedVaultSyncConfig struct {
+	Enabled  bool   `toml:"enabled"`
+	Interval string `toml:"interval"`
+	Target   string `toml:"target"`
+}
+
+type savedVaultCacheConfig struct {
+	TTL  string `toml:"ttl"`
+	Disk bool   `toml:"disk"`
+}
+
+type savedBackupsConfig struct {
+	Enabled bool   `toml:"enabled"`
+	Keep    int    `toml:"keep"`
+	MaxAge  string `toml:"max_age"`
+	Trash   bool   `toml:"trash"`
 }
 
 type savedGuardianConfig struct {
-	Enabled     bool     `toml:"enabled"`
-	IdleTimeout string   `toml:"idle_timeout"`
-	Patterns    []string `toml:"patterns"`
-	Exclude     []string `toml:"exclude"`
-	Notify      bool     `toml:"notify"`
+	Enabled          bool     `toml:"enabled"`
+	IdleTimeout      string   `toml:"idle_timeout"`
+	Patterns         []string `toml:"patterns"`
+	Exclude          []string `toml:"exclude"`
+	Notify           bool     `toml:"notify"`
+	Symlinks         string   `toml:"symlinks"`
+	IgnoreGenerated  bool     `toml:"ignore_generated"`
+	Incremental      bool     `toml:"incremental"`
+	NormalizeOutput  bool     `toml:"normalize_output"`
+	Profile          string   `toml:"profile"`
+	Debounce         string   `toml:"debounce"`
+	Language         string   `toml:"language"`
+	PlainOutput      bool     `toml:"plain_output"`
+	Journal          bool     `toml:"journal"`
+	Journald         bool     `toml:"journald"`
+	GracePeriod      string   `toml:"grace_period"`
+	EncryptWhen      string   `toml:"encrypt_when"`
+	RescanInterval   string   `toml:"rescan_interval"`
+	PlaintextAllowed []string `toml:"plaintext_allowed"`
+	ConfigScan       string   `toml:"config_scan_interval"`
+	DeepWorkHours    []string `toml:"deep_work_hours"`
 }
 
 // DefaultConfig returns a *Config populated with sensible defaults for the Guardian and Directories sections.
 //
 // Defaults:
-//   - Guardian: Enabled=true, IdleTimeout=5m, Patterns=[".env*"], Exclude=[".env.example", ".env.sample", ".env.keys"], Notify=true
-//   - Directories: Watch=["$HOME/projects"], Recursive=true
+//   - Guardian: Enabled=true, IdleTimeout=5m, Patterns=[".env*"], Exclude=[".env.example", ".env.sample", ".env.keys"], Notify=true,
+//     Symlinks="follow", IgnoreGenerated=true, Incremental=false, NormalizeOutput=false,
+//     Debounce=2s, Language="" (from the environment), PlainOutput=false, Journal=true, Journald=false,
+//     GracePeriod=0 (off), EncryptWhen="" (every idle file), RescanInterval=0 (off),
+//     PlaintextAllowed=[] (every value is a secret), ConfigScanInterval=0 (off),
+//     DeepWorkHours=[] (none)
+//   - Directories: Watch=["$HOME/projects"], Recursive=true, Roots={} (every root enforced)
+//   - Keys: Resolution=["env", "dotenv_keys", "keychain", "vault"], SyncStore="" (off), Team={}
+//   - VaultSync: Enabled=false, Interval=1h, Target="dotenv_keys"
+//   - VaultCache: TTL=5m, Disk=false
+//   - Edit: AutoOpen=true, Editor="" ($VISUAL, $EDITOR, then the platform default)
+//   - Backups: Enabled=false, Keep=5, MaxAge=7d, Trash=false
+//   - Telemetry: Enabled=false, Endpoint="" (opt-in, local only)
+//   - Update: Channel="stable"
+//   - Power: DeferBelow=20
+//   - Logs: MaxSize=5MiB, Backups=3, Keep=14d
+//   - History: Keep=90d
+//   - Identity: Keypair=false
+//   - Remote: Hosts=[], Agent="envdrift-agent"
+//   - Compliance: ReadOnly=false, Interval=15m, Webhook="", MetricsFile=""
+//   - Severity: Notify="low", Fail="low" (every plaintext file), Gitleaks=[] (built-in rules only)
+//   - Hooks: PreEncrypt="", PostEncrypt="", OnFailure="" (none), Timeout=30s
+//   - Notifications: every event type to the desktop, Webhook="", RespectDND=true,
+//     FailuresBreakDND=false, QuietHours=[] (none)
+//   - Profiles: none
+//   - Policies: none
+//   - ManagedPolicies: none
 //
 // The default watch path is constructed from the current user's home directory; if the home directory cannot
 // be determined the path will be "projects" (i.e., the home prefix will be empty).
@@ -90,54 +664,257 @@ func DefaultConfig() *Config {
 	homeDir, _ := os.UserHomeDir()
 	return &Config{
 		Guardian: GuardianConfig{
-			Enabled:     true,
-			IdleTimeout: 5 * time.Minute,
-			Patterns:    []string{".env*"},
-			Exclude:     []string{".env.example", ".env.sample", ".env.keys"},
-			Notify:      true,
+			Enabled:         true,
+			IdleTimeout:     5 * time.Minute,
+			Patterns:        []string{".env*"},
+			Exclude:         []string{".env.example", ".env.sample", ".env.keys"},
+			Notify:          true,
+			Symlinks:        project.SymlinksFollow,
+			IgnoreGenerated: true,
+			Incremental:     false,
+			Debounce:        2 * time.Second,
+			Journal:         true,
 		},
 		Directories: DirectoriesConfig{
 			Watch:     []string{filepath.Join(homeDir, "projects")},
 			Recursive: true,
 		},
+		Keys: KeysConfig{
+			Resolution: []string{"env", "dotenv_keys", "keychain", "vault"},
+			Team:       map[string]string{},
+		},
+		VaultSync: VaultSyncConfig{
+			Enabled:  false,
+			Interval: time.Hour,
+			Target:   "dotenv_keys",
+		},
+		VaultCache: VaultCacheConfig{
+			TTL: 5 * time.Minute,
+		},
+		Edit: EditConfig{
+			AutoOpen: true,
+		},
+		Backups: BackupsConfig{
+			Enabled: false,
+			Keep:    5,
+			MaxAge:  7 * 24 * time.Hour,
+		},
+		Update: UpdateConfig{
+			Channel: update.ChannelStable,
+		},
+		Power: PowerConfig{
+			DeferBelow: 20,
+		},
+		Logs: LogsConfig{
+			MaxSize: logging.DefaultMaxBytes,
+			Backups: logging.DefaultBackups,
+			Keep:    14 * 24 * time.Hour,
+		},
+		History: HistoryConfig{
+			Keep: 90 * 24 * time.Hour,
+		},
+		Remote: RemoteConfig{
+			Hosts: []string{},
+			Agent: "envdrift-agent",
+		},
+		Compliance: ComplianceConfig{
+			Interval: 15 * time.Minute,
+		},
+		Severity: SeverityConfig{
+			Notify:   "low",
+			Fail:     "low",
+			Gitleaks: []string{},
+		},
+		Hooks: HooksConfig{
+			Timeout: 30 * time.Second,
+		},
+		Notifications: NotificationsConfig{
+			Encrypted:  []string{notify.ChannelDesktop},
+			Failure:    []string{notify.ChannelDesktop},
+			Warning:    []string{notify.ChannelDesktop},
+			Info:       []string{notify.ChannelDesktop},
+			RespectDND: true,
+			QuietHours: []string{},
+		},
 	}
 }
 
-// ConfigPath returns the path to the guardian configuration file under the user's home directory: "<home>/.envdrift/guardian.toml".
-// If the user's home directory cannot be determined, the returned path is relative (".envdrift/guardian.toml").
+// ConfigPath returns the path to the guardian configuration file: guardian.toml in the config directory
+// (see paths.ConfigDir), e.g. "~/.config/envdrift/guardian.toml" on Linux.
 func ConfigPath() string {
-	homeDir, _ := os.UserHomeDir()
-	return filepath.Join(homeDir, ".envdrift", "guardian.toml")
+	return filepath.Join(paths.ConfigDir(), "guardian.toml")
 }
 
 // Load reads the guardian configuration from the default config file and returns it.
 // If the config file does not exist, Load returns the default configuration.
+// A file older than SchemaVersion is migrated first (see migrateFile), and
+// ENVDRIFT_ variables then override its settings (see applyEnv).
 // If reading the file or unmarshalling TOML fails, Load returns a non-nil error.
 func Load() (*Config, error) {
+	return load(true)
+}
+
+// LoadWithoutEnv is Load ignoring ENVDRIFT_ overrides: the configuration as
+// guardian.toml alone has it, for a caller that saves it back.
+func LoadWithoutEnv() (*Config, error) {
+	return load(false)
+}
+
+func load(env bool) (*Config, error) {
 	configPath := ConfigPath()
 
 	data, err := os.ReadFile(configPath)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return DefaultConfig(), nil
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err == nil {
+		if data, err = migrateFile(data, configPath); err != nil {
+			return nil, err
 		}
+	}
+	if env {
+		if data, err = applyEnv(data); err != nil {
+			return nil, err
+		}
+	}
+	if data == nil {
+		return DefaultConfig(), nil
+	}
+	return parse(data, configPath)
+}
+
+// LoadFile reads a guardian configuration from path, e.g. a candidate to be
+// checked before it replaces guardian.toml. Unlike Load, a missing file is an
+// error, and an old schema is migrated in memory only.
+func LoadFile(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
 		return nil, err
 	}
+	if data, _, err = upgrade(data, path); err != nil {
+		return nil, err
+	}
+	return parse(data, path)
+}
 
+// parse decodes a guardian.toml document over the defaults; configPath only
+// labels errors.
+func parse(data []byte, configPath string) (*Config, error) {
 	cfg := DefaultConfig()
 	var raw rawConfig
 	if err := toml.Unmarshal(data, &raw); err != nil {
-		return nil, err
+		return nil, describeDecodeError(err, configPath)
+	}
+	if raw.Strict != nil {
+		cfg.Strict = *raw.Strict
+	}
+	if Strict || cfg.Strict {
+		if err := checkUnknownKeys(data, configPath); err != nil {
+			return nil, err
+		}
 	}
 
 	if err := mergeGuardian(&cfg.Guardian, &raw.Guardian, configPath); err != nil {
 		return nil, err
 	}
-	mergeDirectories(&cfg.Directories, &raw.Directories)
+	if err := mergeDirectories(&cfg.Directories, &raw.Directories, configPath); err != nil {
+		return nil, err
+	}
+	if err := mergeKeys(&cfg.Keys, &raw.Keys, configPath); err != nil {
+		return nil, err
+	}
+	if err := mergeVaultSync(&cfg.VaultSync, &raw.VaultSync, configPath); err != nil {
+		return nil, err
+	}
+	if err := mergeVaultCache(&cfg.VaultCache, &raw.VaultCache, configPath); err != nil {
+		return nil, err
+	}
+	if raw.Edit.AutoOpen != nil {
+		cfg.Edit.AutoOpen = *raw.Edit.AutoOpen
+	}
+	if raw.Edit.Editor != nil {
+		cfg.Edit.Editor = *raw.Edit.Editor
+	}
+	if err := mergeBackups(&cfg.Backups, &raw.Backups, configPath); err != nil {
+		return nil, err
+	}
+	if raw.Telemetry.Enabled != nil {
+		cfg.Telemetry.Enabled = *raw.Telemetry.Enabled
+	}
+	if raw.Telemetry.Endpoint != nil {
+		cfg.Telemetry.Endpoint = *raw.Telemetry.Endpoint
+	}
+	if raw.Update.Channel != nil {
+		if err := update.ValidateChannel(*raw.Update.Channel); err != nil {
+			return nil, fmt.Errorf("%s: update.channel: %w", configPath, err)
+		}
+		cfg.Update.Channel = *raw.Update.Channel
+	}
+	if d := raw.Power.DeferBelow; d != nil {
+		if *d < 0 || *d > 100 {
+			return nil, fmt.Errorf("%s: power.defer_below: %d is not a percentage", configPath, *d)
+		}
+		cfg.Power.DeferBelow = *d
+	}
+	if n := raw.Logs.MaxSize; n != nil {
+		if *n < 1<<10 {
+			return nil, fmt.Errorf("%s: logs.max_size: %d bytes is below the 1KiB minimum", configPath, *n)
+		}
+		cfg.Logs.MaxSize = int64(*n)
+	}
+	if n := raw.Logs.Backups; n != nil {
+		if *n < 0 {
+			return nil, fmt.Errorf("%s: logs.backups: %d is negative", configPath, *n)
+		}
+		cfg.Logs.Backups = *n
+	}
+	if d := raw.Logs.Keep; d != nil {
+		if *d < 0 {
+			return nil, fmt.Errorf("%s: logs.keep: %v is negative", configPath, time.Duration(*d))
+		}
+		cfg.Logs.Keep = time.Duration(*d)
+	}
+	if d := raw.History.Keep; d != nil {
+		if *d < 0 {
+			return nil, fmt.Errorf("%s: history.keep: %v is negative", configPath, time.Duration(*d))
+		}
+		cfg.History.Keep = time.Duration(*d)
+	}
+	if raw.Identity.Keypair != nil {
+		cfg.Identity.Keypair = *raw.Identity.Keypair
+	}
+	if err := mergeRemote(&cfg.Remote, &raw.Remote, configPath); err != nil {
+		return nil, err
+	}
+	if err := mergeCompliance(&cfg.Compliance, &raw.Compliance, configPath); err != nil {
+		return nil, err
+	}
+	if err := mergeSeverity(&cfg.Severity, &raw.Severity, configPath); err != nil {
+		return nil, err
+	}
+	if err := mergeHooks(&cfg.Hooks, &raw.Hooks, configPath); err != nil {
+		return nil, err
+	}
+	if err := mergeNotifications(&cfg.Notifications, &raw.Notifications, configPath); err != nil {
+		return nil, err
+	}
+	if err := mergeProfiles(cfg, raw.Profiles, configPath); err != nil {
+		return nil, err
+	}
+	if err := mergePolicies(cfg, raw.Policies, configPath); err != nil {
+		return nil, err
+	}
+	if err := loadManagedPolicies(cfg, raw.ManagedPolicies, configPath); err != nil {
+		return nil, &PolicyError{err}
+	}
 
 	return cfg, nil
 }
 
+// MinRescanInterval is the shortest [guardian] rescan_interval and
+// config_scan_interval: either walks every watched tree and reads files.
+const MinRescanInterval = time.Minute
+
 // mergeGuardian overlays the present fields of a decoded guardian section onto
 // the defaults already in cfg. Only keys actually present in the file change a
 // default; an explicit empty slice (patterns = []) clears it.
@@ -146,11 +923,7 @@ func mergeGuardian(cfg *GuardianConfig, raw *rawGuardianConfig, configPath strin
 		cfg.Enabled = *raw.Enabled
 	}
 	if raw.IdleTimeout != nil {
-		d, err := decodeIdleTimeout(raw.IdleTimeout)
-		if err != nil {
-			return fmt.Errorf("%s: guardian.idle_timeout: %w", configPath, err)
-		}
-		cfg.IdleTimeout = d
+		cfg.IdleTimeout = time.Duration(*raw.IdleTimeout)
 	}
 	if raw.Patterns != nil {
 		cfg.Patterns = *raw.Patterns
@@ -161,34 +934,348 @@ func mergeGuardian(cfg *GuardianConfig, raw *rawGuardianConfig, configPath strin
 	if raw.Notify != nil {
 		cfg.Notify = *raw.Notify
 	}
+	if raw.Symlinks != nil {
+		if err := project.ValidateSymlinks(*raw.Symlinks); err != nil {
+			return fmt.Errorf("%s: %w", configPath, err)
+		}
+		cfg.Symlinks = *raw.Symlinks
+	}
+	if raw.IgnoreGenerated != nil {
+		cfg.IgnoreGenerated = *raw.IgnoreGenerated
+	}
+	if raw.Incremental != nil {
+		cfg.Incremental = *raw.Incremental
+	}
+	if raw.NormalizeOutput != nil {
+		cfg.NormalizeOutput = *raw.NormalizeOutput
+	}
+	if raw.Profile != nil {
+		cfg.Profile = *raw.Profile
+	}
+	if raw.Debounce != nil {
+		d := time.Duration(*raw.Debounce)
+		if d < 0 || d > time.Minute {
+			return fmt.Errorf("%s: guardian.debounce: %v is outside 0s..1m", configPath, d)
+		}
+		cfg.Debounce = d
+	}
+	if raw.Language != nil {
+		cfg.Language = *raw.Language
+	}
+	if raw.PlainOutput != nil {
+		cfg.PlainOutput = *raw.PlainOutput
+	}
+	if raw.Journal != nil {
+		cfg.Journal = *raw.Journal
+	}
+	if raw.Journald != nil {
+		cfg.Journald = *raw.Journald
+	}
+	if raw.GracePeriod != nil {
+		d := time.Duration(*raw.GracePeriod)
+		if d < 0 {
+			return fmt.Errorf("%s: guardian.grace_period: %v is negative", configPath, d)
+		}
+		cfg.GracePeriod = d
+	}
+	if raw.EncryptWhen != nil {
+		if src := strings.TrimSpace(*raw.EncryptWhen); src != "" {
+			if _, err := rule.Compile(src); err != nil {
+				return fmt.Errorf("%s: guardian.encrypt_when: %w", configPath, err)
+			}
+			cfg.EncryptWhen = src
+		}
+	}
+	if raw.RescanInterval != nil {
+		d := time.Duration(*raw.RescanInterval)
+		if d != 0 && d < MinRescanInterval {
+			return fmt.Errorf("%s: guardian.rescan_interval: %v is below %v (use 0s to turn it off)", configPath, d, MinRescanInterval)
+		}
+		cfg.RescanInterval = d
+	}
+	if raw.ConfigScan != nil {
+		d := time.Duration(*raw.ConfigScan)
+		if d != 0 && d < MinRescanInterval {
+			return fmt.Errorf("%s: guardian.config_scan_interval: %v is below %v (use 0s to turn it off)", configPath, d, MinRescanInterval)
+		}
+		cfg.ConfigScanInterval = d
+	}
+	if raw.DeepWorkHours != nil {
+		if _, err := schedule.ParseAll(*raw.DeepWorkHours); err != nil {
+			return fmt.Errorf("%s: guardian.deep_work_hours: %w", configPath, err)
+		}
+		cfg.DeepWorkHours = *raw.DeepWorkHours
+	}
+	if raw.PlaintextAllowed != nil {
+		for _, name := range *raw.PlaintextAllowed {
+			if _, err := path.Match(name, ""); err != nil || name == "" {
+				return fmt.Errorf("%s: guardian.plaintext_allowed: %q is not a variable name or glob", configPath, name)
+			}
+		}
+		cfg.PlaintextAllowed = *raw.PlaintextAllowed
+	}
+	return nil
+}
+
+// mergeKeys overlays the present fields of a decoded keys section onto the
+// defaults in cfg. The sync store and team recipients are only checked for a
+// supported form here; keys sync and keys share report whether they work.
+func mergeKeys(cfg *KeysConfig, raw *rawKeysConfig, configPath string) error {
+	if raw.Resolution != nil {
+		cfg.Resolution = *raw.Resolution
+	}
+	if raw.SyncStore != nil {
+		store := *raw.SyncStore
+		switch {
+		case store == "", strings.HasPrefix(store, "s3://"), strings.HasPrefix(store, "https://"),
+			strings.HasPrefix(store, "file://"), strings.HasPrefix(store, "~"), filepath.IsAbs(store):
+		default:
+			return fmt.Errorf("%s: keys.sync_store: %q is not an absolute path, file://, s3:// or https:// location", configPath, store)
+		}
+		cfg.SyncStore = store
+	}
+	if raw.Team != nil {
+		for name, recipient := range *raw.Team {
+			if err := keyshare.ValidRecipient(recipient); err != nil {
+				return fmt.Errorf("%s: keys.team.%s: %w", configPath, name, err)
+			}
+		}
+		cfg.Team = *raw.Team
+	}
+	return nil
+}
+
+// mergeRemote overlays the present fields of a decoded remote section onto
+// the defaults in cfg. A host starting with "-" would reach ssh as an option,
+// and one with spaces as several arguments, so both are rejected.
+func mergeRemote(cfg *RemoteConfig, raw *rawRemoteConfig, configPath string) error {
+	if raw.Hosts != nil {
+		for _, h := range *raw.Hosts {
+			if err := ValidateRemoteHost(h); err != nil {
+				return fmt.Errorf("%s: remote.hosts: %w", configPath, err)
+			}
+		}
+		cfg.Hosts = *raw.Hosts
+	}
+	if raw.Agent != nil {
+		if strings.TrimSpace(*raw.Agent) == "" {
+			return fmt.Errorf("%s: remote.agent: must not be empty", configPath)
+		}
+		cfg.Agent = *raw.Agent
+	}
+	return nil
+}
+
+// ValidateRemoteHost reports an error unless host is usable as an ssh
+// destination argument.
+func ValidateRemoteHost(host string) error {
+	switch {
+	case host == "":
+		return fmt.Errorf("empty host")
+	case strings.HasPrefix(host, "-"):
+		return fmt.Errorf("host %q starts with '-'", host)
+	case strings.IndexFunc(host, unicode.IsSpace) >= 0:
+		return fmt.Errorf("host %q contains whitespace", host)
+	}
+	return nil
+}
+
+// mergeCompliance overlays the present fields of a decoded compliance section
+// onto the defaults in cfg.
+func mergeCompliance(cfg *ComplianceConfig, raw *rawComplianceConfig, configPath string) error {
+	if raw.ReadOnly != nil {
+		cfg.ReadOnly = *raw.ReadOnly
+	}
+	if raw.Interval != nil {
+		d := time.Duration(*raw.Interval)
+		if d < time.Minute {
+			return fmt.Errorf("%s: compliance.interval: %v is below the 1m minimum", configPath, d)
+		}
+		cfg.Interval = d
+	}
+	if raw.Webhook != nil {
+		if w := *raw.Webhook; w != "" && !strings.HasPrefix(w, "https://") && !strings.HasPrefix(w, "http://") {
+			return fmt.Errorf("%s: compliance.webhook: %q is not an http(s) URL", configPath, w)
+		}
+		cfg.Webhook = *raw.Webhook
+	}
+	if raw.MetricsFile != nil {
+		if m := *raw.MetricsFile; m != "" && !filepath.IsAbs(m) {
+			return fmt.Errorf("%s: compliance.metrics_file: %q is not an absolute path", configPath, m)
+		}
+		cfg.MetricsFile = *raw.MetricsFile
+	}
+	return nil
+}
+
+// mergeSeverity overlays the present fields of a decoded severity section
+// onto the defaults in cfg.
+func mergeSeverity(cfg *SeverityConfig, raw *rawSeverityConfig, configPath string) error {
+	if raw.Notify != nil {
+		if _, err := severity.Parse(*raw.Notify); err != nil {
+			return fmt.Errorf("%s: severity.notify: %w", configPath, err)
+		}
+		cfg.Notify = *raw.Notify
+	}
+	if raw.Fail != nil {
+		if _, err := severity.Parse(*raw.Fail); err != nil {
+			return fmt.Errorf("%s: severity.fail: %w", configPath, err)
+		}
+		cfg.Fail = *raw.Fail
+	}
+	if raw.Gitleaks != nil {
+		cfg.Gitleaks = *raw.Gitleaks
+		if _, err := cfg.GitleaksRules(); err != nil {
+			return fmt.Errorf("%s: severity.gitleaks: %w", configPath, err)
+		}
+	}
+	return nil
+}
+
+// mergeHooks overlays the present fields of a decoded hooks section onto the
+// defaults in cfg.
+func mergeHooks(cfg *HooksConfig, raw *rawHooksConfig, configPath string) error {
+	if raw.PreEncrypt != nil {
+		cfg.PreEncrypt = *raw.PreEncrypt
+	}
+	if raw.PostEncrypt != nil {
+		cfg.PostEncrypt = *raw.PostEncrypt
+	}
+	if raw.OnFailure != nil {
+		cfg.OnFailure = *raw.OnFailure
+	}
+	if raw.Timeout != nil {
+		d := time.Duration(*raw.Timeout)
+		if d < time.Second || d > 10*time.Minute {
+			return fmt.Errorf("%s: hooks.timeout: %v is outside 1s..10m", configPath, d)
+		}
+		cfg.Timeout = d
+	}
+	return nil
+}
+
+// mergeNotifications overlays the present fields of a decoded notifications
+// section onto the defaults in cfg, rejecting unknown channels and a webhook
+// route without a webhook URL.
+func mergeNotifications(cfg *NotificationsConfig, raw *rawNotificationsConfig, configPath string) error {
+	if raw.Webhook != nil {
+		cfg.Webhook = *raw.Webhook
+	}
+	if raw.RespectDND != nil {
+		cfg.RespectDND = *raw.RespectDND
+	}
+	if raw.FailuresBreakDND != nil {
+		cfg.FailuresBreakDND = *raw.FailuresBreakDND
+	}
+	if raw.QuietHours != nil {
+		if _, err := schedule.ParseAll(*raw.QuietHours); err != nil {
+			return fmt.Errorf("%s: notifications.quiet_hours: %w", configPath, err)
+		}
+		cfg.QuietHours = *raw.QuietHours
+	}
+	for _, f := range []struct {
+		name string
+		raw  *[]string
+		dst  *[]string
+	}{
+		{notify.EventEncrypted, raw.Encrypted, &cfg.Encrypted},
+		{notify.EventFailure, raw.Failure, &cfg.Failure},
+		{notify.EventWarning, raw.Warning, &cfg.Warning},
+		{notify.EventInfo, raw.Info, &cfg.Info},
+	} {
+		if f.raw == nil {
+			continue
+		}
+		for _, c := range *f.raw {
+			if err := notify.ValidateChannel(c); err != nil {
+				return fmt.Errorf("%s: notifications.%s: %w", configPath, f.name, err)
+			}
+			if c == notify.ChannelWebhook && cfg.Webhook == "" {
+				return fmt.Errorf("%s: notifications.%s: the webhook channel needs notifications.webhook", configPath, f.name)
+			}
+		}
+		*f.dst = *f.raw
+	}
 	return nil
 }
 
 // mergeDirectories overlays the present fields of a decoded directories section
 // onto the defaults already in cfg (explicit watch = [] clears the default).
-func mergeDirectories(cfg *DirectoriesConfig, raw *rawDirectoriesConfig) {
+func mergeDirectories(cfg *DirectoriesConfig, raw *rawDirectoriesConfig, configPath string) error {
 	if raw.Watch != nil {
 		cfg.Watch = *raw.Watch
 	}
 	if raw.Recursive != nil {
 		cfg.Recursive = *raw.Recursive
 	}
+	for root, r := range raw.Roots {
+		if r.Mode != "" && r.Mode != ModeEnforce && r.Mode != ModeObserve {
+			return fmt.Errorf("%s: directories.roots.%q.mode: %q is not enforce or observe", configPath, root, r.Mode)
+		}
+	}
+	if raw.Roots != nil {
+		cfg.Roots = raw.Roots
+	}
+	return nil
 }
 
-// decodeIdleTimeout converts a TOML idle_timeout value into a time.Duration.
-// The documented form is a duration string ("30s", "5m", "1h", "2d" — parsed
-// by the same project.ParseIdleTimeout the per-project config uses); a bare
-// integer is the legacy raw-nanosecond form pre-#481 Save wrote and stays
-// loadable so existing config files don't start crashing the agent.
-func decodeIdleTimeout(v any) (time.Duration, error) {
-	switch tv := v.(type) {
-	case string:
-		return project.ParseIdleTimeout(tv)
-	case int64:
-		return time.Duration(tv), nil
-	default:
-		return 0, fmt.Errorf("unsupported value %v (type %T); use a duration string like \"5m\"", v, v)
+// mergeVaultSync overlays the present fields of a decoded vault_sync section
+// onto the defaults already in cfg.
+func mergeVaultSync(cfg *VaultSyncConfig, raw *rawVaultSyncConfig, configPath string) error {
+	if raw.Enabled != nil {
+		cfg.Enabled = *raw.Enabled
+	}
+	if raw.Interval != nil {
+		d := time.Duration(*raw.Interval)
+		if d < time.Minute {
+			return fmt.Errorf("%s: vault_sync.interval: %v is below the 1m minimum", configPath, d)
+		}
+		cfg.Interval = d
+	}
+	if raw.Target != nil {
+		if *raw.Target != "dotenv_keys" && *raw.Target != "keychain" {
+			return fmt.Errorf("%s: vault_sync.target: %q is not dotenv_keys or keychain", configPath, *raw.Target)
+		}
+		cfg.Target = *raw.Target
+	}
+	return nil
+}
+
+// mergeVaultCache overlays the present fields of a decoded vault_cache
+// section onto the defaults already in cfg; ttl = "0s" turns the cache off.
+func mergeVaultCache(cfg *VaultCacheConfig, raw *rawVaultCacheConfig, configPath string) error {
+	if raw.TTL != nil {
+		d := time.Duration(*raw.TTL)
+		if d < 0 {
+			return fmt.Errorf("%s: vault_cache.ttl: %v is negative", configPath, d)
+		}
+		cfg.TTL = d
+	}
+	if raw.Disk != nil {
+		cfg.Disk = *raw.Disk
+	}
+	return nil
+}
+
+// mergeBackups overlays the present fields of a decoded backups section onto
+// the defaults already in cfg; max_age = "0s" and keep = 0 disable that limit.
+func mergeBackups(cfg *BackupsConfig, raw *rawBackupsConfig, configPath string) error {
+	if raw.Enabled != nil {
+		cfg.Enabled = *raw.Enabled
 	}
+	if raw.Keep != nil {
+		if *raw.Keep < 0 {
+			return fmt.Errorf("%s: backups.keep: %d is negative", configPath, *raw.Keep)
+		}
+		cfg.Keep = *raw.Keep
+	}
+	if raw.MaxAge != nil {
+		cfg.MaxAge = time.Duration(*raw.MaxAge)
+	}
+	if raw.Trash != nil {
+		cfg.Trash = *raw.Trash
+	}
+	return nil
 }
 
 // FormatIdleTimeout renders a duration in the documented guardian.toml form: a
@@ -220,21 +1307,130 @@ func Save(cfg *Config) error {
 		return err
 	}
 
-	out := savedConfig{
+	data, err := toml.Marshal(toSaved(cfg))
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(configPath, data, 0644)
+}
+
+// toSaved converts cfg to the shape Save serializes.
+func toSaved(cfg *Config) *savedConfig {
+	return &savedConfig{
+		SchemaVersion: SchemaVersion,
+		Strict:        cfg.Strict,
 		Guardian: savedGuardianConfig{
-			Enabled:     cfg.Guardian.Enabled,
-			IdleTimeout: FormatIdleTimeout(cfg.Guardian.IdleTimeout),
-			Patterns:    cfg.Guardian.Patterns,
-			Exclude:     cfg.Guardian.Exclude,
-			Notify:      cfg.Guardian.Notify,
+			Enabled:          cfg.Guardian.Enabled,
+			IdleTimeout:      FormatIdleTimeout(cfg.Guardian.IdleTimeout),
+			Patterns:         cfg.Guardian.Patterns,
+			Exclude:          cfg.Guardian.Exclude,
+			Notify:           cfg.Guardian.Notify,
+			Symlinks:         cfg.Guardian.Symlinks,
+			IgnoreGenerated:  cfg.Guardian.IgnoreGenerated,
+			Incremental:      cfg.Guardian.Incremental,
+			NormalizeOutput:  cfg.Guardian.NormalizeOutput,
+			Profile:          cfg.Guardian.Profile,
+			Debounce:         FormatIdleTimeout(cfg.Guardian.Debounce),
+			Language:         cfg.Guardian.Language,
+			PlainOutput:      cfg.Guardian.PlainOutput,
+			Journal:          cfg.Guardian.Journal,
+			Journald:         cfg.Guardian.Journald,
+			GracePeriod:      FormatIdleTimeout(cfg.Guardian.GracePeriod),
+			EncryptWhen:      cfg.Guardian.EncryptWhen,
+			RescanInterval:   FormatIdleTimeout(cfg.Guardian.RescanInterval),
+			PlaintextAllowed: cfg.Guardian.PlaintextAllowed,
+			ConfigScan:       FormatIdleTimeout(cfg.Guardian.ConfigScanInterval),
+			DeepWorkHours:    cfg.Guardian.DeepWorkHours,
 		},
 		Directories: cfg.Directories,
+		Keys:        cfg.Keys,
+		VaultSync: savedVaultSyncConfig{
+			Enabled:  cfg.VaultSync.Enabled,
+			Interval: FormatIdleTimeout(cfg.VaultSync.Interval),
+			Target:   cfg.VaultSync.Target,
+		},
+		VaultCache: savedVaultCacheConfig{
+			TTL:  FormatIdleTimeout(cfg.VaultCache.TTL),
+			Disk: cfg.VaultCache.Disk,
+		},
+		Edit: cfg.Edit,
+		Backups: savedBackupsConfig{
+			Enabled: cfg.Backups.Enabled,
+			Keep:    cfg.Backups.Keep,
+			MaxAge:  FormatIdleTimeout(cfg.Backups.MaxAge),
+			Trash:   cfg.Backups.Trash,
+		},
+		Telemetry: cfg.Telemetry,
+		Update:    cfg.Update,
+		Power:     cfg.Power,
+		Logs: savedLogsConfig{
+			MaxSize: FormatByteSize(cfg.Logs.MaxSize),
+			Backups: cfg.Logs.Backups,
+			Keep:    FormatIdleTimeout(cfg.Logs.Keep),
+		},
+		History:  savedHistoryConfig{Keep: FormatIdleTimeout(cfg.History.Keep)},
+		Identity: cfg.Identity,
+		Remote:   cfg.Remote,
+		Compliance: savedComplianceConfig{
+			ReadOnly:    cfg.Compliance.ReadOnly,
+			Interval:    FormatIdleTimeout(cfg.Compliance.Interval),
+			Webhook:     cfg.Compliance.Webhook,
+			MetricsFile: cfg.Compliance.MetricsFile,
+		},
+		Severity: cfg.Severity,
+		Hooks: savedHooksConfig{
+			PreEncrypt:  cfg.Hooks.PreEncrypt,
+			PostEncrypt: cfg.Hooks.PostEncrypt,
+			OnFailure:   cfg.Hooks.OnFailure,
+			Timeout:     FormatIdleTimeout(cfg.Hooks.Timeout),
+		},
+		Notifications:   cfg.Notifications,
+		Profiles:        cfg.Profiles,
+		Policies:        savePolicies(cfg.Policies),
+		ManagedPolicies: saveManagedPolicies(cfg.ManagedPolicies),
 	}
+}
 
-	data, err := toml.Marshal(out)
+// Set changes one setting in the config file, creating the file from the
+// defaults if needed. key is "section.name" as written in guardian.toml
+// ("telemetry.enabled"); value is TOML (true, 5, "5m", [".env*"]), and a bare
+// word that is not valid TOML is taken as a string. The new file is validated
+// like Load before it replaces the old one. ENVDRIFT_ overrides are not
+// written to the file.
+func Set(key, value string) error {
+	cfg, err := LoadWithoutEnv()
+	if err != nil {
+		return err
+	}
+	data, err := toml.Marshal(toSaved(cfg))
 	if err != nil {
 		return err
 	}
+	var doc map[string]any
+	if err := toml.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+
+	section, name, ok := strings.Cut(key, ".")
+	table, isTable := doc[section].(map[string]any)
+	if !ok || !isTable {
+		return fmt.Errorf("unknown setting %q (want section.name, e.g. telemetry.enabled)", key)
+	}
+	if _, known := table[name]; !known {
+		return fmt.Errorf("unknown setting %q", key)
+	}
+	table[name] = tomlValue(value)
 
+	if data, err = toml.Marshal(doc); err != nil {
+		return err
+	}
+	configPath := ConfigPath()
+	if _, err := parse(data, configPath); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
+		return err
+	}
 	return os.WriteFile(configPath, data, 0644)
 }
@@ -2,14 +2,22 @@
 package config
 
 import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
 	"runtime"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/pelletier/go-toml/v2"
+
+	"github.com/jainal09/envdrift-agent/internal/netstate"
+	"github.com/jainal09/envdrift-agent/internal/severity"
 )
 
 func TestDefaultConfig(t *testing.T) {
@@ -222,6 +230,208 @@ func TestLoadIdleTimeoutForms(t *testing.T) {
 	}
 }
 
+// TestLoadMigratesSchema: a file from before schema_version is upgraded on
+// Load, rewritten with the original kept as a backup, and a file only lacking
+// the version is left untouched.
+func TestLoadMigratesSchema(t *testing.T) {
+	setTempHome(t)
+	legacy := "# mine\n[guardian]\nidle_timeout = 300000000000\nnotify = false\n"
+	writeGuardianToml(t, legacy)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Guardian.IdleTimeout != 5*time.Minute || cfg.Guardian.Notify {
+		t.Errorf("migrated guardian = %+v", cfg.Guardian)
+	}
+	if backup, err := os.ReadFile(ConfigPath() + ".v1.bak"); err != nil || string(backup) != legacy {
+		t.Errorf("backup = %q, %v; want the original file", backup, err)
+	}
+	data, _ := os.ReadFile(ConfigPath())
+	if !strings.Contains(string(data), "schema_version = 2") || !strings.Contains(string(data), "idle_timeout = '5m'") {
+		t.Errorf("migrated file:\n%s", data)
+	}
+
+	// Nothing to migrate: the file keeps its comments.
+	current := "# mine\n[guardian]\nidle_timeout = \"10m\"\n"
+	writeGuardianToml(t, current)
+	if _, err := Load(); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(ConfigPath()); string(data) != current {
+		t.Errorf("file without changes was rewritten:\n%s", data)
+	}
+
+	writeGuardianToml(t, "schema_version = 99\n")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "newer envdrift-agent") {
+		t.Errorf("future schema error = %v", err)
+	}
+
+	// LoadFile migrates in memory only.
+	candidate := filepath.Join(t.TempDir(), "candidate.toml")
+	if err := os.WriteFile(candidate, []byte(legacy), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if cfg, err := LoadFile(candidate); err != nil || cfg.Guardian.IdleTimeout != 5*time.Minute {
+		t.Errorf("LoadFile = %+v, %v", cfg, err)
+	}
+	if data, _ := os.ReadFile(candidate); string(data) != legacy {
+		t.Errorf("LoadFile rewrote the candidate:\n%s", data)
+	}
+}
+
+func TestLoadStrict(t *testing.T) {
+	setTempHome(t)
+	t.Cleanup(func() { Strict = false })
+
+	// Lenient by default: the typo leaves the default in place.
+	writeGuardianToml(t, "[guardian]\nidle_timout = \"1m\"\n")
+	if cfg, err := Load(); err != nil || cfg.Guardian.IdleTimeout != 5*time.Minute {
+		t.Fatalf("lenient load = %+v, %v", cfg, err)
+	}
+	Strict = true
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "line 2: guardian.idle_timout") {
+		t.Errorf("strict error = %v; want the unknown key and its line", err)
+	}
+	Strict = false
+
+	// strict = true in the file, kept by Save; every key Save writes is known.
+	writeGuardianToml(t, "strict = true\n[guardian]\nidle_timeout = \"1m\"\n")
+	cfg, err := Load()
+	if err != nil || !cfg.Strict {
+		t.Fatalf("strict file = %+v, %v", cfg, err)
+	}
+	cfg.Profiles = map[string]ProfileConfig{"work": {Hosts: []string{"*.corp"}}}
+	if err := Save(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg, err := Load(); err != nil || !cfg.Strict {
+		t.Errorf("after Save = %+v, %v", cfg, err)
+	}
+	writeGuardianToml(t, "strict = true\n[notifications]\nwebhok = \"https://example.com\"\n")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "notifications.webhok") {
+		t.Errorf("strict file error = %v", err)
+	}
+}
+
+func TestLoadEnvOverrides(t *testing.T) {
+	setTempHome(t)
+	t.Setenv("ENVDRIFT_GUARDIAN_IDLE_TIMEOUT", "90s")
+	t.Setenv("ENVDRIFT_DIRECTORIES_WATCH", "~/code, ~/work")
+	t.Setenv("ENVDRIFT_TELEMETRY_ENABLED", "true")
+
+	// Without a file the variables override the defaults.
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Guardian.IdleTimeout != 90*time.Second || !cfg.Telemetry.Enabled {
+		t.Errorf("overrides without a file = %+v", cfg)
+	}
+	if got := cfg.Directories.Watch; len(got) != 2 || got[0] != "~/code" || got[1] != "~/work" {
+		t.Errorf("directories.watch = %q", got)
+	}
+
+	// They win over the file, and leave its other settings alone.
+	writeGuardianToml(t, "[guardian]\nidle_timeout = \"1m\"\nnotify = false\n")
+	t.Setenv("ENVDRIFT_DIRECTORIES_WATCH", `["~/src"]`)
+	if cfg, err = Load(); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Guardian.IdleTimeout != 90*time.Second || cfg.Guardian.Notify || len(cfg.Directories.Watch) != 1 {
+		t.Errorf("overrides over a file = %+v", cfg)
+	}
+	want := []string{"ENVDRIFT_DIRECTORIES_WATCH", "ENVDRIFT_GUARDIAN_IDLE_TIMEOUT", "ENVDRIFT_TELEMETRY_ENABLED"}
+	if got := EnvOverrides(); strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("EnvOverrides() = %q, want %q", got, want)
+	}
+
+	// Set and LoadWithoutEnv see the file alone, so no override is saved.
+	if err := Set("guardian.debounce", `"2s"`); err != nil {
+		t.Fatal(err)
+	}
+	saved, err := LoadWithoutEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.Guardian.IdleTimeout != time.Minute || saved.Telemetry.Enabled || saved.Guardian.Debounce != 2*time.Second {
+		t.Errorf("file after Set = %+v", saved)
+	}
+
+	// A bad value is reported by its variable.
+	t.Setenv("ENVDRIFT_GUARDIAN_IDLE_TIMEOUT", "soon")
+	if _, err := Load(); err == nil || !strings.HasPrefix(err.Error(), "ENVDRIFT_GUARDIAN_IDLE_TIMEOUT: ") {
+		t.Errorf("bad duration error = %v", err)
+	}
+	t.Setenv("ENVDRIFT_GUARDIAN_IDLE_TIMEOUT", "90s")
+	t.Setenv("ENVDRIFT_POWER_DEFER_BELOW", "150")
+	if _, err := Load(); err == nil || !strings.HasPrefix(err.Error(), "ENVDRIFT_POWER_DEFER_BELOW: ") {
+		t.Errorf("bad override error = %v", err)
+	}
+}
+
+func TestLoadDurationsAndSizes(t *testing.T) {
+	setTempHome(t)
+	writeGuardianToml(t, `[guardian]
+idle_timeout = "1h30m"
+
+[backups]
+max_age = "1d12h"
+
+[logs]
+max_size = "10MB"
+backups = 5
+keep = "7d"
+
+[history]
+keep = "0s"
+`)
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Guardian.IdleTimeout != 90*time.Minute || cfg.Backups.MaxAge != 36*time.Hour {
+		t.Errorf("durations = %v, %v", cfg.Guardian.IdleTimeout, cfg.Backups.MaxAge)
+	}
+	if cfg.Logs != (LogsConfig{MaxSize: 10_000_000, Backups: 5, Keep: 7 * 24 * time.Hour}) {
+		t.Errorf("logs = %+v", cfg.Logs)
+	}
+	if cfg.History.Keep != 0 {
+		t.Errorf("history.keep = %v; want 0 (forever)", cfg.History.Keep)
+	}
+
+	for _, tc := range []struct{ toml, want string }{
+		{"[guardian]\nidle_timeout = \"5 minutes\"\n", `guardian.toml:2: guardian.idle_timeout: "5 minutes" is not a duration`},
+		{"[logs]\nmax_size = \"10 furlongs\"\n", `guardian.toml:2: logs.max_size: "10 furlongs" is not a size`},
+		{"[logs]\nmax_size = 100\n", "logs.max_size: 100 bytes is below the 1KiB minimum"},
+		{"[history]\nkeep = \"-1h\"\n", "history.keep: -1h0m0s is negative"},
+	} {
+		writeGuardianToml(t, tc.toml)
+		if _, err := Load(); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%q: error = %v; want %q", tc.toml, err, tc.want)
+		}
+	}
+}
+
+func TestParseByteSize(t *testing.T) {
+	for in, want := range map[string]int64{
+		"512": 512, "512B": 512, "10MB": 10_000_000, "5MiB": 5 << 20, "1.5GB": 1_500_000_000, "64 kib": 64 << 10,
+	} {
+		if got, err := ParseByteSize(in); err != nil || got != want {
+			t.Errorf("ParseByteSize(%q) = %d, %v; want %d", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "MB", "10XB", "-5MB"} {
+		if _, err := ParseByteSize(in); err == nil {
+			t.Errorf("ParseByteSize(%q) succeeded", in)
+		}
+	}
+	if got := FormatByteSize(5 << 20); got != "5MiB" {
+		t.Errorf("FormatByteSize(5MiB) = %q", got)
+	}
+}
+
 // TestLoadExplicitEmptySlicesClearDefaults is the #504-review regression: an
 // explicit empty array (patterns = [], exclude = [], watch = []) must clear the
 // default, not be silently ignored. The old []string + len() > 0 check could
@@ -347,3 +557,818 @@ func TestExcludePatterns(t *testing.T) {
 		}
 	}
 }
+
+func TestLoadKeysResolution(t *testing.T) {
+	setTempHome(t)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(cfg.Keys.Resolution, ","); got != "env,dotenv_keys,keychain,vault" {
+		t.Errorf("default resolution = %s", got)
+	}
+
+	writeGuardianToml(t, "[keys]\nresolution = [\"keychain\", \"env\"]\n")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(cfg.Keys.Resolution, ","); got != "keychain,env" {
+		t.Errorf("configured resolution = %s", got)
+	}
+}
+
+func TestLoadVaultSync(t *testing.T) {
+	setTempHome(t)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.VaultSync.Enabled || cfg.VaultSync.Interval != time.Hour || cfg.VaultSync.Target != "dotenv_keys" {
+		t.Errorf("default vault_sync = %+v", cfg.VaultSync)
+	}
+
+	writeGuardianToml(t, "[vault_sync]\nenabled = true\ninterval = \"15m\"\ntarget = \"keychain\"\n")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cfg.VaultSync.Enabled || cfg.VaultSync.Interval != 15*time.Minute || cfg.VaultSync.Target != "keychain" {
+		t.Errorf("configured vault_sync = %+v", cfg.VaultSync)
+	}
+	if err := Save(cfg); err != nil {
+		t.Fatal(err)
+	}
+	reloaded, err := Load()
+	if err != nil || reloaded.VaultSync != cfg.VaultSync {
+		t.Errorf("vault_sync did not round-trip through Save: %+v, %v", reloaded.VaultSync, err)
+	}
+
+	for _, bad := range []string{"interval = \"10s\"", "target = \"vault\""} {
+		writeGuardianToml(t, "[vault_sync]\n"+bad+"\n")
+		if _, err := Load(); err == nil {
+			t.Errorf("%s should be rejected", bad)
+		}
+	}
+}
+
+func TestLoadVaultCache(t *testing.T) {
+	setTempHome(t)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.VaultCache.TTL != 5*time.Minute || cfg.VaultCache.Disk {
+		t.Errorf("default vault_cache = %+v", cfg.VaultCache)
+	}
+
+	writeGuardianToml(t, "[vault_cache]\nttl = \"0s\"\ndisk = true\n")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.VaultCache.TTL != 0 || !cfg.VaultCache.Disk {
+		t.Errorf("configured vault_cache = %+v", cfg.VaultCache)
+	}
+	if err := Save(cfg); err != nil {
+		t.Fatal(err)
+	}
+	reloaded, err := Load()
+	if err != nil || reloaded.VaultCache != cfg.VaultCache {
+		t.Errorf("vault_cache did not round-trip through Save: %+v, %v", reloaded.VaultCache, err)
+	}
+
+	writeGuardianToml(t, "[vault_cache]\nttl = \"-1m\"\n")
+	if _, err := Load(); err == nil {
+		t.Error("a negative ttl should be rejected")
+	}
+}
+
+func TestLoadEdit(t *testing.T) {
+	setTempHome(t)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cfg.Edit.AutoOpen || cfg.Edit.Editor != "" {
+		t.Errorf("default edit = %+v", cfg.Edit)
+	}
+
+	writeGuardianToml(t, "[edit]\nauto_open = false\neditor = \"code --wait\"\n")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Edit.AutoOpen || cfg.Edit.Editor != "code --wait" {
+		t.Errorf("configured edit = %+v", cfg.Edit)
+	}
+	if err := Save(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if reloaded, err := Load(); err != nil || reloaded.Edit != cfg.Edit {
+		t.Errorf("edit did not round-trip through Save: %+v, %v", reloaded.Edit, err)
+	}
+}
+
+func TestLoadBackups(t *testing.T) {
+	setTempHome(t)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := BackupsConfig{Keep: 5, MaxAge: 7 * 24 * time.Hour}
+	if cfg.Backups != want {
+		t.Errorf("default backups = %+v; want %+v", cfg.Backups, want)
+	}
+
+	writeGuardianToml(t, "[backups]\nenabled = true\nkeep = 0\nmax_age = \"2d\"\ntrash = true\n")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = BackupsConfig{Enabled: true, MaxAge: 48 * time.Hour, Trash: true}
+	if cfg.Backups != want {
+		t.Errorf("configured backups = %+v; want %+v", cfg.Backups, want)
+	}
+	if err := Save(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if reloaded, err := Load(); err != nil || reloaded.Backups != want {
+		t.Errorf("backups did not round-trip through Save: %+v, %v", reloaded.Backups, err)
+	}
+
+	writeGuardianToml(t, "[backups]\nkeep = -1\n")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "backups.keep") {
+		t.Errorf("negative keep error = %v", err)
+	}
+}
+
+func TestLoadGuardianSymlinks(t *testing.T) {
+	setTempHome(t)
+
+	writeGuardianToml(t, "[guardian]\nsymlinks = \"skip\"\n")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Guardian.Symlinks != "skip" {
+		t.Errorf("symlinks = %q; want skip", cfg.Guardian.Symlinks)
+	}
+
+	writeGuardianToml(t, "[guardian]\nsymlinks = \"always\"\n")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "guardian.symlinks") {
+		t.Errorf("unknown policy error = %v", err)
+	}
+}
+
+func TestLoadGuardianJournal(t *testing.T) {
+	setTempHome(t)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cfg.Guardian.Journal || cfg.Guardian.Journald {
+		t.Error("journal should default to on, journald to off")
+	}
+
+	writeGuardianToml(t, "[guardian]\njournal = false\njournald = true\n")
+	if cfg, err = Load(); err != nil || cfg.Guardian.Journal || !cfg.Guardian.Journald {
+		t.Errorf("journal = false, journald = true not applied: %+v, %v", cfg.Guardian, err)
+	}
+}
+
+func TestLoadTelemetry(t *testing.T) {
+	setTempHome(t)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Telemetry != (TelemetryConfig{}) {
+		t.Errorf("default telemetry = %+v; want disabled with no endpoint", cfg.Telemetry)
+	}
+
+	writeGuardianToml(t, "[telemetry]\nenabled = true\nendpoint = \"https://telemetry.example.com/v1\"\n")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := TelemetryConfig{Enabled: true, Endpoint: "https://telemetry.example.com/v1"}
+	if cfg.Telemetry != want {
+		t.Errorf("configured telemetry = %+v; want %+v", cfg.Telemetry, want)
+	}
+}
+
+func TestLoadNotifications(t *testing.T) {
+	setTempHome(t)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cfg.Notifications.RespectDND || cfg.Notifications.FailuresBreakDND {
+		t.Errorf("default DND = %v/%v; want held, failures included", cfg.Notifications.RespectDND, cfg.Notifications.FailuresBreakDND)
+	}
+	for event, channels := range cfg.Notifications.Routes() {
+		if !reflect.DeepEqual(channels, []string{"desktop"}) {
+			t.Errorf("default %s route = %v; want desktop", event, channels)
+		}
+	}
+
+	writeGuardianToml(t, "[notifications]\nfailure = [\"desktop\", \"webhook\"]\ninfo = []\nwebhook = \"https://hooks.example.com/x\"\n")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	routes := cfg.Notifications.Routes()
+	if !reflect.DeepEqual(routes["failure"], []string{"desktop", "webhook"}) || len(routes["info"]) != 0 ||
+		!reflect.DeepEqual(routes["encrypted"], []string{"desktop"}) {
+		t.Errorf("routes = %v", routes)
+	}
+
+	for _, doc := range []string{
+		"[notifications]\nfailure = [\"pager\"]\n",
+		"[notifications]\nfailure = [\"webhook\"]\n",
+	} {
+		writeGuardianToml(t, doc)
+		if _, err := Load(); err == nil || !strings.Contains(err.Error(), "notifications.failure") {
+			t.Errorf("Load(%q) error = %v; want a notifications.failure error", doc, err)
+		}
+	}
+}
+
+func TestSet(t *testing.T) {
+	setTempHome(t)
+
+	for _, kv := range [][2]string{
+		{"telemetry.enabled", "true"},
+		{"guardian.idle_timeout", "10m"},
+		{"guardian.patterns", `[".env", ".env.*"]`},
+		{"backups.keep", "2"},
+	} {
+		if err := Set(kv[0], kv[1]); err != nil {
+			t.Fatalf("Set(%s, %s): %v", kv[0], kv[1], err)
+		}
+	}
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cfg.Telemetry.Enabled || cfg.Guardian.IdleTimeout != 10*time.Minute ||
+		len(cfg.Guardian.Patterns) != 2 || cfg.Backups.Keep != 2 {
+		t.Errorf("after Set: telemetry=%v idle=%v patterns=%v keep=%d",
+			cfg.Telemetry.Enabled, cfg.Guardian.IdleTimeout, cfg.Guardian.Patterns, cfg.Backups.Keep)
+	}
+
+	for _, kv := range [][2]string{
+		{"telemetry", "true"},
+		{"telemetry.nope", "true"},
+		{"nope.enabled", "true"},
+		{"backups.keep", "-1"},
+		{"guardian.symlinks", "sometimes"},
+	} {
+		if err := Set(kv[0], kv[1]); err == nil {
+			t.Errorf("Set(%s, %s) succeeded", kv[0], kv[1])
+		}
+	}
+	if reloaded, err := Load(); err != nil || reloaded.Backups.Keep != 2 {
+		t.Errorf("a rejected Set changed the file: keep=%d, %v", reloaded.Backups.Keep, err)
+	}
+}
+
+func TestLoadUpdateChannel(t *testing.T) {
+	setTempHome(t)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Update.Channel != "stable" {
+		t.Errorf("default channel = %q; want stable", cfg.Update.Channel)
+	}
+	if err := Set("update.channel", "beta"); err != nil {
+		t.Fatal(err)
+	}
+	if cfg, err = Load(); err != nil || cfg.Update.Channel != "beta" {
+		t.Errorf("channel after Set = %q, %v; want beta", cfg.Update.Channel, err)
+	}
+
+	writeGuardianToml(t, "[update]\nchannel = \"nightly\"\n")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "update.channel") {
+		t.Errorf("unknown channel error = %v", err)
+	}
+}
+
+func TestProfiles(t *testing.T) {
+	home := setTempHome(t)
+	origHost := hostname
+	t.Cleanup(func() { hostname = origHost })
+	hostname = func() (string, error) { return "Work-Laptop.corp.example.com", nil }
+
+	writeGuardianToml(t, `[guardian]
+notify = true
+
+[profiles.work]
+hosts = ["work-laptop"]
+watch = ["~/work"]
+vault_sync = true
+vault_target = "keychain"
+
+[profiles.personal]
+watch = ["~/code"]
+notify = false
+`)
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name, reason := cfg.ProfileName(); name != "work" || reason != "host" {
+		t.Errorf("ProfileName = %q, %q; want work by host", name, reason)
+	}
+	eff := cfg.Effective()
+	if eff.ActiveProfile != "work" || !eff.VaultSync.Enabled || eff.VaultSync.Target != "keychain" || !eff.Guardian.Notify {
+		t.Errorf("work profile = %+v / %+v", eff.VaultSync, eff.Guardian)
+	}
+	if roots := eff.ProfileRoots(); len(roots) != 1 || roots[0] != filepath.Join(home, "work") {
+		t.Errorf("ProfileRoots = %v", roots)
+	}
+	if cfg.VaultSync.Enabled || cfg.ProfileRoots() != nil {
+		t.Error("Effective changed the loaded config")
+	}
+
+	// A pin wins over the host match and survives Save.
+	if err := Set("guardian.profile", `"personal"`); err != nil {
+		t.Fatal(err)
+	}
+	if cfg, err = Load(); err != nil {
+		t.Fatal(err)
+	}
+	if err := Save(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg, err = Load(); err != nil {
+		t.Fatal(err)
+	}
+	eff = cfg.Effective()
+	if eff.ActiveProfile != "personal" || eff.Guardian.Notify || !cfg.Guardian.Notify {
+		t.Errorf("pinned personal: active=%q effective notify=%v base notify=%v",
+			eff.ActiveProfile, eff.Guardian.Notify, cfg.Guardian.Notify)
+	}
+	if len(cfg.Profiles) != 2 {
+		t.Errorf("profiles after Save = %v", cfg.Profiles)
+	}
+
+	hostname = func() (string, error) { return "home-desktop", nil }
+	writeGuardianToml(t, "[profiles.work]\nhosts = [\"work-laptop\"]\n")
+	if cfg, err = Load(); err != nil {
+		t.Fatal(err)
+	}
+	if eff := cfg.Effective(); eff != cfg || eff.ProfileRoots() != nil {
+		t.Error("no matching profile should leave the config as loaded")
+	}
+
+	writeGuardianToml(t, "[guardian]\nprofile = \"missing\"\n")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "guardian.profile") {
+		t.Errorf("unknown pinned profile error = %v", err)
+	}
+	writeGuardianToml(t, "[profiles.work]\nvault_target = \"s3\"\n")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "vault_target") {
+		t.Errorf("bad vault_target error = %v", err)
+	}
+}
+
+func TestPolicies(t *testing.T) {
+	setTempHome(t)
+
+	writeGuardianToml(t, `[[policies]]
+name = "off-vpn"
+when = { vpn = false, not_ssid = ["Corp"] }
+idle_timeout = "1m"
+notify = true
+
+[[policies]]
+when = { domain_joined = true }
+idle_timeout = "3m"
+notify = false
+`)
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Policies) != 2 || cfg.Policies[0].IdleTimeout != time.Minute {
+		t.Fatalf("policies = %+v", cfg.Policies)
+	}
+
+	cafe := netstate.State{SSID: "Cafe", DomainJoined: true}
+	p := MatchPolicies(cfg.Policies, cafe)
+	if len(p.Names) != 2 || p.Names[0] != "off-vpn" || p.Names[1] != "policies[1]" {
+		t.Errorf("names = %v", p.Names)
+	}
+	if p.IdleTimeout != time.Minute || p.Notify == nil || !*p.Notify {
+		t.Errorf("off the VPN: idle=%v notify=%v; want the strictest 1m and the first notify", p.IdleTimeout, p.Notify)
+	}
+	office := netstate.State{SSID: "Corp", DomainJoined: true}
+	if p := MatchPolicies(cfg.Policies, office); p.IdleTimeout != 3*time.Minute || *p.Notify {
+		t.Errorf("in the office: %+v", p)
+	}
+	if p := MatchPolicies(cfg.Policies, netstate.State{VPN: true}); len(p.Names) != 0 || p.IdleTimeout != 0 || p.Notify != nil {
+		t.Errorf("on the VPN off-domain: %+v; want nothing", p)
+	}
+
+	if err := Save(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if reloaded, err := Load(); err != nil || len(reloaded.Policies) != 2 || reloaded.Policies[1].IdleTimeout != 3*time.Minute {
+		t.Errorf("policies did not round-trip through Save: %+v, %v", reloaded.Policies, err)
+	}
+
+	writeGuardianToml(t, "[[policies]]\nwhen = { vpn = false }\n")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "policies[0]") {
+		t.Errorf("empty policy error = %v", err)
+	}
+}
+
+func TestManagedPolicies(t *testing.T) {
+	setTempHome(t)
+
+	seed := make([]byte, ed25519.SeedSize)
+	priv := ed25519.NewKeyFromSeed(seed)
+	keyID := []byte("envdrift")
+	pub := base64.StdEncoding.EncodeToString(append(append([]byte("Ed"), keyID...), priv.Public().(ed25519.PublicKey)...))
+	// signFile writes path.minisig as `minisign -S -l` would.
+	signFile := func(path string) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig := ed25519.Sign(priv, data)
+		comment := "timestamp:1700000000"
+		global := ed25519.Sign(priv, append(append([]byte(nil), sig...), comment...))
+		minisig := "untrusted comment: test\n" +
+			base64.StdEncoding.EncodeToString(append(append([]byte("Ed"), keyID...), sig...)) + "\n" +
+			"trusted comment: " + comment + "\n" + base64.StdEncoding.EncodeToString(global) + "\n"
+		if err := os.WriteFile(path+".minisig", []byte(minisig), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	policyFile := filepath.Join(t.TempDir(), "org-policies.toml")
+	if err := os.WriteFile(policyFile, []byte("[[policies]]\nname = \"org\"\nidle_timeout = \"30s\"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	signFile(policyFile)
+	writeGuardianToml(t, fmt.Sprintf(`[[policies]]
+name = "local"
+idle_timeout = "2m"
+
+[managed_policies]
+file = '%s'
+trusted_keys = ["%s"]
+`, policyFile, pub))
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("signed policy file rejected: %v", err)
+	}
+	if all := cfg.AllPolicies(); len(all) != 2 || all[0].Name != "org" || all[1].Name != "local" {
+		t.Fatalf("AllPolicies = %+v; want the managed policy first", all)
+	}
+
+	// Save must keep the managed policies out of guardian.toml.
+	if err := Save(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(ConfigPath()); strings.Contains(string(data), `"org"`) {
+		t.Errorf("managed policy written to guardian.toml:\n%s", data)
+	}
+	if reloaded, err := Load(); err != nil || len(reloaded.Managed) != 1 || len(reloaded.Policies) != 1 {
+		t.Errorf("after Save: %+v, %v", reloaded, err)
+	}
+
+	// A policy file changed after signing is refused, not skipped.
+	if err := os.WriteFile(policyFile, []byte("[[policies]]\nname = \"org\"\nidle_timeout = \"24h\"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var policyErr *PolicyError
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "managed_policies") || !errors.As(err, &policyErr) {
+		t.Errorf("tampered policy error = %v; want a PolicyError", err)
+	}
+	if err := os.Remove(policyFile + ".minisig"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "signature") {
+		t.Errorf("unsigned policy error = %v", err)
+	}
+
+	writeGuardianToml(t, "[managed_policies]\ntrusted_keys = [\"RWQnotakey\"]\nfile = '"+policyFile+"'\n")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "trusted_keys[0]") {
+		t.Errorf("bad key error = %v", err)
+	}
+}
+
+func TestLoadPower(t *testing.T) {
+	setTempHome(t)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Power.DeferBelow != 20 {
+		t.Errorf("default defer_below = %d; want 20", cfg.Power.DeferBelow)
+	}
+	writeGuardianToml(t, "[power]\ndefer_below = 0\n")
+	if cfg, err = Load(); err != nil || cfg.Power.DeferBelow != 0 {
+		t.Errorf("defer_below = 0 -> %d, %v", cfg.Power.DeferBelow, err)
+	}
+	writeGuardianToml(t, "[power]\ndefer_below = 101\n")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "power.defer_below") {
+		t.Errorf("defer_below = 101 error = %v", err)
+	}
+}
+
+func TestLoadDebounce(t *testing.T) {
+	setTempHome(t)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Guardian.Debounce != 2*time.Second {
+		t.Errorf("default debounce = %v; want 2s", cfg.Guardian.Debounce)
+	}
+	writeGuardianToml(t, "[guardian]\ndebounce = \"500ms\"\n")
+	if cfg, err = Load(); err != nil || cfg.Guardian.Debounce != 500*time.Millisecond {
+		t.Errorf("debounce = 500ms -> %v, %v", cfg.Guardian.Debounce, err)
+	}
+	writeGuardianToml(t, "[guardian]\ndebounce = \"5m\"\n")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "guardian.debounce") {
+		t.Errorf("debounce = 5m error = %v", err)
+	}
+}
+
+func TestLoadGracePeriod(t *testing.T) {
+	setTempHome(t)
+
+	cfg, err := Load()
+	if err != nil || cfg.Guardian.GracePeriod != 0 {
+		t.Fatalf("default grace_period = %v, %v; want 0", cfg.Guardian.GracePeriod, err)
+	}
+	writeGuardianToml(t, "[guardian]\ngrace_period = \"3d\"\n")
+	if cfg, err = Load(); err != nil || cfg.Guardian.GracePeriod != 72*time.Hour {
+		t.Errorf("grace_period = 3d -> %v, %v", cfg.Guardian.GracePeriod, err)
+	}
+	writeGuardianToml(t, "[guardian]\ngrace_period = \"-1h\"\n")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "guardian.grace_period") {
+		t.Errorf("grace_period = -1h error = %v", err)
+	}
+}
+
+func TestLoadRescanInterval(t *testing.T) {
+	setTempHome(t)
+
+	cfg, err := Load()
+	if err != nil || cfg.Guardian.RescanInterval != 0 {
+		t.Fatalf("default rescan_interval = %v, %v; want 0", cfg.Guardian.RescanInterval, err)
+	}
+	writeGuardianToml(t, "[guardian]\nrescan_interval = \"1h\"\n")
+	if cfg, err = Load(); err != nil || cfg.Guardian.RescanInterval != time.Hour {
+		t.Errorf("rescan_interval = 1h -> %v, %v", cfg.Guardian.RescanInterval, err)
+	}
+	writeGuardianToml(t, "[guardian]\nrescan_interval = \"10s\"\n")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "guardian.rescan_interval") {
+		t.Errorf("rescan_interval = 10s error = %v", err)
+	}
+
+	writeGuardianToml(t, "[guardian]\nconfig_scan_interval = \"30m\"\n")
+	if cfg, err = Load(); err != nil || cfg.Guardian.ConfigScanInterval != 30*time.Minute {
+		t.Errorf("config_scan_interval = 30m -> %v, %v", cfg.Guardian.ConfigScanInterval, err)
+	}
+	writeGuardianToml(t, "[guardian]\nconfig_scan_interval = \"5s\"\n")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "guardian.config_scan_interval") {
+		t.Errorf("config_scan_interval = 5s error = %v", err)
+	}
+}
+
+func TestLoadEncryptWhen(t *testing.T) {
+	setTempHome(t)
+
+	writeGuardianToml(t, "[guardian]\nencrypt_when = 'file.age > duration(\"30m\") && repo.branch != \"main\"'\n")
+	cfg, err := Load()
+	if err != nil || cfg.Guardian.EncryptWhen != `file.age > duration("30m") && repo.branch != "main"` {
+		t.Fatalf("encrypt_when = %q, %v", cfg.Guardian.EncryptWhen, err)
+	}
+	if err := Save(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg, err = Load(); err != nil || cfg.Guardian.EncryptWhen == "" {
+		t.Errorf("encrypt_when after Save = %q, %v", cfg.Guardian.EncryptWhen, err)
+	}
+	writeGuardianToml(t, "[guardian]\nencrypt_when = \"file.age > 30\"\n")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "guardian.encrypt_when") {
+		t.Errorf("ill-typed encrypt_when error = %v", err)
+	}
+}
+
+func TestLoadHooks(t *testing.T) {
+	setTempHome(t)
+
+	cfg, err := Load()
+	if err != nil || cfg.Hooks.PreEncrypt != "" || cfg.Hooks.Timeout != 30*time.Second {
+		t.Fatalf("default hooks = %+v, %v; want none, 30s timeout", cfg.Hooks, err)
+	}
+	writeGuardianToml(t, "[hooks]\npost_encrypt = \"docker compose up -d\"\ntimeout = \"2m\"\n")
+	if cfg, err = Load(); err != nil || cfg.Hooks.PostEncrypt != "docker compose up -d" || cfg.Hooks.Timeout != 2*time.Minute {
+		t.Errorf("hooks = %+v, %v", cfg.Hooks, err)
+	}
+	writeGuardianToml(t, "[hooks]\ntimeout = \"1h\"\n")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "hooks.timeout") {
+		t.Errorf("timeout = 1h error = %v", err)
+	}
+}
+
+func TestLoadCompliance(t *testing.T) {
+	setTempHome(t)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Compliance.ReadOnly || cfg.Compliance.Interval != 15*time.Minute || cfg.Compliance.Reporting() {
+		t.Errorf("default compliance = %+v; want off, every 15m", cfg.Compliance)
+	}
+	writeGuardianToml(t, "[compliance]\nread_only = true\ninterval = \"1h\"\nwebhook = \"https://hooks.example.com/c\"\n")
+	if cfg, err = Load(); err != nil || !cfg.Compliance.ReadOnly || cfg.Compliance.Interval != time.Hour || !cfg.Compliance.Reporting() {
+		t.Errorf("compliance = %+v, %v", cfg.Compliance, err)
+	}
+	for _, bad := range []struct{ toml, want string }{
+		{"[compliance]\ninterval = \"10s\"\n", "compliance.interval"},
+		{"[compliance]\nwebhook = \"ftp://example.com\"\n", "compliance.webhook"},
+		{"[compliance]\nmetrics_file = \"envdrift.prom\"\n", "compliance.metrics_file"},
+	} {
+		writeGuardianToml(t, bad.toml)
+		if _, err := Load(); err == nil || !strings.Contains(err.Error(), bad.want) {
+			t.Errorf("%q error = %v; want %s", bad.toml, err, bad.want)
+		}
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	setTempHome(t)
+
+	path := filepath.Join(t.TempDir(), "candidate.toml")
+	if err := os.WriteFile(path, []byte("[guardian]\nidle_timeout = \"2m\"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadFile(path)
+	if err != nil || cfg.Guardian.IdleTimeout != 2*time.Minute {
+		t.Fatalf("LoadFile = %+v, %v", cfg, err)
+	}
+	if _, err := LoadFile(path + ".missing"); err == nil {
+		t.Error("a missing candidate should be an error")
+	}
+}
+
+func TestRootModes(t *testing.T) {
+	home := setTempHome(t)
+	writeGuardianToml(t, `[directories]
+watch = ["~/work", "~/scratch"]
+
+[directories.roots."~/scratch"]
+mode = "observe"
+
+[directories.roots."~/work/sandbox"]
+mode = "observe"
+
+[directories.roots."~/work/sandbox/ship"]
+mode = "enforce"
+`)
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct{ path, mode, root string }{
+		{"work/api", ModeEnforce, ""},
+		{"scratch", ModeObserve, "scratch"},
+		{"scratch/demo", ModeObserve, "scratch"},
+		{"scratchpad", ModeEnforce, ""},
+		{"work/sandbox/x", ModeObserve, "work/sandbox"},
+		{"work/sandbox/ship/y", ModeEnforce, "work/sandbox/ship"},
+	} {
+		mode, root := cfg.RootMode(filepath.Join(home, tc.path))
+		want := ""
+		if tc.root != "" {
+			want = filepath.Join(home, tc.root)
+		}
+		if mode != tc.mode || root != want {
+			t.Errorf("RootMode(%s) = %s, %q; want %s, %q", tc.path, mode, root, tc.mode, want)
+		}
+	}
+
+	var got []string
+	for _, r := range cfg.WatchRoots() {
+		rel, _ := filepath.Rel(home, r.Path)
+		got = append(got, rel+"="+r.Mode)
+	}
+	want := "scratch=observe work=enforce work/sandbox=observe work/sandbox/ship=enforce"
+	if strings.Join(got, " ") != filepath.FromSlash(want) {
+		t.Errorf("WatchRoots = %v; want %s", got, want)
+	}
+
+	writeGuardianToml(t, "[directories.roots.\"~/x\"]\nmode = \"audit\"\n")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "directories.roots") {
+		t.Errorf("unknown mode error = %v", err)
+	}
+}
+
+func TestPlaintextAllowed(t *testing.T) {
+	setTempHome(t)
+	writeGuardianToml(t, "[guardian]\nplaintext_allowed = [\"LOG_LEVEL\", \"FEATURE_*\"]\n")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(cfg.Guardian.PlaintextAllowed, ","); got != "LOG_LEVEL,FEATURE_*" {
+		t.Errorf("PlaintextAllowed = %s", got)
+	}
+
+	writeGuardianToml(t, "[guardian]\nplaintext_allowed = [\"FEATURE_[\"]\n")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "guardian.plaintext_allowed") {
+		t.Errorf("bad glob error = %v", err)
+	}
+}
+
+func TestSeverityThresholds(t *testing.T) {
+	setTempHome(t)
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Severity.NotifyLevel() != severity.Low || cfg.Severity.FailLevel() != severity.Low {
+		t.Errorf("default levels = %v, %v; want low", cfg.Severity.NotifyLevel(), cfg.Severity.FailLevel())
+	}
+
+	writeGuardianToml(t, "[severity]\nnotify = \"high\"\nfail = \"Medium\"\n")
+	if cfg, err = Load(); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Severity.NotifyLevel() != severity.High || cfg.Severity.FailLevel() != severity.Medium {
+		t.Errorf("levels = %v, %v; want high, medium", cfg.Severity.NotifyLevel(), cfg.Severity.FailLevel())
+	}
+
+	writeGuardianToml(t, "[severity]\nfail = \"severe\"\n")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "severity.fail") {
+		t.Errorf("bad level error = %v", err)
+	}
+}
+
+func TestSeverityGitleaks(t *testing.T) {
+	setTempHome(t)
+	rules := filepath.Join(t.TempDir(), "gitleaks.toml")
+	if err := os.WriteFile(rules, []byte("[[rules]]\nid = \"acme\"\nregex = '''acme_[a-z0-9]{20}'''\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	writeGuardianToml(t, fmt.Sprintf("[severity]\ngitleaks = [%q]\n", rules))
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := cfg.Severity.GitleaksRules(); err != nil || len(got) != 1 || got[0].ID != "acme" {
+		t.Errorf("GitleaksRules = %+v, %v", got, err)
+	}
+
+	writeGuardianToml(t, fmt.Sprintf("[severity]\ngitleaks = [%q]\n", filepath.Join(t.TempDir(), "missing.toml")))
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "severity.gitleaks") {
+		t.Errorf("missing rule file error = %v", err)
+	}
+}
+
+func TestQuietAndDeepWorkHours(t *testing.T) {
+	setTempHome(t)
+	writeGuardianToml(t, "[notifications]\nquiet_hours = [\"22:00-08:00\"]\n[guardian]\ndeep_work_hours = [\"09:00-12:00\", \"14:00-17:30\"]\n")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := cfg.Notifications.QuietWindows(); len(got) != 1 || got[0].String() != "22:00-08:00" {
+		t.Errorf("QuietWindows = %v", got)
+	}
+	if got := cfg.Guardian.DeepWorkWindows(); len(got) != 2 || got[1].String() != "14:00-17:30" {
+		t.Errorf("DeepWorkWindows = %v", got)
+	}
+
+	for _, tt := range []struct{ toml, want string }{
+		{"[notifications]\nquiet_hours = [\"22:00\"]\n", "notifications.quiet_hours"},
+		{"[guardian]\ndeep_work_hours = [\"09:00-25:00\"]\n", "guardian.deep_work_hours"},
+	} {
+		writeGuardianToml(t, tt.toml)
+		if _, err := Load(); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Load(%q) error = %v, want %s", tt.toml, err, tt.want)
+		}
+	}
+}
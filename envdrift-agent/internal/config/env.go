@@ -0,0 +1,170 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// envPrefix starts every variable that overrides a guardian.toml setting:
+// section.name becomes ENVDRIFT_SECTION_NAME (guardian.idle_timeout is
+// ENVDRIFT_GUARDIAN_IDLE_TIMEOUT) and a top-level key ENVDRIFT_NAME.
+const envPrefix = "ENVDRIFT_"
+
+// envKey is a guardian.toml setting that an environment variable can
+// override. section is empty for a top-level key.
+type envKey struct {
+	section, name string
+	list          bool
+}
+
+func (k envKey) variable() string {
+	key := k.name
+	if k.section != "" {
+		key = k.section + "_" + k.name
+	}
+	return envPrefix + strings.ToUpper(key)
+}
+
+// envKeys lists every setting Save writes with a scalar or list value, i.e.
+// every one Set accepts. Profiles and policies, tables of tables, have no
+// variables.
+func envKeys() []envKey {
+	data, err := toml.Marshal(toSaved(DefaultConfig()))
+	if err != nil {
+		return nil
+	}
+	var doc map[string]any
+	if err := toml.Unmarshal(data, &doc); err != nil {
+		return nil
+	}
+	var keys []envKey
+	for section, v := range doc {
+		table, ok := v.(map[string]any)
+		if !ok {
+			if section != "schema_version" {
+				keys = append(keys, envKey{name: section})
+			}
+			continue
+		}
+		for name, v := range table {
+			if _, nested := v.(map[string]any); nested {
+				continue
+			}
+			_, list := v.([]any)
+			keys = append(keys, envKey{section: section, name: name, list: list})
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].variable() < keys[j].variable() })
+	return keys
+}
+
+// EnvOverrides returns the names of the ENVDRIFT_ variables that are set and
+// override a guardian.toml setting, sorted.
+func EnvOverrides() []string {
+	var names []string
+	for _, k := range envKeys() {
+		if _, ok := os.LookupEnv(k.variable()); ok {
+			names = append(names, k.variable())
+		}
+	}
+	return names
+}
+
+// applyEnv overlays the ENVDRIFT_ variables that are set on the guardian.toml
+// document data, which is nil when there is no file. It returns data
+// unchanged when none is set. Each value is checked on its own first, so a
+// bad one is reported by its variable rather than by a line of the file.
+func applyEnv(data []byte) ([]byte, error) {
+	doc := map[string]any{}
+	if err := toml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	overridden := false
+	for _, k := range envKeys() {
+		value, ok := os.LookupEnv(k.variable())
+		if !ok {
+			continue
+		}
+		v := envValue(value, k.list)
+		if err := checkEnvValue(k, v); err != nil {
+			return nil, err
+		}
+		if k.section == "" {
+			doc[k.name] = v
+		} else {
+			table, ok := doc[k.section].(map[string]any)
+			if !ok {
+				table = map[string]any{}
+				doc[k.section] = table
+			}
+			table[k.name] = v
+		}
+		overridden = true
+	}
+	if !overridden {
+		return data, nil
+	}
+	return toml.Marshal(doc)
+}
+
+// envValue decodes a variable's value as TOML, like Set, falling back to the
+// plain string. A list setting also takes comma-separated items
+// (ENVDRIFT_DIRECTORIES_WATCH=~/code,~/work); empty clears it.
+func envValue(value string, list bool) any {
+	v := tomlValue(value)
+	if _, isList := v.([]any); !list || isList {
+		return v
+	}
+	items := []any{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, tomlString(item))
+		}
+	}
+	return items
+}
+
+// tomlValue decodes value as a TOML value (true, 5, "5m", [".env*"]), or
+// returns it as a string when it is not one.
+func tomlValue(value string) any {
+	var parsed map[string]any
+	if err := toml.Unmarshal([]byte("v = "+value), &parsed); err == nil {
+		return parsed["v"]
+	}
+	return value
+}
+
+// tomlString unquotes a TOML string item, leaving a bare word as it is.
+func tomlString(item string) string {
+	if s, ok := tomlValue(item).(string); ok {
+		return s
+	}
+	return item
+}
+
+// checkEnvValue validates one override as a guardian.toml holding only it.
+func checkEnvValue(k envKey, v any) error {
+	doc := map[string]any{k.name: v}
+	if k.section != "" {
+		doc = map[string]any{k.section: doc}
+	}
+	data, err := toml.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("%s: %w", k.variable(), err)
+	}
+	var raw rawConfig
+	if err := toml.Unmarshal(data, &raw); err != nil {
+		var de *toml.DecodeError
+		if errors.As(err, &de) {
+			return fmt.Errorf("%s: %s", k.variable(), strings.TrimPrefix(de.Error(), "toml: "))
+		}
+		return fmt.Errorf("%s: %w", k.variable(), err)
+	}
+	_, err = parse(data, k.variable())
+	return err
+}
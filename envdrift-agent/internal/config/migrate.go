@@ -0,0 +1,112 @@
+package config
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// SchemaVersion is the guardian.toml layout this build reads and Save
+// writes, as its top-level schema_version. A file without one is version 1.
+const SchemaVersion = 2
+
+// migration upgrades a decoded guardian.toml to version to. apply reports
+// whether it changed anything.
+type migration struct {
+	to    int
+	desc  string
+	apply func(doc map[string]any) (bool, error)
+}
+
+// migrations run in order on a file older than their version. A renamed or
+// restructured key gets one here instead of the old key being silently
+// ignored.
+var migrations = []migration{
+	{2, "idle_timeout in raw nanoseconds to a duration string", migrateNanosecondDurations},
+}
+
+// upgrade migrates a guardian.toml document to SchemaVersion. It returns the
+// document unchanged when no migration changed anything, so a file that only
+// lacks schema_version keeps its comments and layout. from is the version
+// the document declared.
+func upgrade(data []byte, path string) (out []byte, from int, err error) {
+	doc := map[string]any{}
+	if err := toml.Unmarshal(data, &doc); err != nil {
+		return nil, 0, err
+	}
+	from = 1
+	if v, ok := doc["schema_version"]; ok {
+		n, ok := v.(int64)
+		if !ok || n < 1 {
+			return nil, 0, fmt.Errorf("%s: schema_version: %v is not a version number", path, v)
+		}
+		from = int(n)
+	}
+	if from > SchemaVersion {
+		return nil, 0, fmt.Errorf("%s: schema_version %d was written by a newer envdrift-agent (this one reads up to %d)",
+			path, from, SchemaVersion)
+	}
+
+	changed := false
+	for _, m := range migrations {
+		if m.to <= from {
+			continue
+		}
+		c, err := m.apply(doc)
+		if err != nil {
+			return nil, 0, fmt.Errorf("%s: migrating to schema_version %d (%s): %w", path, m.to, m.desc, err)
+		}
+		changed = changed || c
+	}
+	if !changed {
+		return data, from, nil
+	}
+	doc["schema_version"] = SchemaVersion
+	out, err = toml.Marshal(doc)
+	return out, from, err
+}
+
+// migrateFile upgrades the guardian.toml at path, whose content is data, and
+// when that changed it rewrites the file, keeping the original as
+// <path>.v<from>.bak. It returns the content to parse.
+func migrateFile(data []byte, path string) ([]byte, error) {
+	out, from, err := upgrade(data, path)
+	if err != nil || from == SchemaVersion || string(out) == string(data) {
+		return out, err
+	}
+	backup := fmt.Sprintf("%s.v%d.bak", path, from)
+	if err := os.WriteFile(backup, data, 0o644); err != nil {
+		return nil, fmt.Errorf("back up %s before migrating it: %w", path, err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, out, 0o644); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return nil, err
+	}
+	log.Printf("Migrated %s from schema_version %d to %d; the original is kept at %s", path, from, SchemaVersion, backup)
+	return out, nil
+}
+
+// migrateNanosecondDurations rewrites guardian.idle_timeout and
+// guardian.debounce written as integers (time.Duration's nanoseconds, as Save
+// did before #481) in the documented duration-string form.
+func migrateNanosecondDurations(doc map[string]any) (bool, error) {
+	guardian, ok := doc["guardian"].(map[string]any)
+	if !ok {
+		return false, nil
+	}
+	changed := false
+	for _, key := range []string{"idle_timeout", "debounce"} {
+		if n, ok := guardian[key].(int64); ok {
+			guardian[key] = FormatIdleTimeout(time.Duration(n))
+			changed = true
+		}
+	}
+	return changed, nil
+}
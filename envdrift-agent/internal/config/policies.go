@@ -0,0 +1,220 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+
+	"github.com/jainal09/envdrift-agent/internal/minisign"
+	"github.com/jainal09/envdrift-agent/internal/netstate"
+)
+
+// PolicyConfig is one [[policies]] entry: settings that apply while the
+// detected network matches When, e.g. a shorter idle timeout off the
+// corporate VPN.
+type PolicyConfig struct {
+	// Name labels the policy in logs and status output.
+	Name string          `toml:"name"`
+	When PolicyCondition `toml:"when"`
+	// IdleTimeout caps every project's idle timeout — how long a file may
+	// stay in plaintext — while the policy applies; 0 leaves them as set.
+	IdleTimeout time.Duration `toml:"idle_timeout"`
+	// Notify replaces guardian.notify and the projects' notify settings.
+	Notify *bool `toml:"notify,omitempty"`
+}
+
+// PolicyCondition is a [[policies]] when table. Every field that is set must
+// hold; an empty table always matches.
+type PolicyCondition struct {
+	// SSID matches when connected to one of these Wi-Fi networks, NotSSID
+	// when connected to none of them (wired counts as none).
+	SSID    []string `toml:"ssid,omitempty"`
+	NotSSID []string `toml:"not_ssid,omitempty"`
+	// VPN matches on whether a VPN tunnel is up.
+	VPN *bool `toml:"vpn,omitempty"`
+	// DomainJoined matches on Active Directory / realmd domain membership.
+	DomainJoined *bool `toml:"domain_joined,omitempty"`
+}
+
+// ManagedPoliciesConfig is the [managed_policies] section: a policy file
+// distributed by MDM or configuration management, and the keys it must be
+// signed with.
+type ManagedPoliciesConfig struct {
+	// File holds [[policies]] entries in the guardian.toml format. They come
+	// before the local ones: the strictest idle timeout still wins, and
+	// their notify setting takes precedence.
+	File string `toml:"file,omitempty"`
+	// TrustedKeys are minisign public keys. With any set, File is applied
+	// only with a valid signature by one of them in File + ".minisig".
+	TrustedKeys []string `toml:"trusted_keys,omitempty"`
+}
+
+type rawPolicyConfig struct {
+	Name        string          `toml:"name"`
+	When        PolicyCondition `toml:"when"`
+	IdleTimeout *Duration       `toml:"idle_timeout"`
+	Notify      *bool           `toml:"notify"`
+}
+
+type savedPolicyConfig struct {
+	Name        string          `toml:"name,omitempty"`
+	When        PolicyCondition `toml:"when"`
+	IdleTimeout string          `toml:"idle_timeout,omitempty"`
+	Notify      *bool           `toml:"notify,omitempty"`
+}
+
+// Matches reports whether the condition holds on network s.
+func (c PolicyCondition) Matches(s netstate.State) bool {
+	if len(c.SSID) > 0 && !slices.Contains(c.SSID, s.SSID) {
+		return false
+	}
+	if len(c.NotSSID) > 0 && slices.Contains(c.NotSSID, s.SSID) {
+		return false
+	}
+	if c.VPN != nil && *c.VPN != s.VPN {
+		return false
+	}
+	if c.DomainJoined != nil && *c.DomainJoined != s.DomainJoined {
+		return false
+	}
+	return true
+}
+
+// Policy is the combined effect of the policies matching a network.
+type Policy struct {
+	// Names lists the matching policies in file order.
+	Names []string
+	// IdleTimeout is the shortest cap among them (the strictest wins); 0
+	// when none sets one.
+	IdleTimeout time.Duration
+	// Notify is the first matching policy's notify setting, or nil.
+	Notify *bool
+}
+
+// MatchPolicies combines the policies that match network s.
+func MatchPolicies(policies []PolicyConfig, s netstate.State) Policy {
+	var p Policy
+	for i, pc := range policies {
+		if !pc.When.Matches(s) {
+			continue
+		}
+		name := pc.Name
+		if name == "" {
+			name = fmt.Sprintf("policies[%d]", i)
+		}
+		p.Names = append(p.Names, name)
+		if pc.IdleTimeout > 0 && (p.IdleTimeout == 0 || pc.IdleTimeout < p.IdleTimeout) {
+			p.IdleTimeout = pc.IdleTimeout
+		}
+		if p.Notify == nil && pc.Notify != nil {
+			p.Notify = pc.Notify
+		}
+	}
+	return p
+}
+
+// mergePolicies decodes the [[policies]] array; a policy must change
+// something.
+func mergePolicies(cfg *Config, raw []rawPolicyConfig, configPath string) error {
+	for i, r := range raw {
+		pc := PolicyConfig{Name: r.Name, When: r.When, Notify: r.Notify}
+		if r.IdleTimeout != nil {
+			pc.IdleTimeout = time.Duration(*r.IdleTimeout)
+		}
+		if pc.IdleTimeout <= 0 && pc.Notify == nil {
+			return fmt.Errorf("%s: policies[%d]: set idle_timeout or notify", configPath, i)
+		}
+		cfg.Policies = append(cfg.Policies, pc)
+	}
+	return nil
+}
+
+// savePolicies converts the policies to the shape Save writes.
+func savePolicies(policies []PolicyConfig) []savedPolicyConfig {
+	var out []savedPolicyConfig
+	for _, pc := range policies {
+		s := savedPolicyConfig{Name: pc.Name, When: pc.When, Notify: pc.Notify}
+		if pc.IdleTimeout > 0 {
+			s.IdleTimeout = FormatIdleTimeout(pc.IdleTimeout)
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+// AllPolicies returns the managed policies followed by the local ones, the
+// order MatchPolicies combines them in.
+func (c *Config) AllPolicies() []PolicyConfig {
+	return append(slices.Clone(c.Managed), c.Policies...)
+}
+
+// PolicyError is a [managed_policies] file that was rejected: missing,
+// unsigned, signed by an untrusted key or malformed.
+type PolicyError struct {
+	Err error
+}
+
+func (e *PolicyError) Error() string { return e.Err.Error() }
+
+func (e *PolicyError) Unwrap() error { return e.Err }
+
+// loadManagedPolicies reads the [managed_policies] file into cfg.Managed,
+// verifying its signature first when trusted keys are pinned. A file that is
+// missing, unsigned or signed by another key is an error, so a tampered
+// policy is never silently skipped or applied.
+func loadManagedPolicies(cfg *Config, mp ManagedPoliciesConfig, configPath string) error {
+	cfg.ManagedPolicies = mp
+	if len(mp.TrustedKeys) > 0 && mp.File == "" {
+		return fmt.Errorf("%s: managed_policies: trusted_keys without a file", configPath)
+	}
+	keys := make([]minisign.PublicKey, 0, len(mp.TrustedKeys))
+	for i, k := range mp.TrustedKeys {
+		key, err := minisign.ParsePublicKey(k)
+		if err != nil {
+			return fmt.Errorf("%s: managed_policies.trusted_keys[%d]: %w", configPath, i, err)
+		}
+		keys = append(keys, key)
+	}
+	if mp.File == "" {
+		return nil
+	}
+
+	path := expandHome(mp.File)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("%s: managed_policies.file: %w", configPath, err)
+	}
+	if len(keys) > 0 {
+		sig, err := os.ReadFile(path + ".minisig")
+		if err != nil {
+			return fmt.Errorf("%s: managed_policies: signature: %w", configPath, err)
+		}
+		if err := minisign.Verify(keys, data, sig); err != nil {
+			return fmt.Errorf("%s: managed_policies: %s: %w", configPath, path, err)
+		}
+	}
+
+	var raw struct {
+		Policies []rawPolicyConfig `toml:"policies"`
+	}
+	if err := toml.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	managed := &Config{}
+	if err := mergePolicies(managed, raw.Policies, path); err != nil {
+		return err
+	}
+	cfg.Managed = managed.Policies
+	return nil
+}
+
+// saveManagedPolicies returns mp for Save, or nil to omit an unset section.
+func saveManagedPolicies(mp ManagedPoliciesConfig) *ManagedPoliciesConfig {
+	if mp.File == "" && len(mp.TrustedKeys) == 0 {
+		return nil
+	}
+	return &mp
+}
@@ -0,0 +1,124 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ProfileConfig is one [profiles.<name>] table, e.g. work and personal. While
+// the profile is active its settings replace the top-level ones; unset fields
+// keep them.
+type ProfileConfig struct {
+	// Hosts selects the profile on these machines (hostname, case-insensitive)
+	// unless guardian.profile pins one.
+	Hosts []string `toml:"hosts,omitempty"`
+	// Watch limits the guardian to registered projects under these roots;
+	// empty watches every registered project.
+	Watch []string `toml:"watch,omitempty"`
+	// Notify replaces guardian.notify.
+	Notify *bool `toml:"notify,omitempty"`
+	// VaultSync and VaultTarget replace vault_sync.enabled and
+	// vault_sync.target.
+	VaultSync   *bool   `toml:"vault_sync,omitempty"`
+	VaultTarget *string `toml:"vault_target,omitempty"`
+}
+
+// hostname is os.Hostname; tests replace it.
+var hostname = os.Hostname
+
+// mergeProfiles validates the decoded profiles and the guardian.profile pin.
+func mergeProfiles(cfg *Config, profiles map[string]ProfileConfig, configPath string) error {
+	for name, p := range profiles {
+		if p.VaultTarget != nil && *p.VaultTarget != "dotenv_keys" && *p.VaultTarget != "keychain" {
+			return fmt.Errorf("%s: profiles.%s.vault_target: %q is not dotenv_keys or keychain", configPath, name, *p.VaultTarget)
+		}
+	}
+	cfg.Profiles = profiles
+	if pin := cfg.Guardian.Profile; pin != "" {
+		if _, ok := profiles[pin]; !ok {
+			return fmt.Errorf("%s: guardian.profile: no [profiles.%s] table", configPath, pin)
+		}
+	}
+	return nil
+}
+
+// ProfileName returns the active profile and how it was chosen: "pinned"
+// (guardian.profile) or "host" (its hosts list names this machine). It
+// returns "" when no profile applies; several matching hosts lists resolve to
+// the first name in sorted order.
+func (c *Config) ProfileName() (name, reason string) {
+	if c.Guardian.Profile != "" {
+		return c.Guardian.Profile, "pinned"
+	}
+	host, err := hostname()
+	if err != nil || host == "" {
+		return "", ""
+	}
+	short, _, _ := strings.Cut(host, ".")
+	names := make([]string, 0, len(c.Profiles))
+	for n := range c.Profiles {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		for _, h := range c.Profiles[n].Hosts {
+			if strings.EqualFold(h, host) || strings.EqualFold(h, short) {
+				return n, "host"
+			}
+		}
+	}
+	return "", ""
+}
+
+// Effective returns a copy of c with its active profile applied and
+// ActiveProfile set, or c itself when no profile applies. The agent runs on
+// the effective configuration; Save always gets the one Load returned, so
+// the top-level settings are never overwritten by a profile's.
+func (c *Config) Effective() *Config {
+	name, _ := c.ProfileName()
+	p, ok := c.Profiles[name]
+	if !ok {
+		return c
+	}
+	eff := *c
+	eff.ActiveProfile = name
+	if len(p.Watch) > 0 {
+		eff.Directories.Watch = make([]string, len(p.Watch))
+		for i, root := range p.Watch {
+			eff.Directories.Watch[i] = expandHome(root)
+		}
+	}
+	if p.Notify != nil {
+		eff.Guardian.Notify = *p.Notify
+	}
+	if p.VaultSync != nil {
+		eff.VaultSync.Enabled = *p.VaultSync
+	}
+	if p.VaultTarget != nil {
+		eff.VaultSync.Target = *p.VaultTarget
+	}
+	return &eff
+}
+
+// ProfileRoots returns the watch roots the active profile restricts the
+// guardian to, or nil when every registered project is watched. Call it on
+// the configuration Effective returned.
+func (c *Config) ProfileRoots() []string {
+	if c.ActiveProfile == "" || len(c.Profiles[c.ActiveProfile].Watch) == 0 {
+		return nil
+	}
+	return c.Directories.Watch
+}
+
+// expandHome expands a leading "~/" to the user's home directory.
+func expandHome(path string) string {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, rest)
+		}
+	}
+	return path
+}
@@ -0,0 +1,72 @@
+package config
+
+import (
+	"path/filepath"
+	"sort"
+
+	"github.com/jainal09/envdrift-agent/internal/paths"
+)
+
+// Modes a directory can be given in [directories.roots].
+const (
+	// ModeEnforce encrypts plaintext env files once they go idle, the default.
+	ModeEnforce = "enforce"
+	// ModeObserve only reports them: status, the journal and compliance
+	// scans list them, and they are left in plaintext.
+	ModeObserve = "observe"
+)
+
+// RootConfig is one [directories.roots."<path>"] table.
+type RootConfig struct {
+	// Mode is enforce or observe; empty is enforce.
+	Mode string `toml:"mode"`
+}
+
+// WatchRoot is a directory and the mode its projects are in.
+type WatchRoot struct {
+	Path string
+	Mode string
+}
+
+// RootMode returns the mode for path and the [directories.roots] entry that
+// set it: the deepest one path is in, so ~/work/sandbox can observe inside
+// an enforced ~/work. Outside every entry it returns ModeEnforce and "".
+func (c *Config) RootMode(path string) (mode, root string) {
+	path = filepath.Clean(path)
+	mode = ModeEnforce
+	for r, rc := range c.Directories.Roots {
+		dir := filepath.Clean(expandHome(r))
+		if !paths.Within(dir, path) || (root != "" && len(dir) <= len(root)) {
+			continue
+		}
+		root, mode = dir, ModeEnforce
+		if rc.Mode == ModeObserve {
+			mode = ModeObserve
+		}
+	}
+	return mode, root
+}
+
+// WatchRoots returns the watch directories and the [directories.roots]
+// entries, sorted, each with the mode RootMode gives it, for `status`.
+func (c *Config) WatchRoots() []WatchRoot {
+	seen := make(map[string]bool)
+	var roots []WatchRoot
+	add := func(dir string) {
+		dir = filepath.Clean(expandHome(dir))
+		if seen[dir] {
+			return
+		}
+		seen[dir] = true
+		mode, _ := c.RootMode(dir)
+		roots = append(roots, WatchRoot{Path: dir, Mode: mode})
+	}
+	for _, dir := range c.Directories.Watch {
+		add(dir)
+	}
+	for dir := range c.Directories.Roots {
+		add(dir)
+	}
+	sort.Slice(roots, func(i, j int) bool { return roots[i].Path < roots[j].Path })
+	return roots
+}
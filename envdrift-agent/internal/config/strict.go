@@ -0,0 +1,33 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// Strict turns on strict loading for every file, as `strict = true` does for
+// the one declaring it; --strict sets it.
+var Strict bool
+
+// checkUnknownKeys reports every key in data that guardian.toml does not
+// define, with its line, as one error.
+func checkUnknownKeys(data []byte, configPath string) error {
+	dec := toml.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	var raw rawConfig
+	err := dec.Decode(&raw)
+	var missing *toml.StrictMissingError
+	if !errors.As(err, &missing) {
+		return err
+	}
+	lines := make([]string, 0, len(missing.Errors))
+	for _, e := range missing.Errors {
+		row, _ := e.Position()
+		lines = append(lines, fmt.Sprintf("line %d: %s", row, strings.Join(e.Key(), ".")))
+	}
+	return fmt.Errorf("%s: unknown keys (strict mode):\n  %s", configPath, strings.Join(lines, "\n  "))
+}
@@ -0,0 +1,115 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+
+	"github.com/jainal09/envdrift-agent/internal/project"
+)
+
+// Duration is a duration in guardian.toml. A string is a number and unit:
+// "30s", "5m", "1h30m", "2d", "1d12h". A bare integer is nanoseconds, the
+// form Save wrote before #481, kept so old files load.
+type Duration time.Duration
+
+// UnmarshalText parses the string form; the decoder stores integers itself.
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// ParseDuration parses a guardian.toml duration string: anything
+// time.ParseDuration accepts, plus a leading whole number of days ("2d",
+// "1d12h").
+func ParseDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if d, err := project.ParseIdleTimeout(s); err == nil {
+		return d, nil
+	}
+	if days, rest, ok := strings.Cut(s, "d"); ok && days != "" && rest != "" {
+		n, err := strconv.Atoi(days)
+		if err == nil && n >= 0 {
+			if d, err := time.ParseDuration(rest); err == nil && d >= 0 {
+				return time.Duration(n)*24*time.Hour + d, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("%q is not a duration; use a number and unit such as \"30s\", \"5m\", \"1h30m\" or \"2d\"", s)
+}
+
+// ByteSize is a size in guardian.toml: "10MB", "5MiB", "512KB", "1.5GB". KB,
+// MB and GB are powers of 1000, KiB, MiB and GiB powers of 1024; units are
+// case-insensitive. A bare integer is bytes.
+type ByteSize int64
+
+// UnmarshalText parses the string form; the decoder stores integers itself.
+func (s *ByteSize) UnmarshalText(text []byte) error {
+	n, err := ParseByteSize(string(text))
+	if err != nil {
+		return err
+	}
+	*s = ByteSize(n)
+	return nil
+}
+
+// byteUnits maps a lower-case unit to its multiplier.
+var byteUnits = map[string]float64{
+	"": 1, "b": 1,
+	"kb": 1e3, "mb": 1e6, "gb": 1e9,
+	"kib": 1 << 10, "mib": 1 << 20, "gib": 1 << 30,
+}
+
+// ParseByteSize parses a ByteSize string into bytes.
+func ParseByteSize(s string) (int64, error) {
+	t := strings.TrimSpace(s)
+	i := strings.IndexFunc(t, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+	if i < 0 {
+		i = len(t)
+	}
+	num, unit := t[:i], strings.ToLower(strings.TrimSpace(t[i:]))
+	mult, okUnit := byteUnits[unit]
+	n, err := strconv.ParseFloat(num, 64)
+	if !okUnit || err != nil || n*mult > math.MaxInt64 {
+		return 0, fmt.Errorf("%q is not a size; use a number and unit such as \"512KB\", \"10MB\" or \"5MiB\"", s)
+	}
+	return int64(n * mult), nil
+}
+
+// FormatByteSize renders n in the largest binary unit that divides it, as
+// Save writes sizes ("5MiB").
+func FormatByteSize(n int64) string {
+	for _, u := range []struct {
+		name string
+		size int64
+	}{{"GiB", 1 << 30}, {"MiB", 1 << 20}, {"KiB", 1 << 10}} {
+		if n != 0 && n%u.size == 0 {
+			return fmt.Sprintf("%d%s", n/u.size, u.name)
+		}
+	}
+	return fmt.Sprintf("%dB", n)
+}
+
+// describeDecodeError names the file, line and key of a TOML decoding
+// error, e.g. a Duration that does not parse.
+func describeDecodeError(err error, configPath string) error {
+	var de *toml.DecodeError
+	if !errors.As(err, &de) {
+		return err
+	}
+	row, _ := de.Position()
+	msg := strings.TrimPrefix(de.Error(), "toml: ")
+	if key := de.Key(); len(key) > 0 {
+		return fmt.Errorf("%s:%d: %s: %s", configPath, row, strings.Join(key, "."), msg)
+	}
+	return fmt.Errorf("%s:%d: %s", configPath, row, msg)
+}
@@ -0,0 +1,134 @@
+// Package crash writes panic reports to crashes in the state directory.
+//
+// Reports are plain text meant to be attached to a bug report, so everything
+// in them goes through Redact: the home directory becomes ~, KEY=value pairs
+// lose their value and anything shaped like a private key is masked.
+package crash
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jainal09/envdrift-agent/internal/config"
+	"github.com/jainal09/envdrift-agent/internal/paths"
+)
+
+const (
+	filePrefix  = "crash-"
+	stampLayout = "20060102T150405.000Z"
+)
+
+// Report is one recovered panic.
+type Report struct {
+	Time    time.Time
+	Version string
+	// Panic is the recovered value; Stack the panicking goroutine's trace.
+	Panic any
+	Stack []byte
+	// Config is the agent's configuration at the time, summarized by
+	// ConfigSummary.
+	Config *config.Config
+}
+
+// Dir returns crashes in the state directory.
+func Dir() string {
+	return filepath.Join(paths.StateDir(), "crashes")
+}
+
+// Write saves r, redacted, as crash-<time>.txt in dir and returns its path.
+func Write(dir string, r Report) (string, error) {
+	if r.Time.IsZero() {
+		r.Time = time.Now()
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "envdrift-agent crash report\n\n")
+	fmt.Fprintf(&b, "Time:     %s\n", r.Time.UTC().Format(time.RFC3339))
+	fmt.Fprintf(&b, "Version:  %s\n", r.Version)
+	fmt.Fprintf(&b, "Platform: %s/%s (%s)\n", runtime.GOOS, runtime.GOARCH, runtime.Version())
+	fmt.Fprintf(&b, "Panic:    %v\n", r.Panic)
+	if r.Config != nil {
+		fmt.Fprintf(&b, "\nConfig:\n%s", ConfigSummary(r.Config))
+	}
+	fmt.Fprintf(&b, "\nStack:\n%s\n", r.Stack)
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, filePrefix+r.Time.UTC().Format(stampLayout)+".txt")
+	if err := os.WriteFile(path, []byte(Redact(b.String())), 0o600); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// List returns the paths of the reports in dir, newest first.
+func List(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var paths []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasPrefix(e.Name(), filePrefix) {
+			paths = append(paths, filepath.Join(dir, e.Name()))
+		}
+	}
+	// The stamp sorts chronologically.
+	sort.Sort(sort.Reverse(sort.StringSlice(paths)))
+	return paths, nil
+}
+
+// Latest returns the path of the newest report in dir, or "" when there is
+// none.
+func Latest(dir string) (string, error) {
+	paths, err := List(dir)
+	if err != nil || len(paths) == 0 {
+		return "", err
+	}
+	return paths[0], nil
+}
+
+// ConfigSummary lists the settings that shape the agent's behavior. Paths
+// are left to Redact; nothing in guardian.toml is secret, but the telemetry
+// endpoint is reduced to whether one is set.
+func ConfigSummary(cfg *config.Config) string {
+	var b strings.Builder
+	g := cfg.Guardian
+	fmt.Fprintf(&b, "  guardian: enabled=%v idle_timeout=%v patterns=%v exclude=%v notify=%v symlinks=%s ignore_generated=%v incremental=%v normalize_output=%v\n",
+		g.Enabled, g.IdleTimeout, g.Patterns, g.Exclude, g.Notify, g.Symlinks, g.IgnoreGenerated, g.Incremental, g.NormalizeOutput)
+	fmt.Fprintf(&b, "  keys: resolution=%v\n", cfg.Keys.Resolution)
+	fmt.Fprintf(&b, "  vault_sync: enabled=%v interval=%v target=%s\n",
+		cfg.VaultSync.Enabled, cfg.VaultSync.Interval, cfg.VaultSync.Target)
+	fmt.Fprintf(&b, "  edit: auto_open=%v\n", cfg.Edit.AutoOpen)
+	fmt.Fprintf(&b, "  backups: enabled=%v keep=%d max_age=%v trash=%v\n",
+		cfg.Backups.Enabled, cfg.Backups.Keep, cfg.Backups.MaxAge, cfg.Backups.Trash)
+	fmt.Fprintf(&b, "  telemetry: enabled=%v endpoint_set=%v\n",
+		cfg.Telemetry.Enabled, cfg.Telemetry.Endpoint != "")
+	fmt.Fprintf(&b, "  update: channel=%s\n", cfg.Update.Channel)
+	return b.String()
+}
+
+var (
+	// assignment matches NAME=value and NAME: value for env-style names.
+	assignment = regexp.MustCompile(`\b([A-Z][A-Z0-9_]*)(\s*[=:]\s*)("[^"\n]*"|'[^'\n]*'|[^\s,;)}\]]+)`)
+	// keyLike matches dotenvx private keys and other long hex secrets.
+	keyLike = regexp.MustCompile(`\b[0-9a-fA-F]{40,}\b`)
+)
+
+// Redact masks secrets and the user's home directory in s.
+func Redact(s string) string {
+	if home, err := os.UserHomeDir(); err == nil && len(home) > 1 {
+		s = strings.ReplaceAll(s, home, "~")
+	}
+	s = assignment.ReplaceAllString(s, "$1$2[redacted]")
+	return keyLike.ReplaceAllString(s, "[redacted]")
+}
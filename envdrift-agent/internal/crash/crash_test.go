@@ -0,0 +1,80 @@
+package crash
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jainal09/envdrift-agent/internal/config"
+)
+
+func TestRedact(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	tests := []struct {
+		in, want string
+	}{
+		{"API_KEY=hunter2", "API_KEY=[redacted]"},
+		{`parse line: DATABASE_URL="postgres://u:p@h/db" here`, "parse line: DATABASE_URL=[redacted] here"},
+		{"DOTENV_PRIVATE_KEY_PRODUCTION: abc", "DOTENV_PRIVATE_KEY_PRODUCTION: [redacted]"},
+		{"key " + strings.Repeat("ab12", 16), "key [redacted]"},
+		{filepath.Join(home, "proj", ".env"), filepath.Join("~", "proj", ".env")},
+		{"index out of range [3] with length 2", "index out of range [3] with length 2"},
+	}
+	for _, tt := range tests {
+		if got := Redact(tt.in); got != tt.want {
+			t.Errorf("Redact(%q) = %q; want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestWriteAndLatest(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	dir := filepath.Join(t.TempDir(), "crashes")
+
+	if latest, err := Latest(dir); err != nil || latest != "" {
+		t.Fatalf("Latest of a missing dir = %q, %v", latest, err)
+	}
+
+	first := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	if _, err := Write(dir, Report{Time: first, Version: "1.0.0", Panic: "old"}); err != nil {
+		t.Fatal(err)
+	}
+	path, err := Write(dir, Report{
+		Time:    first.Add(time.Minute),
+		Version: "1.1.4",
+		Panic:   "boom SECRET=plain",
+		Stack:   []byte("goroutine 1 [running]:\nmain.main()\n\t" + filepath.Join(home, "src", "main.go") + ":10"),
+		Config:  config.DefaultConfig(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if latest, err := Latest(dir); err != nil || latest != path {
+		t.Errorf("Latest = %q, %v; want %q", latest, err, path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := string(data)
+	for _, want := range []string{"Version:  1.1.4", "SECRET=[redacted]", "guardian: enabled=true", "goroutine 1 [running]"} {
+		if !strings.Contains(report, want) {
+			t.Errorf("report missing %q:\n%s", want, report)
+		}
+	}
+	if strings.Contains(report, "plain") || strings.Contains(report, home) {
+		t.Errorf("report leaks a secret or the home directory:\n%s", report)
+	}
+	if info, err := os.Stat(path); err == nil && runtime.GOOS != "windows" && info.Mode().Perm()&0o077 != 0 {
+		t.Errorf("report mode = %v; want 0600", info.Mode().Perm())
+	}
+}
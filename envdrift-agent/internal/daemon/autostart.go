@@ -0,0 +1,162 @@
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/jainal09/envdrift-agent/internal/wsl"
+)
+
+// --- Linux without systemd ---
+//
+// Alpine, Void and Gentoo desktops run OpenRC or runit, which have no
+// per-user services, and containers usually run no init at all. Without
+// systemd the guardian is started by the desktop session instead, through an
+// XDG autostart entry; with no desktop session either, install explains how
+// to run it in the foreground rather than failing inside systemctl.
+
+// systemdRunDir exists when systemd is PID 1 (sd_booted(3)); a seam for tests.
+var systemdRunDir = "/run/systemd/system"
+
+// getenv is a seam for tests.
+var getenv = os.Getenv
+
+// errNoServiceManager is returned where there is neither systemd nor a
+// desktop session to start the agent.
+var errNoServiceManager = errors.New("systemd is not running and there is no desktop session to autostart the agent: " +
+	"run 'envdrift-agent start' from your init system or as the container's command instead")
+
+// errWSLNoSystemd is returned in WSL without systemd, where WSLg runs no
+// desktop session to honour an autostart entry.
+var errWSLNoSystemd = errors.New("systemd is off in this WSL distribution: set [boot] systemd=true in /etc/wsl.conf " +
+	"and run 'wsl --shutdown' from Windows, or run 'envdrift-agent start' yourself")
+
+// inWSL is a seam for tests.
+var inWSL = wsl.Detect
+
+// systemdAvailable reports whether systemd manages this system.
+func systemdAvailable() bool {
+	info, err := os.Stat(systemdRunDir)
+	return err == nil && info.IsDir()
+}
+
+// desktopSession reports whether the agent runs inside a graphical session
+// whose desktop honours XDG autostart entries.
+func desktopSession() bool {
+	return getenv("DISPLAY") != "" || getenv("WAYLAND_DISPLAY") != "" || getenv("XDG_CURRENT_DESKTOP") != ""
+}
+
+// StartsAtLogin reports whether Install uses an autostart entry, which starts
+// the agent at the next login rather than now.
+func StartsAtLogin() bool {
+	return runtime.GOOS == "linux" && !systemdAvailable() && !inWSL()
+}
+
+const autostartName = "envdrift-guardian.desktop"
+
+// autostartPath returns the XDG autostart entry location,
+// $XDG_CONFIG_HOME/autostart or ~/.config/autostart.
+func autostartPath() (string, error) {
+	if dir := getenv("XDG_CONFIG_HOME"); filepath.IsAbs(dir) {
+		return filepath.Join(dir, "autostart", autostartName), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config", "autostart", autostartName), nil
+}
+
+// installAutostart writes the XDG autostart entry that starts execPath when
+// the user logs in. It does not start the agent now: there is no manager to
+// hand it to, so the caller tells the user to run it or log in again.
+func installAutostart(execPath string) error {
+	if inWSL() {
+		return errWSLNoSystemd
+	}
+	if !desktopSession() {
+		return errNoServiceManager
+	}
+	logPath, err := agentLogPath()
+	if err != nil {
+		return err
+	}
+	path, err := autostartPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(buildAutostartEntry(execPath, logPath)), 0o644)
+}
+
+// isAutostartInstalled reports whether the autostart entry exists.
+func isAutostartInstalled() bool {
+	path, err := autostartPath()
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(path)
+	return err == nil
+}
+
+// uninstallAutostart removes the autostart entry; an absent one is not an
+// error.
+func uninstallAutostart() error {
+	path, err := autostartPath()
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// buildAutostartEntry returns the desktop entry running execPath with
+// "start". A session has no journal to collect output, so the agent writes
+// its rotating log to logPath, as under launchd.
+func buildAutostartEntry(execPath, logPath string) string {
+	var args []string
+	if vars := serviceEnv(); len(vars) > 0 {
+		args = append(args, "env")
+		for _, k := range sortedKeys(vars) {
+			args = append(args, desktopExecQuote(k+"="+vars[k]))
+		}
+	}
+	args = append(args, desktopExecQuote(execPath), "start", "--log-file", desktopExecQuote(logPath))
+	return fmt.Sprintf(`[Desktop Entry]
+Type=Application
+Name=EnvDrift Guardian
+Comment=Auto-encrypt .env files
+Exec=%s
+Terminal=false
+NoDisplay=true
+X-GNOME-Autostart-enabled=true
+`, strings.Join(args, " "))
+}
+
+// desktopExecQuote returns s as one argument of a desktop entry Exec key:
+// double-quoted when needed, with the escapes the Desktop Entry
+// Specification requires inside quotes, % doubled so it is not a field code,
+// and backslashes doubled again for the string value. Control characters,
+// which would end the key, are dropped.
+func desktopExecQuote(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return -1
+		}
+		return r
+	}, s)
+	s = strings.ReplaceAll(s, "%", "%%")
+	if plainWord.MatchString(s) {
+		return s
+	}
+	s = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "`", "\\`", "$", `\$`).Replace(s)
+	return `"` + strings.ReplaceAll(s, `\`, `\\`) + `"`
+}
@@ -9,14 +9,20 @@ import (
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strings"
+	"unicode/utf8"
+
+	"github.com/jainal09/envdrift-agent/internal/paths"
+	"github.com/jainal09/envdrift-agent/internal/systemlog"
 )
 
 // dispatch selects the per-platform implementation for the current runtime.GOOS
 // and invokes it, returning a single "unsupported platform" error on any OS that
-// has no darwin/linux/windows handler. Routing every action through one helper
+// has no darwin/linux/windows/BSD handler. Routing every action through one helper
 // keeps Install/Uninstall/Stop from each duplicating the GOOS switch (#413).
-func dispatch(darwin, linux, windows func() error) error {
+// bsd handles FreeBSD and OpenBSD, whose rc.d systems differ (see rcd.go).
+func dispatch(darwin, linux, windows, bsd func() error) error {
 	switch runtime.GOOS {
 	case "darwin":
 		return darwin()
@@ -24,6 +30,8 @@ func dispatch(darwin, linux, windows func() error) error {
 		return linux()
 	case "windows":
 		return windows()
+	case "freebsd", "openbsd":
+		return bsd()
 	default:
 		return fmt.Errorf("unsupported platform: %s", runtime.GOOS)
 	}
@@ -32,7 +40,7 @@ func dispatch(darwin, linux, windows func() error) error {
 // dispatchBool is the bool-returning analogue of dispatch for status probes; an
 // unsupported platform yields false. It lets IsInstalled/IsRunning share the
 // GOOS switch instead of repeating it (#413).
-func dispatchBool(darwin, linux, windows func() bool) bool {
+func dispatchBool(darwin, linux, windows, bsd func() bool) bool {
 	switch runtime.GOOS {
 	case "darwin":
 		return darwin()
@@ -40,6 +48,8 @@ func dispatchBool(darwin, linux, windows func() bool) bool {
 		return linux()
 	case "windows":
 		return windows()
+	case "freebsd", "openbsd":
+		return bsd()
 	default:
 		return false
 	}
@@ -48,13 +58,13 @@ func dispatchBool(darwin, linux, windows func() bool) bool {
 // Install installs the agent as a system service for the current operating system.
 // It returns an error if installation fails or if the platform is unsupported.
 func Install() error {
-	return dispatch(installMacOS, installLinux, installWindows)
+	return dispatch(installMacOS, installLinux, installWindows, installBSD)
 }
 
 // Uninstall removes the EnvDrift Guardian agent from system services on the current platform.
 // It delegates to the platform-specific uninstall implementation and returns an error if the operation fails or the platform is unsupported.
 func Uninstall() error {
-	return dispatch(uninstallMacOS, uninstallLinux, uninstallWindows)
+	return dispatch(uninstallMacOS, uninstallLinux, uninstallWindows, uninstallBSD)
 }
 
 // Stop stops the running agent service without removing its install unit, so a
@@ -62,19 +72,19 @@ func Uninstall() error {
 // platform-specific stop implementation and returns an error if the operation
 // fails or the platform is unsupported.
 func Stop() error {
-	return dispatch(stopMacOS, stopLinux, stopWindows)
+	return dispatch(stopMacOS, stopLinux, stopWindows, stopBSD)
 }
 
 // IsInstalled reports whether the agent is installed as a background service for the current user on the running platform.
 // It returns `true` if the platform-specific service/unit/task is present, `false` otherwise.
 func IsInstalled() bool {
-	return dispatchBool(isInstalledMacOS, isInstalledLinux, isInstalledWindows)
+	return dispatchBool(isInstalledMacOS, isInstalledLinux, isInstalledWindows, isInstalledBSD)
 }
 
 // IsRunning reports whether the agent service is currently running on the host.
 // It returns true when the platform-specific runtime indicates the agent is active and false on unsupported platforms.
 func IsRunning() bool {
-	return dispatchBool(isRunningMacOS, isRunningLinux, isRunningWindows)
+	return dispatchBool(isRunningMacOS, isRunningLinux, isRunningWindows, isRunningBSD)
 }
 
 // --- macOS LaunchAgent ---
@@ -122,13 +132,22 @@ func installMacOS() error {
 }
 
 // agentLogPath returns the rotating log file the installed service passes via
-// --log-file: <home>/.envdrift/logs/agent.log.
+// --log-file: agent.log in paths.LogDir (~/Library/Logs/envdrift on macOS).
 func agentLogPath() (string, error) {
-	home, err := os.UserHomeDir()
-	if err != nil {
+	if _, err := os.UserHomeDir(); err != nil {
 		return "", err
 	}
-	return filepath.Join(home, ".envdrift", "logs", "agent.log"), nil
+	return filepath.Join(paths.LogDir(), "agent.log"), nil
+}
+
+// serviceEnv returns the environment the installed service needs beyond what
+// its manager provides: ENVDRIFT_HOME when the installing shell has it, so the
+// service uses the same files as the CLI.
+func serviceEnv() map[string]string {
+	if dir := paths.Home(); dir != "" {
+		return map[string]string{paths.HomeEnv: dir}
+	}
+	return nil
 }
 
 // buildLaunchdPlist returns the launchd plist XML for the EnvDrift guardian,
@@ -137,7 +156,7 @@ func agentLogPath() (string, error) {
 //
 // launchd's StandardOutPath cannot rotate, so the guardian's periodic logging
 // previously grew /tmp/envdrift-agent.log without bound (#494). The service
-// now runs with --log-file pointing at ~/.envdrift/logs/agent.log, where the
+// now runs with --log-file pointing at agentLogPath, where the
 // agent's own RotatingWriter enforces a size cap; the /tmp Standard*Path files
 // only receive the brief startup prints and crash output.
 func buildLaunchdPlist(execPath string) string {
@@ -162,6 +181,14 @@ func buildLaunchdPlist(execPath string) string {
 		}
 		argXML.WriteString("        <string>" + xmlEscape(arg) + "</string>")
 	}
+	var envXML strings.Builder
+	if env := serviceEnv(); len(env) > 0 {
+		envXML.WriteString("\n    <key>EnvironmentVariables</key>\n    <dict>")
+		for _, k := range sortedKeys(env) {
+			envXML.WriteString("\n        <key>" + xmlEscape(k) + "</key>\n        <string>" + xmlEscape(env[k]) + "</string>")
+		}
+		envXML.WriteString("\n    </dict>")
+	}
 
 	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
 <!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
@@ -176,13 +203,23 @@ func buildLaunchdPlist(execPath string) string {
     <key>RunAtLoad</key>
     <true/>
     <key>KeepAlive</key>
-    <true/>
+    <true/>%s
     <key>StandardOutPath</key>
     <string>/tmp/envdrift-agent.log</string>
     <key>StandardErrorPath</key>
     <string>/tmp/envdrift-agent.err</string>
 </dict>
-</plist>`, argXML.String())
+</plist>`, argXML.String(), envXML.String())
+}
+
+// sortedKeys returns the keys of m in order, so generated units are stable.
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
 }
 
 // xmlEscape escapes s for safe inclusion in a plist <string> element (#348 G5).
@@ -255,11 +292,15 @@ func systemdPath() (string, error) {
 
 // installLinux creates a user-level systemd service unit for EnvDrift Guardian, writes it to the user's systemd directory, reloads the user daemon, enables the service, and starts it.
 // It returns an error if determining the executable path, resolving the target path, creating directories, writing the unit file, or starting the service fails.
+// Without systemd it installs an XDG autostart entry instead (see autostart.go).
 func installLinux() error {
 	execPath, err := os.Executable()
 	if err != nil {
 		return err
 	}
+	if !systemdAvailable() {
+		return installAutostart(execPath)
+	}
 
 	service := buildSystemdUnit(execPath)
 
@@ -286,25 +327,44 @@ func installLinux() error {
 // so a path containing spaces or special characters is not split by systemd
 // into multiple arguments (#348 G4).
 func buildSystemdUnit(execPath string) string {
+	var env strings.Builder
+	vars := serviceEnv()
+	for _, k := range sortedKeys(vars) {
+		env.WriteString("Environment=" + systemdQuote(k+"="+vars[k]) + "\n")
+	}
 	return fmt.Sprintf(`[Unit]
 Description=EnvDrift Guardian - Auto-encrypt .env files
 After=default.target
 
 [Service]
-ExecStart=%s start
+%sExecStart=%s start
 Restart=always
 RestartSec=10
 
 [Install]
 WantedBy=default.target
-`, systemdQuote(execPath))
+`, env.String(), systemdQuote(execPath))
 }
 
 // systemdQuote double-quotes a path for use in a systemd ExecStart line,
 // escaping backslashes and double quotes per systemd's quoting rules (#348 G4).
+// Control characters and bytes that are not UTF-8 become \xNN escapes: a
+// newline in the path would otherwise end the ExecStart line and start a
+// directive of the path's choosing.
 func systemdQuote(s string) string {
 	s = strings.ReplaceAll(s, `\`, `\\`)
 	s = strings.ReplaceAll(s, `"`, `\"`)
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r < 0x20 || r == 0x7f || (r == utf8.RuneError && size == 1) {
+			fmt.Fprintf(&b, `\x%02x`, s[i])
+		} else {
+			b.WriteString(s[i : i+size])
+		}
+		i += size
+	}
+	s = b.String()
 	// systemd expands `%` specifiers (%h, %u, …) and `$`/`${}` env refs even
 	// inside a double-quoted ExecStart value; escape literal occurrences so a
 	// path containing them isn't reinterpreted.
@@ -315,7 +375,13 @@ func systemdQuote(s string) string {
 
 // uninstallLinux stops and disables the user systemd service and removes its unit file from the user's systemd directory.
 // It returns an error if computing the unit file path or removing the file fails.
+// An autostart entry is removed as well, and alone it is all there is to remove.
 func uninstallLinux() error {
+	if isAutostartInstalled() {
+		if err := uninstallAutostart(); err != nil || !isInstalledSystemd() {
+			return err
+		}
+	}
 	_ = exec.Command("systemctl", "--user", "stop", linuxServiceName).Run()
 	_ = exec.Command("systemctl", "--user", "disable", linuxServiceName).Run()
 	path, err := systemdPath()
@@ -327,17 +393,26 @@ func uninstallLinux() error {
 
 // stopLinux stops the user systemd service without disabling or removing its
 // unit, so it remains installed and can be started again. It returns an error if
-// `systemctl --user stop` fails.
+// `systemctl --user stop` fails, or without systemd, where nothing supervises
+// the agent for it to stop.
 func stopLinux() error {
+	if !systemdAvailable() {
+		return fmt.Errorf("systemd is not running: stop the 'envdrift-agent start' process directly")
+	}
 	if err := exec.Command("systemctl", "--user", "stop", linuxServiceName).Run(); err != nil {
 		return fmt.Errorf("failed to stop agent: %w", err)
 	}
 	return nil
 }
 
-// isInstalledLinux reports whether the systemd user unit file for the daemon exists at the user's systemd configuration path.
-// It returns `true` if the unit file exists and `false` otherwise.
+// isInstalledLinux reports whether the systemd user unit file or the autostart entry exists.
 func isInstalledLinux() bool {
+	return isInstalledSystemd() || isAutostartInstalled()
+}
+
+// isInstalledSystemd reports whether the systemd user unit file for the daemon exists at the user's systemd configuration path.
+// It returns `true` if the unit file exists and `false` otherwise.
+func isInstalledSystemd() bool {
 	path, err := systemdPath()
 	if err != nil {
 		return false
@@ -349,6 +424,9 @@ func isInstalledLinux() bool {
 // isRunningLinux reports whether the Linux user systemd service envdrift-guardian.service is active.
 // It returns true if the service is active, false otherwise.
 func isRunningLinux() bool {
+	if !systemdAvailable() {
+		return false
+	}
 	cmd := exec.Command("systemctl", "--user", "is-active", linuxServiceName)
 	output, _ := cmd.Output()
 	return strings.TrimSpace(string(output)) == "active"
@@ -356,12 +434,14 @@ func isRunningLinux() bool {
 
 // installWindows creates a Windows scheduled task named "EnvDriftGuardian" that runs the current executable with the "start" argument at user logon using limited privileges.
 // It returns an error if the current executable path cannot be determined or if creating the scheduled task via `schtasks` fails.
-
+// It also registers the EnvDrift event log source, which only succeeds when run as administrator; without it
+// events are still logged, just without a message template.
 func installWindows() error {
 	execPath, err := os.Executable()
 	if err != nil {
 		return err
 	}
+	_ = systemlog.Register()
 
 	// Create a scheduled task that runs at login
 	cmd := exec.Command("schtasks", "/create",
@@ -9,8 +9,11 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
 	"testing"
+
+	"github.com/jainal09/envdrift-agent/internal/paths"
 )
 
 func TestLaunchAgentPath(t *testing.T) {
@@ -62,7 +65,7 @@ func TestIsRunning(t *testing.T) {
 // supportedGOOS reports whether the current OS has a per-platform daemon handler.
 func supportedGOOS() bool {
 	switch runtime.GOOS {
-	case "darwin", "linux", "windows":
+	case "darwin", "linux", "windows", "freebsd", "openbsd":
 		return true
 	default:
 		return false
@@ -76,8 +79,12 @@ func supportedGOOS() bool {
 func assertRoutedToCurrentOS(t *testing.T, kind, called string) {
 	t.Helper()
 	if supportedGOOS() {
-		if called != runtime.GOOS {
-			t.Errorf("%s invoked %q handler on %s; want the %s handler", kind, called, runtime.GOOS, runtime.GOOS)
+		want := runtime.GOOS
+		if want == "freebsd" || want == "openbsd" {
+			want = "bsd"
+		}
+		if called != want {
+			t.Errorf("%s invoked %q handler on %s; want the %s handler", kind, called, runtime.GOOS, want)
 		}
 		return
 	}
@@ -100,7 +107,7 @@ func TestDispatchRoutesPerPlatform(t *testing.T) {
 		return func() error { called = name; return nil }
 	}
 
-	err := dispatch(mark("darwin"), mark("linux"), mark("windows"))
+	err := dispatch(mark("darwin"), mark("linux"), mark("windows"), mark("bsd"))
 
 	if supportedGOOS() {
 		if err != nil {
@@ -124,7 +131,7 @@ func TestDispatchPropagatesHandlerError(t *testing.T) {
 
 	sentinel := errors.New("boom")
 	fail := func() error { return sentinel }
-	if err := dispatch(fail, fail, fail); !errors.Is(err, sentinel) {
+	if err := dispatch(fail, fail, fail, fail); !errors.Is(err, sentinel) {
 		t.Errorf("dispatch should return the handler error, got %v", err)
 	}
 }
@@ -138,7 +145,7 @@ func TestDispatchBoolRoutesPerPlatform(t *testing.T) {
 		return func() bool { called = name; return true }
 	}
 
-	got := dispatchBool(mark("darwin"), mark("linux"), mark("windows"))
+	got := dispatchBool(mark("darwin"), mark("linux"), mark("windows"), mark("bsd"))
 
 	if got != supportedGOOS() {
 		t.Errorf("dispatchBool on %s returned %v; want %v", runtime.GOOS, got, supportedGOOS())
@@ -187,6 +194,22 @@ func TestSystemdUnitQuotesExecStart(t *testing.T) {
 			execPath: `/opt/app$HOME/agent`,
 			want:     `ExecStart="/opt/app$$HOME/agent" start`,
 		},
+		{
+			// A raw newline would end ExecStart and start a new directive.
+			name:     "path with newline",
+			execPath: "/opt/a\nExecStartPre=/bin/evil/agent",
+			want:     `ExecStart="/opt/a\x0aExecStartPre=/bin/evil/agent" start`,
+		},
+		{
+			name:     "path with unicode",
+			execPath: `/home/zoë/應用/agent`,
+			want:     `ExecStart="/home/zoë/應用/agent" start`,
+		},
+		{
+			name:     "path with a non-UTF-8 byte",
+			execPath: "/opt/\xff/agent",
+			want:     `ExecStart="/opt/\xff/agent" start`,
+		},
 	}
 
 	for _, tt := range tests {
@@ -199,6 +222,150 @@ func TestSystemdUnitQuotesExecStart(t *testing.T) {
 	}
 }
 
+// TestServiceUnitsPassEnvdriftHome checks that an install under ENVDRIFT_HOME
+// gives the service the same directory, in both the unit and the plist.
+func TestServiceUnitsPassEnvdriftHome(t *testing.T) {
+	t.Setenv(paths.HomeEnv, "/srv/envdrift state")
+	unit := buildSystemdUnit("/usr/local/bin/agent")
+	if want := `Environment="ENVDRIFT_HOME=/srv/envdrift state"`; !strings.Contains(unit, want) {
+		t.Errorf("unit lacks %s:\n%s", want, unit)
+	}
+	plist := buildLaunchdPlist("/usr/local/bin/agent")
+	if want := "<key>ENVDRIFT_HOME</key>\n        <string>/srv/envdrift state</string>"; !strings.Contains(plist, want) {
+		t.Errorf("plist lacks ENVDRIFT_HOME:\n%s", plist)
+	}
+	if err := xml.Unmarshal([]byte(plist), new(struct{})); err != nil {
+		t.Errorf("plist is not XML: %v", err)
+	}
+
+	t.Setenv(paths.HomeEnv, "")
+	if unit := buildSystemdUnit("/usr/local/bin/agent"); strings.Contains(unit, "Environment=") {
+		t.Errorf("unit sets an environment without ENVDRIFT_HOME:\n%s", unit)
+	}
+}
+
+// TestLinuxWithoutSystemd: without systemd, install falls back to an XDG
+// autostart entry in a desktop session and explains itself outside one.
+func TestLinuxWithoutSystemd(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("Linux-only test")
+	}
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_STATE_HOME", "")
+	t.Setenv(paths.HomeEnv, "")
+	origDir, origEnv, origWSL := systemdRunDir, getenv, inWSL
+	t.Cleanup(func() { systemdRunDir, getenv, inWSL = origDir, origEnv, origWSL })
+	systemdRunDir = filepath.Join(home, "no-systemd")
+	inWSL = func() bool { return false }
+	env := map[string]string{}
+	getenv = func(k string) string { return env[k] }
+
+	if !StartsAtLogin() {
+		t.Error("StartsAtLogin() = false without systemd")
+	}
+	if err := installLinux(); !errors.Is(err, errNoServiceManager) {
+		t.Fatalf("installLinux() outside a desktop = %v, want errNoServiceManager", err)
+	}
+
+	env["XDG_CURRENT_DESKTOP"] = "XFCE"
+	if err := installLinux(); err != nil {
+		t.Fatalf("installLinux(): %v", err)
+	}
+	entry := filepath.Join(home, ".config", "autostart", autostartName)
+	data, err := os.ReadFile(entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "start --log-file " + filepath.Join(home, ".local", "state", "envdrift", "logs", "agent.log"); !strings.Contains(string(data), want) {
+		t.Errorf("autostart entry lacks %q:\n%s", want, data)
+	}
+	if !isInstalledLinux() || isRunningLinux() {
+		t.Errorf("installed=%v running=%v, want true false", isInstalledLinux(), isRunningLinux())
+	}
+	if err := stopLinux(); err == nil {
+		t.Error("stopLinux() without systemd = nil, want an error")
+	}
+	if err := uninstallLinux(); err != nil {
+		t.Fatalf("uninstallLinux(): %v", err)
+	}
+	if _, err := os.Stat(entry); !os.IsNotExist(err) {
+		t.Errorf("autostart entry left behind: %v", err)
+	}
+
+	// WSLg sets DISPLAY but runs no session to read autostart entries.
+	inWSL = func() bool { return true }
+	env["DISPLAY"] = ":0"
+	if err := installLinux(); !errors.Is(err, errWSLNoSystemd) {
+		t.Errorf("installLinux() in WSL without systemd = %v, want errWSLNoSystemd", err)
+	}
+	if StartsAtLogin() {
+		t.Error("StartsAtLogin() = true in WSL")
+	}
+}
+
+// TestAutostartEntryQuotesExec checks a path with spaces, quotes, $ and % is
+// one Exec argument and that ENVDRIFT_HOME reaches the agent.
+func TestAutostartEntryQuotesExec(t *testing.T) {
+	t.Setenv(paths.HomeEnv, "")
+	entry := buildAutostartEntry("/usr/bin/envdrift-agent", "/home/me/agent.log")
+	if want := "Exec=/usr/bin/envdrift-agent start --log-file /home/me/agent.log\n"; !strings.Contains(entry, want) {
+		t.Errorf("entry lacks %q:\n%s", want, entry)
+	}
+
+	t.Setenv(paths.HomeEnv, "/srv/envdrift")
+	entry = buildAutostartEntry(`/opt/My "Apps"/50%/$agent`, "/srv/envdrift/logs/agent.log")
+	if want := `Exec=env ENVDRIFT_HOME=/srv/envdrift "/opt/My \\"Apps\\"/50%%/\\$agent" start`; !strings.Contains(entry, want) {
+		t.Errorf("entry lacks %s:\n%s", want, entry)
+	}
+}
+
+// TestBSDRcScripts checks the rc.d scripts run the agent as the installing
+// user with its log file, and that shell-special paths stay one word.
+func TestBSDRcScripts(t *testing.T) {
+	t.Setenv(paths.HomeEnv, "")
+	u := rcUser{name: "me", home: "/home/me"}
+
+	freebsd := buildFreeBSDRcScript("/usr/local/bin/envdrift-agent", u)
+	for _, want := range []string{
+		"# PROVIDE: envdrift_guardian",
+		`rcvar="envdrift_guardian_enable"`,
+		`command="/usr/sbin/daemon"`,
+		`command_args="-f -r -P ${pidfile} -u me /usr/bin/env HOME=/home/me PATH=/sbin:/bin:/usr/sbin:/usr/bin:/usr/local/sbin:/usr/local/bin:/home/me/.local/bin /usr/local/bin/envdrift-agent start --log-file /home/me/.local/state/envdrift/logs/agent.log"`,
+	} {
+		if !strings.Contains(freebsd, want) {
+			t.Errorf("FreeBSD script lacks %s:\n%s", want, freebsd)
+		}
+	}
+
+	openbsd := buildOpenBSDRcScript("/usr/local/bin/envdrift-agent", u)
+	for _, want := range []string{
+		"daemon='/usr/local/bin/envdrift-agent'",
+		"daemon_flags='start --log-file /home/me/.local/state/envdrift/logs/agent.log'",
+		"daemon_user='me'",
+		"rc_bg=YES",
+	} {
+		if !strings.Contains(openbsd, want) {
+			t.Errorf("OpenBSD script lacks %s:\n%s", want, openbsd)
+		}
+	}
+	if strings.Contains(openbsd, "rc_start") {
+		t.Errorf("OpenBSD script overrides rc_start without ENVDRIFT_HOME:\n%s", openbsd)
+	}
+
+	// A path with a space, a quote and a $ is one word after rc.subr's eval,
+	// and ENVDRIFT_HOME reaches the agent on both systems.
+	t.Setenv(paths.HomeEnv, "/srv/envdrift")
+	freebsd = buildFreeBSDRcScript(`/opt/My Apps/it's/$agent`, u)
+	if want := `ENVDRIFT_HOME=/srv/envdrift '/opt/My Apps/it'\\''s/\$agent' start --log-file /srv/envdrift/logs/agent.log"`; !strings.Contains(freebsd, want) {
+		t.Errorf("FreeBSD script lacks %s:\n%s", want, freebsd)
+	}
+	openbsd = buildOpenBSDRcScript("/usr/local/bin/envdrift-agent", u)
+	if want := `rc_exec "ENVDRIFT_HOME=/srv/envdrift ${daemon} ${daemon_flags}"`; !strings.Contains(openbsd, want) {
+		t.Errorf("OpenBSD script lacks %s:\n%s", want, openbsd)
+	}
+}
+
 // TestLaunchdPlistEscapesExecPath is the #348 G5 regression: XML-special
 // characters in the exec path must be escaped so the plist is valid XML.
 func TestLaunchdPlistEscapesExecPath(t *testing.T) {
@@ -242,7 +409,7 @@ func TestLaunchdPlistPassesRotatingLogFile(t *testing.T) {
 	if !strings.Contains(plist, "<string>--log-file</string>") {
 		t.Fatalf("plist does not pass --log-file: the launchd log grows unbounded (#494)\n%s", plist)
 	}
-	logPath := filepath.Join(home, ".envdrift", "logs", "agent.log")
+	logPath := filepath.Join(paths.LogDir(), "agent.log")
 	if !strings.Contains(plist, "<string>"+xmlEscape(logPath)+"</string>") {
 		t.Errorf("plist missing rotating log path %q:\n%s", logPath, plist)
 	}
@@ -316,3 +483,94 @@ func TestLaunchdPlistWarnsWhenHomeDirUnavailable(t *testing.T) {
 		}
 	}
 }
+
+// systemdUnquote reverses systemdQuote the way systemd reads the ExecStart
+// word back: strip the quotes, then undo %%, $$ and the C-style escapes.
+func systemdUnquote(t *testing.T, q string) string {
+	t.Helper()
+	if len(q) < 2 || q[0] != '"' || q[len(q)-1] != '"' {
+		t.Fatalf("not double-quoted: %q", q)
+	}
+	q = q[1 : len(q)-1]
+	var b strings.Builder
+	for i := 0; i < len(q); i++ {
+		switch {
+		case (q[i] == '%' || q[i] == '$') && i+1 < len(q) && q[i+1] == q[i]:
+			b.WriteByte(q[i])
+			i++
+		case q[i] == '%' || q[i] == '$':
+			t.Fatalf("unescaped %c in %q", q[i], q)
+		case q[i] == '"':
+			t.Fatalf("unescaped quote in %q", q)
+		case q[i] == '\\' && i+1 < len(q) && (q[i+1] == '\\' || q[i+1] == '"'):
+			b.WriteByte(q[i+1])
+			i++
+		case q[i] == '\\' && i+3 < len(q) && q[i+1] == 'x':
+			n, err := strconv.ParseUint(q[i+2:i+4], 16, 8)
+			if err != nil {
+				t.Fatalf("bad \\x escape in %q", q)
+			}
+			b.WriteByte(byte(n))
+			i += 3
+		case q[i] == '\\':
+			t.Fatalf("dangling backslash in %q", q)
+		default:
+			b.WriteByte(q[i])
+		}
+	}
+	return b.String()
+}
+
+// FuzzSystemdQuote checks that any exec path, however hostile, stays one
+// ExecStart word on one line and reads back unchanged.
+func FuzzSystemdQuote(f *testing.F) {
+	for _, seed := range []string{
+		"/usr/bin/agent", "/opt/My Apps/agent", `/opt/a"b\c`, "/opt/%h/$HOME/${X}",
+		"/opt/a\nb\r\tc", "/home/zoë/應用/agent", "/opt/\xff\xfe", "/opt/a\\x41",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, path string) {
+		q := systemdQuote(path)
+		if strings.ContainsAny(q, "\n\r") {
+			t.Fatalf("quoted path spans lines: %q", q)
+		}
+		if got := systemdUnquote(t, q); got != path {
+			t.Fatalf("round trip: %q -> %q -> %q", path, q, got)
+		}
+	})
+}
+
+func TestRecentLogs(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	orig := journalLogs
+	t.Cleanup(func() { journalLogs = orig })
+	journalLogs = func(n int) ([]byte, error) {
+		return []byte("journal 1\njournal 2\n"), nil
+	}
+
+	got, err := RecentLogs(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := ""
+	if runtime.GOOS == "linux" {
+		want = "journal 1\njournal 2"
+	}
+	if got != want {
+		t.Errorf("without a log file RecentLogs = %q; want %q", got, want)
+	}
+
+	logPath := filepath.Join(paths.LogDir(), "agent.log")
+	if err := os.MkdirAll(filepath.Dir(logPath), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(logPath, []byte("one\ntwo\nthree\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := RecentLogs(2); err != nil || got != "two\nthree" {
+		t.Errorf("RecentLogs(2) = %q, %v; want the last two lines of agent.log", got, err)
+	}
+}
@@ -0,0 +1,51 @@
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// journalLogs reads the Linux service's journal; tests replace it.
+var journalLogs = func(n int) ([]byte, error) {
+	return exec.Command("journalctl", "--user", "-u", linuxServiceName,
+		"-n", strconv.Itoa(n), "--no-pager", "-o", "short-iso").Output()
+}
+
+// RecentLogs returns up to the last n lines the agent logged: the rotating
+// agent.log the macOS service writes (or a `start
+// --log-file` run left), otherwise the user journal of the Linux service. It
+// returns "" when there is neither, e.g. for the Windows scheduled task.
+func RecentLogs(n int) (string, error) {
+	logPath, err := agentLogPath()
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(logPath)
+	switch {
+	case err == nil:
+		return lastLines(string(data), n), nil
+	case !os.IsNotExist(err):
+		return "", err
+	}
+	if runtime.GOOS != "linux" {
+		return "", nil
+	}
+	out, err := journalLogs(n)
+	if err != nil {
+		return "", fmt.Errorf("journalctl: %w", err)
+	}
+	return lastLines(string(out), n), nil
+}
+
+// lastLines returns the final n lines of s.
+func lastLines(s string, n int) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,228 @@
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"github.com/jainal09/envdrift-agent/internal/paths"
+)
+
+// --- FreeBSD / OpenBSD rc.d ---
+//
+// The BSDs have no per-user service manager, so the guardian is a system rc.d
+// service that runs as the user who installed it. Installing therefore needs
+// root: `sudo envdrift-agent install` (doas on OpenBSD), and SUDO_USER /
+// DOAS_USER names the account whose files it watches. FreeBSD supervises the
+// agent with daemon(8) -r, which restarts it like Restart=always; OpenBSD's
+// rc.subr backgrounds it under su -l.
+
+// rcName is the rc.d script name and, on FreeBSD, the rcvar prefix.
+const rcName = "envdrift_guardian"
+
+// rcPath returns the rc.d script location for goos.
+func rcPath(goos string) string {
+	if goos == "openbsd" {
+		return filepath.Join("/etc/rc.d", rcName)
+	}
+	return filepath.Join("/usr/local/etc/rc.d", rcName)
+}
+
+// rcUser is the account the service runs as.
+type rcUser struct {
+	name, home string
+}
+
+// serviceUser returns the user who ran sudo or doas. Running install as root
+// without either is refused rather than watching root's home.
+func serviceUser() (rcUser, error) {
+	if os.Geteuid() != 0 {
+		return rcUser{}, fmt.Errorf("rc.d services are system-wide: run 'sudo envdrift-agent install' (or doas); the agent then runs as you")
+	}
+	name := os.Getenv("SUDO_USER")
+	if name == "" {
+		name = os.Getenv("DOAS_USER")
+	}
+	if name == "" || name == "root" {
+		return rcUser{}, fmt.Errorf("run install through sudo or doas from the account whose .env files the agent should watch")
+	}
+	u, err := user.Lookup(name)
+	if err != nil {
+		return rcUser{}, err
+	}
+	return rcUser{name: u.Username, home: u.HomeDir}, nil
+}
+
+// installBSD writes the rc.d script, enables it and starts the service.
+func installBSD() error {
+	execPath, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	u, err := serviceUser()
+	if err != nil {
+		return err
+	}
+	script := buildFreeBSDRcScript(execPath, u)
+	if runtime.GOOS == "openbsd" {
+		script = buildOpenBSDRcScript(execPath, u)
+	}
+	if err := os.WriteFile(rcPath(runtime.GOOS), []byte(script), 0o755); err != nil {
+		return err
+	}
+	if runtime.GOOS == "openbsd" {
+		if err := exec.Command("rcctl", "enable", rcName).Run(); err != nil {
+			return fmt.Errorf("rcctl enable: %w", err)
+		}
+		return exec.Command("rcctl", "start", rcName).Run()
+	}
+	if err := exec.Command("sysrc", rcName+"_enable=YES").Run(); err != nil {
+		return fmt.Errorf("sysrc: %w", err)
+	}
+	return exec.Command("service", rcName, "start").Run()
+}
+
+// uninstallBSD stops and disables the service and removes its script.
+func uninstallBSD() error {
+	if runtime.GOOS == "openbsd" {
+		_ = exec.Command("rcctl", "stop", rcName).Run()
+		_ = exec.Command("rcctl", "disable", rcName).Run()
+	} else {
+		_ = exec.Command("service", rcName, "stop").Run()
+		_ = exec.Command("sysrc", "-x", rcName+"_enable").Run()
+	}
+	return os.Remove(rcPath(runtime.GOOS))
+}
+
+// stopBSD stops the service, leaving it enabled for the next boot.
+func stopBSD() error {
+	cmd := exec.Command("service", rcName, "stop")
+	if runtime.GOOS == "openbsd" {
+		cmd = exec.Command("rcctl", "stop", rcName)
+	}
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to stop agent: %w", err)
+	}
+	return nil
+}
+
+// isInstalledBSD reports whether the rc.d script exists.
+func isInstalledBSD() bool {
+	_, err := os.Stat(rcPath(runtime.GOOS))
+	return err == nil
+}
+
+// isRunningBSD reports whether rc.d sees the service running.
+func isRunningBSD() bool {
+	cmd := exec.Command("service", rcName, "status")
+	if runtime.GOOS == "openbsd" {
+		cmd = exec.Command("rcctl", "check", rcName)
+	}
+	return cmd.Run() == nil
+}
+
+// bsdLogFile is the rotating log the service writes, in u's state directory.
+func bsdLogFile(u rcUser) string {
+	return filepath.Join(paths.ServiceLogDir(u.home), "agent.log")
+}
+
+// buildFreeBSDRcScript returns the FreeBSD rc.d script running execPath as u
+// under daemon(8). rc's PATH lacks /usr/local/bin, where ports install
+// dotenvx, and pip's ~/.local/bin, so the agent gets both.
+func buildFreeBSDRcScript(execPath string, u rcUser) string {
+	env := []string{
+		"HOME=" + u.home,
+		"PATH=/sbin:/bin:/usr/sbin:/usr/bin:/usr/local/sbin:/usr/local/bin:" + filepath.Join(u.home, ".local", "bin"),
+	}
+	vars := serviceEnv()
+	for _, k := range sortedKeys(vars) {
+		env = append(env, k+"="+vars[k])
+	}
+	// ${pidfile} is the one expansion wanted inside command_args.
+	args := []string{"-f", "-r", "-P", "${pidfile}", "-u", dqEscape(rcWord(u.name)), "/usr/bin/env"}
+	for _, e := range env {
+		args = append(args, dqEscape(rcWord(e)))
+	}
+	args = append(args, dqEscape(rcWord(execPath)), "start", "--log-file", dqEscape(rcWord(bsdLogFile(u))))
+
+	return fmt.Sprintf(`#!/bin/sh
+#
+# PROVIDE: %[1]s
+# REQUIRE: LOGIN
+# KEYWORD: shutdown
+#
+# EnvDrift Guardian - Auto-encrypt .env files, running as %[2]s.
+
+. /etc/rc.subr
+
+name="%[1]s"
+rcvar="%[1]s_enable"
+
+load_rc_config $name
+: ${%[1]s_enable:="NO"}
+
+pidfile="/var/run/${name}.pid"
+command="/usr/sbin/daemon"
+command_args="%[3]s"
+
+run_rc_command "$1"
+`, rcName, u.name, strings.Join(args, " "))
+}
+
+// buildOpenBSDRcScript returns the OpenBSD rc.d script running execPath as u.
+// rc.subr finds the running agent by matching "daemon daemon_flags" against
+// process arguments, so ENVDRIFT_HOME goes in the environment via rc_start
+// rather than into the command line.
+func buildOpenBSDRcScript(execPath string, u rcUser) string {
+	var start string
+	if env := serviceEnv(); len(env) > 0 {
+		var vars []string
+		for _, k := range sortedKeys(env) {
+			vars = append(vars, dqEscape(k+"="+rcWord(env[k])))
+		}
+		start = fmt.Sprintf("\nrc_start() {\n\trc_exec \"%s ${daemon} ${daemon_flags}\"\n}\n",
+			strings.Join(vars, " "))
+	}
+	return fmt.Sprintf(`#!/bin/ksh
+#
+# EnvDrift Guardian - Auto-encrypt .env files, running as %[1]s.
+
+daemon=%[2]s
+daemon_flags=%[3]s
+daemon_user=%[4]s
+
+. /etc/rc.d/rc.subr
+
+rc_bg=YES
+rc_reload=NO
+%[5]s
+rc_cmd $1
+`, u.name, shQuote(execPath), shQuote("start --log-file "+bsdLogFile(u)), shQuote(u.name), start)
+}
+
+// plainWord matches a shell word that needs no quoting.
+var plainWord = regexp.MustCompile(`^[A-Za-z0-9_./@%+=:,-]+$`)
+
+// rcWord returns s as one shell word, single-quoted only when it has to be.
+func rcWord(s string) string {
+	if plainWord.MatchString(s) {
+		return s
+	}
+	return shQuote(s)
+}
+
+// shQuote single-quotes s for sh, escaping embedded single quotes.
+func shQuote(s string) string {
+	return `'` + strings.ReplaceAll(s, `'`, `'\''`) + `'`
+}
+
+// dqEscape escapes s for the inside of a double-quoted sh string, which
+// rc.subr later evals: the single quotes of rcWord survive to the eval.
+func dqEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "`", "\\`", "$", `\$`).Replace(s)
+}
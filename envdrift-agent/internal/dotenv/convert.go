@@ -0,0 +1,140 @@
+package dotenv
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Output formats Convert renders.
+const (
+	FormatDotenv = "dotenv"
+	FormatJSON   = "json"
+	FormatYAML   = "yaml"
+	// FormatTFVars is a Terraform variable definitions (.tfvars) file.
+	FormatTFVars = "tfvars"
+)
+
+// Formats lists the formats Convert accepts.
+var Formats = []string{FormatDotenv, FormatJSON, FormatYAML, FormatTFVars}
+
+// tfVarPrefix is the prefix Terraform strips from environment variables that
+// set input variables; a key carrying it names the same variable in tfvars.
+const tfVarPrefix = "TF_VAR_"
+
+// hclIdentifier matches the attribute names a tfvars file accepts.
+var hclIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// yamlReserved are the keys YAML would read as something other than a
+// string when left unquoted.
+var yamlReserved = map[string]bool{
+	"y": true, "n": true, "yes": true, "no": true, "on": true, "off": true,
+	"true": true, "false": true, "null": true,
+}
+
+// Convert renders entries in format, every value as a string in file order.
+// A key that format cannot express, such as a dotted name in tfvars, is an
+// error naming it.
+func Convert(entries []Entry, format string) ([]byte, error) {
+	switch format {
+	case FormatDotenv:
+		return Marshal(entries), nil
+	case FormatJSON:
+		return marshalJSON(entries), nil
+	case FormatYAML:
+		return marshalYAML(entries), nil
+	case FormatTFVars:
+		return marshalTFVars(entries)
+	}
+	return nil, fmt.Errorf("unsupported format %q (want %s)", format, strings.Join(Formats, ", "))
+}
+
+// jsonString encodes s as a JSON string, leaving <, > and & as they are;
+// YAML reads the same text as a double-quoted scalar.
+func jsonString(s string) string {
+	var b strings.Builder
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// marshalJSON renders entries as one JSON object, keys in file order.
+func marshalJSON(entries []Entry) []byte {
+	if len(entries) == 0 {
+		return []byte("{}\n")
+	}
+	var b strings.Builder
+	b.WriteString("{\n")
+	for i, e := range entries {
+		fmt.Fprintf(&b, "  %s: %s", jsonString(e.Key), jsonString(e.Value))
+		if i < len(entries)-1 {
+			b.WriteByte(',')
+		}
+		b.WriteByte('\n')
+	}
+	b.WriteString("}\n")
+	return []byte(b.String())
+}
+
+// marshalYAML renders entries as a YAML mapping with double-quoted values.
+func marshalYAML(entries []Entry) []byte {
+	if len(entries) == 0 {
+		return []byte("{}\n")
+	}
+	var b strings.Builder
+	for _, e := range entries {
+		key := e.Key
+		if yamlReserved[strings.ToLower(key)] {
+			key = jsonString(key)
+		}
+		fmt.Fprintf(&b, "%s: %s\n", key, jsonString(e.Value))
+	}
+	return []byte(b.String())
+}
+
+// marshalTFVars renders entries as tfvars string assignments. A TF_VAR_
+// prefix is dropped, as Terraform does for the environment.
+func marshalTFVars(entries []Entry) ([]byte, error) {
+	var b strings.Builder
+	for _, e := range entries {
+		name := strings.TrimPrefix(e.Key, tfVarPrefix)
+		if !hclIdentifier.MatchString(name) {
+			return nil, fmt.Errorf("%s is not a valid Terraform variable name", e.Key)
+		}
+		fmt.Fprintf(&b, "%s = %s\n", name, hclQuote(e.Value))
+	}
+	return []byte(b.String()), nil
+}
+
+// hclQuote double-quotes s as an HCL string literal, escaping the template
+// sequences ${ and %{ so the value is taken literally.
+func hclQuote(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch c {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '$', '%':
+			b.WriteByte(c)
+			if i+1 < len(s) && s[i+1] == '{' {
+				b.WriteByte(c)
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
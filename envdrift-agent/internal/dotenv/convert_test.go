@@ -0,0 +1,54 @@
+package dotenv
+
+import "testing"
+
+func TestConvert(t *testing.T) {
+	entries := []Entry{
+		{Key: "DB_URL", Value: `postgres://u:p"w@h/db?a=1&b=<2>`},
+		{Key: "TF_VAR_region", Value: "eu-west-1"},
+		{Key: "TEMPLATE", Value: "${HOME}\n%{x} 100%"},
+		{Key: "yes", Value: "true"},
+	}
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{FormatJSON, `{
+  "DB_URL": "postgres://u:p\"w@h/db?a=1&b=<2>",
+  "TF_VAR_region": "eu-west-1",
+  "TEMPLATE": "${HOME}\n%{x} 100%",
+  "yes": "true"
+}
+`},
+		{FormatYAML, `DB_URL: "postgres://u:p\"w@h/db?a=1&b=<2>"
+TF_VAR_region: "eu-west-1"
+TEMPLATE: "${HOME}\n%{x} 100%"
+"yes": "true"
+`},
+		{FormatTFVars, `DB_URL = "postgres://u:p\"w@h/db?a=1&b=<2>"
+region = "eu-west-1"
+TEMPLATE = "$${HOME}\n%%{x} 100%"
+yes = "true"
+`},
+		{FormatDotenv, string(Marshal(entries))},
+	}
+	for _, tt := range tests {
+		got, err := Convert(entries, tt.format)
+		if err != nil {
+			t.Fatalf("Convert(%s): %v", tt.format, err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("Convert(%s) =\n%s\nwant\n%s", tt.format, got, tt.want)
+		}
+	}
+
+	if _, err := Convert([]Entry{{Key: "a.b", Value: "1"}}, FormatTFVars); err == nil {
+		t.Error("a dotted key must not convert to tfvars")
+	}
+	if _, err := Convert(entries, "toml"); err == nil {
+		t.Error("an unknown format must be an error")
+	}
+	if got, _ := Convert(nil, FormatJSON); string(got) != "{}\n" {
+		t.Errorf("Convert(nil, json) = %q; want an empty object", got)
+	}
+}
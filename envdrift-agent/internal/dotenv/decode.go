@@ -0,0 +1,371 @@
+package dotenv
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// FormatOf returns the format of a structured file from its extension:
+// json for .json, yaml for .yaml and .yml, dotenv otherwise.
+func FormatOf(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return FormatJSON
+	case ".yaml", ".yml":
+		return FormatYAML
+	}
+	return FormatDotenv
+}
+
+// Decode reads data in format into entries, the reverse of Convert for
+// dotenv, json and yaml. Nested mappings are flattened by joining keys with
+// "_", so {"db": {"host": "h"}} becomes db_host; numbers and booleans keep
+// their text and null becomes an empty value. Lists are not supported. As
+// with Parse, a repeated key keeps its first position and its last value.
+func Decode(data []byte, format string) ([]Entry, error) {
+	switch format {
+	case FormatDotenv:
+		return Parse(data)
+	case FormatJSON:
+		return decodeJSON(data)
+	case FormatYAML:
+		return decodeYAML(data)
+	}
+	return nil, fmt.Errorf("unsupported format %q (want %s, %s or %s)", format, FormatDotenv, FormatJSON, FormatYAML)
+}
+
+// entrySet collects decoded entries in first-seen order.
+type entrySet struct {
+	entries []Entry
+	index   map[string]int
+}
+
+// set assigns key, rejecting a name dotenvx would not accept.
+func (s *entrySet) set(key, value string) error {
+	if !keyPattern.MatchString(key) {
+		return fmt.Errorf("invalid variable name %q", key)
+	}
+	if s.index == nil {
+		s.index = make(map[string]int)
+	}
+	if pos, ok := s.index[key]; ok {
+		s.entries[pos].Value = value
+		return nil
+	}
+	s.index[key] = len(s.entries)
+	s.entries = append(s.entries, Entry{Key: key, Value: value})
+	return nil
+}
+
+// joinKey is key nested under prefix.
+func joinKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "_" + key
+}
+
+// decodeJSON reads a JSON object, keeping its key order.
+func decodeJSON(data []byte) ([]Entry, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, fmt.Errorf("parse JSON: %w", err)
+	}
+	if d, ok := tok.(json.Delim); !ok || d != '{' {
+		return nil, errors.New("parse JSON: the top level must be an object")
+	}
+	var s entrySet
+	if err := s.decodeJSONObject(dec, ""); err != nil {
+		return nil, fmt.Errorf("parse JSON: %w", err)
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("parse JSON: data after the top-level object")
+	}
+	return s.entries, nil
+}
+
+// decodeJSONObject reads the members of an object whose opening brace was
+// consumed, through its closing brace.
+func (s *entrySet) decodeJSONObject(dec *json.Decoder, prefix string) error {
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		key := joinKey(prefix, tok.(string))
+		if tok, err = dec.Token(); err != nil {
+			return err
+		}
+		switch v := tok.(type) {
+		case json.Delim:
+			if v == '[' {
+				return fmt.Errorf("%s: lists are not supported", key)
+			}
+			err = s.decodeJSONObject(dec, key)
+		case string:
+			err = s.set(key, v)
+		case json.Number:
+			err = s.set(key, v.String())
+		case bool:
+			err = s.set(key, strconv.FormatBool(v))
+		case nil:
+			err = s.set(key, "")
+		}
+		if err != nil {
+			return err
+		}
+	}
+	_, err := dec.Token()
+	return err
+}
+
+// yamlLevel is a mapping being read: the root, or a key whose value is on
+// the following lines.
+type yamlLevel struct {
+	indent      int
+	key         string
+	childIndent int
+	children    bool
+}
+
+// blockHeader matches a literal (|) or folded (>) block scalar header with an
+// optional chomping indicator.
+var blockHeader = regexp.MustCompile(`^[|>][+-]?$`)
+
+// decodeYAML reads the YAML subset config files use: one document of nested
+// block mappings whose values are plain, quoted or block scalars. Anything
+// else — lists, flow collections, anchors, tags — is an error naming the
+// line.
+func decodeYAML(data []byte) ([]Entry, error) {
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	var s entrySet
+	stack := []*yamlLevel{{indent: -1, childIndent: -1}}
+	pop := func() error {
+		top := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if !top.children {
+			return s.set(top.key, "")
+		}
+		return nil
+	}
+
+	started := false
+	for i := 0; i < len(lines); i++ {
+		lineNo := i + 1
+		line := strings.TrimRight(lines[i], " \t")
+		content := strings.TrimLeft(line, " ")
+		if content == "" || strings.HasPrefix(content, "#") {
+			continue
+		}
+		if content == "---" || content == "..." {
+			if started {
+				return nil, fmt.Errorf("line %d: multiple documents are not supported", lineNo)
+			}
+			continue
+		}
+		started = true
+		if content[0] == '\t' {
+			return nil, fmt.Errorf("line %d: tabs are not allowed in indentation", lineNo)
+		}
+		indent := len(line) - len(content)
+
+		for indent <= stack[len(stack)-1].indent {
+			if err := pop(); err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNo, err)
+			}
+		}
+		parent := stack[len(stack)-1]
+		if parent.childIndent < 0 {
+			parent.childIndent = indent
+		} else if indent != parent.childIndent {
+			return nil, fmt.Errorf("line %d: inconsistent indentation", lineNo)
+		}
+		parent.children = true
+
+		key, rest, err := splitYAMLKey(content)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		key = joinKey(parent.key, key)
+		if strings.HasPrefix(rest, "#") {
+			rest = ""
+		}
+		if header, _, _ := strings.Cut(rest, " #"); blockHeader.MatchString(header) {
+			value, consumed := yamlBlock(header, indent, lines[i+1:])
+			i += consumed
+			err = s.set(key, value)
+		} else if rest == "" {
+			stack = append(stack, &yamlLevel{indent: indent, key: key, childIndent: -1})
+			continue
+		} else {
+			var value string
+			if value, err = yamlScalar(rest); err == nil {
+				err = s.set(key, value)
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+	}
+	for len(stack) > 1 {
+		if err := pop(); err != nil {
+			return nil, err
+		}
+	}
+	return s.entries, nil
+}
+
+// splitYAMLKey splits a mapping line into its key and the rest of the line.
+func splitYAMLKey(line string) (key, rest string, err error) {
+	switch {
+	case line == "-" || strings.HasPrefix(line, "- "):
+		return "", "", errors.New("lists are not supported")
+	case line[0] == '?':
+		return "", "", errors.New("complex keys are not supported")
+	case line[0] == '"' || line[0] == '\'':
+		key, n, err := yamlQuoted(line)
+		if err != nil {
+			return "", "", err
+		}
+		after := strings.TrimLeft(line[n:], " ")
+		if !strings.HasPrefix(after, ":") || (len(after) > 1 && after[1] != ' ') {
+			return "", "", errors.New(`expected "key: value"`)
+		}
+		return key, strings.TrimSpace(after[1:]), nil
+	}
+	if k, v, ok := strings.Cut(line, ": "); ok {
+		return strings.TrimSpace(k), strings.TrimSpace(v), nil
+	}
+	if strings.HasSuffix(line, ":") {
+		return strings.TrimSpace(line[:len(line)-1]), "", nil
+	}
+	return "", "", errors.New(`expected "key: value"`)
+}
+
+// yamlScalar decodes a single-line value.
+func yamlScalar(s string) (string, error) {
+	switch s[0] {
+	case '"', '\'':
+		value, n, err := yamlQuoted(s)
+		if err != nil {
+			return "", err
+		}
+		if tail := strings.TrimSpace(s[n:]); tail != "" && !strings.HasPrefix(tail, "#") {
+			return "", errors.New("unexpected text after a quoted value")
+		}
+		return value, nil
+	case '[', '{':
+		return "", errors.New("flow collections are not supported")
+	case '&', '*', '!':
+		return "", errors.New("anchors, aliases and tags are not supported")
+	}
+	if i := strings.Index(s, " #"); i >= 0 {
+		s = strings.TrimSpace(s[:i])
+	}
+	switch s {
+	case "~", "null", "Null", "NULL":
+		return "", nil
+	}
+	return s, nil
+}
+
+// yamlQuoted decodes the quoted scalar s starts with and returns how many
+// bytes it spans. Quoted scalars must close on the same line.
+func yamlQuoted(s string) (value string, n int, err error) {
+	if s[0] == '"' {
+		end := closingQuote(s[1:], '"')
+		if end < 0 {
+			return "", 0, errors.New("unterminated double-quoted value")
+		}
+		value, err := strconv.Unquote(s[:end+2])
+		if err != nil {
+			return "", 0, fmt.Errorf("invalid escape in %s", s[:end+2])
+		}
+		return value, end + 2, nil
+	}
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		if s[i] != '\'' {
+			b.WriteByte(s[i])
+			continue
+		}
+		if i+1 < len(s) && s[i+1] == '\'' {
+			b.WriteByte('\'')
+			i++
+			continue
+		}
+		return b.String(), i + 1, nil
+	}
+	return "", 0, errors.New("unterminated single-quoted value")
+}
+
+// yamlBlock reads a literal or folded block scalar from the lines after its
+// header, which belong to it while blank or indented past indent. It returns
+// the value and how many lines it used.
+func yamlBlock(header string, indent int, lines []string) (string, int) {
+	var block []string
+	blockIndent := -1
+	consumed := 0
+	for _, line := range lines {
+		content := strings.TrimLeft(line, " ")
+		if strings.TrimSpace(line) == "" {
+			block = append(block, "")
+			consumed++
+			continue
+		}
+		n := len(line) - len(content)
+		if blockIndent < 0 {
+			blockIndent = n
+		}
+		if n <= indent || n < blockIndent {
+			break
+		}
+		block = append(block, strings.TrimRight(line[blockIndent:], "\r"))
+		consumed++
+	}
+	trailing := 0
+	for len(block) > 0 && block[len(block)-1] == "" {
+		block = block[:len(block)-1]
+		trailing++
+	}
+	// Trailing blank lines after the block belong to what follows.
+	consumed -= trailing
+
+	var b strings.Builder
+	if header[0] == '|' {
+		b.WriteString(strings.Join(block, "\n"))
+	} else {
+		text := false
+		for _, line := range block {
+			switch {
+			case line == "":
+				b.WriteByte('\n')
+				text = false
+			case text:
+				b.WriteByte(' ')
+				b.WriteString(line)
+			default:
+				b.WriteString(line)
+				text = true
+			}
+		}
+	}
+	value := b.String()
+	switch {
+	case len(block) == 0 || strings.HasSuffix(header, "-"):
+	case strings.HasSuffix(header, "+"):
+		value += strings.Repeat("\n", trailing+1)
+	default:
+		value += "\n"
+	}
+	return value, consumed
+}
@@ -0,0 +1,130 @@
+package dotenv
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDecodeYAML(t *testing.T) {
+	data := `---
+# service settings
+API_KEY: sk-123 # inline comment
+PORT: 8080
+DEBUG: false
+EMPTY:
+NONE: ~
+QUOTED: "a \"b\"\tc # not a comment"
+SINGLE: 'it''s'
+db:
+  host: db.internal
+  credentials:
+    user: app
+
+  pass: "p#ss"
+TLS_CERT: |
+  -----BEGIN-----
+  abc
+
+SUMMARY: >-
+  one
+  two
+
+  three
+LAST: end
+`
+	got, err := Decode([]byte(data), FormatYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Entry{
+		{Key: "API_KEY", Value: "sk-123"},
+		{Key: "PORT", Value: "8080"},
+		{Key: "DEBUG", Value: "false"},
+		{Key: "EMPTY", Value: ""},
+		{Key: "NONE", Value: ""},
+		{Key: "QUOTED", Value: "a \"b\"\tc # not a comment"},
+		{Key: "SINGLE", Value: "it's"},
+		{Key: "db_host", Value: "db.internal"},
+		{Key: "db_credentials_user", Value: "app"},
+		{Key: "db_pass", Value: "p#ss"},
+		{Key: "TLS_CERT", Value: "-----BEGIN-----\nabc\n"},
+		{Key: "SUMMARY", Value: "one two\nthree"},
+		{Key: "LAST", Value: "end"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Decode(yaml) =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestDecodeYAMLUnsupported(t *testing.T) {
+	tests := map[string]string{
+		"list":        "hosts:\n  - a\n  - b\n",
+		"flow":        "hosts: [a, b]\n",
+		"anchor":      "a: &x 1\n",
+		"indentation": "a:\n    b: 1\n  c: 2\n",
+		"tab":         "a:\n\tb: 1\n",
+		"no colon":    "just text\n",
+		"bad name":    "has space: 1\n",
+		"documents":   "a: 1\n---\nb: 2\n",
+	}
+	for name, data := range tests {
+		if _, err := Decode([]byte(data), FormatYAML); err == nil {
+			t.Errorf("%s: Decode(%q) succeeded; want an error", name, data)
+		} else if !strings.HasPrefix(err.Error(), "line ") {
+			t.Errorf("%s: error %q does not name the line", name, err)
+		}
+	}
+}
+
+func TestDecodeJSON(t *testing.T) {
+	data := `{"Z": "last?", "PORT": 8080, "ratio": 1.5, "on": true, "nil": null, "db": {"host": "h", "port": 5432}}`
+	got, err := Decode([]byte(data), FormatJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Entry{
+		{Key: "Z", Value: "last?"},
+		{Key: "PORT", Value: "8080"},
+		{Key: "ratio", Value: "1.5"},
+		{Key: "on", Value: "true"},
+		{Key: "nil", Value: ""},
+		{Key: "db_host", Value: "h"},
+		{Key: "db_port", Value: "5432"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Decode(json) =\n%+v\nwant\n%+v", got, want)
+	}
+
+	for _, bad := range []string{`["a"]`, `{"a": [1]}`, `{"a": 1} {}`, `{"a b": 1}`, `{"a": `} {
+		if _, err := Decode([]byte(bad), FormatJSON); err == nil {
+			t.Errorf("Decode(%s) succeeded; want an error", bad)
+		}
+	}
+}
+
+// TestDecodeRoundTrip pins that Decode reads back what Convert writes.
+func TestDecodeRoundTrip(t *testing.T) {
+	entries := []Entry{{Key: "A", Value: "multi\nline \"q\" 'x' #h"}, {Key: "yes", Value: "true"}, {Key: "E", Value: ""}}
+	for _, format := range []string{FormatDotenv, FormatJSON, FormatYAML} {
+		data, err := Convert(entries, format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := Decode(data, format)
+		if err != nil {
+			t.Fatalf("Decode(%s): %v", format, err)
+		}
+		if !reflect.DeepEqual(got, entries) {
+			t.Errorf("%s round trip = %+v; want %+v", format, got, entries)
+		}
+	}
+}
+
+func TestFormatOf(t *testing.T) {
+	for path, want := range map[string]string{"values.yaml": FormatYAML, "v.YML": FormatYAML, "v.json": FormatJSON, ".env.local": FormatDotenv} {
+		if got := FormatOf(path); got != want {
+			t.Errorf("FormatOf(%s) = %s; want %s", path, got, want)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+// Package dotenv parses dotenv-formatted data into ordered key/value entries.
+//
+// It follows the dialect dotenvx reads and writes: optional `export ` prefix,
+// double-quoted values with \n / \" / \\ escapes (which may span lines),
+// literal single- and back-quoted values, and unquoted values with an inline
+// ` # comment` stripped.
+package dotenv
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Entry is one KEY=VALUE assignment, in file order.
+type Entry struct {
+	Key   string
+	Value string
+}
+
+// keyPattern is the set of variable names dotenvx accepts.
+var keyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+
+// Parse decodes dotenv data. Blank lines, comments and lines without an `=`
+// are skipped; a later assignment of the same key overrides an earlier one
+// (the entry keeps its first position). An invalid key or an unterminated
+// quoted value is an error naming the offending line.
+func Parse(data []byte) ([]Entry, error) {
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	var entries []Entry
+	index := make(map[string]int)
+
+	for i := 0; i < len(lines); i++ {
+		lineNo := i + 1
+		line := strings.TrimSpace(lines[i])
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		eq := strings.IndexByte(line, '=')
+		if eq < 0 {
+			continue
+		}
+		key := strings.TrimSpace(line[:eq])
+		if !keyPattern.MatchString(key) {
+			return nil, fmt.Errorf("line %d: invalid variable name %q", lineNo, key)
+		}
+
+		raw := strings.TrimLeft(line[eq+1:], " \t")
+		value, consumed, err := parseValue(raw, lines[i+1:])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s: %w", lineNo, key, err)
+		}
+		i += consumed
+
+		if pos, ok := index[key]; ok {
+			entries[pos].Value = value
+			continue
+		}
+		index[key] = len(entries)
+		entries = append(entries, Entry{Key: key, Value: value})
+	}
+	return entries, nil
+}
+
+// parseValue decodes the value part of an assignment. rest holds the lines
+// after the current one so a quoted value may continue across them; consumed
+// reports how many of those lines the value used.
+func parseValue(raw string, rest []string) (value string, consumed int, err error) {
+	if raw == "" {
+		return "", 0, nil
+	}
+	quote := raw[0]
+	if quote != '"' && quote != '\'' && quote != '`' {
+		if i := strings.Index(raw, " #"); i >= 0 {
+			raw = raw[:i]
+		}
+		return strings.TrimSpace(raw), 0, nil
+	}
+
+	body := raw[1:]
+	for {
+		if end := closingQuote(body, quote); end >= 0 {
+			body = body[:end]
+			break
+		}
+		if consumed >= len(rest) {
+			return "", 0, fmt.Errorf("unterminated %c-quoted value", quote)
+		}
+		body += "\n" + rest[consumed]
+		consumed++
+	}
+
+	if quote == '"' {
+		body = unescapeDoubleQuoted(body)
+	}
+	return body, consumed, nil
+}
+
+// closingQuote returns the index in s of the first unescaped quote, or -1.
+// Backslash escapes only apply inside double quotes.
+func closingQuote(s string, quote byte) int {
+	for i := 0; i < len(s); i++ {
+		if quote == '"' && s[i] == '\\' {
+			i++
+			continue
+		}
+		if s[i] == quote {
+			return i
+		}
+	}
+	return -1
+}
+
+// unescapeDoubleQuoted expands the escapes dotenvx honors in double-quoted
+// values; an unknown escape is kept verbatim.
+func unescapeDoubleQuoted(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		case '"', '\\':
+			b.WriteByte(s[i])
+		default:
+			b.WriteByte('\\')
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+// Map returns entries as a map keyed by variable name.
+func Map(entries []Entry) map[string]string {
+	m := make(map[string]string, len(entries))
+	for _, e := range entries {
+		m[e.Key] = e.Value
+	}
+	return m
+}
+
+// Marshal renders entries as dotenv text, one double-quoted assignment per
+// line, escaped so that Parse reads back the identical values.
+func Marshal(entries []Entry) []byte {
+	var b strings.Builder
+	for _, e := range entries {
+		b.WriteString(e.Key)
+		b.WriteString(`="`)
+		b.WriteString(escapeDoubleQuoted(e.Value))
+		b.WriteString("\"\n")
+	}
+	return []byte(b.String())
+}
+
+// escapeDoubleQuoted is the inverse of unescapeDoubleQuoted.
+func escapeDoubleQuoted(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`)
+	return r.Replace(s)
+}
@@ -0,0 +1,98 @@
+// Package dotenv tests
+package dotenv
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	input := strings.Join([]string{
+		"# header comment",
+		"",
+		"PLAIN=value",
+		"export EXPORTED=yes",
+		`DOUBLE="a \"quoted\" value\nwith newline"`,
+		`SINGLE='literal \n kept'`,
+		"INLINE=bare # trailing comment",
+		"EMPTY=",
+		`MULTI="first`,
+		`second"`,
+		"not an assignment",
+		"PLAIN=override",
+	}, "\n")
+
+	entries, err := Parse([]byte(input))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	want := []Entry{
+		{"PLAIN", "override"},
+		{"EXPORTED", "yes"},
+		{"DOUBLE", "a \"quoted\" value\nwith newline"},
+		{"SINGLE", `literal \n kept`},
+		{"INLINE", "bare"},
+		{"EMPTY", ""},
+		{"MULTI", "first\nsecond"},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("got %d entries %v, want %d", len(entries), entries, len(want))
+	}
+	for i := range want {
+		if entries[i] != want[i] {
+			t.Errorf("entry %d = %+v, want %+v", i, entries[i], want[i])
+		}
+	}
+}
+
+func TestParseCRLF(t *testing.T) {
+	entries, err := Parse([]byte("A=1\r\nB=\"2\"\r\n"))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	m := Map(entries)
+	if m["A"] != "1" || m["B"] != "2" {
+		t.Errorf("CRLF input parsed as %v", m)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"invalid key", "1BAD=x", "line 1: invalid variable name"},
+		{"unterminated", "OK=1\nKEY=\"never closed", "line 2: KEY: unterminated"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.input))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Parse(%q) error = %v, want containing %q", tt.input, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarshalRoundTrip(t *testing.T) {
+	entries := []Entry{
+		{"A", "plain"},
+		{"B", "has \"quotes\" and \\ backslash"},
+		{"C", "line1\nline2"},
+		{"D", ""},
+	}
+	got, err := Parse(Marshal(entries))
+	if err != nil {
+		t.Fatalf("Parse(Marshal): %v", err)
+	}
+	if len(got) != len(entries) {
+		t.Fatalf("round trip lost entries: %v", got)
+	}
+	for i := range entries {
+		if got[i] != entries[i] {
+			t.Errorf("round trip entry %d = %+v, want %+v", i, got[i], entries[i])
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package encrypt
+
+import (
+	"os"
+	"path"
+	"strings"
+	"sync"
+)
+
+// Some variables, such as LOG_LEVEL or FEATURE_FLAGS, are meant to stay
+// plaintext. Their names or globs are set once from [guardian]
+// plaintext_allowed: such a variable's plaintext value does not make a file
+// plaintext or partially encrypted, and EncryptSilentContext puts it back
+// after `envdrift encrypt` has encrypted it.
+
+var (
+	allowedMu sync.RWMutex
+	allowed   []string
+)
+
+// SetPlaintextAllowed sets the variable names and globs (path.Match syntax)
+// that may stay plaintext; nil allows none.
+func SetPlaintextAllowed(patterns []string) {
+	allowedMu.Lock()
+	defer allowedMu.Unlock()
+	allowed = append([]string(nil), patterns...)
+}
+
+// PlaintextAllowed reports whether the variable key may stay plaintext.
+func PlaintextAllowed(key string) bool {
+	key = lineKey(key)
+	allowedMu.RLock()
+	defer allowedMu.RUnlock()
+	for _, p := range allowed {
+		if ok, _ := path.Match(p, key); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// allowedValues returns the raw text after the `=` of each allowed variable
+// assigned a plaintext value in data.
+func allowedValues(data []byte) map[string]string {
+	values := make(map[string]string)
+	for _, line := range strings.Split(string(data), "\n") {
+		body := strings.TrimRight(line, "\r")
+		eq := strings.IndexByte(body, '=')
+		if eq < 0 || strings.HasPrefix(strings.TrimSpace(body), "#") || !PlaintextAllowed(body[:eq]) {
+			continue
+		}
+		if v := unquoteValue(body[eq+1:]); v != "" && ciphertextProvider(v) == "" {
+			values[lineKey(body[:eq])] = body[eq+1:]
+		}
+	}
+	return values
+}
+
+// restoreAllowed gives the allowed variables the file at path had in
+// plaintext before it was encrypted, before, their plaintext back. It leaves
+// a SOPS file alone: its MAC covers every value.
+func restoreAllowed(path string, before []byte) error {
+	values := allowedValues(before)
+	if len(values) == 0 {
+		return nil
+	}
+	after, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if v, err := scanValues(strings.NewReader(string(after))); err != nil || v.provider != ProviderDotenvx {
+		return err
+	}
+	lines := strings.SplitAfter(string(after), "\n")
+	n := 0
+	for i, line := range lines {
+		body := strings.TrimRight(line, "\r\n")
+		eq := strings.IndexByte(body, '=')
+		if eq < 0 {
+			continue
+		}
+		raw, ok := values[lineKey(body[:eq])]
+		if !ok || ciphertextProvider(unquoteValue(body[eq+1:])) != ProviderDotenvx {
+			continue
+		}
+		lines[i] = body[:eq+1] + raw + line[len(body):]
+		n++
+	}
+	if n == 0 {
+		return nil
+	}
+	return writeInPlace(path, []byte(strings.Join(lines, "")))
+}
+
+// lineKey is the variable name on the left of an assignment's `=`.
+func lineKey(s string) string {
+	return strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(s), "export "))
+}
@@ -0,0 +1,108 @@
+package encrypt
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func allowPlaintext(t *testing.T, patterns ...string) {
+	t.Helper()
+	SetPlaintextAllowed(patterns)
+	t.Cleanup(func() { SetPlaintextAllowed(nil) })
+}
+
+func TestClassifyPlaintextAllowed(t *testing.T) {
+	allowPlaintext(t, "LOG_LEVEL", "FEATURE_*")
+	tests := []struct {
+		name, content string
+		want          FileState
+	}{
+		{"allowed left plaintext", "SECRET=\"encrypted:BDx\"\nLOG_LEVEL=debug\nFEATURE_X=on\n", FileState{Encrypted, ProviderDotenvx}},
+		{"export", "SECRET=\"encrypted:BDx\"\nexport LOG_LEVEL=debug\n", FileState{Encrypted, ProviderDotenvx}},
+		{"secret added", "SECRET=\"encrypted:BDx\"\nLOG_LEVEL=debug\nTOKEN=abc\n", FileState{Partial, ProviderDotenvx}},
+		{"only allowed", "LOG_LEVEL=debug\nFEATURE_X=on\n", FileState{State: Encrypted}},
+		{"glob is whole-name", "SECRET=\"encrypted:BDx\"\nMY_FEATURE_X=on\n", FileState{Partial, ProviderDotenvx}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), ".env")
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			got, err := Classify(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Classify = %+v (%s), want %+v (%s)", got, got.State, tt.want, tt.want.State)
+			}
+			if enc, _ := IsEncrypted(path); enc != (tt.want.State == Encrypted) {
+				t.Errorf("IsEncrypted = %v", enc)
+			}
+		})
+	}
+}
+
+// TestRestoreAllowed checks that encrypting leaves the allowed variables as
+// they were written, and that incremental encryption still applies to the
+// rest.
+func TestRestoreAllowed(t *testing.T) {
+	allowPlaintext(t, "LOG_LEVEL", "FEATURE_*")
+	path := incrementalEnv(t)
+	publicKey := "pk-1"
+	dotenvx := fakeDotenvx(&publicKey)
+	encryptFn := func(ctx context.Context, path string) error {
+		before, _ := os.ReadFile(path)
+		if err := dotenvx(ctx, path); err != nil {
+			return err
+		}
+		return restoreAllowed(path, before)
+	}
+
+	if err := os.WriteFile(path, []byte("A=1\nLOG_LEVEL=\"debug\" # verbose\nFEATURE_X=on\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := EncryptIncremental(context.Background(), path, encryptFn); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	for _, want := range []string{"LOG_LEVEL=\"debug\" # verbose\n", "FEATURE_X=on\n"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("encrypted file lacks %q:\n%s", want, data)
+		}
+	}
+	first := readValues(t, path)
+	if !strings.HasPrefix(first["A"], "encrypted:") {
+		t.Errorf("A = %q; want ciphertext", first["A"])
+	}
+	if enc, _ := IsEncrypted(path); !enc {
+		t.Error("IsEncrypted = false with only allowed values in plaintext")
+	}
+
+	decryptTo(t, path, publicKey, "A=1\nB=2\nLOG_LEVEL=info\n")
+	kept, err := EncryptIncremental(context.Background(), path, encryptFn)
+	if err != nil || kept != 1 {
+		t.Fatalf("EncryptIncremental = %d, %v; want A's ciphertext kept", kept, err)
+	}
+	if v := readValues(t, path); v["A"] != first["A"] || v["LOG_LEVEL"] != "info" {
+		t.Errorf("values = %v", v)
+	}
+}
+
+func TestRestoreAllowedSkipsSOPS(t *testing.T) {
+	allowPlaintext(t, "LOG_LEVEL")
+	path := filepath.Join(t.TempDir(), ".env")
+	sops := "LOG_LEVEL=ENC[AES256_GCM,data:x,type:str]\nsops_version=3.9.0\n"
+	if err := os.WriteFile(path, []byte(sops), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := restoreAllowed(path, []byte("LOG_LEVEL=debug\n")); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != sops {
+		t.Errorf("SOPS file rewritten:\n%s", data)
+	}
+}
@@ -0,0 +1,101 @@
+package encrypt
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// State is what Classify found in an env file.
+type State int
+
+const (
+	// Plaintext has no ciphertext value.
+	Plaintext State = iota
+	// Encrypted has ciphertext and no plaintext secret value, or only values
+	// allowed to stay plaintext: what IsEncrypted reports true for.
+	Encrypted
+	// Partial mixes ciphertext with plaintext secret values, e.g. after a
+	// new variable was appended to an encrypted file.
+	Partial
+	// Template is a plaintext file whose name marks it as meant to be
+	// committed as is (.env.example, .env.sample, .env.template, .env.dist).
+	Template
+	// Binary is not text: it has NUL bytes or invalid UTF-8.
+	Binary
+)
+
+// String returns the state's name, e.g. "partial".
+func (s State) String() string {
+	switch s {
+	case Encrypted:
+		return "encrypted"
+	case Partial:
+		return "partial"
+	case Template:
+		return "template"
+	case Binary:
+		return "binary"
+	}
+	return "plaintext"
+}
+
+// Providers of ciphertext values.
+const (
+	ProviderDotenvx = "dotenvx"
+	ProviderSOPS    = "sops"
+)
+
+// FileState classifies an env file.
+type FileState struct {
+	State State
+	// Provider made the file's first ciphertext value (ProviderDotenvx or
+	// ProviderSOPS); "" when it has none.
+	Provider string
+}
+
+// templateWords mark an env file name as a template.
+var templateWords = []string{"example", "sample", "template", "dist"}
+
+// IsTemplate reports whether path's name marks it as a template meant to be
+// committed in plaintext.
+func IsTemplate(path string) bool {
+	base := strings.ToLower(filepath.Base(path))
+	for _, w := range templateWords {
+		if strings.Contains(base, w) {
+			return true
+		}
+	}
+	return false
+}
+
+// Classify reads the env file at path and reports its state with the
+// finer grain IsEncrypted folds into a bool. A template that holds
+// ciphertext is classified by its values, not its name.
+func Classify(path string) (FileState, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return FileState{}, err
+	}
+	if bytes.IndexByte(data, 0) >= 0 || !utf8.Valid(data) {
+		return FileState{State: Binary}, nil
+	}
+	v, err := scanValues(bytes.NewReader(data))
+	if err != nil {
+		return FileState{}, err
+	}
+	fs := FileState{Provider: v.provider}
+	switch {
+	case v.ciphertext > 0 && v.plaintext > 0:
+		fs.State = Partial
+	case v.encrypted():
+		fs.State = Encrypted
+	case IsTemplate(path):
+		fs.State = Template
+	default:
+		fs.State = Plaintext
+	}
+	return fs, nil
+}
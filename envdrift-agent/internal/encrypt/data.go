@@ -0,0 +1,55 @@
+package encrypt
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// EncryptData encrypts the dotenv data as the env file target and returns
+// the ciphertext; target itself is not written. dotenvx picks the
+// environment's key by file name, so it encrypts a copy named like target in
+// a private temporary directory — memory-backed /dev/shm where Linux has it
+// — that is removed as soon as dotenvx returns. The key comes from target's
+// sibling .env.keys, where dotenvx adds one for a new environment. Output
+// that still holds a plaintext value is an error.
+func EncryptData(ctx context.Context, target string, data []byte) ([]byte, error) {
+	dir, err := os.MkdirTemp(scratchDir(), "envdrift-encrypt-")
+	if err != nil {
+		if dir, err = os.MkdirTemp("", "envdrift-encrypt-"); err != nil {
+			return nil, err
+		}
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, filepath.Base(target))
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return nil, err
+	}
+	out, err := runDotenvxKeys(ctx, path, filepath.Join(filepath.Dir(target), ".env.keys"), nil, "encrypt", "--stdout")
+	if err != nil {
+		return nil, err
+	}
+	counts, err := scanValues(bytes.NewReader(out))
+	if err != nil {
+		return nil, err
+	}
+	if counts.plaintext > 0 {
+		return nil, fmt.Errorf("dotenvx left %d values of %s unencrypted", counts.plaintext, filepath.Base(target))
+	}
+	return out, nil
+}
+
+// scratchDir is where EncryptData puts its copy: /dev/shm on Linux, which
+// never reaches the disk, otherwise the system temporary directory.
+func scratchDir() string {
+	if runtime.GOOS == "linux" {
+		if info, err := os.Stat("/dev/shm"); err == nil && info.IsDir() {
+			return "/dev/shm"
+		}
+	}
+	return ""
+}
@@ -0,0 +1,53 @@
+package encrypt
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestEncryptData pins that dotenvx encrypts a copy named like the target,
+// with the target's .env.keys, and that the copy is gone afterwards.
+func TestEncryptData(t *testing.T) {
+	dir := t.TempDir()
+	argsFile := filepath.Join(dir, "args")
+	// Echoes the copy dotenvx was given with each value "encrypted".
+	writeFakeExe(t, dir, "dotenvx", `echo "$PWD $@" > "`+argsFile+`"
+while IFS='=' read -r k v; do printf '%s="encrypted:%s"\n' "$k" "$k"; done < "$4"`)
+	t.Setenv("PATH", dir)
+	target := filepath.Join(t.TempDir(), ".env.production")
+
+	out, err := EncryptData(context.Background(), target, []byte("API_KEY=sk-live\nDB=x\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "API_KEY=\"encrypted:API_KEY\"\nDB=\"encrypted:DB\"\n"; string(out) != want {
+		t.Errorf("EncryptData = %q; want %q", out, want)
+	}
+	args, err := os.ReadFile(argsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scratch, rest, _ := strings.Cut(strings.TrimSpace(string(args)), " ")
+	want := "encrypt --stdout -f .env.production -fk " + filepath.Join(filepath.Dir(target), ".env.keys")
+	if rest != want {
+		t.Errorf("dotenvx args = %q; want %q", rest, want)
+	}
+	if _, err := os.Stat(scratch); !os.IsNotExist(err) {
+		t.Errorf("scratch directory %s left behind: %v", scratch, err)
+	}
+	if _, err := os.Stat(target); !os.IsNotExist(err) {
+		t.Errorf("EncryptData wrote the target: %v", err)
+	}
+}
+
+// TestEncryptDataRejectsPlaintextOutput guards the fail-closed rule: output
+// dotenvx did not fully encrypt is never returned.
+func TestEncryptDataRejectsPlaintextOutput(t *testing.T) {
+	installFakeDotenvx(t, `API_KEY="sk-live"`)
+	if _, err := EncryptData(context.Background(), filepath.Join(t.TempDir(), ".env"), []byte("API_KEY=sk-live\n")); err == nil {
+		t.Fatal("EncryptData must fail when a value is still plaintext")
+	}
+}
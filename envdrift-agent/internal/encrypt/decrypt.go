@@ -0,0 +1,188 @@
+package encrypt
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/jainal09/envdrift-agent/internal/dotenv"
+	"github.com/jainal09/envdrift-agent/internal/longpath"
+)
+
+// ErrDotenvxNotFound is returned when the dotenvx binary cannot be located.
+var ErrDotenvxNotFound = errors.New("dotenvx not found. Install it: pip install envdrift && envdrift install dotenvx")
+
+// DecryptEntries decrypts the dotenv file at path in memory and returns its
+// variables in file order. Nothing is written to disk: dotenvx runs with
+// --stdout and its output is parsed here.
+//
+// env is appended to the inherited environment of the dotenvx child (e.g. a
+// DOTENV_PRIVATE_KEY_<ENV> resolved from somewhere other than .env.keys).
+// dotenvx's plaintext DOTENV_PUBLIC_KEY* entries are dropped. A value that is
+// still ciphertext after decryption (dotenvx could not find the private key)
+// is an error rather than being handed to the caller as if it were plaintext.
+func DecryptEntries(ctx context.Context, path string, env []string) ([]dotenv.Entry, error) {
+	out, err := runDotenvx(ctx, path, env, "decrypt", "--stdout")
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := dotenv.Parse(out)
+	if err != nil {
+		return nil, fmt.Errorf("parse decrypted %s: %w", path, err)
+	}
+
+	entries := parsed[:0]
+	for _, e := range parsed {
+		if strings.HasPrefix(e.Key, dotenvxPublicKeyPrefix) {
+			continue
+		}
+		if ciphertextProvider(e.Value) != "" {
+			return nil, fmt.Errorf("decrypt %s: %s is still encrypted (private key not found?)", path, e.Key)
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// DecryptValue decrypts a single variable of the dotenv file at path in
+// memory. It returns an error when the variable is not defined.
+func DecryptValue(ctx context.Context, path, key string, env []string) (string, error) {
+	entries, err := DecryptEntries(ctx, path, env)
+	if err != nil {
+		return "", err
+	}
+	for _, e := range entries {
+		if e.Key == key {
+			return e.Value, nil
+		}
+	}
+	return "", fmt.Errorf("%s is not defined in %s", key, path)
+}
+
+// DecryptInPlace decrypts the dotenv file at path on disk with dotenvx,
+// leaving plaintext in the file. env is passed as for DecryptEntries.
+func DecryptInPlace(ctx context.Context, path string, env []string) error {
+	_, err := runDotenvx(ctx, path, env, "decrypt")
+	return err
+}
+
+// EncryptDotenvx encrypts the dotenv file at path in place with dotenvx,
+// generating its DOTENV_PRIVATE_KEY_<ENV> in the sibling .env.keys when
+// there is none yet. Unlike Encrypt it does not go through the envdrift CLI,
+// so it works for files outside an envdrift project (e.g. during import).
+func EncryptDotenvx(ctx context.Context, path string) error {
+	_, err := runDotenvx(ctx, path, nil, "encrypt")
+	return err
+}
+
+// EncryptDotenvxKeys is EncryptDotenvx with the key store at keysPath, for a
+// file encrypted away from the directory it is meant for.
+func EncryptDotenvxKeys(ctx context.Context, path, keysPath string) error {
+	_, err := runDotenvxKeys(ctx, path, keysPath, nil, "encrypt")
+	return err
+}
+
+// runDotenvx runs `dotenvx <args...> -f <file> -fk <sibling .env.keys>` in the
+// file's directory and returns its stdout. The key store is pinned to the
+// file's sibling .env.keys (dotenvx v2 otherwise looks in the process cwd,
+// mirroring the CLI's #566 fix).
+func runDotenvx(ctx context.Context, path string, env []string, args ...string) ([]byte, error) {
+	return runDotenvxKeys(ctx, path, filepath.Join(filepath.Dir(path), ".env.keys"), env, args...)
+}
+
+// runDotenvxKeys is runDotenvx with the key store at keysPath.
+func runDotenvxKeys(ctx context.Context, path, keysPath string, env []string, args ...string) ([]byte, error) {
+	dotenvx, err := findDotenvx()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+
+	// Past MAX_PATH on Windows the command runs from an ancestor directory,
+	// so both files are named by absolute path (longpath.WorkDir).
+	dir, name := longpath.WorkDir(path)
+	keysFile := filepath.Base(keysPath)
+	if name != filepath.Base(path) || filepath.Dir(keysPath) != filepath.Dir(path) {
+		keysFile = longpath.Extended(keysPath)
+	}
+	args = append(args, "-f", dashSafe(name), "-fk", dashSafe(keysFile))
+	cmd := exec.CommandContext(ctx, dotenvx, args...)
+	cmd.Dir = dir
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			return nil, fmt.Errorf("dotenvx %s %s: %w", args[0], path, err)
+		}
+		return nil, fmt.Errorf("dotenvx %s %s: %w: %s", args[0], path, err, msg)
+	}
+	return stdout.Bytes(), nil
+}
+
+// dashSafe prefixes a leading-dash file name with ./ so dotenvx's argument
+// parser does not read it as a flag (the CLI's _dash_safe_path, #474).
+func dashSafe(name string) string {
+	if strings.HasPrefix(name, "-") {
+		return "." + string(filepath.Separator) + name
+	}
+	return name
+}
+
+// IsDotenvxAvailable reports whether the dotenvx binary can be found.
+func IsDotenvxAvailable() bool {
+	_, err := findDotenvx()
+	return err == nil
+}
+
+// findDotenvx locates the dotenvx binary: PATH first, then the user bin
+// directory `envdrift install dotenvx` falls back to outside a virtualenv.
+func findDotenvx() (string, error) {
+	name := "dotenvx"
+	if runtime.GOOS == "windows" {
+		name = "dotenvx.exe"
+	}
+	if p, err := exec.LookPath(name); err == nil {
+		return p, nil
+	}
+
+	if home, err := os.UserHomeDir(); err == nil {
+		candidate := filepath.Join(home, ".local", "bin", name)
+		if runtime.GOOS == "windows" {
+			candidate = filepath.Join(os.Getenv("APPDATA"), "Python", "Scripts", name)
+		}
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate, nil
+		}
+	}
+	return "", ErrDotenvxNotFound
+}
+
+// DotenvxVersion returns the dotenvx binary in use and the version it
+// reports (`dotenvx --version`).
+func DotenvxVersion(ctx context.Context) (path, version string, err error) {
+	path, err = findDotenvx()
+	if err != nil {
+		return "", "", err
+	}
+	out, err := exec.CommandContext(ctx, path, "--version").Output()
+	if err != nil {
+		return path, "", fmt.Errorf("dotenvx --version: %w", err)
+	}
+	return path, strings.TrimSpace(string(out)), nil
+}
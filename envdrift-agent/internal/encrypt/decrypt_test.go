@@ -0,0 +1,112 @@
+// Package encrypt tests for in-memory decryption via dotenvx.
+package encrypt
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// installFakeDotenvx puts a fake `dotenvx` on PATH whose `decrypt --stdout`
+// prints output and records its argv in the returned file.
+func installFakeDotenvx(t *testing.T, output string) (argsFile string) {
+	t.Helper()
+	dir := t.TempDir()
+	argsFile = filepath.Join(dir, "args")
+	outFile := filepath.Join(dir, "output")
+	if err := os.WriteFile(outFile, []byte(output+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// PATH holds only dir, so the script sticks to shell builtins.
+	writeFakeExe(t, dir, "dotenvx", `echo "$@" > "`+argsFile+`"
+while IFS= read -r line; do printf '%s\n' "$line"; done < "`+outFile+`"`)
+	t.Setenv("PATH", dir)
+	return argsFile
+}
+
+// writeEnv creates a .env file to decrypt; its content is irrelevant to the
+// fake dotenvx.
+func writeEnv(t *testing.T, name string) string {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(p, []byte("A=\"encrypted:x\"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestDecryptEntries(t *testing.T) {
+	argsFile := installFakeDotenvx(t, `#/---[DOTENV_PUBLIC_KEY]---/
+DOTENV_PUBLIC_KEY="03abc"
+API_KEY="sk-live"
+DB_URL="postgres://x"`)
+	envPath := writeEnv(t, ".env")
+
+	entries, err := DecryptEntries(context.Background(), envPath, nil)
+	if err != nil {
+		t.Fatalf("DecryptEntries: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Key != "API_KEY" || entries[0].Value != "sk-live" || entries[1].Key != "DB_URL" {
+		t.Errorf("unexpected entries (public key must be dropped): %+v", entries)
+	}
+
+	args, err := os.ReadFile(argsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(string(args)); got != "decrypt --stdout -f .env -fk .env.keys" {
+		t.Errorf("dotenvx args = %q", got)
+	}
+}
+
+// TestDecryptEntries_StillEncryptedFails guards the fail-closed rule: a value
+// dotenvx could not decrypt must never be returned as if it were plaintext.
+func TestDecryptEntries_StillEncryptedFails(t *testing.T) {
+	installFakeDotenvx(t, `API_KEY="encrypted:BDb7..."`)
+	envPath := writeEnv(t, ".env")
+
+	_, err := DecryptEntries(context.Background(), envPath, nil)
+	if err == nil || !strings.Contains(err.Error(), "still encrypted") {
+		t.Fatalf("expected still-encrypted error, got %v", err)
+	}
+}
+
+func TestDecryptEntries_DashSafeFileName(t *testing.T) {
+	argsFile := installFakeDotenvx(t, `A="1"`)
+	envPath := writeEnv(t, "-weird.env")
+
+	if _, err := DecryptEntries(context.Background(), envPath, nil); err != nil {
+		t.Fatalf("DecryptEntries: %v", err)
+	}
+	args, _ := os.ReadFile(argsFile)
+	if !strings.Contains(string(args), "-f ./-weird.env") {
+		t.Errorf("leading-dash file must be ./-prefixed, got args %q", args)
+	}
+}
+
+func TestDecryptValue(t *testing.T) {
+	installFakeDotenvx(t, `A="1"
+B="two"`)
+	envPath := writeEnv(t, ".env")
+
+	got, err := DecryptValue(context.Background(), envPath, "B", nil)
+	if err != nil || got != "two" {
+		t.Fatalf("DecryptValue(B) = %q, %v", got, err)
+	}
+	if _, err := DecryptValue(context.Background(), envPath, "MISSING", nil); err == nil {
+		t.Error("DecryptValue of an undefined key should fail")
+	}
+}
+
+func TestDecryptEntries_DotenvxMissing(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("APPDATA", t.TempDir())
+	envPath := writeEnv(t, ".env")
+
+	if _, err := DecryptEntries(context.Background(), envPath, nil); err != ErrDotenvxNotFound {
+		t.Errorf("expected ErrDotenvxNotFound, got %v", err)
+	}
+}
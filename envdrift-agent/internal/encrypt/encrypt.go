@@ -6,11 +6,15 @@ import (
 	"bufio"
 	"context"
 	"errors"
+	"fmt"
+	"io"
 	"os"
 	"os/exec"
-	"path/filepath"
 	"regexp"
 	"strings"
+
+	"github.com/jainal09/envdrift-agent/internal/dotenv"
+	"github.com/jainal09/envdrift-agent/internal/longpath"
 )
 
 // ErrEnvdriftNotFound is returned when envdrift CLI is not installed.
@@ -59,7 +63,9 @@ var sopsMetadataScalarKeys = map[string]struct{}{
 var sopsMetadataGroupKey = regexp.MustCompile(`^sops_(?:age|pgp|kms|gcp_kms|azure_kv|hc_vault)(?:_|__|$)`)
 
 // IsEncrypted reports whether a .env file is FULLY encrypted: at least one
-// assigned value is ciphertext and no plaintext secret value remains.
+// assigned value is ciphertext and no plaintext secret value remains. A file
+// whose values are all allowed to stay plaintext (see SetPlaintextAllowed)
+// has nothing to encrypt and counts as encrypted too.
 //
 // The pre-#481 predicate returned true as soon as ANY value was encrypted, so a
 // mixed-state file — encrypted values plus a freshly added plaintext secret —
@@ -79,8 +85,35 @@ func IsEncrypted(path string) (encrypted bool, err error) {
 		}
 	}()
 
-	sawCiphertext := false
-	scanner := bufio.NewScanner(file)
+	v, err := scanValues(file)
+	if err != nil {
+		return false, err
+	}
+	return v.encrypted(), nil
+}
+
+// valueCounts is what scanValues found in an env file.
+type valueCounts struct {
+	ciphertext, plaintext int
+	// allowed counts the plaintext values of variables allowed to stay
+	// plaintext; they are not in plaintext.
+	allowed int
+	// provider made the first ciphertext value: ProviderDotenvx or
+	// ProviderSOPS.
+	provider string
+}
+
+// encrypted reports whether the file v counts holds no plaintext secret
+// value and some ciphertext, or only values allowed to stay plaintext.
+func (v valueCounts) encrypted() bool {
+	return v.plaintext == 0 && (v.ciphertext > 0 || v.allowed > 0)
+}
+
+// scanValues counts the ciphertext and plaintext secret values of the
+// assignments read from r.
+func scanValues(r io.Reader) (valueCounts, error) {
+	var v valueCounts
+	scanner := bufio.NewScanner(r)
 	for scanner.Scan() {
 		line := strings.TrimSpace(scanner.Text())
 		if line == "" || strings.HasPrefix(line, "#") {
@@ -103,26 +136,34 @@ func IsEncrypted(path string) (encrypted bool, err error) {
 			// Empty assignment (KEY=, KEY="", KEY='') carries no secret.
 			continue
 		}
-		if isCiphertextValue(value) {
-			sawCiphertext = true
+		if provider := ciphertextProvider(value); provider != "" {
+			if v.provider == "" {
+				v.provider = provider
+			}
+			v.ciphertext++
+			continue
+		}
+		if PlaintextAllowed(key) {
+			v.allowed++
 			continue
 		}
 		// A plaintext secret value: the file is not fully encrypted.
-		return false, nil
-	}
-	if serr := scanner.Err(); serr != nil {
-		return false, serr
+		v.plaintext++
 	}
-
-	return sawCiphertext, nil
+	return v, scanner.Err()
 }
 
-// isCiphertextValue reports whether an unquoted assigned value is genuine
-// ciphertext (dotenvx or SOPS format).
-func isCiphertextValue(value string) bool {
+// ciphertextProvider returns the provider whose ciphertext an unquoted
+// assigned value is (ProviderDotenvx or ProviderSOPS), or "" for plaintext.
+func ciphertextProvider(value string) string {
+	switch {
 	// dotenvx prefix match stays case-insensitive (pre-existing behavior).
-	return strings.HasPrefix(strings.ToLower(value), dotenvxCiphertextPrefix) ||
-		strings.HasPrefix(value, sopsCiphertextPrefix)
+	case strings.HasPrefix(strings.ToLower(value), dotenvxCiphertextPrefix):
+		return ProviderDotenvx
+	case strings.HasPrefix(value, sopsCiphertextPrefix):
+		return ProviderSOPS
+	}
+	return ""
 }
 
 // isSOPSMetadataKey reports whether key belongs to the exact SOPS metadata
@@ -205,12 +246,22 @@ func EncryptSilent(path string) error {
 // is killed and Run returns instead of blocking forever. Pre-#494 the
 // subprocess had no context or timeout, so one hung child wedged the
 // guardian's entire control loop (shutdown and event processing included).
+//
+// The variables allowed to stay plaintext (see SetPlaintextAllowed) get
+// their plaintext back afterwards.
 func EncryptSilentContext(ctx context.Context, path string) error {
 	cmd, err := buildEncryptCommandContext(ctx, path)
 	if err != nil {
 		return err
 	}
-	return cmd.Run()
+	before, readErr := os.ReadFile(path)
+	if err := cmd.Run(); err != nil {
+		return err
+	}
+	if readErr != nil {
+		return nil
+	}
+	return restoreAllowed(path, before)
 }
 
 // IsEnvdriftAvailable checks if envdrift CLI is available.
@@ -232,9 +283,16 @@ func buildEncryptCommand(path string) (*exec.Cmd, error) {
 // ENV_FILE argument. The pre-#481 code invoked `envdrift lock <file>`, but
 // `lock` takes no positional argument — every invocation exited 2 with
 // "Got unexpected extra argument(s)" and no file was ever encrypted.
+//
+// The command runs in the file's directory (so the CLI finds envdrift.toml
+// from there) on the base name; a Windows directory past MAX_PATH cannot be a
+// working directory, so there it runs from the nearest ancestor that can, on
+// the extended-length path (see longpath.WorkDir).
 func buildEncryptCommandContext(ctx context.Context, path string) (*exec.Cmd, error) {
-	dir := filepath.Dir(path)
-	fileName := filepath.Base(path)
+	dir, fileName := longpath.WorkDir(path)
+	// The name is a single argv entry, never shell text, so spaces, quotes and
+	// Unicode pass through as-is; only a leading dash needs care.
+	fileName = dashSafe(fileName)
 
 	envdrift, isPython, err := findEnvdrift(ctx)
 	if err != nil {
@@ -287,3 +345,46 @@ func findEnvdrift(ctx context.Context) (path string, isPython bool, err error) {
 
 	return "", false, exec.ErrNotFound
 }
+
+// KeyNames returns the variable names assigned in the dotenv file at path, in
+// file order, without dotenvx's public key or SOPS's metadata. Names stay
+// readable in an encrypted file, so no decryption is needed.
+func KeyNames(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := dotenv.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	var names []string
+	for _, e := range entries {
+		if strings.HasPrefix(e.Key, dotenvxPublicKeyPrefix) || isSOPSMetadataKey(e.Key) {
+			continue
+		}
+		names = append(names, e.Key)
+	}
+	return names, nil
+}
+
+// PlaintextValues returns the assignments in the dotenv file at path whose
+// values are plaintext secrets: not ciphertext, not empty, not dotenvx's
+// public key or SOPS's metadata, and not allowed to stay plaintext.
+func PlaintextValues(path string) ([]dotenv.Entry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := dotenv.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	var values []dotenv.Entry
+	for _, e := range entries {
+		if isSecretValue(e) && ciphertextProvider(e.Value) == "" {
+			values = append(values, e)
+		}
+	}
+	return values, nil
+}
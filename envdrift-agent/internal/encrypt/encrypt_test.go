@@ -225,6 +225,41 @@ func TestIsEncryptedMissingFile(t *testing.T) {
 	}
 }
 
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name, file, content string
+		want                FileState
+	}{
+		{"plaintext", ".env", "SECRET=plain\n", FileState{State: Plaintext}},
+		{"empty", ".env", "# nothing yet\nEMPTY=\n", FileState{State: Plaintext}},
+		{"dotenvx", ".env", "DOTENV_PUBLIC_KEY=\"03ab\"\nSECRET=\"encrypted:BDx\"\n", FileState{Encrypted, ProviderDotenvx}},
+		{"sops", ".env", "SECRET=\"ENC[AES256_GCM,data:abc,type:str]\"\nsops_version=3.9.0\n", FileState{Encrypted, ProviderSOPS}},
+		{"partial", ".env", "SECRET=\"encrypted:BDx\"\nADDED=plain\n", FileState{Partial, ProviderDotenvx}},
+		{"template", ".env.example", "SECRET=changeme\n", FileState{State: Template}},
+		{"encrypted template", ".env.example", "SECRET=\"encrypted:BDx\"\n", FileState{Encrypted, ProviderDotenvx}},
+		{"nul byte", ".env", "SECRET=a\x00b\n", FileState{State: Binary}},
+		{"invalid utf-8", ".env", "SECRET=\xff\xfe\n", FileState{State: Binary}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			got, err := Classify(path)
+			if err != nil {
+				t.Fatalf("Classify: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Classify = %+v (%s), want %+v (%s)", got, got.State, tt.want, tt.want.State)
+			}
+		})
+	}
+	if _, err := Classify("/nonexistent/path/.env"); err == nil {
+		t.Error("Classify of a missing file must fail")
+	}
+}
+
 func TestIsEnvdriftAvailable(t *testing.T) {
 	// This test just ensures the function doesn't panic
 	// Result depends on whether envdrift is installed
@@ -534,3 +569,68 @@ func TestEncryptSilentContext_SucceedsWithinDeadline(t *testing.T) {
 		t.Fatalf("EncryptSilentContext: %v", err)
 	}
 }
+
+// TestEncryptSilentContext_HostileFileNames runs a fake envdrift on env files
+// whose names carry shell metacharacters, quotes, a leading dash and
+// non-ASCII, and checks each arrives as one intact argument in the file's
+// directory.
+func TestEncryptSilentContext_HostileFileNames(t *testing.T) {
+	binDir := t.TempDir()
+	argsFile := filepath.Join(t.TempDir(), "args")
+	// NUL-separated so even a newline in a name would round-trip.
+	writeFakeExe(t, binDir, "envdrift", `{ printf '%s\0' "$PWD"; for a in "$@"; do printf '%s\0' "$a"; done; } > "`+argsFile+`"`)
+	t.Setenv("PATH", binDir)
+
+	for _, name := range []string{
+		".env with spaces",
+		`.env.it's "quoted"`,
+		".env.$(touch pwned)",
+		".env;rm -rf ~",
+		".env.zoë.日本語",
+		".env.line\nbreak",
+		"-rf.env",
+	} {
+		dir := t.TempDir()
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("A=1\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := EncryptSilentContext(context.Background(), path); err != nil {
+			t.Fatalf("%q: %v", name, err)
+		}
+
+		data, err := os.ReadFile(argsFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := strings.Split(strings.TrimSuffix(string(data), "\x00"), "\x00")
+		want := name
+		if strings.HasPrefix(name, "-") {
+			want = "./" + name
+		}
+		if len(got) != 3 || got[1] != "encrypt" || got[2] != want {
+			t.Errorf("%q: argv = %q; want [encrypt %q]", name, got[1:], want)
+		}
+		if real, _ := filepath.EvalSymlinks(dir); got[0] != dir && got[0] != real {
+			t.Errorf("%q: ran in %q; want %q", name, got[0], dir)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "pwned")); err == nil {
+			t.Errorf("%q: file name was executed as shell code", name)
+		}
+	}
+}
+
+func TestKeyNames(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	content := "#/---[DOTENV_PUBLIC_KEY]---/\nDOTENV_PUBLIC_KEY=\"03ab\"\nDB_URL=\"encrypted:abc\"\nexport API_KEY=plain\nsops_version=3.8.1\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	names, err := KeyNames(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(names, ",") != "DB_URL,API_KEY" {
+		t.Errorf("KeyNames = %v; want [DB_URL API_KEY]", names)
+	}
+}
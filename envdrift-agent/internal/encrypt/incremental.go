@@ -0,0 +1,398 @@
+package encrypt
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/jainal09/envdrift-agent/internal/dotenv"
+	"github.com/jainal09/envdrift-agent/internal/paths"
+)
+
+// dotenvx encrypts every value with fresh randomness, so re-encrypting a file
+// after one value changed rewrites the ciphertext of all of them and the git
+// diff shows every line. Incremental encryption remembers, per file, a keyed
+// hash of each value's plaintext next to its ciphertext; after the file is
+// encrypted again it is decrypted, and each value whose plaintext hash still
+// matches gets its old ciphertext back, so only the values that changed
+// differ. Hashing what the new ciphertext decrypts to, rather than what the
+// file held before, and leaving the file alone if it changed while it was
+// being decrypted, means a write racing the encryption is never undone; a
+// file the agent cannot decrypt keeps all its new ciphertext. The hashes are
+// HMAC-SHA-256 under incremental.key in the config directory, kept apart from
+// incremental.json, so a copy of the state alone does not let low-entropy
+// secrets be guessed offline. It applies to dotenvx files only: SOPS
+// authenticates the whole file with one MAC, so its values cannot be swapped
+// one at a time.
+
+// incrementalMu serializes updates of the incremental state within one
+// process.
+var incrementalMu sync.Mutex
+
+// fileValues is what incremental encryption remembers of one file.
+type fileValues struct {
+	// Salt is mixed into every hash so equal values in different files do
+	// not hash alike.
+	Salt string `json:"salt"`
+	// PublicKey is the file's DOTENV_PUBLIC_KEY* value: remembered
+	// ciphertext is put back only while it is unchanged.
+	PublicKey string                 `json:"public_key"`
+	Values    map[string]storedValue `json:"values"`
+}
+
+// storedValue is one variable's plaintext hash and ciphertext.
+type storedValue struct {
+	Hash       string `json:"hash"`
+	Ciphertext string `json:"ciphertext"`
+}
+
+// IncrementalStatePath returns incremental.json in the state directory.
+func IncrementalStatePath() string {
+	return filepath.Join(paths.StateDir(), "incremental.json")
+}
+
+// IncrementalKeyPath returns incremental.key, the key of the hashes in
+// incremental.json, in the config directory.
+func IncrementalKeyPath() string {
+	return filepath.Join(paths.ConfigDir(), "incremental.key")
+}
+
+// incrementalDecrypt decrypts the freshly encrypted file; tests replace it.
+var incrementalDecrypt = DecryptEntries
+
+// EncryptIncremental runs encryptFn on path and then gives every value whose
+// plaintext is unchanged since the file was last encrypted (or decrypted by
+// RememberValues) its earlier ciphertext back. kept is how many values got
+// it back. err is encryptFn's: failing to keep ciphertext is not an error,
+// the file is encrypted either way, only with every value's ciphertext new.
+func EncryptIncremental(ctx context.Context, path string, encryptFn func(context.Context, string) error) (kept int, err error) {
+	if err := encryptFn(ctx, path); err != nil {
+		return 0, err
+	}
+	kept, _ = keepUnchanged(ctx, path)
+	return kept, nil
+}
+
+// RememberValues records the ciphertext of each variable of encrypted, the
+// dotenvx-encrypted content of the file at path, against its plaintext in
+// plain, the same file decrypted. `edit` calls it after decrypting a file
+// in place so the values the edit leaves alone keep their ciphertext.
+func RememberValues(path string, encrypted, plain []byte) error {
+	encEntries, err := dotenv.Parse(encrypted)
+	if err != nil {
+		return err
+	}
+	plainEntries, err := dotenv.Parse(plain)
+	if err != nil {
+		return err
+	}
+	publicKey, ok := dotenvxPublicKey(encEntries)
+	if !ok {
+		return nil
+	}
+	key, err := incrementalKey()
+	if err != nil {
+		return err
+	}
+	salt, err := newSalt()
+	if err != nil {
+		return err
+	}
+	plainValues := dotenv.Map(plainEntries)
+	fv := fileValues{Salt: salt, PublicKey: publicKey, Values: make(map[string]storedValue)}
+	for _, e := range encEntries {
+		p, ok := plainValues[e.Key]
+		if !isSecretValue(e) || !ok || ciphertextProvider(p) != "" {
+			continue
+		}
+		fv.Values[e.Key] = storedValue{Hash: hashValue(key, salt, e.Key, p), Ciphertext: e.Value}
+	}
+	return storeFileValues(path, &fv)
+}
+
+// keepUnchanged puts the remembered ciphertext back into the freshly
+// encrypted file at path for every value that decrypts to the plaintext it
+// was remembered for, then remembers the file as it now is.
+func keepUnchanged(ctx context.Context, path string) (int, error) {
+	after, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	afterEntries, err := dotenv.Parse(after)
+	if err != nil {
+		return 0, err
+	}
+	publicKey, ok := dotenvxPublicKey(afterEntries)
+	if !ok {
+		return 0, storeFileValues(path, nil)
+	}
+	plainEntries, err := incrementalDecrypt(ctx, path, nil)
+	if err != nil {
+		return 0, err
+	}
+	key, err := incrementalKey()
+	if err != nil {
+		return 0, err
+	}
+
+	fv, err := loadFileValues(path)
+	if err != nil {
+		return 0, err
+	}
+	if fv == nil || fv.PublicKey != publicKey {
+		salt, err := newSalt()
+		if err != nil {
+			return 0, err
+		}
+		fv = &fileValues{Salt: salt, PublicKey: publicKey}
+	}
+
+	// Hashes of what each value now decrypts to.
+	hashes := make(map[string]string)
+	for _, e := range plainEntries {
+		if isSecretValue(e) {
+			hashes[e.Key] = hashValue(key, fv.Salt, e.Key, e.Value)
+		}
+	}
+	restore := make(map[string]string)
+	for _, e := range afterEntries {
+		prev, ok := fv.Values[e.Key]
+		if h, known := hashes[e.Key]; known && ok && prev.Hash == h && prev.Ciphertext != e.Value {
+			restore[e.Key] = prev.Ciphertext
+		}
+	}
+
+	// A save while the file was being decrypted must not be overwritten;
+	// the encryption that save triggers remembers the file instead.
+	if now, err := os.ReadFile(path); err != nil || !bytes.Equal(now, after) {
+		return 0, err
+	}
+
+	kept := 0
+	if len(restore) > 0 {
+		data, n := replaceValues(after, restore)
+		if n > 0 {
+			if err := writeInPlace(path, data); err != nil {
+				return 0, err
+			}
+			if afterEntries, err = dotenv.Parse(data); err != nil {
+				return 0, err
+			}
+		}
+		kept = n
+	}
+
+	values := make(map[string]storedValue)
+	for _, e := range afterEntries {
+		if !isSecretValue(e) {
+			continue
+		}
+		if h, ok := hashes[e.Key]; ok {
+			values[e.Key] = storedValue{Hash: h, Ciphertext: e.Value}
+		}
+	}
+	fv.Values = values
+	return kept, storeFileValues(path, fv)
+}
+
+// dotenvxPublicKey returns the public key of a file whose every value is
+// dotenvx ciphertext; ok is false for any other file.
+func dotenvxPublicKey(entries []dotenv.Entry) (publicKey string, ok bool) {
+	for _, e := range entries {
+		switch {
+		case strings.HasPrefix(e.Key, dotenvxPublicKeyPrefix):
+			if publicKey == "" {
+				publicKey = e.Value
+			}
+		case isSecretValue(e) && ciphertextProvider(e.Value) != ProviderDotenvx:
+			return "", false
+		}
+	}
+	return publicKey, publicKey != ""
+}
+
+// isSecretValue reports whether e assigns a value that is encrypted: not a
+// public key, not SOPS metadata, not allowed to stay plaintext and not empty.
+func isSecretValue(e dotenv.Entry) bool {
+	return e.Value != "" && !strings.HasPrefix(e.Key, dotenvxPublicKeyPrefix) && !isSOPSMetadataKey(e.Key) &&
+		!PlaintextAllowed(e.Key)
+}
+
+// hashValue is the HMAC-SHA-256 under key of one variable's plaintext,
+// salted per file.
+func hashValue(key []byte, salt, name, value string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(salt + "\x00" + name + "\x00" + value))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// incrementalKey reads the hex key at IncrementalKeyPath, or writes a new
+// one readable only by the user.
+func incrementalKey() ([]byte, error) {
+	path := IncrementalKeyPath()
+	data, err := os.ReadFile(path)
+	if err == nil {
+		key, err := hex.DecodeString(strings.TrimSpace(string(data)))
+		if err != nil || len(key) != 32 {
+			return nil, fmt.Errorf("%s is not an incremental encryption key", path)
+		}
+		return key, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, []byte(hex.EncodeToString(key)), 0o600); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+func newSalt() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// replaceValues swaps in the ciphertext in values for the quoted dotenvx
+// ciphertext assigned to each of its keys in data, keeping everything else
+// of the line (an `export`, an inline comment, the line ending). n is how
+// many values it replaced.
+func replaceValues(data []byte, values map[string]string) (out []byte, n int) {
+	lines := strings.SplitAfter(string(data), "\n")
+	for i, line := range lines {
+		body := strings.TrimRight(line, "\r\n")
+		ending := line[len(body):]
+		eq := strings.IndexByte(body, '=')
+		if eq < 0 {
+			continue
+		}
+		key := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(body[:eq]), "export "))
+		ciphertext, ok := values[key]
+		if !ok {
+			continue
+		}
+		rest := strings.TrimSpace(body[eq+1:])
+		quoted := stripCommentAfterQuotedValue(rest)
+		if !isWrappedInMatchingQuotes(quoted) || ciphertextProvider(unquoteValue(quoted)) != ProviderDotenvx {
+			continue
+		}
+		lines[i] = body[:eq+1] + `"` + ciphertext + `"` + rest[len(quoted):] + ending
+		n++
+	}
+	return []byte(strings.Join(lines, "")), n
+}
+
+// writeInPlace replaces the file at path with data through a temp file in
+// the same directory, keeping its permissions.
+func writeInPlace(path string, data []byte) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), info.Mode().Perm())
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+	}
+	return err
+}
+
+// loadFileValues returns what is remembered of the file at path, or nil.
+func loadFileValues(path string) (*fileValues, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	incrementalMu.Lock()
+	defer incrementalMu.Unlock()
+	state, err := loadIncremental()
+	if err != nil {
+		return nil, err
+	}
+	fv, ok := state[abs]
+	if !ok {
+		return nil, nil
+	}
+	return &fv, nil
+}
+
+// storeFileValues remembers fv for the file at path; nil forgets it.
+func storeFileValues(path string, fv *fileValues) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	incrementalMu.Lock()
+	defer incrementalMu.Unlock()
+	state, err := loadIncremental()
+	if err != nil {
+		return err
+	}
+	if fv == nil {
+		if _, ok := state[abs]; !ok {
+			return nil
+		}
+		delete(state, abs)
+	} else {
+		state[abs] = *fv
+	}
+	return saveIncremental(state)
+}
+
+func loadIncremental() (map[string]fileValues, error) {
+	state := make(map[string]fileValues)
+	data, err := os.ReadFile(IncrementalStatePath())
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", IncrementalStatePath(), err)
+	}
+	return state, nil
+}
+
+func saveIncremental(state map[string]fileValues) error {
+	path := IncrementalStatePath()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
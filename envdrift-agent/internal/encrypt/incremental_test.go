@@ -0,0 +1,356 @@
+package encrypt
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jainal09/envdrift-agent/internal/dotenv"
+)
+
+// fakeDotenvx returns an encryptFn that encrypts every plaintext value of a
+// file the way dotenvx does: with ciphertext that differs on every run, and
+// a DOTENV_PUBLIC_KEY header holding *publicKey.
+func fakeDotenvx(publicKey *string) func(context.Context, string) error {
+	run := 0
+	return func(_ context.Context, path string) error {
+		run++
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		var b strings.Builder
+		fmt.Fprintf(&b, "DOTENV_PUBLIC_KEY=%q\n", *publicKey)
+		for _, line := range strings.SplitAfter(string(data), "\n") {
+			key, value, ok := strings.Cut(strings.TrimSpace(line), "=")
+			if !ok || key == "DOTENV_PUBLIC_KEY" {
+				continue
+			}
+			value = strings.Trim(value, `"`)
+			if !strings.HasPrefix(value, "encrypted:") {
+				value = fmt.Sprintf("encrypted:%d:%s", run, base64.StdEncoding.EncodeToString([]byte(value)))
+			}
+			fmt.Fprintf(&b, "%s=%q\n", key, value)
+		}
+		return os.WriteFile(path, []byte(b.String()), 0o600)
+	}
+}
+
+// fakeDecrypt decrypts a file fakeDotenvx encrypted.
+func fakeDecrypt(_ context.Context, path string, _ []string) ([]dotenv.Entry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := dotenv.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	for i, e := range entries {
+		rest, ok := strings.CutPrefix(e.Value, "encrypted:")
+		if !ok {
+			continue
+		}
+		_, b64, _ := strings.Cut(rest, ":")
+		plain, err := base64.StdEncoding.DecodeString(b64)
+		if err != nil || b64 == "" {
+			return nil, fmt.Errorf("cannot decrypt %s", e.Key)
+		}
+		entries[i].Value = string(plain)
+	}
+	return entries, nil
+}
+
+func incrementalEnv(t *testing.T) string {
+	t.Helper()
+	t.Setenv("ENVDRIFT_HOME", t.TempDir())
+	orig := incrementalDecrypt
+	incrementalDecrypt = fakeDecrypt
+	t.Cleanup(func() { incrementalDecrypt = orig })
+	return filepath.Join(t.TempDir(), ".env")
+}
+
+func readValues(t *testing.T, path string) map[string]string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, err := dotenv.Parse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dotenv.Map(entries)
+}
+
+// decryptTo stands in for decrypting the file in place: the public key
+// header stays, the values are plaintext again.
+func decryptTo(t *testing.T, path, publicKey, plain string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte("DOTENV_PUBLIC_KEY=\""+publicKey+"\"\n"+plain), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestEncryptIncremental_KeepsUnchanged checks that re-encrypting after one
+// value changed gives the others their earlier ciphertext back.
+func TestEncryptIncremental_KeepsUnchanged(t *testing.T) {
+	path := incrementalEnv(t)
+	publicKey := "pk-1"
+	encryptFn := fakeDotenvx(&publicKey)
+
+	if err := os.WriteFile(path, []byte("A=1\nB=2\nC=3\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	kept, err := EncryptIncremental(context.Background(), path, encryptFn)
+	if err != nil || kept != 0 {
+		t.Fatalf("first EncryptIncremental = %d, %v; want 0, nil", kept, err)
+	}
+	first := readValues(t, path)
+
+	decryptTo(t, path, publicKey, "A=1\nB=changed\nC=3\n")
+	kept, err = EncryptIncremental(context.Background(), path, encryptFn)
+	if err != nil || kept != 2 {
+		t.Fatalf("second EncryptIncremental = %d, %v; want 2, nil", kept, err)
+	}
+	second := readValues(t, path)
+	for _, k := range []string{"A", "C"} {
+		if second[k] != first[k] {
+			t.Errorf("%s = %q; want its earlier ciphertext %q", k, second[k], first[k])
+		}
+	}
+	if second["B"] == first["B"] || !strings.HasPrefix(second["B"], "encrypted:") {
+		t.Errorf("B = %q; want new ciphertext", second["B"])
+	}
+}
+
+// TestEncryptIncremental_RacingWrite checks that a value written after the
+// agent decided to encrypt, but before dotenvx read the file, is not undone
+// by putting back the ciphertext of the value it replaced.
+func TestEncryptIncremental_RacingWrite(t *testing.T) {
+	path := incrementalEnv(t)
+	publicKey := "pk-1"
+	dotenvx := fakeDotenvx(&publicKey)
+	if err := os.WriteFile(path, []byte("A=1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := EncryptIncremental(context.Background(), path, dotenvx); err != nil {
+		t.Fatal(err)
+	}
+	first := readValues(t, path)
+
+	decryptTo(t, path, publicKey, "A=1\n")
+	racing := func(ctx context.Context, path string) error {
+		// The user saves A=2 just before dotenvx reads the file.
+		if err := os.WriteFile(path, []byte("A=2\n"), 0o600); err != nil {
+			return err
+		}
+		return dotenvx(ctx, path)
+	}
+	kept, err := EncryptIncremental(context.Background(), path, racing)
+	if err != nil || kept != 0 {
+		t.Fatalf("EncryptIncremental = %d, %v; want 0, nil", kept, err)
+	}
+	got := readValues(t, path)["A"]
+	if got == first["A"] {
+		t.Fatal("the ciphertext of A=1 was put back over A=2")
+	}
+	if entries, _ := fakeDecrypt(context.Background(), path, nil); dotenv.Map(entries)["A"] != "2" {
+		t.Errorf("A decrypts to %q; want 2", dotenv.Map(entries)["A"])
+	}
+}
+
+// TestEncryptIncremental_WriteDuringDecrypt checks that a save landing while
+// the fresh file is being decrypted is kept rather than overwritten with the
+// restored ciphertext.
+func TestEncryptIncremental_WriteDuringDecrypt(t *testing.T) {
+	path := incrementalEnv(t)
+	publicKey := "pk-1"
+	dotenvx := fakeDotenvx(&publicKey)
+	if err := os.WriteFile(path, []byte("A=1\nB=2\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := EncryptIncremental(context.Background(), path, dotenvx); err != nil {
+		t.Fatal(err)
+	}
+
+	decryptTo(t, path, publicKey, "A=1\nB=3\n")
+	saved := []byte("A=4\nB=5\n")
+	incrementalDecrypt = func(ctx context.Context, path string, keys []string) ([]dotenv.Entry, error) {
+		entries, err := fakeDecrypt(ctx, path, keys)
+		// The user saves while dotenvx decrypts.
+		if werr := os.WriteFile(path, saved, 0o600); werr != nil {
+			t.Fatal(werr)
+		}
+		return entries, err
+	}
+	kept, err := EncryptIncremental(context.Background(), path, dotenvx)
+	if err != nil || kept != 0 {
+		t.Fatalf("EncryptIncremental = %d, %v; want 0, nil", kept, err)
+	}
+	if got, _ := os.ReadFile(path); string(got) != string(saved) {
+		t.Errorf("file = %q; want the save %q kept", got, saved)
+	}
+}
+
+// TestEncryptIncremental_Undecryptable checks that a file the agent cannot
+// decrypt keeps all its new ciphertext.
+func TestEncryptIncremental_Undecryptable(t *testing.T) {
+	path := incrementalEnv(t)
+	publicKey := "pk-1"
+	encryptFn := fakeDotenvx(&publicKey)
+	if err := os.WriteFile(path, []byte("A=1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := EncryptIncremental(context.Background(), path, encryptFn); err != nil {
+		t.Fatal(err)
+	}
+	first := readValues(t, path)
+
+	incrementalDecrypt = func(context.Context, string, []string) ([]dotenv.Entry, error) {
+		return nil, fmt.Errorf("no private key")
+	}
+	decryptTo(t, path, publicKey, "A=1\n")
+	kept, err := EncryptIncremental(context.Background(), path, encryptFn)
+	if err != nil || kept != 0 {
+		t.Fatalf("EncryptIncremental = %d, %v; want 0, nil", kept, err)
+	}
+	if got := readValues(t, path)["A"]; got == first["A"] {
+		t.Error("ciphertext put back without decrypting the new file")
+	}
+}
+
+// TestIncrementalStateIsKeyed checks that incremental.json holds keyed
+// hashes, with the key stored apart from it.
+func TestIncrementalStateIsKeyed(t *testing.T) {
+	path := incrementalEnv(t)
+	publicKey := "pk-1"
+	if err := os.WriteFile(path, []byte("A=hunter2\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := EncryptIncremental(context.Background(), path, fakeDotenvx(&publicKey)); err != nil {
+		t.Fatal(err)
+	}
+	fv, err := loadFileValues(path)
+	if err != nil || fv == nil {
+		t.Fatalf("loadFileValues = %+v, %v", fv, err)
+	}
+	sum := sha256.Sum256([]byte(fv.Salt + "\x00A\x00hunter2"))
+	if fv.Values["A"].Hash == hex.EncodeToString(sum[:]) {
+		t.Error("hash is the unkeyed salted SHA-256")
+	}
+	state, err := os.ReadFile(IncrementalStatePath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := os.ReadFile(IncrementalKeyPath())
+	if err != nil {
+		t.Fatalf("no key at %s: %v", IncrementalKeyPath(), err)
+	}
+	if strings.Contains(string(state), string(key)) || IncrementalKeyPath() == IncrementalStatePath() {
+		t.Error("the hash key is stored with the hashes")
+	}
+}
+
+// TestEncryptIncremental_PublicKeyChanged checks that ciphertext made for
+// another key pair is never put back.
+func TestEncryptIncremental_PublicKeyChanged(t *testing.T) {
+	path := incrementalEnv(t)
+	publicKey := "pk-1"
+	encryptFn := fakeDotenvx(&publicKey)
+
+	if err := os.WriteFile(path, []byte("A=1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := EncryptIncremental(context.Background(), path, encryptFn); err != nil {
+		t.Fatal(err)
+	}
+	first := readValues(t, path)
+
+	publicKey = "pk-2"
+	decryptTo(t, path, "pk-2", "A=1\n")
+	kept, err := EncryptIncremental(context.Background(), path, encryptFn)
+	if err != nil || kept != 0 {
+		t.Fatalf("EncryptIncremental = %d, %v; want 0, nil", kept, err)
+	}
+	if got := readValues(t, path)["A"]; got == first["A"] {
+		t.Errorf("A kept ciphertext %q made for the old key", got)
+	}
+}
+
+// TestEncryptIncremental_SkipsSOPS checks that a SOPS file is left as
+// encryptFn wrote it and nothing is remembered of it.
+func TestEncryptIncremental_SkipsSOPS(t *testing.T) {
+	path := incrementalEnv(t)
+	encryptFn := func(_ context.Context, path string) error {
+		return os.WriteFile(path, []byte("A=ENC[AES256_GCM,data:x,type:str]\nsops_version=3.8.1\n"), 0o600)
+	}
+	if err := os.WriteFile(path, []byte("A=1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	kept, err := EncryptIncremental(context.Background(), path, encryptFn)
+	if err != nil || kept != 0 {
+		t.Fatalf("EncryptIncremental = %d, %v; want 0, nil", kept, err)
+	}
+	if fv, err := loadFileValues(path); err != nil || fv != nil {
+		t.Errorf("remembered %+v, %v for a SOPS file; want nothing", fv, err)
+	}
+}
+
+// TestEncryptIncremental_Error checks that encryptFn's error is returned and
+// the file is left alone.
+func TestEncryptIncremental_Error(t *testing.T) {
+	path := incrementalEnv(t)
+	if err := os.WriteFile(path, []byte("A=1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	boom := fmt.Errorf("boom")
+	_, err := EncryptIncremental(context.Background(), path, func(context.Context, string) error { return boom })
+	if err != boom {
+		t.Fatalf("EncryptIncremental error = %v; want %v", err, boom)
+	}
+	if got := readValues(t, path)["A"]; got != "1" {
+		t.Errorf("A = %q; want the plaintext left alone", got)
+	}
+}
+
+// TestRememberValues is the `edit` flow: a file encrypted elsewhere is
+// decrypted, edited and encrypted again, and the values the edit left alone
+// keep their ciphertext.
+func TestRememberValues(t *testing.T) {
+	path := incrementalEnv(t)
+	encrypted := "DOTENV_PUBLIC_KEY=\"pk-1\"\nA=\"encrypted:aaa\"\nB=\"encrypted:bbb\"\n"
+	if err := RememberValues(path, []byte(encrypted), []byte("DOTENV_PUBLIC_KEY=\"pk-1\"\nA=1\nB=2\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	publicKey := "pk-1"
+	decryptTo(t, path, publicKey, "A=1\nB=3\n")
+	kept, err := EncryptIncremental(context.Background(), path, fakeDotenvx(&publicKey))
+	if err != nil || kept != 1 {
+		t.Fatalf("EncryptIncremental = %d, %v; want 1, nil", kept, err)
+	}
+	got := readValues(t, path)
+	if got["A"] != "encrypted:aaa" {
+		t.Errorf("A = %q; want encrypted:aaa", got["A"])
+	}
+	if got["B"] == "encrypted:bbb" {
+		t.Error("B kept the ciphertext of its old value")
+	}
+}
+
+// TestReplaceValues checks that a swapped value keeps the rest of its line.
+func TestReplaceValues(t *testing.T) {
+	in := "export A=\"encrypted:new\" # note\r\nB=\"encrypted:b\"\nC=plain\n"
+	out, n := replaceValues([]byte(in), map[string]string{"A": "encrypted:old", "C": "encrypted:c"})
+	want := "export A=\"encrypted:old\" # note\r\nB=\"encrypted:b\"\nC=plain\n"
+	if string(out) != want || n != 1 {
+		t.Errorf("replaceValues = %q, %d; want %q, 1", out, n, want)
+	}
+}
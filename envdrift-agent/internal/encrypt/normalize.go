@@ -0,0 +1,177 @@
+package encrypt
+
+import (
+	"bytes"
+	"maps"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/jainal09/envdrift-agent/internal/dotenv"
+)
+
+// Normalized output makes the encrypted file's layout a function of its
+// variables alone, so that re-encrypting it only changes the lines of the
+// values that changed: the header (the dotenvx banner, the public key and
+// the comments above the first variable) comes first, then every variable
+// once, sorted by name, on one line, with the comments directly above it
+// moving along; blank lines between variables and comments that carry a
+// timestamp are dropped. Only dotenvx files are normalized: SOPS computes
+// its MAC over the values in file order.
+
+// timestampComment matches a comment recording when something happened,
+// which would otherwise differ on every encryption.
+var timestampComment = regexp.MustCompile(`\d{4}-\d{2}-\d{2}[T ]\d{1,2}:\d{2}`)
+
+// normalizedVar is one variable of a normalized file with what travels
+// with it.
+type normalizedVar struct {
+	key      string
+	export   bool
+	comments []string
+	// inline is a `# comment` following the value on its line.
+	inline string
+}
+
+// Normalize rewrites the dotenvx-encrypted file at path in the normalized
+// layout. changed is false when the file is already normalized, is not a
+// dotenvx file, or has a line Normalize does not understand; it is then
+// left alone.
+func Normalize(path string) (changed bool, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+	out, ok := normalize(data)
+	if !ok || bytes.Equal(out, data) {
+		return false, nil
+	}
+	return true, writeInPlace(path, out)
+}
+
+// normalize returns data in the normalized layout; ok is false when data
+// is not a dotenvx-encrypted file it can lay out again.
+func normalize(data []byte) (out []byte, ok bool) {
+	entries, err := dotenv.Parse(data)
+	if err != nil {
+		return nil, false
+	}
+	if _, ok := dotenvxPublicKey(entries); !ok {
+		return nil, false
+	}
+	values := dotenv.Map(entries)
+
+	var header, pending []string
+	var vars []*normalizedVar
+	byKey := make(map[string]*normalizedVar)
+	for _, line := range strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "":
+			if len(vars) == 0 {
+				header = append(header, pending...)
+				pending = nil
+				header = append(header, "")
+			}
+			continue
+		case strings.HasPrefix(line, "#"):
+			if !timestampComment.MatchString(line) {
+				pending = append(pending, line)
+			}
+			continue
+		}
+
+		k, rest, found := strings.Cut(line, "=")
+		if !found {
+			return nil, false
+		}
+		k = strings.TrimSpace(k)
+		export := strings.HasPrefix(k, "export ")
+		key := strings.TrimSpace(strings.TrimPrefix(k, "export "))
+		rest = strings.TrimSpace(rest)
+		inline := ""
+		switch {
+		case rest == "":
+		case isQuoteByte(rest[0]) || rest[0] == '`':
+			quoted := stripCommentAfterQuotedValue(rest)
+			if !isWrappedInMatchingQuotes(quoted) {
+				// A multi-line value, or a malformed one.
+				return nil, false
+			}
+			inline = strings.TrimSpace(rest[len(quoted):])
+		default:
+			if i := strings.Index(rest, " #"); i >= 0 {
+				inline = strings.TrimSpace(rest[i:])
+			}
+		}
+		if timestampComment.MatchString(inline) {
+			inline = ""
+		}
+
+		if len(vars) == 0 || strings.HasPrefix(key, dotenvxPublicKeyPrefix) {
+			// dotenvx's `# .env` line sits right above the first variable.
+			header = append(header, pending...)
+			pending = nil
+		}
+		if strings.HasPrefix(key, dotenvxPublicKeyPrefix) {
+			header = append(header, assignment(export, key, values[key], inline))
+			continue
+		}
+		v, seen := byKey[key]
+		if !seen {
+			v = &normalizedVar{key: key, export: export}
+			byKey[key] = v
+			vars = append(vars, v)
+		}
+		v.comments = append(v.comments, pending...)
+		pending = nil
+		if inline != "" {
+			v.inline = inline
+		}
+	}
+
+	slices.SortFunc(vars, func(a, b *normalizedVar) int { return strings.Compare(a.key, b.key) })
+
+	var lines []string
+	for _, line := range header {
+		// One blank line at most between header lines, none at its ends.
+		if line == "" && (len(lines) == 0 || lines[len(lines)-1] == "") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	// A header comment, like dotenvx's `# .env`, heads the variables; a
+	// public key is set apart from them.
+	if len(lines) > 0 && len(vars) > 0 && !strings.HasPrefix(lines[len(lines)-1], "#") {
+		lines = append(lines, "")
+	}
+	for _, v := range vars {
+		lines = append(lines, v.comments...)
+		lines = append(lines, assignment(v.export, v.key, values[v.key], v.inline))
+	}
+	lines = append(lines, pending...)
+	out = []byte(strings.Join(lines, "\n") + "\n")
+
+	// Whatever the layout, the file must assign exactly what it did.
+	got, err := dotenv.Parse(out)
+	if err != nil || !maps.Equal(dotenv.Map(got), values) {
+		return nil, false
+	}
+	return out, true
+}
+
+// assignment renders one normalized KEY="value" line.
+func assignment(export bool, key, value, inline string) string {
+	line := string(bytes.TrimSuffix(dotenv.Marshal([]dotenv.Entry{{Key: key, Value: value}}), []byte("\n")))
+	if export {
+		line = "export " + line
+	}
+	if inline != "" {
+		line += " " + inline
+	}
+	return line
+}
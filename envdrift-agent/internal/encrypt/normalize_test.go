@@ -0,0 +1,101 @@
+package encrypt
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+const dotenvxBanner = `#/-------------------[DOTENV_PUBLIC_KEY]--------------------/
+#/            public-key encryption for .env files          /
+#/       [how it works](https://dotenvx.com/encryption)     /
+#/----------------------------------------------------------/
+`
+
+// TestNormalize pins the normalized layout: the header first, variables
+// sorted with the comments above them, blank lines and timestamp comments
+// gone, an `export` and an inline comment kept.
+func TestNormalize(t *testing.T) {
+	in := dotenvxBanner + "DOTENV_PUBLIC_KEY=\"pk\"\r\n" +
+		"\n" +
+		"# .env\n" +
+		"ZED=\"encrypted:z\"\n" +
+		"\n" +
+		"# encrypted 2026-10-15 09:30:00\n" +
+		"# the database\n" +
+		"export DB='encrypted:d' # primary\n" +
+		"\n\n" +
+		"API=encrypted:a\n" +
+		"# trailing note\n"
+	want := dotenvxBanner + "DOTENV_PUBLIC_KEY=\"pk\"\n" +
+		"\n" +
+		"# .env\n" +
+		"API=\"encrypted:a\"\n" +
+		"# the database\n" +
+		"export DB=\"encrypted:d\" # primary\n" +
+		"ZED=\"encrypted:z\"\n" +
+		"# trailing note\n"
+
+	out, ok := normalize([]byte(in))
+	if !ok {
+		t.Fatal("normalize refused a dotenvx file")
+	}
+	if string(out) != want {
+		t.Errorf("normalize =\n%s\nwant\n%s", out, want)
+	}
+	if again, ok := normalize(out); !ok || string(again) != want {
+		t.Errorf("normalize is not idempotent:\n%s", again)
+	}
+}
+
+// TestNormalize_Duplicates checks that a variable assigned twice appears
+// once, with the value that wins.
+func TestNormalize_Duplicates(t *testing.T) {
+	in := "DOTENV_PUBLIC_KEY=\"pk\"\nA=\"encrypted:1\"\nB=\"encrypted:b\"\nA=\"encrypted:2\"\n"
+	want := "DOTENV_PUBLIC_KEY=\"pk\"\n\nA=\"encrypted:2\"\nB=\"encrypted:b\"\n"
+	if out, ok := normalize([]byte(in)); !ok || string(out) != want {
+		t.Errorf("normalize = %q, %v; want %q", out, ok, want)
+	}
+}
+
+// TestNormalize_LeavesOthersAlone checks the files normalize refuses.
+func TestNormalize_LeavesOthersAlone(t *testing.T) {
+	for name, in := range map[string]string{
+		"plaintext":  "A=1\n",
+		"mixed":      "DOTENV_PUBLIC_KEY=\"pk\"\nA=\"encrypted:a\"\nB=plain\n",
+		"sops":       "A=ENC[AES256_GCM,data:x,type:str]\nsops_version=3.8.1\n",
+		"no key":     "A=\"encrypted:a\"\n",
+		"stray line": "DOTENV_PUBLIC_KEY=\"pk\"\nA=\"encrypted:a\"\nnot an assignment\n",
+	} {
+		if out, ok := normalize([]byte(in)); ok {
+			t.Errorf("%s: normalize = %q; want it refused", name, out)
+		}
+	}
+}
+
+// TestNormalizeFile checks that Normalize rewrites a file only when its
+// layout differs, keeping its permissions.
+func TestNormalizeFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(path, []byte("DOTENV_PUBLIC_KEY=\"pk\"\nB=\"encrypted:b\"\nA=\"encrypted:a\"\n"), 0o640); err != nil {
+		t.Fatal(err)
+	}
+	changed, err := Normalize(path)
+	if err != nil || !changed {
+		t.Fatalf("Normalize = %v, %v; want true, nil", changed, err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "DOTENV_PUBLIC_KEY=\"pk\"\n\nA=\"encrypted:a\"\nB=\"encrypted:b\"\n"; string(data) != want {
+		t.Errorf("file = %q; want %q", data, want)
+	}
+	if info, err := os.Stat(path); err == nil && runtime.GOOS != "windows" && info.Mode().Perm() != 0o640 {
+		t.Errorf("mode = %v; want 0640", info.Mode().Perm())
+	}
+	if changed, err := Normalize(path); err != nil || changed {
+		t.Errorf("second Normalize = %v, %v; want false, nil", changed, err)
+	}
+}
@@ -0,0 +1,216 @@
+// Package exports tracks plaintext files with a self-destruct timer: files
+// written by `envdrift-agent export --expire` and env files decrypted in
+// place by `envdrift-agent edit`. The ledger, exports.json in the state
+// directory, holds paths and deadlines only; the running guardian leaves a
+// pending file alone and expires due entries by deleting or re-encrypting the
+// file.
+package exports
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/jainal09/envdrift-agent/internal/paths"
+)
+
+// What happens to an export when its timer runs out.
+const (
+	ActionDelete  = "delete"
+	ActionEncrypt = "encrypt"
+)
+
+// Export is one timed plaintext export.
+type Export struct {
+	// Path is the plaintext file that was written.
+	Path string `json:"path"`
+	// Source is the encrypted file it was decrypted from.
+	Source string `json:"source"`
+	// Expires is when the guardian acts on Path.
+	Expires time.Time `json:"expires"`
+	// Action is ActionDelete or ActionEncrypt.
+	Action string `json:"action"`
+	// Edit marks an `edit` session: Path is the env file itself, decrypted in
+	// place, and is re-encrypted early once the editor closes it.
+	Edit bool `json:"edit,omitempty"`
+}
+
+// mu serializes ledger updates within one process.
+var mu sync.Mutex
+
+// LedgerPath returns exports.json in the state directory.
+func LedgerPath() string {
+	return filepath.Join(paths.StateDir(), "exports.json")
+}
+
+// Add records e in the ledger, replacing any earlier entry for the same path.
+func Add(e Export) error {
+	if e.Action != ActionDelete && e.Action != ActionEncrypt {
+		return fmt.Errorf("unknown export action %q (want %s or %s)", e.Action, ActionDelete, ActionEncrypt)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	ledger, err := load()
+	if err != nil {
+		return err
+	}
+	kept := ledger[:0]
+	for _, x := range ledger {
+		if x.Path != e.Path {
+			kept = append(kept, x)
+		}
+	}
+	return save(append(kept, e))
+}
+
+// Remove drops path's entry without touching the file.
+func Remove(path string) error {
+	mu.Lock()
+	defer mu.Unlock()
+	ledger, err := load()
+	if err != nil {
+		return err
+	}
+	kept := ledger[:0]
+	for _, x := range ledger {
+		if x.Path != path {
+			kept = append(kept, x)
+		}
+	}
+	return save(kept)
+}
+
+// List returns the pending exports.
+func List() ([]Export, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	return load()
+}
+
+// Pending returns the unexpired entry for path, if any.
+func Pending(path string, now time.Time) (Export, bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	ledger, err := load()
+	if err != nil {
+		return Export{}, false
+	}
+	for _, e := range ledger {
+		if e.Path == path && now.Before(e.Expires) {
+			return e, true
+		}
+	}
+	return Export{}, false
+}
+
+// Finish acts on path's entry now, whatever its deadline, and removes it
+// from the ledger. found is false when path has no entry.
+func Finish(ctx context.Context, path string, encryptFn func(context.Context, string) error) (e Export, found bool, err error) {
+	mu.Lock()
+	defer mu.Unlock()
+	ledger, err := load()
+	if err != nil {
+		return Export{}, false, err
+	}
+	rest := ledger[:0]
+	for _, x := range ledger {
+		if x.Path == path && !found {
+			e, found = x, true
+			continue
+		}
+		rest = append(rest, x)
+	}
+	if !found {
+		return Export{}, false, nil
+	}
+	if _, err := os.Stat(e.Path); err == nil {
+		if err := act(ctx, e, encryptFn); err != nil {
+			return e, true, err
+		}
+	}
+	return e, true, save(rest)
+}
+
+// Expire acts on every export due at now: ActionDelete removes the file,
+// ActionEncrypt hands it to encryptFn. Handled exports — and those whose
+// file is already gone — leave the ledger; a failed one stays for the next
+// call and its error is returned alongside the handled list.
+func Expire(ctx context.Context, now time.Time, encryptFn func(context.Context, string) error) ([]Export, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	ledger, err := load()
+	if err != nil || len(ledger) == 0 {
+		return nil, err
+	}
+
+	var done, pending []Export
+	var errs []error
+	for _, e := range ledger {
+		if now.Before(e.Expires) {
+			pending = append(pending, e)
+			continue
+		}
+		if _, err := os.Stat(e.Path); errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err := act(ctx, e, encryptFn); err != nil {
+			errs = append(errs, err)
+			pending = append(pending, e)
+			continue
+		}
+		done = append(done, e)
+	}
+	if err := save(pending); err != nil {
+		errs = append(errs, err)
+	}
+	return done, errors.Join(errs...)
+}
+
+// act deletes or encrypts e.Path.
+func act(ctx context.Context, e Export, encryptFn func(context.Context, string) error) error {
+	var err error
+	if e.Action == ActionEncrypt {
+		err = encryptFn(ctx, e.Path)
+	} else {
+		err = os.Remove(e.Path)
+	}
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", e.Action, e.Path, err)
+	}
+	return nil
+}
+
+func load() ([]Export, error) {
+	data, err := os.ReadFile(LedgerPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var ledger []Export
+	if err := json.Unmarshal(data, &ledger); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", LedgerPath(), err)
+	}
+	return ledger, nil
+}
+
+func save(ledger []Export) error {
+	path := LedgerPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	if ledger == nil {
+		ledger = []Export{}
+	}
+	data, err := json.MarshalIndent(ledger, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
@@ -0,0 +1,104 @@
+package exports
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func setTempHome(t *testing.T) {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+}
+
+func TestExpire(t *testing.T) {
+	setTempHome(t)
+	dir := t.TempDir()
+	now := time.Now()
+	paths := map[string]string{}
+	for _, name := range []string{"due-delete", "due-encrypt", "later", "failing"} {
+		paths[name] = filepath.Join(dir, name+".env")
+		if err := os.WriteFile(paths[name], []byte("A=1\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, e := range []Export{
+		{Path: paths["due-delete"], Expires: now.Add(-time.Minute), Action: ActionDelete},
+		{Path: paths["due-encrypt"], Expires: now.Add(-time.Minute), Action: ActionEncrypt},
+		{Path: paths["later"], Expires: now.Add(time.Hour), Action: ActionDelete},
+		{Path: paths["failing"], Expires: now.Add(-time.Minute), Action: ActionEncrypt},
+		{Path: filepath.Join(dir, "already-gone.env"), Expires: now.Add(-time.Minute), Action: ActionDelete},
+	} {
+		if err := Add(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var encrypted []string
+	done, err := Expire(context.Background(), now, func(_ context.Context, path string) error {
+		if path == paths["failing"] {
+			return errors.New("no key")
+		}
+		encrypted = append(encrypted, path)
+		return nil
+	})
+	if err == nil {
+		t.Error("the failing export's error should be returned")
+	}
+	if len(done) != 2 {
+		t.Errorf("done = %+v, want the two due exports", done)
+	}
+	if _, err := os.Stat(paths["due-delete"]); !os.IsNotExist(err) {
+		t.Error("due delete export still exists")
+	}
+	if len(encrypted) != 1 || encrypted[0] != paths["due-encrypt"] {
+		t.Errorf("encrypted = %v", encrypted)
+	}
+
+	pending, _ := List()
+	if len(pending) != 2 || pending[0].Path != paths["later"] || pending[1].Path != paths["failing"] {
+		t.Errorf("pending = %+v, want later and the failed retry", pending)
+	}
+}
+
+func TestAddRejectsUnknownAction(t *testing.T) {
+	setTempHome(t)
+	if err := Add(Export{Path: "/x", Action: "shred"}); err == nil {
+		t.Error("unknown action should fail")
+	}
+}
+
+func TestPendingAndFinish(t *testing.T) {
+	setTempHome(t)
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(path, []byte("A=1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	if err := Add(Export{Path: path, Expires: now.Add(time.Hour), Action: ActionEncrypt, Edit: true}); err != nil {
+		t.Fatal(err)
+	}
+	if _, held := Pending(path, now); !held {
+		t.Fatal("entry should be pending before its deadline")
+	}
+	if _, held := Pending(path, now.Add(2*time.Hour)); held {
+		t.Error("entry should not be pending after its deadline")
+	}
+
+	var encrypted []string
+	e, found, err := Finish(context.Background(), path, func(_ context.Context, p string) error {
+		encrypted = append(encrypted, p)
+		return nil
+	})
+	if err != nil || !found || !e.Edit || len(encrypted) != 1 {
+		t.Fatalf("Finish = %+v, %v, %v; encrypted %v", e, found, err, encrypted)
+	}
+	if _, found, _ := Finish(context.Background(), path, nil); found {
+		t.Error("finished entry should be gone")
+	}
+}
@@ -0,0 +1,83 @@
+package fsys
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestFS runs the same operations against the disk and a Mem, so Mem keeps
+// the semantics the stores rely on.
+func TestFS(t *testing.T) {
+	for name, target := range map[string]FS{"OS": OS{}, "Mem": NewMem(nil)} {
+		t.Run(name, func(t *testing.T) {
+			root := filepath.Join(t.TempDir(), "root")
+			dir := filepath.Join(root, "a", "b")
+			if err := target.MkdirAll(dir, 0o700); err != nil {
+				t.Fatal(err)
+			}
+			file := filepath.Join(dir, "f")
+			if err := target.WriteFile(file, []byte("one"), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := target.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600); !errors.Is(err, fs.ErrExist) {
+				t.Errorf("O_EXCL over a file: %v", err)
+			}
+			if err := target.WriteFile(filepath.Join(root, "missing", "f"), nil, 0o600); !errors.Is(err, fs.ErrNotExist) {
+				t.Errorf("write into a missing directory: %v", err)
+			}
+
+			f, err := target.OpenFile(file, os.O_WRONLY|os.O_APPEND, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, _ = f.Write([]byte(" two"))
+			_ = f.Close()
+			r, err := target.Open(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, _ := io.ReadAll(r)
+			_ = r.Close()
+			if string(data) != "one two" {
+				t.Errorf("content = %q", data)
+			}
+
+			tmp, err := target.CreateTemp(dir, ".f.tmp-*")
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, _ = tmp.Write([]byte("three"))
+			_ = tmp.Close()
+			if err := target.Rename(tmp.Name(), file); err != nil {
+				t.Fatal(err)
+			}
+			if data, _ := target.ReadFile(file); string(data) != "three" {
+				t.Errorf("content after rename = %q", data)
+			}
+			if info, err := target.Stat(file); err != nil || info.Size() != 5 || info.IsDir() {
+				t.Errorf("Stat = %v, %v", info, err)
+			}
+
+			entries, err := target.ReadDir(filepath.Join(root, "a"))
+			if err != nil || len(entries) != 1 || entries[0].Name() != "b" || !entries[0].IsDir() {
+				t.Errorf("ReadDir = %v, %v", entries, err)
+			}
+			if err := target.Remove(dir); err == nil {
+				t.Error("Remove of a non-empty directory succeeded")
+			}
+			if err := target.RemoveAll(filepath.Join(root, "a")); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := target.Stat(file); !errors.Is(err, fs.ErrNotExist) {
+				t.Errorf("Stat after RemoveAll: %v", err)
+			}
+			if _, err := target.ReadDir(filepath.Join(root, "a")); !os.IsNotExist(err) {
+				t.Errorf("ReadDir after RemoveAll: %v", err)
+			}
+		})
+	}
+}
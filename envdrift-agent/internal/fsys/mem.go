@@ -0,0 +1,360 @@
+package fsys
+
+import (
+	"bytes"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jainal09/envdrift-agent/internal/clock"
+)
+
+// Mem is an in-memory FS for tests. Paths are cleaned and otherwise taken
+// as given; filesystem roots always exist. Modification times come from
+// Clock (the system clock when nil). The zero Mem is empty and ready to use.
+type Mem struct {
+	Clock clock.Clock
+
+	mu    sync.Mutex
+	nodes map[string]*memNode
+	temp  int
+}
+
+// memNode is a file or directory of a Mem.
+type memNode struct {
+	dir     bool
+	data    []byte
+	mode    fs.FileMode
+	modTime time.Time
+}
+
+// NewMem returns an empty Mem whose files are stamped by c.
+func NewMem(c clock.Clock) *Mem {
+	return &Mem{Clock: c}
+}
+
+// node returns the node at the cleaned name. Callers hold m.mu.
+func (m *Mem) node(name string) (*memNode, bool) {
+	if isRoot(name) {
+		return &memNode{dir: true, mode: fs.ModeDir | 0o755}, true
+	}
+	n, ok := m.nodes[name]
+	return n, ok
+}
+
+// isRoot reports whether the cleaned name is a filesystem root or the
+// current directory, which every Mem has.
+func isRoot(name string) bool {
+	return name == "." || filepath.Dir(name) == name
+}
+
+// parentDir checks that the directory holding name exists. Callers hold m.mu.
+func (m *Mem) parentDir(op, name string) error {
+	if p, ok := m.node(filepath.Dir(name)); !ok || !p.dir {
+		return &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+	return nil
+}
+
+func (m *Mem) now() time.Time {
+	return clock.Or(m.Clock).Now()
+}
+
+func (m *Mem) Open(name string) (File, error) {
+	return m.OpenFile(name, os.O_RDONLY, 0)
+}
+
+func (m *Mem) OpenFile(name string, flag int, perm fs.FileMode) (File, error) {
+	name = filepath.Clean(name)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n, ok := m.node(name)
+	switch {
+	case ok && flag&os.O_CREATE != 0 && flag&os.O_EXCL != 0:
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrExist}
+	case ok && n.dir && flag&(os.O_WRONLY|os.O_RDWR) != 0:
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	case !ok && flag&os.O_CREATE == 0:
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	case !ok:
+		if err := m.parentDir("open", name); err != nil {
+			return nil, err
+		}
+		n = &memNode{mode: perm.Perm(), modTime: m.now()}
+		m.set(name, n)
+	}
+	if flag&os.O_TRUNC != 0 {
+		n.data = nil
+	}
+	f := &memFile{mem: m, name: name, node: n, flag: flag}
+	if flag&(os.O_WRONLY|os.O_RDWR) == 0 {
+		f.reader = bytes.NewReader(append([]byte(nil), n.data...))
+	}
+	return f, nil
+}
+
+// CreateTemp creates a new file in dir named by pattern, its last "*"
+// replaced by a counter (or the counter appended), as os.CreateTemp does.
+func (m *Mem) CreateTemp(dir, pattern string) (File, error) {
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	prefix, suffix := pattern, ""
+	if i := strings.LastIndex(pattern, "*"); i >= 0 {
+		prefix, suffix = pattern[:i], pattern[i+1:]
+	}
+	for {
+		m.mu.Lock()
+		m.temp++
+		name := filepath.Join(dir, prefix+strconv.Itoa(m.temp)+suffix)
+		m.mu.Unlock()
+		f, err := m.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o600)
+		if err == nil || !os.IsExist(err) {
+			return f, err
+		}
+	}
+}
+
+func (m *Mem) ReadFile(name string) ([]byte, error) {
+	name = filepath.Clean(name)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n, ok := m.node(name)
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	if n.dir {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrInvalid}
+	}
+	return append([]byte(nil), n.data...), nil
+}
+
+func (m *Mem) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	f, err := m.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+func (m *Mem) ReadDir(name string) ([]fs.DirEntry, error) {
+	name = filepath.Clean(name)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n, ok := m.node(name)
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	if !n.dir {
+		return nil, &fs.PathError{Op: "readdirent", Path: name, Err: fs.ErrInvalid}
+	}
+	var entries []fs.DirEntry
+	for path, child := range m.nodes {
+		if filepath.Dir(path) == name && path != name {
+			entries = append(entries, fs.FileInfoToDirEntry(child.info(filepath.Base(path))))
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return entries, nil
+}
+
+func (m *Mem) Stat(name string) (fs.FileInfo, error) {
+	name = filepath.Clean(name)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n, ok := m.node(name)
+	if !ok {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+	}
+	return n.info(filepath.Base(name)), nil
+}
+
+func (m *Mem) MkdirAll(path string, perm fs.FileMode) error {
+	path = filepath.Clean(path)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var missing []string
+	for p := path; ; p = filepath.Dir(p) {
+		n, ok := m.node(p)
+		if ok {
+			if !n.dir {
+				return &fs.PathError{Op: "mkdir", Path: p, Err: fs.ErrExist}
+			}
+			break
+		}
+		missing = append(missing, p)
+	}
+	for _, p := range missing {
+		m.set(p, &memNode{dir: true, mode: fs.ModeDir | perm.Perm(), modTime: m.now()})
+	}
+	return nil
+}
+
+func (m *Mem) Chmod(name string, mode fs.FileMode) error {
+	name = filepath.Clean(name)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n, ok := m.nodes[name]
+	if !ok {
+		if isRoot(name) {
+			return nil
+		}
+		return &fs.PathError{Op: "chmod", Path: name, Err: fs.ErrNotExist}
+	}
+	n.mode = n.mode&fs.ModeType | mode.Perm()
+	return nil
+}
+
+// Rename moves oldpath, and everything below it when it is a directory, to
+// newpath, replacing a file there.
+func (m *Mem) Rename(oldpath, newpath string) error {
+	oldpath, newpath = filepath.Clean(oldpath), filepath.Clean(newpath)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n, ok := m.nodes[oldpath]
+	if !ok {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: fs.ErrNotExist}
+	}
+	if err := m.parentDir("rename", newpath); err != nil {
+		return err
+	}
+	if dst, ok := m.nodes[newpath]; ok && dst.dir {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: fs.ErrExist}
+	}
+	moved := map[string]*memNode{newpath: n}
+	prefix := oldpath + string(filepath.Separator)
+	for path, child := range m.nodes {
+		if strings.HasPrefix(path, prefix) {
+			moved[filepath.Join(newpath, strings.TrimPrefix(path, prefix))] = child
+			delete(m.nodes, path)
+		}
+	}
+	delete(m.nodes, oldpath)
+	for path, child := range moved {
+		m.set(path, child)
+	}
+	return nil
+}
+
+// Remove removes a file or an empty directory.
+func (m *Mem) Remove(name string) error {
+	name = filepath.Clean(name)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.nodes[name]; !ok {
+		return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrNotExist}
+	}
+	prefix := name + string(filepath.Separator)
+	for path := range m.nodes {
+		if strings.HasPrefix(path, prefix) {
+			return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrExist}
+		}
+	}
+	delete(m.nodes, name)
+	return nil
+}
+
+// RemoveAll removes path and everything below it; a missing path is not an
+// error.
+func (m *Mem) RemoveAll(path string) error {
+	path = filepath.Clean(path)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	prefix := path + string(filepath.Separator)
+	for p := range m.nodes {
+		if p == path || strings.HasPrefix(p, prefix) {
+			delete(m.nodes, p)
+		}
+	}
+	return nil
+}
+
+// set stores n at the cleaned name. Callers hold m.mu.
+func (m *Mem) set(name string, n *memNode) {
+	if m.nodes == nil {
+		m.nodes = make(map[string]*memNode)
+	}
+	m.nodes[name] = n
+}
+
+// info describes n under the base name.
+func (n *memNode) info(name string) fs.FileInfo {
+	return memInfo{name: name, size: int64(len(n.data)), mode: n.mode, modTime: n.modTime}
+}
+
+// memInfo is a snapshot of a memNode for Stat and ReadDir.
+type memInfo struct {
+	name    string
+	size    int64
+	mode    fs.FileMode
+	modTime time.Time
+}
+
+func (i memInfo) Name() string       { return i.name }
+func (i memInfo) Size() int64        { return i.size }
+func (i memInfo) Mode() fs.FileMode  { return i.mode }
+func (i memInfo) ModTime() time.Time { return i.modTime }
+func (i memInfo) IsDir() bool        { return i.mode.IsDir() }
+func (i memInfo) Sys() any           { return nil }
+
+// memFile is an open file of a Mem. Reads see the content as of Open;
+// writes go straight to the node.
+type memFile struct {
+	mem    *Mem
+	name   string
+	node   *memNode
+	flag   int
+	reader *bytes.Reader
+	offset int
+	closed bool
+}
+
+func (f *memFile) Name() string { return f.name }
+
+func (f *memFile) Read(p []byte) (int, error) {
+	if f.closed {
+		return 0, fs.ErrClosed
+	}
+	if f.reader == nil {
+		return 0, &fs.PathError{Op: "read", Path: f.name, Err: fs.ErrPermission}
+	}
+	return f.reader.Read(p)
+}
+
+func (f *memFile) Write(p []byte) (int, error) {
+	if f.closed {
+		return 0, fs.ErrClosed
+	}
+	if f.flag&(os.O_WRONLY|os.O_RDWR) == 0 {
+		return 0, &fs.PathError{Op: "write", Path: f.name, Err: fs.ErrPermission}
+	}
+	f.mem.mu.Lock()
+	defer f.mem.mu.Unlock()
+	if f.flag&os.O_APPEND != 0 {
+		f.offset = len(f.node.data)
+	}
+	if end := f.offset + len(p); end > len(f.node.data) {
+		f.node.data = append(f.node.data, make([]byte, end-len(f.node.data))...)
+	}
+	copy(f.node.data[f.offset:], p)
+	f.offset += len(p)
+	f.node.modTime = f.mem.now()
+	return len(p), nil
+}
+
+func (f *memFile) Close() error {
+	if f.closed {
+		return fs.ErrClosed
+	}
+	f.closed = true
+	return nil
+}
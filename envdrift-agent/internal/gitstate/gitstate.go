@@ -0,0 +1,145 @@
+// Package gitstate detects a git operation in progress in the repository
+// containing a path, so the agent can hold off writing working-tree files
+// while git is writing them too, and reads the branch it has checked out.
+package gitstate
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// staleLock is the age past which an index.lock is taken for the leftover of
+// a crashed git process rather than a running one, so it cannot defer
+// encryption forever.
+const staleLock = 10 * time.Minute
+
+// markers are the files and directories git keeps in the git directory for
+// the length of an operation, in the order they are reported.
+var markers = []struct{ name, op string }{
+	{"rebase-merge", "rebase"},
+	{"rebase-apply", "rebase"},
+	{"MERGE_HEAD", "merge"},
+	{"CHERRY_PICK_HEAD", "cherry-pick"},
+	{"REVERT_HEAD", "revert"},
+	{"index.lock", "checkout"},
+}
+
+// InProgress returns the git operation under way in the repository
+// containing path ("merge", "rebase", "cherry-pick", "revert", or "checkout"
+// for any command holding index.lock), or "" when there is none or path is
+// not in a repository.
+func InProgress(path string) string {
+	gitDir := Dir(path)
+	if gitDir == "" {
+		return ""
+	}
+	now := time.Now()
+	for _, m := range markers {
+		info, err := os.Stat(filepath.Join(gitDir, m.name))
+		if err != nil {
+			continue
+		}
+		if m.name == "index.lock" && now.Sub(info.ModTime()) > staleLock {
+			continue
+		}
+		return m.op
+	}
+	return ""
+}
+
+// Dir returns the git directory of the repository containing path, or ""
+// when there is none. A .git file (a worktree or submodule) is followed to
+// the directory its "gitdir:" line names.
+func Dir(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return ""
+	}
+	for dir := abs; ; dir = filepath.Dir(dir) {
+		dotGit := filepath.Join(dir, ".git")
+		if info, err := os.Stat(dotGit); err == nil {
+			if info.IsDir() {
+				return dotGit
+			}
+			return readGitFile(dotGit)
+		}
+		if filepath.Dir(dir) == dir {
+			return ""
+		}
+	}
+}
+
+// HooksDir returns the hooks directory of the repository containing path, or
+// "" when there is none. Worktrees share the hooks of their main repository,
+// found through the git directory's commondir file. core.hooksPath is not
+// consulted.
+func HooksDir(path string) string {
+	gitDir := Dir(path)
+	if gitDir == "" {
+		return ""
+	}
+	if data, err := os.ReadFile(filepath.Join(gitDir, "commondir")); err == nil {
+		common := strings.TrimSpace(string(data))
+		if !filepath.IsAbs(common) {
+			common = filepath.Join(gitDir, common)
+		}
+		gitDir = filepath.Clean(common)
+	}
+	return filepath.Join(gitDir, "hooks")
+}
+
+// readGitFile resolves a "gitdir: <path>" .git file, relative to its own
+// directory when the path is.
+func readGitFile(dotGit string) string {
+	data, err := os.ReadFile(dotGit)
+	if err != nil {
+		return ""
+	}
+	target, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir:")
+	if !ok {
+		return ""
+	}
+	target = strings.TrimSpace(target)
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(filepath.Dir(dotGit), target)
+	}
+	return filepath.Clean(target)
+}
+
+// Branch returns the branch checked out in the repository containing path,
+// read from HEAD, or "" when HEAD is detached or path is not in a
+// repository.
+func Branch(path string) string {
+	gitDir := Dir(path)
+	if gitDir == "" {
+		return ""
+	}
+	data, err := os.ReadFile(filepath.Join(gitDir, "HEAD"))
+	if err != nil {
+		return ""
+	}
+	branch, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "ref: refs/heads/")
+	if !ok {
+		return ""
+	}
+	return branch
+}
+
+// Root returns the working tree of the repository containing path: the
+// directory holding its .git, or "" when there is none.
+func Root(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return ""
+	}
+	for dir := abs; ; dir = filepath.Dir(dir) {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir
+		}
+		if filepath.Dir(dir) == dir {
+			return ""
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package gitstate
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestInProgress(t *testing.T) {
+	repo := t.TempDir()
+	gitDir := filepath.Join(repo, ".git")
+	if err := os.MkdirAll(filepath.Join(repo, "app"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(gitDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	env := filepath.Join(repo, "app", ".env")
+
+	if op := InProgress(env); op != "" {
+		t.Fatalf("idle repo reports %q", op)
+	}
+
+	lock := filepath.Join(gitDir, "index.lock")
+	if err := os.WriteFile(lock, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if op := InProgress(env); op != "checkout" {
+		t.Errorf("index.lock -> %q; want checkout", op)
+	}
+	old := time.Now().Add(-2 * staleLock)
+	if err := os.Chtimes(lock, old, old); err != nil {
+		t.Fatal(err)
+	}
+	if op := InProgress(env); op != "" {
+		t.Errorf("stale index.lock -> %q; want none", op)
+	}
+
+	if err := os.WriteFile(filepath.Join(gitDir, "MERGE_HEAD"), []byte("abc\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if op := InProgress(env); op != "merge" {
+		t.Errorf("MERGE_HEAD -> %q; want merge", op)
+	}
+	if err := os.Mkdir(filepath.Join(gitDir, "rebase-merge"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if op := InProgress(env); op != "rebase" {
+		t.Errorf("rebase-merge -> %q; want rebase", op)
+	}
+}
+
+func TestDirFollowsGitFile(t *testing.T) {
+	root := t.TempDir()
+	real := filepath.Join(root, "main", ".git", "worktrees", "wt")
+	if err := os.MkdirAll(real, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	wt := filepath.Join(root, "wt")
+	if err := os.Mkdir(wt, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(wt, ".git"), []byte("gitdir: ../main/.git/worktrees/wt\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got := Dir(filepath.Join(wt, ".env")); got != real {
+		t.Errorf("Dir = %q; want %q", got, real)
+	}
+
+	if err := os.WriteFile(filepath.Join(real, "commondir"), []byte("../..\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := HooksDir(wt), filepath.Join(root, "main", ".git", "hooks"); got != want {
+		t.Errorf("HooksDir = %q; want %q", got, want)
+	}
+}
+
+func TestBranchAndRoot(t *testing.T) {
+	repo := t.TempDir()
+	gitDir := filepath.Join(repo, ".git")
+	if err := os.MkdirAll(filepath.Join(repo, "app"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(gitDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	env := filepath.Join(repo, "app", ".env")
+	if err := os.WriteFile(filepath.Join(gitDir, "HEAD"), []byte("ref: refs/heads/feature/login\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got := Branch(env); got != "feature/login" {
+		t.Errorf("Branch = %q; want feature/login", got)
+	}
+	if got := Root(env); got != repo {
+		t.Errorf("Root = %q; want %q", got, repo)
+	}
+	if err := os.WriteFile(filepath.Join(gitDir, "HEAD"), []byte("0123456789abcdef0123456789abcdef01234567\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got := Branch(env); got != "" {
+		t.Errorf("detached Branch = %q; want none", got)
+	}
+}
@@ -0,0 +1,33 @@
+package guardian
+
+import (
+	"log"
+	"time"
+
+	"github.com/jainal09/envdrift-agent/internal/backups"
+)
+
+// backupFile copies path into the backup store before it is encrypted and
+// applies the retention policy. A failed backup is logged, not fatal:
+// leaving the file in plaintext because its safety copy failed would defeat
+// the point of the agent.
+func (g *Guardian) backupFile(projectPath, path string) {
+	if g.backups == nil {
+		return
+	}
+	g.backupMu.Lock()
+	defer g.backupMu.Unlock()
+	if _, err := g.backups.Create(path); err != nil {
+		log.Printf("[%s] Backing up %s before encryption: %v", projectPath, path, err)
+		return
+	}
+	cfg := g.globalConfig.Backups
+	policy := backups.Policy{Keep: cfg.Keep, MaxAge: cfg.MaxAge, Trash: cfg.Trash}
+	removed, err := g.backups.Prune(policy, time.Now())
+	if err != nil {
+		log.Printf("Pruning backups: %v", err)
+	}
+	if len(removed) > 0 {
+		log.Printf("Pruned %d old backup(s)", len(removed))
+	}
+}
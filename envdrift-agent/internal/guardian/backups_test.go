@@ -0,0 +1,38 @@
+package guardian
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/jainal09/envdrift-agent/internal/backups"
+)
+
+// TestCheckIdleFiles_BacksUpBeforeEncrypting checks that with [backups]
+// enabled the plaintext is copied aside before `envdrift encrypt` runs, and
+// that the retention policy is applied right after.
+func TestCheckIdleFiles_BacksUpBeforeEncrypting(t *testing.T) {
+	f := newIdleCheckFixture(t, "ok")
+	f.g.globalConfig.Backups.Enabled = true
+	f.g.globalConfig.Backups.Keep = 1
+	f.g.backups = &backups.Store{Dir: t.TempDir()}
+
+	path := f.trackIdle(t, ".env", "SECRET=first\n")
+	f.g.checkIdleFiles(context.Background())
+	f.trackIdle(t, ".env", "SECRET=second\n")
+	f.g.checkIdleFiles(context.Background())
+
+	list, err := f.g.backups.List(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 {
+		t.Fatalf("keep = 1 left %d backups", len(list))
+	}
+	if err := f.g.backups.Restore(list[0]); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "SECRET=second\n" {
+		t.Errorf("restored %q; want the newest plaintext", data)
+	}
+}
@@ -0,0 +1,96 @@
+package guardian
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/jainal09/envdrift-agent/internal/encrypt"
+	"github.com/jainal09/envdrift-agent/internal/exports"
+	"github.com/jainal09/envdrift-agent/internal/keys"
+	"github.com/jainal09/envdrift-agent/internal/paths"
+	"github.com/jainal09/envdrift-agent/internal/registry"
+	"github.com/jainal09/envdrift-agent/internal/watcher"
+)
+
+// WatchedFile is an env file of a registered project.
+type WatchedFile struct {
+	Project, Path string
+}
+
+// BulkFilter narrows the watched set for encrypt-all and decrypt-all. The
+// zero filter keeps every file.
+type BulkFilter struct {
+	// Root keeps the files under this absolute directory.
+	Root string
+	// Envs keeps the files of these environments: "production" selects
+	// .env.production, "" selects .env.
+	Envs []string
+	// Include keeps the files whose name matches one of these patterns,
+	// and Exclude drops those matching one of its own, as [guardian]
+	// patterns and exclude do.
+	Include, Exclude []string
+}
+
+// keeps reports whether the filter keeps path.
+func (f BulkFilter) keeps(path string) bool {
+	if f.Root != "" && !paths.Within(filepath.Clean(f.Root), path) {
+		return false
+	}
+	if len(f.Envs) > 0 {
+		name, found := keys.KeyNameForFile(path), false
+		for _, env := range f.Envs {
+			found = found || keys.KeyName(env) == name
+		}
+		if !found {
+			return false
+		}
+	}
+	include, excluded := watcher.Match(path, f.Include, f.Exclude)
+	return (len(f.Include) == 0 || include != "") && excluded == ""
+}
+
+// WatchedFiles lists, sorted by path, the env files RunOnce would sweep —
+// those of the enabled registered projects in the active profile that their
+// patterns select — that filter keeps.
+func (g *Guardian) WatchedFiles(filter BulkFilter) ([]WatchedFile, error) {
+	reg, err := registry.Load()
+	if err != nil {
+		return nil, err
+	}
+	files, _ := g.projectFiles(reg)
+	var kept []WatchedFile
+	for _, f := range files {
+		if filter.keeps(f.path) {
+			kept = append(kept, WatchedFile{Project: f.project, Path: f.path})
+		}
+	}
+	return kept, nil
+}
+
+// EncryptAll encrypts the plaintext files among files like RunOnce, for
+// encrypt-all. The files were chosen by hand, so a project's onboarding grace
+// period does not hold them back, and a file decrypted in place by edit or
+// decrypt-all has its session ended and is encrypted. Files open in another
+// process, mid-git or vetoed by the pre_encrypt hook are still skipped.
+func (g *Guardian) EncryptAll(ctx context.Context, files []WatchedFile, workers int, progress func(Progress)) (RunOnceResult, error) {
+	if g.readOnly {
+		return RunOnceResult{}, errReadOnly
+	}
+	if !encrypt.IsEnvdriftAvailable() {
+		return RunOnceResult{}, errNoEnvdrift
+	}
+	list := make([]projectFile, len(files))
+	for i, f := range files {
+		list[i] = projectFile{project: f.Project, path: f.Path}
+	}
+	return g.sweep(ctx, list, workers, progress, func(ctx context.Context, f projectFile) (swept, error) {
+		if e, held := exports.Pending(f.path, time.Now()); held && e.Edit {
+			if err := exports.Remove(f.path); err != nil {
+				return sweptSkipped, fmt.Errorf("%s: %w", f.path, err)
+			}
+		}
+		return g.encryptOnce(ctx, f, nil)
+	})
+}
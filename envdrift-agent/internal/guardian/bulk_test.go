@@ -0,0 +1,102 @@
+package guardian
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/jainal09/envdrift-agent/internal/config"
+	"github.com/jainal09/envdrift-agent/internal/exports"
+)
+
+// TestWatchedFiles narrows the registered projects' env files by root,
+// environment and name patterns.
+func TestWatchedFiles(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	api, web := makeProject(t), makeProject(t)
+	writeRegistry(t, home, api, web)
+	for _, p := range []string{
+		filepath.Join(api, ".env"), filepath.Join(api, ".env.production"), filepath.Join(api, ".env.local"),
+		filepath.Join(web, ".env.production"),
+	} {
+		if err := os.WriteFile(p, []byte("SECRET=plaintext\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	g, err := New(config.DefaultConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		filter BulkFilter
+		want   []string
+	}{
+		{"all", BulkFilter{}, []string{
+			filepath.Join(api, ".env"), filepath.Join(api, ".env.local"), filepath.Join(api, ".env.production"),
+			filepath.Join(web, ".env.production"),
+		}},
+		{"root", BulkFilter{Root: api, Exclude: []string{".env.local"}}, []string{
+			filepath.Join(api, ".env"), filepath.Join(api, ".env.production"),
+		}},
+		{"env", BulkFilter{Envs: []string{"production"}}, []string{
+			filepath.Join(api, ".env.production"), filepath.Join(web, ".env.production"),
+		}},
+		{"default env", BulkFilter{Envs: []string{""}}, []string{filepath.Join(api, ".env")}},
+		{"include", BulkFilter{Root: web, Include: []string{".env.prod*"}}, []string{filepath.Join(web, ".env.production")}},
+	}
+	for _, tt := range tests {
+		files, err := g.WatchedFiles(tt.filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, f := range files {
+			got = append(got, f.Path)
+		}
+		sort.Strings(tt.want)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: files = %v; want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// TestEncryptAll encrypts a file held by an edit session, ending the
+// session, as encrypt-all does after decrypt-all.
+func TestEncryptAll(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	installFakeBins(t, filepath.Join(t.TempDir(), "encrypt-started"))
+	t.Setenv("ENVDRIFT_AGENT_FAKE_ENVDRIFT", "ok")
+	proj := makeProject(t)
+	path := filepath.Join(proj, ".env")
+	if err := os.WriteFile(path, []byte("SECRET=plaintext\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := exports.Add(exports.Export{Path: path, Source: path, Expires: time.Now().Add(time.Hour), Action: exports.ActionEncrypt, Edit: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	g, err := New(config.DefaultConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := g.EncryptAll(context.Background(), []WatchedFile{{Project: proj, Path: path}}, 1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(res, RunOnceResult{Encrypted: 1}) {
+		t.Errorf("result = %+v; want 1 encrypted", res)
+	}
+	if _, held := exports.Pending(path, time.Now()); held {
+		t.Error("the edit session must end")
+	}
+}
@@ -0,0 +1,80 @@
+package guardian
+
+import (
+	"log"
+	"time"
+)
+
+// clockJumpTolerance is how far the wall time between two idle checks may
+// stray from the check interval before it counts as a suspend or a clock
+// change rather than a slow check.
+const clockJumpTolerance = time.Minute
+
+// resumeTimers keeps idle timers counting only time the agent was running.
+// Idle checks end at most checkTick apart, so a longer wall-clock gap since
+// the last one means the machine slept (or the clock was set forward): the
+// files tracked before it have their modification times moved forward by
+// the gap, so they are not all encrypted the moment the machine wakes, and
+// their idle time from before the sleep is kept. A clock set back leaves
+// modification times in the future, which would hold files in plaintext
+// until it caught up; those are moved back to now. Only the idle-check
+// worker calls it, before checkIdleFiles.
+func (g *Guardian) resumeTimers(now time.Time) {
+	if g.checkedAt.IsZero() {
+		return
+	}
+	wall := now.Round(0)
+	gap := wall.Sub(g.checkedAt) - g.checkTick
+	switch {
+	case gap > clockJumpTolerance:
+		log.Printf("No idle check for %v (sleep, or the clock was set forward); idle timers paused for it",
+			gap.Round(time.Second))
+		// Anything tracked before the next tick was due was tracked before
+		// the gap; later entries are from after the resume.
+		before := g.checkedAt.Add(g.checkTick)
+		for _, pw := range g.watchers() {
+			pw.shiftTimers(before, gap)
+		}
+	case wall.Before(g.checkedAt.Add(-clockJumpTolerance)):
+		log.Printf("The clock was set back %v; idle timers ahead of it restart now",
+			g.checkedAt.Sub(wall).Round(time.Second))
+		for _, pw := range g.watchers() {
+			pw.clampTimers(wall)
+		}
+	}
+}
+
+// watchers returns the project watchers.
+func (g *Guardian) watchers() []*ProjectWatcher {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	list := make([]*ProjectWatcher, 0, len(g.projects))
+	for _, pw := range g.projects {
+		list = append(list, pw)
+	}
+	return list
+}
+
+// shiftTimers moves the modification times recorded before cutoff forward
+// by d. The result drops any monotonic reading: that clock stops during sleep
+// on some platforms and not on others, so idleFiles must compare wall times.
+func (pw *ProjectWatcher) shiftTimers(cutoff time.Time, d time.Duration) {
+	pw.mu.Lock()
+	defer pw.mu.Unlock()
+	for path, modTime := range pw.lastMod {
+		if !modTime.After(cutoff) {
+			pw.lastMod[path] = modTime.Round(0).Add(d)
+		}
+	}
+}
+
+// clampTimers moves the modification times after now back to now.
+func (pw *ProjectWatcher) clampTimers(now time.Time) {
+	pw.mu.Lock()
+	defer pw.mu.Unlock()
+	for path, modTime := range pw.lastMod {
+		if modTime.After(now) {
+			pw.lastMod[path] = now
+		}
+	}
+}
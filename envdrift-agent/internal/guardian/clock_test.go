@@ -0,0 +1,144 @@
+package guardian
+
+import (
+	"io"
+	"log"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jainal09/envdrift-agent/internal/clock"
+)
+
+// TestResumeTimers_Sleep simulates an 8-hour suspend between two idle checks:
+// a file edited a minute before the sleep is not idle on wake, its idle time
+// from before the sleep is kept, and a file edited after the wake is left
+// alone.
+func TestResumeTimers_Sleep(t *testing.T) {
+	prevOut := log.Writer()
+	log.SetOutput(io.Discard)
+	t.Cleanup(func() { log.SetOutput(prevOut) })
+
+	f := newIdleCheckFixture(t, "ok")
+	f.pw.config.IdleTimeout = 5 * time.Minute
+	now := time.Now().Round(0)
+	const sleep = 8 * time.Hour
+	f.g.checkedAt = now.Add(-sleep)
+	before := filepath.Join(f.projectDir, ".env")
+	f.pw.TrackFile(before, f.g.checkedAt.Add(-time.Minute))
+	after := filepath.Join(f.projectDir, ".env.local")
+	f.pw.TrackFile(after, now.Add(-10*time.Second))
+
+	if idle := f.pw.GetIdleFiles(); len(idle) != 1 {
+		t.Fatalf("before resumeTimers idle = %v; want the file edited before the sleep", idle)
+	}
+	f.g.resumeTimers(now)
+	if idle := f.pw.GetIdleFiles(); len(idle) != 0 {
+		t.Errorf("after a sleep idle = %v; want none", idle)
+	}
+	gap := sleep - f.g.checkTick
+	if got, _ := f.pw.trackedModTime(before); !got.Equal(f.g.checkedAt.Add(-time.Minute + gap)) {
+		t.Errorf("timer of the file edited before the sleep = %v; want shifted by %v", got, gap)
+	}
+	if got, _ := f.pw.trackedModTime(after); !got.Equal(now.Add(-10 * time.Second)) {
+		t.Errorf("timer of the file edited after the wake = %v; want unchanged", got)
+	}
+}
+
+// TestResumeTimers_ClockSetBack simulates the clock set back two hours:
+// timers left in the future restart now instead of waiting for the clock.
+func TestResumeTimers_ClockSetBack(t *testing.T) {
+	prevOut := log.Writer()
+	log.SetOutput(io.Discard)
+	t.Cleanup(func() { log.SetOutput(prevOut) })
+
+	f := newIdleCheckFixture(t, "ok")
+	now := time.Now().Round(0)
+	f.g.checkedAt = now.Add(2 * time.Hour)
+	ahead := filepath.Join(f.projectDir, ".env")
+	f.pw.mu.Lock()
+	f.pw.lastMod[ahead] = now.Add(time.Hour)
+	f.pw.mu.Unlock()
+	past := filepath.Join(f.projectDir, ".env.local")
+	f.pw.TrackFile(past, now.Add(-time.Minute))
+
+	f.g.resumeTimers(now)
+	if got, _ := f.pw.trackedModTime(ahead); !got.Equal(now) {
+		t.Errorf("timer ahead of the clock = %v; want now", got)
+	}
+	if got, _ := f.pw.trackedModTime(past); !got.Equal(now.Add(-time.Minute)) {
+		t.Errorf("timer behind the clock = %v; want unchanged", got)
+	}
+}
+
+// TestResumeTimers_NoGap covers consecutive checks: nothing moves, however
+// long the tracked files have been idle.
+func TestResumeTimers_NoGap(t *testing.T) {
+	f := newIdleCheckFixture(t, "ok")
+	now := time.Now().Round(0)
+	f.g.checkedAt = now.Add(-f.g.checkTick)
+	path := filepath.Join(f.projectDir, ".env")
+	f.pw.TrackFile(path, now.Add(-time.Hour))
+
+	f.g.resumeTimers(now)
+	if got, _ := f.pw.trackedModTime(path); !got.Equal(now.Add(-time.Hour)) {
+		t.Errorf("timer = %v; want unchanged", got)
+	}
+}
+
+// TestTrackFile_FutureModTime covers a file stamped by a host whose clock
+// runs ahead: its idle clock starts now.
+func TestTrackFile_FutureModTime(t *testing.T) {
+	f := newIdleCheckFixture(t, "ok")
+	path := filepath.Join(f.projectDir, ".env")
+	f.pw.TrackFile(path, time.Now().Add(24*time.Hour))
+	if got, _ := f.pw.trackedModTime(path); got.After(time.Now()) {
+		t.Errorf("tracked modification time = %v; want no later than now", got)
+	}
+}
+
+// TestIdleFiles_FakeClock drives the idle timeout with a fake clock: a file
+// becomes idle exactly when idle_timeout has passed since its last change.
+func TestIdleFiles_FakeClock(t *testing.T) {
+	f := newIdleCheckFixture(t, "ok")
+	f.pw.config.IdleTimeout = 5 * time.Minute
+	fake := clock.NewFake(time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC))
+	f.g.clock = fake
+	f.pw.SetClock(fake)
+
+	path := filepath.Join(f.projectDir, ".env")
+	f.pw.TrackFile(path, fake.Now())
+	fake.Advance(5*time.Minute - time.Second)
+	if idle := f.pw.GetIdleFiles(); len(idle) != 0 {
+		t.Fatalf("idle = %v a second before the timeout", idle)
+	}
+	fake.Advance(time.Second)
+	if idle := f.pw.GetIdleFiles(); len(idle) != 1 {
+		t.Fatalf("idle = %v at the timeout; want the file", idle)
+	}
+
+	// A stamp ahead of the fake clock restarts the timer at its time.
+	f.pw.TrackFile(path, fake.Now().Add(time.Hour))
+	if got, _ := f.pw.trackedModTime(path); !got.Equal(fake.Now()) {
+		t.Errorf("tracked modification time = %v; want %v", got, fake.Now())
+	}
+}
+
+// TestCheckInterval checks that a short idle timeout is checked more often
+// than every 30 seconds, but never more than once a second.
+func TestCheckInterval(t *testing.T) {
+	tests := []struct {
+		idle, want time.Duration
+	}{
+		{5 * time.Minute, 30 * time.Second},
+		{time.Minute, 30 * time.Second},
+		{20 * time.Second, 10 * time.Second},
+		{time.Second, time.Second},
+		{0, 30 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := checkInterval(tt.idle); got != tt.want {
+			t.Errorf("checkInterval(%v) = %v, want %v", tt.idle, got, tt.want)
+		}
+	}
+}
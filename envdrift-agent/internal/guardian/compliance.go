@@ -0,0 +1,170 @@
+package guardian
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/jainal09/envdrift-agent/internal/compliance"
+	"github.com/jainal09/envdrift-agent/internal/encrypt"
+	"github.com/jainal09/envdrift-agent/internal/identity"
+	"github.com/jainal09/envdrift-agent/internal/project"
+	"github.com/jainal09/envdrift-agent/internal/registry"
+	"github.com/jainal09/envdrift-agent/internal/severity"
+)
+
+// errReadOnly is returned by the operations that would modify files while
+// [compliance] read_only is set.
+var errReadOnly = errors.New("read-only compliance mode ([compliance] read_only): the agent does not modify files")
+
+// Compliance scans the env files of the enabled registered projects, as
+// run-once would, and reports which are in plaintext and which env or
+// .env.keys files other users can read. It modifies nothing.
+func (g *Guardian) Compliance(ctx context.Context) (compliance.Report, error) {
+	reg, err := registry.Load()
+	if err != nil {
+		return compliance.Report{}, err
+	}
+	files, projects := g.projectFiles(reg)
+	return g.complianceOf(ctx, files, projects)
+}
+
+// ComplianceOf is Compliance for the projects rooted at roots, registered or
+// not and whether or not their [guardian] is enabled: a CI job checks a
+// repository with the rules the agent would apply to it (the project's
+// envdrift.toml patterns, excludes and ignore_generated). A project config
+// that does not load is an error rather than a project skipped.
+func (g *Guardian) ComplianceOf(ctx context.Context, roots []string) (compliance.Report, error) {
+	configs := make(map[string]*project.GuardianConfig, len(roots))
+	for _, root := range roots {
+		cfg, err := project.LoadProjectConfigWithDefaults(root, g.projectDefaults())
+		if err != nil {
+			return compliance.Report{}, fmt.Errorf("%s: %w", root, err)
+		}
+		configs[root] = cfg
+	}
+	files, projects := g.filesOf(configs)
+	return g.complianceOf(ctx, files, projects)
+}
+
+// complianceOf reports on files, found in that many projects.
+func (g *Guardian) complianceOf(ctx context.Context, files []projectFile, projects int) (compliance.Report, error) {
+	host, _ := os.Hostname()
+	r := compliance.Report{
+		Generated: time.Now().UTC(),
+		Host:      host,
+		ReadOnly:  g.readOnly,
+		Projects:  projects,
+	}
+	var keyFiles []string
+	seenKeys := map[string]bool{}
+	for _, f := range files {
+		if ctx.Err() != nil {
+			return r, ctx.Err()
+		}
+		info, err := os.Stat(f.path)
+		if err != nil {
+			continue
+		}
+		encrypted, err := encrypt.IsEncrypted(f.path)
+		if err != nil {
+			log.Printf("Compliance: checking %s: %v", f.path, err)
+			continue
+		}
+		r.EnvFiles++
+		if encrypted {
+			r.Encrypted++
+		} else {
+			worst, _, err := severity.File(f.path)
+			if err != nil {
+				log.Printf("Compliance: classifying %s: %v", f.path, err)
+			}
+			r.Plaintext = append(r.Plaintext, compliance.File{Path: f.path, Project: f.project, Modified: info.ModTime().UTC(),
+				Severity: worst.Level.String(), Reason: describeFinding(worst)})
+		}
+		if issue, ok := compliance.CheckPermissions(f.path); ok {
+			r.Permissions = append(r.Permissions, issue)
+		}
+		if keys := filepath.Join(filepath.Dir(f.path), ".env.keys"); !seenKeys[keys] {
+			seenKeys[keys] = true
+			keyFiles = append(keyFiles, keys)
+		}
+	}
+	sort.Strings(keyFiles)
+	for _, path := range keyFiles {
+		if issue, ok := compliance.CheckPermissions(path); ok {
+			r.Permissions = append(r.Permissions, issue)
+		}
+	}
+	return r, nil
+}
+
+// complianceLoop refreshes the compliance report every [compliance]
+// interval: compliance.json for status, the metrics file and the webhook
+// when configured. A webhook post due while offline is queued and retried on
+// the next pass.
+func (g *Guardian) complianceLoop(ctx context.Context) {
+	cfg := g.globalConfig.Compliance
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+	for {
+		g.reportCompliance(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// reportCompliance runs one scan and publishes it.
+func (g *Guardian) reportCompliance(ctx context.Context) {
+	cfg := g.globalConfig.Compliance
+	r, err := g.Compliance(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("Compliance scan: %v", err)
+		}
+		return
+	}
+	log.Printf("Compliance: %s", r.Summary())
+	id, err := identity.Ensure(identity.DefaultPath(), g.globalConfig.Identity.Keypair)
+	if err != nil {
+		log.Printf("Agent identity: %v", err)
+	} else {
+		r.AgentID = id.ID
+	}
+	if err := compliance.Save(compliance.DefaultPath(), r); err != nil {
+		log.Printf("Saving compliance report: %v", err)
+	}
+	if cfg.MetricsFile != "" {
+		if err := compliance.WriteMetrics(cfg.MetricsFile, r); err != nil {
+			log.Printf("Writing compliance metrics: %v", err)
+		}
+	}
+	if cfg.Webhook == "" || g.queueIfOffline("compliance report") {
+		return
+	}
+	if id == nil {
+		// Without it the fleet cannot tell whose report it is.
+		log.Printf("Not sending the compliance report: no agent identity")
+		return
+	}
+	if err := compliance.Send(ctx, cfg.Webhook, r, id); err != nil {
+		log.Printf("Sending compliance report: %v", err)
+	}
+}
+
+// describeFinding is what a compliance report says made a file as severe as
+// it is: the variable and the reason, never its value.
+func describeFinding(f severity.Finding) string {
+	if f.Key == "" {
+		return f.Reason
+	}
+	return f.Key + ": " + f.Reason
+}
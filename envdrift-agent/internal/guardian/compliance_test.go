@@ -0,0 +1,101 @@
+package guardian
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/jainal09/envdrift-agent/internal/config"
+)
+
+// TestCompliance reports the plaintext file, counts the encrypted one and
+// flags the env and key files other users can read, without touching them.
+func TestCompliance(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	proj := makeProject(t)
+	writeRegistry(t, home, proj)
+	for name, content := range map[string]string{
+		".env":            "SECRET=plaintext\n",
+		".env.production": "SECRET=\"encrypted:abc123\"\n",
+		".env.keys":       "DOTENV_PRIVATE_KEY_PRODUCTION=abc\n",
+	} {
+		path := filepath.Join(proj, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Chmod(filepath.Join(proj, ".env.keys"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.Compliance.ReadOnly = true
+	g, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	r, err := g.Compliance(context.Background())
+	if err != nil {
+		t.Fatalf("Compliance: %v", err)
+	}
+	if !r.ReadOnly || r.Projects != 1 || r.EnvFiles != 2 || r.Encrypted != 1 {
+		t.Fatalf("report = %+v, want 1 project with 2 env files, 1 encrypted", r)
+	}
+	if len(r.Plaintext) != 1 || r.Plaintext[0].Path != filepath.Join(proj, ".env") || r.Plaintext[0].Project != proj {
+		t.Errorf("plaintext = %+v, want just .env", r.Plaintext)
+	}
+	if runtime.GOOS != "windows" {
+		if len(r.Permissions) != 1 || r.Permissions[0].Path != filepath.Join(proj, ".env.keys") {
+			t.Errorf("permissions = %+v, want just .env.keys", r.Permissions)
+		}
+	}
+	if data, _ := os.ReadFile(filepath.Join(proj, ".env")); string(data) != "SECRET=plaintext\n" {
+		t.Errorf("the scan modified .env: %q", data)
+	}
+}
+
+// TestCheckIdleFiles_ReadOnly leaves an idle plaintext file alone in
+// read-only compliance mode and stops tracking it.
+func TestCheckIdleFiles_ReadOnly(t *testing.T) {
+	prevOut := log.Writer()
+	log.SetOutput(io.Discard)
+	t.Cleanup(func() { log.SetOutput(prevOut) })
+
+	f := newIdleCheckFixture(t, "ok")
+	f.g.readOnly = true
+	path := f.trackIdle(t, ".env", "SECRET=plaintext\n")
+
+	f.g.checkIdleFiles(context.Background())
+
+	if _, err := os.Stat(f.marker); err == nil {
+		t.Error("envdrift encrypt must not run in read-only mode")
+	}
+	if f.tracked(path) {
+		t.Error("a file left in plaintext by read-only mode must not stay tracked")
+	}
+}
+
+// TestRunOnce_ReadOnly refuses to sweep in read-only compliance mode.
+func TestRunOnce_ReadOnly(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	cfg := config.DefaultConfig()
+	cfg.Compliance.ReadOnly = true
+	g, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if _, err := g.RunOnce(context.Background(), 0, nil); !errors.Is(err, errReadOnly) {
+		t.Errorf("RunOnce = %v, want errReadOnly", err)
+	}
+}
@@ -0,0 +1,123 @@
+package guardian
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jainal09/envdrift-agent/internal/i18n"
+	"github.com/jainal09/envdrift-agent/internal/journal"
+	"github.com/jainal09/envdrift-agent/internal/severity"
+	"github.com/jainal09/envdrift-agent/internal/watcher"
+)
+
+// configScanLoop scans every watched project's config files each interval
+// ([guardian] config_scan_interval) until ctx is cancelled, and once right
+// away.
+func (g *Guardian) configScanLoop(ctx context.Context, interval time.Duration) {
+	seen := make(map[string]time.Time)
+	g.configScan(seen)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			g.configScan(seen)
+		}
+	}
+}
+
+// configScan warns of the values that look like secrets in the YAML, JSON
+// and TOML files of the watched projects (see severity.ScanConfig): each is
+// logged and journaled, and notified when at least [severity] notify. It
+// never encrypts or changes a config file. seen holds the modification time
+// of each file already scanned, which is read again only once it changes,
+// and loses the files that are gone. It returns how many values it warned
+// of.
+func (g *Guardian) configScan(seen map[string]time.Time) int {
+	g.mu.RLock()
+	projects := make(map[string]*ProjectWatcher, len(g.projects))
+	for k, v := range g.projects {
+		projects[k] = v
+	}
+	g.mu.RUnlock()
+	projectPaths := make([]string, 0, len(projects))
+	for path := range projects {
+		projectPaths = append(projectPaths, path)
+	}
+	sort.Strings(projectPaths)
+
+	warned := 0
+	found := make(map[string]bool)
+	for _, projectPath := range projectPaths {
+		pw := projects[projectPath]
+		for _, path := range configFiles(projectPath, pw.config.Exclude, pw.config.IgnoreGenerated) {
+			found[path] = true
+			info, err := os.Stat(path)
+			if err != nil || seen[path].Equal(info.ModTime()) {
+				continue
+			}
+			seen[path] = info.ModTime()
+			findings, err := severity.ScanConfig(path)
+			if err != nil {
+				log.Printf("[%s] Config scan: reading %s: %v", projectPath, path, err)
+				continue
+			}
+			for _, f := range findings {
+				what := f.Level.Describe()
+				if f.Key != "" {
+					what = fmt.Sprintf("%s (%s)", what, f.Key)
+				}
+				log.Printf("[%s] Config scan: possible %s in %s, line %d", projectPath, what, path, f.Line)
+				g.record(journal.KindSecretInConfig, projectPath, path,
+					fmt.Sprintf("line %d: %s, %s", f.Line, f.Level, describeFinding(f)))
+				if g.notifyWarning != nil && g.shouldNotify(pw) && f.Level >= g.notifyLevel() {
+					_ = g.notifyWarning(i18n.T("guardian.config_secret", what, path, f.Line))
+				}
+				warned++
+			}
+		}
+	}
+	for path := range seen {
+		if !found[path] {
+			delete(seen, path)
+		}
+	}
+	return warned
+}
+
+// configFiles walks a project for the config files ScanConfig reads, the
+// way its watcher walks for env files: skipping hidden directories, and
+// generated ones when ignoreGenerated, and the files exclude matches.
+func configFiles(projectPath string, exclude []string, ignoreGenerated bool) []string {
+	root := filepath.Clean(projectPath)
+	var files []string
+	_ = filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			name := d.Name()
+			if path != root && (strings.HasPrefix(name, ".") || (ignoreGenerated && watcher.IsGeneratedDir(name))) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() || !severity.IsConfigFile(path) {
+			return nil
+		}
+		if _, excluded := watcher.Match(path, severity.ConfigPatterns, exclude); excluded != "" {
+			return nil
+		}
+		files = append(files, path)
+		return nil
+	})
+	return files
+}
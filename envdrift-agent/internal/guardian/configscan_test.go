@@ -0,0 +1,72 @@
+package guardian
+
+import (
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConfigScan(t *testing.T) {
+	prevOut := log.Writer()
+	log.SetOutput(io.Discard)
+	t.Cleanup(func() { log.SetOutput(prevOut) })
+
+	f := newIdleCheckFixture(t, "ok")
+	f.pw.config.Notify = true
+	var notes []string
+	f.g.notifyWarning = func(msg string) error {
+		notes = append(notes, msg)
+		return nil
+	}
+	token := "ghp_" + strings.Repeat("a1B2", 9)
+	write := func(name, content string) string {
+		path := filepath.Join(f.projectDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	values := write("deploy/values.yaml", "replicas: 2\ngithub_token: "+token+"\n")
+	write("node_modules/pkg/config.json", `{"token": "`+token+`"}`)
+	write(".github/settings.yml", "token: "+token+"\n")
+
+	seen := make(map[string]time.Time)
+	if n := f.g.configScan(seen); n != 1 {
+		t.Fatalf("configScan warned of %d values; want the one in deploy/values.yaml", n)
+	}
+	if len(notes) != 1 || !strings.Contains(notes[0], "API token (github_token)") || !strings.Contains(notes[0], "line 2") {
+		t.Errorf("notifications = %q", notes)
+	}
+	if strings.Contains(strings.Join(notes, ""), token) {
+		t.Error("a notification holds the value")
+	}
+	if f.tracked(values) {
+		t.Error("a config file must never be tracked for encryption")
+	}
+
+	if n := f.g.configScan(seen); n != 0 {
+		t.Errorf("rescanning an unchanged file warned of %d values", n)
+	}
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(values, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if n := f.g.configScan(seen); n != 1 {
+		t.Errorf("a changed file warned of %d values; want 1", n)
+	}
+
+	if err := os.Remove(values); err != nil {
+		t.Fatal(err)
+	}
+	f.g.configScan(seen)
+	if _, ok := seen[values]; ok {
+		t.Error("a removed file must be forgotten")
+	}
+}
@@ -0,0 +1,38 @@
+package guardian
+
+import (
+	"log"
+	"runtime/debug"
+
+	"github.com/jainal09/envdrift-agent/internal/crash"
+	"github.com/jainal09/envdrift-agent/internal/i18n"
+)
+
+// crashDir is where recoverPanic writes reports; tests replace it.
+var crashDir = crash.Dir
+
+// recoverPanic is deferred at the top of Start and of every goroutine the
+// guardian spawns. It writes a redacted crash report and tells the user where
+// it is, then re-panics: the agent still exits and the service manager
+// restarts it, exactly as without the report.
+func (g *Guardian) recoverPanic() {
+	r := recover()
+	if r == nil {
+		return
+	}
+	path, err := crash.Write(crashDir(), crash.Report{
+		Version: g.Version,
+		Panic:   r,
+		Stack:   debug.Stack(),
+		Config:  g.globalConfig,
+	})
+	if err != nil {
+		log.Printf("Writing crash report: %v", err)
+	} else {
+		log.Printf("Crash report written to %s", path)
+		if g.globalConfig.Guardian.Notify {
+			_ = g.notifyError(i18n.T("guardian.crashed", path))
+		}
+	}
+	panic(r)
+}
@@ -0,0 +1,53 @@
+package guardian
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/jainal09/envdrift-agent/internal/config"
+	"github.com/jainal09/envdrift-agent/internal/crash"
+)
+
+// TestRecoverPanic_WritesReportAndRepanics checks that a panic in a guardian
+// goroutine leaves a crash report and a notification behind and still
+// propagates, so the service manager restarts the agent as before.
+func TestRecoverPanic_WritesReportAndRepanics(t *testing.T) {
+	dir := t.TempDir()
+	orig := crashDir
+	crashDir = func() string { return dir }
+	t.Cleanup(func() { crashDir = orig })
+
+	g, err := New(config.DefaultConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.Version = "1.1.4"
+	var notified []string
+	g.notifyError = func(msg string) error {
+		notified = append(notified, msg)
+		return nil
+	}
+
+	func() {
+		defer func() {
+			if r := recover(); r != "boom" {
+				t.Errorf("re-panicked with %v; want boom", r)
+			}
+		}()
+		defer g.recoverPanic()
+		panic("boom")
+	}()
+
+	path, err := crash.Latest(dir)
+	if err != nil || path == "" {
+		t.Fatalf("no crash report written: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), "Panic:    boom") || !strings.Contains(string(data), "Version:  1.1.4") {
+		t.Errorf("report = %s", data)
+	}
+	if len(notified) != 1 || !strings.Contains(notified[0], "report-bug") {
+		t.Errorf("notifications = %v", notified)
+	}
+}
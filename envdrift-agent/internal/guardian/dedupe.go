@@ -0,0 +1,34 @@
+package guardian
+
+import "os"
+
+// fileSet collects files by identity rather than by name, so one file
+// reached through overlapping project roots, a symlink or a hard link is
+// handled once per pass. TrackFile does the same within one project.
+type fileSet struct {
+	names map[string]bool
+	infos []os.FileInfo
+}
+
+// add reports whether path is a file not yet in the set, and adds it. A path
+// that cannot be stat'ed is compared by name only.
+func (s *fileSet) add(path string) bool {
+	if s.names[path] {
+		return false
+	}
+	if s.names == nil {
+		s.names = make(map[string]bool)
+	}
+	s.names[path] = true
+	info, err := os.Stat(path)
+	if err != nil {
+		return true
+	}
+	for _, other := range s.infos {
+		if os.SameFile(info, other) {
+			return false
+		}
+	}
+	s.infos = append(s.infos, info)
+	return true
+}
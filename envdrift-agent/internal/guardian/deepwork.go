@@ -0,0 +1,35 @@
+package guardian
+
+import (
+	"log"
+	"time"
+)
+
+// deepWorkState reports whether plaintext files are held back at now for
+// [guardian] deep_work_hours, and until when: in one of the windows with
+// the screen unlocked. inWindow is set in a window whatever the screen; the
+// lock probe only runs then.
+func (g *Guardian) deepWorkState(now time.Time) (until time.Time, inWindow, hold bool) {
+	if !g.deepWork.Contains(now) {
+		return time.Time{}, false, false
+	}
+	return g.deepWork.End(now), true, g.screenLocked == nil || !g.screenLocked()
+}
+
+// deepWorkHold is deepWorkState for the idle check, which logs the start
+// and end of each hold once.
+func (g *Guardian) deepWorkHold(now time.Time) (until time.Time, hold bool) {
+	until, inWindow, hold := g.deepWorkState(now)
+	if hold != g.deepWorking {
+		switch {
+		case hold:
+			log.Printf("Deep work hours until %s; holding idle files unless the screen is locked", until.Format("15:04"))
+		case inWindow:
+			log.Println("Deep work hours, but the screen is locked; encrypting idle files")
+		default:
+			log.Println("Deep work hours over; encrypting idle files again")
+		}
+		g.deepWorking = hold
+	}
+	return until, hold
+}
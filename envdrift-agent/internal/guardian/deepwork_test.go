@@ -0,0 +1,49 @@
+package guardian
+
+import (
+	"context"
+	"io"
+	"log"
+	"os"
+	"testing"
+
+	"github.com/jainal09/envdrift-agent/internal/schedule"
+)
+
+// TestCheckIdleFiles_DeepWork covers [guardian] deep_work_hours: in a window
+// an idle file stays tracked in plaintext while the screen is unlocked, and
+// is encrypted once it locks.
+func TestCheckIdleFiles_DeepWork(t *testing.T) {
+	prevOut := log.Writer()
+	log.SetOutput(io.Discard)
+	t.Cleanup(func() { log.SetOutput(prevOut) })
+
+	f := newIdleCheckFixture(t, "ok")
+	var err error
+	if f.g.deepWork, err = schedule.ParseAll([]string{"00:00-24:00"}); err != nil {
+		t.Fatal(err)
+	}
+	locked := false
+	f.g.screenLocked = func() bool { return locked }
+	path := f.trackIdle(t, ".env", "SECRET=plaintext\n")
+
+	f.g.checkIdleFiles(context.Background())
+	if _, err := os.Stat(f.marker); err == nil {
+		t.Fatal("a file must not be encrypted in deep work hours with the screen unlocked")
+	}
+	if !f.tracked(path) {
+		t.Fatal("a held file must stay tracked for a later check")
+	}
+	if !f.g.deepWorking {
+		t.Error("deepWorking not set while holding files")
+	}
+
+	locked = true
+	f.g.checkIdleFiles(context.Background())
+	if _, err := os.Stat(f.marker); err != nil {
+		t.Fatalf("envdrift encrypt was not invoked once the screen locked: %v", err)
+	}
+	if f.g.deepWorking {
+		t.Error("deepWorking still set with the screen locked")
+	}
+}
@@ -0,0 +1,248 @@
+package guardian
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jainal09/envdrift-agent/internal/encrypt"
+	"github.com/jainal09/envdrift-agent/internal/exports"
+	"github.com/jainal09/envdrift-agent/internal/gitstate"
+	"github.com/jainal09/envdrift-agent/internal/lockcheck"
+	"github.com/jainal09/envdrift-agent/internal/paths"
+	"github.com/jainal09/envdrift-agent/internal/project"
+	"github.com/jainal09/envdrift-agent/internal/registry"
+	"github.com/jainal09/envdrift-agent/internal/watcher"
+)
+
+// Check is one step of an Explanation.
+type Check struct {
+	// Name is what was checked, e.g. "pattern".
+	Name string
+	// Result is what the check found.
+	Result string
+	// Blocks is set when the check keeps the file from being encrypted.
+	Blocks bool
+}
+
+// Explanation is how the guardian, with the current configuration, treats
+// one file: the checks it applies, in order, up to the first that blocks.
+type Explanation struct {
+	Checks []Check
+	// Verdict sums the checks up.
+	Verdict string
+}
+
+// Explain evaluates the current configuration against path, which must be
+// absolute: the registered project containing it, the active profile, the
+// project's patterns and excludes, the symlink policy, and the state the idle
+// check looks at (encrypted, held by an edit or export session, in a project's
+// onboarding grace period, mid-git, held back by encrypt_when, open in another
+// process).
+func (g *Guardian) Explain(ctx context.Context, path string) (Explanation, error) {
+	ex, cfg, projectPath, err := g.explainName(path, false)
+	if err != nil || ex.Verdict != "" {
+		return ex, err
+	}
+	stop := func(name, result, verdict string) (Explanation, error) {
+		ex.Checks = append(ex.Checks, Check{name, result, true})
+		ex.Verdict = verdict
+		return ex, nil
+	}
+	pass := func(name, format string, args ...any) {
+		ex.Checks = append(ex.Checks, Check{Name: name, Result: fmt.Sprintf(format, args...)})
+	}
+
+	linfo, err := os.Lstat(path)
+	if err != nil {
+		return stop("file", "does not exist", "watched; encrypted once it is written and idle")
+	}
+	if linfo.Mode()&os.ModeSymlink != 0 {
+		if cfg.Symlinks == project.SymlinksSkip {
+			return stop("symlink", "a symlink, and symlinks = \"skip\"", "not watched")
+		}
+		pass("symlink", "a symlink, followed (symlinks = \"follow\")")
+	}
+	if info, err := os.Stat(path); err != nil || !info.Mode().IsRegular() {
+		return stop("file", "not a regular file", "not watched")
+	}
+
+	fs, err := encrypt.Classify(path)
+	if err != nil {
+		return stop("encryption", err.Error(), "watched; cannot be read")
+	}
+	if fs.State == encrypt.Encrypted && fs.Provider == "" {
+		pass("encryption", "only variables allowed to stay plaintext (plaintext_allowed)")
+		ex.Verdict = "watched; nothing to encrypt"
+		return ex, nil
+	}
+	if fs.State == encrypt.Encrypted {
+		pass("encryption", "encrypted (%s)", fs.Provider)
+		ex.Verdict = "watched; already encrypted"
+		return ex, nil
+	}
+	pass("encryption", "%s", describeState(fs))
+
+	if e, held := exports.Pending(path, time.Now()); held {
+		kind := "an export"
+		if e.Edit {
+			kind = "an edit session"
+		}
+		return stop("snoozed", fmt.Sprintf("held in plaintext by %s until %s", kind, e.Expires.Local().Format(time.Kitchen)),
+			"watched; encrypted when the session ends")
+	}
+	pass("snoozed", "no")
+	if root, ok := g.observes(path); ok {
+		return stop("mode", root+" is in observe mode", "watched; reported, never encrypted on its own")
+	}
+	if r, ok := observedRoot(g.observing(time.Now()), path); ok {
+		return stop("onboarding", fmt.Sprintf("%s is a new project, observed until %s", r.Path, r.Until.Local().Format(time.RFC822)),
+			"watched; encrypted once idle after the grace period or 'envdrift-agent approve'")
+	}
+	if op := gitstate.InProgress(path); op != "" {
+		return stop("git", "git "+op+" in progress", "watched; encrypted once git is done")
+	}
+	pass("git", "no operation in progress")
+	if g.rule != nil {
+		if ok, why := g.ruleAllows(projectPath, path); !ok {
+			return stop("encrypt_when", why, "watched; encrypted once encrypt_when is true")
+		}
+		pass("encrypt_when", "true")
+	}
+	if len(g.deepWork) > 0 {
+		switch until, inWindow, hold := g.deepWorkState(time.Now()); {
+		case hold:
+			return stop("deep work", "in deep_work_hours until "+until.Format("15:04"),
+				"watched; encrypted once idle after deep work hours, or while the screen is locked")
+		case inWindow:
+			pass("deep work", "in deep_work_hours, but the screen is locked")
+		default:
+			pass("deep work", "outside deep_work_hours")
+		}
+	}
+	if lockcheck.IsFileOpen(path) {
+		return stop("locked", "open in another process", "watched; encrypted once it is closed")
+	}
+	pass("locked", "no")
+
+	g.refreshPolicy(ctx)
+	timeout := cfg.IdleTimeout
+	if g.policy.IdleTimeout > 0 && g.policy.IdleTimeout < timeout {
+		timeout = g.policy.IdleTimeout
+	}
+	ex.Verdict = fmt.Sprintf("watched; encrypted once idle for %v", timeout)
+	return ex, nil
+}
+
+// Simulate evaluates the configuration against path by name alone, for
+// sample paths that need not exist on this machine: the checks of Explain up
+// to the exclude patterns. A path outside the registered projects is judged
+// by the global [guardian] defaults as if its project had opted in.
+func (g *Guardian) Simulate(path string) (Explanation, error) {
+	ex, cfg, _, err := g.explainName(path, true)
+	if err != nil || ex.Verdict != "" {
+		return ex, err
+	}
+	ex.Verdict = fmt.Sprintf("watched; encrypted once idle for %v", cfg.IdleTimeout)
+	return ex, nil
+}
+
+// explainName runs the checks that depend on path's name and the
+// configuration only. A set Verdict means a check blocked; otherwise cfg is
+// the project configuration that applies, and projectPath the registered
+// project containing path. With anyProject, a path outside the registered
+// projects is checked against the global defaults.
+func (g *Guardian) explainName(path string, anyProject bool) (ex Explanation, cfg *project.GuardianConfig, projectPath string, err error) {
+	stop := func(name, result, verdict string) (Explanation, *project.GuardianConfig, string, error) {
+		ex.Checks = append(ex.Checks, Check{name, result, true})
+		ex.Verdict = verdict
+		return ex, nil, "", nil
+	}
+	pass := func(name, format string, args ...any) {
+		ex.Checks = append(ex.Checks, Check{Name: name, Result: fmt.Sprintf(format, args...)})
+	}
+
+	reg, err := registry.Load()
+	if err != nil {
+		return ex, nil, "", err
+	}
+	for _, p := range reg.GetProjectPaths() {
+		if paths.Within(filepath.Clean(p), path) && len(p) > len(projectPath) {
+			projectPath = p
+		}
+	}
+	switch {
+	case projectPath != "":
+		pass("project", "%s", projectPath)
+		if g.profileRoots != nil && len(g.profilePaths([]string{projectPath})) == 0 {
+			return stop("profile", fmt.Sprintf("%s is outside profile %s's watch roots %v",
+				projectPath, g.globalConfig.ActiveProfile, g.profileRoots), "not watched")
+		}
+		cfg, err = project.LoadProjectConfigWithDefaults(projectPath, g.projectDefaults())
+		if err != nil {
+			return stop("project config", err.Error(), "not watched")
+		}
+		if !cfg.Enabled {
+			return stop("enabled", "the project's [guardian] enabled is not true", "not watched")
+		}
+		pass("enabled", "yes")
+
+		rel, _ := filepath.Rel(projectPath, filepath.Dir(path))
+		for _, dir := range strings.Split(rel, string(filepath.Separator)) {
+			if isHiddenDir(dir) {
+				return stop("directory", fmt.Sprintf("inside hidden directory %s, which is not watched", dir), "not watched")
+			}
+			if cfg.IgnoreGenerated && watcher.IsGeneratedDir(dir) {
+				return stop("generated", fmt.Sprintf("inside %s, dependencies or test fixtures, and ignore_generated = true", dir), "not watched")
+			}
+		}
+	case anyProject:
+		pass("project", "none registered; the global defaults apply")
+		cfg = g.projectDefaults()
+	default:
+		return stop("project", "not inside a registered project", "not watched")
+	}
+
+	include, excluded := watcher.Match(path, cfg.Patterns, cfg.Exclude)
+	if include == "" {
+		return stop("pattern", fmt.Sprintf("matches none of %v", cfg.Patterns), "not watched")
+	}
+	pass("pattern", "matches %q", include)
+	if excluded != "" {
+		result := fmt.Sprintf("matches exclude %q, which wins over %q", excluded, include)
+		if encrypt.IsTemplate(path) {
+			result += "; a template, kept in plaintext"
+		}
+		return stop("exclude", result, "not watched")
+	}
+	if watcher.IsEditorTemp(filepath.Base(path)) {
+		return stop("editor temp", "an editor's scratch file", "not watched")
+	}
+	if cfg.IgnoreGenerated && watcher.IsGenerated(filepath.Base(path)) {
+		return stop("generated", "generated by a framework from the env files, and ignore_generated = true", "not watched")
+	}
+	pass("exclude", "matches none of %v", cfg.Exclude)
+	return ex, cfg, projectPath, nil
+}
+
+// isHiddenDir reports whether a directory name is hidden, as the watcher
+// skips it below a project root.
+func isHiddenDir(name string) bool {
+	return name != "." && strings.HasPrefix(name, ".")
+}
+
+// describeState words a file state that is not fully encrypted for Explain.
+func describeState(fs encrypt.FileState) string {
+	switch fs.State {
+	case encrypt.Partial:
+		return fmt.Sprintf("partially encrypted (%s), with plaintext values", fs.Provider)
+	case encrypt.Template:
+		return "plaintext, named like a template"
+	case encrypt.Binary:
+		return "binary, not an env file"
+	}
+	return "plaintext"
+}
@@ -0,0 +1,74 @@
+package guardian
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jainal09/envdrift-agent/internal/config"
+)
+
+func TestExplain(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	installFakeBins(t, filepath.Join(t.TempDir(), "encrypt-started"))
+
+	proj := makeProject(t)
+	disabled := makeProjectWithToml(t, "[guardian]\nenabled = false\n")
+	writeRegistry(t, home, proj, disabled)
+	files := map[string]string{
+		".env":                     "SECRET=plaintext\n",
+		".env.production":          "SECRET=\"encrypted:abc123\"\n",
+		".env.example":             "SECRET=\n",
+		"config.yaml":              "secret: x\n",
+		".hidden/.env":             "SECRET=plaintext\n",
+		filepath.Join("a", ".env"): "SECRET=plaintext\n",
+		".env.local.php":           "<?php return [];\n",
+		filepath.Join("node_modules", "pkg", ".env"): "SECRET=plaintext\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(proj, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	g, err := New(config.DefaultConfig())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	tests := []struct {
+		path, verdict, last string
+	}{
+		{filepath.Join(proj, ".env"), "watched; encrypted once idle for 1s", "no"},
+		{filepath.Join(proj, "a", ".env"), "watched; encrypted once idle", "no"},
+		{filepath.Join(proj, ".env.production"), "watched; already encrypted", "encrypted"},
+		{filepath.Join(proj, ".env.example"), "not watched", `matches exclude ".env.example", which wins over ".env*"; a template`},
+		{filepath.Join(proj, "config.yaml"), "not watched", "matches none of"},
+		{filepath.Join(proj, ".hidden", ".env"), "not watched", "hidden directory .hidden"},
+		{filepath.Join(proj, ".env.local.php"), "not watched", "generated by a framework"},
+		{filepath.Join(proj, "node_modules", "pkg", ".env"), "not watched", "inside node_modules"},
+		{filepath.Join(proj, ".env.new"), "watched; encrypted once it is written", "does not exist"},
+		{filepath.Join(disabled, ".env"), "not watched", "enabled is not true"},
+		{filepath.Join(home, ".env"), "not watched", "not inside a registered project"},
+	}
+	for _, tt := range tests {
+		ex, err := g.Explain(context.Background(), tt.path)
+		if err != nil {
+			t.Fatalf("Explain(%s): %v", tt.path, err)
+		}
+		if !strings.HasPrefix(ex.Verdict, tt.verdict) {
+			t.Errorf("Explain(%s) verdict = %q, want %q", tt.path, ex.Verdict, tt.verdict)
+		}
+		last := ex.Checks[len(ex.Checks)-1]
+		if !strings.Contains(last.Result, tt.last) {
+			t.Errorf("Explain(%s) last check = %+v, want %q", tt.path, last, tt.last)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package guardian
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/jainal09/envdrift-agent/internal/exports"
+	"github.com/jainal09/envdrift-agent/internal/history"
+	"github.com/jainal09/envdrift-agent/internal/lockcheck"
+)
+
+// fileIsOpen is the lock check used for `edit` sessions; tests replace it.
+var fileIsOpen = lockcheck.IsFileOpen
+
+// expireExports acts on `export --expire` and `edit` files whose timer has
+// run out, deleting or re-encrypting them, and re-encrypts an `edit` file
+// early once the editor that had it open closes it. It runs on the
+// idle-check worker, so a slow re-encrypt is bounded like any other
+// (encryptTimeout).
+func (g *Guardian) expireExports(ctx context.Context) {
+	encryptFn := func(ctx context.Context, path string) error {
+		encCtx, cancel := context.WithTimeout(ctx, g.encryptTimeout)
+		defer cancel()
+		return g.encryptFile(encCtx, path)
+	}
+
+	done, err := exports.Expire(ctx, time.Now(), encryptFn)
+	if err != nil {
+		log.Printf("Expiring exports: %v", err)
+	}
+	for _, e := range done {
+		delete(g.editsOpen, e.Path)
+		g.recordExpiry(e, "timer")
+	}
+
+	pending, err := exports.List()
+	if err != nil {
+		return
+	}
+	if g.editsOpen == nil {
+		g.editsOpen = make(map[string]bool)
+	}
+	for _, e := range pending {
+		if !e.Edit || ctx.Err() != nil {
+			continue
+		}
+		if fileIsOpen(e.Path) {
+			g.editsOpen[e.Path] = true
+			continue
+		}
+		if !g.editsOpen[e.Path] {
+			continue
+		}
+		delete(g.editsOpen, e.Path)
+		if _, _, err := exports.Finish(ctx, e.Path, encryptFn); err != nil {
+			log.Printf("Re-encrypting closed edit %s: %v", e.Path, err)
+			continue
+		}
+		g.recordExpiry(e, "editor closed")
+	}
+}
+
+// recordExpiry logs an expired export and records it in history.
+func (g *Guardian) recordExpiry(e exports.Export, reason string) {
+	log.Printf("Export expired (%s): %s (%s)", reason, e.Path, e.Action)
+	if err := history.Record(history.Entry{Action: history.ActionExportExpired, Path: e.Path, Detail: e.Action}); err != nil {
+		log.Printf("Failed to record export expiry in history: %v", err)
+	}
+}
@@ -0,0 +1,85 @@
+package guardian
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jainal09/envdrift-agent/internal/config"
+	"github.com/jainal09/envdrift-agent/internal/exports"
+	"github.com/jainal09/envdrift-agent/internal/history"
+)
+
+func TestExpireExportsDeletesAndRecords(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	plain := filepath.Join(t.TempDir(), "plain.env")
+	if err := os.WriteFile(plain, []byte("SECRET=1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := exports.Add(exports.Export{Path: plain, Expires: time.Now().Add(-time.Second), Action: exports.ActionDelete}); err != nil {
+		t.Fatal(err)
+	}
+
+	g, err := New(config.DefaultConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.expireExports(context.Background())
+
+	if _, err := os.Stat(plain); !os.IsNotExist(err) {
+		t.Error("expired export was not deleted")
+	}
+	entries, _ := history.Read()
+	if len(entries) != 1 || entries[0].Action != history.ActionExportExpired || entries[0].Path != plain {
+		t.Errorf("history = %+v", entries)
+	}
+}
+
+func TestExpireExportsFinishesEditWhenEditorCloses(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	plain := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(plain, []byte("SECRET=1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	// ActionDelete keeps the test independent of the envdrift CLI; the
+	// early-finish logic is the same for either action.
+	if err := exports.Add(exports.Export{Path: plain, Expires: time.Now().Add(time.Hour), Action: exports.ActionDelete, Edit: true}); err != nil {
+		t.Fatal(err)
+	}
+	open := false
+	orig := fileIsOpen
+	fileIsOpen = func(string) bool { return open }
+	t.Cleanup(func() { fileIsOpen = orig })
+
+	g, err := New(config.DefaultConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Never seen open (e.g. an editor that does not hold the file): wait.
+	g.expireExports(context.Background())
+	if _, err := os.Stat(plain); err != nil {
+		t.Fatal("edit finished before the editor was ever seen")
+	}
+	open = true
+	g.expireExports(context.Background())
+	if _, held := exports.Pending(plain, time.Now()); !held {
+		t.Fatal("edit finished while the editor still had the file open")
+	}
+	open = false
+	g.expireExports(context.Background())
+	if _, err := os.Stat(plain); !os.IsNotExist(err) {
+		t.Error("edit not finished after the editor closed the file")
+	}
+	if _, held := exports.Pending(plain, time.Now()); held {
+		t.Error("finished edit still in the ledger")
+	}
+}
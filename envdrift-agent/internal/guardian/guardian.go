@@ -7,16 +7,36 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/jainal09/envdrift-agent/internal/backups"
+	"github.com/jainal09/envdrift-agent/internal/clock"
 	"github.com/jainal09/envdrift-agent/internal/config"
 	"github.com/jainal09/envdrift-agent/internal/encrypt"
+	"github.com/jainal09/envdrift-agent/internal/exports"
+	"github.com/jainal09/envdrift-agent/internal/gitstate"
+	"github.com/jainal09/envdrift-agent/internal/hooks"
+	"github.com/jainal09/envdrift-agent/internal/i18n"
+	"github.com/jainal09/envdrift-agent/internal/journal"
 	"github.com/jainal09/envdrift-agent/internal/lockcheck"
+	"github.com/jainal09/envdrift-agent/internal/netstate"
 	"github.com/jainal09/envdrift-agent/internal/notify"
+	"github.com/jainal09/envdrift-agent/internal/offline"
+	"github.com/jainal09/envdrift-agent/internal/pending"
+	"github.com/jainal09/envdrift-agent/internal/plugins"
+	"github.com/jainal09/envdrift-agent/internal/power"
 	"github.com/jainal09/envdrift-agent/internal/project"
 	"github.com/jainal09/envdrift-agent/internal/registry"
+	"github.com/jainal09/envdrift-agent/internal/rule"
+	"github.com/jainal09/envdrift-agent/internal/schedule"
+	"github.com/jainal09/envdrift-agent/internal/screenlock"
+	"github.com/jainal09/envdrift-agent/internal/severity"
+	"github.com/jainal09/envdrift-agent/internal/systemlog"
+	"github.com/jainal09/envdrift-agent/internal/telemetry"
+	"github.com/jainal09/envdrift-agent/internal/vaultsync"
 	"github.com/jainal09/envdrift-agent/internal/watcher"
 )
 
@@ -27,13 +47,39 @@ var errNoEnvdrift = fmt.Errorf("envdrift not found. Install it: pip install envd
 // encryption can never stall the other projects indefinitely (#494).
 const defaultEncryptTimeout = 2 * time.Minute
 
+// defaultDrainTimeout is how long shutdown waits for an encryption in flight
+// to finish before killing it, kept short so stopping the agent from a
+// terminal or a service manager stays prompt.
+const defaultDrainTimeout = 3 * time.Second
+
+// defaultCheckTick is how often idle files are checked for the default
+// idle_timeout.
+const defaultCheckTick = 30 * time.Second
+
+// checkInterval is how often idle files are checked for a global idle
+// timeout: every 30 seconds, or every half timeout when that is shorter so a
+// timeout of a few seconds is honored closely, but at most once a second.
+func checkInterval(idle time.Duration) time.Duration {
+	if half := idle / 2; half > 0 && half < defaultCheckTick {
+		return max(half, time.Second)
+	}
+	return defaultCheckTick
+}
+
 // ProjectWatcher manages watching a single project with its own config.
 type ProjectWatcher struct {
 	projectPath string
 	config      *project.GuardianConfig
 	watcher     *watcher.Watcher
 	lastMod     map[string]time.Time
-	mu          sync.RWMutex
+	// due holds files to verify on the next idle check however recently
+	// they changed (see MarkDue).
+	due map[string]bool
+	// firstSeen holds when each tracked file was first tracked.
+	firstSeen map[string]time.Time
+	// clock times idleness; nil is the system clock.
+	clock clock.Clock
+	mu    sync.RWMutex
 }
 
 // NewProjectWatcher creates a watcher for a single project.
@@ -42,15 +88,33 @@ func NewProjectWatcher(projectPath string, cfg *project.GuardianConfig) (*Projec
 	if err != nil {
 		return nil, err
 	}
+	w.SetSkipSymlinks(cfg.Symlinks == project.SymlinksSkip)
+	w.SetIgnoreGenerated(cfg.IgnoreGenerated)
+	// Coalesce each file's save burst into one event.
+	w.SetDebounce(cfg.Debounce)
 
 	return &ProjectWatcher{
 		projectPath: projectPath,
 		config:      cfg,
 		watcher:     w,
 		lastMod:     make(map[string]time.Time),
+		due:         make(map[string]bool),
+		firstSeen:   make(map[string]time.Time),
 	}, nil
 }
 
+// SetClock replaces the clock timing idleness and debounce windows, for
+// tests. Call it before Start.
+func (pw *ProjectWatcher) SetClock(c clock.Clock) {
+	pw.clock = c
+	pw.watcher.SetClock(clock.Or(c))
+}
+
+// now reads pw's clock.
+func (pw *ProjectWatcher) now() time.Time {
+	return clock.Or(pw.clock).Now()
+}
+
 // Start begins watching the project directory.
 func (pw *ProjectWatcher) Start() error {
 	if err := pw.watcher.AddDirectory(pw.projectPath); err != nil {
@@ -70,23 +134,77 @@ func (pw *ProjectWatcher) Events() <-chan watcher.FileEvent {
 	return pw.watcher.Events()
 }
 
-// TrackFile records a file modification.
+// TrackFile records a file modification. A path that is the same file as one
+// already tracked — a hard link, a followed symlink, or another spelling on a
+// case-insensitive filesystem — updates that entry instead of adding a second
+// one, so the file is encrypted once.
 func (pw *ProjectWatcher) TrackFile(path string, modTime time.Time) {
 	pw.mu.Lock()
 	defer pw.mu.Unlock()
+	if same, ok := pw.trackedAs(path); ok {
+		path = same
+	} else {
+		pw.firstSeen[path] = pw.now()
+	}
+	// A modification time ahead of the clock (a file stamped by a host
+	// whose clock runs fast) would not go idle until the clock caught up.
+	if now := pw.now().Round(0); modTime.After(now) {
+		modTime = now
+	}
 	pw.lastMod[path] = modTime
 }
 
+// trackedAs returns the tracked path naming the same file as path, if any.
+// Callers hold pw.mu.
+func (pw *ProjectWatcher) trackedAs(path string) (string, bool) {
+	if _, ok := pw.lastMod[path]; ok {
+		return path, true
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", false
+	}
+	for tracked := range pw.lastMod {
+		if other, err := os.Stat(tracked); err == nil && os.SameFile(info, other) {
+			return tracked, true
+		}
+	}
+	return "", false
+}
+
+// MarkDue tracks path and has the next idle check verify it regardless of
+// the idle timeout; later modifications do not postpone it. A recheck request
+// after a git checkout uses it.
+func (pw *ProjectWatcher) MarkDue(path string) {
+	pw.mu.Lock()
+	defer pw.mu.Unlock()
+	if same, ok := pw.trackedAs(path); ok {
+		path = same
+	} else {
+		now := pw.now()
+		pw.lastMod[path] = now
+		pw.firstSeen[path] = now
+	}
+	pw.due[path] = true
+}
+
 // GetIdleFiles returns files that have been idle longer than the configured timeout.
 func (pw *ProjectWatcher) GetIdleFiles() []string {
+	return pw.idleFiles(0)
+}
+
+// idleFiles is GetIdleFiles with the timeout capped at limit when a policy
+// sets one (limit > 0).
+func (pw *ProjectWatcher) idleFiles(limit time.Duration) []string {
 	pw.mu.RLock()
 	defer pw.mu.RUnlock()
 
-	now := time.Now()
+	timeout := pw.idleTimeout(limit)
+	now := pw.now()
 	var idle []string
 
 	for path, modTime := range pw.lastMod {
-		if now.Sub(modTime) >= pw.config.IdleTimeout {
+		if pw.due[path] || now.Sub(modTime) >= timeout {
 			idle = append(idle, path)
 		}
 	}
@@ -94,11 +212,23 @@ func (pw *ProjectWatcher) GetIdleFiles() []string {
 	return idle
 }
 
+// idleTimeout is the project's idle timeout capped at limit when a policy
+// sets one (limit > 0).
+func (pw *ProjectWatcher) idleTimeout(limit time.Duration) time.Duration {
+	timeout := pw.config.IdleTimeout
+	if limit > 0 && limit < timeout {
+		timeout = limit
+	}
+	return timeout
+}
+
 // RemoveFile stops tracking a file.
 func (pw *ProjectWatcher) RemoveFile(path string) {
 	pw.mu.Lock()
 	defer pw.mu.Unlock()
 	delete(pw.lastMod, path)
+	delete(pw.due, path)
+	delete(pw.firstSeen, path)
 }
 
 // Guardian orchestrates file watching and auto-encryption for multiple projects.
@@ -108,7 +238,11 @@ type Guardian struct {
 	registryWatcher *registry.RegistryWatcher
 	checkTick       time.Duration
 	encryptTimeout  time.Duration
+	drainTimeout    time.Duration
 	mu              sync.RWMutex
+	// queue runs the idle check's encryptions so shutdown can drain them;
+	// it is nil until Start, and without it they run inline.
+	queue *encryptQueue
 	// checking marks an idle-check worker in flight so ticks never pile up
 	// overlapping workers; checkWG lets shutdown wait for that worker (#494).
 	checking atomic.Bool
@@ -116,32 +250,188 @@ type Guardian struct {
 	// These are set during Start() for use by onRegistryChange
 	ctx    context.Context
 	events chan projectEvent
-	// notifyError/notifyEncrypted dispatch the desktop notifications for the
-	// failed- and successful-encrypt paths. They default to the real notify
-	// package and are overridable in tests so notification behaviour (e.g. the
+	// notifyError/notifyEncrypted dispatch the notifications for the
+	// failed- and successful-encrypt paths. They default to a notify.Notifier
+	// routed by [notifications] and are overridable in tests so notification behaviour (e.g. the
 	// #494 rule that a timeout must NOT notify) is observable without a real
 	// desktop backend.
 	notifyError     func(string) error
 	notifyEncrypted func(string) error
+	// notifyWarning reports vault sync conflicts; overridable like the above.
+	notifyWarning func(string) error
+	// notifier backs the above by default; the idle-check worker flushes
+	// what it held during do-not-disturb. nil in tests that build a Guardian
+	// by hand.
+	notifier *notify.Notifier
+
+	// vaultSyncer pulls rotated keys from project vaults when [vault_sync]
+	// is enabled; syncWG lets shutdown wait for an in-flight pass.
+	vaultSyncer *vaultsync.Syncer
+	syncWG      sync.WaitGroup
+
+	// editsOpen records `edit` sessions whose file was seen open, so closing
+	// the editor re-encrypts early. Only the idle-check worker touches it.
+	editsOpen map[string]bool
+
+	// gitBusy records files held back by a git operation in their
+	// repository, so the deferral is logged once. Only the idle-check worker
+	// touches it.
+	gitBusy map[string]bool
+
+	// backups copies files aside before encrypting them when [backups] is
+	// enabled; nil otherwise. backupMu serializes backup and prune for the
+	// run-once workers.
+	backups  *backups.Store
+	backupMu sync.Mutex
+
+	// telemetry buffers usage counts when [telemetry] is enabled; nil
+	// otherwise. telemetrySent is the last delivery attempt.
+	telemetry     *telemetry.Store
+	telemetrySent time.Time
+
+	// hooks runs the [hooks] commands around encryptions; nil when none is
+	// configured.
+	hooks *hooks.Runner
+	// plugins are the installed plugins, loaded by Start and RunOnce.
+	plugins []plugins.Plugin
+	// rule is [guardian] encrypt_when; nil when unset.
+	rule *rule.Rule
+
+	// readOnly is [compliance] read_only: plaintext files are reported and
+	// left alone, and nothing else that modifies files runs.
+	readOnly bool
+
+	// journal records watcher events and idle-check decisions for `debug
+	// replay` (guardian.journal).
+	journal bool
+
+	// profileRoots limits the guardian to projects under the active
+	// profile's watch roots; nil watches every registered project.
+	profileRoots []string
+
+	// detectNetwork probes the network for [[policies]]; policy is their
+	// combined effect as of policyChecked. Only the idle-check worker uses
+	// policy and policyChecked.
+	detectNetwork func(context.Context) netstate.State
+	policy        config.Policy
+	policyChecked time.Time
+
+	// deepWork is [guardian] deep_work_hours; screenLocked probes whether
+	// the screen is locked, which lets encryption run in them anyway.
+	deepWork     schedule.Windows
+	screenLocked func() bool
+	// deepWorking is whether the last idle check held files for deep work;
+	// only the idle-check worker touches it.
+	deepWorking bool
+
+	// detectPower probes the power source; power is the state as of
+	// powerChecked, guarded by powerMu (see powerState).
+	detectPower  func() power.State
+	powerMu      sync.Mutex
+	power        power.State
+	powerChecked time.Time
+	// openProbed records when an idle file was last found open by
+	// lockcheck, for skipOpenProbe. Only the idle-check worker touches it.
+	openProbed map[string]time.Time
+	// checkedAt is the wall time the last idle check ended, for
+	// resumeTimers. Only the idle-check worker touches it.
+	checkedAt time.Time
+	// waiting holds why the last idle check left each file in plaintext,
+	// and pendingWritten what writePending last recorded for `status`.
+	// Only the idle-check worker touches them.
+	waiting        map[string]pending.File
+	pendingWritten []pending.File
+	// telemetryDeferred tracks a power deferral of sendTelemetry for its log.
+	telemetryDeferred bool
+
+	// detectOnline reports network connectivity; offline is the state as
+	// of the last check, guarded by netMu (see queueIfOffline).
+	detectOnline func() bool
+	netMu        sync.Mutex
+	offline      bool
+
+	// clock times idleness, deferrals and retries; nil is the system
+	// clock. Project watchers share it.
+	clock clock.Clock
+
+	// Version is the agent version reported with telemetry.
+	Version string
+}
+
+// now reads g's clock.
+func (g *Guardian) now() time.Time {
+	return clock.Or(g.clock).Now()
 }
 
-// New creates a Guardian configured with cfg.
+// New creates a Guardian configured with cfg, which should be the
+// configuration Effective returned so the active profile applies.
 func New(cfg *config.Config) (*Guardian, error) {
+	notifier := &notify.Notifier{
+		Routes:           cfg.Notifications.Routes(),
+		Webhook:          cfg.Notifications.Webhook,
+		HoldDuringDND:    cfg.Notifications.RespectDND,
+		FailuresBreakDND: cfg.Notifications.FailuresBreakDND,
+		QuietHours:       cfg.Notifications.QuietWindows(),
+	}
 	g := &Guardian{
 		globalConfig:    cfg,
 		projects:        make(map[string]*ProjectWatcher),
-		checkTick:       30 * time.Second,
+		checkTick:       checkInterval(cfg.Guardian.IdleTimeout),
 		encryptTimeout:  defaultEncryptTimeout,
-		notifyError:     notify.Error,
-		notifyEncrypted: notify.Encrypted,
+		drainTimeout:    defaultDrainTimeout,
+		notifier:        notifier,
+		notifyError:     notifier.Error,
+		notifyEncrypted: notifier.Encrypted,
+		notifyWarning:   notifier.Warning,
+		detectNetwork:   netstate.Detect,
+		detectPower:     power.Detect,
+		deepWork:        cfg.Guardian.DeepWorkWindows(),
+		screenLocked:    screenlock.Locked,
+		detectOnline:    netstate.Online,
+		openProbed:      make(map[string]time.Time),
+		gitBusy:         make(map[string]bool),
+		vaultSyncer: &vaultsync.Syncer{
+			Target:    cfg.VaultSync.Target,
+			StatePath: vaultsync.DefaultStatePath(),
+		},
+	}
+	if cfg.Backups.Enabled {
+		g.backups = &backups.Store{Dir: backups.DefaultDir()}
+	}
+	if roots := cfg.ProfileRoots(); roots != nil {
+		g.profileRoots = roots
+		log.Printf("Profile %s: watching projects under %v", cfg.ActiveProfile, roots)
+	}
+	if cfg.Telemetry.Enabled {
+		g.telemetry = &telemetry.Store{Path: telemetry.DefaultPath()}
+	}
+	if h := cfg.Hooks; h.PreEncrypt != "" || h.PostEncrypt != "" || h.OnFailure != "" {
+		g.hooks = &hooks.Runner{
+			Commands: map[string]string{
+				hooks.PreEncrypt:  h.PreEncrypt,
+				hooks.PostEncrypt: h.PostEncrypt,
+				hooks.OnFailure:   h.OnFailure,
+			},
+			Timeout: h.Timeout,
+		}
+	}
+	if cfg.Guardian.EncryptWhen != "" {
+		// Load has already compiled it once to check it.
+		r, err := rule.Compile(cfg.Guardian.EncryptWhen)
+		if err != nil {
+			return nil, fmt.Errorf("guardian.encrypt_when: %w", err)
+		}
+		g.rule = r
 	}
+	g.journal = cfg.Guardian.Journal
+	g.readOnly = cfg.Compliance.ReadOnly
 
 	return g, nil
 }
 
 // projectDefaults derives the per-project default GuardianConfig from the
 // global ~/.envdrift/guardian.toml settings (#494): idle_timeout, patterns,
-// exclude and notify act as the documented defaults for every registered
+// exclude, notify, symlinks and ignore_generated act as the documented defaults for every registered
 // project and are overridden by the project's own [guardian] section.
 // Enabled is deliberately NOT inherited — watching stays per-project opt-in;
 // the global guardian.enabled is the agent's master switch, checked in Start().
@@ -162,11 +452,18 @@ func (g *Guardian) projectDefaults() *project.GuardianConfig {
 		d.Exclude = append([]string(nil), gc.Exclude...)
 	}
 	d.Notify = gc.Notify
+	if gc.Symlinks != "" {
+		d.Symlinks = gc.Symlinks
+	}
+	d.IgnoreGenerated = gc.IgnoreGenerated
+	d.Debounce = gc.Debounce
 	return d
 }
 
 // Start begins the guardian loop.
 func (g *Guardian) Start(ctx context.Context) error {
+	defer g.recoverPanic()
+
 	// Honor the global guardian switch (#348 G3): when disabled, no-op cleanly
 	// before standing up any watcher or goroutine.
 	if g.globalConfig == nil || !g.globalConfig.Guardian.Enabled {
@@ -180,6 +477,16 @@ func (g *Guardian) Start(ctx context.Context) error {
 	}
 
 	log.Println("EnvDrift Guardian starting...")
+	systemlog.Emit(systemlog.Event{Kind: systemlog.KindStart, Message: "EnvDrift Guardian started"})
+	g.loadPlugins(ctx)
+
+	// A queue left by an earlier run is stale: this run retries everything.
+	if _, _, err := offline.Clear(); err != nil {
+		log.Printf("Clearing offline queue: %v", err)
+	}
+	if err := pending.Write(nil); err != nil {
+		log.Printf("Clearing pending files: %v", err)
+	}
 
 	// Create an aggregated events channel and publish ctx/events under g.mu
 	// before the registry watcher can fire onRegistryChange (which reads them
@@ -201,6 +508,69 @@ func (g *Guardian) Start(ctx context.Context) error {
 	// Load initial projects
 	g.loadProjects(rw.GetRegistry())
 
+	if g.readOnly {
+		log.Println("Read-only compliance mode: reporting plaintext env files without encrypting them")
+	}
+	if g.globalConfig.Compliance.Reporting() {
+		g.syncWG.Add(1)
+		go func() {
+			defer g.syncWG.Done()
+			defer g.recoverPanic()
+			g.complianceLoop(ctx)
+		}()
+	}
+
+	if g.globalConfig.VaultSync.Enabled && g.readOnly {
+		log.Println("Vault sync disabled in read-only compliance mode")
+	} else if g.globalConfig.VaultSync.Enabled {
+		g.syncWG.Add(1)
+		go func() {
+			defer g.syncWG.Done()
+			defer g.recoverPanic()
+			g.vaultSyncLoop(ctx, rw.GetRegistry)
+		}()
+	}
+
+	// Read-only mode drops reported files from tracking, so a rescan would
+	// report them as missed every time; the compliance scans cover it.
+	if interval := g.globalConfig.Guardian.RescanInterval; interval > 0 && !g.readOnly {
+		g.syncWG.Add(1)
+		go func() {
+			defer g.syncWG.Done()
+			defer g.recoverPanic()
+			g.rescanLoop(ctx, interval)
+		}()
+	}
+
+	if interval := g.globalConfig.Guardian.ConfigScanInterval; interval > 0 {
+		g.syncWG.Add(1)
+		go func() {
+			defer g.syncWG.Done()
+			defer g.recoverPanic()
+			g.configScanLoop(ctx, interval)
+		}()
+	}
+
+	if g.globalConfig.History.Keep > 0 || g.globalConfig.Logs.Keep > 0 {
+		g.syncWG.Add(1)
+		go func() {
+			defer g.syncWG.Done()
+			defer g.recoverPanic()
+			g.retentionLoop(ctx)
+		}()
+	}
+
+	// Verify the env files a git hook's recheck request names right away.
+	rechecks := make(chan struct{}, 1)
+	go func() {
+		defer g.recoverPanic()
+		g.watchRechecks(ctx, rechecks)
+	}()
+
+	// Encryptions run on the queue. It outlives ctx: shutdown drains it.
+	g.queue = newEncryptQueue()
+	g.queue.Start(context.Background())
+
 	// Start the check loop
 	ticker := time.NewTicker(g.checkTick)
 	defer ticker.Stop()
@@ -218,10 +588,16 @@ func (g *Guardian) Start(ctx context.Context) error {
 			log.Println("Guardian shutting down...")
 			g.stopAllProjects()
 			g.registryWatcher.Stop()
-			// Wait for an in-flight idle check: its encrypt subprocess is
-			// killed via the cancelled context, so this returns promptly and
-			// Start's return means the guardian is fully stopped (#494).
+			// Let an encryption in flight finish, for up to drainTimeout,
+			// then wait for the idle check: it starts no other, so Start's
+			// return means the guardian is fully stopped (#494).
+			g.drainQueue()
 			g.checkWG.Wait()
+			g.syncWG.Wait()
+			if err := pending.Write(nil); err != nil {
+				log.Printf("Clearing pending files: %v", err)
+			}
+			systemlog.Emit(systemlog.Event{Kind: systemlog.KindStop, Message: "EnvDrift Guardian stopped"})
 			return nil
 
 		case event := <-events:
@@ -232,11 +608,15 @@ func (g *Guardian) Start(ctx context.Context) error {
 			if ok {
 				pw.TrackFile(event.filePath, event.modTime)
 				log.Printf("[%s] File modified: %s", event.projectPath, event.filePath)
+				g.record(journal.KindModified, event.projectPath, event.filePath, "")
 			}
 
 		case <-ticker.C:
 			// Check for idle files in all projects
 			g.startIdleCheck(ctx)
+
+		case <-rechecks:
+			g.startIdleCheck(ctx)
 		}
 	}
 }
@@ -255,7 +635,17 @@ func (g *Guardian) startIdleCheck(ctx context.Context) {
 	go func() {
 		defer g.checkWG.Done()
 		defer g.checking.Store(false)
+		defer g.recoverPanic()
+		defer func() { g.checkedAt = g.now().Round(0) }()
+		g.resumeTimers(g.now())
+		g.refreshPolicy(ctx)
 		g.checkIdleFiles(ctx)
+		g.writePending()
+		if !g.readOnly {
+			g.expireExports(ctx)
+		}
+		g.sendTelemetry(ctx)
+		g.flushNotifications()
 	}()
 }
 
@@ -281,6 +671,7 @@ type projectEvent struct {
 
 // forwardEvents forwards events from a project watcher to the aggregated channel.
 func (g *Guardian) forwardEvents(ctx context.Context, projectPath string, pw *ProjectWatcher, out chan<- projectEvent) {
+	defer g.recoverPanic()
 	for {
 		select {
 		case <-ctx.Done():
@@ -312,7 +703,7 @@ func (g *Guardian) loadProjects(reg *registry.Registry) {
 		return
 	}
 
-	projectPaths := reg.GetProjectPaths()
+	projectPaths := g.profilePaths(reg.GetProjectPaths())
 	log.Printf("Loading %d registered projects", len(projectPaths))
 
 	// Load project configs, with the global guardian.toml values as the
@@ -322,6 +713,11 @@ func (g *Guardian) loadProjects(reg *registry.Registry) {
 		log.Printf("Error loading project configs: %v", err)
 		return
 	}
+	enabled := make(map[string]*project.GuardianConfig, len(configs))
+	for _, pc := range configs {
+		enabled[pc.Path] = pc.Guardian
+	}
+	g.onboard(enabled)
 
 	g.mu.Lock()
 	defer g.mu.Unlock()
@@ -336,6 +732,7 @@ func (g *Guardian) loadProjects(reg *registry.Registry) {
 			log.Printf("Error creating watcher for %s: %v", pc.Path, err)
 			continue
 		}
+		pw.SetClock(g.clock)
 
 		if err := pw.Start(); err != nil {
 			log.Printf("Error starting watcher for %s: %v", pc.Path, err)
@@ -345,6 +742,7 @@ func (g *Guardian) loadProjects(reg *registry.Registry) {
 		g.projects[pc.Path] = pw
 		log.Printf("Watching project: %s (idle_timeout: %v, patterns: %v)",
 			pc.Path, pc.Guardian.IdleTimeout, pc.Guardian.Patterns)
+		g.record(journal.KindWatch, pc.Path, "", pc.Guardian.IdleTimeout.String())
 	}
 }
 
@@ -360,7 +758,8 @@ func (g *Guardian) onRegistryChange(reg *registry.Registry) {
 
 	log.Println("Registry changed, reloading projects...")
 
-	enabledPaths, failedPaths := g.loadEnabledConfigs(reg.GetProjectPaths())
+	enabledPaths, failedPaths := g.loadEnabledConfigs(g.profilePaths(reg.GetProjectPaths()))
+	g.onboard(enabledPaths)
 
 	g.mu.Lock()
 	defer g.mu.Unlock()
@@ -458,6 +857,7 @@ func (g *Guardian) startNewProjects(enabledPaths map[string]*project.GuardianCon
 			log.Printf("Error creating watcher for %s: %v", path, err)
 			continue
 		}
+		pw.SetClock(g.clock)
 
 		if err := pw.Start(); err != nil {
 			log.Printf("Error starting watcher for %s: %v", path, err)
@@ -466,6 +866,7 @@ func (g *Guardian) startNewProjects(enabledPaths map[string]*project.GuardianCon
 
 		g.projects[path] = pw
 		log.Printf("Added project: %s (idle_timeout: %v)", path, cfg.IdleTimeout)
+		g.record(journal.KindWatch, path, "", cfg.IdleTimeout.String())
 
 		// Start event forwarding for the new project
 		if g.events != nil && g.ctx != nil {
@@ -497,19 +898,42 @@ func (g *Guardian) checkIdleFiles(ctx context.Context) {
 		projects[k] = v
 	}
 	g.mu.RUnlock()
-
-	for projectPath, pw := range projects {
-		idleFiles := pw.GetIdleFiles()
+	projectPaths := make([]string, 0, len(projects))
+	for path := range projects {
+		projectPaths = append(projectPaths, path)
+	}
+	sort.Strings(projectPaths)
+	held := g.observing(g.now())
+	deepWorkUntil, deepWork := g.deepWorkHold(g.now())
+	g.waiting = make(map[string]pending.File)
+
+	// Nested or overlapping projects track the same file more than once; the
+	// first project handles it, the others drop it once it is encrypted.
+	var seen fileSet
+	for _, projectPath := range projectPaths {
+		pw := projects[projectPath]
+		idleFiles := pw.idleFiles(g.policy.IdleTimeout)
 
 		for _, path := range idleFiles {
 			// Shutting down: leave the remaining files for the next run.
 			if ctx.Err() != nil {
 				return
 			}
+			if !seen.add(path) {
+				continue
+			}
 
 			// Check if file exists
 			if _, err := os.Stat(path); os.IsNotExist(err) {
 				pw.RemoveFile(path)
+				g.record(journal.KindGone, projectPath, path, "")
+				continue
+			}
+
+			// An `edit` or `export --expire` session keeps the file in
+			// plaintext until its timer runs out; expireExports acts then.
+			if e, held := exports.Pending(path, g.now()); held {
+				g.deferFile(projectPath, path, "edit or export session", e.Expires)
 				continue
 			}
 
@@ -521,16 +945,73 @@ func (g *Guardian) checkIdleFiles(ctx context.Context) {
 			}
 			if encrypted {
 				pw.RemoveFile(path)
+				g.record(journal.KindAlready, projectPath, path, "")
+				continue
+			}
+
+			// In read-only mode the file is only reported (see Compliance);
+			// it is dropped from tracking until it changes again.
+			if g.readOnly {
+				log.Printf("[%s] Read-only mode, leaving plaintext file: %s", projectPath, path)
+				g.record(journal.KindDeferred, projectPath, path, "read-only compliance mode")
+				pw.RemoveFile(path)
+				continue
+			}
+			// Likewise under a root in observe mode.
+			if root, ok := g.observes(path); ok {
+				log.Printf("[%s] %s is observed, leaving plaintext file: %s", projectPath, root, path)
+				g.record(journal.KindDeferred, projectPath, path, "observe mode ("+root+")")
+				pw.RemoveFile(path)
+				continue
+			}
+
+			// A project in its onboarding grace period is only observed; the
+			// file stays tracked and is encrypted once the period ends.
+			if r, ok := observedRoot(held, path); ok {
+				g.deferFile(projectPath, path,
+					"new project, observed until "+r.Until.Local().Format(time.RFC822)+" or 'envdrift-agent approve'", r.Until)
+				continue
+			}
+
+			// Leave the file alone while git is writing the working tree
+			// (a merge, rebase or checkout); a later check picks it up.
+			if op := gitstate.InProgress(path); op != "" {
+				if !g.gitBusy[path] {
+					log.Printf("[%s] git %s in progress, deferring: %s", projectPath, op, path)
+					g.gitBusy[path] = true
+				}
+				g.deferFile(projectPath, path, "git "+op+" in progress", time.Time{})
+				continue
+			}
+			delete(g.gitBusy, path)
+
+			// [guardian] encrypt_when holds the file back until it is true.
+			if ok, why := g.ruleAllows(projectPath, path); !ok {
+				g.deferFile(projectPath, path, why, time.Time{})
+				continue
+			}
+
+			// So do deep work hours, unless the screen is locked.
+			if deepWork {
+				g.deferFile(projectPath, path, "deep work hours until "+deepWorkUntil.Format("15:04"), deepWorkUntil)
 				continue
 			}
 
-			// Check if file is open by another process
+			// Check if file is open by another process. On battery a file
+			// an editor holds open is not re-probed on every check.
+			now := g.now()
+			if g.skipOpenProbe(path, now) {
+				g.waiting[path] = pending.File{Reason: "open in another process"}
+				continue
+			}
 			if lockcheck.IsFileOpen(path) {
 				log.Printf("[%s] File still open, skipping: %s", projectPath, path)
+				g.openProbed[path] = now
+				g.deferFile(projectPath, path, "open in another process", time.Time{})
 				continue
 			}
 
-			if !g.encryptIdleFile(ctx, projectPath, pw, path) {
+			if !g.encryptQueued(ctx, projectPath, pw, path) {
 				return
 			}
 		}
@@ -541,7 +1022,20 @@ func (g *Guardian) checkIdleFiles(ctx context.Context) {
 // handles logging/notification. It returns false when the guardian is
 // shutting down (the caller must stop), true otherwise.
 func (g *Guardian) encryptIdleFile(ctx context.Context, projectPath string, pw *ProjectWatcher, path string) bool {
+	// A failing pre_encrypt hook vetoes the encryption; the file stays
+	// tracked and is tried again on a later check.
+	if err := g.runHook(ctx, hooks.PreEncrypt, projectPath, path, nil); err != nil {
+		if ctx.Err() != nil {
+			return false
+		}
+		log.Printf("[%s] Deferring %s: %v", projectPath, path, err)
+		g.deferFile(projectPath, path, err.Error(), time.Time{})
+		return true
+	}
 	log.Printf("[%s] Encrypting idle file: %s", projectPath, path)
+	g.backupFile(projectPath, path)
+	// Classified now: once encrypted there is nothing left to classify.
+	worst, _, _ := severity.File(path)
 
 	// defer cancel() so the child context is always released even if
 	// EncryptSilentContext panics; timedOut is read from encCtx.Err() before
@@ -549,7 +1043,7 @@ func (g *Guardian) encryptIdleFile(ctx context.Context, projectPath string, pw *
 	// the cancellation (#494).
 	encCtx, cancel := context.WithTimeout(ctx, g.encryptTimeout)
 	defer cancel()
-	err := encrypt.EncryptSilentContext(encCtx, path)
+	err := g.encryptFile(encCtx, path)
 	timedOut := errors.Is(encCtx.Err(), context.DeadlineExceeded)
 
 	if err != nil {
@@ -560,25 +1054,74 @@ func (g *Guardian) encryptIdleFile(ctx context.Context, projectPath string, pw *
 		if timedOut {
 			log.Printf("[%s] Encrypting %s timed out after %v (subprocess killed); will retry on a later check",
 				projectPath, path, g.encryptTimeout)
+			_ = g.runHook(ctx, hooks.OnFailure, projectPath, path, fmt.Errorf("timed out after %v", g.encryptTimeout))
+			g.record(journal.KindFailed, projectPath, path, fmt.Sprintf("timed out after %v", g.encryptTimeout))
+			g.waiting[path] = pending.File{Reason: fmt.Sprintf("encryption timed out after %v; retried", g.encryptTimeout),
+				Due: g.now().Add(g.checkTick)}
 			// Do not notify on timeout: the file is retried on the next check,
 			// and a "Failed to encrypt" desktop notification every checkTick
 			// (e.g. a persistently slow drive) would be indistinguishable from a
 			// permanent failure and just noisy (#494).
 		} else {
 			log.Printf("[%s] Error encrypting %s: %v", projectPath, path, err)
-			if pw.config.Notify {
-				_ = g.notifyError("Failed to encrypt: " + path)
+			g.record(journal.KindFailed, projectPath, path, err.Error())
+			g.waiting[path] = pending.File{Reason: "encryption failed: " + err.Error() + "; retried",
+				Due: g.now().Add(g.checkTick)}
+			systemlog.Emit(systemlog.Event{Level: systemlog.Error, Kind: systemlog.KindEncryptFailed,
+				Path: path, Message: "encryption failed: " + err.Error()})
+			if g.shouldNotify(pw) {
+				_ = g.notifyError(i18n.T("guardian.encrypt_failed", path))
 			}
+			_ = g.runHook(ctx, hooks.OnFailure, projectPath, path, err)
 		}
 		return true
 	}
 
 	log.Printf("[%s] Successfully encrypted: %s", projectPath, path)
-	if pw.config.Notify {
+	g.record(journal.KindEncrypted, projectPath, path, "")
+	g.countTelemetry(telemetry.Encryptions)
+	if g.shouldNotify(pw) && worst.Level >= g.notifyLevel() {
 		_ = g.notifyEncrypted(path)
 	}
+	_ = g.runHook(ctx, hooks.PostEncrypt, projectPath, path, nil)
 
 	// Remove from tracking
 	pw.RemoveFile(path)
 	return true
 }
+
+// encryptFile runs `envdrift encrypt` on path. With [guardian] incremental
+// on, the values unchanged since the file was last encrypted keep their
+// ciphertext; with normalize_output on, the encrypted file is then laid out
+// in its normalized form.
+func (g *Guardian) encryptFile(ctx context.Context, path string) error {
+	var err error
+	if g.globalConfig.Guardian.Incremental {
+		var kept int
+		kept, err = encrypt.EncryptIncremental(ctx, path, encrypt.EncryptSilentContext)
+		if kept > 0 {
+			log.Printf("Kept the ciphertext of %d unchanged value(s) in %s", kept, path)
+		}
+	} else {
+		err = encrypt.EncryptSilentContext(ctx, path)
+	}
+	if err != nil || !g.globalConfig.Guardian.NormalizeOutput {
+		return err
+	}
+	// The file is encrypted either way; a layout it keeps is no failure.
+	if _, nerr := encrypt.Normalize(path); nerr != nil {
+		log.Printf("Normalizing %s: %v", path, nerr)
+	}
+	return nil
+}
+
+// flushNotifications delivers the notifications held while the OS was in
+// do-not-disturb, once it has ended. Only the idle-check worker calls it.
+func (g *Guardian) flushNotifications() {
+	if g.notifier == nil {
+		return
+	}
+	if err := g.notifier.FlushHeld(); err != nil {
+		log.Printf("Delivering held notifications: %v", err)
+	}
+}
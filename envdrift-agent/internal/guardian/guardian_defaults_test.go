@@ -10,6 +10,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/jainal09/envdrift-agent/internal/clock"
 	"github.com/jainal09/envdrift-agent/internal/config"
 	"github.com/jainal09/envdrift-agent/internal/project"
 	"github.com/jainal09/envdrift-agent/internal/registry"
@@ -194,3 +195,79 @@ func TestGuardian_GlobalEnabledDoesNotOptProjectsIn(t *testing.T) {
 		t.Fatalf("global enabled=true must not opt in a project without its own [guardian] enabled = true")
 	}
 }
+
+// TestGuardian_DebounceAppliesToProjectsLoadedAtStartup checks that the
+// global debounce reaches the watchers of projects registered before the
+// guardian started, not only those added later: an editor's save, a write
+// followed by a temp file renamed over the original, yields one event.
+func TestGuardian_DebounceAppliesToProjectsLoadedAtStartup(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	proj := makeProjectWithToml(t, "[guardian]\nenabled = true\n")
+	envPath := filepath.Join(proj, ".env")
+	if err := os.WriteFile(envPath, []byte("A=1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.Guardian.Enabled = true
+	cfg.Guardian.Debounce = 2 * time.Second
+	g, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	fake := clock.NewFake(time.Now())
+	g.clock = fake
+
+	g.loadProjects(&registry.Registry{Projects: []registry.ProjectEntry{{Path: proj, Added: "now"}}})
+	defer g.stopAllProjects()
+	g.mu.RLock()
+	pw, ok := g.projects[proj]
+	g.mu.RUnlock()
+	if !ok {
+		t.Fatal("project not loaded")
+	}
+
+	if err := os.WriteFile(envPath, []byte("A=2\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tmp := filepath.Join(proj, "env.tmp")
+	if err := os.WriteFile(tmp, []byte("A=3\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(tmp, envPath); err != nil {
+		t.Fatal(err)
+	}
+
+	// Let fsnotify deliver the burst; the fake clock holds it back.
+	deadline := time.Now().Add(2 * time.Second)
+	for fake.Pending() == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(200 * time.Millisecond)
+	select {
+	case ev := <-pw.Events():
+		t.Fatalf("event %+v before the debounce window; debounce not applied", ev)
+	default:
+	}
+	if n := fake.Pending(); n != 1 {
+		t.Fatalf("%d debounce timers for one save; want 1", n)
+	}
+
+	fake.Advance(2 * time.Second)
+	select {
+	case ev := <-pw.Events():
+		if ev.Path != envPath {
+			t.Errorf("event for %s; want %s", ev.Path, envPath)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no event after the debounce window")
+	}
+	select {
+	case ev := <-pw.Events():
+		t.Errorf("second event %+v for one save", ev)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
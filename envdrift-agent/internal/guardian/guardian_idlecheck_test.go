@@ -10,10 +10,12 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/jainal09/envdrift-agent/internal/config"
+	"github.com/jainal09/envdrift-agent/internal/journal"
 	"github.com/jainal09/envdrift-agent/internal/project"
 )
 
@@ -57,6 +59,7 @@ func newIdleCheckFixture(t *testing.T, envdriftMode string) *idleCheckFixture {
 		t.Fatalf("New: %v", err)
 	}
 	g.projects[projectDir] = pw
+	g.detectOnline = func() bool { return true }
 
 	return &idleCheckFixture{g: g, pw: pw, projectDir: projectDir, marker: marker}
 }
@@ -275,3 +278,127 @@ func TestCheckIdleFiles_CancelledContextReturnsEarly(t *testing.T) {
 		t.Errorf("envdrift must not be invoked after shutdown (marker err=%v)", err)
 	}
 }
+
+// TestTrackFile_SameFileTrackedOnce covers hard links and followed symlinks:
+// every name for one file collapses onto the first tracked path, so the file
+// is encrypted once per idle period rather than once per name.
+func TestTrackFile_SameFileTrackedOnce(t *testing.T) {
+	f := newIdleCheckFixture(t, "ok")
+	path := f.trackIdle(t, ".env", "SECRET=plaintext\n")
+
+	hard := filepath.Join(f.projectDir, ".env.hardlink")
+	if err := os.Link(path, hard); err != nil {
+		t.Skipf("hard links unavailable: %v", err)
+	}
+	f.pw.TrackFile(hard, time.Now())
+	names := []string{hard}
+	if soft := filepath.Join(f.projectDir, ".env.symlink"); os.Symlink(path, soft) == nil {
+		f.pw.TrackFile(soft, time.Now())
+		names = append(names, soft)
+	}
+
+	for _, name := range names {
+		if f.tracked(name) {
+			t.Errorf("%s tracked separately from %s", name, path)
+		}
+	}
+	if !f.tracked(path) {
+		t.Fatal("original path no longer tracked")
+	}
+	if idle := f.pw.GetIdleFiles(); len(idle) != 0 {
+		t.Errorf("a new write through another name must reset the idle clock, idle = %v", idle)
+	}
+}
+
+// TestCheckIdleFiles_DefersDuringGitMerge checks that an idle file is not
+// encrypted while its repository is mid-merge, and is once the merge ends.
+func TestCheckIdleFiles_DefersDuringGitMerge(t *testing.T) {
+	f := newIdleCheckFixture(t, "ok")
+	gitDir := filepath.Join(f.projectDir, ".git")
+	if err := os.Mkdir(gitDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	mergeHead := filepath.Join(gitDir, "MERGE_HEAD")
+	if err := os.WriteFile(mergeHead, []byte("abc\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	path := f.trackIdle(t, ".env", "SECRET=plaintext\n")
+
+	f.g.checkIdleFiles(context.Background())
+	if _, err := os.Stat(f.marker); err == nil {
+		t.Fatal("encrypted during a merge")
+	}
+	if !f.tracked(path) || !f.g.gitBusy[path] {
+		t.Fatal("deferred file must stay tracked")
+	}
+
+	if err := os.Remove(mergeHead); err != nil {
+		t.Fatal(err)
+	}
+	f.g.checkIdleFiles(context.Background())
+	if f.tracked(path) || f.g.gitBusy[path] {
+		t.Error("not encrypted after the merge ended")
+	}
+}
+
+// TestCheckIdleFiles_OverlappingProjectsHandleOnce covers nested project
+// roots: a file tracked by both watchers is encrypted and notified once per
+// check, not once per project.
+func TestCheckIdleFiles_OverlappingProjectsHandleOnce(t *testing.T) {
+	prevOut := log.Writer()
+	log.SetOutput(io.Discard)
+	t.Cleanup(func() { log.SetOutput(prevOut) })
+
+	f := newIdleCheckFixture(t, "fail")
+	f.pw.config.Notify = true
+	notified := 0
+	f.g.notifyError = func(string) error { notified++; return nil }
+	inner := filepath.Join(f.projectDir, "api")
+	if err := os.Mkdir(inner, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	path := f.trackIdle(t, filepath.Join("api", ".env"), "SECRET=plaintext\n")
+
+	cfg := *f.pw.config
+	nested, err := NewProjectWatcher(inner, &cfg)
+	if err != nil {
+		t.Fatalf("NewProjectWatcher: %v", err)
+	}
+	t.Cleanup(nested.Stop)
+	nested.TrackFile(path, time.Now().Add(-time.Hour))
+	f.g.projects[inner] = nested
+
+	f.g.checkIdleFiles(context.Background())
+
+	if notified != 1 {
+		t.Errorf("a file tracked by two projects must be handled once, notified %d times", notified)
+	}
+}
+
+// TestCheckIdleFiles_Journal covers guardian.journal: decisions are recorded
+// for `debug replay`, once per reason.
+func TestCheckIdleFiles_Journal(t *testing.T) {
+	f := newIdleCheckFixture(t, "ok")
+	t.Setenv("ENVDRIFT_AGENT_FAKE_LSOF", "open")
+	path := f.trackIdle(t, ".env", "SECRET=plaintext\n")
+
+	f.g.checkIdleFiles(context.Background())
+	f.g.openProbed = map[string]time.Time{}
+	f.g.checkIdleFiles(context.Background())
+	t.Setenv("ENVDRIFT_AGENT_FAKE_LSOF", "")
+	f.g.checkIdleFiles(context.Background())
+
+	events, err := journal.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var kinds []string
+	for _, e := range events {
+		if e.Path == path {
+			kinds = append(kinds, e.Kind)
+		}
+	}
+	if got := strings.Join(kinds, " "); got != "deferred encrypted" {
+		t.Errorf("journal = %q, want one deferral then the encryption", got)
+	}
+}
@@ -14,7 +14,7 @@ import (
 )
 
 // writeRegistry writes ~/.envdrift/projects.json under the test HOME.
-func writeRegistry(t *testing.T, home string, paths ...string) {
+func writeRegistry(t testing.TB, home string, paths ...string) {
 	t.Helper()
 	dir := filepath.Join(home, ".envdrift")
 	if err := os.MkdirAll(dir, 0o755); err != nil {
@@ -34,7 +34,7 @@ func writeRegistry(t *testing.T, home string, paths ...string) {
 }
 
 // makeProject creates a project dir with an enabled envdrift.toml.
-func makeProject(t *testing.T) string {
+func makeProject(t testing.TB) string {
 	t.Helper()
 	d := t.TempDir()
 	toml := "[guardian]\nenabled = true\nidle_timeout = \"1s\"\n"
@@ -46,8 +46,10 @@ func TestMain(m *testing.M) {
 //     handle.exe/PowerShell probe.
 //   - envdrift encrypt <file>: writes a marker file (so the test knows the
 //     subprocess started), then acts per ENVDRIFT_AGENT_FAKE_ENVDRIFT:
-//     "ok" exits 0, "fail" exits 1, default ("hang") sleeps far longer than
-//     any test deadline — the hung `envdrift` subprocess from #494.
+//     "ok" exits 0, "fail" exits 1, "slow" exits 0 after 20ms (a stand-in
+//     for dotenvx's startup in benchmarks), "busy" exits 0 after 500ms (an
+//     encryption shutdown must drain), default ("hang") sleeps far longer
+//     than any test deadline — the hung `envdrift` subprocess from #494.
 func fakeBinMain() int {
 	base := strings.TrimSuffix(filepath.Base(os.Args[0]), ".exe")
 	switch base {
@@ -70,6 +72,12 @@ func fakeBinMain() int {
 				return 0
 			case "fail":
 				return 1
+			case "slow":
+				time.Sleep(20 * time.Millisecond)
+				return 0
+			case "busy":
+				time.Sleep(500 * time.Millisecond)
+				return 0
 			default:
 				time.Sleep(30 * time.Second)
 			}
@@ -82,7 +90,7 @@ func fakeBinMain() int {
 // installFakeBins copies the running test binary into a fresh dir as `envdrift`
 // (and `lsof` on Unix), prepends that dir to PATH, and arms the fake-bin env
 // vars so the copies act as fakes (see TestMain/fakeBinMain).
-func installFakeBins(t *testing.T, marker string) {
+func installFakeBins(t testing.TB, marker string) {
 	t.Helper()
 
 	self, err := os.Executable()
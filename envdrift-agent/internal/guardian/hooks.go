@@ -0,0 +1,63 @@
+package guardian
+
+import (
+	"context"
+	"log"
+	"os"
+	"time"
+
+	"github.com/jainal09/envdrift-agent/internal/hooks"
+	"github.com/jainal09/envdrift-agent/internal/plugins"
+)
+
+// pluginEvents maps the hooks plugins are told about to their event names.
+var pluginEvents = map[string]string{
+	hooks.PostEncrypt: plugins.EventEncrypted,
+	hooks.OnFailure:   plugins.EventFailed,
+}
+
+// runHook runs the [hooks] command for event on path, if one is configured,
+// and tells subscribed plugins. The error is the pre_encrypt veto; the other
+// hooks' errors are only logged, as the encryption they follow has already
+// happened or failed.
+func (g *Guardian) runHook(ctx context.Context, event, projectPath, path string, cause error) error {
+	if name, ok := pluginEvents[event]; ok && len(g.plugins) > 0 {
+		e := plugins.Event{Name: name, File: path, Project: projectPath, Time: time.Now().UTC()}
+		e.Host, _ = os.Hostname()
+		if cause != nil {
+			e.Error = cause.Error()
+		}
+		if err := plugins.Emit(ctx, g.plugins, e); err != nil {
+			log.Printf("[%s] %v", projectPath, err)
+		}
+	}
+	if g.hooks == nil {
+		return nil
+	}
+	err := g.hooks.Run(ctx, hooks.Event{Name: event, File: path, Project: projectPath, Err: cause})
+	if err != nil && event != hooks.PreEncrypt {
+		log.Printf("[%s] %v", projectPath, err)
+		return nil
+	}
+	return err
+}
+
+// loadPlugins describes the installed plugins once, before encrypting, so
+// events go to the plugins that were there when the agent started.
+func (g *Guardian) loadPlugins(ctx context.Context) {
+	list, err := plugins.List(ctx)
+	if err != nil {
+		log.Printf("Loading plugins: %v", err)
+		return
+	}
+	for _, p := range list {
+		if p.Err != nil {
+			log.Printf("Plugin %s is not used: %v", p.Name, p.Err)
+			continue
+		}
+		g.plugins = append(g.plugins, p)
+	}
+	if len(g.plugins) > 0 {
+		log.Printf("Loaded %d plugin(s) from %s", len(g.plugins), plugins.Dir())
+	}
+}
@@ -0,0 +1,96 @@
+package guardian
+
+import (
+	"context"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jainal09/envdrift-agent/internal/hooks"
+	"github.com/jainal09/envdrift-agent/internal/paths"
+	"github.com/jainal09/envdrift-agent/internal/plugins"
+)
+
+// TestCheckIdleFiles_Hooks covers [hooks]: a failing pre_encrypt hook defers
+// the encryption and keeps the file tracked; once it passes, the file is
+// encrypted and post_encrypt runs for it.
+func TestCheckIdleFiles_Hooks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook commands in this test are POSIX shell")
+	}
+	prevOut := log.Writer()
+	log.SetOutput(io.Discard)
+	t.Cleanup(func() { log.SetOutput(prevOut) })
+
+	f := newIdleCheckFixture(t, "ok")
+	gate := filepath.Join(t.TempDir(), "allow")
+	posted := filepath.Join(t.TempDir(), "posted")
+	f.g.hooks = &hooks.Runner{
+		Commands: map[string]string{
+			hooks.PreEncrypt:  "test -e " + gate,
+			hooks.PostEncrypt: `printf '%s' "$ENVDRIFT_FILE" > ` + posted,
+		},
+		Timeout: 5 * time.Second,
+	}
+	path := f.trackIdle(t, ".env", "SECRET=plaintext\n")
+
+	f.g.checkIdleFiles(context.Background())
+	if _, err := os.Stat(f.marker); err == nil {
+		t.Fatal("a vetoing pre_encrypt hook must stop the encryption")
+	}
+	if !f.tracked(path) {
+		t.Fatal("a vetoed file must stay tracked for a later check")
+	}
+
+	if err := os.WriteFile(gate, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	f.g.checkIdleFiles(context.Background())
+	if _, err := os.Stat(f.marker); err != nil {
+		t.Fatalf("envdrift encrypt was not invoked once the hook passed: %v", err)
+	}
+	if data, _ := os.ReadFile(posted); string(data) != path {
+		t.Errorf("post_encrypt saw ENVDRIFT_FILE=%q; want %s", data, path)
+	}
+}
+
+// TestCheckIdleFiles_Plugins covers plugin events: a plugin subscribed to
+// "encrypted" is told about each file the agent encrypts.
+func TestCheckIdleFiles_Plugins(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the test plugin is a POSIX shell script")
+	}
+	prevOut := log.Writer()
+	log.SetOutput(io.Discard)
+	t.Cleanup(func() { log.SetOutput(prevOut) })
+
+	f := newIdleCheckFixture(t, "ok")
+	t.Setenv(paths.HomeEnv, t.TempDir())
+	received := filepath.Join(t.TempDir(), "received")
+	script := "#!/bin/sh\ncase \"$1\" in\n" +
+		"describe) echo '{\"name\":\"audit\",\"events\":[\"encrypted\"]}' ;;\n" +
+		"event) cat > " + received + " ;;\nesac\n"
+	if err := os.MkdirAll(plugins.Dir(), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(plugins.Dir(), "audit"), []byte(script), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	f.g.loadPlugins(context.Background())
+	path := f.trackIdle(t, ".env", "SECRET=plaintext\n")
+
+	f.g.checkIdleFiles(context.Background())
+	data, err := os.ReadFile(received)
+	if err != nil {
+		t.Fatalf("the plugin was not told about the encryption: %v", err)
+	}
+	if !strings.Contains(string(data), `"name":"encrypted"`) || !strings.Contains(string(data), strconv.Quote(path)) {
+		t.Errorf("plugin received %s", data)
+	}
+}
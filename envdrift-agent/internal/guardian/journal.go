@@ -0,0 +1,56 @@
+package guardian
+
+import (
+	"log"
+
+	"github.com/jainal09/envdrift-agent/internal/journal"
+	"github.com/jainal09/envdrift-agent/internal/systemlog"
+)
+
+// record adds an event to the journal when guardian.journal is on, and to
+// the systemd journal when guardian.journald is. A decision repeating the
+// previous one for its file is dropped from both.
+func (g *Guardian) record(kind, projectPath, path, detail string) {
+	e := journal.Event{Kind: kind, Project: projectPath, Path: path, Detail: detail}
+	if journal.Repeats(e) {
+		return
+	}
+	systemlog.Record(systemlog.Event{
+		Level:   recordLevel[kind],
+		Kind:    kind,
+		Path:    path,
+		Project: projectPath,
+		Result:  recordResult[kind],
+		Message: recordMessage(kind, detail),
+	})
+	if !g.journal {
+		return
+	}
+	if err := journal.Append(e); err != nil {
+		log.Printf("Recording %s event in the journal: %v", kind, err)
+	}
+}
+
+// recordResult is the RESULT= field of a journal kind that decides a file;
+// watch and modified events have none.
+var recordResult = map[string]string{
+	journal.KindEncrypted: "success",
+	journal.KindAlready:   "skipped",
+	journal.KindGone:      "skipped",
+	journal.KindDeferred:  "deferred",
+	journal.KindFailed:    "failure",
+}
+
+var recordLevel = map[string]systemlog.Level{
+	journal.KindDeferred: systemlog.Warning,
+	journal.KindFailed:   systemlog.Error,
+
+	journal.KindSecretInConfig: systemlog.Warning,
+}
+
+func recordMessage(kind, detail string) string {
+	if detail == "" {
+		return kind
+	}
+	return kind + ": " + detail
+}
@@ -0,0 +1,14 @@
+package guardian
+
+import "github.com/jainal09/envdrift-agent/internal/config"
+
+// observes reports whether path lies under a [directories.roots] entry in
+// observe mode, and returns that entry: its plaintext env files are reported
+// like read-only mode's and never encrypted on their own.
+func (g *Guardian) observes(path string) (string, bool) {
+	if g.globalConfig == nil {
+		return "", false
+	}
+	mode, root := g.globalConfig.RootMode(path)
+	return root, mode == config.ModeObserve
+}
@@ -0,0 +1,46 @@
+package guardian
+
+import (
+	"context"
+	"io"
+	"log"
+	"os"
+	"testing"
+
+	"github.com/jainal09/envdrift-agent/internal/config"
+)
+
+// TestCheckIdleFiles_Observe leaves an idle plaintext file under an observe
+// root alone, stops tracking it, and keeps the rescan from tracking it again.
+func TestCheckIdleFiles_Observe(t *testing.T) {
+	prevOut := log.Writer()
+	log.SetOutput(io.Discard)
+	t.Cleanup(func() { log.SetOutput(prevOut) })
+
+	f := newIdleCheckFixture(t, "ok")
+	f.g.globalConfig.Directories.Roots = map[string]config.RootConfig{
+		f.projectDir: {Mode: config.ModeObserve},
+	}
+	path := f.trackIdle(t, ".env", "SECRET=plaintext\n")
+
+	f.g.checkIdleFiles(context.Background())
+
+	if _, err := os.Stat(f.marker); err == nil {
+		t.Error("envdrift encrypt must not run under an observe root")
+	}
+	if f.tracked(path) {
+		t.Error("a file left in plaintext by observe mode must not stay tracked")
+	}
+	if n := f.g.rescan(); n != 0 || f.tracked(path) {
+		t.Errorf("rescan found %d missed changes; want the observed file left untracked", n)
+	}
+
+	// An enforce root nested in it takes over.
+	f.g.globalConfig.Directories.Roots[f.projectDir+string(os.PathSeparator)+"app"] = config.RootConfig{Mode: config.ModeEnforce}
+	if root, ok := f.g.observes(path); !ok || root != f.projectDir {
+		t.Errorf("observes(.env) = %q, %v; want the project", root, ok)
+	}
+	if _, ok := f.g.observes(f.projectDir + string(os.PathSeparator) + "app" + string(os.PathSeparator) + ".env"); ok {
+		t.Error("observes(app/.env) = true under an enforce root")
+	}
+}
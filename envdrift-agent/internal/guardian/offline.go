@@ -0,0 +1,41 @@
+package guardian
+
+import (
+	"log"
+	"time"
+
+	"github.com/jainal09/envdrift-agent/internal/offline"
+)
+
+// offlineRecheck is how soon work queued while offline is retried.
+const offlineRecheck = time.Minute
+
+// queueIfOffline reports whether ops must wait for the network. While the
+// machine is offline they are recorded in the offline queue for `status`
+// instead of failing every interval; only the start and end of an outage
+// are logged. The vault sync loop and the idle-check worker both call it.
+func (g *Guardian) queueIfOffline(ops ...string) bool {
+	online := g.detectOnline == nil || g.detectOnline()
+
+	g.netMu.Lock()
+	defer g.netMu.Unlock()
+	if online {
+		if g.offline {
+			g.offline = false
+			st, _, err := offline.Clear()
+			if err != nil {
+				log.Printf("Clearing offline queue: %v", err)
+			}
+			log.Printf("Back online: retrying %d queued operation(s)", len(st.Queued))
+		}
+		return false
+	}
+	if !g.offline {
+		g.offline = true
+		log.Printf("Offline: queueing vault sync and telemetry until the network returns")
+	}
+	if err := offline.Queue(time.Now(), ops...); err != nil {
+		log.Printf("Recording offline queue: %v", err)
+	}
+	return true
+}
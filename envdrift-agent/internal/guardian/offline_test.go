@@ -0,0 +1,38 @@
+package guardian
+
+import (
+	"testing"
+
+	"github.com/jainal09/envdrift-agent/internal/offline"
+	"github.com/jainal09/envdrift-agent/internal/registry"
+)
+
+// TestQueueIfOffline checks that work is queued while offline, once per
+// operation, and that the queue clears when the network returns.
+func TestQueueIfOffline(t *testing.T) {
+	f := newIdleCheckFixture(t, "ok")
+	online := false
+	f.g.detectOnline = func() bool { return online }
+
+	reg := &registry.Registry{Projects: []registry.ProjectEntry{{Path: "/src/api"}, {Path: "/src/web"}}}
+	for i := 0; i < 3; i++ {
+		if !f.g.queueIfOffline(f.g.vaultSyncOps(reg)...) {
+			t.Fatal("vault sync not queued while offline")
+		}
+	}
+	if !f.g.queueIfOffline("telemetry report") {
+		t.Fatal("telemetry not queued while offline")
+	}
+	st, ok, err := offline.Read()
+	if err != nil || !ok || len(st.Queued) != 3 {
+		t.Fatalf("offline state = %+v, %v, %v; want 3 queued operations", st, ok, err)
+	}
+
+	online = true
+	if f.g.queueIfOffline("telemetry report") {
+		t.Fatal("queued while online")
+	}
+	if _, ok, _ := offline.Read(); ok {
+		t.Error("offline queue not cleared once back online")
+	}
+}
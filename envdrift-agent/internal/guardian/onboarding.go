@@ -0,0 +1,120 @@
+package guardian
+
+import (
+	"log"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jainal09/envdrift-agent/internal/encrypt"
+	"github.com/jainal09/envdrift-agent/internal/i18n"
+	"github.com/jainal09/envdrift-agent/internal/onboarding"
+	"github.com/jainal09/envdrift-agent/internal/paths"
+	"github.com/jainal09/envdrift-agent/internal/project"
+)
+
+// gracePeriod returns [guardian] grace_period; 0 turns onboarding off.
+func (g *Guardian) gracePeriod() time.Duration {
+	if g.globalConfig == nil {
+		return 0
+	}
+	return g.globalConfig.Guardian.GracePeriod
+}
+
+// onboard starts the grace period of the projects in configs the onboarding
+// ledger has not seen yet, and reports what the agent would encrypt in each
+// once it ends.
+func (g *Guardian) onboard(configs map[string]*project.GuardianConfig) {
+	grace := g.gracePeriod()
+	if grace <= 0 || len(configs) == 0 {
+		return
+	}
+	roots := make([]string, 0, len(configs))
+	for root := range configs {
+		roots = append(roots, root)
+	}
+	sort.Strings(roots)
+	added, err := onboarding.Track(roots, time.Now(), grace)
+	if err != nil {
+		log.Printf("Onboarding new projects: %v", err)
+		return
+	}
+	for _, r := range added {
+		files := g.wouldEncrypt(r.Path, configs[r.Path])
+		log.Printf("[%s] New project: observing until %s; %d plaintext env files would be encrypted%s. Run 'envdrift-agent approve %s' to enforce now.",
+			r.Path, r.Until.Local().Format(time.RFC822), len(files), listFiles(r.Path, files), r.Path)
+		if configs[r.Path].Notify && g.notifyWarning != nil {
+			_ = g.notifyWarning(i18n.T("guardian.onboarding", r.Path, len(files), r.Until.Local().Format(time.RFC822)))
+		}
+	}
+}
+
+// listFiles formats files relative to root for a log line: ": a, b".
+func listFiles(root string, files []string) string {
+	if len(files) == 0 {
+		return ""
+	}
+	rel := make([]string, len(files))
+	for i, f := range files {
+		if r, err := filepath.Rel(root, f); err == nil {
+			f = r
+		}
+		rel[i] = f
+	}
+	return ": " + strings.Join(rel, ", ")
+}
+
+// wouldEncrypt lists the plaintext env files cfg selects in root.
+func (g *Guardian) wouldEncrypt(root string, cfg *project.GuardianConfig) []string {
+	pw, err := NewProjectWatcher(root, cfg)
+	if err != nil {
+		return nil
+	}
+	defer pw.Stop()
+	var files []string
+	for _, path := range pw.watcher.Scan(root) {
+		if encrypted, err := encrypt.IsEncrypted(path); err == nil && !encrypted {
+			files = append(files, path)
+		}
+	}
+	return files
+}
+
+// WouldEncrypt lists the plaintext env files of the registered project at
+// root that the agent would encrypt, for `approve`.
+func (g *Guardian) WouldEncrypt(root string) ([]string, error) {
+	cfg, err := project.LoadProjectConfigWithDefaults(root, g.projectDefaults())
+	if err != nil {
+		return nil, err
+	}
+	return g.wouldEncrypt(root, cfg), nil
+}
+
+// observing returns the projects in their grace period at now, keyed by
+// path; nil when onboarding is off.
+func (g *Guardian) observing(now time.Time) map[string]onboarding.Root {
+	if g.gracePeriod() <= 0 {
+		return nil
+	}
+	roots, err := onboarding.Observing(now)
+	if err != nil {
+		log.Printf("Reading the onboarding ledger: %v", err)
+		return nil
+	}
+	held := make(map[string]onboarding.Root, len(roots))
+	for _, r := range roots {
+		held[r.Path] = r
+	}
+	return held
+}
+
+// observedRoot returns the root in held that contains path, if any.
+func observedRoot(held map[string]onboarding.Root, path string) (onboarding.Root, bool) {
+	for root, r := range held {
+		if paths.Within(filepath.Clean(root), path) {
+			return r, true
+		}
+	}
+	return onboarding.Root{}, false
+}
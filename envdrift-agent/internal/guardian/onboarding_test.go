@@ -0,0 +1,59 @@
+package guardian
+
+import (
+	"context"
+	"io"
+	"log"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jainal09/envdrift-agent/internal/onboarding"
+	"github.com/jainal09/envdrift-agent/internal/project"
+)
+
+// TestCheckIdleFiles_Onboarding observes a new project during its grace
+// period, keeping its idle plaintext file tracked, and encrypts it once the
+// project is approved.
+func TestCheckIdleFiles_Onboarding(t *testing.T) {
+	prevOut := log.Writer()
+	log.SetOutput(io.Discard)
+	t.Cleanup(func() { log.SetOutput(prevOut) })
+
+	f := newIdleCheckFixture(t, "ok")
+	f.g.globalConfig.Guardian.GracePeriod = time.Hour
+	// The first look at the ledger only records what is already watched.
+	if _, err := onboarding.Track(nil, time.Now(), time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	path := f.trackIdle(t, ".env", "SECRET=plaintext\n")
+	f.g.onboard(map[string]*project.GuardianConfig{f.projectDir: f.pw.config})
+
+	f.g.checkIdleFiles(context.Background())
+	if _, err := os.Stat(f.marker); err == nil {
+		t.Fatal("a project in its grace period must not be encrypted")
+	}
+	if !f.tracked(path) {
+		t.Fatal("an observed file must stay tracked for when the grace period ends")
+	}
+	if files := f.g.wouldEncrypt(f.projectDir, f.pw.config); len(files) != 1 || files[0] != path {
+		t.Errorf("wouldEncrypt = %v; want [%s]", files, path)
+	}
+
+	if _, found, err := onboarding.Approve(f.projectDir, time.Now()); !found || err != nil {
+		t.Fatalf("Approve = %v, %v", found, err)
+	}
+	f.g.checkIdleFiles(context.Background())
+	if _, err := os.Stat(f.marker); err != nil {
+		t.Fatalf("an approved project must be encrypted: %v", err)
+	}
+}
+
+// TestOnboard_Off leaves the ledger alone when grace_period is 0.
+func TestOnboard_Off(t *testing.T) {
+	f := newIdleCheckFixture(t, "ok")
+	f.g.onboard(map[string]*project.GuardianConfig{f.projectDir: f.pw.config})
+	if _, err := os.Stat(onboarding.LedgerPath()); !os.IsNotExist(err) {
+		t.Errorf("onboarding ledger written with grace_period = 0: %v", err)
+	}
+}
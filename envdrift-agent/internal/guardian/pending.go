@@ -0,0 +1,101 @@
+package guardian
+
+import (
+	"log"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/jainal09/envdrift-agent/internal/encrypt"
+	"github.com/jainal09/envdrift-agent/internal/journal"
+	"github.com/jainal09/envdrift-agent/internal/pending"
+)
+
+// deferFile records that the idle check left path in plaintext, and why, in
+// the journal and for `status`; due is when it is projected to be encrypted,
+// zero when that cannot be foreseen. Only the idle-check worker calls it.
+func (g *Guardian) deferFile(projectPath, path, why string, due time.Time) {
+	g.record(journal.KindDeferred, projectPath, path, why)
+	g.waiting[path] = pending.File{Reason: why, Due: due}
+}
+
+// trackedFile is a tracked file's state, for pendingFiles.
+type trackedFile struct {
+	path      string
+	lastMod   time.Time
+	firstSeen time.Time
+	due       bool
+}
+
+// tracked returns the state of the tracked files.
+func (pw *ProjectWatcher) tracked() []trackedFile {
+	pw.mu.RLock()
+	defer pw.mu.RUnlock()
+	files := make([]trackedFile, 0, len(pw.lastMod))
+	for path, modTime := range pw.lastMod {
+		files = append(files, trackedFile{path: path, lastMod: modTime, firstSeen: pw.firstSeen[path], due: pw.due[path]})
+	}
+	return files
+}
+
+// pendingFiles lists the tracked plaintext files at now with the reason
+// the last idle check gave for each; a file it did not decide is still
+// waiting for idle, due once its idle timeout passes. Only the idle-check
+// worker calls it.
+func (g *Guardian) pendingFiles(now time.Time) []pending.File {
+	g.mu.RLock()
+	projects := make(map[string]*ProjectWatcher, len(g.projects))
+	for k, v := range g.projects {
+		projects[k] = v
+	}
+	g.mu.RUnlock()
+	projectPaths := make([]string, 0, len(projects))
+	for path := range projects {
+		projectPaths = append(projectPaths, path)
+	}
+	sort.Strings(projectPaths)
+
+	var files []pending.File
+	var seen fileSet
+	for _, projectPath := range projectPaths {
+		pw := projects[projectPath]
+		timeout := pw.idleTimeout(g.policy.IdleTimeout)
+		for _, t := range pw.tracked() {
+			if !seen.add(t.path) {
+				continue
+			}
+			f, decided := g.waiting[t.path]
+			if !decided {
+				if encrypted, err := encrypt.IsEncrypted(t.path); err != nil || encrypted {
+					continue
+				}
+				f = pending.File{Reason: pending.ReasonIdle, Due: t.lastMod.Add(timeout)}
+				if t.due {
+					f.Due = now
+				}
+			}
+			f.Path, f.Project, f.FirstSeen = t.path, projectPath, t.firstSeen
+			files = append(files, f)
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files
+}
+
+// writePending records the pending files for `status` when they changed
+// since the last write; none in read-only mode, which encrypts nothing.
+// Only the idle-check worker calls it, after checkIdleFiles.
+func (g *Guardian) writePending() {
+	var files []pending.File
+	if !g.readOnly {
+		files = g.pendingFiles(time.Now())
+	}
+	if reflect.DeepEqual(files, g.pendingWritten) {
+		return
+	}
+	if err := pending.Write(files); err != nil {
+		log.Printf("Recording pending files: %v", err)
+		return
+	}
+	g.pendingWritten = files
+}
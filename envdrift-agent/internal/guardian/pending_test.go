@@ -0,0 +1,59 @@
+package guardian
+
+import (
+	"context"
+	"io"
+	"log"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jainal09/envdrift-agent/internal/exports"
+	"github.com/jainal09/envdrift-agent/internal/pending"
+)
+
+// TestWritePending covers the per-file state `status` shows: a file still
+// changing waits for idle until its timeout, a snoozed file until its
+// session expires, and encrypted files are not listed.
+func TestWritePending(t *testing.T) {
+	prevOut := log.Writer()
+	log.SetOutput(io.Discard)
+	t.Cleanup(func() { log.SetOutput(prevOut) })
+
+	f := newIdleCheckFixture(t, "ok")
+	f.pw.config.IdleTimeout = 5 * time.Minute
+	changed := time.Now().Add(-time.Minute).Round(0)
+	fresh := f.trackIdle(t, ".env", "SECRET=plaintext\n")
+	f.pw.TrackFile(fresh, changed)
+	snoozed := f.trackIdle(t, ".env.local", "SECRET=plaintext\n")
+	expires := time.Now().Add(10 * time.Minute)
+	if err := exports.Add(exports.Export{Path: snoozed, Expires: expires, Action: exports.ActionEncrypt, Edit: true}); err != nil {
+		t.Fatal(err)
+	}
+	f.trackIdle(t, ".env.production", "SECRET=\"encrypted:BDx\"\n")
+
+	f.g.checkIdleFiles(context.Background())
+	f.g.writePending()
+
+	files, err := pending.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("pending = %+v; want .env and .env.local", files)
+	}
+	byPath := map[string]pending.File{files[0].Path: files[0], files[1].Path: files[1]}
+	if p := byPath[fresh]; p.Reason != pending.ReasonIdle || !p.Due.Equal(changed.Add(5*time.Minute)) ||
+		p.Project != f.projectDir || p.FirstSeen.IsZero() {
+		t.Errorf("pending %s = %+v; want waiting for idle until %v", filepath.Base(fresh), p, changed.Add(5*time.Minute))
+	}
+	if p := byPath[snoozed]; p.Reason != "edit or export session" || !p.Due.Equal(expires) {
+		t.Errorf("pending %s = %+v; want held by the session until %v", filepath.Base(snoozed), p, expires)
+	}
+
+	f.g.readOnly = true
+	f.g.writePending()
+	if files, err := pending.Read(); err != nil || len(files) != 0 {
+		t.Errorf("pending in read-only mode = %v, %v; want none", files, err)
+	}
+}
@@ -0,0 +1,60 @@
+package guardian
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/jainal09/envdrift-agent/internal/config"
+	"github.com/jainal09/envdrift-agent/internal/severity"
+)
+
+// networkRecheck spaces network detection: it runs external probes, and the
+// policies only need to follow a laptop moving between networks.
+const networkRecheck = time.Minute
+
+// refreshPolicy re-detects the network and recombines [[policies]] when the
+// last detection is older than networkRecheck. Only the idle-check worker
+// calls it, so g.policy needs no lock.
+func (g *Guardian) refreshPolicy(ctx context.Context) {
+	policies := g.globalConfig.AllPolicies()
+	if len(policies) == 0 {
+		return
+	}
+	now := time.Now()
+	if !g.policyChecked.IsZero() && now.Sub(g.policyChecked) < networkRecheck {
+		return
+	}
+	g.policyChecked = now
+
+	state := g.detectNetwork(ctx)
+	policy := config.MatchPolicies(policies, state)
+	if strings.Join(policy.Names, ",") != strings.Join(g.policy.Names, ",") {
+		if len(policy.Names) == 0 {
+			log.Printf("Network changed (ssid=%q vpn=%v domain=%v): no policy applies",
+				state.SSID, state.VPN, state.DomainJoined)
+		} else {
+			log.Printf("Network changed (ssid=%q vpn=%v domain=%v): applying %s",
+				state.SSID, state.VPN, state.DomainJoined, strings.Join(policy.Names, ", "))
+		}
+	}
+	g.policy = policy
+}
+
+// shouldNotify is pw's notify setting unless a policy overrides it.
+func (g *Guardian) shouldNotify(pw *ProjectWatcher) bool {
+	if g.policy.Notify != nil {
+		return *g.policy.Notify
+	}
+	return pw.config.Notify
+}
+
+// notifyLevel is the least severe plaintext file whose encryption is worth a
+// notification, from [severity] notify.
+func (g *Guardian) notifyLevel() severity.Level {
+	if g.globalConfig == nil {
+		return severity.Low
+	}
+	return g.globalConfig.Severity.NotifyLevel()
+}
@@ -0,0 +1,59 @@
+package guardian
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jainal09/envdrift-agent/internal/config"
+	"github.com/jainal09/envdrift-agent/internal/netstate"
+)
+
+// TestPolicies_CapIdleTimeoutOffVPN checks that a [[policies]] entry
+// shortens the idle timeout only while its network condition holds, and that
+// the network is re-detected at most once per networkRecheck.
+func TestPolicies_CapIdleTimeoutOffVPN(t *testing.T) {
+	f := newIdleCheckFixture(t, "ok")
+	offVPN := false
+	f.g.globalConfig.Policies = []config.PolicyConfig{{
+		Name:        "off-vpn",
+		When:        config.PolicyCondition{VPN: &offVPN},
+		IdleTimeout: time.Minute,
+	}}
+	vpn := true
+	probes := 0
+	f.g.detectNetwork = func(context.Context) netstate.State {
+		probes++
+		return netstate.State{VPN: vpn}
+	}
+
+	path := filepath.Join(f.projectDir, ".env")
+	if err := os.WriteFile(path, []byte("SECRET=1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// Idle for 2m: under the default 5m timeout, over the policy's 1m.
+	f.pw.TrackFile(path, time.Now().Add(-2*time.Minute))
+
+	f.g.refreshPolicy(context.Background())
+	f.g.checkIdleFiles(context.Background())
+	if !f.tracked(path) {
+		t.Fatal("encrypted on the VPN, where no policy applies")
+	}
+
+	vpn = false
+	f.g.refreshPolicy(context.Background())
+	if probes != 1 {
+		t.Fatalf("network probed %d times within networkRecheck; want 1", probes)
+	}
+	f.g.policyChecked = time.Now().Add(-networkRecheck)
+	f.g.refreshPolicy(context.Background())
+	if len(f.g.policy.Names) != 1 || f.g.policy.IdleTimeout != time.Minute {
+		t.Fatalf("policy off the VPN = %+v", f.g.policy)
+	}
+	f.g.checkIdleFiles(context.Background())
+	if f.tracked(path) {
+		t.Error("off the VPN the 1m policy cap did not encrypt a 2m-idle file")
+	}
+}
@@ -0,0 +1,60 @@
+package guardian
+
+import (
+	"log"
+	"time"
+
+	"github.com/jainal09/envdrift-agent/internal/power"
+)
+
+const (
+	// powerRecheck spaces power detection and is how soon deferred work is
+	// retried.
+	powerRecheck = time.Minute
+	// openRecheckOnBattery spaces the lockcheck probes (an lsof or
+	// handle.exe run) of an idle file found open, while on battery.
+	openRecheckOnBattery = 2 * time.Minute
+)
+
+// powerState returns the power state, re-detected at most every
+// powerRecheck. The vault sync loop and the idle-check worker both call it.
+func (g *Guardian) powerState() power.State {
+	g.powerMu.Lock()
+	defer g.powerMu.Unlock()
+	if now := time.Now(); g.powerChecked.IsZero() || now.Sub(g.powerChecked) >= powerRecheck {
+		g.power = g.detectPower()
+		g.powerChecked = now
+	}
+	return g.power
+}
+
+// deferForPower reports whether background work should wait for the
+// battery: on battery at or below [power] defer_below. what names the work
+// for the log, which records only the start of a deferral.
+func (g *Guardian) deferForPower(what string, deferring *bool) bool {
+	st := g.powerState()
+	low := st.Low(g.globalConfig.Power.DeferBelow)
+	if low && !*deferring {
+		log.Printf("Deferring %s: on %s (defer_below = %d%%)", what, st, g.globalConfig.Power.DeferBelow)
+	} else if !low && *deferring {
+		log.Printf("Resuming %s: on %s", what, st)
+	}
+	*deferring = low
+	return low
+}
+
+// skipOpenProbe reports whether path, found open by the last lockcheck
+// probe, should not be probed again yet: on battery a file held open by an
+// editor is re-probed every openRecheckOnBattery instead of on every idle
+// check. Only the idle-check worker calls it.
+func (g *Guardian) skipOpenProbe(path string, now time.Time) bool {
+	last, ok := g.openProbed[path]
+	if !ok {
+		return false
+	}
+	if !g.powerState().OnBattery || now.Sub(last) >= openRecheckOnBattery {
+		delete(g.openProbed, path)
+		return false
+	}
+	return true
+}
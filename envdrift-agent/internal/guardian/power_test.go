@@ -0,0 +1,75 @@
+package guardian
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jainal09/envdrift-agent/internal/power"
+)
+
+// TestDeferForPower_LowBattery checks that background work is deferred only
+// on battery at or below [power] defer_below, and that the power source is
+// re-detected at most once per powerRecheck.
+func TestDeferForPower_LowBattery(t *testing.T) {
+	f := newIdleCheckFixture(t, "ok")
+	f.g.globalConfig.Power.DeferBelow = 20
+	state := power.State{OnBattery: true, Percent: 15}
+	probes := 0
+	f.g.detectPower = func() power.State {
+		probes++
+		return state
+	}
+
+	deferring := false
+	if !f.g.deferForPower("vault sync", &deferring) || !deferring {
+		t.Fatal("15% on battery was not deferred with defer_below = 20")
+	}
+
+	state = power.State{OnBattery: false, Percent: 15}
+	if !f.g.deferForPower("vault sync", &deferring) {
+		t.Error("power re-detected within powerRecheck")
+	}
+	if probes != 1 {
+		t.Fatalf("power probed %d times within powerRecheck; want 1", probes)
+	}
+
+	f.g.powerChecked = time.Now().Add(-powerRecheck)
+	if f.g.deferForPower("vault sync", &deferring) || deferring {
+		t.Error("still deferred after plugging in")
+	}
+
+	f.g.globalConfig.Power.DeferBelow = 0
+	state = power.State{OnBattery: true, Percent: 1}
+	f.g.powerChecked = time.Time{}
+	if f.g.deferForPower("vault sync", &deferring) {
+		t.Error("deferred with defer_below = 0")
+	}
+}
+
+// TestSkipOpenProbe_OnBattery checks that an idle file found open is not
+// re-probed by lockcheck until openRecheckOnBattery passes on battery, and
+// is re-probed on every check on mains power.
+func TestSkipOpenProbe_OnBattery(t *testing.T) {
+	f := newIdleCheckFixture(t, "ok")
+	onBattery := true
+	f.g.detectPower = func() power.State { return power.State{OnBattery: onBattery, Percent: 80} }
+
+	now := time.Now()
+	if f.g.skipOpenProbe("/p/.env", now) {
+		t.Fatal("skipped a file never found open")
+	}
+	f.g.openProbed["/p/.env"] = now
+	if !f.g.skipOpenProbe("/p/.env", now.Add(time.Minute)) {
+		t.Error("re-probed an open file within openRecheckOnBattery on battery")
+	}
+	if f.g.skipOpenProbe("/p/.env", now.Add(openRecheckOnBattery)) {
+		t.Error("open file not re-probed after openRecheckOnBattery")
+	}
+
+	onBattery = false
+	f.g.powerChecked = time.Time{}
+	f.g.openProbed["/p/.env"] = now
+	if f.g.skipOpenProbe("/p/.env", now.Add(time.Second)) {
+		t.Error("skipped the probe on mains power")
+	}
+}
@@ -0,0 +1,25 @@
+package guardian
+
+import (
+	"path/filepath"
+
+	"github.com/jainal09/envdrift-agent/internal/paths"
+)
+
+// profilePaths drops the registered projects outside the active profile's
+// watch roots, so a work profile never touches personal projects.
+func (g *Guardian) profilePaths(projects []string) []string {
+	if g.profileRoots == nil {
+		return projects
+	}
+	var kept []string
+	for _, p := range projects {
+		for _, root := range g.profileRoots {
+			if paths.Within(filepath.Clean(root), filepath.Clean(p)) {
+				kept = append(kept, p)
+				break
+			}
+		}
+	}
+	return kept
+}
@@ -0,0 +1,28 @@
+package guardian
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestProfilePaths(t *testing.T) {
+	root := t.TempDir()
+	work := filepath.Join(root, "work")
+	paths := []string{
+		filepath.Join(work, "api"),
+		work,
+		filepath.Join(root, "workshop"),
+		filepath.Join(root, "code", "blog"),
+	}
+
+	g := &Guardian{}
+	if got := g.profilePaths(paths); !reflect.DeepEqual(got, paths) {
+		t.Errorf("without a profile = %v; want every path", got)
+	}
+	g.profileRoots = []string{work + string(filepath.Separator)}
+	want := paths[:2]
+	if got := g.profilePaths(paths); !reflect.DeepEqual(got, want) {
+		t.Errorf("work profile = %v; want %v", got, want)
+	}
+}
@@ -0,0 +1,175 @@
+package guardian
+
+import (
+	"context"
+	"log"
+	"sync"
+)
+
+// encryptQueue runs the guardian's encryptions on one worker, in the order
+// they were enqueued. Shutdown drains it rather than abandoning it: Stop
+// refuses new jobs but lets the queued and running ones finish, so a
+// restart does not kill `envdrift encrypt` halfway through a file or skip
+// the journal entry, notification and post_encrypt hook of one it finished.
+// Only a drain that outlasts Stop's context cancels the running job.
+type encryptQueue struct {
+	mu       sync.Mutex
+	jobs     []*queuedJob
+	started  bool
+	stopping bool
+	// wake nudges an idle worker after Enqueue or Stop.
+	wake   chan struct{}
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// queuedJob is one function waiting for the queue's worker.
+type queuedJob struct {
+	run  func(ctx context.Context)
+	done chan struct{}
+}
+
+func newEncryptQueue() *encryptQueue {
+	return &encryptQueue{wake: make(chan struct{}, 1), done: make(chan struct{})}
+}
+
+// Start runs the worker. Jobs get a context derived from ctx: cancelling
+// ctx abandons the queue, cancelling the running job and dropping the
+// queued ones, as a drain cut short does.
+func (q *encryptQueue) Start(ctx context.Context) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.started {
+		return
+	}
+	q.started = true
+	ctx, q.cancel = context.WithCancel(ctx)
+	go q.work(ctx)
+}
+
+// Enqueue adds run to the queue and returns a channel closed once it has
+// returned, or has been dropped without running. It returns false, and
+// run is never called, once Stop has been called.
+func (q *encryptQueue) Enqueue(run func(ctx context.Context)) (<-chan struct{}, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.stopping {
+		return nil, false
+	}
+	job := &queuedJob{run: run, done: make(chan struct{})}
+	q.jobs = append(q.jobs, job)
+	q.signal()
+	return job.done, true
+}
+
+// Stop refuses new jobs and waits for the worker to finish the queued and
+// running ones. When ctx ends first, the running job's context is cancelled
+// and the jobs not yet started are dropped; Stop then still waits for the
+// worker to exit and returns ctx's error. A queue never started has nothing
+// to drain.
+func (q *encryptQueue) Stop(ctx context.Context) error {
+	q.mu.Lock()
+	q.stopping = true
+	started := q.started
+	if !started {
+		q.dropLocked()
+	}
+	q.signal()
+	q.mu.Unlock()
+	if !started {
+		return nil
+	}
+
+	select {
+	case <-q.done:
+		q.cancel()
+		return nil
+	case <-ctx.Done():
+		q.cancel()
+		<-q.done
+		return ctx.Err()
+	}
+}
+
+// work runs jobs until the queue is stopped and empty, or ctx ends.
+func (q *encryptQueue) work(ctx context.Context) {
+	defer close(q.done)
+	for {
+		q.mu.Lock()
+		if ctx.Err() != nil {
+			q.dropLocked()
+			q.mu.Unlock()
+			return
+		}
+		if len(q.jobs) == 0 {
+			stopping := q.stopping
+			q.mu.Unlock()
+			if stopping {
+				return
+			}
+			select {
+			case <-q.wake:
+			case <-ctx.Done():
+			}
+			continue
+		}
+		job := q.jobs[0]
+		q.jobs = q.jobs[1:]
+		q.mu.Unlock()
+
+		func() {
+			defer close(job.done)
+			job.run(ctx)
+		}()
+	}
+}
+
+// signal wakes the worker. Callers hold q.mu.
+func (q *encryptQueue) signal() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// dropLocked releases the jobs not yet started without running them.
+// Callers hold q.mu.
+func (q *encryptQueue) dropLocked() {
+	for _, job := range q.jobs {
+		close(job.done)
+	}
+	q.jobs = nil
+}
+
+// encryptQueued encrypts path on the queue and waits for it, returning what
+// encryptIdleFile returned: false when the guardian is shutting down,
+// including when the queue no longer takes jobs. Without a queue (a
+// Guardian not started) it encrypts inline, bounded by ctx.
+func (g *Guardian) encryptQueued(ctx context.Context, projectPath string, pw *ProjectWatcher, path string) bool {
+	if g.queue == nil {
+		return g.encryptIdleFile(ctx, projectPath, pw, path)
+	}
+	cont := false
+	done, ok := g.queue.Enqueue(func(ctx context.Context) {
+		defer g.recoverPanic()
+		cont = g.encryptIdleFile(ctx, projectPath, pw, path)
+	})
+	if !ok {
+		return false
+	}
+	<-done
+	return cont
+}
+
+// drainQueue stops the encryption queue, giving the encryption in flight
+// drainTimeout to finish before its subprocess is killed.
+func (g *Guardian) drainQueue() {
+	if g.queue == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), g.drainTimeout)
+	defer cancel()
+	if err := g.queue.Stop(ctx); err != nil {
+		log.Printf("Encryption still running %v after shutdown began; stopped it", g.drainTimeout)
+	}
+}
@@ -0,0 +1,270 @@
+package guardian
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestEncryptQueue_RunsInOrder checks that jobs run one at a time in the
+// order they were enqueued.
+func TestEncryptQueue_RunsInOrder(t *testing.T) {
+	q := newEncryptQueue()
+	q.Start(context.Background())
+
+	var got []int
+	var last <-chan struct{}
+	for i := range 5 {
+		done, ok := q.Enqueue(func(context.Context) { got = append(got, i) })
+		if !ok {
+			t.Fatalf("Enqueue %d refused before Stop", i)
+		}
+		last = done
+	}
+	<-last
+	if err := q.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	if want := []int{0, 1, 2, 3, 4}; !slices.Equal(got, want) {
+		t.Errorf("jobs ran as %v, want %v", got, want)
+	}
+}
+
+// TestEncryptQueue_StopDrains checks that Stop lets the running job and the
+// queued ones finish, with their contexts live, and refuses new jobs.
+func TestEncryptQueue_StopDrains(t *testing.T) {
+	q := newEncryptQueue()
+	q.Start(context.Background())
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	var finished atomic.Int32
+	q.Enqueue(func(ctx context.Context) {
+		close(started)
+		<-release
+		if ctx.Err() == nil {
+			finished.Add(1)
+		}
+	})
+	q.Enqueue(func(ctx context.Context) {
+		if ctx.Err() == nil {
+			finished.Add(1)
+		}
+	})
+	<-started
+
+	stopped := make(chan error, 1)
+	go func() { stopped <- q.Stop(context.Background()) }()
+	waitRefused(t, q)
+	close(release)
+
+	if err := <-stopped; err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	if n := finished.Load(); n != 2 {
+		t.Errorf("%d jobs finished with a live context, want 2", n)
+	}
+}
+
+// TestEncryptQueue_StopDeadline checks that a drain outlasting Stop's
+// context cancels the running job and drops the queued one without running
+// it, and that Stop still waits for the running job to return.
+func TestEncryptQueue_StopDeadline(t *testing.T) {
+	q := newEncryptQueue()
+	q.Start(context.Background())
+
+	started := make(chan struct{})
+	var cancelled, returned atomic.Bool
+	q.Enqueue(func(ctx context.Context) {
+		close(started)
+		<-ctx.Done()
+		cancelled.Store(true)
+		time.Sleep(10 * time.Millisecond)
+		returned.Store(true)
+	})
+	var ran atomic.Bool
+	dropped, _ := q.Enqueue(func(context.Context) { ran.Store(true) })
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := q.Stop(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Stop = %v, want context.DeadlineExceeded", err)
+	}
+	if !cancelled.Load() || !returned.Load() {
+		t.Errorf("running job cancelled=%v returned=%v when Stop returned, want both", cancelled.Load(), returned.Load())
+	}
+	select {
+	case <-dropped:
+	default:
+		t.Error("the dropped job's channel is not closed")
+	}
+	if ran.Load() {
+		t.Error("a job queued behind a cut-short drain ran")
+	}
+}
+
+// TestEncryptQueue_StartContextCancelled checks that cancelling Start's
+// context abandons the queue: the running job is cancelled and Stop has
+// nothing left to wait for.
+func TestEncryptQueue_StartContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	q := newEncryptQueue()
+	q.Start(ctx)
+
+	started := make(chan struct{})
+	done, _ := q.Enqueue(func(ctx context.Context) {
+		close(started)
+		<-ctx.Done()
+	})
+	<-started
+	cancel()
+	<-done
+	if err := q.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+}
+
+// TestEncryptQueue_EnqueueDuringShutdown enqueues from many goroutines while
+// Stop runs: every job accepted runs exactly once before Stop returns,
+// every one refused never runs, and the race detector stays quiet.
+func TestEncryptQueue_EnqueueDuringShutdown(t *testing.T) {
+	for range 20 {
+		q := newEncryptQueue()
+		q.Start(context.Background())
+
+		var accepted, ran atomic.Int32
+		var wg sync.WaitGroup
+		for range 8 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for range 50 {
+					if _, ok := q.Enqueue(func(context.Context) { ran.Add(1) }); !ok {
+						return
+					}
+					accepted.Add(1)
+				}
+			}()
+		}
+		time.Sleep(time.Millisecond)
+		if err := q.Stop(context.Background()); err != nil {
+			t.Fatalf("Stop: %v", err)
+		}
+		// Every accepted job ran before Stop returned; the senders still
+		// running see only refusals from here on.
+		drained := ran.Load()
+		wg.Wait()
+		if a, r := accepted.Load(), ran.Load(); a != r || r != drained {
+			t.Fatalf("accepted %d jobs, %d ran (%d by the time Stop returned)", a, r, drained)
+		}
+		if _, ok := q.Enqueue(func(context.Context) {}); ok {
+			t.Fatal("Enqueue accepted a job after Stop")
+		}
+	}
+}
+
+// TestEncryptQueue_StopBeforeStart checks that a queue never started stops
+// at once and releases what was queued.
+func TestEncryptQueue_StopBeforeStart(t *testing.T) {
+	q := newEncryptQueue()
+	done, _ := q.Enqueue(func(context.Context) { t.Error("job ran on a queue never started") })
+	if err := q.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	<-done
+}
+
+// TestGuardian_ShutdownDrainsEncryption is the restart regression: an
+// encryption in flight when the agent is told to stop finishes, and the file
+// is dropped from tracking as encrypted, instead of its subprocess being
+// killed halfway.
+func TestGuardian_ShutdownDrainsEncryption(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping subprocess test in short mode")
+	}
+	prevOut := log.Writer()
+	log.SetOutput(io.Discard)
+	t.Cleanup(func() { log.SetOutput(prevOut) })
+
+	t.Setenv("ENVDRIFT_AGENT_FAKE_ENVDRIFT", "busy")
+	_, pw, projectDir, cancel, done := slowEncryptGuardian(t)
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Start returned error on shutdown: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Start() did not return within 5s of cancel")
+	}
+
+	pw.mu.RLock()
+	_, tracked := pw.lastMod[filepath.Join(projectDir, ".env")]
+	pw.mu.RUnlock()
+	if tracked {
+		t.Error("the encryption in flight at shutdown did not complete: .env is still tracked")
+	}
+}
+
+// TestGuardian_ShutdownStopsHungEncryption checks that a drain is bounded:
+// a hung encryption is killed once drainTimeout passes and its file stays
+// tracked for the next run.
+func TestGuardian_ShutdownStopsHungEncryption(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping subprocess test in short mode")
+	}
+	prevOut := log.Writer()
+	log.SetOutput(io.Discard)
+	t.Cleanup(func() { log.SetOutput(prevOut) })
+
+	g, pw, projectDir, cancel, done := slowEncryptGuardian(t)
+	g.drainTimeout = 100 * time.Millisecond
+	start := time.Now()
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Start() did not return within 5s of cancel")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("shutdown took %v with a 100ms drain", elapsed)
+	}
+
+	path := filepath.Join(projectDir, ".env")
+	pw.mu.RLock()
+	_, tracked := pw.lastMod[path]
+	pw.mu.RUnlock()
+	if !tracked {
+		t.Error("a file whose encryption was killed at shutdown must stay tracked")
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != "SECRET=plaintext\n" {
+		t.Errorf(".env = %q, %v; want the plaintext left alone", data, err)
+	}
+}
+
+// waitRefused waits until q refuses new jobs.
+func waitRefused(t *testing.T, q *encryptQueue) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		q.mu.Lock()
+		stopping := q.stopping
+		q.mu.Unlock()
+		if stopping {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Stop never began refusing jobs")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,101 @@
+package guardian
+
+import (
+	"context"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/jainal09/envdrift-agent/internal/paths"
+	"github.com/jainal09/envdrift-agent/internal/recheck"
+)
+
+// watchRechecks takes the requests `envdrift-agent recheck` files (from the
+// git hooks, after a checkout or merge) as they arrive, marks the env files
+// under each requested directory due, and signals due so Start runs an idle
+// check right away. Requests left from before the agent started are taken
+// first. It returns when ctx is cancelled.
+func (g *Guardian) watchRechecks(ctx context.Context, due chan<- struct{}) {
+	signal := func() {
+		if g.takeRechecks() > 0 {
+			select {
+			case due <- struct{}{}:
+			default: // A check is already queued.
+			}
+		}
+	}
+
+	if err := os.MkdirAll(recheck.Dir(), 0o755); err != nil {
+		log.Printf("Recheck requests disabled: %v", err)
+		return
+	}
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		log.Printf("Recheck requests disabled: %v", err)
+		return
+	}
+	defer func() { _ = fsw.Close() }()
+	if err := fsw.Add(recheck.Dir()); err != nil {
+		log.Printf("Recheck requests disabled: %v", err)
+		return
+	}
+
+	signal()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-fsw.Events:
+			if !ok {
+				return
+			}
+			if event.Op&(fsnotify.Create|fsnotify.Rename|fsnotify.Write) != 0 {
+				signal()
+			}
+		case err, ok := <-fsw.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("Recheck watcher error: %v", err)
+		}
+	}
+}
+
+// takeRechecks takes the pending recheck requests and marks every env file
+// under a requested directory that a watched project covers as due, so the
+// next idle check verifies it without waiting for the idle timeout. It
+// returns how many files it marked.
+func (g *Guardian) takeRechecks() int {
+	dirs, err := recheck.Take()
+	if err != nil {
+		log.Printf("Error reading recheck requests: %v", err)
+		return 0
+	}
+
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	marked := 0
+	for _, dir := range dirs {
+		dir = filepath.Clean(dir)
+		for projectPath, pw := range g.projects {
+			// Scan the overlap: the requested repository inside the
+			// project, or the project inside the repository.
+			root := filepath.Clean(projectPath)
+			switch {
+			case paths.Within(root, dir):
+				root = dir
+			case paths.Within(dir, root):
+			default:
+				continue
+			}
+			for _, path := range pw.watcher.Scan(root) {
+				pw.MarkDue(path)
+				marked++
+			}
+		}
+		log.Printf("Recheck requested for %s", dir)
+	}
+	return marked
+}
@@ -0,0 +1,50 @@
+package guardian
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jainal09/envdrift-agent/internal/recheck"
+)
+
+// TestTakeRechecks_EncryptsWithoutIdleTimeout checks that a recheck request
+// has the next idle check encrypt a freshly checked-out plaintext file,
+// which would otherwise wait out the idle timeout.
+func TestTakeRechecks_EncryptsWithoutIdleTimeout(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	f := newIdleCheckFixture(t, "ok")
+
+	path := filepath.Join(f.projectDir, "app", ".env")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("SECRET=plaintext\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f.pw.TrackFile(path, time.Now())
+
+	if err := recheck.Request(filepath.Join(f.projectDir, "app")); err != nil {
+		t.Fatal(err)
+	}
+	if err := recheck.Request(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	if n := f.g.takeRechecks(); n != 1 {
+		t.Fatalf("marked %d files; want 1", n)
+	}
+	// A later write does not postpone a due file.
+	f.pw.TrackFile(path, time.Now())
+
+	f.g.checkIdleFiles(context.Background())
+	if _, err := os.Stat(f.marker); err != nil {
+		t.Fatal("the rechecked file was not encrypted")
+	}
+	if f.tracked(path) {
+		t.Error("encrypted file still tracked")
+	}
+}
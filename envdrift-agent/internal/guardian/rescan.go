@@ -0,0 +1,117 @@
+package guardian
+
+import (
+	"context"
+	"log"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/jainal09/envdrift-agent/internal/encrypt"
+	"github.com/jainal09/envdrift-agent/internal/journal"
+)
+
+// rescanLoop re-walks every watched project each interval ([guardian]
+// rescan_interval) until ctx is cancelled.
+func (g *Guardian) rescanLoop(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			g.rescan()
+		}
+	}
+}
+
+// rescan compares the state the watcher's events built with a walk of each
+// project: a plaintext env file that is not tracked, or was written after
+// its tracked modification, had its event missed and is tracked now, so the
+// idle check encrypts it as usual; a tracked file that is gone had its
+// removal missed and is dropped. Each discrepancy is logged. It returns how
+// many it found.
+func (g *Guardian) rescan() int {
+	g.mu.RLock()
+	projects := make(map[string]*ProjectWatcher, len(g.projects))
+	for k, v := range g.projects {
+		projects[k] = v
+	}
+	g.mu.RUnlock()
+	projectPaths := make([]string, 0, len(projects))
+	for path := range projects {
+		projectPaths = append(projectPaths, path)
+	}
+	sort.Strings(projectPaths)
+
+	missed := 0
+	for _, projectPath := range projectPaths {
+		pw := projects[projectPath]
+		found := make(map[string]bool)
+		for _, path := range pw.watcher.Scan(projectPath) {
+			found[path] = true
+			// The idle check drops observed files from tracking on purpose.
+			if _, ok := g.observes(path); ok {
+				continue
+			}
+			info, err := os.Stat(path)
+			if err != nil {
+				continue
+			}
+			modTime, tracked := pw.trackedModTime(path)
+			switch {
+			case !tracked:
+				if encrypted, err := encrypt.IsEncrypted(path); err != nil || encrypted {
+					continue
+				}
+				log.Printf("[%s] Rescan: untracked plaintext file, its event was missed: %s", projectPath, path)
+			case info.ModTime().After(modTime):
+				log.Printf("[%s] Rescan: written since its last event, the write was missed: %s", projectPath, path)
+			default:
+				continue
+			}
+			pw.TrackFile(path, info.ModTime())
+			g.record(journal.KindModified, projectPath, path, "found by rescan")
+			missed++
+		}
+		for _, path := range pw.trackedFiles() {
+			if found[path] {
+				continue
+			}
+			if _, err := os.Stat(path); os.IsNotExist(err) {
+				log.Printf("[%s] Rescan: tracked file is gone, its removal was missed: %s", projectPath, path)
+				pw.RemoveFile(path)
+				g.record(journal.KindGone, projectPath, path, "found by rescan")
+				missed++
+			}
+		}
+	}
+	if missed > 0 {
+		log.Printf("Rescan found %d change(s) the watcher missed", missed)
+	}
+	return missed
+}
+
+// trackedModTime returns the modification recorded for the tracked file
+// naming the same file as path, if any.
+func (pw *ProjectWatcher) trackedModTime(path string) (time.Time, bool) {
+	pw.mu.RLock()
+	defer pw.mu.RUnlock()
+	tracked, ok := pw.trackedAs(path)
+	if !ok {
+		return time.Time{}, false
+	}
+	return pw.lastMod[tracked], true
+}
+
+// trackedFiles lists the tracked files.
+func (pw *ProjectWatcher) trackedFiles() []string {
+	pw.mu.RLock()
+	defer pw.mu.RUnlock()
+	paths := make([]string, 0, len(pw.lastMod))
+	for path := range pw.lastMod {
+		paths = append(paths, path)
+	}
+	return paths
+}
@@ -0,0 +1,59 @@
+package guardian
+
+import (
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestRescan covers the discrepancies a rescan repairs: an untracked
+// plaintext file and a missed write are tracked, a vanished tracked file is
+// dropped, and encrypted or already-tracked files are left alone.
+func TestRescan(t *testing.T) {
+	prevOut := log.Writer()
+	log.SetOutput(io.Discard)
+	t.Cleanup(func() { log.SetOutput(prevOut) })
+
+	f := newIdleCheckFixture(t, "ok")
+	write := func(name, content string) string {
+		path := filepath.Join(f.projectDir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	missed := write(".env", "SECRET=plaintext\n")
+	write(".env.production", "SECRET=\"encrypted:BDx\"\n")
+	current := write(".env.local", "SECRET=plaintext\n")
+	info, err := os.Stat(current)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.pw.TrackFile(current, info.ModTime())
+	stale := write(".env.test", "SECRET=plaintext\n")
+	f.pw.TrackFile(stale, time.Now().Add(-time.Hour))
+	gone := filepath.Join(f.projectDir, ".env.gone")
+	f.pw.TrackFile(gone, time.Now().Add(-time.Hour))
+
+	if n := f.g.rescan(); n != 3 {
+		t.Errorf("rescan found %d discrepancies; want 3 (missed file, missed write, missed removal)", n)
+	}
+	if !f.tracked(missed) {
+		t.Error("an untracked plaintext file must be tracked after a rescan")
+	}
+	if modTime, _ := f.pw.trackedModTime(stale); time.Since(modTime) > time.Minute {
+		t.Error("a missed write must restart the file's idle clock")
+	}
+	if f.tracked(gone) {
+		t.Error("a vanished tracked file must be dropped")
+	}
+	if f.tracked(filepath.Join(f.projectDir, ".env.production")) {
+		t.Error("an encrypted file must not be tracked")
+	}
+	if n := f.g.rescan(); n != 0 {
+		t.Errorf("second rescan found %d discrepancies; want 0", n)
+	}
+}
@@ -0,0 +1,41 @@
+package guardian
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/jainal09/envdrift-agent/internal/retention"
+)
+
+// retentionInterval is how often the agent applies [history] keep and
+// [logs] keep.
+const retentionInterval = time.Hour
+
+// retentionLoop prunes the history log, the journal and the rotated agent
+// logs right away and then every retentionInterval until ctx is cancelled.
+func (g *Guardian) retentionLoop(ctx context.Context) {
+	ticker := time.NewTicker(retentionInterval)
+	defer ticker.Stop()
+	for {
+		g.applyRetention()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// applyRetention drops the records past their retention. A failure is
+// logged: the next pass tries again.
+func (g *Guardian) applyRetention() {
+	r, err := retention.Prune(retention.Policy(g.globalConfig, g.now()))
+	if err != nil {
+		log.Printf("Applying retention: %v", err)
+	}
+	if r.Total() > 0 {
+		log.Printf("Retention: dropped %d history entries, %d journal events and %d rotated log files",
+			r.History, r.Journal, r.LogFiles)
+	}
+}
@@ -0,0 +1,49 @@
+package guardian
+
+import (
+	"log"
+	"os"
+	"time"
+
+	"github.com/jainal09/envdrift-agent/internal/gitstate"
+	"github.com/jainal09/envdrift-agent/internal/lockcheck"
+	"github.com/jainal09/envdrift-agent/internal/rule"
+)
+
+// ruleAllows evaluates [guardian] encrypt_when for path. When it is false,
+// why says so for the journal. A rule that fails to evaluate (or a file that
+// cannot be read) allows the encryption: a broken rule must not leave files
+// in plaintext.
+func (g *Guardian) ruleAllows(projectPath, path string) (ok bool, why string) {
+	if g.rule == nil {
+		return true, ""
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return true, ""
+	}
+	now := time.Now()
+	f := rule.Facts{
+		Path:    path,
+		Age:     now.Sub(info.ModTime()),
+		Size:    info.Size(),
+		Branch:  gitstate.Branch(path),
+		Repo:    gitstate.Root(path),
+		Project: projectPath,
+		Now:     now,
+	}
+	f.Host, _ = os.Hostname()
+	// The lock probe is the costly fact; only a rule that reads it pays.
+	if g.rule.Uses("file.locked") {
+		f.Locked = lockcheck.IsFileOpen(path)
+	}
+	allowed, err := g.rule.Eval(f)
+	if err != nil {
+		log.Printf("[%s] encrypt_when failed for %s, encrypting anyway: %v", projectPath, path, err)
+		return true, ""
+	}
+	if !allowed {
+		return false, "encrypt_when is false: " + g.rule.String()
+	}
+	return true, ""
+}
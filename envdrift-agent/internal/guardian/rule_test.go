@@ -0,0 +1,63 @@
+package guardian
+
+import (
+	"context"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jainal09/envdrift-agent/internal/rule"
+)
+
+// TestCheckIdleFiles_EncryptWhen covers [guardian] encrypt_when: a file the
+// rule rejects stays tracked in plaintext, and one it accepts is encrypted.
+func TestCheckIdleFiles_EncryptWhen(t *testing.T) {
+	prevOut := log.Writer()
+	log.SetOutput(io.Discard)
+	t.Cleanup(func() { log.SetOutput(prevOut) })
+
+	f := newIdleCheckFixture(t, "ok")
+	var err error
+	if f.g.rule, err = rule.Compile(`file.name.endsWith(".local") || repo.branch == "main"`); err != nil {
+		t.Fatal(err)
+	}
+	path := f.trackIdle(t, ".env", "SECRET=plaintext\n")
+
+	f.g.checkIdleFiles(context.Background())
+	if _, err := os.Stat(f.marker); err == nil {
+		t.Fatal("a file encrypt_when rejects must not be encrypted")
+	}
+	if !f.tracked(path) {
+		t.Fatal("a rejected file must stay tracked for a later check")
+	}
+
+	if f.g.rule, err = rule.Compile(`file.size > 0 && project.path != ""`); err != nil {
+		t.Fatal(err)
+	}
+	f.g.checkIdleFiles(context.Background())
+	if _, err := os.Stat(f.marker); err != nil {
+		t.Fatalf("envdrift encrypt was not invoked once encrypt_when held: %v", err)
+	}
+}
+
+// TestRuleAllows_EvalError pins that a rule failing at run time encrypts.
+func TestRuleAllows_EvalError(t *testing.T) {
+	prevOut := log.Writer()
+	log.SetOutput(io.Discard)
+	t.Cleanup(func() { log.SetOutput(prevOut) })
+
+	r, err := rule.Compile(`file.size / 0 == 1`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := &Guardian{rule: r}
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(path, []byte("A=1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if ok, why := g.ruleAllows("/p", path); !ok {
+		t.Errorf("a failing rule held the file back: %s", why)
+	}
+}
@@ -0,0 +1,239 @@
+package guardian
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jainal09/envdrift-agent/internal/encrypt"
+	"github.com/jainal09/envdrift-agent/internal/exports"
+	"github.com/jainal09/envdrift-agent/internal/gitstate"
+	"github.com/jainal09/envdrift-agent/internal/hooks"
+	"github.com/jainal09/envdrift-agent/internal/lockcheck"
+	"github.com/jainal09/envdrift-agent/internal/onboarding"
+	"github.com/jainal09/envdrift-agent/internal/project"
+	"github.com/jainal09/envdrift-agent/internal/registry"
+	"github.com/jainal09/envdrift-agent/internal/telemetry"
+)
+
+// Progress reports one file of a RunOnce sweep.
+type Progress struct {
+	// Done counts the files handled so far, out of Total.
+	Done, Total int
+	// Path is the file just handled.
+	Path string
+}
+
+// RunOnceResult counts what a RunOnce sweep did with each file.
+type RunOnceResult struct {
+	Encrypted int
+	// Already were encrypted before the sweep.
+	Already int
+	// Skipped are held by an edit/export session, open in another process,
+	// in a repository git is writing, in a project still in its onboarding
+	// grace period, or vetoed by the pre_encrypt hook, and the running agent
+	// handles them later; or under a root in observe mode.
+	Skipped int
+	// Failed holds one error per file that could not be encrypted.
+	Failed []error
+}
+
+// DefaultRunOnceWorkers bounds the files RunOnce encrypts at a time when
+// the caller does not: one per CPU, at most 8, as each is an envdrift
+// process of its own.
+func DefaultRunOnceWorkers() int {
+	return min(runtime.NumCPU(), 8)
+}
+
+// RunOnce encrypts every plaintext env file of the enabled registered
+// projects, as the running guardian would once each file went idle, and
+// returns: for cron jobs and machines without the agent installed. Files the
+// guardian would leave alone for now are skipped, not waited for. Up to
+// workers files are encrypted at a time (DefaultRunOnceWorkers when
+// workers < 1). progress, when non-nil, is called once before the first file
+// and after each, from the calling goroutine. The error is ctx's when the
+// sweep was cancelled; per-file failures are in the result, sorted by path.
+func (g *Guardian) RunOnce(ctx context.Context, workers int, progress func(Progress)) (RunOnceResult, error) {
+	var res RunOnceResult
+	if g.readOnly {
+		return res, errReadOnly
+	}
+	if !encrypt.IsEnvdriftAvailable() {
+		return res, errNoEnvdrift
+	}
+	reg, err := registry.Load()
+	if err != nil {
+		return res, err
+	}
+	files, _ := g.projectFiles(reg)
+	held := g.observing(time.Now())
+	return g.sweep(ctx, files, workers, progress, func(ctx context.Context, f projectFile) (swept, error) {
+		if _, ok := g.observes(f.path); ok {
+			return sweptSkipped, nil
+		}
+		return g.encryptOnce(ctx, f, held)
+	})
+}
+
+// sweep runs encryptFile on files, workers at a time, for RunOnce and
+// EncryptAll, and counts the outcomes.
+func (g *Guardian) sweep(ctx context.Context, files []projectFile, workers int, progress func(Progress),
+	encryptFile func(context.Context, projectFile) (swept, error)) (RunOnceResult, error) {
+	var res RunOnceResult
+	g.loadPlugins(ctx)
+	if progress != nil {
+		progress(Progress{Total: len(files)})
+	}
+	if workers < 1 {
+		workers = DefaultRunOnceWorkers()
+	}
+
+	type outcome struct {
+		path string
+		out  swept
+		err  error
+	}
+	paths := make(chan projectFile)
+	outcomes := make(chan outcome)
+	var wg sync.WaitGroup
+	for range min(workers, len(files)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for f := range paths {
+				out, err := encryptFile(ctx, f)
+				outcomes <- outcome{f.path, out, err}
+			}
+		}()
+	}
+	go func() {
+		defer close(paths)
+		for _, f := range files {
+			select {
+			case paths <- f:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(outcomes)
+	}()
+
+	done := 0
+	for o := range outcomes {
+		switch {
+		case o.err != nil:
+			res.Failed = append(res.Failed, o.err)
+		case o.out == sweptEncrypted:
+			res.Encrypted++
+		case o.out == sweptAlready:
+			res.Already++
+		default:
+			res.Skipped++
+		}
+		done++
+		if progress != nil {
+			progress(Progress{Done: done, Total: len(files), Path: o.path})
+		}
+	}
+	sort.Slice(res.Failed, func(i, j int) bool { return res.Failed[i].Error() < res.Failed[j].Error() })
+	return res, ctx.Err()
+}
+
+// projectFile is an env file and the project it was found in.
+type projectFile struct {
+	project, path string
+}
+
+// projectFiles lists, sorted by path, the env files of the enabled projects
+// in reg, in the active profile, that the project's patterns and excludes
+// select, and counts the enabled projects. A file reached through several
+// projects, symlinks or hard links is listed once, under its first name.
+func (g *Guardian) projectFiles(reg *registry.Registry) ([]projectFile, int) {
+	enabled, _ := g.loadEnabledConfigs(g.profilePaths(reg.GetProjectPaths()))
+	return g.filesOf(enabled)
+}
+
+// filesOf is projectFiles for the projects in enabled, keyed by root.
+func (g *Guardian) filesOf(enabled map[string]*project.GuardianConfig) ([]projectFile, int) {
+	var files []projectFile
+	for project, cfg := range enabled {
+		pw, err := NewProjectWatcher(project, cfg)
+		if err != nil {
+			continue
+		}
+		for _, path := range pw.watcher.Scan(project) {
+			files = append(files, projectFile{project, path})
+		}
+		pw.Stop()
+	}
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].path != files[j].path {
+			return files[i].path < files[j].path
+		}
+		return files[i].project < files[j].project
+	})
+	var seen fileSet
+	unique := files[:0]
+	for _, f := range files {
+		if seen.add(f.path) {
+			unique = append(unique, f)
+		}
+	}
+	return unique, len(enabled)
+}
+
+// swept is what encryptOnce did with a file.
+type swept int
+
+const (
+	sweptEncrypted swept = iota
+	sweptAlready
+	sweptSkipped
+)
+
+// encryptOnce encrypts f unless it is already encrypted or the guardian
+// would leave it alone for now (gone, held by a session, in a project held
+// for onboarding, mid-git, open, vetoed by the pre_encrypt hook).
+func (g *Guardian) encryptOnce(ctx context.Context, f projectFile, held map[string]onboarding.Root) (swept, error) {
+	path := f.path
+	if _, err := os.Stat(path); err != nil {
+		return sweptSkipped, nil
+	}
+	if _, held := exports.Pending(path, time.Now()); held {
+		return sweptSkipped, nil
+	}
+	encrypted, err := encrypt.IsEncrypted(path)
+	if err != nil {
+		return sweptSkipped, fmt.Errorf("%s: %w", path, err)
+	}
+	if encrypted {
+		return sweptAlready, nil
+	}
+	if _, ok := observedRoot(held, path); ok {
+		return sweptSkipped, nil
+	}
+	if gitstate.InProgress(path) != "" || lockcheck.IsFileOpen(path) {
+		return sweptSkipped, nil
+	}
+	if err := g.runHook(ctx, hooks.PreEncrypt, f.project, path, nil); err != nil {
+		return sweptSkipped, nil
+	}
+
+	g.backupFile("run-once", path)
+	encCtx, cancel := context.WithTimeout(ctx, g.encryptTimeout)
+	defer cancel()
+	if err := g.encryptFile(encCtx, path); err != nil {
+		_ = g.runHook(ctx, hooks.OnFailure, f.project, path, err)
+		return sweptSkipped, fmt.Errorf("%s: %w", path, err)
+	}
+	g.countTelemetry(telemetry.Encryptions)
+	_ = g.runHook(ctx, hooks.PostEncrypt, f.project, path, nil)
+	return sweptEncrypted, nil
+}
@@ -0,0 +1,155 @@
+package guardian
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jainal09/envdrift-agent/internal/config"
+)
+
+// TestRunOnce sweeps a registered project once: the plaintext file is
+// encrypted, the encrypted one counted, and progress reported per file.
+func TestRunOnce(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	installFakeBins(t, filepath.Join(t.TempDir(), "encrypt-started"))
+	t.Setenv("ENVDRIFT_AGENT_FAKE_ENVDRIFT", "ok")
+
+	proj := makeProject(t)
+	writeRegistry(t, home, proj)
+	for name, content := range map[string]string{
+		".env":            "SECRET=plaintext\n",
+		".env.production": "SECRET=\"encrypted:abc123\"\n",
+	} {
+		if err := os.WriteFile(filepath.Join(proj, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	g, err := New(config.DefaultConfig())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	var reports []Progress
+	res, err := g.RunOnce(context.Background(), 1, func(p Progress) { reports = append(reports, p) })
+	if err != nil {
+		t.Fatalf("RunOnce: %v", err)
+	}
+	if res.Encrypted != 1 || res.Already != 1 || res.Skipped != 0 || len(res.Failed) != 0 {
+		t.Fatalf("result = %+v, want 1 encrypted and 1 already encrypted", res)
+	}
+	if len(reports) != 3 || reports[0] != (Progress{Total: 2}) || reports[2].Done != 2 {
+		t.Fatalf("progress = %+v, want a start report then one per file", reports)
+	}
+}
+
+// TestRunOnce_Failure collects a failing encryption in the result instead of
+// stopping the sweep.
+func TestRunOnce_Failure(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	installFakeBins(t, filepath.Join(t.TempDir(), "encrypt-started"))
+	t.Setenv("ENVDRIFT_AGENT_FAKE_ENVDRIFT", "fail")
+
+	proj := makeProject(t)
+	writeRegistry(t, home, proj)
+	for _, name := range []string{".env", ".env.local"} {
+		if err := os.WriteFile(filepath.Join(proj, name), []byte("SECRET=plaintext\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	g, err := New(config.DefaultConfig())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	res, err := g.RunOnce(context.Background(), 0, nil)
+	if err != nil {
+		t.Fatalf("RunOnce: %v", err)
+	}
+	if len(res.Failed) != 2 || res.Encrypted != 0 {
+		t.Fatalf("result = %+v, want both files failed", res)
+	}
+}
+
+// BenchmarkRunOnce sweeps 50 plaintext files with a serial and a parallel
+// pool; the fake envdrift takes 20ms per file, like a real subprocess would.
+func BenchmarkRunOnce(b *testing.B) {
+	for _, workers := range []int{1, 8} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			home := b.TempDir()
+			b.Setenv("HOME", home)
+			b.Setenv("USERPROFILE", home)
+			installFakeBins(b, filepath.Join(b.TempDir(), "encrypt-started"))
+			b.Setenv("ENVDRIFT_AGENT_FAKE_ENVDRIFT", "slow")
+
+			proj := makeProject(b)
+			writeRegistry(b, home, proj)
+			for i := range 50 {
+				name := filepath.Join(proj, fmt.Sprintf(".env.svc%02d", i))
+				if err := os.WriteFile(name, []byte("SECRET=plaintext\n"), 0o644); err != nil {
+					b.Fatal(err)
+				}
+			}
+			g, err := New(config.DefaultConfig())
+			if err != nil {
+				b.Fatalf("New: %v", err)
+			}
+
+			b.ResetTimer()
+			for range b.N {
+				// The fake encrypts nothing, so every pass sees 50 files.
+				res, err := g.RunOnce(context.Background(), workers, nil)
+				if err != nil || res.Encrypted != 50 {
+					b.Fatalf("RunOnce = %+v, %v; want 50 encrypted", res, err)
+				}
+			}
+		})
+	}
+}
+
+// TestRunOnce_OverlappingProjects lists a file reached through a nested
+// project and a symlink once.
+func TestRunOnce_OverlappingProjects(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	installFakeBins(t, filepath.Join(t.TempDir(), "encrypt-started"))
+	t.Setenv("ENVDRIFT_AGENT_FAKE_ENVDRIFT", "ok")
+
+	outer := makeProject(t)
+	inner := filepath.Join(outer, "api")
+	if err := os.Mkdir(inner, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	toml, err := os.ReadFile(filepath.Join(outer, "envdrift.toml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(inner, "envdrift.toml"), toml, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(inner, ".env")
+	if err := os.WriteFile(path, []byte("SECRET=plaintext\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_ = os.Symlink(path, filepath.Join(outer, ".env.api"))
+	writeRegistry(t, home, outer, inner)
+
+	g, err := New(config.DefaultConfig())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	res, err := g.RunOnce(context.Background(), 0, nil)
+	if err != nil {
+		t.Fatalf("RunOnce: %v", err)
+	}
+	if res.Encrypted != 1 {
+		t.Fatalf("result = %+v, want the shared file encrypted once", res)
+	}
+}
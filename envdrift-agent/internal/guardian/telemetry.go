@@ -0,0 +1,60 @@
+package guardian
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/jainal09/envdrift-agent/internal/telemetry"
+)
+
+// telemetryRetry spaces delivery attempts so an unreachable endpoint is not
+// hit on every idle check.
+const telemetryRetry = time.Hour
+
+// countTelemetry adds one to counter name when [telemetry] is enabled.
+func (g *Guardian) countTelemetry(name string) {
+	if g.telemetry == nil {
+		return
+	}
+	if err := g.telemetry.Count(name, time.Now()); err != nil {
+		log.Printf("Recording telemetry: %v", err)
+	}
+}
+
+// sendTelemetry delivers the counts of completed days to the configured
+// endpoint, at most once per telemetryRetry and not on a low battery. A
+// report due while offline is queued and retried on the next idle check.
+// Without an endpoint the counts stay local. Only the idle-check worker calls
+// it.
+func (g *Guardian) sendTelemetry(ctx context.Context) {
+	endpoint := g.globalConfig.Telemetry.Endpoint
+	if g.telemetry == nil || endpoint == "" {
+		return
+	}
+	now := time.Now()
+	if now.Sub(g.telemetrySent) < telemetryRetry || g.deferForPower("telemetry", &g.telemetryDeferred) {
+		return
+	}
+	g.telemetrySent = now
+
+	report, err := g.telemetry.Unsent(g.Version, now)
+	if err != nil {
+		log.Printf("Reading telemetry: %v", err)
+		return
+	}
+	if len(report.Days) == 0 {
+		return
+	}
+	if g.queueIfOffline("telemetry report") {
+		g.telemetrySent = time.Time{}
+		return
+	}
+	if err := telemetry.Send(ctx, endpoint, report); err != nil {
+		log.Printf("Sending telemetry: %v", err)
+		return
+	}
+	if err := g.telemetry.MarkSent(report); err != nil {
+		log.Printf("Recording telemetry delivery: %v", err)
+	}
+}
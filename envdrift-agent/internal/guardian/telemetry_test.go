@@ -0,0 +1,47 @@
+package guardian
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jainal09/envdrift-agent/internal/telemetry"
+)
+
+// TestTelemetry_CountsEncryptionsAndSendsCompletedDays checks that with
+// [telemetry] enabled an encryption is counted locally, that today's counts
+// are not sent yet, and that nothing is sent without an endpoint.
+func TestTelemetry_CountsEncryptionsAndSendsCompletedDays(t *testing.T) {
+	f := newIdleCheckFixture(t, "ok")
+	f.g.globalConfig.Telemetry.Enabled = true
+	f.g.telemetry = &telemetry.Store{Path: filepath.Join(t.TempDir(), "telemetry.json")}
+
+	f.trackIdle(t, ".env", "SECRET=1\n")
+	f.g.checkIdleFiles(context.Background())
+
+	report, err := f.g.telemetry.Unsent("dev", time.Now().AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	today := time.Now().Format("2006-01-02")
+	if report.Days[today][telemetry.Encryptions] != 1 {
+		t.Fatalf("counted %v; want one encryption today", report.Days)
+	}
+
+	var posts atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		posts.Add(1)
+	}))
+	defer srv.Close()
+
+	f.g.sendTelemetry(context.Background())
+	f.g.globalConfig.Telemetry.Endpoint = srv.URL
+	f.g.sendTelemetry(context.Background())
+	if n := posts.Load(); n != 0 {
+		t.Errorf("sent %d report(s); want none without an endpoint or a completed day", n)
+	}
+}
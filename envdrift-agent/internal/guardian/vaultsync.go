@@ -0,0 +1,97 @@
+package guardian
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/jainal09/envdrift-agent/internal/i18n"
+	"github.com/jainal09/envdrift-agent/internal/registry"
+	"github.com/jainal09/envdrift-agent/internal/vaultsync"
+)
+
+// vaultSyncLoop runs a vault sync pass over every registered project right
+// away and then every [vault_sync] interval until ctx is cancelled. On a low
+// battery ([power] defer_below) passes wait until the machine is charging or
+// charged above the threshold; offline, each project's pass is queued and
+// retried every offlineRecheck until the network returns.
+func (g *Guardian) vaultSyncLoop(ctx context.Context, currentRegistry func() *registry.Registry) {
+	interval := g.globalConfig.VaultSync.Interval
+	log.Printf("Vault sync enabled (every %v, into %s)", interval, g.vaultSyncer.Target)
+
+	// notified remembers reported conflicts so an unresolved one notifies
+	// once, not on every pass; it is cleared when the key syncs again.
+	notified := make(map[string]bool)
+	deferred := false
+	for {
+		wait := interval
+		if g.deferForPower("vault sync", &deferred) {
+			// Retry as soon as the power state may have changed.
+			wait = powerRecheck
+		} else if g.queueIfOffline(g.vaultSyncOps(currentRegistry())...) {
+			wait = offlineRecheck
+		} else {
+			g.syncVaultKeys(ctx, currentRegistry(), notified)
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// vaultSyncOps names a sync pass of each project in reg for the offline
+// queue.
+func (g *Guardian) vaultSyncOps(reg *registry.Registry) []string {
+	if reg == nil {
+		return nil
+	}
+	var ops []string
+	for _, path := range g.profilePaths(reg.GetProjectPaths()) {
+		ops = append(ops, "vault sync "+path)
+	}
+	return ops
+}
+
+// syncVaultKeys runs one sync pass, logging every change and notifying on
+// new conflicts.
+func (g *Guardian) syncVaultKeys(ctx context.Context, reg *registry.Registry, notified map[string]bool) {
+	if reg == nil {
+		return
+	}
+	for _, path := range g.profilePaths(reg.GetProjectPaths()) {
+		if ctx.Err() != nil {
+			return
+		}
+		results, err := g.vaultSyncer.SyncProject(ctx, path)
+		if err != nil {
+			log.Printf("[%s] Vault sync failed: %v", path, err)
+		}
+		for _, r := range results {
+			id := r.Dir + "|" + r.KeyName
+			switch r.Outcome {
+			case vaultsync.Created, vaultsync.Updated:
+				log.Printf("[%s] Vault sync: %s %s from %s", path, r.Outcome, r.KeyName, r.SecretName)
+				delete(notified, id)
+			case vaultsync.Unchanged:
+				delete(notified, id)
+			case vaultsync.Missing:
+				log.Printf("[%s] Vault sync: secret %s for %s does not exist", path, r.SecretName, r.KeyName)
+			case vaultsync.Failed:
+				log.Printf("[%s] Vault sync: %s: %v", path, r.KeyName, r.Err)
+			case vaultsync.Conflict:
+				log.Printf("[%s] Vault sync: %s in %s differs from vault secret %s (%s) and was changed locally; left untouched",
+					path, r.KeyName, r.Dir, r.SecretName, r.Remote)
+				if !notified[id] {
+					notified[id] = true
+					if g.notifyWarning != nil && g.globalConfig.Guardian.Notify {
+						_ = g.notifyWarning(i18n.T("guardian.vault_conflict", r.KeyName, r.Dir))
+					}
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package guardian
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jainal09/envdrift-agent/internal/config"
+	"github.com/jainal09/envdrift-agent/internal/registry"
+	"github.com/jainal09/envdrift-agent/internal/vault"
+	"github.com/jainal09/envdrift-agent/internal/vaultsync"
+)
+
+type staticVault map[string]string
+
+func (staticVault) Name() string { return "aws" }
+
+func (v staticVault) GetSecret(_ context.Context, name string) (string, error) {
+	s, ok := v[name]
+	if !ok {
+		return "", vault.ErrNotFound
+	}
+	return s, nil
+}
+
+func (v staticVault) SetSecret(_ context.Context, name, value string) error {
+	v[name] = value
+	return nil
+}
+
+func TestSyncVaultKeysNotifiesConflictOnce(t *testing.T) {
+	dir := t.TempDir()
+	toml := "[vault.aws]\nregion = \"us-east-1\"\n\n[[vault.sync.mappings]]\nsecret_name = \"myapp/prod\"\nfolder_path = \".\"\n"
+	if err := os.WriteFile(filepath.Join(dir, "envdrift.toml"), []byte(toml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// A local key with no sync history that differs from the vault.
+	if err := os.WriteFile(filepath.Join(dir, ".env.keys"), []byte("DOTENV_PRIVATE_KEY_PRODUCTION=local\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	store := staticVault{"myapp/prod": "remote"}
+
+	cfg := config.DefaultConfig()
+	cfg.Guardian.Notify = true
+	var warnings []string
+	g := &Guardian{
+		globalConfig: cfg,
+		vaultSyncer: &vaultsync.Syncer{
+			Target:      "dotenv_keys",
+			StatePath:   filepath.Join(t.TempDir(), "vault-sync.json"),
+			NewProvider: func(vault.Config) (vault.Provider, error) { return store, nil },
+		},
+		notifyWarning: func(msg string) error {
+			warnings = append(warnings, msg)
+			return nil
+		},
+	}
+	reg := &registry.Registry{Projects: []registry.ProjectEntry{{Path: dir}}}
+	notified := map[string]bool{}
+
+	g.syncVaultKeys(context.Background(), reg, notified)
+	g.syncVaultKeys(context.Background(), reg, notified)
+	if len(warnings) != 1 {
+		t.Fatalf("conflict notified %d times, want 1: %q", len(warnings), warnings)
+	}
+
+	// Once the conflict is resolved (local now matches), a later conflict
+	// notifies again.
+	if err := os.WriteFile(filepath.Join(dir, ".env.keys"), []byte("DOTENV_PRIVATE_KEY_PRODUCTION=remote\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	g.syncVaultKeys(context.Background(), reg, notified)
+	store["myapp/prod"] = "rotated"
+	if err := os.WriteFile(filepath.Join(dir, ".env.keys"), []byte("DOTENV_PRIVATE_KEY_PRODUCTION=edited\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	g.syncVaultKeys(context.Background(), reg, notified)
+	if len(warnings) != 2 {
+		t.Fatalf("got %d warnings after a new conflict, want 2", len(warnings))
+	}
+}
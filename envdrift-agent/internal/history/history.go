@@ -0,0 +1,230 @@
+// Package history keeps an append-only audit log of plaintext access and
+// other security-relevant agent actions in history.jsonl in the state
+// directory.
+//
+// Entries carry metadata only (what happened, to which file, which variable
+// name) — never a secret value. Record enforces it: anything in a detail
+// shaped like a value or a private key is stored as a hash of it instead.
+// Entries older than [history] keep are dropped by Prune.
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/jainal09/envdrift-agent/internal/clock"
+	"github.com/jainal09/envdrift-agent/internal/fsys"
+	"github.com/jainal09/envdrift-agent/internal/longpath"
+	"github.com/jainal09/envdrift-agent/internal/paths"
+)
+
+// Actions recorded in the history log.
+const (
+	ActionReveal = "reveal"
+	ActionExec   = "exec"
+	ActionExport = "export"
+	ActionEdit   = "edit"
+	// ActionRestore is a pre-encryption backup written back over its file.
+	ActionRestore = "restore"
+	// ActionExportExpired is the guardian deleting or re-encrypting an
+	// export whose self-destruct timer ran out.
+	ActionExportExpired = "export-expired"
+	// ActionRecipientAdd and ActionRecipientRemove change who a file is
+	// encrypted for; Detail is the recipient.
+	ActionRecipientAdd    = "recipient-add"
+	ActionRecipientRemove = "recipient-remove"
+	// ActionKeyShare is private keys encrypted for teammates and
+	// ActionKeyAccept such a share written to .env.keys; Detail names the
+	// keys and the recipients or sender.
+	ActionKeyShare  = "key-share"
+	ActionKeyAccept = "key-accept"
+	// ActionApprove ends a new project's onboarding grace period early.
+	ActionApprove = "approve"
+	// ActionDecryptAll is a file decrypted in place by decrypt-all; Detail
+	// is how long it stays decrypted.
+	ActionDecryptAll = "decrypt-all"
+	// ActionDirenv is a file decrypted into a shell's environment by the
+	// direnv extension on entering its directory.
+	ActionDirenv = "direnv"
+	// ActionPurge is entries purged on demand; Detail is the cutoff.
+	ActionPurge = "purge"
+	// ActionIdentityRotate replaces the agent identity; Detail is what was
+	// replaced and the previous agent ID.
+	ActionIdentityRotate = "identity-rotate"
+)
+
+// Entry is one history record, serialized as a single JSON line.
+type Entry struct {
+	Time   time.Time `json:"time"`
+	Action string    `json:"action"`
+	Path   string    `json:"path"`
+	// Detail is free-form, non-secret context (e.g. the variable name a
+	// reveal was scoped to). Record hashes any value in it; see Scrub.
+	Detail string `json:"detail,omitempty"`
+}
+
+// mu serializes appends from goroutines of this process; each entry is a
+// single O_APPEND write, so concurrent agent processes don't interleave lines.
+var mu sync.Mutex
+
+// Seams for tests: the filesystem holding the log and the clock stamping
+// entries.
+var (
+	logFS    fsys.FS     = fsys.OS{}
+	logClock clock.Clock = clock.Real{}
+)
+
+// Path returns the history log location: history.jsonl in the state
+// directory.
+func Path() string {
+	return filepath.Join(paths.StateDir(), "history.jsonl")
+}
+
+// Record appends e to the history log, stamping the current time when e.Time
+// is zero. The log is created 0600: it reveals which secrets were accessed.
+// Windows extended-length paths are logged in their plain spelling, so one
+// file has one name in the log, and the detail goes through Scrub.
+func Record(e Entry) error {
+	if e.Time.IsZero() {
+		e.Time = logClock.Now()
+	}
+	e.Path = longpath.Strip(e.Path)
+	e.Detail = Scrub(e.Detail)
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	path := Path()
+	if err := logFS.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("create history directory: %w", err)
+	}
+	f, err := logFS.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("open history log: %w", err)
+	}
+	if _, err := f.Write(line); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("write history log: %w", err)
+	}
+	return f.Close()
+}
+
+// Read returns every entry in the history log, oldest first. A missing log is
+// an empty history; a corrupt line is skipped rather than hiding the rest.
+func Read() ([]Entry, error) {
+	f, err := logFS.Open(Path())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	return entries, scanner.Err()
+}
+
+var (
+	// assignment matches NAME=value for env-style names. A colon does not
+	// count: it would take C:\ for one.
+	assignment = regexp.MustCompile(`\b([A-Z][A-Z0-9_]*)(\s*=\s*)("[^"\n]*"|'[^'\n]*'|[^\s,;)}\]]+)`)
+	// privateKey matches dotenvx (secp256k1, 64 hex digits) and age private
+	// keys. Public keys are longer or lowercase and pass.
+	privateKey = regexp.MustCompile(`\b[0-9a-fA-F]{64}\b|AGE-SECRET-KEY-1[0-9A-Z]+`)
+)
+
+// Scrub replaces what in a detail could be a secret with its hash: the value
+// of a NAME=value pair and anything shaped like a private key. Entries then
+// still tell whether two accesses involved the same value without holding
+// it. Details the agent writes (variable names, for=1h, backup=ID) pass
+// unchanged.
+func Scrub(detail string) string {
+	detail = assignment.ReplaceAllStringFunc(detail, func(m string) string {
+		sub := assignment.FindStringSubmatch(m)
+		return sub[1] + sub[2] + hash(sub[3])
+	})
+	return privateKey.ReplaceAllStringFunc(detail, hash)
+}
+
+// hash names s by the first 64 bits of its SHA-256.
+func hash(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return "sha256:" + hex.EncodeToString(sum[:8])
+}
+
+// Prune drops the entries older than before, rewriting the log in place, and
+// returns how many it dropped. Corrupt lines go too. An entry another process
+// appends while the log is rewritten can be lost.
+func Prune(before time.Time) (int, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	path := Path()
+	data, err := logFS.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	var kept bytes.Buffer
+	dropped := 0
+	for _, line := range bytes.SplitAfter(data, []byte("\n")) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(line, &e); err != nil || e.Time.Before(before) {
+			dropped++
+			continue
+		}
+		kept.Write(line)
+	}
+	if dropped == 0 {
+		return 0, nil
+	}
+
+	f, err := logFS.CreateTemp(filepath.Dir(path), ".history-*")
+	if err != nil {
+		return 0, fmt.Errorf("prune history log: %w", err)
+	}
+	_, err = f.Write(kept.Bytes())
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = logFS.Chmod(f.Name(), 0o600)
+	}
+	if err == nil {
+		err = logFS.Rename(f.Name(), path)
+	}
+	if err != nil {
+		_ = logFS.Remove(f.Name())
+		return 0, fmt.Errorf("prune history log: %w", err)
+	}
+	return dropped, nil
+}
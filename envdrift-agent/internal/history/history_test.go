@@ -0,0 +1,207 @@
+// Package history tests
+package history
+
+import (
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jainal09/envdrift-agent/internal/clock"
+	"github.com/jainal09/envdrift-agent/internal/fsys"
+)
+
+// setTempHome points the history log at a fresh temp dir for the test.
+func setTempHome(t *testing.T) {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+}
+
+func TestReadMissingLogIsEmpty(t *testing.T) {
+	setTempHome(t)
+	entries, err := Read()
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("expected empty history, got %v", entries)
+	}
+}
+
+func TestRecordAndRead(t *testing.T) {
+	setTempHome(t)
+
+	if err := Record(Entry{Action: ActionReveal, Path: "/p/.env"}); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+	stamp := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := Record(Entry{Time: stamp, Action: ActionReveal, Path: "/p/.env", Detail: "API_KEY"}); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+
+	entries, err := Read()
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	if entries[0].Time.IsZero() {
+		t.Error("Record should stamp a zero Time")
+	}
+	if !entries[1].Time.Equal(stamp) || entries[1].Detail != "API_KEY" {
+		t.Errorf("second entry = %+v", entries[1])
+	}
+
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(Path())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if perm := info.Mode().Perm(); perm != 0o600 {
+			t.Errorf("history log mode = %o, want 600", perm)
+		}
+	}
+}
+
+func TestReadSkipsCorruptLines(t *testing.T) {
+	setTempHome(t)
+	if err := Record(Entry{Action: ActionReveal, Path: "/a"}); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.OpenFile(Path(), os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.WriteString("{not json\n")
+	_ = f.Close()
+	if err := Record(Entry{Action: ActionReveal, Path: "/b"}); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := Read()
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Path != "/a" || entries[1].Path != "/b" {
+		t.Errorf("corrupt line should be skipped, got %+v", entries)
+	}
+}
+
+// TestRecordStampsFromClock records into an in-memory log with a fake clock.
+func TestRecordStampsFromClock(t *testing.T) {
+	setTempHome(t)
+	fake := clock.NewFake(time.Date(2026, 5, 6, 7, 8, 9, 0, time.UTC))
+	origFS, origClock := logFS, logClock
+	logFS, logClock = fsys.NewMem(fake), fake
+	t.Cleanup(func() { logFS, logClock = origFS, origClock })
+
+	if err := Record(Entry{Action: ActionEdit, Path: "/p/.env"}); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := Read()
+	if err != nil || len(entries) != 1 || !entries[0].Time.Equal(fake.Now()) {
+		t.Fatalf("entries = %+v, %v; want one stamped %v", entries, err, fake.Now())
+	}
+	if _, err := os.Stat(Path()); !os.IsNotExist(err) {
+		t.Errorf("the in-memory log reached the disk: %v", err)
+	}
+}
+
+func TestScrub(t *testing.T) {
+	for detail, want := range map[string]string{
+		// What the commands record passes unchanged.
+		"API_KEY":                              "API_KEY",
+		"for=1h0m0s":                           "for=1h0m0s",
+		"backup=20260102T030405 undo for=5m0s": "backup=20260102T030405 undo for=5m0s",
+		"keys=DOTENV_PRIVATE_KEY,DOTENV_PRIVATE_KEY_CI to=alice": "keys=DOTENV_PRIVATE_KEY,DOTENV_PRIVATE_KEY_CI to=alice",
+		`output=C:\Users\me\plain.env`:                           `output=C:\Users\me\plain.env`,
+		"dotenvx:02" + strings.Repeat("ab", 32):                  "dotenvx:02" + strings.Repeat("ab", 32),
+		// Values and private keys become hashes.
+		"API_KEY=hunter2":                   "API_KEY=" + hash("hunter2"),
+		`DB_URL="postgres://u:p@h/db" node`: "DB_URL=" + hash(`"postgres://u:p@h/db"`) + " node",
+		strings.Repeat("0f", 32):            hash(strings.Repeat("0f", 32)),
+		"key AGE-SECRET-KEY-1QQPQ8Z":        "key " + hash("AGE-SECRET-KEY-1QQPQ8Z"),
+	} {
+		if got := Scrub(detail); got != want {
+			t.Errorf("Scrub(%q) = %q; want %q", detail, got, want)
+		}
+	}
+	if !strings.HasPrefix(hash("x"), "sha256:") || hash("x") == hash("y") {
+		t.Errorf("hash = %q, %q", hash("x"), hash("y"))
+	}
+}
+
+// TestRecordNeverStoresValues checks the log on disk: however a secret value
+// reaches an entry's detail, only its hash is written.
+func TestRecordNeverStoresValues(t *testing.T) {
+	setTempHome(t)
+	const secret = "s3cr3t-value-9f8e7d"
+	privKey := strings.Repeat("c4", 32)
+	for _, detail := range []string{
+		"API_KEY=" + secret,
+		"API_KEY = '" + secret + "'",
+		"env=prod TOKEN=" + secret + ",OTHER=1",
+		"DOTENV_PRIVATE_KEY=" + privKey,
+		"key " + privKey,
+	} {
+		if err := Record(Entry{Action: ActionExec, Path: "/p/.env", Detail: detail}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	data, err := os.ReadFile(Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, value := range []string{secret, privKey} {
+		if strings.Contains(string(data), value) {
+			t.Errorf("history log holds %q:\n%s", value, data)
+		}
+	}
+	if !strings.Contains(string(data), hash(secret)) {
+		t.Errorf("history log lacks the hash of the value:\n%s", data)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	setTempHome(t)
+	now := time.Date(2026, 5, 6, 7, 8, 9, 0, time.UTC)
+	for _, age := range []time.Duration{100 * 24 * time.Hour, 50 * 24 * time.Hour, time.Hour} {
+		if err := Record(Entry{Time: now.Add(-age), Action: ActionReveal, Path: "/p/.env"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	f, err := os.OpenFile(Path(), os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.WriteString("{not json\n")
+	_ = f.Close()
+
+	n, err := Prune(now.Add(-90 * 24 * time.Hour))
+	if err != nil || n != 2 {
+		t.Fatalf("Prune = %d, %v; want the old entry and the corrupt line", n, err)
+	}
+	entries, err := Read()
+	if err != nil || len(entries) != 2 || !entries[0].Time.Equal(now.Add(-50*24*time.Hour)) {
+		t.Fatalf("entries after Prune = %+v, %v", entries, err)
+	}
+	if runtime.GOOS != "windows" {
+		if info, err := os.Stat(Path()); err != nil || info.Mode().Perm() != 0o600 {
+			t.Errorf("pruned log = %v, %v; want mode 600", info, err)
+		}
+	}
+	if n, err := Prune(now.Add(-90 * 24 * time.Hour)); err != nil || n != 0 {
+		t.Errorf("second Prune = %d, %v; want 0", n, err)
+	}
+	// Appends go on after a prune.
+	if err := Record(Entry{Action: ActionEdit, Path: "/p/.env"}); err != nil {
+		t.Fatal(err)
+	}
+	if entries, _ := Read(); len(entries) != 3 {
+		t.Errorf("entries after a new Record = %d; want 3", len(entries))
+	}
+}
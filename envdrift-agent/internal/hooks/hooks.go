@@ -0,0 +1,113 @@
+// Package hooks runs the user's [hooks] commands around encryptions, for
+// integrations such as restarting `docker compose` after an env file is
+// encrypted or posting to an internal tool when encryption fails.
+//
+// A hook is a shell command line, run with sh -c (cmd /C on Windows) in the
+// project directory. The event is passed in the environment:
+//
+//	ENVDRIFT_EVENT    pre_encrypt, post_encrypt or on_failure
+//	ENVDRIFT_FILE     the env file
+//	ENVDRIFT_PROJECT  the project directory
+//	ENVDRIFT_ERROR    why encryption failed (on_failure only)
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Hook events.
+const (
+	PreEncrypt  = "pre_encrypt"
+	PostEncrypt = "post_encrypt"
+	OnFailure   = "on_failure"
+)
+
+// Event is one occasion for a hook.
+type Event struct {
+	// Name is PreEncrypt, PostEncrypt or OnFailure.
+	Name    string
+	File    string
+	Project string
+	// Err is why encryption failed, for OnFailure.
+	Err error
+}
+
+// Runner runs the hook commands.
+type Runner struct {
+	// Commands maps an event name to its command line.
+	Commands map[string]string
+	// Timeout bounds each run.
+	Timeout time.Duration
+}
+
+// shellCommand is a seam for tests.
+var shellCommand = func(ctx context.Context, line string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.CommandContext(ctx, "cmd", "/C", line)
+	}
+	return exec.CommandContext(ctx, "sh", "-c", line)
+}
+
+// maxOutput bounds the hook output quoted in an error.
+const maxOutput = 512
+
+// Run runs the hook for e, if one is configured, and waits for it. The error
+// names the hook and carries the end of its output when it exits non-zero or
+// times out.
+func (r *Runner) Run(ctx context.Context, e Event) error {
+	if r == nil {
+		return nil
+	}
+	line := strings.TrimSpace(r.Commands[e.Name])
+	if line == "" {
+		return nil
+	}
+	if r.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.Timeout)
+		defer cancel()
+	}
+	cmd := shellCommand(ctx, line)
+	cmd.Dir = e.Project
+	cmd.Env = append(os.Environ(),
+		"ENVDRIFT_EVENT="+e.Name,
+		"ENVDRIFT_FILE="+e.File,
+		"ENVDRIFT_PROJECT="+e.Project,
+	)
+	if e.Err != nil {
+		cmd.Env = append(cmd.Env, "ENVDRIFT_ERROR="+e.Err.Error())
+	}
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	// Let a killed shell's children go instead of waiting on their output.
+	cmd.WaitDelay = time.Second
+
+	err := cmd.Run()
+	if err == nil {
+		return nil
+	}
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		err = fmt.Errorf("timed out after %v", r.Timeout)
+	}
+	if tail := lastBytes(strings.TrimSpace(out.String()), maxOutput); tail != "" {
+		return fmt.Errorf("%s hook: %w: %s", e.Name, err, tail)
+	}
+	return fmt.Errorf("%s hook: %w", e.Name, err)
+}
+
+// lastBytes returns the end of s, at most n bytes.
+func lastBytes(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return "..." + s[len(s)-n:]
+}
@@ -0,0 +1,82 @@
+package hooks
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRun(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook commands in this test are POSIX shell")
+	}
+	dir := t.TempDir()
+	out := filepath.Join(dir, "out")
+	r := &Runner{
+		Commands: map[string]string{
+			PostEncrypt: `printf '%s|%s|%s|%s' "$ENVDRIFT_EVENT" "$ENVDRIFT_FILE" "$ENVDRIFT_PROJECT" "$PWD" > ` + out,
+			OnFailure:   `printf '%s' "$ENVDRIFT_ERROR" > ` + out,
+			PreEncrypt:  `echo "branch is main"; exit 3`,
+		},
+		Timeout: 5 * time.Second,
+	}
+	ctx := context.Background()
+	file := filepath.Join(dir, ".env")
+
+	if err := r.Run(ctx, Event{Name: PostEncrypt, File: file, Project: dir}); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(out)
+	fields := strings.Split(string(data), "|")
+	if len(fields) != 4 || fields[0] != PostEncrypt || fields[1] != file || fields[2] != dir {
+		t.Fatalf("post_encrypt saw %q", data)
+	}
+	// The hook runs in the project directory.
+	want, _ := filepath.EvalSymlinks(dir)
+	if got, _ := filepath.EvalSymlinks(fields[3]); got != want {
+		t.Errorf("post_encrypt ran in %s; want %s", fields[3], dir)
+	}
+
+	if err := r.Run(ctx, Event{Name: OnFailure, File: file, Project: dir, Err: errors.New("no key")}); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(out); string(data) != "no key" {
+		t.Errorf("on_failure saw ENVDRIFT_ERROR=%q", data)
+	}
+
+	err := r.Run(ctx, Event{Name: PreEncrypt, File: file, Project: dir})
+	if err == nil || !strings.Contains(err.Error(), "pre_encrypt hook") || !strings.Contains(err.Error(), "branch is main") {
+		t.Errorf("failing hook error = %v; want its name and output", err)
+	}
+}
+
+func TestRunTimeout(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook commands in this test are POSIX shell")
+	}
+	r := &Runner{Commands: map[string]string{PreEncrypt: "sleep 10"}, Timeout: 200 * time.Millisecond}
+	start := time.Now()
+	err := r.Run(context.Background(), Event{Name: PreEncrypt, Project: t.TempDir()})
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("hung hook error = %v; want a timeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("hung hook took %v", elapsed)
+	}
+}
+
+func TestRunNone(t *testing.T) {
+	var nilRunner *Runner
+	if err := nilRunner.Run(context.Background(), Event{Name: PreEncrypt}); err != nil {
+		t.Error(err)
+	}
+	r := &Runner{Commands: map[string]string{PreEncrypt: "  "}}
+	if err := r.Run(context.Background(), Event{Name: PreEncrypt}); err != nil {
+		t.Error(err)
+	}
+}
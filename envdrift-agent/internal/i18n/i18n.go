@@ -0,0 +1,134 @@
+// Package i18n translates notification text and CLI messages. A catalog is
+// a flat JSON object of message IDs to fmt-style text, embedded from
+// locales/<lang>.json; en.json is the base every other catalog falls back
+// to, message by message. A community translation is one new file in
+// locales, named by its language (de.json) or language and region
+// (pt_BR.json).
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Base is the language of the complete catalog.
+const Base = "en"
+
+//go:embed locales/*.json
+var embedded embed.FS
+
+// Seams for tests: the catalog files and the environment.
+var (
+	catalogs fs.FS = embedded
+	getenv         = os.Getenv
+)
+
+var (
+	// mu guards language and loaded.
+	mu sync.Mutex
+	// language is the SetLanguage override; empty detects it.
+	language string
+	// loaded caches parsed catalogs by name; a missing one is nil.
+	loaded = map[string]map[string]string{}
+)
+
+// SetLanguage pins the language (e.g. "de", "pt_BR", "de-DE"); an empty tag
+// goes back to detecting it from the environment.
+func SetLanguage(tag string) {
+	mu.Lock()
+	defer mu.Unlock()
+	language = normalize(tag)
+}
+
+// Language returns the active language: the SetLanguage tag, otherwise the
+// first of ENVDRIFT_LANG, LC_ALL, LC_MESSAGES and LANG that names one, and
+// Base when none does.
+func Language() string {
+	mu.Lock()
+	defer mu.Unlock()
+	return activeLanguage()
+}
+
+// T returns message id in the active language, formatted with args as by
+// fmt.Sprintf. A message missing from the active catalog comes from Base; an
+// unknown id is returned as is.
+func T(id string, args ...any) string {
+	mu.Lock()
+	msg, ok := id, false
+	for _, lang := range candidates(activeLanguage()) {
+		if msg, ok = catalog(lang)[id]; ok {
+			break
+		}
+	}
+	mu.Unlock()
+	if !ok {
+		msg = id
+	}
+	if len(args) == 0 {
+		return msg
+	}
+	return fmt.Sprintf(msg, args...)
+}
+
+// activeLanguage implements Language; callers hold mu.
+func activeLanguage() string {
+	if language != "" {
+		return language
+	}
+	for _, name := range []string{"ENVDRIFT_LANG", "LC_ALL", "LC_MESSAGES", "LANG"} {
+		if tag := normalize(getenv(name)); tag != "" {
+			return tag
+		}
+	}
+	return Base
+}
+
+// candidates lists the catalogs consulted for tag, most specific first:
+// pt_BR, then pt, then Base.
+func candidates(tag string) []string {
+	list := []string{tag}
+	if lang, _, ok := strings.Cut(tag, "_"); ok {
+		list = append(list, lang)
+	}
+	if tag != Base {
+		list = append(list, Base)
+	}
+	return list
+}
+
+// normalize turns a locale such as "de_DE.UTF-8", "de-DE" or "de_DE@euro"
+// into "de_DE"; "C" and "POSIX" carry no language and become "".
+func normalize(tag string) string {
+	tag, _, _ = strings.Cut(tag, ".")
+	tag, _, _ = strings.Cut(tag, "@")
+	tag = strings.ReplaceAll(strings.TrimSpace(tag), "-", "_")
+	if tag == "C" || tag == "POSIX" {
+		return ""
+	}
+	lang, region, ok := strings.Cut(tag, "_")
+	if !ok {
+		return strings.ToLower(tag)
+	}
+	return strings.ToLower(lang) + "_" + strings.ToUpper(region)
+}
+
+// catalog returns the parsed locales/<lang>.json, nil when there is none or
+// it does not parse. Callers hold mu.
+func catalog(lang string) map[string]string {
+	if c, ok := loaded[lang]; ok {
+		return c
+	}
+	var c map[string]string
+	if data, err := fs.ReadFile(catalogs, "locales/"+lang+".json"); err == nil {
+		if json.Unmarshal(data, &c) != nil {
+			c = nil
+		}
+	}
+	loaded[lang] = c
+	return c
+}
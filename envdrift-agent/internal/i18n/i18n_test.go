@@ -0,0 +1,118 @@
+package i18n
+
+import (
+	"encoding/json"
+	"io/fs"
+	"path"
+	"regexp"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// useCatalogs swaps in test catalogs and environment for one test.
+func useCatalogs(t *testing.T, files fstest.MapFS, env map[string]string) {
+	t.Helper()
+	origFS, origEnv := catalogs, getenv
+	catalogs = files
+	getenv = func(name string) string { return env[name] }
+	mu.Lock()
+	loaded = map[string]map[string]string{}
+	mu.Unlock()
+	t.Cleanup(func() {
+		catalogs, getenv = origFS, origEnv
+		SetLanguage("")
+		mu.Lock()
+		loaded = map[string]map[string]string{}
+		mu.Unlock()
+	})
+}
+
+func TestTFallsBack(t *testing.T) {
+	useCatalogs(t, fstest.MapFS{
+		"locales/en.json":    {Data: []byte(`{"greet": "Hello %s", "bye": "Bye", "only.en": "English"}`)},
+		"locales/pt.json":    {Data: []byte(`{"greet": "Olá %s", "bye": "Tchau"}`)},
+		"locales/pt_BR.json": {Data: []byte(`{"bye": "Falou"}`)},
+	}, map[string]string{"LANG": "pt_BR.UTF-8"})
+
+	for _, tt := range []struct {
+		id   string
+		args []any
+		want string
+	}{
+		{"bye", nil, "Falou"},
+		{"greet", []any{"Ana"}, "Olá Ana"},
+		{"only.en", nil, "English"},
+		{"missing.id", nil, "missing.id"},
+	} {
+		if got := T(tt.id, tt.args...); got != tt.want {
+			t.Errorf("T(%q) = %q; want %q", tt.id, got, tt.want)
+		}
+	}
+
+	SetLanguage("en")
+	if got := T("bye"); got != "Bye" {
+		t.Errorf("pinned en: T(bye) = %q", got)
+	}
+}
+
+func TestLanguage(t *testing.T) {
+	for _, tt := range []struct {
+		env  map[string]string
+		pin  string
+		want string
+	}{
+		{nil, "", "en"},
+		{map[string]string{"LANG": "C"}, "", "en"},
+		{map[string]string{"LANG": "de_DE.UTF-8"}, "", "de_DE"},
+		{map[string]string{"LANG": "de_DE.UTF-8", "LC_ALL": "fr_FR@euro"}, "", "fr_FR"},
+		{map[string]string{"LANG": "de_DE.UTF-8", "ENVDRIFT_LANG": "ja"}, "", "ja"},
+		{map[string]string{"LANG": "de_DE.UTF-8"}, "pt-br", "pt_BR"},
+	} {
+		useCatalogs(t, fstest.MapFS{}, tt.env)
+		SetLanguage(tt.pin)
+		if got := Language(); got != tt.want {
+			t.Errorf("env %v, pin %q: Language = %q; want %q", tt.env, tt.pin, got, tt.want)
+		}
+	}
+}
+
+// verbs matches fmt verbs, so a translation keeps its base's arguments.
+var verbs = regexp.MustCompile(`%[-+# 0-9.]*[a-zA-Z%]`)
+
+// TestCatalogsMatchBase checks every shipped catalog against en.json: it
+// parses, defines no message the base lacks, and uses the same fmt verbs in
+// the same order.
+func TestCatalogsMatchBase(t *testing.T) {
+	read := func(name string) map[string]string {
+		data, err := fs.ReadFile(embedded, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var c map[string]string
+		if err := json.Unmarshal(data, &c); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		return c
+	}
+	base := read("locales/" + Base + ".json")
+	names, err := fs.Glob(embedded, "locales/*.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range names {
+		if path.Base(name) == Base+".json" {
+			continue
+		}
+		for id, msg := range read(name) {
+			want, ok := base[id]
+			if !ok {
+				t.Errorf("%s: %s is not in %s.json", name, id, Base)
+				continue
+			}
+			if got, w := verbs.FindAllString(msg, -1), verbs.FindAllString(want, -1); strings.Join(got, " ") != strings.Join(w, " ") {
+				t.Errorf("%s: %s uses %v; the base uses %v", name, id, got, w)
+			}
+		}
+	}
+}
@@ -0,0 +1,269 @@
+// Package identity gives each install of the agent an agent ID and,
+// optionally, a machine registration keypair, kept in identity.json in the
+// state directory.
+//
+// The ID names the install to a fleet server: it rides along with every
+// compliance report. With [identity] keypair = true the agent also holds an
+// ed25519 key; its public half is registered with the server once, and Sign
+// then authenticates each request the agent makes to it, so a report cannot
+// be forged for another machine. Nothing is derived from the machine itself.
+package identity
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jainal09/envdrift-agent/internal/paths"
+)
+
+// Headers Sign sets on a request.
+const (
+	HeaderAgentID   = "X-EnvDrift-Agent-ID"
+	HeaderTimestamp = "X-EnvDrift-Timestamp"
+	HeaderSignature = "X-EnvDrift-Signature"
+)
+
+// keyPrefix starts the text form of a public key.
+const keyPrefix = "ed25519:"
+
+// MaxSkew is how far a signed request's timestamp may be from the verifier's
+// clock.
+const MaxSkew = 5 * time.Minute
+
+// Identity is the identity.json document.
+type Identity struct {
+	// ID is a random UUID naming the install.
+	ID      string    `json:"agent_id"`
+	Created time.Time `json:"created"`
+	// Seed is the registration key's ed25519 seed; empty without a keypair.
+	Seed       []byte    `json:"seed,omitempty"`
+	KeyCreated time.Time `json:"key_created,omitempty"`
+}
+
+// mu serializes Ensure and Rotate within the process.
+var mu sync.Mutex
+
+// DefaultPath returns identity.json in the state directory.
+func DefaultPath() string {
+	return filepath.Join(paths.StateDir(), "identity.json")
+}
+
+// Ensure returns the identity at path, creating its ID on first use and a
+// keypair when keypair is set and it has none. A keypair that is no longer
+// wanted stays in the file, in case it is turned back on, but is left out of
+// the identity returned.
+func Ensure(path string, keypair bool) (*Identity, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	id, err := load(path)
+	if err != nil {
+		return nil, err
+	}
+	changed := false
+	if id.ID == "" {
+		if id.ID, err = newID(); err != nil {
+			return nil, err
+		}
+		id.Created = time.Now().UTC()
+		changed = true
+	}
+	if keypair && len(id.Seed) == 0 {
+		if err := id.newKey(); err != nil {
+			return nil, err
+		}
+		changed = true
+	}
+	if changed {
+		if err := save(path, id); err != nil {
+			return nil, err
+		}
+	}
+	if !keypair {
+		id.Seed, id.KeyCreated = nil, time.Time{}
+	}
+	return id, nil
+}
+
+// Rotate replaces the keypair at path with a new one when keypair is set,
+// and with newAgentID the agent ID too, and returns the result. Whatever it
+// replaces has to be registered again.
+func Rotate(path string, newAgentID, keypair bool) (*Identity, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	id, err := load(path)
+	if err != nil {
+		return nil, err
+	}
+	if newAgentID || id.ID == "" {
+		if id.ID, err = newID(); err != nil {
+			return nil, err
+		}
+		id.Created = time.Now().UTC()
+	}
+	if keypair {
+		if err := id.newKey(); err != nil {
+			return nil, err
+		}
+	}
+	if err := save(path, id); err != nil {
+		return nil, err
+	}
+	return id, nil
+}
+
+// HasKey reports whether id holds a keypair.
+func (id *Identity) HasKey() bool {
+	return len(id.Seed) == ed25519.SeedSize
+}
+
+// PublicKey returns the text form of id's public key ("ed25519:<base64>"),
+// the one a fleet server registers, or "" without a keypair.
+func (id *Identity) PublicKey() string {
+	if !id.HasKey() {
+		return ""
+	}
+	return EncodePublicKey(ed25519.NewKeyFromSeed(id.Seed).Public().(ed25519.PublicKey))
+}
+
+// Fingerprint returns "SHA256:" and the hex SHA-256 of id's public key, or
+// "" without a keypair.
+func (id *Identity) Fingerprint() string {
+	if !id.HasKey() {
+		return ""
+	}
+	sum := sha256.Sum256(ed25519.NewKeyFromSeed(id.Seed).Public().(ed25519.PublicKey))
+	return "SHA256:" + hex.EncodeToString(sum[:])
+}
+
+// Sign sets the agent ID header on req and, when id holds a keypair, a
+// timestamp and a signature covering the ID, the timestamp, req's method and
+// path, and body, which must be req's body.
+func (id *Identity) Sign(req *http.Request, body []byte, now time.Time) {
+	req.Header.Set(HeaderAgentID, id.ID)
+	if !id.HasKey() {
+		return
+	}
+	ts := strconv.FormatInt(now.Unix(), 10)
+	sig := ed25519.Sign(ed25519.NewKeyFromSeed(id.Seed), signedMessage(req, id.ID, ts, body))
+	req.Header.Set(HeaderTimestamp, ts)
+	req.Header.Set(HeaderSignature, base64.StdEncoding.EncodeToString(sig))
+}
+
+// Verify checks the signature Sign set on req against the registered public
+// key, in the text form PublicKey returns, for a server receiving it; body
+// is req's body. It returns the agent ID.
+func Verify(publicKey string, req *http.Request, body []byte, now time.Time) (string, error) {
+	pub, err := ParsePublicKey(publicKey)
+	if err != nil {
+		return "", err
+	}
+	agentID, ts := req.Header.Get(HeaderAgentID), req.Header.Get(HeaderTimestamp)
+	sig, err := base64.StdEncoding.DecodeString(req.Header.Get(HeaderSignature))
+	if err != nil || len(sig) == 0 {
+		return "", errors.New("missing or malformed signature")
+	}
+	secs, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return "", errors.New("missing or malformed timestamp")
+	}
+	if skew := now.Sub(time.Unix(secs, 0)); skew > MaxSkew || skew < -MaxSkew {
+		return "", fmt.Errorf("timestamp is %v off", skew.Round(time.Second))
+	}
+	if !ed25519.Verify(pub, signedMessage(req, agentID, ts, body), sig) {
+		return "", errors.New("signature does not match")
+	}
+	return agentID, nil
+}
+
+// signedMessage is what a signature covers: the agent ID, the timestamp,
+// the method, the URL path and the body's SHA-256, one per line.
+func signedMessage(req *http.Request, agentID, ts string, body []byte) []byte {
+	sum := sha256.Sum256(body)
+	return []byte(strings.Join([]string{agentID, ts, req.Method, req.URL.EscapedPath(), hex.EncodeToString(sum[:])}, "\n"))
+}
+
+// EncodePublicKey returns the text form of pub.
+func EncodePublicKey(pub ed25519.PublicKey) string {
+	return keyPrefix + base64.StdEncoding.EncodeToString(pub)
+}
+
+// ParsePublicKey parses the text form of a public key.
+func ParsePublicKey(s string) (ed25519.PublicKey, error) {
+	raw, ok := strings.CutPrefix(strings.TrimSpace(s), keyPrefix)
+	if !ok {
+		return nil, fmt.Errorf("public key %q does not start with %s", s, keyPrefix)
+	}
+	pub, err := base64.StdEncoding.DecodeString(raw)
+	if err != nil || len(pub) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("public key %q is not an ed25519 key", s)
+	}
+	return pub, nil
+}
+
+// newKey gives id a fresh keypair.
+func (id *Identity) newKey() error {
+	seed := make([]byte, ed25519.SeedSize)
+	if _, err := rand.Read(seed); err != nil {
+		return err
+	}
+	id.Seed, id.KeyCreated = seed, time.Now().UTC()
+	return nil
+}
+
+// newID returns a random (version 4) UUID.
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	h := hex.EncodeToString(b)
+	return h[:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:], nil
+}
+
+// load reads the identity at path; a missing file is an empty one.
+func load(path string) (*Identity, error) {
+	id := &Identity{}
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, id); err != nil {
+			return nil, fmt.Errorf("parse %s: %w", path, err)
+		}
+	case !os.IsNotExist(err):
+		return nil, err
+	}
+	return id, nil
+}
+
+// save writes id through a temp file, 0600: it holds the private key.
+func save(path string, id *Identity) error {
+	data, err := json.MarshalIndent(id, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
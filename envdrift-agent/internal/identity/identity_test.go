@@ -0,0 +1,121 @@
+package identity
+
+import (
+	"bytes"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEnsure(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "identity.json")
+	id, err := Ensure(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`).MatchString(id.ID) {
+		t.Errorf("ID = %q; want a version 4 UUID", id.ID)
+	}
+	if id.HasKey() || id.PublicKey() != "" || id.Fingerprint() != "" {
+		t.Errorf("identity without a keypair = %+v", id)
+	}
+	if runtime.GOOS != "windows" {
+		if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o600 {
+			t.Errorf("identity file = %v, %v; want mode 600", info, err)
+		}
+	}
+
+	// The keypair is added to the same ID, and kept while not wanted.
+	withKey, err := Ensure(path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if withKey.ID != id.ID || !withKey.HasKey() || !strings.HasPrefix(withKey.PublicKey(), "ed25519:") {
+		t.Fatalf("identity with a keypair = %+v", withKey)
+	}
+	if off, err := Ensure(path, false); err != nil || off.HasKey() || off.ID != id.ID {
+		t.Errorf("Ensure(keypair off) = %+v, %v; want the ID without the key", off, err)
+	}
+	if again, err := Ensure(path, true); err != nil || again.PublicKey() != withKey.PublicKey() {
+		t.Errorf("keypair turned back on = %+v, %v; want the same key", again, err)
+	}
+}
+
+func TestRotate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "identity.json")
+	id, err := Ensure(path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyOnly, err := Rotate(path, false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keyOnly.ID != id.ID || keyOnly.PublicKey() == id.PublicKey() {
+		t.Errorf("key rotation = %s %s; want the same ID, a new key", keyOnly.ID, keyOnly.PublicKey())
+	}
+	both, err := Rotate(path, true, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if both.ID == id.ID || both.PublicKey() == keyOnly.PublicKey() {
+		t.Errorf("full rotation kept %s or its key", both.ID)
+	}
+	if got, err := Ensure(path, true); err != nil || got.ID != both.ID || got.PublicKey() != both.PublicKey() {
+		t.Errorf("rotation not saved: %+v, %v", got, err)
+	}
+}
+
+func TestSignVerify(t *testing.T) {
+	id, err := Ensure(filepath.Join(t.TempDir(), "identity.json"), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	body := []byte(`{"env_files":3}`)
+	signed := func() *http.Request {
+		req, err := http.NewRequest(http.MethodPost, "https://fleet.example.com/reports", bytes.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		id.Sign(req, body, now)
+		return req
+	}
+
+	if agentID, err := Verify(id.PublicKey(), signed(), body, now.Add(time.Minute)); err != nil || agentID != id.ID {
+		t.Fatalf("Verify = %q, %v", agentID, err)
+	}
+	other, err := Rotate(filepath.Join(t.TempDir(), "other.json"), true, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged := signed()
+	forged.Header.Set(HeaderAgentID, other.ID)
+	for name, tc := range map[string]struct {
+		key  string
+		req  *http.Request
+		body []byte
+		at   time.Time
+	}{
+		"tampered body":  {id.PublicKey(), signed(), []byte(`{"env_files":0}`), now},
+		"another key":    {other.PublicKey(), signed(), body, now},
+		"another agent":  {id.PublicKey(), forged, body, now},
+		"replayed later": {id.PublicKey(), signed(), body, now.Add(time.Hour)},
+		"bad key":        {"ssh-ed25519 AAAA", signed(), body, now},
+	} {
+		if _, err := Verify(tc.key, tc.req, tc.body, tc.at); err == nil {
+			t.Errorf("%s: Verify succeeded", name)
+		}
+	}
+
+	unsigned, _ := http.NewRequest(http.MethodPost, "https://fleet.example.com/reports", nil)
+	(&Identity{ID: id.ID}).Sign(unsigned, body, now)
+	if unsigned.Header.Get(HeaderAgentID) != id.ID || unsigned.Header.Get(HeaderSignature) != "" {
+		t.Errorf("Sign without a keypair set %v", unsigned.Header)
+	}
+}
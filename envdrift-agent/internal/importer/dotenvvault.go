@@ -0,0 +1,166 @@
+package importer
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jainal09/envdrift-agent/internal/dotenv"
+	"github.com/jainal09/envdrift-agent/internal/keys"
+)
+
+// vaultEntryPrefix names the per-environment ciphertexts in .env.vault.
+const vaultEntryPrefix = "DOTENV_VAULT_"
+
+// FromDotenvVault converts a dotenv-vault project: every DOTENV_VAULT_<ENV>
+// in dir/.env.vault is decrypted with its DOTENV_KEY — taken from the
+// DOTENV_KEY environment variable (comma-separated for several environments)
+// or the DOTENV_KEY_<ENV> entries dotenv-vault keeps in .env.keys — and
+// written as the matching dotenvx file: development becomes .env, any other
+// environment .env.<env>.
+//
+// Decryption is done here, with dotenv-vault's AES-256-GCM format, rather
+// than through the Node CLI, which would need a dotenv.org login. An
+// environment without a key is skipped; an existing target file is only
+// overwritten with Options.Force.
+func FromDotenvVault(ctx context.Context, dir string, opts Options) ([]Result, error) {
+	vaultPath := filepath.Join(dir, ".env.vault")
+	data, err := os.ReadFile(vaultPath)
+	if err != nil {
+		return nil, err
+	}
+	vaultEntries, err := dotenv.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", vaultPath, err)
+	}
+	dotenvKeys, err := dotenvVaultKeys(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []Result
+	for _, e := range vaultEntries {
+		if !strings.HasPrefix(e.Key, vaultEntryPrefix) {
+			continue
+		}
+		env := strings.ToLower(strings.TrimPrefix(e.Key, vaultEntryPrefix))
+		target := filepath.Join(dir, ".env")
+		if env != "development" {
+			target += "." + env
+		}
+		res := Result{Source: vaultPath, Target: target, KeyName: keys.KeyNameForFile(target)}
+
+		key, ok := dotenvKeys[env]
+		if !ok {
+			res.Skipped = fmt.Sprintf("no DOTENV_KEY for environment %q", env)
+			results = append(results, res)
+			continue
+		}
+		if _, err := os.Stat(target); err == nil && !opts.Force {
+			res.Skipped = "target exists (use --force to overwrite)"
+			results = append(results, res)
+			continue
+		}
+
+		plain, err := decryptVaultEntry(e.Value, key)
+		if err != nil {
+			return results, fmt.Errorf("%s: %w", e.Key, err)
+		}
+		entries, err := dotenv.Parse(plain)
+		if err != nil {
+			return results, fmt.Errorf("parse decrypted %s: %w", e.Key, err)
+		}
+		if err := writeEncrypted(ctx, target, entries); err != nil {
+			return results, err
+		}
+		res.Vars = len(entries)
+		results = append(results, res)
+	}
+	if len(results) == 0 {
+		return nil, fmt.Errorf("%s has no %s* entries", vaultPath, vaultEntryPrefix)
+	}
+	return results, nil
+}
+
+// dotenvVaultKeys maps environment name to DOTENV_KEY URI, from .env.keys
+// first and then $DOTENV_KEY, which wins.
+func dotenvVaultKeys(dir string) (map[string]string, error) {
+	var uris []string
+	if data, err := os.ReadFile(filepath.Join(dir, ".env.keys")); err == nil {
+		entries, err := dotenv.Parse(data)
+		if err != nil {
+			return nil, fmt.Errorf("parse .env.keys: %w", err)
+		}
+		for _, e := range entries {
+			if strings.HasPrefix(e.Key, "DOTENV_KEY_") {
+				uris = append(uris, e.Value)
+			}
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if v := os.Getenv("DOTENV_KEY"); v != "" {
+		uris = append(uris, strings.Split(v, ",")...)
+	}
+
+	byEnv := make(map[string]string, len(uris))
+	for _, uri := range uris {
+		u, err := url.Parse(strings.TrimSpace(uri))
+		if err != nil || u.Scheme != "dotenv" {
+			return nil, errors.New("malformed DOTENV_KEY: want dotenv://:key_...@dotenv.org/vault/.env.vault?environment=<env>")
+		}
+		env := strings.ToLower(u.Query().Get("environment"))
+		if env == "" {
+			return nil, errors.New("DOTENV_KEY is missing its environment parameter")
+		}
+		byEnv[env] = uri
+	}
+	return byEnv, nil
+}
+
+// decryptVaultEntry decrypts one DOTENV_VAULT_<ENV> value: base64 of a
+// 12-byte nonce followed by the AES-256-GCM ciphertext and tag, keyed by the
+// last 64 hex characters of the DOTENV_KEY password.
+func decryptVaultEntry(ciphertext, dotenvKey string) ([]byte, error) {
+	u, err := url.Parse(strings.TrimSpace(dotenvKey))
+	if err != nil {
+		return nil, errors.New("malformed DOTENV_KEY")
+	}
+	password, _ := u.User.Password()
+	if len(password) < 64 {
+		return nil, errors.New("DOTENV_KEY is missing its key part")
+	}
+	key, err := hex.DecodeString(password[len(password)-64:])
+	if err != nil {
+		return nil, errors.New("DOTENV_KEY key part is not hex")
+	}
+	raw, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return nil, errors.New("ciphertext is not base64")
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) < gcm.NonceSize()+gcm.Overhead() {
+		return nil, errors.New("ciphertext is too short")
+	}
+	plain, err := gcm.Open(nil, raw[:gcm.NonceSize()], raw[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, errors.New("decryption failed (wrong DOTENV_KEY?)")
+	}
+	return plain, nil
+}
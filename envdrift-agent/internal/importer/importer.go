@@ -0,0 +1,90 @@
+// Package importer converts env files encrypted by other tools — dotenv-vault
+// and SOPS — into dotenvx-encrypted files, so a team can adopt envdrift
+// without re-entering its secrets, and turns structured JSON or YAML config
+// into an encrypted env file.
+//
+// Each source is decrypted with its own key material (or parsed), written
+// out and immediately encrypted with dotenvx, which generates (or reuses)
+// the DOTENV_PRIVATE_KEY_<ENV> in the folder's .env.keys. The plaintext
+// exists on disk, mode 0600, only between those two steps, in a private
+// directory beside the target, which is replaced only once encryption has
+// succeeded.
+package importer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/jainal09/envdrift-agent/internal/dotenv"
+	"github.com/jainal09/envdrift-agent/internal/encrypt"
+)
+
+// Sources accepted by --from.
+const (
+	SourceDotenvVault = "dotenv-vault"
+	SourceSOPS        = "sops"
+)
+
+// Options tunes an import.
+type Options struct {
+	// Force overwrites existing target files (dotenv-vault only; SOPS files
+	// are converted in place).
+	Force bool
+}
+
+// Result describes one converted (or skipped) environment.
+type Result struct {
+	// Source is the file the secrets came from.
+	Source string
+	// Target is the dotenvx-encrypted file written.
+	Target string
+	// KeyName is the private key variable dotenvx keeps in .env.keys.
+	KeyName string
+	// Vars is the number of variables imported.
+	Vars int
+	// Backup is where a converted SOPS original was moved.
+	Backup string
+	// Skipped says why nothing was written, when non-empty.
+	Skipped string
+}
+
+// Import converts the dir's files encrypted by source.
+func Import(ctx context.Context, source, dir string, opts Options) ([]Result, error) {
+	switch source {
+	case SourceDotenvVault:
+		return FromDotenvVault(ctx, dir, opts)
+	case SourceSOPS:
+		return FromSOPS(ctx, dir)
+	default:
+		return nil, fmt.Errorf("unsupported import source %q (want %s or %s)", source, SourceDotenvVault, SourceSOPS)
+	}
+}
+
+// encryptFile encrypts a plaintext env file in place with the key store at
+// keysPath; tests replace it.
+var encryptFile = encrypt.EncryptDotenvxKeys
+
+// writeEncrypted writes entries to path encrypted with dotenvx. The
+// plaintext is written (mode 0600) and encrypted under the same name in a
+// private directory beside path, with path's .env.keys so dotenvx picks the
+// same key, and only then renamed over path: an existing file there is left
+// untouched when encryption fails.
+func writeEncrypted(ctx context.Context, path string, entries []dotenv.Entry) (err error) {
+	dir, err := os.MkdirTemp(filepath.Dir(path), ".envdrift-import-")
+	if err != nil {
+		return err
+	}
+	defer func() { err = errors.Join(err, os.RemoveAll(dir)) }()
+
+	tmp := filepath.Join(dir, filepath.Base(path))
+	if err := os.WriteFile(tmp, dotenv.Marshal(entries), 0600); err != nil {
+		return err
+	}
+	if err := encryptFile(ctx, tmp, filepath.Join(filepath.Dir(path), ".env.keys")); err != nil {
+		return fmt.Errorf("encrypt %s: %w", path, err)
+	}
+	return os.Rename(tmp, path)
+}
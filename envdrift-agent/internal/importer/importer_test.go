@@ -0,0 +1,201 @@
+package importer
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeEncrypt replaces encryptFile, recording the plaintext handed to it by
+// the target it was written for (its name beside the key store); fail makes
+// it error.
+func fakeEncrypt(t *testing.T, fail bool) map[string]string {
+	t.Helper()
+	orig := encryptFile
+	t.Cleanup(func() { encryptFile = orig })
+	seen := map[string]string{}
+	encryptFile = func(_ context.Context, path, keysPath string) error {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		seen[filepath.Join(filepath.Dir(keysPath), filepath.Base(path))] = string(data)
+		if fail {
+			return errors.New("dotenvx failed")
+		}
+		return os.WriteFile(path, []byte("SECRET=\"encrypted:xyz\"\n"), 0600)
+	}
+	return seen
+}
+
+// vaultCiphertext encrypts plain the way dotenv-vault does.
+func vaultCiphertext(t *testing.T, keyHex, plain string) string {
+	t.Helper()
+	key, _ := hex.DecodeString(keyHex)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gcm, _ := cipher.NewGCM(block)
+	nonce := make([]byte, gcm.NonceSize())
+	_, _ = rand.Read(nonce)
+	return base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, []byte(plain), nil))
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFromDotenvVault(t *testing.T) {
+	t.Setenv("DOTENV_KEY", "")
+	seen := fakeEncrypt(t, false)
+	dir := t.TempDir()
+	devKey := strings.Repeat("ab", 32)
+	prodKey := strings.Repeat("cd", 32)
+	writeFile(t, filepath.Join(dir, ".env.vault"),
+		"DOTENV_VAULT_DEVELOPMENT=\""+vaultCiphertext(t, devKey, "DB=dev\n")+"\"\n"+
+			"DOTENV_VAULT_PRODUCTION=\""+vaultCiphertext(t, prodKey, "DB=prod\nAPI=\"x y\"\n")+"\"\n"+
+			"DOTENV_VAULT_CI=\""+vaultCiphertext(t, devKey, "DB=ci\n")+"\"\n")
+	writeFile(t, filepath.Join(dir, ".env.keys"),
+		"DOTENV_KEY_DEVELOPMENT=\"dotenv://:key_"+devKey+"@dotenv.org/vault/.env.vault?environment=development\"\n")
+	t.Setenv("DOTENV_KEY", "dotenv://:key_"+prodKey+"@dotenv.org/vault/.env.vault?environment=production")
+
+	results, err := FromDotenvVault(context.Background(), dir, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("results = %+v", results)
+	}
+	dev, prod, ci := results[0], results[1], results[2]
+	if dev.Target != filepath.Join(dir, ".env") || dev.KeyName != "DOTENV_PRIVATE_KEY" || dev.Vars != 1 {
+		t.Errorf("development = %+v", dev)
+	}
+	if prod.Target != filepath.Join(dir, ".env.production") || prod.KeyName != "DOTENV_PRIVATE_KEY_PRODUCTION" || prod.Vars != 2 {
+		t.Errorf("production = %+v", prod)
+	}
+	if !strings.Contains(ci.Skipped, "no DOTENV_KEY") {
+		t.Errorf("ci without a key should be skipped: %+v", ci)
+	}
+	if got := seen[prod.Target]; got != "DB=\"prod\"\nAPI=\"x y\"\n" {
+		t.Errorf("plaintext handed to dotenvx = %q", got)
+	}
+
+	// A second run does not clobber the now-existing targets.
+	results, err = FromDotenvVault(context.Background(), dir, Options{})
+	if err != nil || !strings.Contains(results[0].Skipped, "--force") {
+		t.Errorf("rerun = %+v, %v", results, err)
+	}
+}
+
+func TestFromDotenvVaultForceKeepsTargetWhenEncryptFails(t *testing.T) {
+	fakeEncrypt(t, true)
+	dir := t.TempDir()
+	key := strings.Repeat("cd", 32)
+	writeFile(t, filepath.Join(dir, ".env.vault"),
+		"DOTENV_VAULT_PRODUCTION=\""+vaultCiphertext(t, key, "DB=prod\n")+"\"\n")
+	t.Setenv("DOTENV_KEY", "dotenv://:key_"+key+"@dotenv.org/vault/.env.vault?environment=production")
+	target := filepath.Join(dir, ".env.production")
+	original := "DB=\"encrypted:original\"\n"
+	writeFile(t, target, original)
+
+	if _, err := FromDotenvVault(context.Background(), dir, Options{Force: true}); err == nil {
+		t.Fatal("expected the encryption error")
+	}
+	if data, err := os.ReadFile(target); err != nil || string(data) != original {
+		t.Errorf("original target = %q, %v; want it untouched", data, err)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 2 {
+		t.Errorf("left behind in %s: %v", dir, entries)
+	}
+}
+
+func TestFromDotenvVaultWrongKey(t *testing.T) {
+	fakeEncrypt(t, false)
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, ".env.vault"),
+		"DOTENV_VAULT_PRODUCTION=\""+vaultCiphertext(t, strings.Repeat("ab", 32), "DB=prod\n")+"\"\n")
+	t.Setenv("DOTENV_KEY", "dotenv://:key_"+strings.Repeat("00", 32)+"@dotenv.org/vault/.env.vault?environment=production")
+
+	_, err := FromDotenvVault(context.Background(), dir, Options{})
+	if err == nil || !strings.Contains(err.Error(), "wrong DOTENV_KEY") {
+		t.Fatalf("expected a decryption error, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".env.production")); !os.IsNotExist(err) {
+		t.Error("nothing should be written when decryption fails")
+	}
+}
+
+func TestFromSOPS(t *testing.T) {
+	seen := fakeEncrypt(t, false)
+	dir := t.TempDir()
+	sopsFile := filepath.Join(dir, ".env.production")
+	writeFile(t, sopsFile, "DB=ENC[AES256_GCM,data:abc]\nsops_version=3.9.0\nsops_mac=ENC[AES256_GCM,data:mac]\n")
+	writeFile(t, filepath.Join(dir, ".env"), "PLAIN=1\n")
+
+	orig := runSOPS
+	t.Cleanup(func() { runSOPS = orig })
+	var decrypted []string
+	runSOPS = func(_ context.Context, path string) ([]byte, error) {
+		decrypted = append(decrypted, path)
+		return []byte("DB=postgres://prod\n"), nil
+	}
+
+	results, err := FromSOPS(context.Background(), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(decrypted) != 1 || decrypted[0] != sopsFile {
+		t.Fatalf("sops ran on %v, want only the SOPS file", decrypted)
+	}
+	r := results[0]
+	if r.Vars != 1 || r.Backup != sopsFile+".sops" || r.KeyName != "DOTENV_PRIVATE_KEY_PRODUCTION" {
+		t.Errorf("result = %+v", r)
+	}
+	if seen[sopsFile] != "DB=\"postgres://prod\"\n" {
+		t.Errorf("plaintext handed to dotenvx = %q", seen[sopsFile])
+	}
+	if data, _ := os.ReadFile(r.Backup); !strings.Contains(string(data), "sops_version") {
+		t.Errorf("SOPS original not kept: %q", data)
+	}
+}
+
+func TestFromSOPSRestoresOriginalWhenEncryptFails(t *testing.T) {
+	fakeEncrypt(t, true)
+	dir := t.TempDir()
+	sopsFile := filepath.Join(dir, ".env")
+	original := "DB=ENC[AES256_GCM,data:abc]\nsops_version=3.9.0\n"
+	writeFile(t, sopsFile, original)
+
+	orig := runSOPS
+	t.Cleanup(func() { runSOPS = orig })
+	runSOPS = func(context.Context, string) ([]byte, error) { return []byte("DB=secret\n"), nil }
+
+	if _, err := FromSOPS(context.Background(), dir); err == nil {
+		t.Fatal("expected the encryption error")
+	}
+	if data, _ := os.ReadFile(sopsFile); string(data) != original {
+		t.Errorf("original not restored: %q", data)
+	}
+	if _, err := os.Stat(sopsFile + ".sops"); !os.IsNotExist(err) {
+		t.Error("backup should have been moved back")
+	}
+}
+
+func TestImportUnknownSource(t *testing.T) {
+	if _, err := Import(context.Background(), "vault-classic", t.TempDir(), Options{}); err == nil {
+		t.Error("unknown source should fail")
+	}
+}
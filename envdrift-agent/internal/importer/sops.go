@@ -0,0 +1,109 @@
+package importer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jainal09/envdrift-agent/internal/dotenv"
+	"github.com/jainal09/envdrift-agent/internal/keys"
+)
+
+// sopsBackupSuffix is appended to a converted SOPS file kept for rollback.
+const sopsBackupSuffix = ".sops"
+
+// runSOPS decrypts a SOPS dotenv file to stdout; tests replace it. sops finds
+// its keys (age, PGP, cloud KMS) the way it does in the user's shell.
+var runSOPS = func(ctx context.Context, path string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "sops", "--decrypt", "--input-type", "dotenv", "--output-type", "dotenv", path)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		var execErr *exec.Error
+		if errors.As(err, &execErr) {
+			return nil, errors.New("sops not found. Install it: https://github.com/getsops/sops")
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("sops --decrypt %s: %s", path, msg)
+		}
+		return nil, fmt.Errorf("sops --decrypt %s: %w", path, err)
+	}
+	return stdout.Bytes(), nil
+}
+
+// FromSOPS converts every SOPS-encrypted .env* file directly in dir. Each one
+// is decrypted with sops, moved aside to <file>.sops, and replaced by a
+// dotenvx-encrypted file of the same name; if encryption fails the original
+// is moved back.
+func FromSOPS(ctx context.Context, dir string) ([]Result, error) {
+	files, err := sopsFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no SOPS-encrypted .env files in %s", dir)
+	}
+
+	var results []Result
+	for _, path := range files {
+		res := Result{Source: path, Target: path, KeyName: keys.KeyNameForFile(path), Backup: path + sopsBackupSuffix}
+		if _, err := os.Stat(res.Backup); err == nil {
+			res.Skipped = "backup " + filepath.Base(res.Backup) + " already exists"
+			results = append(results, res)
+			continue
+		}
+
+		plain, err := runSOPS(ctx, path)
+		if err != nil {
+			return results, err
+		}
+		entries, err := dotenv.Parse(plain)
+		if err != nil {
+			return results, fmt.Errorf("parse decrypted %s: %w", path, err)
+		}
+		if err := os.Rename(path, res.Backup); err != nil {
+			return results, err
+		}
+		if err := writeEncrypted(ctx, path, entries); err != nil {
+			return results, errors.Join(err, os.Rename(res.Backup, path))
+		}
+		res.Vars = len(entries)
+		results = append(results, res)
+	}
+	return results, nil
+}
+
+// sopsFiles lists the .env* files in dir that carry SOPS metadata.
+func sopsFiles(dir string) ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, ".env*"))
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, path := range matches {
+		base := filepath.Base(path)
+		if base == ".env.keys" || base == ".env.vault" || strings.HasSuffix(base, sopsBackupSuffix) {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue // directories, unreadable files
+		}
+		entries, err := dotenv.Parse(data)
+		if err != nil {
+			continue
+		}
+		if _, ok := dotenv.Map(entries)["sops_version"]; ok {
+			files = append(files, path)
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
@@ -0,0 +1,37 @@
+package importer
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/jainal09/envdrift-agent/internal/dotenv"
+	"github.com/jainal09/envdrift-agent/internal/keys"
+)
+
+// FromValues converts the structured config at source, in format (json,
+// yaml or dotenv), into the dotenvx-encrypted env file target; nested keys
+// are flattened as dotenv.Decode does. An existing target is only
+// overwritten with Options.Force. The source is left in place.
+func FromValues(ctx context.Context, source, target, format string, opts Options) (Result, error) {
+	res := Result{Source: source, Target: target, KeyName: keys.KeyNameForFile(target)}
+	if _, err := os.Stat(target); err == nil && !opts.Force {
+		return res, fmt.Errorf("%s already exists (use --force to overwrite)", target)
+	}
+	data, err := os.ReadFile(source)
+	if err != nil {
+		return res, err
+	}
+	entries, err := dotenv.Decode(data, format)
+	if err != nil {
+		return res, fmt.Errorf("%s: %w", source, err)
+	}
+	if len(entries) == 0 {
+		return res, fmt.Errorf("%s defines no variables", source)
+	}
+	if err := writeEncrypted(ctx, target, entries); err != nil {
+		return res, err
+	}
+	res.Vars = len(entries)
+	return res, nil
+}
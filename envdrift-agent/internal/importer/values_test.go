@@ -0,0 +1,55 @@
+package importer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jainal09/envdrift-agent/internal/dotenv"
+)
+
+func TestFromValues(t *testing.T) {
+	seen := fakeEncrypt(t, false)
+	dir := t.TempDir()
+	source := filepath.Join(dir, "values.yaml")
+	writeFile(t, source, "API_KEY: sk-1\ndb:\n  host: h\n")
+	target := filepath.Join(dir, ".env.production")
+
+	res, err := FromValues(context.Background(), source, target, dotenv.FormatYAML, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Vars != 2 || res.KeyName != "DOTENV_PRIVATE_KEY_PRODUCTION" {
+		t.Errorf("result = %+v", res)
+	}
+	if want := "API_KEY=\"sk-1\"\ndb_host=\"h\"\n"; seen[target] != want {
+		t.Errorf("plaintext handed to dotenvx = %q; want %q", seen[target], want)
+	}
+	if data, _ := os.ReadFile(target); !strings.Contains(string(data), "encrypted:") {
+		t.Errorf("target = %q; want it encrypted", data)
+	}
+
+	if _, err := FromValues(context.Background(), source, target, dotenv.FormatYAML, Options{}); err == nil {
+		t.Error("an existing target must not be overwritten without Force")
+	}
+	if _, err := FromValues(context.Background(), source, target, dotenv.FormatYAML, Options{Force: true}); err != nil {
+		t.Errorf("Force: %v", err)
+	}
+}
+
+func TestFromValuesRemovesPlaintextWhenEncryptFails(t *testing.T) {
+	fakeEncrypt(t, true)
+	dir := t.TempDir()
+	source := filepath.Join(dir, "values.json")
+	writeFile(t, source, `{"A": "1"}`)
+	target := filepath.Join(dir, ".env")
+
+	if _, err := FromValues(context.Background(), source, target, dotenv.FormatJSON, Options{}); err == nil {
+		t.Fatal("FromValues must fail when encryption fails")
+	}
+	if _, err := os.Stat(target); !os.IsNotExist(err) {
+		t.Errorf("plaintext target left behind: %v", err)
+	}
+}
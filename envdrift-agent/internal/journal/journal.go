@@ -0,0 +1,244 @@
+// Package journal keeps an append-only log of what the guardian saw and
+// decided — projects watched, files modified, and what each idle check did
+// with a file — in journal.jsonl in the state directory, so `envdrift-agent
+// debug replay` can answer "why didn't my file get encrypted?" after the
+// fact.
+//
+// Events carry paths and reasons only, never file contents. The log is
+// rotated to journal.jsonl.1 once it reaches MaxSize, and events older than
+// [logs] keep are dropped by Prune.
+package journal
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/jainal09/envdrift-agent/internal/longpath"
+	"github.com/jainal09/envdrift-agent/internal/paths"
+)
+
+// Event kinds. Watch and Modified come from the watchers; the rest are the
+// idle check's decision for one file.
+const (
+	// KindWatch is a project being watched; Detail is its idle timeout.
+	KindWatch = "watch"
+	// KindModified is a write to a tracked file, which restarts its idle
+	// clock.
+	KindModified = "modified"
+	// KindEncrypted is a file the guardian encrypted.
+	KindEncrypted = "encrypted"
+	// KindAlready is an idle file that was already encrypted.
+	KindAlready = "already-encrypted"
+	// KindGone is a tracked file that no longer exists.
+	KindGone = "gone"
+	// KindDeferred is an idle file left for a later check; Detail says why.
+	KindDeferred = "deferred"
+	// KindFailed is a failed or timed-out encryption; Detail is the error.
+	KindFailed = "failed"
+	// KindSecretInConfig is a value in a YAML, JSON or TOML file that looks
+	// like a secret, found by the config scan; Detail says which. The file is
+	// never encrypted.
+	KindSecretInConfig = "secret-in-config"
+)
+
+// MaxSize is the size at which the log is rotated.
+const MaxSize = 4 << 20
+
+// Event is one journal record, serialized as a single JSON line.
+type Event struct {
+	Time    time.Time `json:"time"`
+	Kind    string    `json:"kind"`
+	Project string    `json:"project,omitempty"`
+	Path    string    `json:"path,omitempty"`
+	Detail  string    `json:"detail,omitempty"`
+}
+
+var (
+	// mu serializes appends and guards last.
+	mu sync.Mutex
+	// last is the previous decision recorded for each path in this process.
+	last = map[string]Event{}
+)
+
+// Path returns the journal location: journal.jsonl in the state directory.
+func Path() string {
+	return filepath.Join(paths.StateDir(), "journal.jsonl")
+}
+
+// Record appends e to the journal unless it Repeats the previous decision
+// for its path (a file deferred on every check while it stays open).
+func Record(e Event) error {
+	if Repeats(e) {
+		return nil
+	}
+	return Append(e)
+}
+
+// Repeats reports whether decision e repeats the previous one for its path
+// since the file was last modified, and otherwise remembers e as the
+// latest. Watch and modified events never repeat.
+func Repeats(e Event) bool {
+	path := longpath.Strip(e.Path)
+	mu.Lock()
+	defer mu.Unlock()
+	switch e.Kind {
+	case KindWatch:
+	case KindModified:
+		delete(last, path)
+	default:
+		if prev, ok := last[path]; ok && prev.Kind == e.Kind && prev.Detail == e.Detail {
+			return true
+		}
+		last[path] = e
+	}
+	return false
+}
+
+// Append adds e to the journal, stamping the current time when e.Time is
+// zero.
+func Append(e Event) error {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	e.Path = longpath.Strip(e.Path)
+	e.Project = longpath.Strip(e.Project)
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	path := Path()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("create journal directory: %w", err)
+	}
+	if info, err := os.Stat(path); err == nil && info.Size()+int64(len(line)) > MaxSize {
+		if err := os.Rename(path, path+".1"); err != nil {
+			return fmt.Errorf("rotate journal: %w", err)
+		}
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("open journal: %w", err)
+	}
+	if _, err := f.Write(line); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("write journal: %w", err)
+	}
+	return f.Close()
+}
+
+// Read returns every event in the journal, the rotated part included, oldest
+// first. A missing journal is empty; a corrupt line is skipped.
+func Read() ([]Event, error) {
+	var events []Event
+	for _, path := range []string{Path() + ".1", Path()} {
+		part, err := readFile(path)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, part...)
+	}
+	return events, nil
+}
+
+func readFile(path string) ([]Event, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	var events []Event
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var e Event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue
+		}
+		events = append(events, e)
+	}
+	return events, scanner.Err()
+}
+
+// Prune drops the events older than before from the journal, its rotated
+// part included, and returns how many it dropped.
+func Prune(before time.Time) (int, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	dropped := 0
+	for _, path := range []string{Path() + ".1", Path()} {
+		n, err := pruneFile(path, before)
+		dropped += n
+		if err != nil {
+			return dropped, fmt.Errorf("prune journal: %w", err)
+		}
+	}
+	return dropped, nil
+}
+
+// pruneFile rewrites one part of the journal without the events older than
+// before or corrupt, removing it when none is left.
+func pruneFile(path string, before time.Time) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	var kept bytes.Buffer
+	dropped := 0
+	for _, line := range bytes.SplitAfter(data, []byte("\n")) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		var e Event
+		if err := json.Unmarshal(line, &e); err != nil || e.Time.Before(before) {
+			dropped++
+			continue
+		}
+		kept.Write(line)
+	}
+	switch {
+	case dropped == 0:
+		return 0, nil
+	case kept.Len() == 0:
+		return dropped, os.Remove(path)
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), ".journal-*")
+	if err != nil {
+		return 0, err
+	}
+	_, err = f.Write(kept.Bytes())
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(f.Name(), 0o600)
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+		return 0, err
+	}
+	return dropped, nil
+}
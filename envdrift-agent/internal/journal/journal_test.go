@@ -0,0 +1,145 @@
+package journal
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// setTempHome points the journal at a fresh temp dir and forgets the
+// decisions recorded by earlier tests.
+func setTempHome(t *testing.T) {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	mu.Lock()
+	last = map[string]Event{}
+	mu.Unlock()
+}
+
+func TestRecordAndRead(t *testing.T) {
+	setTempHome(t)
+
+	events := []Event{
+		{Kind: KindModified, Path: "/p/.env"},
+		{Kind: KindDeferred, Path: "/p/.env", Detail: "open in another process"},
+		// Deferred again for the same reason: not recorded twice.
+		{Kind: KindDeferred, Path: "/p/.env", Detail: "open in another process"},
+		{Kind: KindEncrypted, Path: "/p/.env"},
+		{Kind: KindModified, Path: "/p/.env"},
+		// A new idle period records the same decision again.
+		{Kind: KindEncrypted, Path: "/p/.env"},
+	}
+	for _, e := range events {
+		if err := Record(e); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+	}
+
+	got, err := Read()
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	var kinds []string
+	for _, e := range got {
+		if e.Time.IsZero() {
+			t.Error("Record should stamp a zero Time")
+		}
+		kinds = append(kinds, e.Kind)
+	}
+	want := "modified deferred encrypted modified encrypted"
+	if strings.Join(kinds, " ") != want {
+		t.Errorf("kinds = %v, want %s", kinds, want)
+	}
+}
+
+func TestRecordRotates(t *testing.T) {
+	setTempHome(t)
+	if err := os.MkdirAll(filepath.Dir(Path()), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(Path(), bytes.Repeat([]byte("x\n"), MaxSize/2), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Record(Event{Kind: KindModified, Path: "/p/.env"}); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+	if _, err := os.Stat(Path() + ".1"); err != nil {
+		t.Fatalf("full journal not rotated: %v", err)
+	}
+	got, err := Read()
+	if err != nil || len(got) != 1 {
+		t.Fatalf("Read = %v, %v; want the new event only", got, err)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	setTempHome(t)
+	now := time.Date(2026, 3, 20, 9, 0, 0, 0, time.UTC)
+	// The rotated part holds only old events, the active one both.
+	if err := Append(Event{Time: now.Add(-30 * 24 * time.Hour), Kind: KindModified, Path: "/p/.env"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(Path(), Path()+".1"); err != nil {
+		t.Fatal(err)
+	}
+	for _, age := range []time.Duration{20 * 24 * time.Hour, time.Hour} {
+		if err := Append(Event{Time: now.Add(-age), Kind: KindEncrypted, Path: "/p/.env"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	n, err := Prune(now.Add(-14 * 24 * time.Hour))
+	if err != nil || n != 2 {
+		t.Fatalf("Prune = %d, %v; want 2", n, err)
+	}
+	if _, err := os.Stat(Path() + ".1"); !os.IsNotExist(err) {
+		t.Errorf("emptied rotated part still there: %v", err)
+	}
+	got, err := Read()
+	if err != nil || len(got) != 1 || !got[0].Time.Equal(now.Add(-time.Hour)) {
+		t.Errorf("Read after Prune = %+v, %v; want the recent event", got, err)
+	}
+}
+
+func TestReplay(t *testing.T) {
+	start := time.Date(2026, 3, 1, 9, 0, 0, 0, time.Local)
+	at := func(d time.Duration) time.Time { return start.Add(d) }
+	events := []Event{
+		{Time: at(0), Kind: KindWatch, Project: "/src/api", Detail: "5m0s"},
+		{Time: at(time.Minute), Kind: KindModified, Project: "/src/api", Path: "/src/api/.env"},
+		{Time: at(7 * time.Minute), Kind: KindDeferred, Project: "/src/api", Path: "/src/api/.env", Detail: "open in another process"},
+		{Time: at(2 * time.Minute), Kind: KindModified, Project: "/src/api", Path: "/src/api/.env.local"},
+		{Time: at(8 * time.Minute), Kind: KindEncrypted, Project: "/src/api", Path: "/src/api/.env.local"},
+		{Time: at(9 * time.Minute), Kind: KindModified, Project: "/src/api", Path: "/src/api/.env.test"},
+	}
+
+	var buf bytes.Buffer
+	Replay(&buf, events, "")
+	out := buf.String()
+	for _, want := range []string{
+		"idle clock restarted; due at 2026-03-01 09:06:00",
+		"open in another process; idle 6m0s; retried on every check",
+		"/src/api/.env.local  idle 6m0s",
+		"  /src/api/.env: last check deferred it: open in another process",
+		"  /src/api/.env.test: modified 2026-03-01 09:09:00, due at 2026-03-01 09:14:00, and never checked after",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("replay missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "  /src/api/.env.local:") {
+		t.Errorf("encrypted file listed as plaintext:\n%s", out)
+	}
+
+	buf.Reset()
+	Replay(&buf, events, "/src/api/.env.test")
+	if out := buf.String(); strings.Contains(out, "/src/api/.env.local") || !strings.Contains(out, "idle timeout 5m0s") {
+		t.Errorf("replay of one file:\n%s", out)
+	}
+}
@@ -0,0 +1,128 @@
+package journal
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jainal09/envdrift-agent/internal/paths"
+)
+
+// timeLayout is how Replay prints event times.
+const timeLayout = "2006-01-02 15:04:05"
+
+// fileState is what the replayed guardian knows about one file.
+type fileState struct {
+	project  string
+	modified time.Time
+	last     Event
+}
+
+// Replay re-runs events through the guardian's idle rule — a file is due
+// for encryption once it has not been modified for its project's idle
+// timeout — and writes each event with what the guardian knew when it
+// happened. It ends with the files the journal leaves in plaintext and why.
+// A non-empty path limits the output to that file and its project.
+func Replay(w io.Writer, events []Event, path string) {
+	timeouts := map[string]time.Duration{}
+	files := map[string]*fileState{}
+	for _, e := range events {
+		if path != "" && e.Path != path && !(e.Kind == KindWatch && paths.Within(e.Project, path)) {
+			continue
+		}
+		at := e.Time.Local().Format(timeLayout)
+		if e.Kind == KindWatch {
+			timeouts[e.Project], _ = time.ParseDuration(e.Detail)
+			fmt.Fprintf(w, "%s  %-17s %s  (idle timeout %s)\n", at, e.Kind, e.Project, e.Detail)
+			continue
+		}
+
+		st := files[e.Path]
+		if st == nil {
+			st = &fileState{project: e.Project}
+			files[e.Path] = st
+		}
+		st.last = e
+		timeout := timeouts[e.Project]
+		var note string
+		switch e.Kind {
+		case KindModified:
+			st.modified = e.Time
+			note = "idle clock restarted"
+			if timeout > 0 {
+				note += "; due at " + e.Time.Add(timeout).Local().Format(timeLayout)
+			}
+		default:
+			note = decisionNote(e, st, timeout)
+		}
+		fmt.Fprintf(w, "%s  %-17s %s  %s\n", at, e.Kind, e.Path, note)
+	}
+
+	fmt.Fprintln(w)
+	plaintext := pending(files)
+	if len(plaintext) == 0 {
+		fmt.Fprintln(w, "No file is left in plaintext by the journal.")
+		return
+	}
+	fmt.Fprintln(w, "Left in plaintext:")
+	for _, p := range plaintext {
+		fmt.Fprintf(w, "  %s: %s\n", p, pendingReason(files[p], timeouts[files[p].project]))
+	}
+}
+
+// decisionNote explains an idle-check decision against the replayed state.
+func decisionNote(e Event, st *fileState, timeout time.Duration) string {
+	var parts []string
+	if e.Detail != "" {
+		parts = append(parts, e.Detail)
+	}
+	if !st.modified.IsZero() {
+		idle := e.Time.Sub(st.modified).Round(time.Second)
+		if timeout > 0 && idle < timeout {
+			parts = append(parts, fmt.Sprintf("idle %s of %s: checked early (git recheck or a policy's shorter timeout)", idle, timeout))
+		} else {
+			parts = append(parts, fmt.Sprintf("idle %s", idle))
+		}
+	}
+	switch e.Kind {
+	case KindDeferred:
+		parts = append(parts, "retried on every check")
+	case KindFailed:
+		parts = append(parts, "retried on the next check")
+	}
+	return strings.Join(parts, "; ")
+}
+
+// pending lists, sorted, the files whose last event leaves them in
+// plaintext.
+func pending(files map[string]*fileState) []string {
+	var paths []string
+	for p, st := range files {
+		switch st.last.Kind {
+		case KindModified, KindDeferred, KindFailed:
+			paths = append(paths, p)
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// pendingReason says why a file the journal leaves in plaintext was not
+// encrypted.
+func pendingReason(st *fileState, timeout time.Duration) string {
+	switch st.last.Kind {
+	case KindDeferred:
+		return "last check deferred it: " + st.last.Detail
+	case KindFailed:
+		return "last encryption failed: " + st.last.Detail
+	}
+	if timeout == 0 {
+		return "modified " + st.modified.Local().Format(timeLayout) +
+			" and never checked; its project's watch started before the journal"
+	}
+	return fmt.Sprintf("modified %s, due at %s, and never checked after: the agent stopped, "+
+		"or the journal ends before its next check",
+		st.modified.Local().Format(timeLayout), st.modified.Add(timeout).Local().Format(timeLayout))
+}
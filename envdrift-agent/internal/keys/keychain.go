@@ -0,0 +1,103 @@
+package keys
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// keychainService is the service/label keys are stored under in the OS
+// keychain, with the private-key variable name as the account.
+const keychainService = "envdrift"
+
+// keychainCommand runs a keychain tool and returns its stdout. It is a seam so
+// tests can fake the platform tools.
+var keychainCommand = func(ctx context.Context, name string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	err := cmd.Run()
+	return stdout.Bytes(), err
+}
+
+// keychainSource reads the key from the OS keychain: the macOS login keychain
+// via `security`, the freedesktop Secret Service via `secret-tool` on Linux.
+type keychainSource struct{}
+
+func (keychainSource) Name() string { return SourceKeychain }
+
+func (keychainSource) Lookup(ctx context.Context, req Request) (string, string, bool, error) {
+	var (
+		tool, location string
+		args           []string
+		notFoundCode   int
+	)
+	switch runtime.GOOS {
+	case "darwin":
+		tool, notFoundCode = "security", 44
+		args = []string{"find-generic-password", "-s", keychainService, "-a", req.KeyName, "-w"}
+		location = "macOS keychain (service " + keychainService + ")"
+	case "linux":
+		tool, notFoundCode = "secret-tool", 1
+		args = []string{"lookup", "service", keychainService, "account", req.KeyName}
+		location = "Secret Service (service " + keychainService + ")"
+	default:
+		return "", "", false, fmt.Errorf("%w: no keychain integration on %s", ErrUnavailable, runtime.GOOS)
+	}
+
+	out, err := keychainCommand(ctx, tool, args...)
+	if err != nil {
+		var execErr *exec.Error
+		if errors.As(err, &execErr) {
+			return "", location, false, fmt.Errorf("%w: %s not installed", ErrUnavailable, tool)
+		}
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == notFoundCode {
+			return "", location, false, nil
+		}
+		return "", location, false, fmt.Errorf("%s: %w", tool, err)
+	}
+
+	value := strings.TrimRight(string(out), "\r\n")
+	if value == "" {
+		return "", location, false, nil
+	}
+	return value, location, true, nil
+}
+
+// keychainStore runs a keychain tool with stdin; a seam like keychainCommand.
+var keychainStore = func(ctx context.Context, stdin []byte, name string, args ...string) error {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdin = bytes.NewReader(stdin)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%s: %s", name, msg)
+		}
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
+
+// writeKeychain stores the key in the OS keychain under keychainService. The
+// value travels on stdin — `security -i` reads its command from stdin,
+// `secret-tool store` reads the secret — never in argv.
+func writeKeychain(ctx context.Context, keyName, value string) error {
+	switch runtime.GOOS {
+	case "darwin":
+		line := fmt.Sprintf("add-generic-password -U -s %s -a %s -w %s\n",
+			keychainService, keyName, strconv.Quote(value))
+		return keychainStore(ctx, []byte(line), "security", "-i")
+	case "linux":
+		return keychainStore(ctx, []byte(value), "secret-tool", "store",
+			"--label", keychainService+" "+keyName, "service", keychainService, "account", keyName)
+	default:
+		return fmt.Errorf("%w: no keychain integration on %s", ErrUnavailable, runtime.GOOS)
+	}
+}
@@ -0,0 +1,388 @@
+// Package keys resolves dotenvx private keys (DOTENV_PRIVATE_KEY[_<ENV>])
+// through an ordered, configurable chain of sources.
+//
+// The default order is process environment → the file's sibling .env.keys →
+// OS keychain → configured vault; the first source that has the key wins.
+// "plugin:<name>" adds a key source plugin to the chain.
+// decrypt-style commands (reveal, exec) hand the resolved key to dotenvx via
+// its environment, so the chain — not dotenvx's own lookup — decides which
+// key is used.
+package keys
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jainal09/envdrift-agent/internal/dotenv"
+	"github.com/jainal09/envdrift-agent/internal/plugins"
+	"github.com/jainal09/envdrift-agent/internal/project"
+	"github.com/jainal09/envdrift-agent/internal/vault"
+)
+
+// Source names accepted in the [keys] resolution chain.
+const (
+	SourceEnv        = "env"
+	SourceDotenvKeys = "dotenv_keys"
+	SourceKeychain   = "keychain"
+	SourceVault      = "vault"
+	// SourcePlugin prefixes a plugin name: "plugin:<name>" asks that plugin
+	// (see package plugins).
+	SourcePlugin = "plugin:"
+)
+
+// DefaultChain is the documented lookup order.
+var DefaultChain = []string{SourceEnv, SourceDotenvKeys, SourceKeychain, SourceVault}
+
+// ErrUnavailable marks a source that cannot be consulted at all on this
+// machine (no keychain tool, no vault configured) as opposed to one that was
+// consulted and did not have the key.
+var ErrUnavailable = errors.New("source unavailable")
+
+// Request identifies the key to resolve.
+type Request struct {
+	// KeyName is the variable name, e.g. DOTENV_PRIVATE_KEY_PRODUCTION.
+	KeyName string
+	// Dir is the directory of the env file; file-based sources look there.
+	Dir string
+}
+
+// Source is one link in the resolution chain. Lookup returns found=false when
+// the source was consulted and does not hold the key, and an error wrapping
+// ErrUnavailable when it cannot be consulted.
+type Source interface {
+	Name() string
+	Lookup(ctx context.Context, req Request) (value, location string, found bool, err error)
+}
+
+// Resolution is the outcome of resolving a key. Value is the secret and must
+// never be logged or printed.
+type Resolution struct {
+	KeyName  string
+	Source   string
+	Location string
+	Value    string
+}
+
+// Step records how one source answered, for `keys whereis`.
+type Step struct {
+	Source   string
+	Location string
+	Found    bool
+	Err      error
+}
+
+// Resolver walks its sources in order.
+type Resolver struct {
+	sources []Source
+}
+
+// NewResolver builds a resolver for the named chain, using the built-in
+// source implementations. An empty chain means DefaultChain; an unknown or
+// repeated source name is an error so a config typo fails loudly.
+func NewResolver(chain []string) (*Resolver, error) {
+	if len(chain) == 0 {
+		chain = DefaultChain
+	}
+	seen := make(map[string]bool, len(chain))
+	sources := make([]Source, 0, len(chain))
+	for _, name := range chain {
+		if seen[name] {
+			return nil, fmt.Errorf("keys.resolution: %q listed more than once", name)
+		}
+		seen[name] = true
+		src, ok := builtinSource(name)
+		if !ok {
+			return nil, fmt.Errorf("keys.resolution: unknown source %q (want one of %s, or %s<name>)",
+				name, strings.Join(DefaultChain, ", "), SourcePlugin)
+		}
+		sources = append(sources, src)
+	}
+	return &Resolver{sources: sources}, nil
+}
+
+// CacheVault makes the resolver's vault source read secrets through c.
+func (r *Resolver) CacheVault(c *vault.Cache) {
+	for i, src := range r.sources {
+		if _, ok := src.(vaultSource); ok {
+			r.sources[i] = vaultSource{cache: c}
+		}
+	}
+}
+
+// NewResolverFromSources builds a resolver over caller-supplied sources.
+func NewResolverFromSources(sources ...Source) *Resolver {
+	return &Resolver{sources: sources}
+}
+
+// builtinSource maps a chain name to its implementation.
+func builtinSource(name string) (Source, bool) {
+	switch name {
+	case SourceEnv:
+		return envSource{}, true
+	case SourceDotenvKeys:
+		return dotenvKeysSource{}, true
+	case SourceKeychain:
+		return keychainSource{}, true
+	case SourceVault:
+		return vaultSource{}, true
+	}
+	if plugin, ok := strings.CutPrefix(name, SourcePlugin); ok && plugin != "" {
+		return pluginSource{plugin: plugin}, true
+	}
+	return nil, false
+}
+
+// Resolve returns the key from the first source that has it. found=false
+// means no source had it; err is returned only when a source failed in a way
+// other than being unavailable (a resolution must not silently skip a broken
+// source and pick a lower-priority, possibly stale, key).
+func (r *Resolver) Resolve(ctx context.Context, req Request) (res Resolution, found bool, err error) {
+	for _, src := range r.sources {
+		value, location, ok, lerr := src.Lookup(ctx, req)
+		if lerr != nil {
+			if errors.Is(lerr, ErrUnavailable) {
+				continue
+			}
+			return Resolution{}, false, fmt.Errorf("%s: %w", src.Name(), lerr)
+		}
+		if ok {
+			return Resolution{KeyName: req.KeyName, Source: src.Name(), Location: location, Value: value}, true, nil
+		}
+	}
+	return Resolution{}, false, nil
+}
+
+// Explain consults every source (not stopping at the first hit) and reports
+// what each one said, without exposing any value.
+func (r *Resolver) Explain(ctx context.Context, req Request) []Step {
+	steps := make([]Step, 0, len(r.sources))
+	for _, src := range r.sources {
+		_, location, ok, err := src.Lookup(ctx, req)
+		steps = append(steps, Step{Source: src.Name(), Location: location, Found: ok, Err: err})
+	}
+	return steps
+}
+
+// KeyNameForFile derives dotenvx's private-key variable name from an env file
+// name, the same derivation dotenvx and the CLI apply: `.env` →
+// DOTENV_PRIVATE_KEY, `.env.<env>` → DOTENV_PRIVATE_KEY_<ENV> (dots become
+// underscores, uppercased). A file named by the project's [naming]
+// convention, such as config/production.env under "config/{env}.env", takes
+// its environment's key name instead.
+func KeyNameForFile(path string) string {
+	if naming, err := project.LoadNaming(filepath.Dir(path)); err == nil {
+		if env, _, ok := naming.Environment(path); ok {
+			return KeyName(env)
+		}
+	}
+	return DotenvxKeyName(path)
+}
+
+// DotenvxKeyName is the key name derived from path's base name alone, as
+// dotenvx does whatever the [naming] convention; it differs from
+// KeyNameForFile only for files the convention names.
+func DotenvxKeyName(path string) string {
+	return KeyName(strings.TrimPrefix(filepath.Base(path), ".env"))
+}
+
+// KeyName returns the private-key variable name for an environment name;
+// "" names the default key.
+func KeyName(env string) string {
+	suffix := strings.TrimLeft(strings.ToUpper(strings.ReplaceAll(env, ".", "_")), "_")
+	if suffix == "" {
+		return "DOTENV_PRIVATE_KEY"
+	}
+	return "DOTENV_PRIVATE_KEY_" + suffix
+}
+
+// envSource reads the key from the process environment.
+type envSource struct{}
+
+func (envSource) Name() string { return SourceEnv }
+
+func (envSource) Lookup(_ context.Context, req Request) (string, string, bool, error) {
+	v, ok := os.LookupEnv(req.KeyName)
+	if !ok || v == "" {
+		return "", "", false, nil
+	}
+	return v, "$" + req.KeyName, true, nil
+}
+
+// dotenvKeysSource reads the key from the .env.keys next to the env file.
+type dotenvKeysSource struct{}
+
+func (dotenvKeysSource) Name() string { return SourceDotenvKeys }
+
+func (dotenvKeysSource) Lookup(_ context.Context, req Request) (string, string, bool, error) {
+	path := filepath.Join(req.Dir, ".env.keys")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", path, false, nil
+		}
+		return "", path, false, err
+	}
+	entries, err := dotenv.Parse(data)
+	if err != nil {
+		return "", path, false, err
+	}
+	v, ok := dotenv.Map(entries)[req.KeyName]
+	if !ok || v == "" {
+		return "", path, false, nil
+	}
+	return v, path, true, nil
+}
+
+// LocalKey reads keyName from a local store — SourceDotenvKeys (dir's
+// .env.keys) or SourceKeychain.
+func LocalKey(ctx context.Context, store, dir, keyName string) (string, bool, error) {
+	src, err := localStore(store)
+	if err != nil {
+		return "", false, err
+	}
+	value, _, found, err := src.Lookup(ctx, Request{KeyName: keyName, Dir: dir})
+	return value, found, err
+}
+
+// StoreLocalKey writes keyName to a local store (see LocalKey).
+func StoreLocalKey(ctx context.Context, store, dir, keyName, value string) error {
+	if _, err := localStore(store); err != nil {
+		return err
+	}
+	if store == SourceKeychain {
+		return writeKeychain(ctx, keyName, value)
+	}
+	return WriteDotenvKey(dir, keyName, value)
+}
+
+func localStore(store string) (Source, error) {
+	switch store {
+	case SourceDotenvKeys:
+		return dotenvKeysSource{}, nil
+	case SourceKeychain:
+		return keychainSource{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported local key store %q (want %s or %s)", store, SourceDotenvKeys, SourceKeychain)
+	}
+}
+
+// WriteDotenvKey sets keyName=value in dir's .env.keys, replacing an existing
+// line for the key in place or appending one, and keeps the file 0600.
+func WriteDotenvKey(dir, keyName, value string) error {
+	path := filepath.Join(dir, ".env.keys")
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	line := keyName + "=" + value
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	if len(data) == 0 {
+		lines = nil
+	}
+	replaced := false
+	for i, l := range lines {
+		k, _, ok := strings.Cut(strings.TrimPrefix(strings.TrimSpace(l), "export "), "=")
+		if ok && strings.TrimSpace(k) == keyName {
+			lines[i] = line
+			replaced = true
+		}
+	}
+	if !replaced {
+		lines = append(lines, line)
+	}
+
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0600); err != nil {
+		return err
+	}
+	return os.Chmod(path, 0600)
+}
+
+// newVaultProvider builds the provider for a project's vault config. It is a
+// seam so tests can substitute an in-memory store.
+var newVaultProvider = vault.New
+
+// vaultSource reads the key from the secret store configured in the
+// project's [vault] tables: the [[vault.sync.mappings]] entry for req.Dir
+// whose environment matches the key names the secret to fetch. With a cache
+// the secret is read through it.
+type vaultSource struct {
+	cache *vault.Cache
+}
+
+func (vaultSource) Name() string { return SourceVault }
+
+func (s vaultSource) Lookup(ctx context.Context, req Request) (string, string, bool, error) {
+	settings, found, err := project.LoadVaultSettings(req.Dir)
+	if err != nil {
+		return "", "", false, err
+	}
+	if !found {
+		return "", "", false, fmt.Errorf("%w: no vault provider configured", ErrUnavailable)
+	}
+	mapping, ok := VaultMapping(settings, req.Dir, req.KeyName)
+	if !ok {
+		return "", "", false, fmt.Errorf("%w: no [[vault.sync.mappings]] entry for %s", ErrUnavailable, req.Dir)
+	}
+
+	location := settings.Config.Provider + " secret " + mapping.SecretName
+	provider, err := newVaultProvider(settings.Config)
+	if err != nil {
+		return "", location, false, err
+	}
+	if s.cache != nil {
+		provider = s.cache.Wrap(settings.Config, provider)
+	}
+	raw, err := provider.GetSecret(ctx, mapping.SecretName)
+	if errors.Is(err, vault.ErrNotFound) {
+		return "", location, false, nil
+	}
+	if err != nil {
+		return "", location, false, err
+	}
+	value, err := vault.KeyMaterial(raw, req.KeyName)
+	if err != nil {
+		return "", location, false, fmt.Errorf("%s: %w", mapping.SecretName, err)
+	}
+	return value, location, true, nil
+}
+
+// VaultMapping returns the sync mapping for dir whose environment's key name
+// is keyName.
+func VaultMapping(settings *project.VaultSettings, dir, keyName string) (project.VaultMapping, bool) {
+	dir = filepath.Clean(dir)
+	for _, m := range settings.Mappings {
+		if m.FolderPath == dir && KeyName(m.Environment) == keyName {
+			return m, true
+		}
+	}
+	return project.VaultMapping{}, false
+}
+
+// pluginSource asks an installed plugin that declares itself a key source.
+type pluginSource struct {
+	plugin string
+}
+
+func (s pluginSource) Name() string { return SourcePlugin + s.plugin }
+
+func (s pluginSource) Lookup(ctx context.Context, req Request) (string, string, bool, error) {
+	// A missing plugin is a broken chain, not an unavailable source: the
+	// config names it, and skipping it could pick a stale key further down.
+	p, err := plugins.Find(ctx, s.plugin)
+	if err != nil {
+		return "", "", false, err
+	}
+	reply, err := p.LookupKey(ctx, plugins.KeyRequest{Name: req.KeyName, Dir: req.Dir})
+	if err != nil {
+		return "", "", false, err
+	}
+	if !reply.Found {
+		return "", reply.Location, false, nil
+	}
+	return reply.Value, reply.Location, true, nil
+}
@@ -0,0 +1,333 @@
+// Package keys tests
+package keys
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jainal09/envdrift-agent/internal/paths"
+	"github.com/jainal09/envdrift-agent/internal/plugins"
+	"github.com/jainal09/envdrift-agent/internal/vault"
+)
+
+func TestKeyNameForFile(t *testing.T) {
+	tests := map[string]string{
+		".env":                        "DOTENV_PRIVATE_KEY",
+		"/p/.env.production":          "DOTENV_PRIVATE_KEY_PRODUCTION",
+		".env.staging.eu":             "DOTENV_PRIVATE_KEY_STAGING_EU",
+		filepath.Join("a", ".env.ci"): "DOTENV_PRIVATE_KEY_CI",
+	}
+	for in, want := range tests {
+		if got := KeyNameForFile(in); got != want {
+			t.Errorf("KeyNameForFile(%q) = %q, want %q", in, got, want)
+		}
+	}
+	if got := KeyName(""); got != "DOTENV_PRIVATE_KEY" {
+		t.Errorf("KeyName(\"\") = %q", got)
+	}
+}
+
+func TestKeyNameForFileNamingConvention(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "envdrift.toml"), []byte("[naming]\nenv_file = \"config/{env}.env\"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "config", "production.env")
+	if got := KeyNameForFile(path); got != "DOTENV_PRIVATE_KEY_PRODUCTION" {
+		t.Errorf("KeyNameForFile(%s) = %q; want the convention's environment", path, got)
+	}
+	if got := DotenvxKeyName(path); got == "DOTENV_PRIVATE_KEY_PRODUCTION" {
+		t.Errorf("DotenvxKeyName(%s) = %q; want the name from the base name alone", path, got)
+	}
+	if got := KeyNameForFile(filepath.Join(dir, ".env.staging")); got != "DOTENV_PRIVATE_KEY_STAGING" {
+		t.Errorf("a .env.staging outside the convention = %q; want dotenvx's name for it", got)
+	}
+}
+
+func TestNewResolverRejectsBadChains(t *testing.T) {
+	if _, err := NewResolver([]string{"env", "hsm"}); err == nil || !strings.Contains(err.Error(), "unknown source") {
+		t.Errorf("unknown source should fail, got %v", err)
+	}
+	if _, err := NewResolver([]string{"env", "env"}); err == nil || !strings.Contains(err.Error(), "more than once") {
+		t.Errorf("duplicate source should fail, got %v", err)
+	}
+	r, err := NewResolver(nil)
+	if err != nil {
+		t.Fatalf("empty chain should use the default: %v", err)
+	}
+	if len(r.sources) != len(DefaultChain) {
+		t.Errorf("default chain has %d sources, want %d", len(r.sources), len(DefaultChain))
+	}
+}
+
+func writeKeys(t *testing.T, content string) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, ".env.keys"), []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestResolveOrder(t *testing.T) {
+	dir := writeKeys(t, "DOTENV_PRIVATE_KEY_PRODUCTION=\"from-file\"\n")
+	req := Request{KeyName: "DOTENV_PRIVATE_KEY_PRODUCTION", Dir: dir}
+
+	r, err := NewResolver([]string{SourceEnv, SourceDotenvKeys})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, found, err := r.Resolve(context.Background(), req)
+	if err != nil || !found || res.Source != SourceDotenvKeys || res.Value != "from-file" {
+		t.Fatalf("Resolve without env = %+v, %v, %v", res, found, err)
+	}
+
+	t.Setenv("DOTENV_PRIVATE_KEY_PRODUCTION", "from-env")
+	res, found, err = r.Resolve(context.Background(), req)
+	if err != nil || !found || res.Source != SourceEnv || res.Value != "from-env" {
+		t.Fatalf("env must win over .env.keys, got %+v, %v, %v", res, found, err)
+	}
+
+	r, _ = NewResolver([]string{SourceDotenvKeys, SourceEnv})
+	res, _, _ = r.Resolve(context.Background(), req)
+	if res.Source != SourceDotenvKeys {
+		t.Errorf("reordered chain must consult .env.keys first, got %s", res.Source)
+	}
+}
+
+func TestResolveNotFound(t *testing.T) {
+	r, _ := NewResolver([]string{SourceDotenvKeys, SourceVault})
+	_, found, err := r.Resolve(context.Background(), Request{KeyName: "DOTENV_PRIVATE_KEY", Dir: t.TempDir()})
+	if err != nil || found {
+		t.Errorf("Resolve = found %v, err %v; want not found, nil (vault unavailable is skipped)", found, err)
+	}
+}
+
+// fakeSource is a scripted Source for chain tests.
+type fakeSource struct {
+	name  string
+	value string
+	err   error
+}
+
+func (f fakeSource) Name() string { return f.name }
+
+func (f fakeSource) Lookup(context.Context, Request) (string, string, bool, error) {
+	return f.value, "fake", f.value != "", f.err
+}
+
+// TestResolveStopsOnBrokenSource pins fail-closed resolution: a source that
+// errors (other than being unavailable) must not be skipped in favour of a
+// lower-priority key.
+func TestResolveStopsOnBrokenSource(t *testing.T) {
+	r := NewResolverFromSources(
+		fakeSource{name: "broken", err: errors.New("permission denied")},
+		fakeSource{name: "fallback", value: "stale"},
+	)
+	if _, _, err := r.Resolve(context.Background(), Request{KeyName: "K"}); err == nil {
+		t.Fatal("a broken source must stop resolution")
+	}
+
+	r = NewResolverFromSources(
+		fakeSource{name: "absent", err: ErrUnavailable},
+		fakeSource{name: "fallback", value: "v"},
+	)
+	res, found, err := r.Resolve(context.Background(), Request{KeyName: "K"})
+	if err != nil || !found || res.Source != "fallback" {
+		t.Errorf("unavailable source should be skipped, got %+v, %v, %v", res, found, err)
+	}
+}
+
+func TestExplainConsultsEverySource(t *testing.T) {
+	r := NewResolverFromSources(
+		fakeSource{name: "a", value: "1"},
+		fakeSource{name: "b", value: "2"},
+		fakeSource{name: "c", err: ErrUnavailable},
+	)
+	steps := r.Explain(context.Background(), Request{KeyName: "K"})
+	if len(steps) != 3 || !steps[0].Found || !steps[1].Found || steps[2].Err == nil {
+		t.Errorf("Explain steps = %+v", steps)
+	}
+}
+
+func TestKeychainSource(t *testing.T) {
+	if runtime.GOOS != "darwin" && runtime.GOOS != "linux" {
+		t.Skip("keychain integration is darwin/linux only")
+	}
+	orig := keychainCommand
+	t.Cleanup(func() { keychainCommand = orig })
+
+	var gotArgs []string
+	keychainCommand = func(_ context.Context, name string, args ...string) ([]byte, error) {
+		gotArgs = append([]string{name}, args...)
+		return []byte("secret-from-keychain\n"), nil
+	}
+	v, _, found, err := keychainSource{}.Lookup(context.Background(), Request{KeyName: "DOTENV_PRIVATE_KEY_CI"})
+	if err != nil || !found || v != "secret-from-keychain" {
+		t.Fatalf("Lookup = %q, %v, %v", v, found, err)
+	}
+	if !strings.Contains(strings.Join(gotArgs, " "), "DOTENV_PRIVATE_KEY_CI") {
+		t.Errorf("keychain tool not queried for the key name: %v", gotArgs)
+	}
+
+	keychainCommand = func(context.Context, string, ...string) ([]byte, error) {
+		return nil, &exec.Error{Name: "security", Err: exec.ErrNotFound}
+	}
+	if _, _, _, err := (keychainSource{}).Lookup(context.Background(), Request{KeyName: "K"}); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("missing keychain tool should be ErrUnavailable, got %v", err)
+	}
+}
+
+// memoryVault is an in-memory vault.Provider.
+type memoryVault map[string]string
+
+func (memoryVault) Name() string { return "aws" }
+
+func (m memoryVault) GetSecret(_ context.Context, name string) (string, error) {
+	v, ok := m[name]
+	if !ok {
+		return "", vault.ErrNotFound
+	}
+	return v, nil
+}
+
+func (m memoryVault) SetSecret(_ context.Context, name, value string) error {
+	m[name] = value
+	return nil
+}
+
+func TestVaultSource(t *testing.T) {
+	store := memoryVault{"myapp/prod": "DOTENV_PRIVATE_KEY_PRODUCTION=abc123"}
+	var gotCfg vault.Config
+	orig := newVaultProvider
+	newVaultProvider = func(cfg vault.Config) (vault.Provider, error) {
+		gotCfg = cfg
+		return store, nil
+	}
+	t.Cleanup(func() { newVaultProvider = orig })
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "envdrift.toml"), []byte(`
+[vault.aws]
+profile = "corp-sso"
+
+[[vault.sync.mappings]]
+secret_name = "myapp/prod"
+folder_path = "."
+
+[[vault.sync.mappings]]
+secret_name = "myapp/missing"
+folder_path = "."
+environment = "staging"
+`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	value, location, found, err := vaultSource{}.Lookup(context.Background(),
+		Request{KeyName: "DOTENV_PRIVATE_KEY_PRODUCTION", Dir: dir})
+	if err != nil || !found || value != "abc123" || location != "aws secret myapp/prod" {
+		t.Fatalf("Lookup = %q, %q, %v, %v", value, location, found, err)
+	}
+	if gotCfg.AWS.Profile != "corp-sso" {
+		t.Errorf("provider config = %+v; want the [vault.aws] profile", gotCfg)
+	}
+
+	_, _, found, err = vaultSource{}.Lookup(context.Background(),
+		Request{KeyName: "DOTENV_PRIVATE_KEY_STAGING", Dir: dir})
+	if err != nil || found {
+		t.Errorf("missing secret should be not found, got found %v, err %v", found, err)
+	}
+
+	_, _, _, err = vaultSource{}.Lookup(context.Background(),
+		Request{KeyName: "DOTENV_PRIVATE_KEY_CI", Dir: dir})
+	if !errors.Is(err, ErrUnavailable) {
+		t.Errorf("unmapped key should be unavailable, got %v", err)
+	}
+
+	// Through the resolver's cache a rotation is only seen after the TTL.
+	resolver, err := NewResolver([]string{SourceVault})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resolver.CacheVault(&vault.Cache{TTL: time.Hour})
+	req := Request{KeyName: "DOTENV_PRIVATE_KEY_PRODUCTION", Dir: dir}
+	if _, _, err := resolver.Resolve(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	store["myapp/prod"] = "DOTENV_PRIVATE_KEY_PRODUCTION=rotated"
+	if res, _, err := resolver.Resolve(context.Background(), req); err != nil || res.Value != "abc123" {
+		t.Errorf("cached Resolve = %q, %v; want the cached key", res.Value, err)
+	}
+}
+
+func TestWriteDotenvKey(t *testing.T) {
+	dir := writeKeys(t, "#/ keys /\nDOTENV_PRIVATE_KEY_PRODUCTION=\"old\"\nDOTENV_PRIVATE_KEY_CI=ci\n")
+	if err := WriteDotenvKey(dir, "DOTENV_PRIVATE_KEY_PRODUCTION", "new"); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteDotenvKey(dir, "DOTENV_PRIVATE_KEY_STAGING", "stg"); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, ".env.keys")
+	data, _ := os.ReadFile(path)
+	want := "#/ keys /\nDOTENV_PRIVATE_KEY_PRODUCTION=new\nDOTENV_PRIVATE_KEY_CI=ci\nDOTENV_PRIVATE_KEY_STAGING=stg\n"
+	if string(data) != want {
+		t.Errorf(".env.keys =\n%s\nwant\n%s", data, want)
+	}
+	if info, _ := os.Stat(path); runtime.GOOS != "windows" && info.Mode().Perm() != 0o600 {
+		t.Errorf(".env.keys mode = %v, want 0600", info.Mode().Perm())
+	}
+}
+
+func TestPluginSource(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the test plugin is a POSIX shell script")
+	}
+	t.Setenv(paths.HomeEnv, t.TempDir())
+	if err := os.MkdirAll(plugins.Dir(), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	script := `#!/bin/sh
+case "$1" in
+describe) echo '{"name":"store","key_source":true}' ;;
+key) grep -q PRODUCTION && echo '{"found":true,"value":"from-plugin","location":"team store"}' || echo '{"found":false}' ;;
+esac
+`
+	if err := os.WriteFile(filepath.Join(plugins.Dir(), "store"), []byte(script), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewResolver([]string{SourceEnv, SourcePlugin + "store"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, found, err := r.Resolve(context.Background(), Request{KeyName: "DOTENV_PRIVATE_KEY_PRODUCTION", Dir: t.TempDir()})
+	if err != nil || !found || res.Source != "plugin:store" || res.Value != "from-plugin" || res.Location != "team store" {
+		t.Fatalf("Resolve = %+v, %v, %v", res, found, err)
+	}
+	if _, found, err := r.Resolve(context.Background(), Request{KeyName: "DOTENV_PRIVATE_KEY_CI"}); err != nil || found {
+		t.Errorf("Resolve of a key the plugin lacks = %v, %v", found, err)
+	}
+
+	// A chain naming a plugin that is not installed fails instead of
+	// falling through to the next source.
+	r, err = NewResolver([]string{SourcePlugin + "missing", SourceEnv})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("DOTENV_PRIVATE_KEY", "from-env")
+	if _, _, err := r.Resolve(context.Background(), Request{KeyName: "DOTENV_PRIVATE_KEY"}); err == nil {
+		t.Error("a missing plugin must fail resolution")
+	}
+	if _, err := NewResolver([]string{SourcePlugin}); err == nil {
+		t.Error(`"plugin:" without a name must be rejected`)
+	}
+}
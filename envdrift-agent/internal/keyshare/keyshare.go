@@ -0,0 +1,233 @@
+// Package keyshare hands dotenvx private keys to teammates without a shared
+// vault: the keys are encrypted with age to the recipients' age or SSH public
+// keys, and the armored result can travel over any channel — chat, email, a
+// pull request comment — since only the recipients can open it.
+//
+// Recipients are age keys (age1...), SSH public keys (ssh-ed25519,
+// ssh-rsa), "github:<user>" for the SSH keys GitHub publishes for a user, or
+// a name from [keys] team standing for one of those. Encryption and
+// decryption go through the age CLI, which reads all of these forms.
+package keyshare
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Share is what a bundle carries.
+type Share struct {
+	// From is user@host of the sender.
+	From string `json:"from"`
+	// Project is the base name of the sender's project directory, for the
+	// recipient's information only.
+	Project string            `json:"project"`
+	Created time.Time         `json:"created"`
+	Keys    map[string]string `json:"keys"`
+}
+
+// KeyNames returns the names of the shared keys, sorted.
+func (s Share) KeyNames() []string {
+	names := make([]string, 0, len(s.Keys))
+	for name := range s.Keys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Seams for tests.
+var (
+	runAge     = runAgeCLI
+	githubURL  = "https://github.com"
+	httpClient = &http.Client{Timeout: 15 * time.Second}
+	homeDir    = os.UserHomeDir
+	getenv     = os.Getenv
+)
+
+// runAgeCLI runs age with stdin and returns stdout; stderr becomes the error.
+func runAgeCLI(ctx context.Context, stdin []byte, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "age", args...)
+	cmd.Stdin = bytes.NewReader(stdin)
+	var out, errOut bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &errOut
+	if err := cmd.Run(); err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return nil, errors.New("age is not installed or not on PATH (https://age-encryption.org)")
+		}
+		if msg := strings.TrimSpace(errOut.String()); msg != "" {
+			return nil, fmt.Errorf("age: %s", msg)
+		}
+		return nil, fmt.Errorf("age: %w", err)
+	}
+	return out.Bytes(), nil
+}
+
+// githubUser matches a GitHub username.
+var githubUser = regexp.MustCompile(`^[A-Za-z0-9](?:[A-Za-z0-9-]{0,37}[A-Za-z0-9])?$`)
+
+// ValidRecipient reports an error unless spec is a recipient form Resolve
+// accepts without consulting a team list.
+func ValidRecipient(spec string) error {
+	switch {
+	case strings.HasPrefix(spec, "github:"):
+		if !githubUser.MatchString(strings.TrimPrefix(spec, "github:")) {
+			return fmt.Errorf("%q is not a GitHub username", strings.TrimPrefix(spec, "github:"))
+		}
+	case strings.HasPrefix(spec, "age1"), strings.HasPrefix(spec, "ssh-ed25519 "), strings.HasPrefix(spec, "ssh-rsa "):
+	default:
+		return fmt.Errorf("recipient %q: want github:<user>, an age1... key or an ssh-ed25519/ssh-rsa public key", spec)
+	}
+	return nil
+}
+
+// Resolve returns the age recipients spec stands for. A name found in team
+// is replaced by its entry first.
+func Resolve(ctx context.Context, spec string, team map[string]string) ([]string, error) {
+	if entry, ok := team[spec]; ok {
+		spec = entry
+	}
+	if err := ValidRecipient(spec); err != nil {
+		return nil, err
+	}
+	if user, ok := strings.CutPrefix(spec, "github:"); ok {
+		return githubKeys(ctx, user)
+	}
+	return []string{spec}, nil
+}
+
+// githubKeys fetches the SSH public keys GitHub publishes for user, keeping
+// the types age can encrypt to.
+func githubKeys(ctx context.Context, user string) ([]string, error) {
+	url := githubURL + "/" + user + ".keys"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("github:%s: %w", user, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("github:%s: no such GitHub user", user)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("github:%s: %s", user, resp.Status)
+	}
+	var found []string
+	sc := bufio.NewScanner(io.LimitReader(resp.Body, 1<<20))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if strings.HasPrefix(line, "ssh-ed25519 ") || strings.HasPrefix(line, "ssh-rsa ") {
+			found = append(found, line)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("github:%s: %w", user, err)
+	}
+	if len(found) == 0 {
+		return nil, fmt.Errorf("github:%s has no ssh-ed25519 or ssh-rsa keys on GitHub", user)
+	}
+	return found, nil
+}
+
+// Seal encrypts share to recipients as an armored age file.
+func Seal(ctx context.Context, share Share, recipients []string) ([]byte, error) {
+	if len(recipients) == 0 {
+		return nil, errors.New("no recipients")
+	}
+	plaintext, err := json.Marshal(share)
+	if err != nil {
+		return nil, err
+	}
+	args := []string{"--encrypt", "--armor"}
+	for _, r := range recipients {
+		args = append(args, "--recipient", r)
+	}
+	return runAge(ctx, plaintext, args...)
+}
+
+// Open decrypts a bundle with the first of identities that fits.
+func Open(ctx context.Context, data []byte, identities []string) (Share, error) {
+	if len(identities) == 0 {
+		return Share{}, errors.New("no identity to decrypt with: pass --identity with your SSH or age private key")
+	}
+	args := []string{"--decrypt"}
+	for _, id := range identities {
+		args = append(args, "--identity", id)
+	}
+	plaintext, err := runAge(ctx, data, args...)
+	if err != nil {
+		return Share{}, err
+	}
+	var share Share
+	if err := json.Unmarshal(plaintext, &share); err != nil || len(share.Keys) == 0 {
+		return Share{}, errors.New("the file decrypts but is not an envdrift key share")
+	}
+	return share, nil
+}
+
+// DefaultIdentities returns the private keys a recipient most likely holds:
+// ~/.ssh/id_ed25519 and ~/.ssh/id_rsa, and the age key file SOPS uses
+// ($SOPS_AGE_KEY_FILE, or keys.txt in the sops/age config directory). Only
+// files that exist are returned.
+func DefaultIdentities() []string {
+	home, err := homeDir()
+	if err != nil {
+		return nil
+	}
+	candidates := []string{
+		filepath.Join(home, ".ssh", "id_ed25519"),
+		filepath.Join(home, ".ssh", "id_rsa"),
+	}
+	switch ageKeys := getenv("SOPS_AGE_KEY_FILE"); {
+	case ageKeys != "":
+		candidates = append(candidates, ageKeys)
+	case getenv("XDG_CONFIG_HOME") != "":
+		candidates = append(candidates, filepath.Join(getenv("XDG_CONFIG_HOME"), "sops", "age", "keys.txt"))
+	case runtime.GOOS == "darwin":
+		candidates = append(candidates, filepath.Join(home, "Library", "Application Support", "sops", "age", "keys.txt"))
+	case runtime.GOOS == "windows":
+		candidates = append(candidates, filepath.Join(getenv("APPDATA"), "sops", "age", "keys.txt"))
+	default:
+		candidates = append(candidates, filepath.Join(home, ".config", "sops", "age", "keys.txt"))
+	}
+	var found []string
+	for _, path := range candidates {
+		if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+			found = append(found, path)
+		}
+	}
+	return found
+}
+
+// Sender returns user@host for Share.From.
+func Sender() string {
+	user := getenv("USER")
+	if user == "" {
+		user = getenv("USERNAME")
+	}
+	host, _ := os.Hostname()
+	switch {
+	case user != "" && host != "":
+		return user + "@" + host
+	case host != "":
+		return host
+	}
+	return user
+}
@@ -0,0 +1,134 @@
+package keyshare
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResolve(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/alice.keys":
+			fmt.Fprint(w, "ssh-ed25519 AAAAalice\necdsa-sha2-nistp256 AAAAskipped\nssh-rsa AAAArsa\n")
+		case "/nokeys.keys":
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	oldURL, oldClient := githubURL, httpClient
+	githubURL, httpClient = srv.URL, srv.Client()
+	defer func() { githubURL, httpClient = oldURL, oldClient }()
+
+	ctx := context.Background()
+	team := map[string]string{"al": "github:alice", "ci": "age1ci"}
+	for _, tc := range []struct {
+		spec string
+		want []string
+	}{
+		{"github:alice", []string{"ssh-ed25519 AAAAalice", "ssh-rsa AAAArsa"}},
+		{"al", []string{"ssh-ed25519 AAAAalice", "ssh-rsa AAAArsa"}},
+		{"ci", []string{"age1ci"}},
+		{"ssh-ed25519 AAAAbob bob@laptop", []string{"ssh-ed25519 AAAAbob bob@laptop"}},
+	} {
+		got, err := Resolve(ctx, tc.spec, team)
+		if err != nil || !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("Resolve(%q) = %q, %v; want %q", tc.spec, got, err, tc.want)
+		}
+	}
+	for _, spec := range []string{"github:ghost", "github:nokeys", "github:-bad", "bob", "ecdsa-sha2-nistp256 AAAA"} {
+		if _, err := Resolve(ctx, spec, team); err == nil {
+			t.Errorf("Resolve(%q) succeeded", spec)
+		}
+	}
+}
+
+// fakeAge stands in for the age CLI, recording its arguments. It "encrypts"
+// by adding the armor header, and only "decrypts" with the identity "right".
+func fakeAge(t *testing.T) *[][]string {
+	t.Helper()
+	var calls [][]string
+	old := runAge
+	runAge = func(_ context.Context, stdin []byte, args ...string) ([]byte, error) {
+		calls = append(calls, args)
+		if args[0] == "--encrypt" {
+			return append([]byte("-----BEGIN AGE ENCRYPTED FILE-----\n"), stdin...), nil
+		}
+		if !strings.Contains(strings.Join(args, " "), "--identity right") {
+			return nil, errors.New("age: no identity matched any of the recipients")
+		}
+		return bytes.TrimPrefix(stdin, []byte("-----BEGIN AGE ENCRYPTED FILE-----\n")), nil
+	}
+	t.Cleanup(func() { runAge = old })
+	return &calls
+}
+
+func TestSealOpen(t *testing.T) {
+	calls := fakeAge(t)
+	ctx := context.Background()
+	share := Share{From: "me@laptop", Project: "api", Created: time.Unix(0, 0).UTC(),
+		Keys: map[string]string{"DOTENV_PRIVATE_KEY_PRODUCTION": "abc", "DOTENV_PRIVATE_KEY": "def"}}
+
+	sealed, err := Seal(ctx, share, []string{"age1x", "ssh-ed25519 AAAA"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"--encrypt", "--armor", "--recipient", "age1x", "--recipient", "ssh-ed25519 AAAA"}
+	if !reflect.DeepEqual((*calls)[0], want) {
+		t.Errorf("age args = %q, want %q", (*calls)[0], want)
+	}
+	if _, err := Seal(ctx, share, nil); err == nil {
+		t.Error("Seal without recipients succeeded")
+	}
+
+	got, err := Open(ctx, sealed, []string{"wrong", "right"})
+	if err != nil || !reflect.DeepEqual(got, share) {
+		t.Fatalf("Open = %+v, %v", got, err)
+	}
+	if names := got.KeyNames(); !reflect.DeepEqual(names, []string{"DOTENV_PRIVATE_KEY", "DOTENV_PRIVATE_KEY_PRODUCTION"}) {
+		t.Errorf("KeyNames = %q", names)
+	}
+	if _, err := Open(ctx, sealed, []string{"wrong"}); err == nil {
+		t.Error("Open with the wrong identity succeeded")
+	}
+	if _, err := Open(ctx, sealed, nil); err == nil {
+		t.Error("Open without identities succeeded")
+	}
+}
+
+func TestDefaultIdentities(t *testing.T) {
+	home := t.TempDir()
+	oldHome, oldEnv := homeDir, getenv
+	homeDir = func() (string, error) { return home, nil }
+	ageKeys := filepath.Join(home, "age.txt")
+	getenv = func(k string) string {
+		if k == "SOPS_AGE_KEY_FILE" {
+			return ageKeys
+		}
+		return ""
+	}
+	defer func() { homeDir, getenv = oldHome, oldEnv }()
+
+	for _, p := range []string{filepath.Join(home, ".ssh", "id_ed25519"), ageKeys} {
+		if err := os.MkdirAll(filepath.Dir(p), 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte("key"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := []string{filepath.Join(home, ".ssh", "id_ed25519"), ageKeys}
+	if got := DefaultIdentities(); !reflect.DeepEqual(got, want) {
+		t.Errorf("DefaultIdentities = %q, want %q", got, want)
+	}
+}
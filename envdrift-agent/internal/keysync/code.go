@@ -0,0 +1,160 @@
+package keysync
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jainal09/envdrift-agent/internal/paths"
+)
+
+// KeySize is the length of a sync key: an AES-256 key.
+const KeySize = 32
+
+// ErrNoKey is returned by LoadKey when this device has not joined a sync.
+var ErrNoKey = errors.New("this device has no sync code: run 'envdrift-agent keys sync init' on your first device " +
+	"and 'envdrift-agent keys sync join <code>' on the others")
+
+// ErrWrongKey is returned when the bundle in the store does not open with
+// this device's sync key.
+var ErrWrongKey = errors.New("the synced bundle does not decrypt with this device's sync code " +
+	"(it was created with another code, or altered)")
+
+// codeEncoding writes sync codes without padding. Base32 has no 0, 1, 8 or
+// 9, so lowercase o and l cannot be mistaken for digits when typed.
+var codeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// NewKey returns a random sync key.
+func NewKey() ([]byte, error) {
+	key := make([]byte, KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// FormatCode returns key as the sync code a user types on another device:
+// lowercase base32 in dash-separated groups of four.
+func FormatCode(key []byte) string {
+	s := strings.ToLower(codeEncoding.EncodeToString(key))
+	var groups []string
+	for len(s) > 4 {
+		groups = append(groups, s[:4])
+		s = s[4:]
+	}
+	return strings.Join(append(groups, s), "-")
+}
+
+// ParseCode returns the key a sync code stands for, ignoring case, dashes
+// and spaces.
+func ParseCode(code string) ([]byte, error) {
+	s := strings.ToUpper(strings.NewReplacer("-", "", " ", "", "\t", "").Replace(strings.TrimSpace(code)))
+	key, err := codeEncoding.DecodeString(s)
+	if err != nil || len(key) != KeySize {
+		return nil, errors.New("not a sync code: expected the 52-letter code 'keys sync init' printed")
+	}
+	return key, nil
+}
+
+// KeyPath returns keysync.key in the config directory.
+func KeyPath() string {
+	return filepath.Join(paths.ConfigDir(), "keysync.key")
+}
+
+// LoadKey reads the sync key at path; ErrNoKey when there is none.
+func LoadKey(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, ErrNoKey
+	}
+	if err != nil {
+		return nil, err
+	}
+	key, err := ParseCode(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return key, nil
+}
+
+// SaveKey writes key to path as its sync code, readable only by the user.
+func SaveKey(path string, key []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, []byte(FormatCode(key)+"\n"), 0600); err != nil {
+		return err
+	}
+	return os.Chmod(path, 0600)
+}
+
+// envelope is what the store holds: the bundle sealed with AES-256-GCM.
+type envelope struct {
+	Format     string `json:"format"`
+	Version    int    `json:"version"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+const (
+	envelopeFormat  = "envdrift-keysync"
+	envelopeVersion = 1
+)
+
+// seal encrypts plaintext under key. The format and version are bound as
+// additional data, so an envelope cannot be relabelled.
+func seal(key, plaintext []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	env := envelope{Format: envelopeFormat, Version: envelopeVersion, Nonce: nonce}
+	env.Ciphertext = aead.Seal(nil, nonce, plaintext, additionalData(env.Version))
+	return json.Marshal(env)
+}
+
+// open decrypts what seal produced.
+func open(key, data []byte) ([]byte, error) {
+	var env envelope
+	if err := json.Unmarshal(data, &env); err != nil || env.Format != envelopeFormat {
+		return nil, errors.New("the sync store holds something other than an envdrift key bundle")
+	}
+	if env.Version != envelopeVersion {
+		return nil, fmt.Errorf("the key bundle has format version %d; upgrade envdrift-agent to read it", env.Version)
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(env.Nonce) != aead.NonceSize() {
+		return nil, ErrWrongKey
+	}
+	plaintext, err := aead.Open(nil, env.Nonce, env.Ciphertext, additionalData(env.Version))
+	if err != nil {
+		return nil, ErrWrongKey
+	}
+	return plaintext, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func additionalData(version int) []byte {
+	return []byte(fmt.Sprintf("%s/%d", envelopeFormat, version))
+}
@@ -0,0 +1,371 @@
+// Package keysync keeps the .env.keys files of a user's projects in step
+// across their devices, so a new laptop can decrypt the env files it clones.
+//
+// Every device that joined holds the same random sync key. The keys of the
+// registered projects travel as one bundle sealed with AES-256-GCM under it,
+// so the store — a synced folder, an S3 bucket or a WebDAV server — only ever
+// holds ciphertext. Projects are matched across devices by their path below
+// the home directory.
+//
+// Like vaultsync, a sync only replaces a local key that is unchanged since
+// the last sync; a key changed on two devices is a conflict, left alone and
+// reported unless the caller prefers one side. Keys are never deleted from
+// the bundle: removing a line from .env.keys does not remove it elsewhere.
+package keysync
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jainal09/envdrift-agent/internal/dotenv"
+	"github.com/jainal09/envdrift-agent/internal/keys"
+	"github.com/jainal09/envdrift-agent/internal/paths"
+)
+
+// Outcome is what a sync did with one key.
+type Outcome string
+
+const (
+	// Unchanged means the key is the same here and in the bundle.
+	Unchanged Outcome = "unchanged"
+	// Uploaded means the local key was new or changed and went into the bundle.
+	Uploaded Outcome = "uploaded"
+	// Downloaded means the key from the bundle was written locally.
+	Downloaded Outcome = "downloaded"
+	// Conflict means the key changed here and on another device; neither
+	// side was touched.
+	Conflict Outcome = "conflict"
+	// Skipped means the key's project directory does not exist here.
+	Skipped Outcome = "skipped"
+	// Failed means the key could not be written; Result.Err says why.
+	Failed Outcome = "failed"
+)
+
+// Prefer resolves conflicts.
+type Prefer string
+
+const (
+	// PreferNone leaves conflicts alone.
+	PreferNone Prefer = ""
+	// PreferLocal uploads this device's key.
+	PreferLocal Prefer = "local"
+	// PreferRemote writes the bundle's key here.
+	PreferRemote Prefer = "remote"
+)
+
+// Result is the outcome for one key.
+type Result struct {
+	// Dir is the directory holding .env.keys on this device.
+	Dir     string
+	KeyName string
+	Outcome Outcome
+	Err     error
+	// Device last uploaded the bundle's key, for conflicts.
+	Device string
+}
+
+// Syncer merges local keys with the bundle in a store.
+type Syncer struct {
+	Store Store
+	Key   []byte
+	// Device names this device in the bundle; the hostname when empty.
+	Device string
+	// StatePath holds the fingerprints of the last synced keys.
+	StatePath string
+	// Prefer decides conflicts.
+	Prefer Prefer
+	// DryRun reports what a sync would do without writing anything.
+	DryRun bool
+}
+
+// DefaultStatePath returns key-sync.json in the state directory.
+func DefaultStatePath() string {
+	return filepath.Join(paths.StateDir(), "key-sync.json")
+}
+
+// bundle is the plaintext inside the sealed envelope.
+type bundle struct {
+	Updated time.Time        `json:"updated"`
+	Device  string           `json:"device"`
+	Keys    map[string]entry `json:"keys"`
+}
+
+// entry is one private key. Dir is portable: "~/" and a slash-separated
+// path below the home directory, or an absolute path outside it.
+type entry struct {
+	Dir     string    `json:"dir"`
+	Name    string    `json:"name"`
+	Value   string    `json:"value"`
+	Updated time.Time `json:"updated"`
+	Device  string    `json:"device"`
+}
+
+// now and homeDir are seams for tests.
+var (
+	now     = time.Now
+	homeDir = os.UserHomeDir
+)
+
+// Sync merges the keys in the .env.keys files of projects (and their
+// subdirectories) with the stored bundle, writes what other devices
+// changed, and uploads what this one did.
+func (s *Syncer) Sync(ctx context.Context, projects []string) ([]Result, error) {
+	remote := bundle{Keys: map[string]entry{}}
+	data, err := s.Store.Get(ctx)
+	switch {
+	case errors.Is(err, ErrNotFound):
+	case err != nil:
+		return nil, err
+	default:
+		plaintext, err := open(s.Key, data)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(plaintext, &remote); err != nil {
+			return nil, fmt.Errorf("decode key bundle: %w", err)
+		}
+		if remote.Keys == nil {
+			remote.Keys = map[string]entry{}
+		}
+	}
+
+	local, err := collect(projects)
+	if err != nil {
+		return nil, err
+	}
+	state := s.loadState()
+	device := s.device()
+	changed := false
+
+	ids := make([]string, 0, len(local)+len(remote.Keys))
+	for id := range local {
+		ids = append(ids, id)
+	}
+	for id := range remote.Keys {
+		if _, ok := local[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	var results []Result
+	for _, id := range ids {
+		l, haveLocal := local[id]
+		r, haveRemote := remote.Keys[id]
+		res := Result{KeyName: l.Name, Dir: l.dir}
+		if !haveLocal {
+			res.KeyName = r.Name
+		}
+
+		upload := func() {
+			res.Outcome = Uploaded
+			remote.Keys[id] = entry{Dir: l.Dir, Name: l.Name, Value: l.Value, Updated: now().UTC(), Device: device}
+			state[id] = fingerprint(l.Value)
+			changed = true
+		}
+		download := func() {
+			dir, err := localDir(r.Dir)
+			if err != nil {
+				res.Outcome, res.Err = Failed, err
+				return
+			}
+			res.Dir = dir
+			if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+				res.Outcome = Skipped
+				return
+			}
+			if !s.DryRun {
+				if err := keys.WriteDotenvKey(dir, r.Name, r.Value); err != nil {
+					res.Outcome, res.Err = Failed, err
+					return
+				}
+			}
+			res.Outcome = Downloaded
+			state[id] = fingerprint(r.Value)
+		}
+
+		switch {
+		case !haveRemote:
+			upload()
+		case !haveLocal:
+			download()
+		case l.Value == r.Value:
+			res.Outcome = Unchanged
+			state[id] = fingerprint(l.Value)
+		case state[id] == fingerprint(l.Value):
+			// Only the other device changed it: a rotation.
+			download()
+		case state[id] == fingerprint(r.Value):
+			upload()
+		case s.Prefer == PreferLocal:
+			upload()
+		case s.Prefer == PreferRemote:
+			download()
+		default:
+			res.Outcome, res.Device = Conflict, r.Device
+		}
+		results = append(results, res)
+	}
+
+	if s.DryRun {
+		return results, nil
+	}
+	if changed {
+		remote.Updated, remote.Device = now().UTC(), device
+		plaintext, err := json.Marshal(remote)
+		if err != nil {
+			return results, err
+		}
+		sealed, err := seal(s.Key, plaintext)
+		if err != nil {
+			return results, err
+		}
+		if err := s.Store.Put(ctx, sealed); err != nil {
+			return results, fmt.Errorf("upload to %s: %w", s.Store, err)
+		}
+	}
+	return results, s.saveState(state)
+}
+
+func (s *Syncer) device() string {
+	if s.Device != "" {
+		return s.Device
+	}
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		return "unknown"
+	}
+	return host
+}
+
+// localEntry is a key found on this device; dir is where its .env.keys is.
+type localEntry struct {
+	entry
+	dir string
+}
+
+// collect reads the private keys from every .env.keys in projects, skipping
+// hidden directories and dependency trees, keyed by entryID.
+func collect(projects []string) (map[string]localEntry, error) {
+	found := map[string]localEntry{}
+	for _, root := range projects {
+		root = filepath.Clean(root)
+		err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+			if err != nil {
+				return nil // Skip inaccessible entries
+			}
+			if d.IsDir() {
+				if path != root && (strings.HasPrefix(d.Name(), ".") || skipDirs[d.Name()]) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if d.Name() != ".env.keys" {
+				return nil
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			entries, err := dotenv.Parse(data)
+			if err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+			dir := filepath.Dir(path)
+			portable, err := portableDir(dir)
+			if err != nil {
+				return err
+			}
+			for name, value := range dotenv.Map(entries) {
+				if !strings.HasPrefix(name, keys.KeyName("")) || value == "" {
+					continue
+				}
+				found[entryID(portable, name)] = localEntry{
+					entry: entry{Dir: portable, Name: name, Value: value},
+					dir:   dir,
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return found, nil
+}
+
+// skipDirs are dependency and build trees that never hold a project's keys.
+var skipDirs = map[string]bool{"node_modules": true, "vendor": true, "venv": true, "__pycache__": true}
+
+func entryID(portable, name string) string {
+	return portable + "|" + name
+}
+
+// portableDir names dir the same way on every device: relative to the home
+// directory when it is inside it.
+func portableDir(dir string) (string, error) {
+	home, err := homeDir()
+	if err != nil {
+		return "", err
+	}
+	if paths.Within(home, dir) {
+		rel, _ := filepath.Rel(home, dir)
+		if rel == "." {
+			return "~", nil
+		}
+		return "~/" + filepath.ToSlash(rel), nil
+	}
+	return filepath.ToSlash(dir), nil
+}
+
+// localDir is portableDir's inverse on this device.
+func localDir(portable string) (string, error) {
+	rest, ok := strings.CutPrefix(portable, "~")
+	if !ok {
+		return filepath.FromSlash(portable), nil
+	}
+	home, err := homeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, filepath.FromSlash(strings.TrimPrefix(rest, "/"))), nil
+}
+
+// fingerprint identifies a key value without storing it.
+func fingerprint(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
+
+// loadState reads the fingerprint map; a missing or corrupt file is an empty
+// state, which turns every differing key into a conflict rather than
+// overwriting it.
+func (s *Syncer) loadState() map[string]string {
+	state := map[string]string{}
+	data, err := os.ReadFile(s.StatePath)
+	if err != nil {
+		return state
+	}
+	_ = json.Unmarshal(data, &state)
+	return state
+}
+
+func (s *Syncer) saveState(state map[string]string) error {
+	if err := os.MkdirAll(filepath.Dir(s.StatePath), 0700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.StatePath, data, 0600)
+}
@@ -0,0 +1,270 @@
+package keysync
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestCodeRoundTrip(t *testing.T) {
+	key, err := NewKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := FormatCode(key)
+	if len(strings.ReplaceAll(code, "-", "")) != 52 {
+		t.Fatalf("code %q is not 52 letters", code)
+	}
+	got, err := ParseCode(" " + strings.ToUpper(strings.ReplaceAll(code, "-", " ")) + "\n")
+	if err != nil || string(got) != string(key) {
+		t.Fatalf("ParseCode = %x, %v; want %x", got, err, key)
+	}
+	if _, err := ParseCode("abcd-efgh"); err == nil {
+		t.Error("short code accepted")
+	}
+}
+
+func TestSealOpen(t *testing.T) {
+	key, _ := NewKey()
+	other, _ := NewKey()
+	sealed, err := seal(key, []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(sealed), "secret") {
+		t.Fatal("plaintext visible in the envelope")
+	}
+	if got, err := open(key, sealed); err != nil || string(got) != "secret" {
+		t.Fatalf("open = %q, %v", got, err)
+	}
+	if _, err := open(other, sealed); !errors.Is(err, ErrWrongKey) {
+		t.Fatalf("open with another key = %v, want ErrWrongKey", err)
+	}
+}
+
+// device is one machine in a sync test: its own home and state.
+type device struct {
+	home  string
+	state string
+}
+
+func newDevice(t *testing.T) device {
+	t.Helper()
+	home := t.TempDir()
+	return device{home: home, state: filepath.Join(t.TempDir(), "key-sync.json")}
+}
+
+// sync runs a sync as d against store.
+func (d device) sync(t *testing.T, store Store, key []byte, prefer Prefer) map[string]Outcome {
+	t.Helper()
+	homeDir = func() (string, error) { return d.home, nil }
+	t.Cleanup(func() { homeDir = os.UserHomeDir })
+	s := &Syncer{Store: store, Key: key, Device: filepath.Base(d.home), StatePath: d.state, Prefer: prefer}
+	results, err := s.Sync(context.Background(), []string{filepath.Join(d.home, "code")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	outcomes := map[string]Outcome{}
+	for _, r := range results {
+		rel, _ := filepath.Rel(d.home, r.Dir)
+		outcomes[filepath.ToSlash(rel)+" "+r.KeyName] = r.Outcome
+	}
+	return outcomes
+}
+
+func writeKeys(t *testing.T, dir, content string) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".env.keys"), []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readKeys(t *testing.T, dir string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, ".env.keys"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestSyncBetweenDevices(t *testing.T) {
+	store := newFileStore(filepath.Join(t.TempDir(), "bundle"))
+	key, _ := NewKey()
+	laptop, desktop := newDevice(t), newDevice(t)
+
+	writeKeys(t, filepath.Join(laptop.home, "code", "api"), "DOTENV_PRIVATE_KEY_PRODUCTION=aaa\nOTHER=x\n")
+	writeKeys(t, filepath.Join(laptop.home, "code", "node_modules", "pkg"), "DOTENV_PRIVATE_KEY=ignored\n")
+	got := laptop.sync(t, store, key, PreferNone)
+	if got["code/api DOTENV_PRIVATE_KEY_PRODUCTION"] != Uploaded || len(got) != 1 {
+		t.Fatalf("first sync = %v", got)
+	}
+	data, _ := os.ReadFile(store.path)
+	if strings.Contains(string(data), "aaa") {
+		t.Fatal("key stored in plaintext")
+	}
+
+	// A new device with the project cloned gets the key; one without the
+	// other project's directory skips it.
+	if err := os.MkdirAll(filepath.Join(desktop.home, "code", "api"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	writeKeys(t, filepath.Join(laptop.home, "code", "web"), "DOTENV_PRIVATE_KEY=www\n")
+	laptop.sync(t, store, key, PreferNone)
+	got = desktop.sync(t, store, key, PreferNone)
+	if got["code/api DOTENV_PRIVATE_KEY_PRODUCTION"] != Downloaded || got["code/web DOTENV_PRIVATE_KEY"] != Skipped {
+		t.Fatalf("desktop sync = %v", got)
+	}
+	if keys := readKeys(t, filepath.Join(desktop.home, "code", "api")); keys != "DOTENV_PRIVATE_KEY_PRODUCTION=aaa\n" {
+		t.Fatalf("desktop .env.keys = %q", keys)
+	}
+
+	// A rotation on one device reaches the other.
+	writeKeys(t, filepath.Join(desktop.home, "code", "api"), "DOTENV_PRIVATE_KEY_PRODUCTION=bbb\n")
+	if got = desktop.sync(t, store, key, PreferNone); got["code/api DOTENV_PRIVATE_KEY_PRODUCTION"] != Uploaded {
+		t.Fatalf("rotation upload = %v", got)
+	}
+	if got = laptop.sync(t, store, key, PreferNone); got["code/api DOTENV_PRIVATE_KEY_PRODUCTION"] != Downloaded {
+		t.Fatalf("rotation download = %v", got)
+	}
+	if keys := readKeys(t, filepath.Join(laptop.home, "code", "api")); keys != "DOTENV_PRIVATE_KEY_PRODUCTION=bbb\nOTHER=x\n" {
+		t.Fatalf("laptop .env.keys = %q", keys)
+	}
+
+	// Changed on both: a conflict until a side is preferred.
+	writeKeys(t, filepath.Join(laptop.home, "code", "api"), "DOTENV_PRIVATE_KEY_PRODUCTION=ccc\n")
+	writeKeys(t, filepath.Join(desktop.home, "code", "api"), "DOTENV_PRIVATE_KEY_PRODUCTION=ddd\n")
+	desktop.sync(t, store, key, PreferNone)
+	if got = laptop.sync(t, store, key, PreferNone); got["code/api DOTENV_PRIVATE_KEY_PRODUCTION"] != Conflict {
+		t.Fatalf("conflict = %v", got)
+	}
+	if keys := readKeys(t, filepath.Join(laptop.home, "code", "api")); keys != "DOTENV_PRIVATE_KEY_PRODUCTION=ccc\n" {
+		t.Fatalf("conflicting key overwritten: %q", keys)
+	}
+	if got = laptop.sync(t, store, key, PreferRemote); got["code/api DOTENV_PRIVATE_KEY_PRODUCTION"] != Downloaded {
+		t.Fatalf("prefer remote = %v", got)
+	}
+	if keys := readKeys(t, filepath.Join(laptop.home, "code", "api")); keys != "DOTENV_PRIVATE_KEY_PRODUCTION=ddd\n" {
+		t.Fatalf("after prefer remote .env.keys = %q", keys)
+	}
+
+	// Another sync code cannot read the bundle.
+	other, _ := NewKey()
+	s := &Syncer{Store: store, Key: other, StatePath: filepath.Join(t.TempDir(), "s.json")}
+	if _, err := s.Sync(context.Background(), nil); !errors.Is(err, ErrWrongKey) {
+		t.Fatalf("sync with another code = %v, want ErrWrongKey", err)
+	}
+}
+
+func TestOpenStore(t *testing.T) {
+	dir := t.TempDir()
+	for _, tc := range []struct {
+		location, want string
+	}{
+		{dir, filepath.Join(dir, bundleName)},
+		{"file://" + filepath.ToSlash(dir) + "/keys.sync", filepath.Join(dir, "keys.sync")},
+		{"s3://bucket/envdrift/keys", "s3://bucket/envdrift/keys"},
+		{"https://dav.example.com/me/", "https://dav.example.com/me/" + bundleName},
+	} {
+		store, err := OpenStore(tc.location)
+		if err != nil {
+			t.Fatalf("OpenStore(%q): %v", tc.location, err)
+		}
+		if store.String() != tc.want {
+			t.Errorf("OpenStore(%q) = %s, want %s", tc.location, store, tc.want)
+		}
+	}
+	for _, bad := range []string{"", "relative/path", "http://dav.example.com/x", "https://me:pw@dav.example.com/x", "s3://bucket"} {
+		if _, err := OpenStore(bad); err == nil {
+			t.Errorf("OpenStore(%q) accepted", bad)
+		}
+	}
+}
+
+func TestWebDAVStore(t *testing.T) {
+	var mu sync.Mutex
+	var stored []byte
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "me" || pass != "pw" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Method {
+		case http.MethodGet:
+			if stored == nil {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write(stored)
+		case http.MethodPut:
+			stored, _ = io.ReadAll(r.Body)
+			w.WriteHeader(http.StatusCreated)
+		}
+	}))
+	defer srv.Close()
+	old := httpClient
+	httpClient = srv.Client()
+	defer func() { httpClient = old }()
+	t.Setenv("ENVDRIFT_KEYSYNC_USER", "me")
+	t.Setenv("ENVDRIFT_KEYSYNC_PASSWORD", "pw")
+
+	store, err := OpenStore(srv.URL + "/keys")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if _, err := store.Get(ctx); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get before Put = %v, want ErrNotFound", err)
+	}
+	if err := store.Put(ctx, []byte("sealed")); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := store.Get(ctx); err != nil || string(got) != "sealed" {
+		t.Fatalf("Get = %q, %v", got, err)
+	}
+}
+
+func TestS3Store(t *testing.T) {
+	var objects = map[string][]byte{}
+	old := runAWS
+	runAWS = func(_ context.Context, stdin []byte, args ...string) ([]byte, []byte, error) {
+		src, dst := args[len(args)-2], args[len(args)-1]
+		if dst == "-" {
+			data, ok := objects[src]
+			if !ok {
+				return nil, []byte("fatal error: An error occurred (404) when calling the HeadObject operation: Key \"x\" does not exist"), errors.New("exit status 1")
+			}
+			return data, nil, nil
+		}
+		objects[dst] = stdin
+		return nil, nil, nil
+	}
+	defer func() { runAWS = old }()
+
+	store, err := OpenStore("s3://bucket/keys")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if _, err := store.Get(ctx); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get before Put = %v, want ErrNotFound", err)
+	}
+	if err := store.Put(ctx, []byte("sealed")); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := store.Get(ctx); err != nil || string(got) != "sealed" {
+		t.Fatalf("Get = %q, %v", got, err)
+	}
+}
@@ -0,0 +1,241 @@
+package keysync
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned by Store.Get when nothing has been synced yet.
+var ErrNotFound = errors.New("no key bundle in the sync store yet")
+
+// Store holds the sealed key bundle. It only ever sees ciphertext.
+type Store interface {
+	// Get returns the stored bundle, or ErrNotFound.
+	Get(ctx context.Context) ([]byte, error)
+	// Put replaces the stored bundle.
+	Put(ctx context.Context, data []byte) error
+	// String names the location for messages.
+	String() string
+}
+
+// bundleName is the file name used when a location names a directory.
+const bundleName = "envdrift-keys.sync"
+
+// OpenStore returns the store at location:
+//
+//   - a path or file:// URL: a file, typically in a folder a sync client
+//     (Dropbox, iCloud Drive, Syncthing) replicates; a directory gets
+//     envdrift-keys.sync inside it
+//   - s3://bucket/key: an S3 object, through the aws CLI and its credential
+//     chain
+//   - https://...: a WebDAV resource (Nextcloud, ownCloud, a NAS), with the
+//     basic-auth credentials in ENVDRIFT_KEYSYNC_USER and
+//     ENVDRIFT_KEYSYNC_PASSWORD
+func OpenStore(location string) (Store, error) {
+	switch {
+	case location == "":
+		return nil, errors.New("no sync store configured: set [keys] sync_store or pass --store")
+	case strings.HasPrefix(location, "s3://"):
+		u, err := url.Parse(location)
+		if err != nil || u.Host == "" || strings.Trim(u.Path, "/") == "" {
+			return nil, fmt.Errorf("sync store %q: want s3://bucket/key", location)
+		}
+		return s3Store{uri: location}, nil
+	case strings.HasPrefix(location, "https://"):
+		u, err := url.Parse(location)
+		if err != nil || u.Host == "" {
+			return nil, fmt.Errorf("sync store %q: not a URL", location)
+		}
+		if u.User != nil {
+			return nil, fmt.Errorf("sync store %q: put WebDAV credentials in ENVDRIFT_KEYSYNC_USER and ENVDRIFT_KEYSYNC_PASSWORD, not the URL", u.Redacted())
+		}
+		if strings.HasSuffix(u.Path, "/") {
+			u.Path += bundleName
+		}
+		return webdavStore{url: u.String()}, nil
+	case strings.HasPrefix(location, "http://"):
+		return nil, fmt.Errorf("sync store %q: WebDAV needs https, the credentials would travel in the clear", location)
+	case strings.HasPrefix(location, "file://"):
+		u, err := url.Parse(location)
+		if err != nil || u.Path == "" {
+			return nil, fmt.Errorf("sync store %q: not a file URL", location)
+		}
+		path := u.Path
+		if len(path) > 2 && path[0] == '/' && path[2] == ':' {
+			path = path[1:] // file:///C:/Users/...
+		}
+		return newFileStore(filepath.FromSlash(path)), nil
+	}
+	path := location
+	if rest, ok := strings.CutPrefix(path, "~"); ok && (rest == "" || rest[0] == '/' || rest[0] == filepath.Separator) {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		path = filepath.Join(home, rest)
+	}
+	if !filepath.IsAbs(path) {
+		return nil, fmt.Errorf("sync store %q: want an absolute path, file://, s3:// or https:// location", location)
+	}
+	return newFileStore(path), nil
+}
+
+// fileStore keeps the bundle in a local file.
+type fileStore struct {
+	path string
+}
+
+func newFileStore(path string) fileStore {
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		path = filepath.Join(path, bundleName)
+	}
+	return fileStore{path: path}
+}
+
+func (s fileStore) String() string { return s.path }
+
+func (s fileStore) Get(context.Context) ([]byte, error) {
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	return data, err
+}
+
+// Put writes through a temporary file and a rename, so a sync client never
+// replicates half a bundle.
+func (s fileStore) Put(_ context.Context, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), "."+filepath.Base(s.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+// runAWS runs the aws CLI with optional stdin; a seam for tests.
+var runAWS = func(ctx context.Context, stdin []byte, args ...string) (stdout, stderr []byte, err error) {
+	cmd := exec.CommandContext(ctx, "aws", args...)
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+	var out, errOut bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &errOut
+	err = cmd.Run()
+	return out.Bytes(), errOut.Bytes(), err
+}
+
+// s3Store keeps the bundle in an S3 object. Going through the aws CLI gives
+// it the user's full credential chain, as the AWS vault provider does.
+type s3Store struct {
+	uri string
+}
+
+func (s s3Store) String() string { return s.uri }
+
+func (s s3Store) Get(ctx context.Context) ([]byte, error) {
+	out, errOut, err := runAWS(ctx, nil, "s3", "cp", "--only-show-errors", s.uri, "-")
+	if err != nil {
+		msg := string(errOut)
+		if strings.Contains(msg, "(404)") || strings.Contains(msg, "NoSuchKey") || strings.Contains(msg, "does not exist") {
+			return nil, ErrNotFound
+		}
+		return nil, awsError(err, errOut)
+	}
+	return out, nil
+}
+
+func (s s3Store) Put(ctx context.Context, data []byte) error {
+	_, errOut, err := runAWS(ctx, data, "s3", "cp", "--only-show-errors", "-", s.uri)
+	if err != nil {
+		return awsError(err, errOut)
+	}
+	return nil
+}
+
+func awsError(err error, stderr []byte) error {
+	if errors.Is(err, exec.ErrNotFound) {
+		return errors.New("aws: the AWS CLI is not installed or not on PATH")
+	}
+	if msg := strings.TrimSpace(string(stderr)); msg != "" {
+		return fmt.Errorf("aws: %s", msg)
+	}
+	return fmt.Errorf("aws: %w", err)
+}
+
+// httpClient talks to WebDAV servers; tests point it at a fake server.
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+// webdavStore keeps the bundle as a WebDAV resource. Plain GET and PUT are
+// all it needs, so any server that accepts uploads works.
+type webdavStore struct {
+	url string
+}
+
+func (s webdavStore) String() string { return s.url }
+
+func (s webdavStore) Get(ctx context.Context) ([]byte, error) {
+	resp, err := s.do(ctx, http.MethodGet, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("webdav: GET %s: %s", s.url, resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 16<<20))
+}
+
+func (s webdavStore) Put(ctx context.Context, data []byte) error {
+	resp, err := s.do(ctx, http.MethodPut, data)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("webdav: PUT %s: %s", s.url, resp.Status)
+	}
+	return nil
+}
+
+func (s webdavStore) do(ctx context.Context, method string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, s.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if user := os.Getenv("ENVDRIFT_KEYSYNC_USER"); user != "" {
+		req.SetBasicAuth(user, os.Getenv("ENVDRIFT_KEYSYNC_PASSWORD"))
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/octet-stream")
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("webdav: %w", err)
+	}
+	return resp, nil
+}
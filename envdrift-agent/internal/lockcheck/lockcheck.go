@@ -8,10 +8,13 @@ import (
 	"log"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"runtime"
 	"strconv"
 	"strings"
 	"sync"
+
+	"github.com/jainal09/envdrift-agent/internal/longpath"
 )
 
 // lsofMissingOnce ensures the "lsof unavailable" warning is logged at most once.
@@ -21,18 +24,56 @@ var lsofMissingOnce sync.Once
 // the caller cannot tell whether the file is open and must assume it is.
 var errLockToolUnavailable = errors.New("lock-detection tool unavailable")
 
+// errSharingViolation marks a Windows exclusive open refused because another
+// process has the file open.
+var errSharingViolation = errors.New("file is open in another process")
+
 // openPIDs lists the PIDs of processes that currently hold a file open. It is
 // a package-level seam so tests can inject a fake process lister on every
-// platform; production code uses lsofOpenPIDs.
-var openPIDs = lsofOpenPIDs
+// platform; production code uses lsofOpenPIDs, fstatOpenPIDs on the BSDs, and
+// linuxOpenPIDs on Linux.
+var openPIDs = platformOpenPIDs()
+
+// platformOpenPIDs picks the process lister for the running OS: the BSDs ship
+// fstat(1) in the base system, while lsof is an optional package there, and
+// Linux can read /proc when lsof is missing.
+func platformOpenPIDs() func(string) ([]int, error) {
+	switch {
+	case isBSD(runtime.GOOS):
+		return fstatOpenPIDs
+	case runtime.GOOS == "linux":
+		return linuxOpenPIDs
+	}
+	return lsofOpenPIDs
+}
+
+// lockTool names the process lister in log messages.
+func lockTool() string {
+	switch {
+	case isBSD(runtime.GOOS):
+		return "fstat"
+	case runtime.GOOS == "linux":
+		return "lsof or /proc"
+	}
+	return "lsof"
+}
+
+// isBSD reports whether goos is one of the BSDs.
+func isBSD(goos string) bool {
+	switch goos {
+	case "freebsd", "openbsd", "netbsd", "dragonfly":
+		return true
+	}
+	return false
+}
 
 // IsFileOpen checks if a file is currently open by any process.
 // IsFileOpen reports whether the file at path is currently open by any process.
-// On Darwin and Linux it checks via lsof; on Windows it uses handle.exe with a PowerShell fallback.
+// On Darwin and Linux it checks via lsof, on the BSDs via fstat; on Windows it uses handle.exe with an exclusive-open fallback.
 // It returns true if the file is open, and false if the file is not open, the check cannot be performed, or the platform is unsupported.
 func IsFileOpen(path string) bool {
 	switch runtime.GOOS {
-	case "darwin", "linux":
+	case "darwin", "linux", "freebsd", "openbsd", "netbsd", "dragonfly":
 		return isFileOpenUnix(path)
 	case "windows":
 		return isFileOpenWindows(path)
@@ -68,12 +109,12 @@ func lsofErrorMeansOpen(err error, path string) bool {
 	if errors.Is(err, errLockToolUnavailable) {
 		// lsof binary missing (or not executable): cannot tell, warn once.
 		lsofMissingOnce.Do(func() {
-			log.Printf("lockcheck: lsof unavailable (%v); treating files as open to avoid encrypting in-use files", err)
+			log.Printf("lockcheck: %s unavailable (%v); treating files as open to avoid encrypting in-use files", lockTool(), err)
 		})
 		return true
 	}
 	// Any other error (signal, timeout, unexpected exit code) is ambiguous.
-	log.Printf("lockcheck: lsof failed for %s (%v); treating file as open", path, err)
+	log.Printf("lockcheck: %s failed for %s (%v); treating file as open", lockTool(), path, err)
 	return true
 }
 
@@ -117,6 +158,114 @@ func lsofOpenPIDs(path string) ([]int, error) {
 	return parsePIDs(stdout.String()), nil
 }
 
+// linuxOpenPIDs uses lsof when a real one is installed and scans /proc
+// otherwise. Alpine and most container images have no lsof, or only
+// BusyBox's, which ignores -t and the path and lists every open file, so
+// trusting it would report every file as open.
+func linuxOpenPIDs(path string) ([]int, error) {
+	if lsofUsable() {
+		pids, err := lsofOpenPIDs(path)
+		if !errors.Is(err, errLockToolUnavailable) {
+			return pids, err
+		}
+	}
+	return procOpenPIDs(path)
+}
+
+// lsofUsable reports whether lsof on PATH is a real lsof, not a BusyBox
+// applet linked to the busybox binary.
+func lsofUsable() bool {
+	bin, err := exec.LookPath("lsof")
+	if err != nil {
+		return false
+	}
+	if resolved, err := filepath.EvalSymlinks(bin); err == nil {
+		bin = resolved
+	}
+	return !strings.HasPrefix(filepath.Base(bin), "busybox")
+}
+
+// procRoot is the proc filesystem procOpenPIDs reads; a seam for tests.
+var procRoot = "/proc"
+
+// procOpenPIDs returns the PIDs of processes with a descriptor on path by
+// reading the /proc/<pid>/fd links, as lsof does. Like an unprivileged lsof it
+// sees only the processes it may inspect, normally the user's own. An
+// unreadable /proc is reported as errLockToolUnavailable.
+func procOpenPIDs(path string) ([]int, error) {
+	target, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	if resolved, err := filepath.EvalSymlinks(target); err == nil {
+		target = resolved
+	}
+	entries, err := os.ReadDir(procRoot)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errLockToolUnavailable, err)
+	}
+	var pids []int
+	for _, e := range entries {
+		pid, err := strconv.Atoi(e.Name())
+		if err != nil {
+			continue
+		}
+		fdDir := filepath.Join(procRoot, e.Name(), "fd")
+		fds, err := os.ReadDir(fdDir)
+		if err != nil {
+			continue // another user's process, or it exited
+		}
+		for _, fd := range fds {
+			if link, err := os.Readlink(filepath.Join(fdDir, fd.Name())); err == nil && link == target {
+				pids = append(pids, pid)
+				break
+			}
+		}
+	}
+	return pids, nil
+}
+
+// fstatOpenPIDs returns the PIDs of processes that hold path open, via
+// `fstat -- <path>`: a header line, then one line per open descriptor with the
+// PID in the third column (USER CMD PID FD ...) on FreeBSD, OpenBSD, NetBSD
+// and DragonFly alike. Only the header means nobody has it open. Error
+// handling matches lsofOpenPIDs, except that fstat exits 0 either way, so any
+// exit error is ambiguous.
+func fstatOpenPIDs(path string) ([]int, error) {
+	cmd := exec.Command("fstat", "--", path)
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+
+	if err := cmd.Run(); err != nil {
+		var execErr *exec.Error
+		if errors.As(err, &execErr) {
+			return nil, fmt.Errorf("%w: %v", errLockToolUnavailable, execErr)
+		}
+		return nil, err
+	}
+	return parseFstat(stdout.String()), nil
+}
+
+// parseFstat extracts the PID column from fstat output, skipping the header.
+// Like parsePIDs, an unparsable PID is recorded as -1.
+func parseFstat(output string) []int {
+	var pids []int
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || fields[0] == "USER" {
+			continue
+		}
+		pid := -1
+		if len(fields) >= 3 {
+			if n, err := strconv.Atoi(fields[2]); err == nil {
+				pid = n
+			}
+		}
+		pids = append(pids, pid)
+	}
+	return pids
+}
+
 // parsePIDs parses `lsof -t` output (one PID per line) into a PID slice. A
 // line that is not a valid PID is recorded as -1: we cannot prove it is the
 // agent's own PID, so it conservatively counts as another process.
@@ -140,17 +289,26 @@ func parsePIDs(output string) []int {
 // isFileOpenWindows uses handle.exe to check if file is open
 // isFileOpenWindows reports whether the file at path is open by any process on Windows.
 // It uses `handle.exe -nobanner` when available; if `handle.exe` is unavailable or returns an error,
-// it falls back to a PowerShell-based exclusive-open check.
+// it falls back to an exclusive-open check.
+//
+// Files on network shares skip handle.exe: it only sees local processes, so a
+// file held open from another machine would look closed, while the exclusive
+// open fails with a sharing violation across SMB.
 func isFileOpenWindows(path string) bool {
-	// First try handle.exe (Sysinternals)
-	cmd := exec.Command("handle.exe", "-nobanner", path)
+	if longpath.IsUNC(path) {
+		return isFileOpenWindowsExclusive(path)
+	}
+
+	// First try handle.exe (Sysinternals); it matches names as strings, so
+	// it gets the plain spelling even past MAX_PATH.
+	cmd := exec.Command(handleTool(runtime.GOARCH), "-nobanner", longpath.Strip(path))
 	var stdout bytes.Buffer
 	cmd.Stdout = &stdout
 
 	err := cmd.Run()
 	if err != nil {
-		// handle.exe not available or error, try PowerShell fallback
-		return isFileOpenWindowsPowerShell(path)
+		// handle.exe not available or error: fall back to an exclusive open
+		return isFileOpenWindowsExclusive(path)
 	}
 
 	output := strings.TrimSpace(stdout.String())
@@ -158,38 +316,54 @@ func isFileOpenWindows(path string) bool {
 	return !strings.Contains(output, "No matching handles found")
 }
 
-// isFileOpenWindowsPowerShell attempts to determine whether the file at path is open by another process using a PowerShell-based exclusive open attempt.
-// It returns true if the open attempt fails (indicating the file is locked), false otherwise.
-func isFileOpenWindowsPowerShell(path string) bool {
-	// Use PowerShell with proper argument escaping
-	cmd := exec.Command("powershell", "-NoProfile", "-Command",
-		"try { $fs = [System.IO.File]::Open($args[0], 'Open', 'ReadWrite', 'None'); $fs.Close(); exit 0 } catch { exit 1 }",
-		path)
-	err := cmd.Run()
-	return err != nil // Error means file is locked
+// handleTool returns the handle.exe build for goarch. On ARM64 the x86
+// handle.exe runs emulated but cannot load its kernel driver, so the native
+// handle64a.exe from the same Sysinternals download is used when on PATH.
+func handleTool(goarch string) string {
+	if goarch == "arm64" {
+		if _, err := exec.LookPath("handle64a.exe"); err == nil {
+			return "handle64a.exe"
+		}
+	}
+	return "handle.exe"
+}
+
+// isFileOpenWindowsExclusive reports whether the file at path is held open by
+// another process, by trying to open it with no sharing allowed
+// (exclusiveOpen). A sharing or lock violation means it is open; any other
+// failure except a missing file is ambiguous and treated as open, like an lsof
+// error.
+//
+// This replaced a PowerShell [System.IO.File]::Open script that received the
+// path as command text: arguments after -Command are parsed as more script,
+// so a file named `'; Remove-Item ...` ran as code under the user's account.
+// The Win32 call takes the path as data and starts no process.
+func isFileOpenWindowsExclusive(path string) bool {
+	err := exclusiveOpen(longpath.Extended(path))
+	if err == nil || errors.Is(err, os.ErrNotExist) {
+		return false
+	}
+	if !errors.Is(err, errSharingViolation) {
+		log.Printf("lockcheck: exclusive open of %s failed (%v); treating file as open", path, err)
+	}
+	return true
 }
 
 // GetOpenProcesses returns list of processes that have the file open.
 // GetOpenProcesses returns the process IDs of processes that have the specified file open.
-// It runs `lsof -t -- <path>` on Darwin and Linux and returns a slice of PID strings.
-// Returns nil on non-Darwin/Linux platforms, if `lsof` fails, or if no processes are found.
+// It runs `lsof -t -- <path>` on Darwin and Linux (fstat on the BSDs, /proc on Linux without lsof) and returns a slice of PID strings.
+// Returns nil on other platforms, if the lister fails, or if no processes are found.
 func GetOpenProcesses(path string) []string {
-	if runtime.GOOS != "darwin" && runtime.GOOS != "linux" {
+	if runtime.GOOS != "darwin" && runtime.GOOS != "linux" && !isBSD(runtime.GOOS) {
 		return nil
 	}
-
-	cmd := exec.Command("lsof", "-t", "--", path)
-	var stdout bytes.Buffer
-	cmd.Stdout = &stdout
-
-	if err := cmd.Run(); err != nil {
+	pids, err := platformOpenPIDs()(path)
+	if err != nil || len(pids) == 0 {
 		return nil
 	}
-
-	output := strings.TrimSpace(stdout.String())
-	if output == "" {
-		return nil
+	out := make([]string, len(pids))
+	for i, pid := range pids {
+		out[i] = strconv.Itoa(pid)
 	}
-
-	return strings.Split(output, "\n")
+	return out
 }
@@ -0,0 +1,13 @@
+//go:build !windows
+
+package lockcheck
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// exclusiveOpen is Windows-only: Unix has no mandatory share modes.
+func exclusiveOpen(string) error {
+	return fmt.Errorf("exclusive open is not supported on %s", runtime.GOOS)
+}
@@ -105,6 +105,11 @@ func TestIsFileOpenUnixMissingLsof(t *testing.T) {
 		t.Skip("lsof still resolvable despite cleared PATH; cannot exercise missing-binary path")
 	}
 
+	// Linux falls back to /proc; take that away too.
+	origProc := procRoot
+	procRoot = filepath.Join(emptyDir, "no-proc")
+	t.Cleanup(func() { procRoot = origProc })
+
 	tempFile := filepath.Join(emptyDir, ".env.test")
 	if err := os.WriteFile(tempFile, []byte("X=1\n"), 0o644); err != nil {
 		t.Fatalf("write temp file: %v", err)
@@ -195,6 +200,67 @@ func TestIsFileOpenUnixListerErrors(t *testing.T) {
 	}
 }
 
+// TestLinuxOpenPIDsWithoutLsof: with lsof missing, or only BusyBox's applet,
+// which lists every open file whatever it is asked, the /proc scan still
+// finds this process holding the file and nothing else.
+func TestLinuxOpenPIDsWithoutLsof(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("/proc scan is Linux-only")
+	}
+	dir := t.TempDir()
+	path := filepath.Join(dir, ".env")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	closed := filepath.Join(dir, ".env.closed")
+	if err := os.WriteFile(closed, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	bin := t.TempDir()
+	busybox := filepath.Join(bin, "busybox")
+	if err := os.WriteFile(busybox, []byte("#!/bin/sh\necho '1\tbash\t/dev/tty'\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		name  string
+		setup func() error
+	}{
+		{"missing", func() error { return nil }},
+		{"busybox", func() error { return os.Symlink(busybox, filepath.Join(bin, "lsof")) }},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.setup(); err != nil {
+				t.Fatal(err)
+			}
+			t.Setenv("PATH", bin)
+			if lsofUsable() {
+				t.Fatal("lsofUsable() = true, want false")
+			}
+			pids, err := linuxOpenPIDs(path)
+			if err != nil || !slices.Equal(pids, []int{os.Getpid()}) {
+				t.Errorf("linuxOpenPIDs(open) = %v, %v; want [%d]", pids, err, os.Getpid())
+			}
+			if pids, err := linuxOpenPIDs(closed); err != nil || len(pids) != 0 {
+				t.Errorf("linuxOpenPIDs(closed) = %v, %v; want none", pids, err)
+			}
+		})
+	}
+}
+
+func TestHandleTool(t *testing.T) {
+	if got := handleTool("amd64"); got != "handle.exe" {
+		t.Errorf("handleTool(amd64) = %q", got)
+	}
+	bin := t.TempDir()
+	t.Setenv("PATH", bin)
+	if got := handleTool("arm64"); got != "handle.exe" {
+		t.Errorf("handleTool(arm64) without handle64a = %q, want handle.exe", got)
+	}
+}
+
 func TestParsePIDs(t *testing.T) {
 	cases := []struct {
 		name   string
@@ -216,6 +282,31 @@ func TestParsePIDs(t *testing.T) {
 	}
 }
 
+func TestParseFstat(t *testing.T) {
+	cases := []struct {
+		name   string
+		output string
+		want   []int
+	}{
+		{"header only", "USER     CMD          PID   FD MOUNT      INUM MODE         SZ|DV R/W NAME\n", nil},
+		{"freebsd", "USER     CMD          PID   FD MOUNT      INUM MODE         SZ|DV R/W NAME\n" +
+			"me       vim         4242    5 /home    123456 -rw-r--r--     120  rw /home/me/app/.env\n" +
+			"me       envdrift-a   917   12 /home    123456 -rw-r--r--     120  r  /home/me/app/.env\n",
+			[]int{4242, 917}},
+		{"openbsd", "USER     CMD          PID   FD MOUNT        INUM  MODE         R/W    SZ|DV NAME\n" +
+			"me       nvim       31337    4 /home      829451  -rw-r--r--    rw      120 /home/me/app/.env\n",
+			[]int{31337}},
+		{"short line becomes -1", "USER CMD PID\nme vim\n", []int{-1}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := parseFstat(tc.output); !slices.Equal(got, tc.want) {
+				t.Errorf("parseFstat(%q) = %v, want %v", tc.output, got, tc.want)
+			}
+		})
+	}
+}
+
 func TestGetOpenProcessesNonexistent(t *testing.T) {
 	if runtime.GOOS == "windows" {
 		t.Skip("GetOpenProcesses not implemented for Windows")
@@ -249,3 +340,79 @@ func TestGetOpenProcessesClosedFile(t *testing.T) {
 		t.Errorf("Expected empty slice for closed file, got %v", processes)
 	}
 }
+
+// hostileNames are env file names that break naive quoting: shell and
+// PowerShell metacharacters, quotes, a leading dash, and non-ASCII.
+var hostileNames = []string{
+	".env with spaces",
+	`.env.it's "quoted"`,
+	".env.$(touch pwned)",
+	".env;rm -rf ~",
+	"-rf.env",
+	".env.zoë.日本語",
+	".env.`whoami`",
+}
+
+// TestLsofHandlesHostileFileNames runs the real lsof on each hostile name
+// while this process holds it open: seeing our own PID proves the path
+// reached lsof intact as one argument.
+func TestLsofHandlesHostileFileNames(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("lsof is Unix-only")
+	}
+	if _, err := exec.LookPath("lsof"); err != nil {
+		t.Skip("lsof not on PATH; cannot exercise the real PID lister")
+	}
+
+	for _, name := range hostileNames {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+			if err := os.WriteFile(path, []byte("A=1\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			f, err := os.Open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+
+			pids, err := lsofOpenPIDs(path)
+			if err != nil || !slices.Contains(pids, os.Getpid()) {
+				t.Errorf("lsofOpenPIDs(%q) = %v, %v; want our own PID %d", path, pids, err, os.Getpid())
+			}
+		})
+	}
+}
+
+// TestExclusiveOpenHostileFileNames exercises the Windows exclusive-open
+// probe on each hostile name: open while this process holds a handle, closed
+// once it is released. No name can change what runs, since no script is
+// involved.
+func TestExclusiveOpenHostileFileNames(t *testing.T) {
+	if runtime.GOOS != "windows" {
+		t.Skip("exclusive open is Windows-only")
+	}
+
+	for _, name := range append(hostileNames, `'; Remove-Item -Recurse $HOME; '`) {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+			if err := os.WriteFile(path, []byte("A=1\n"), 0o644); err != nil {
+				t.Skipf("name not representable on this filesystem: %v", err)
+			}
+			f, err := os.Open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !isFileOpenWindowsExclusive(path) {
+				t.Error("file held open by this process reported closed")
+			}
+			_ = f.Close()
+			if isFileOpenWindowsExclusive(path) {
+				t.Error("closed file reported open")
+			}
+		})
+	}
+	if isFileOpenWindowsExclusive(filepath.Join(t.TempDir(), "missing.env")) {
+		t.Error("missing file reported open")
+	}
+}
@@ -0,0 +1,32 @@
+//go:build windows
+
+package lockcheck
+
+import (
+	"errors"
+	"syscall"
+)
+
+const (
+	errorSharingViolation syscall.Errno = 32
+	errorLockViolation    syscall.Errno = 33
+)
+
+// exclusiveOpen opens path for reading and writing with a share mode of 0,
+// which fails with ERROR_SHARING_VIOLATION while any other handle is open,
+// and closes it again at once.
+func exclusiveOpen(path string) error {
+	p, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return err
+	}
+	h, err := syscall.CreateFile(p, syscall.GENERIC_READ|syscall.GENERIC_WRITE, 0, nil,
+		syscall.OPEN_EXISTING, syscall.FILE_ATTRIBUTE_NORMAL, 0)
+	if err != nil {
+		if errors.Is(err, errorSharingViolation) || errors.Is(err, errorLockViolation) {
+			return errSharingViolation
+		}
+		return err
+	}
+	return syscall.CloseHandle(h)
+}
@@ -9,10 +9,14 @@
 package logging
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
 )
 
 const (
@@ -168,3 +172,38 @@ func (w *RotatingWriter) rotateLocked() error {
 func (w *RotatingWriter) backupPath(i int) string {
 	return fmt.Sprintf("%s.%d", w.path, i)
 }
+
+// PruneBackups removes the rotated files of the log at path (<path>.1,
+// <path>.2, ...) last written before before, and returns how many it
+// removed. The active log is left alone.
+func PruneBackups(path string, before time.Time) (int, error) {
+	dir, prefix := filepath.Dir(path), filepath.Base(path)+"."
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	removed := 0
+	var errs []error
+	for _, e := range entries {
+		n, ok := strings.CutPrefix(e.Name(), prefix)
+		if !ok || e.IsDir() {
+			continue
+		}
+		if _, err := strconv.Atoi(n); err != nil {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil || !info.ModTime().Before(before) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, e.Name())); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		removed++
+	}
+	return removed, errors.Join(errs...)
+}
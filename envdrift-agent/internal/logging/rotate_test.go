@@ -7,6 +7,7 @@ import (
 	"runtime"
 	"strings"
 	"testing"
+	"time"
 )
 
 // TestRotatingWriter_RotatesAtMaxBytes proves the size cap is enforced: once
@@ -235,3 +236,45 @@ func TestRotatingWriter_WriteAfterCloseFails(t *testing.T) {
 		t.Error("Write after Close must fail")
 	}
 }
+
+// TestPruneBackups removes the rotated files last written before the cutoff
+// and leaves the active log and unrelated files alone.
+func TestPruneBackups(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "agent.log")
+	old := time.Now().Add(-30 * 24 * time.Hour)
+	for name, mtime := range map[string]time.Time{
+		"agent.log":     old,
+		"agent.log.1":   time.Now(),
+		"agent.log.2":   old,
+		"agent.log.3":   old,
+		"agent.log.bak": old,
+	} {
+		p := filepath.Join(dir, name)
+		if err := os.WriteFile(p, []byte("x\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(p, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	n, err := PruneBackups(path, time.Now().Add(-14*24*time.Hour))
+	if err != nil || n != 2 {
+		t.Fatalf("PruneBackups = %d, %v; want 2", n, err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	if got := strings.Join(names, " "); got != "agent.log agent.log.1 agent.log.bak" {
+		t.Errorf("files left = %s", got)
+	}
+	if n, err := PruneBackups(filepath.Join(dir, "missing", "agent.log"), time.Now()); err != nil || n != 0 {
+		t.Errorf("PruneBackups of a missing directory = %d, %v", n, err)
+	}
+}
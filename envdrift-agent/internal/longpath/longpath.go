@@ -0,0 +1,115 @@
+// Package longpath adapts Windows paths for the APIs and tools that still
+// enforce MAX_PATH (260 characters).
+//
+// Go's os package already adds the \\?\ extended-length prefix to long
+// absolute paths on its own calls, but fsnotify's Win32 calls, child process
+// working directories and file names handed to other programs do not, and
+// monorepos on Windows routinely exceed the limit. Everything here is a no-op
+// on other platforms.
+package longpath
+
+import (
+	"runtime"
+	"strings"
+)
+
+const (
+	// maxPath is MAX_PATH less room for an 8.3 file name, the limit Win32
+	// directory APIs apply (and the threshold Go's own os package uses).
+	maxPath = 248
+	// maxWorkDir is the longest working directory CreateProcess accepts:
+	// MAX_PATH less the trailing backslash and NUL. \\?\ does not lift it.
+	maxWorkDir = 258
+
+	extendedPrefix = `\\?\`
+	uncPrefix      = `\\?\UNC\`
+)
+
+// isWindows selects the Windows behavior; tests flip it to exercise that
+// logic on any platform.
+var isWindows = runtime.GOOS == "windows"
+
+// Extended returns path in extended-length form (\\?\C:\… or
+// \\?\UNC\server\share\…) when it is absolute and too long for the classic
+// Win32 limit; shorter, relative and already-prefixed paths are returned
+// unchanged.
+func Extended(path string) string {
+	if !isWindows || len(path) < maxPath || strings.HasPrefix(path, extendedPrefix) || strings.HasPrefix(path, `\\.\`) {
+		return path
+	}
+	path = strings.ReplaceAll(path, "/", `\`)
+	switch {
+	case IsUNC(path):
+		return uncPrefix + path[2:]
+	case len(path) >= 3 && path[1] == ':' && path[2] == '\\':
+		return extendedPrefix + path
+	default:
+		return path // relative: the prefix only applies to absolute paths
+	}
+}
+
+// Strip undoes Extended, so paths shown to users, logged or compared use one
+// spelling however they were obtained.
+func Strip(path string) string {
+	if !isWindows {
+		return path
+	}
+	switch {
+	case strings.HasPrefix(path, uncPrefix):
+		return `\\` + path[len(uncPrefix):]
+	case strings.HasPrefix(path, extendedPrefix):
+		return path[len(extendedPrefix):]
+	default:
+		return path
+	}
+}
+
+// IsUNC reports whether path names a file on a network share
+// (\\server\share\… or \\?\UNC\…).
+func IsUNC(path string) bool {
+	if !isWindows {
+		return false
+	}
+	if strings.HasPrefix(path, uncPrefix) {
+		return true
+	}
+	return strings.HasPrefix(path, `\\`) && !strings.HasPrefix(path, extendedPrefix) && !strings.HasPrefix(path, `\\.\`)
+}
+
+// WorkDir splits the absolute file path into a working directory and the
+// argument naming the file from it, for running a tool on the file: normally
+// the file's directory and base name. When that directory is too long to be a
+// Windows working directory, the nearest ancestor that fits is used instead
+// and the file is passed as an extended-length absolute path.
+func WorkDir(path string) (dir, arg string) {
+	dir, base := split(path)
+	if !isWindows || len(dir) <= maxWorkDir {
+		return dir, base
+	}
+	for len(dir) > maxWorkDir {
+		parent, _ := split(dir)
+		if parent == dir || parent == "" {
+			break
+		}
+		dir = parent
+	}
+	return dir, Extended(path)
+}
+
+// split is filepath.Split without the trailing separator on dir. It accepts
+// both separators on Windows, whatever platform the tests run on.
+func split(path string) (dir, base string) {
+	seps := "/"
+	if isWindows {
+		seps = `\/`
+	}
+	i := strings.LastIndexAny(path, seps)
+	if i < 0 {
+		return ".", path
+	}
+	dir = path[:i]
+	if dir == "" || (isWindows && len(dir) == 2 && dir[1] == ':') {
+		dir = path[:i+1] // keep the root: "/" or "C:\"
+	}
+	return dir, path[i+1:]
+}
@@ -0,0 +1,87 @@
+package longpath
+
+import (
+	"strings"
+	"testing"
+)
+
+// onWindows runs the rest of the test with the Windows behavior enabled.
+func onWindows(t *testing.T) {
+	t.Helper()
+	orig := isWindows
+	isWindows = true
+	t.Cleanup(func() { isWindows = orig })
+}
+
+func TestExtendedAndStrip(t *testing.T) {
+	onWindows(t)
+	deep := strings.Repeat(`\nested-directory`, 16)
+
+	tests := []struct {
+		path, want string
+	}{
+		{`C:\repo\.env`, `C:\repo\.env`},
+		{`C:\repo` + deep + `\.env`, `\\?\C:\repo` + deep + `\.env`},
+		{`C:/repo` + strings.ReplaceAll(deep, `\`, "/") + `/.env`, `\\?\C:\repo` + deep + `\.env`},
+		{`\\server\share` + deep + `\.env`, `\\?\UNC\server\share` + deep + `\.env`},
+		{`\\?\C:\repo` + deep, `\\?\C:\repo` + deep},
+		{`repo` + deep, `repo` + deep},
+	}
+	for _, tt := range tests {
+		got := Extended(tt.path)
+		if got != tt.want {
+			t.Errorf("Extended(%q) = %q; want %q", tt.path, got, tt.want)
+		}
+		if want := Strip(strings.ReplaceAll(tt.path, "/", `\`)); Strip(got) != want {
+			t.Errorf("Strip(%q) = %q; want %q", got, Strip(got), want)
+		}
+	}
+}
+
+func TestIsUNC(t *testing.T) {
+	onWindows(t)
+	for path, want := range map[string]bool{
+		`\\server\share\.env`:     true,
+		`\\?\UNC\server\share\.e`: true,
+		`\\?\C:\repo\.env`:        false,
+		`C:\repo\.env`:            false,
+	} {
+		if got := IsUNC(path); got != want {
+			t.Errorf("IsUNC(%q) = %v; want %v", path, got, want)
+		}
+	}
+}
+
+func TestWorkDir(t *testing.T) {
+	onWindows(t)
+
+	if dir, arg := WorkDir(`C:\repo\.env`); dir != `C:\repo` || arg != ".env" {
+		t.Errorf("short path: %q, %q", dir, arg)
+	}
+	if dir, arg := WorkDir(`C:\.env`); dir != `C:\` || arg != ".env" {
+		t.Errorf("root file: %q, %q", dir, arg)
+	}
+
+	long := `C:\repo` + strings.Repeat(`\nested-directory`, 20) + `\.env`
+	dir, arg := WorkDir(long)
+	if len(dir) > maxWorkDir || !strings.HasPrefix(long, dir+`\`) {
+		t.Errorf("work dir %q (%d chars) is not an ancestor that fits", dir, len(dir))
+	}
+	if arg != `\\?\`+long {
+		t.Errorf("arg = %q; want the extended-length path", arg)
+	}
+}
+
+func TestNoOpOffWindows(t *testing.T) {
+	orig := isWindows
+	isWindows = false
+	t.Cleanup(func() { isWindows = orig })
+
+	long := "/repo" + strings.Repeat("/nested-directory", 20) + "/.env"
+	if Extended(long) != long || Strip(long) != long || IsUNC("//server/share") {
+		t.Error("non-Windows paths must pass through unchanged")
+	}
+	if dir, arg := WorkDir(long); dir+"/"+arg != long {
+		t.Errorf("WorkDir(%q) = %q, %q", long, dir, arg)
+	}
+}
@@ -0,0 +1,110 @@
+// Package minisign verifies minisign signatures (https://jedisct1.github.io/minisign/):
+// Ed25519 over a file, or over its BLAKE2b-512 hash for the default
+// prehashed signatures, plus the signature over the trusted comment. It is
+// used to check managed policy files against public keys pinned in the local
+// configuration.
+package minisign
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	untrustedPrefix = "untrusted comment:"
+	trustedPrefix   = "trusted comment: "
+)
+
+// ErrUnknownKey means the signature was made by none of the trusted keys.
+var ErrUnknownKey = errors.New("signed by an untrusted key")
+
+// PublicKey is a minisign public key.
+type PublicKey struct {
+	ID  [8]byte
+	Key ed25519.PublicKey
+}
+
+// String returns the key ID as minisign prints it.
+func (k PublicKey) String() string {
+	id := k.ID
+	for i, j := 0, len(id)-1; i < j; i, j = i+1, j-1 {
+		id[i], id[j] = id[j], id[i]
+	}
+	return strings.ToUpper(hex.EncodeToString(id[:]))
+}
+
+// ParsePublicKey parses a public key: the base64 line minisign prints
+// ("RWQ..."), or the whole .pub file.
+func ParsePublicKey(s string) (PublicKey, error) {
+	var k PublicKey
+	raw, err := base64.StdEncoding.DecodeString(payload(s))
+	if err != nil || len(raw) != 42 || string(raw[:2]) != "Ed" {
+		return k, fmt.Errorf("not a minisign public key")
+	}
+	copy(k.ID[:], raw[2:10])
+	k.Key = ed25519.PublicKey(raw[10:])
+	return k, nil
+}
+
+// Verify checks that sig, the contents of a .minisig file, is a valid
+// signature of data by one of keys, trusted comment included.
+func Verify(keys []PublicKey, data, sig []byte) error {
+	lines := strings.Split(strings.ReplaceAll(string(sig), "\r\n", "\n"), "\n")
+	if len(lines) < 4 || !strings.HasPrefix(lines[0], untrustedPrefix) || !strings.HasPrefix(lines[2], trustedPrefix) {
+		return fmt.Errorf("malformed signature file")
+	}
+	raw, err := base64.StdEncoding.DecodeString(lines[1])
+	if err != nil || len(raw) != 74 {
+		return fmt.Errorf("malformed signature")
+	}
+	global, err := base64.StdEncoding.DecodeString(lines[3])
+	if err != nil || len(global) != ed25519.SignatureSize {
+		return fmt.Errorf("malformed trusted comment signature")
+	}
+
+	alg, signature := string(raw[:2]), raw[10:]
+	var key *PublicKey
+	for i := range keys {
+		if bytes.Equal(keys[i].ID[:], raw[2:10]) {
+			key = &keys[i]
+			break
+		}
+	}
+	if key == nil {
+		return ErrUnknownKey
+	}
+
+	switch alg {
+	case "Ed":
+	case "ED":
+		sum := blake2b512(data)
+		data = sum[:]
+	default:
+		return fmt.Errorf("unsupported signature algorithm %q", alg)
+	}
+	if !ed25519.Verify(key.Key, data, signature) {
+		return fmt.Errorf("signature by key %s does not match", key)
+	}
+	comment := strings.TrimPrefix(lines[2], trustedPrefix)
+	if !ed25519.Verify(key.Key, append(append([]byte(nil), signature...), comment...), global) {
+		return fmt.Errorf("trusted comment signature by key %s does not match", key)
+	}
+	return nil
+}
+
+// payload returns the base64 line of a key: s itself, or the line after the
+// untrusted comment of a key file.
+func payload(s string) string {
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, untrustedPrefix) {
+			return line
+		}
+	}
+	return ""
+}
@@ -0,0 +1,100 @@
+package minisign
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestBlake2b512(t *testing.T) {
+	tests := map[string]string{
+		// RFC 7693 appendix A and the empty message.
+		"":    "786a02f742015903c6c6fd852552d272912f4740e15847618a86e217f71f5419d25e1031afee585313896444934eb04b903a685b1448b755d56f701afe9be2ce",
+		"abc": "ba80a53f981c4d0d6a2797b69f12f6e94c212f14685ac4b74b12bb6fdbffa2d17d87c5392aab792dc252d5de4533cc9518d38aa8dbf1925ab92386edd4009923",
+		// Block boundaries.
+		string(bytes.Repeat([]byte("x"), 128)):  "082b91ea2e15d1556d2ceefdd5af5d64d31b4e01aff1959724578876293825b236ee8079173a0a38160d7d6685d6bca0bfb62c177b3599b8727d9173e2115b91",
+		string(bytes.Repeat([]byte("x"), 129)):  "362a53bbe2ec08097b2f358a41d0e153aeed4c132af928400872413650e7bf22f9ae428ff73770170bbd95f935e5dd1953c17de8c7264c72d1f99303bf22dfaa",
+		string(bytes.Repeat([]byte("x"), 1000)): "63e36e4569cd772ba719ec50f6627e07ab6764c5ddffc84607abdff7f0083511dd276f3949c66386ca236cd123b5adc4919875d5abb17d17bbabdeabf0db7e9f",
+	}
+	for in, want := range tests {
+		if sum := blake2b512([]byte(in)); hex.EncodeToString(sum[:]) != want {
+			t.Errorf("blake2b512(%d bytes) = %x, want %s", len(in), sum, want)
+		}
+	}
+}
+
+// testKey returns a key pair with a fixed ID and the public key as minisign
+// prints it.
+func testKey(seed byte) (ed25519.PrivateKey, [8]byte, string) {
+	priv := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{seed}, ed25519.SeedSize))
+	id := [8]byte{seed, 1, 2, 3, 4, 5, 6, 7}
+	raw := append(append([]byte("Ed"), id[:]...), priv.Public().(ed25519.PublicKey)...)
+	return priv, id, base64.StdEncoding.EncodeToString(raw)
+}
+
+// sign produces a .minisig file the way minisign does.
+func sign(priv ed25519.PrivateKey, id [8]byte, data []byte, prehash bool, comment string) []byte {
+	alg := "Ed"
+	if prehash {
+		alg = "ED"
+		sum := blake2b512(data)
+		data = sum[:]
+	}
+	sig := ed25519.Sign(priv, data)
+	global := ed25519.Sign(priv, append(append([]byte(nil), sig...), comment...))
+	raw := append(append([]byte(alg), id[:]...), sig...)
+	return []byte(fmt.Sprintf("untrusted comment: signature from minisign secret key\n%s\ntrusted comment: %s\n%s\n",
+		base64.StdEncoding.EncodeToString(raw), comment, base64.StdEncoding.EncodeToString(global)))
+}
+
+func TestVerify(t *testing.T) {
+	priv, id, pub := testKey(7)
+	key, err := ParsePublicKey("untrusted comment: minisign public key\n" + pub + "\n")
+	if err != nil {
+		t.Fatalf("ParsePublicKey: %v", err)
+	}
+	_, _, otherPub := testKey(9)
+	other, err := ParsePublicKey(otherPub)
+	if err != nil {
+		t.Fatalf("ParsePublicKey: %v", err)
+	}
+	data := []byte("[[policies]]\nidle_timeout = \"1m\"\n")
+
+	for _, prehash := range []bool{false, true} {
+		sig := sign(priv, id, data, prehash, "timestamp:1700000000\tfile:policies.toml")
+		if err := Verify([]PublicKey{other, key}, data, sig); err != nil {
+			t.Errorf("prehash=%v: valid signature rejected: %v", prehash, err)
+		}
+		if err := Verify([]PublicKey{key}, append(data, '#'), sig); err == nil {
+			t.Errorf("prehash=%v: tampered file accepted", prehash)
+		}
+		forged := bytes.Replace(sig, []byte("file:policies.toml"), []byte("file:other.toml"), 1)
+		if err := Verify([]PublicKey{key}, data, forged); err == nil {
+			t.Errorf("prehash=%v: tampered trusted comment accepted", prehash)
+		}
+		if err := Verify([]PublicKey{other}, data, sig); !errors.Is(err, ErrUnknownKey) {
+			t.Errorf("prehash=%v: untrusted key error = %v", prehash, err)
+		}
+	}
+	if err := Verify([]PublicKey{key}, data, []byte("not a signature")); err == nil {
+		t.Error("malformed signature accepted")
+	}
+}
+
+func TestParsePublicKey(t *testing.T) {
+	if _, err := ParsePublicKey("RWQnotakey"); err == nil {
+		t.Error("garbage accepted as a public key")
+	}
+	_, _, pub := testKey(7)
+	key, err := ParsePublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := key.String(); got != "0706050403020107" {
+		t.Errorf("key ID = %s", got)
+	}
+}
@@ -0,0 +1,193 @@
+// Package netstate detects the network the machine is on — Wi-Fi SSID, an
+// active VPN, domain membership — for the network conditions of [[policies]].
+// Online tells the guardian whether there is a network at all.
+//
+// Every probe is best effort: a missing tool or unsupported platform yields
+// the zero value (no SSID, no VPN, not joined) rather than an error, so a
+// policy keyed on "vpn = false" errs on the strict side.
+package netstate
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"net"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// probeTimeout bounds each external command.
+const probeTimeout = 5 * time.Second
+
+// State is the detected network.
+type State struct {
+	// SSID is the connected Wi-Fi network; empty on wired or no network.
+	SSID string
+	// VPN reports an up tunnel interface with an IPv4 address.
+	VPN bool
+	// DomainJoined reports membership of an Active Directory (or realmd)
+	// domain.
+	DomainJoined bool
+}
+
+// Seams for tests: the external commands and the interface list.
+var (
+	runCommand = func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		return exec.CommandContext(ctx, name, args...).Output()
+	}
+	interfaces = listInterfaces
+	getenv     = os.Getenv
+)
+
+// Detect probes the current network state.
+func Detect(ctx context.Context) State {
+	return State{
+		SSID:         ssid(ctx),
+		VPN:          vpnUp(),
+		DomainJoined: domainJoined(ctx),
+	}
+}
+
+// output runs a probe command with probeTimeout.
+func output(ctx context.Context, name string, args ...string) string {
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+	out, err := runCommand(ctx, name, args...)
+	if err != nil {
+		return ""
+	}
+	return string(out)
+}
+
+// ssid returns the connected Wi-Fi network name.
+func ssid(ctx context.Context) string {
+	switch runtime.GOOS {
+	case "darwin":
+		// "Current Wi-Fi Network: Corp"
+		out := output(ctx, "networksetup", "-getairportnetwork", "en0")
+		if _, name, ok := strings.Cut(out, "Network: "); ok {
+			return strings.TrimSpace(name)
+		}
+	case "linux":
+		// nmcli -t prints "yes:Corp" for the active network.
+		for _, line := range lines(output(ctx, "nmcli", "-t", "-f", "active,ssid", "dev", "wifi")) {
+			if name, ok := strings.CutPrefix(line, "yes:"); ok {
+				return name
+			}
+		}
+		return strings.TrimSpace(output(ctx, "iwgetid", "-r"))
+	case "windows":
+		// "    SSID                   : Corp" (BSSID lines are skipped).
+		for _, line := range lines(output(ctx, "netsh", "wlan", "show", "interfaces")) {
+			key, value, ok := strings.Cut(line, ":")
+			if ok && strings.TrimSpace(key) == "SSID" {
+				return strings.TrimSpace(value)
+			}
+		}
+	}
+	return ""
+}
+
+// iface is the part of net.Interface vpnUp needs.
+type iface struct {
+	name  string
+	up    bool
+	hasV4 bool
+	// routable reports an address other than loopback or link-local.
+	routable bool
+}
+
+func listInterfaces() []iface {
+	list, err := net.Interfaces()
+	if err != nil {
+		return nil
+	}
+	out := make([]iface, 0, len(list))
+	for _, in := range list {
+		i := iface{name: in.Name, up: in.Flags&net.FlagUp != 0}
+		if addrs, err := in.Addrs(); err == nil {
+			for _, a := range addrs {
+				n, ok := a.(*net.IPNet)
+				if !ok {
+					continue
+				}
+				if n.IP.To4() != nil {
+					i.hasV4 = true
+				}
+				if n.IP.IsGlobalUnicast() {
+					i.routable = true
+				}
+			}
+		}
+		out = append(out, i)
+	}
+	return out
+}
+
+// Online reports whether the machine is connected to a network: an up
+// interface with a routable address (not loopback or link-local). It sends
+// no traffic, so a captive portal or a dead upstream still counts as online.
+func Online() bool {
+	for _, in := range interfaces() {
+		if in.up && in.routable {
+			return true
+		}
+	}
+	return false
+}
+
+// vpnPrefixes are tunnel interface names on Unix; vpnWords are substrings of
+// the adapter names Windows VPN clients create.
+var (
+	vpnPrefixes = []string{"tun", "tap", "utun", "wg", "ppp", "ipsec", "gpd", "tailscale", "zt"}
+	vpnWords    = []string{"vpn", "anyconnect", "wireguard", "tap-windows", "globalprotect", "fortinet", "openvpn", "tailscale", "zscaler"}
+)
+
+// vpnUp reports an up tunnel interface carrying an IPv4 address. macOS keeps
+// several address-less utun interfaces for system services, hence the
+// address requirement.
+func vpnUp() bool {
+	for _, in := range interfaces() {
+		if !in.up || !in.hasV4 {
+			continue
+		}
+		name := strings.ToLower(in.name)
+		for _, p := range vpnPrefixes {
+			if strings.HasPrefix(name, p) {
+				return true
+			}
+		}
+		for _, w := range vpnWords {
+			if strings.Contains(name, w) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// domainJoined reports domain membership: USERDNSDOMAIN on Windows (set for
+// domain logons), dsconfigad on macOS and realmd on Linux.
+func domainJoined(ctx context.Context) bool {
+	switch runtime.GOOS {
+	case "windows":
+		return getenv("USERDNSDOMAIN") != ""
+	case "darwin":
+		return strings.Contains(output(ctx, "dsconfigad", "-show"), "Active Directory Domain")
+	case "linux":
+		return strings.Contains(output(ctx, "realm", "list"), "configured: kerberos-member")
+	}
+	return false
+}
+
+func lines(s string) []string {
+	var out []string
+	sc := bufio.NewScanner(bytes.NewBufferString(s))
+	for sc.Scan() {
+		out = append(out, strings.TrimRight(sc.Text(), "\r"))
+	}
+	return out
+}
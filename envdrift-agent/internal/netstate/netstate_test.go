@@ -0,0 +1,110 @@
+package netstate
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// stubCommands answers probe commands from outputs, keyed by the command
+// line; anything else fails like a missing tool.
+func stubCommands(t *testing.T, outputs map[string]string) {
+	t.Helper()
+	orig := runCommand
+	t.Cleanup(func() { runCommand = orig })
+	runCommand = func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		if out, ok := outputs[strings.Join(append([]string{name}, args...), " ")]; ok {
+			return []byte(out), nil
+		}
+		return nil, errors.New("not found")
+	}
+}
+
+func TestSSID(t *testing.T) {
+	stubCommands(t, map[string]string{
+		"networksetup -getairportnetwork en0": "Current Wi-Fi Network: Corp WiFi\n",
+		"nmcli -t -f active,ssid dev wifi":    "no:Neighbour\nyes:Corp WiFi\n",
+		"netsh wlan show interfaces":          "    Name                   : Wi-Fi\r\n    SSID                   : Corp WiFi\r\n    BSSID                  : aa:bb:cc:dd:ee:ff\r\n",
+	})
+	switch runtime.GOOS {
+	case "darwin", "linux", "windows":
+		if got := ssid(context.Background()); got != "Corp WiFi" {
+			t.Errorf("ssid = %q; want Corp WiFi", got)
+		}
+	}
+
+	stubCommands(t, nil)
+	if got := ssid(context.Background()); got != "" {
+		t.Errorf("ssid without tools = %q; want empty", got)
+	}
+}
+
+func TestVPNUp(t *testing.T) {
+	orig := interfaces
+	t.Cleanup(func() { interfaces = orig })
+
+	tests := []struct {
+		name string
+		list []iface
+		want bool
+	}{
+		{"none", []iface{{name: "en0", up: true, hasV4: true}}, false},
+		{"idle macOS utun", []iface{{name: "utun0", up: true}}, false},
+		{"down wireguard", []iface{{name: "wg0", hasV4: true}}, false},
+		{"wireguard", []iface{{name: "wg0", up: true, hasV4: true}}, true},
+		{"openvpn tun", []iface{{name: "tun0", up: true, hasV4: true}}, true},
+		{"windows anyconnect", []iface{{name: "Cisco AnyConnect Secure Mobility Client Connection", up: true, hasV4: true}}, true},
+	}
+	for _, tt := range tests {
+		interfaces = func() []iface { return tt.list }
+		if got := vpnUp(); got != tt.want {
+			t.Errorf("%s: vpnUp = %v; want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestOnline(t *testing.T) {
+	orig := interfaces
+	t.Cleanup(func() { interfaces = orig })
+
+	tests := []struct {
+		name string
+		list []iface
+		want bool
+	}{
+		{"nothing", nil, false},
+		{"loopback or link-local only", []iface{{name: "lo", up: true, hasV4: true}, {name: "en0", up: true}}, false},
+		{"down", []iface{{name: "en0", hasV4: true, routable: true}}, false},
+		{"wi-fi", []iface{{name: "lo", up: true, hasV4: true}, {name: "en0", up: true, hasV4: true, routable: true}}, true},
+	}
+	for _, tt := range tests {
+		interfaces = func() []iface { return tt.list }
+		if got := Online(); got != tt.want {
+			t.Errorf("%s: Online = %v; want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDomainJoined(t *testing.T) {
+	stubCommands(t, map[string]string{
+		"dsconfigad -show": "Active Directory Forest = corp.example.com\nActive Directory Domain = corp.example.com\n",
+		"realm list":       "corp.example.com\n  type: kerberos\n  configured: kerberos-member\n",
+	})
+	origEnv := getenv
+	t.Cleanup(func() { getenv = origEnv })
+	getenv = func(string) string { return "CORP.EXAMPLE.COM" }
+
+	switch runtime.GOOS {
+	case "darwin", "linux", "windows":
+		if !domainJoined(context.Background()) {
+			t.Error("domainJoined = false on a joined machine")
+		}
+	}
+	stubCommands(t, nil)
+	getenv = func(string) string { return "" }
+	if domainJoined(context.Background()) {
+		t.Error("domainJoined = true without any domain")
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !freebsd && !openbsd && !netbsd && !dragonfly
+
+package notify
+
+import "github.com/gen2brain/beeep"
+
+// desktopNotify shows a desktop notification; beeep handles each platform.
+func desktopNotify(title, message string) error {
+	return beeep.Notify(title, message, "")
+}
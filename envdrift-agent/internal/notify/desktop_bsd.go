@@ -0,0 +1,12 @@
+//go:build freebsd || openbsd || netbsd || dragonfly
+
+package notify
+
+import "os/exec"
+
+// desktopNotify shows a desktop notification with notify-send (libnotify,
+// from ports or packages). beeep is not used here: its D-Bus backend needs
+// cgo on the BSDs, and release binaries are built without it.
+func desktopNotify(title, message string) error {
+	return exec.Command("notify-send", "--app-name=envdrift-agent", title, message).Run()
+}
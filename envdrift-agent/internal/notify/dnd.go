@@ -0,0 +1,128 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/jainal09/envdrift-agent/internal/i18n"
+	"github.com/jainal09/envdrift-agent/internal/output"
+)
+
+// maxHeld caps the notifications held during do-not-disturb; older ones are
+// only counted.
+const maxHeld = 50
+
+// dndProbeTimeout bounds each do-not-disturb probe command.
+const dndProbeTimeout = 3 * time.Second
+
+// Seams for tests: the do-not-disturb probe and its commands, and the clock
+// quiet hours are read from.
+var (
+	dndActive  = DoNotDisturb
+	now        = time.Now
+	runCommand = func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		return exec.CommandContext(ctx, name, args...).Output()
+	}
+)
+
+// held is a desktop notification put off until do-not-disturb or quiet
+// hours end.
+type held struct {
+	title, message string
+}
+
+// DoNotDisturb reports whether the OS is suppressing notifications: a macOS
+// Focus turned on by hand, Windows with toasts turned off (Focus Assist / Do
+// not disturb), or GNOME with banners hidden. Detection is best effort; an
+// unknown state is "off".
+func DoNotDisturb() bool {
+	ctx, cancel := context.WithTimeout(context.Background(), dndProbeTimeout)
+	defer cancel()
+	switch runtime.GOOS {
+	case "darwin":
+		return macFocusActive()
+	case "windows":
+		out, err := runCommand(ctx, "reg", "query",
+			`HKCU\Software\Microsoft\Windows\CurrentVersion\Notifications\Settings`,
+			"/v", "NOC_GLOBAL_SETTING_TOASTS_ENABLED")
+		return err == nil && strings.Contains(string(out), "0x0")
+	case "linux":
+		out, err := runCommand(ctx, "gsettings", "get", "org.gnome.desktop.notifications", "show-banners")
+		return err == nil && strings.TrimSpace(string(out)) == "false"
+	}
+	return false
+}
+
+// macFocusActive reads the Focus assertions macOS keeps for manually enabled
+// Focus modes. A scheduled Focus leaves no assertion and is not detected.
+func macFocusActive() bool {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return false
+	}
+	data, err := os.ReadFile(filepath.Join(home, "Library", "DoNotDisturb", "DB", "Assertions.json"))
+	if err != nil {
+		return false
+	}
+	var doc struct {
+		Data []struct {
+			StoreAssertionRecords []json.RawMessage `json:"storeAssertionRecords"`
+		} `json:"data"`
+	}
+	if json.Unmarshal(data, &doc) != nil {
+		return false
+	}
+	for _, d := range doc.Data {
+		if len(d.StoreAssertionRecords) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// quiet reports whether desktop notifications are held now: during quiet
+// hours, or with HoldDuringDND set while do-not-disturb is on.
+func (n *Notifier) quiet() bool {
+	return n.QuietHours.Contains(now()) || (n.HoldDuringDND && dndActive())
+}
+
+// holdForDND reports whether a desktop notification for event is put off:
+// while quiet, except a failure when FailuresBreakDND is set. A held
+// notification is queued for FlushHeld.
+func (n *Notifier) holdForDND(event, title, message string) bool {
+	if (event == EventFailure && n.FailuresBreakDND) || !n.quiet() {
+		return false
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.heldCount++
+	n.held = append(n.held, held{title, message})
+	if len(n.held) > maxHeld {
+		n.held = n.held[len(n.held)-maxHeld:]
+	}
+	return true
+}
+
+// FlushHeld delivers the notifications held during do-not-disturb or quiet
+// hours once they have ended: a single one as it was, several as one
+// summary. It is cheap while nothing is held, so it can run on every idle
+// check.
+func (n *Notifier) FlushHeld() error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.heldCount == 0 || n.quiet() {
+		return nil
+	}
+	count, last := n.heldCount, n.held[len(n.held)-1]
+	n.held, n.heldCount = nil, 0
+	if count == 1 {
+		return send(last.title, last.message)
+	}
+	return send(output.Text(i18n.T("notify.held.title")), i18n.T("notify.held.body", count, last.message))
+}
@@ -1,52 +1,200 @@
-// Package notify provides desktop notification support.
+// Package notify provides desktop notification support and routes each
+// notification event type to its configured channels (desktop, webhook),
+// holding desktop notifications while the OS is in do-not-disturb and
+// during quiet hours.
 package notify
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
 	"runtime"
+	"sync"
+	"time"
 
-	"github.com/gen2brain/beeep"
+	"github.com/jainal09/envdrift-agent/internal/i18n"
+	"github.com/jainal09/envdrift-agent/internal/output"
+	"github.com/jainal09/envdrift-agent/internal/schedule"
 )
 
+// Event types a route can name.
+const (
+	// EventEncrypted is a file the guardian encrypted.
+	EventEncrypted = "encrypted"
+	// EventFailure is a failed encryption or a crash.
+	EventFailure = "failure"
+	// EventWarning is a condition needing attention, e.g. a vault conflict.
+	EventWarning = "warning"
+	// EventInfo is everything else.
+	EventInfo = "info"
+)
+
+// Channels an event can be routed to.
+const (
+	ChannelDesktop = "desktop"
+	ChannelWebhook = "webhook"
+)
+
+// Routes maps an event type to the channels it is sent to. An event without
+// an entry goes to the desktop; one mapped to no channels is dropped.
+type Routes map[string][]string
+
+// Notifier sends events along its Routes.
+type Notifier struct {
+	Routes Routes
+	// Webhook is the URL the webhook channel POSTs to.
+	Webhook string
+	// HoldDuringDND holds desktop notifications while the OS is in
+	// do-not-disturb and delivers them with FlushHeld once it ends.
+	HoldDuringDND bool
+	// FailuresBreakDND still shows EventFailure during do-not-disturb and
+	// quiet hours.
+	FailuresBreakDND bool
+	// QuietHours holds desktop notifications during its windows, whatever
+	// HoldDuringDND, and delivers them with FlushHeld once they end.
+	QuietHours schedule.Windows
+
+	// mu guards held and heldCount.
+	mu        sync.Mutex
+	held      []held
+	heldCount int
+}
+
+// defaultNotifier sends every event to the desktop; the package-level
+// functions use it.
+var defaultNotifier = &Notifier{}
+
+// webhookTimeout bounds one webhook delivery.
+const webhookTimeout = 5 * time.Second
+
+// Seams for tests: the desktop backend and the webhook client.
+var (
+	send       = sendDesktop
+	httpClient = &http.Client{Timeout: webhookTimeout}
+)
+
+// ValidateChannel reports an error unless name is a known channel.
+func ValidateChannel(name string) error {
+	if name != ChannelDesktop && name != ChannelWebhook {
+		return fmt.Errorf("unknown notification channel %q (want %s or %s)", name, ChannelDesktop, ChannelWebhook)
+	}
+	return nil
+}
+
+// Send delivers title and message to every channel event is routed to,
+// without emoji in plain output mode. A failing channel does not stop the
+// others; their errors are joined.
+func (n *Notifier) Send(event, title, message string) error {
+	title, message = output.Text(title), output.Text(message)
+	channels, ok := n.Routes[event]
+	if !ok {
+		channels = []string{ChannelDesktop}
+	}
+	var errs []error
+	for _, c := range channels {
+		switch c {
+		case ChannelDesktop:
+			if !n.holdForDND(event, title, message) {
+				errs = append(errs, send(title, message))
+			}
+		case ChannelWebhook:
+			errs = append(errs, n.postWebhook(event, title, message))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// webhookPayload is the JSON body POSTed to the webhook channel.
+type webhookPayload struct {
+	Event   string    `json:"event"`
+	Title   string    `json:"title"`
+	Message string    `json:"message"`
+	Time    time.Time `json:"time"`
+}
+
+// postWebhook POSTs the event as JSON to n.Webhook.
+func (n *Notifier) postWebhook(event, title, message string) error {
+	if n.Webhook == "" {
+		return errors.New("notification webhook is not configured")
+	}
+	body, err := json.Marshal(webhookPayload{Event: event, Title: title, Message: message, Time: time.Now()})
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), webhookTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.Webhook, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("notification webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// Encrypted sends an EventEncrypted notification titled "🔐 File Encrypted"
+// naming path. Titles and the message are translated (see i18n).
+func (n *Notifier) Encrypted(path string) error {
+	return n.Send(EventEncrypted, i18n.T("notify.encrypted.title"), i18n.T("notify.encrypted.body", path))
+}
+
+// Warning sends an EventWarning notification titled "⚠️ EnvDrift Warning".
+func (n *Notifier) Warning(message string) error {
+	return n.Send(EventWarning, i18n.T("notify.warning.title"), message)
+}
+
+// Error sends an EventFailure notification titled "❌ EnvDrift Error".
+func (n *Notifier) Error(message string) error {
+	return n.Send(EventFailure, i18n.T("notify.error.title"), message)
+}
+
+// Info sends an EventInfo notification titled "ℹ️ EnvDrift".
+func (n *Notifier) Info(message string) error {
+	return n.Send(EventInfo, i18n.T("notify.info.title"), message)
+}
+
 // Encrypted sends a desktop notification indicating that the specified file was encrypted.
 // The notification title is "🔐 File Encrypted" and the message includes the provided path.
 // It returns an error if the notification could not be dispatched.
 func Encrypted(path string) error {
-	title := "🔐 File Encrypted"
-	message := fmt.Sprintf("Encrypted: %s", path)
-	return send(title, message)
+	return defaultNotifier.Encrypted(path)
 }
 
 // Warning sends a warning desktop notification titled "⚠️ EnvDrift Warning" with the provided message.
 // It returns an error if the notification cannot be delivered.
 func Warning(message string) error {
-	return send("⚠️ EnvDrift Warning", message)
+	return defaultNotifier.Warning(message)
 }
 
+// Error sends an error desktop notification titled "❌ EnvDrift Error" with the provided message.
 // It returns any error encountered while attempting to display the notification.
 func Error(message string) error {
-	return send("❌ EnvDrift Error", message)
+	return defaultNotifier.Error(message)
 }
 
 // Info sends an informational desktop notification with the provided message.
 // It returns an error if the notification could not be delivered.
 func Info(message string) error {
-	return send("ℹ️ EnvDrift", message)
-}
-
-// send dispatches a desktop notification with the given title and message.
-// It returns any error reported by the underlying notification backend.
-func send(title, message string) error {
-	// beeep handles cross-platform notifications
-	return beeep.Notify(title, message, "")
+	return defaultNotifier.Info(message)
 }
 
 // IsSupported reports whether desktop notifications are supported on the current operating system.
-// It returns true for "darwin", "linux", and "windows", and false for other platforms.
+// It returns true for "darwin", "linux", "windows" and the BSDs (through notify-send) when there is a
+// desktop session to show them in, and false for other platforms.
 func IsSupported() bool {
 	switch runtime.GOOS {
-	case "darwin", "linux", "windows":
-		return true
+	case "darwin", "linux", "windows", "freebsd", "openbsd", "netbsd", "dragonfly":
+		return hasDesktopSession()
 	default:
 		return false
 	}
@@ -2,7 +2,19 @@
 package notify
 
 import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/jainal09/envdrift-agent/internal/schedule"
 )
 
 func TestIsSupported(t *testing.T) {
@@ -28,3 +40,229 @@ func TestWarningNotificationNoSend(t *testing.T) {
 	}
 	t.Skip("Skipping to avoid sending actual notifications")
 }
+
+// stubDesktop records desktop notifications instead of showing them.
+func stubDesktop(t *testing.T) *[]string {
+	t.Helper()
+	var titles []string
+	orig := send
+	send = func(title, message string) error {
+		titles = append(titles, title)
+		return nil
+	}
+	t.Cleanup(func() { send = orig })
+	return &titles
+}
+
+func TestNotifierRoutes(t *testing.T) {
+	desktop := stubDesktop(t)
+	var posted []webhookPayload
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var p webhookPayload
+		if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+			t.Errorf("decode webhook body: %v", err)
+		}
+		posted = append(posted, p)
+	}))
+	defer srv.Close()
+
+	n := &Notifier{
+		Routes: Routes{
+			EventEncrypted: {ChannelDesktop},
+			EventFailure:   {ChannelWebhook, ChannelDesktop},
+			EventInfo:      {},
+		},
+		Webhook: srv.URL,
+	}
+	for _, err := range []error{
+		n.Encrypted("/p/.env"),
+		n.Error("Failed to encrypt: /p/.env"),
+		n.Info("hello"),
+		n.Warning("unrouted"),
+	} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := []string{"🔐 File Encrypted", "❌ EnvDrift Error", "⚠️ EnvDrift Warning"}
+	if !reflect.DeepEqual(*desktop, want) {
+		t.Errorf("desktop = %v; want %v (info silenced, warning on the default)", *desktop, want)
+	}
+	if len(posted) != 1 || posted[0].Event != EventFailure || posted[0].Message != "Failed to encrypt: /p/.env" {
+		t.Errorf("webhook got %+v; want the one failure", posted)
+	}
+}
+
+func TestNotifierWebhookErrors(t *testing.T) {
+	desktop := stubDesktop(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	n := &Notifier{Routes: Routes{EventFailure: {ChannelWebhook, ChannelDesktop}}, Webhook: srv.URL}
+	err := n.Error("boom")
+	if err == nil || !strings.Contains(err.Error(), "502") {
+		t.Errorf("err = %v; want the webhook status", err)
+	}
+	if len(*desktop) != 1 {
+		t.Error("a failing webhook kept the desktop notification from being sent")
+	}
+}
+
+func TestNotifierHoldsDuringDND(t *testing.T) {
+	desktop := stubDesktop(t)
+	dnd := true
+	orig := dndActive
+	dndActive = func() bool { return dnd }
+	t.Cleanup(func() { dndActive = orig })
+
+	n := &Notifier{HoldDuringDND: true, FailuresBreakDND: true}
+	_ = n.Encrypted("/p/.env")
+	_ = n.Encrypted("/p/.env.local")
+	_ = n.Error("Failed to encrypt: /p/.env.prod")
+	if want := []string{"❌ EnvDrift Error"}; !reflect.DeepEqual(*desktop, want) {
+		t.Fatalf("during DND sent %v; want only the failure", *desktop)
+	}
+
+	if err := n.FlushHeld(); err != nil || len(*desktop) != 1 {
+		t.Fatalf("flushed %v (%v) while DND is still on", *desktop, err)
+	}
+	dnd = false
+	if err := n.FlushHeld(); err != nil {
+		t.Fatal(err)
+	}
+	if len(*desktop) != 2 || (*desktop)[1] != "🔔 EnvDrift" {
+		t.Errorf("after DND sent %v; want one summary", *desktop)
+	}
+	_ = n.FlushHeld()
+	if len(*desktop) != 2 {
+		t.Errorf("flushed the held notifications twice: %v", *desktop)
+	}
+
+	n.FailuresBreakDND = false
+	dnd = true
+	_ = n.Error("boom")
+	if len(*desktop) != 2 {
+		t.Error("failure shown during DND without failures_break_dnd")
+	}
+}
+
+func TestNotifierHoldsDuringQuietHours(t *testing.T) {
+	desktop := stubDesktop(t)
+	origDND, origNow := dndActive, now
+	dndActive = func() bool { return false }
+	clock := time.Date(2026, 3, 10, 23, 30, 0, 0, time.Local)
+	now = func() time.Time { return clock }
+	t.Cleanup(func() { dndActive, now = origDND, origNow })
+
+	quiet, err := schedule.ParseAll([]string{"22:00-08:00"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Quiet hours hold notifications without respect_dnd.
+	n := &Notifier{QuietHours: quiet}
+	_ = n.Encrypted("/p/.env")
+	if len(*desktop) != 0 {
+		t.Fatalf("during quiet hours sent %v", *desktop)
+	}
+	clock = clock.Add(8 * time.Hour) // 07:30
+	if err := n.FlushHeld(); err != nil || len(*desktop) != 0 {
+		t.Fatalf("flushed %v (%v) before quiet hours end", *desktop, err)
+	}
+	clock = clock.Add(time.Hour) // 08:30
+	if err := n.FlushHeld(); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"🔐 File Encrypted"}; !reflect.DeepEqual(*desktop, want) {
+		t.Errorf("after quiet hours sent %v; want %v", *desktop, want)
+	}
+}
+
+func TestDoNotDisturbGNOME(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("gsettings probe is Linux-only")
+	}
+	out := "false\n"
+	orig := runCommand
+	runCommand = func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		return []byte(out), nil
+	}
+	t.Cleanup(func() { runCommand = orig })
+
+	if !DoNotDisturb() {
+		t.Error("show-banners false not detected as do-not-disturb")
+	}
+	out = "true\n"
+	if DoNotDisturb() {
+		t.Error("show-banners true detected as do-not-disturb")
+	}
+}
+
+func TestHasDesktopSession(t *testing.T) {
+	origEnv, origOS, origWSL, origPwsh := getenv, goos, inWSL, powerShell
+	t.Cleanup(func() { getenv, goos, inWSL, powerShell = origEnv, origOS, origWSL, origPwsh })
+	inWSL = func() bool { return false }
+
+	runtimeDir := t.TempDir()
+	for _, tc := range []struct {
+		goos string
+		env  map[string]string
+		want bool
+	}{
+		{"darwin", nil, true},
+		{"windows", nil, true},
+		{"linux", nil, false},
+		{"linux", map[string]string{"XDG_RUNTIME_DIR": runtimeDir}, false},
+		{"linux", map[string]string{"DISPLAY": ":0"}, true},
+		{"linux", map[string]string{"WAYLAND_DISPLAY": "wayland-0"}, true},
+		{"freebsd", map[string]string{"DBUS_SESSION_BUS_ADDRESS": "unix:path=/tmp/bus"}, true},
+	} {
+		goos = tc.goos
+		getenv = func(k string) string { return tc.env[k] }
+		if got := hasDesktopSession(); got != tc.want {
+			t.Errorf("hasDesktopSession() on %s with %v = %v, want %v", tc.goos, tc.env, got, tc.want)
+		}
+	}
+
+	// The systemd user bus counts once it exists.
+	if err := os.WriteFile(filepath.Join(runtimeDir, "bus"), nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	goos = "linux"
+	getenv = func(k string) string { return map[string]string{"XDG_RUNTIME_DIR": runtimeDir}[k] }
+	if !hasDesktopSession() {
+		t.Error("hasDesktopSession() = false with $XDG_RUNTIME_DIR/bus")
+	}
+
+	// Under WSL only powershell.exe counts, whatever WSLg sets.
+	inWSL = func() bool { return true }
+	powerShell = func() string { return "" }
+	getenv = func(k string) string { return map[string]string{"DISPLAY": ":0"}[k] }
+	if hasDesktopSession() {
+		t.Error("hasDesktopSession() = true in WSL without powershell.exe")
+	}
+	powerShell = func() string { return "/mnt/c/powershell.exe" }
+	if !hasDesktopSession() {
+		t.Error("hasDesktopSession() = false in WSL with powershell.exe")
+	}
+}
+
+// TestToastEnv checks the toast text is forwarded to Windows through WSLENV,
+// keeping the variables the user already forwards.
+func TestToastEnv(t *testing.T) {
+	env := toastEnv([]string{"PATH=/bin", "WSLENV=USERPROFILE/p"}, `"; rm -rf ~`, "body")
+	want := []string{
+		"PATH=/bin",
+		`ENVDRIFT_TOAST_TITLE="; rm -rf ~`,
+		"ENVDRIFT_TOAST_BODY=body",
+		"WSLENV=USERPROFILE/p:ENVDRIFT_TOAST_TITLE:ENVDRIFT_TOAST_BODY",
+	}
+	if !reflect.DeepEqual(env, want) {
+		t.Errorf("toastEnv() = %q, want %q", env, want)
+	}
+	if env := toastEnv(nil, "t", "b"); env[len(env)-1] != "WSLENV=ENVDRIFT_TOAST_TITLE:ENVDRIFT_TOAST_BODY" {
+		t.Errorf("toastEnv() without WSLENV = %q", env)
+	}
+}
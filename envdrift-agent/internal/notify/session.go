@@ -0,0 +1,61 @@
+package notify
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+)
+
+// Seams for tests: the session environment.
+var (
+	getenv = os.Getenv
+	goos   = runtime.GOOS
+)
+
+// noSessionOnce logs the missing desktop session once.
+var noSessionOnce sync.Once
+
+// sendDesktop shows a notification through desktopNotify when there is a
+// desktop to show it on, or as a Windows toast under WSL. Without one, in a
+// container or an SSH login, it logs once and drops desktop notifications
+// instead of failing each time.
+func sendDesktop(title, message string) error {
+	if goos == "linux" && inWSL() {
+		if pwsh := powerShell(); pwsh != "" {
+			return wslToast(pwsh, title, message)
+		}
+	}
+	if !hasDesktopSession() {
+		noSessionOnce.Do(func() {
+			log.Printf("notify: no desktop session (no display or D-Bus session bus); desktop notifications are off, route events to the webhook channel to receive them")
+		})
+		return nil
+	}
+	return desktopNotify(title, message)
+}
+
+// hasDesktopSession reports whether a notification can be shown. macOS and
+// Windows always have one for a logged-in user, and WSL does when it can
+// reach powershell.exe; elsewhere notifications go over the D-Bus session
+// bus, found through DBUS_SESSION_BUS_ADDRESS or the systemd user bus at
+// $XDG_RUNTIME_DIR/bus, and an X11 or Wayland display lets notify-send start
+// one.
+func hasDesktopSession() bool {
+	if goos == "darwin" || goos == "windows" {
+		return true
+	}
+	if goos == "linux" && inWSL() {
+		return powerShell() != ""
+	}
+	if getenv("DBUS_SESSION_BUS_ADDRESS") != "" || getenv("DISPLAY") != "" || getenv("WAYLAND_DISPLAY") != "" {
+		return true
+	}
+	if dir := getenv("XDG_RUNTIME_DIR"); dir != "" {
+		if _, err := os.Stat(filepath.Join(dir, "bus")); err == nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,54 @@
+package notify
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/jainal09/envdrift-agent/internal/wsl"
+)
+
+// Seams for tests: WSL detection and its powershell.exe.
+var (
+	inWSL      = wsl.Detect
+	powerShell = wsl.PowerShell
+)
+
+// toastScript shows a Windows toast titled $env:ENVDRIFT_TOAST_TITLE with
+// the text $env:ENVDRIFT_TOAST_BODY, under PowerShell's own app ID, which
+// Windows accepts without a registered shortcut. Both reach the XML as text
+// nodes, so neither is ever parsed as markup or as script.
+const toastScript = `$ErrorActionPreference = 'Stop'
+[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] > $null
+$xml = [Windows.UI.Notifications.ToastNotificationManager]::GetTemplateContent([Windows.UI.Notifications.ToastTemplateType]::ToastText02)
+$text = $xml.GetElementsByTagName('text')
+$text.Item(0).AppendChild($xml.CreateTextNode($env:ENVDRIFT_TOAST_TITLE)) > $null
+$text.Item(1).AppendChild($xml.CreateTextNode($env:ENVDRIFT_TOAST_BODY)) > $null
+$app = '{1AC14E77-02E7-4E5D-B744-2EB1AE5198B7}\WindowsPowerShell\v1.0\powershell.exe'
+[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier($app).Show([Windows.UI.Notifications.ToastNotification]::new($xml))`
+
+// wslToast shows title and message as a Windows toast from inside WSL. The
+// Linux notification daemons WSLg may offer display nothing on the Windows
+// desktop. The text travels in environment variables that WSLENV forwards
+// to powershell.exe, never in its command line.
+func wslToast(pwsh, title, message string) error {
+	cmd := exec.Command(pwsh, "-NoProfile", "-NonInteractive", "-Command", toastScript)
+	cmd.Env = toastEnv(os.Environ(), title, message)
+	return cmd.Run()
+}
+
+// toastEnv returns env with the toast text added and listed in WSLENV.
+func toastEnv(env []string, title, message string) []string {
+	forward := "ENVDRIFT_TOAST_TITLE:ENVDRIFT_TOAST_BODY"
+	out := make([]string, 0, len(env)+3)
+	for _, kv := range env {
+		if v, ok := strings.CutPrefix(kv, "WSLENV="); ok {
+			if v != "" {
+				forward = v + ":" + forward
+			}
+			continue
+		}
+		out = append(out, kv)
+	}
+	return append(out, "ENVDRIFT_TOAST_TITLE="+title, "ENVDRIFT_TOAST_BODY="+message, "WSLENV="+forward)
+}
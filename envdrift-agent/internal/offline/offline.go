@@ -0,0 +1,123 @@
+// Package offline records the network operations the guardian put off while
+// the machine had no connection — vault sync passes, telemetry reports — so
+// `envdrift-agent status`, running in another process, can report the outage
+// and the size of the queue. The state lives in offline.json in the state
+// directory and holds operation names only.
+package offline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/jainal09/envdrift-agent/internal/paths"
+)
+
+// State is the recorded outage.
+type State struct {
+	// Since is when the first operation was queued.
+	Since time.Time `json:"since"`
+	// Queued names each put-off operation once (e.g. "vault sync /src/api").
+	Queued []string `json:"queued"`
+}
+
+// mu serializes state updates within one process.
+var mu sync.Mutex
+
+// Path returns offline.json in the state directory.
+func Path() string {
+	return filepath.Join(paths.StateDir(), "offline.json")
+}
+
+// Read returns the recorded outage. ok is false when the agent is online
+// (no state file).
+func Read() (st State, ok bool, err error) {
+	mu.Lock()
+	defer mu.Unlock()
+	return load()
+}
+
+// Queue records ops as waiting for the network, starting an outage at now
+// when none is recorded. An operation already queued is not added twice.
+func Queue(now time.Time, ops ...string) error {
+	mu.Lock()
+	defer mu.Unlock()
+	st, ok, err := load()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		st.Since = now
+	}
+	for _, op := range ops {
+		if !contains(st.Queued, op) {
+			st.Queued = append(st.Queued, op)
+		}
+	}
+	return save(st)
+}
+
+// Clear ends the recorded outage and returns it; ok is false when there was
+// none.
+func Clear() (st State, ok bool, err error) {
+	mu.Lock()
+	defer mu.Unlock()
+	st, ok, err = load()
+	if err != nil || !ok {
+		return st, ok, err
+	}
+	if err := os.Remove(Path()); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return st, ok, fmt.Errorf("clear offline state: %w", err)
+	}
+	return st, true, nil
+}
+
+func contains(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+// load reads the state file; a missing file means online.
+func load() (State, bool, error) {
+	var st State
+	data, err := os.ReadFile(Path())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return st, false, nil
+		}
+		return st, false, fmt.Errorf("read offline state: %w", err)
+	}
+	if err := json.Unmarshal(data, &st); err != nil {
+		return st, false, fmt.Errorf("parse offline state: %w", err)
+	}
+	return st, true, nil
+}
+
+// save writes the state atomically (temp file and rename).
+func save(st State) error {
+	path := Path()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("create state directory: %w", err)
+	}
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("write offline state: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("write offline state: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package offline
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestQueueAndClear(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	if _, ok, err := Read(); err != nil || ok {
+		t.Fatalf("Read before any outage = %v, %v; want online", ok, err)
+	}
+
+	start := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	if err := Queue(start, "vault sync /src/api", "vault sync /src/web"); err != nil {
+		t.Fatal(err)
+	}
+	if err := Queue(start.Add(time.Minute), "vault sync /src/api", "telemetry report"); err != nil {
+		t.Fatal(err)
+	}
+
+	st, ok, err := Read()
+	if err != nil || !ok {
+		t.Fatalf("Read = %v, %v; want an outage", ok, err)
+	}
+	want := []string{"vault sync /src/api", "vault sync /src/web", "telemetry report"}
+	if !reflect.DeepEqual(st.Queued, want) {
+		t.Errorf("queued = %v; want %v", st.Queued, want)
+	}
+	if !st.Since.Equal(start) {
+		t.Errorf("since = %v; want the first queueing, %v", st.Since, start)
+	}
+
+	cleared, ok, err := Clear()
+	if err != nil || !ok || len(cleared.Queued) != 3 {
+		t.Fatalf("Clear = %v, %v, %v; want the 3 queued operations", cleared, ok, err)
+	}
+	if _, ok, _ := Read(); ok {
+		t.Error("still offline after Clear")
+	}
+	if _, ok, err := Clear(); err != nil || ok {
+		t.Errorf("second Clear = %v, %v; want nothing to clear", ok, err)
+	}
+}
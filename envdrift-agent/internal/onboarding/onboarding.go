@@ -0,0 +1,148 @@
+// Package onboarding tracks the grace period of newly registered projects
+// ([guardian] grace_period): for a while after the agent first sees a project
+// it only reports the project's plaintext env files, so adding a directory
+// never encrypts anything by surprise. The ledger, onboarding.json in the
+// state directory, records when each project was first seen and when the
+// agent starts enforcing there; `envdrift-agent approve` ends a grace period
+// early.
+package onboarding
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jainal09/envdrift-agent/internal/paths"
+)
+
+// Root is one project in the ledger.
+type Root struct {
+	Path string `json:"path"`
+	// Added is when the agent first saw the project.
+	Added time.Time `json:"added"`
+	// Until is when enforcement starts on its own.
+	Until time.Time `json:"until"`
+	// Approved is when `approve` ended the grace period; zero until then.
+	Approved time.Time `json:"approved,omitempty"`
+}
+
+// Observing reports whether r is still in its grace period at now.
+func (r Root) Observing(now time.Time) bool {
+	return r.Approved.IsZero() && now.Before(r.Until)
+}
+
+// mu serializes ledger updates within one process.
+var mu sync.Mutex
+
+// LedgerPath returns onboarding.json in the state directory.
+func LedgerPath() string {
+	return filepath.Join(paths.StateDir(), "onboarding.json")
+}
+
+// Track records the roots not yet in the ledger and returns those that start
+// a grace period of grace at now. On the first call, with no ledger yet, the
+// roots are recorded as already enforced: the grace period is for projects
+// added after it was turned on, not for the ones the agent was guarding.
+func Track(roots []string, now time.Time, grace time.Duration) ([]Root, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	ledger, found, err := load()
+	if err != nil {
+		return nil, err
+	}
+	known := make(map[string]bool, len(ledger))
+	for _, r := range ledger {
+		known[r.Path] = true
+	}
+	var added []Root
+	for _, path := range roots {
+		if known[path] {
+			continue
+		}
+		known[path] = true
+		r := Root{Path: path, Added: now, Until: now}
+		if found {
+			r.Until = now.Add(grace)
+			added = append(added, r)
+		}
+		ledger = append(ledger, r)
+	}
+	if found && len(added) == 0 {
+		return nil, nil
+	}
+	return added, save(ledger)
+}
+
+// Observing returns the roots in their grace period at now, sorted by path.
+func Observing(now time.Time) ([]Root, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	ledger, _, err := load()
+	if err != nil {
+		return nil, err
+	}
+	var out []Root
+	for _, r := range ledger {
+		if r.Observing(now) {
+			out = append(out, r)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Path < out[j].Path })
+	return out, nil
+}
+
+// Approve ends root's grace period at now. found is false when root is not
+// in its grace period.
+func Approve(root string, now time.Time) (r Root, found bool, err error) {
+	mu.Lock()
+	defer mu.Unlock()
+	ledger, _, err := load()
+	if err != nil {
+		return Root{}, false, err
+	}
+	for i := range ledger {
+		if filepath.Clean(ledger[i].Path) == filepath.Clean(root) && ledger[i].Observing(now) {
+			ledger[i].Approved = now
+			return ledger[i], true, save(ledger)
+		}
+	}
+	return Root{}, false, nil
+}
+
+// load reads the ledger; found is false when there is none yet.
+func load() (ledger []Root, found bool, err error) {
+	data, err := os.ReadFile(LedgerPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if err := json.Unmarshal(data, &ledger); err != nil {
+		return nil, false, fmt.Errorf("parse %s: %w", LedgerPath(), err)
+	}
+	return ledger, true, nil
+}
+
+func save(ledger []Root) error {
+	if ledger == nil {
+		ledger = []Root{}
+	}
+	data, err := json.MarshalIndent(ledger, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(LedgerPath()), 0o700); err != nil {
+		return err
+	}
+	tmp := LedgerPath() + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, LedgerPath())
+}
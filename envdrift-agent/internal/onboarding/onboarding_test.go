@@ -0,0 +1,69 @@
+package onboarding
+
+import (
+	"testing"
+	"time"
+)
+
+func setTempHome(t *testing.T) {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+}
+
+// TestTrack records the projects present when onboarding starts as enforced
+// and gives only later ones a grace period.
+func TestTrack(t *testing.T) {
+	setTempHome(t)
+	now := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+
+	added, err := Track([]string{"/src/api", "/src/web"}, now, 24*time.Hour)
+	if err != nil || len(added) != 0 {
+		t.Fatalf("first Track = %v, %v; want the existing projects enforced", added, err)
+	}
+	added, err = Track([]string{"/src/api", "/src/web", "/src/new"}, now.Add(time.Hour), 24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(added) != 1 || added[0].Path != "/src/new" || !added[0].Until.Equal(now.Add(25*time.Hour)) {
+		t.Fatalf("Track = %+v; want /src/new observed for 24h", added)
+	}
+	if again, err := Track([]string{"/src/new"}, now.Add(2*time.Hour), 24*time.Hour); err != nil || len(again) != 0 {
+		t.Errorf("Track of a known project = %v, %v; want nothing new", again, err)
+	}
+
+	roots, err := Observing(now.Add(2 * time.Hour))
+	if err != nil || len(roots) != 1 || roots[0].Path != "/src/new" {
+		t.Fatalf("Observing = %+v, %v; want /src/new", roots, err)
+	}
+	if roots, _ := Observing(now.Add(26 * time.Hour)); len(roots) != 0 {
+		t.Errorf("Observing after the grace period = %+v; want none", roots)
+	}
+}
+
+// TestApprove ends a grace period early, once.
+func TestApprove(t *testing.T) {
+	setTempHome(t)
+	now := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+	if _, err := Track(nil, now, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Track([]string{"/src/new"}, now, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	r, found, err := Approve("/src/new/", now.Add(time.Minute))
+	if err != nil || !found || r.Approved.IsZero() {
+		t.Fatalf("Approve = %+v, %v, %v", r, found, err)
+	}
+	if roots, _ := Observing(now.Add(time.Minute)); len(roots) != 0 {
+		t.Errorf("Observing after Approve = %+v; want none", roots)
+	}
+	if _, found, err := Approve("/src/new", now.Add(2*time.Minute)); found || err != nil {
+		t.Errorf("second Approve = %v, %v; want not found", found, err)
+	}
+	if _, found, _ := Approve("/src/unknown", now); found {
+		t.Error("Approve of an unknown project must not find it")
+	}
+}
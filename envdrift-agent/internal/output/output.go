@@ -0,0 +1,120 @@
+// Package output keeps CLI and notification text usable by screen readers
+// and log processors. In plain mode — --no-emoji, guardian.plain_output, or
+// ENVDRIFT_PLAIN_OUTPUT set to anything but "", "0" or "false" — emoji and
+// ANSI color escapes are stripped from everything written through Writer or
+// passed through Text.
+package output
+
+import (
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"sync/atomic"
+)
+
+// plain is the SetPlain switch; ENVDRIFT_PLAIN_OUTPUT turns plain mode on
+// regardless.
+var plain atomic.Bool
+
+// getenv is a seam for tests.
+var getenv = os.Getenv
+
+// ansiEscapes matches CSI (color, cursor) and OSC (hyperlink, title) escapes.
+var ansiEscapes = regexp.MustCompile(`\x1b\[[0-9;?]*[A-Za-z]|\x1b\][^\x07\x1b]*(\x07|\x1b\\)`)
+
+// SetPlain switches plain mode on or off for this process.
+func SetPlain(on bool) {
+	plain.Store(on)
+}
+
+// Plain reports whether output is plain.
+func Plain() bool {
+	if plain.Load() {
+		return true
+	}
+	switch strings.ToLower(strings.TrimSpace(getenv("ENVDRIFT_PLAIN_OUTPUT"))) {
+	case "", "0", "false":
+		return false
+	}
+	return true
+}
+
+// Text returns s, stripped when output is plain.
+func Text(s string) string {
+	if !Plain() {
+		return s
+	}
+	return Strip(s)
+}
+
+// Strip removes ANSI escapes and emoji from s, together with the spaces
+// that separated an emoji from the text after it ("✅ Done" becomes "Done").
+func Strip(s string) string {
+	s = ansiEscapes.ReplaceAllString(s, "")
+	var b strings.Builder
+	b.Grow(len(s))
+	skipSpaces := false
+	for _, r := range s {
+		if isEmoji(r) {
+			skipSpaces = true
+			continue
+		}
+		if skipSpaces && r == ' ' {
+			continue
+		}
+		skipSpaces = false
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// isEmoji reports whether r is a pictograph or one of the joiners and
+// variation selectors that build emoji sequences. Arrows and other text
+// symbols are kept.
+func isEmoji(r rune) bool {
+	switch {
+	case r >= 0x1F000 && r <= 0x1FAFF: // pictographs, emoticons, transport, flags
+		return true
+	case r >= 0x2600 && r <= 0x27BF: // miscellaneous symbols, dingbats (⚠ ✅ ❌)
+		return true
+	case r >= 0x2B00 && r <= 0x2BFF: // stars, heavy arrows (⭐ ⬆)
+		return true
+	case r == 0x2139 || r == 0x231A || r == 0x231B || r == 0x23F3: // ℹ ⌚ ⌛ ⏳
+		return true
+	case r == 0x200D || r == 0xFE0E || r == 0xFE0F || r == 0x20E3: // ZWJ, variation selectors, keycap
+		return true
+	}
+	return false
+}
+
+// Writer wraps w so that what is written is stripped while output is plain.
+// The mode is checked on every write, so the wrapper can be installed
+// before flags are parsed.
+func Writer(w io.Writer) io.Writer {
+	return plainWriter{w}
+}
+
+type plainWriter struct {
+	w io.Writer
+}
+
+func (p plainWriter) Write(b []byte) (int, error) {
+	if !Plain() {
+		return p.w.Write(b)
+	}
+	if _, err := io.WriteString(p.w, Strip(string(b))); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// Raw returns the writer Writer wrapped, or w itself: for data such as
+// revealed secret values, which must reach the caller byte for byte even in
+// plain mode.
+func Raw(w io.Writer) io.Writer {
+	if p, ok := w.(plainWriter); ok {
+		return p.w
+	}
+	return w
+}
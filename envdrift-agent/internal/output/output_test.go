@@ -0,0 +1,53 @@
+package output
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestStrip(t *testing.T) {
+	for _, tt := range []struct{ in, want string }{
+		{"✅ Agent installed and will start on system boot", "Agent installed and will start on system boot"},
+		{"⚠️  Warning: envdrift not found", "Warning: envdrift not found"},
+		{"🔐 File Encrypted", "File Encrypted"},
+		{"ℹ️ EnvDrift", "EnvDrift"},
+		{"\x1b[1;32mok\x1b[0m done", "ok done"},
+		{"\x1b]8;;https://example.com\x07link\x1b]8;;\x07", "link"},
+		{"env → keychain, 100% — fine", "env → keychain, 100% — fine"},
+		{"👩‍💻 dev", "dev"},
+	} {
+		if got := Strip(tt.in); got != tt.want {
+			t.Errorf("Strip(%q) = %q; want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestWriterFollowsMode(t *testing.T) {
+	orig := getenv
+	env := ""
+	getenv = func(string) string { return env }
+	t.Cleanup(func() {
+		getenv = orig
+		SetPlain(false)
+	})
+
+	var buf bytes.Buffer
+	w := Writer(&buf)
+	fmt.Fprintln(w, "✅ Done")
+	env = "1"
+	fmt.Fprintln(w, "✅ Done")
+	env = "false"
+	SetPlain(true)
+	fmt.Fprintln(w, "✅ Done")
+	if want := "✅ Done\nDone\nDone\n"; buf.String() != want {
+		t.Errorf("wrote %q; want %q", buf.String(), want)
+	}
+
+	if Raw(w) != &buf {
+		t.Error("Raw did not unwrap the writer")
+	}
+	if Raw(&buf) != &buf {
+		t.Error("Raw changed an unwrapped writer")
+	}
+}
@@ -0,0 +1,89 @@
+package paths
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// legacyFiles maps each file or directory older versions kept in ~/.envdrift
+// to the directory it now lives in. Rotated and backup siblings
+// (journal.jsonl.1, guardian.toml.bak) follow their file. projects.json is
+// absent: the envdrift CLI writes it there.
+var legacyFiles = map[string]func() string{
+	"guardian.toml":   ConfigDir,
+	"recipients.json": ConfigDir,
+	"journal.jsonl":   StateDir,
+	"history.jsonl":   StateDir,
+	"telemetry.json":  StateDir,
+	"exports.json":    StateDir,
+	"offline.json":    StateDir,
+	"vault-sync.json": StateDir,
+	"backups":         StateDir,
+	"crashes":         StateDir,
+	"recheck":         CacheDir,
+}
+
+// Move is one file Migrate moved.
+type Move struct {
+	From, To string
+}
+
+// Migrate moves the files an older version left in ~/.envdrift to the
+// current locations. A file already present at its new location is left
+// where it is, so Migrate is cheap to call on every start and never
+// overwrites. It does nothing under ENVDRIFT_HOME.
+func Migrate() ([]Move, error) {
+	legacy := Legacy()
+	if Home() != "" {
+		return nil, nil
+	}
+	entries, err := os.ReadDir(legacy)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var moves []Move
+	var errs []string
+	for _, e := range entries {
+		to := destination(e.Name())
+		if to == "" || filepath.Dir(to) == filepath.Clean(legacy) {
+			continue
+		}
+		from := filepath.Join(legacy, e.Name())
+		if _, err := os.Lstat(to); err == nil {
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(to), 0o700); err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		if err := os.Rename(from, to); err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		moves = append(moves, Move{From: from, To: to})
+	}
+	if len(errs) > 0 {
+		return moves, fmt.Errorf("moving files out of %s: %s", legacy, strings.Join(errs, "; "))
+	}
+	return moves, nil
+}
+
+// destination returns where a ~/.envdrift entry moves to, or "" for one
+// that stays.
+func destination(entry string) string {
+	if entry == "logs" {
+		return LogDir()
+	}
+	for file, dir := range legacyFiles {
+		if entry == file || strings.HasPrefix(entry, file+".") {
+			return filepath.Join(dir(), entry)
+		}
+	}
+	return ""
+}
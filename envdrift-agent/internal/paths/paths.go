@@ -0,0 +1,145 @@
+// Package paths locates the agent's files. Settings live in the config
+// directory, what the agent records (journal, history, backups, logs) in the
+// state directory, and disposable files in the cache directory, following
+// each platform's conventions:
+//
+//	Linux    $XDG_CONFIG_HOME/envdrift  $XDG_STATE_HOME/envdrift      $XDG_CACHE_HOME/envdrift
+//	         (~/.config/envdrift)       (~/.local/state/envdrift)     (~/.cache/envdrift)
+//	macOS    ~/Library/Application Support/envdrift (config and state), ~/Library/Caches/envdrift,
+//	         logs in ~/Library/Logs/envdrift
+//	Windows  %APPDATA%\envdrift         %LOCALAPPDATA%\envdrift       %LOCALAPPDATA%\envdrift\cache
+//
+// ENVDRIFT_HOME puts everything in one directory instead, the layout of
+// ~/.envdrift that older versions used; ENVDRIFT_HOME=~/.envdrift keeps it.
+// Migrate moves an existing ~/.envdrift to the new locations.
+package paths
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// HomeEnv names the variable that selects the single-directory layout.
+const HomeEnv = "ENVDRIFT_HOME"
+
+// name is the directory the agent's files live in under each base.
+const name = "envdrift"
+
+// Seams for tests.
+var (
+	goos        = runtime.GOOS
+	getenv      = os.Getenv
+	userHomeDir = os.UserHomeDir
+)
+
+// home returns the user's home directory, or "" (so paths are relative)
+// when it cannot be determined.
+func home() string {
+	h, _ := userHomeDir()
+	return h
+}
+
+// Legacy returns ~/.envdrift, where older versions kept every file and the
+// envdrift CLI still keeps projects.json.
+func Legacy() string {
+	return filepath.Join(home(), ".envdrift")
+}
+
+// Home returns the ENVDRIFT_HOME directory, with a leading ~ expanded, or ""
+// when it is not set.
+func Home() string {
+	dir := strings.TrimSpace(getenv(HomeEnv))
+	if dir == "~" || strings.HasPrefix(dir, "~/") || strings.HasPrefix(dir, `~\`) {
+		dir = filepath.Join(home(), dir[1:])
+	}
+	return dir
+}
+
+// ConfigDir returns the directory holding guardian.toml and recipients.json.
+func ConfigDir() string {
+	if dir := Home(); dir != "" {
+		return dir
+	}
+	switch goos {
+	case "darwin":
+		return filepath.Join(home(), "Library", "Application Support", name)
+	case "windows":
+		return filepath.Join(windowsDir("APPDATA", "Roaming"), name)
+	}
+	return filepath.Join(xdgDir("XDG_CONFIG_HOME", ".config"), name)
+}
+
+// StateDir returns the directory holding what the agent records: the
+// journal, history, backups, crash reports and sync state.
+func StateDir() string {
+	if dir := Home(); dir != "" {
+		return dir
+	}
+	switch goos {
+	case "darwin":
+		return filepath.Join(home(), "Library", "Application Support", name)
+	case "windows":
+		return filepath.Join(windowsDir("LOCALAPPDATA", "Local"), name)
+	}
+	return filepath.Join(xdgDir("XDG_STATE_HOME", filepath.Join(".local", "state")), name)
+}
+
+// CacheDir returns the directory for files that may be deleted at any time,
+// such as pending recheck requests.
+func CacheDir() string {
+	if dir := Home(); dir != "" {
+		return dir
+	}
+	switch goos {
+	case "darwin":
+		return filepath.Join(home(), "Library", "Caches", name)
+	case "windows":
+		return filepath.Join(windowsDir("LOCALAPPDATA", "Local"), name, "cache")
+	}
+	return filepath.Join(xdgDir("XDG_CACHE_HOME", ".cache"), name)
+}
+
+// LogDir returns the directory of the rotating agent.log.
+func LogDir() string {
+	if goos == "darwin" && Home() == "" {
+		return filepath.Join(home(), "Library", "Logs", name)
+	}
+	return filepath.Join(StateDir(), "logs")
+}
+
+// ServiceLogDir returns LogDir for the Unix user whose home directory is home,
+// as a service started outside their session sees it: with ENVDRIFT_HOME as
+// given, and without any XDG variables. A BSD rc.d install, run as root for
+// that user, points the service's --log-file there.
+func ServiceLogDir(home string) string {
+	if dir := Home(); dir != "" {
+		return filepath.Join(dir, "logs")
+	}
+	return filepath.Join(home, ".local", "state", name, "logs")
+}
+
+// Within reports whether path is root or below it, comparing the cleaned
+// paths without touching the file system.
+func Within(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// xdgDir returns $env, or ~/fallback when it is unset or, against the
+// specification, relative.
+func xdgDir(env, fallback string) string {
+	if dir := getenv(env); filepath.IsAbs(dir) {
+		return dir
+	}
+	return filepath.Join(home(), fallback)
+}
+
+// windowsDir returns %env%, or AppData\fallback under the home directory.
+func windowsDir(env, fallback string) string {
+	if dir := getenv(env); dir != "" {
+		return dir
+	}
+	return filepath.Join(home(), "AppData", fallback)
+}
@@ -0,0 +1,120 @@
+package paths
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fakePlatform makes the package see platform with the given environment and
+// home directory.
+func fakePlatform(t *testing.T, platform, home string, env map[string]string) {
+	t.Helper()
+	oldGOOS, oldGetenv, oldHome := goos, getenv, userHomeDir
+	t.Cleanup(func() { goos, getenv, userHomeDir = oldGOOS, oldGetenv, oldHome })
+	goos = platform
+	getenv = func(k string) string { return env[k] }
+	userHomeDir = func() (string, error) { return home, nil }
+}
+
+func TestDirs(t *testing.T) {
+	home := filepath.FromSlash("/home/me")
+	j := func(parts ...string) string { return filepath.Join(append([]string{home}, parts...)...) }
+	tests := []struct {
+		name                       string
+		goos                       string
+		env                        map[string]string
+		config, state, cache, logs string
+	}{
+		{"linux defaults", "linux", nil,
+			j(".config", "envdrift"), j(".local", "state", "envdrift"), j(".cache", "envdrift"), j(".local", "state", "envdrift", "logs")},
+		{"linux XDG", "linux", map[string]string{"XDG_CONFIG_HOME": "/x/config", "XDG_STATE_HOME": "/x/state", "XDG_CACHE_HOME": "relative"},
+			filepath.Join("/x/config", "envdrift"), filepath.Join("/x/state", "envdrift"), j(".cache", "envdrift"), filepath.Join("/x/state", "envdrift", "logs")},
+		{"macOS", "darwin", nil,
+			j("Library", "Application Support", "envdrift"), j("Library", "Application Support", "envdrift"), j("Library", "Caches", "envdrift"), j("Library", "Logs", "envdrift")},
+		{"windows", "windows", map[string]string{"APPDATA": "/r", "LOCALAPPDATA": "/l"},
+			filepath.Join("/r", "envdrift"), filepath.Join("/l", "envdrift"), filepath.Join("/l", "envdrift", "cache"), filepath.Join("/l", "envdrift", "logs")},
+		{"ENVDRIFT_HOME", "darwin", map[string]string{HomeEnv: "~/.envdrift"},
+			j(".envdrift"), j(".envdrift"), j(".envdrift"), j(".envdrift", "logs")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakePlatform(t, tt.goos, home, tt.env)
+			got := []string{ConfigDir(), StateDir(), CacheDir(), LogDir()}
+			want := []string{tt.config, tt.state, tt.cache, tt.logs}
+			for i := range got {
+				if got[i] != want[i] {
+					t.Errorf("dir %d = %s, want %s", i, got[i], want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	home := t.TempDir()
+	fakePlatform(t, "linux", home, nil)
+	legacy := Legacy()
+	for _, name := range []string{"guardian.toml", "guardian.toml.v1.bak", "journal.jsonl", "journal.jsonl.1", "projects.json", "recheck/abc.req", "logs/agent.log"} {
+		path := filepath.Join(legacy, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A file already at its new location is not overwritten.
+	if err := os.MkdirAll(StateDir(), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(StateDir(), "journal.jsonl"), []byte("new"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	moves, err := Migrate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(moves) != 5 {
+		t.Errorf("moves = %v; want guardian.toml, its backup, journal.jsonl.1, logs and recheck", moves)
+	}
+	for path, want := range map[string]string{
+		filepath.Join(ConfigDir(), "guardian.toml"):        "guardian.toml",
+		filepath.Join(ConfigDir(), "guardian.toml.v1.bak"): "guardian.toml.v1.bak",
+		filepath.Join(StateDir(), "journal.jsonl"):         "new",
+		filepath.Join(StateDir(), "journal.jsonl.1"):       "journal.jsonl.1",
+		filepath.Join(LogDir(), "agent.log"):               "logs/agent.log",
+		filepath.Join(CacheDir(), "recheck", "abc.req"):    "recheck/abc.req",
+		filepath.Join(legacy, "projects.json"):             "projects.json",
+		filepath.Join(legacy, "journal.jsonl"):             "journal.jsonl",
+	} {
+		if data, err := os.ReadFile(path); err != nil || string(data) != want {
+			t.Errorf("%s = %q, %v; want %q", path, data, err, want)
+		}
+	}
+
+	if moves, err := Migrate(); err != nil || len(moves) != 0 {
+		t.Errorf("second Migrate = %v, %v; want nothing to do", moves, err)
+	}
+}
+
+func TestWithin(t *testing.T) {
+	root := filepath.Join("home", "me", "src")
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{root, true},
+		{filepath.Join(root, "api", ".env"), true},
+		{filepath.Join(root, "..src-other"), true},
+		{filepath.Join("home", "me"), false},
+		{filepath.Join("home", "me", "src2"), false},
+		{filepath.Join(root, "..", "other"), false},
+	}
+	for _, tt := range tests {
+		if got := Within(root, tt.path); got != tt.want {
+			t.Errorf("Within(%q, %q) = %v, want %v", root, tt.path, got, tt.want)
+		}
+	}
+}
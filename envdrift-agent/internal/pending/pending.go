@@ -0,0 +1,93 @@
+// Package pending records the plaintext env files the guardian tracks but has
+// not encrypted yet — when each was first detected, why it is waiting and
+// when it is projected to be encrypted — so `envdrift-agent status`, running
+// in another process, can show each file's deadline. The state lives in
+// pending.json in the state directory and holds paths and reasons only.
+package pending
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/jainal09/envdrift-agent/internal/paths"
+)
+
+// ReasonIdle is the reason of a file still changing: it is encrypted once it
+// has been idle for the idle timeout. Every other reason is the detail of a
+// deferral, e.g. "open in another process".
+const ReasonIdle = "waiting for idle"
+
+// File is one plaintext file waiting for encryption.
+type File struct {
+	Path    string `json:"path"`
+	Project string `json:"project"`
+	// FirstSeen is when the guardian first tracked the file.
+	FirstSeen time.Time `json:"first_seen"`
+	// Reason says why the file is not encrypted yet.
+	Reason string `json:"reason"`
+	// Due is when the file is projected to be encrypted; zero when that
+	// waits on something the agent cannot foresee, such as an editor
+	// closing the file or a git operation ending.
+	Due time.Time `json:"due"`
+}
+
+// mu serializes state updates within one process.
+var mu sync.Mutex
+
+// Path returns pending.json in the state directory.
+func Path() string {
+	return filepath.Join(paths.StateDir(), "pending.json")
+}
+
+// Read returns the recorded files, ordered by path; none when no file is
+// pending or the agent has not run.
+func Read() ([]File, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	data, err := os.ReadFile(Path())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read pending files: %w", err)
+	}
+	var files []File
+	if err := json.Unmarshal(data, &files); err != nil {
+		return nil, fmt.Errorf("parse pending files: %w", err)
+	}
+	return files, nil
+}
+
+// Write replaces the recorded files with files; none removes the state file.
+func Write(files []File) error {
+	mu.Lock()
+	defer mu.Unlock()
+	path := Path()
+	if len(files) == 0 {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("clear pending files: %w", err)
+		}
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("create state directory: %w", err)
+	}
+	data, err := json.MarshalIndent(files, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("write pending files: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("write pending files: %w", err)
+	}
+	return nil
+}
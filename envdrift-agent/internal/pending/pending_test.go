@@ -0,0 +1,41 @@
+package pending
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWriteAndRead(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	if files, err := Read(); err != nil || len(files) != 0 {
+		t.Fatalf("Read before any write = %v, %v; want none", files, err)
+	}
+
+	seen := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	want := []File{
+		{Path: "/src/api/.env", Project: "/src/api", FirstSeen: seen, Reason: ReasonIdle, Due: seen.Add(5 * time.Minute)},
+		{Path: "/src/web/.env", Project: "/src/web", FirstSeen: seen, Reason: "open in another process"},
+	}
+	if err := Write(want); err != nil {
+		t.Fatal(err)
+	}
+	files, err := Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(files, want) {
+		t.Errorf("Read = %+v; want %+v", files, want)
+	}
+
+	if err := Write(nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(Path()); !os.IsNotExist(err) {
+		t.Errorf("state file after writing no files: %v; want removed", err)
+	}
+}
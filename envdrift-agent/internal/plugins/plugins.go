@@ -0,0 +1,386 @@
+// Package plugins runs external executables that extend the agent without
+// forking it. A plugin is any executable in the plugins directory (`plugins`
+// in the config directory). The agent invokes it with the request type as
+// its only argument and the request as JSON on stdin:
+//
+//	describe  reply with a Manifest: name, version, and what the plugin
+//	          handles
+//	event     an encryption event the plugin subscribed to (Manifest.Events);
+//	          no reply is read
+//	key       look up a dotenvx private key, for the [keys] resolution
+//	          source "plugin:<name>" (Manifest.KeySource); reply with a
+//	          KeyReply
+//	command   run one of Manifest.Commands for `envdrift-agent plugin run`,
+//	          attached to the terminal
+//
+// Every request carries "protocol": 1. A non-zero exit is a failure, and
+// the plugin's stderr is its message.
+package plugins
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jainal09/envdrift-agent/internal/paths"
+)
+
+// Protocol is the version of the request format.
+const Protocol = 1
+
+// Request types.
+const (
+	TypeDescribe = "describe"
+	TypeEvent    = "event"
+	TypeKey      = "key"
+	TypeCommand  = "command"
+)
+
+// Events a plugin can subscribe to.
+const (
+	EventEncrypted = "encrypted"
+	EventFailed    = "failed"
+)
+
+// Manifest is a plugin's reply to describe.
+type Manifest struct {
+	Name        string `json:"name"`
+	Version     string `json:"version"`
+	Description string `json:"description"`
+	// Events lists the events the plugin receives.
+	Events []string `json:"events,omitempty"`
+	// KeySource marks a plugin that answers key requests.
+	KeySource bool `json:"key_source,omitempty"`
+	// Commands lists the commands `plugin run` accepts.
+	Commands []string `json:"commands,omitempty"`
+}
+
+// Plugin is one executable in the plugins directory.
+type Plugin struct {
+	// Name is the file name without a Windows .exe/.cmd/.bat extension; it
+	// names the plugin on the command line and in "plugin:<name>".
+	Name     string
+	Path     string
+	Manifest Manifest
+	// Err is why describe failed; the plugin is listed but not used.
+	Err error
+}
+
+// Event is what an event request carries.
+type Event struct {
+	Name    string    `json:"name"`
+	File    string    `json:"file"`
+	Project string    `json:"project"`
+	Error   string    `json:"error,omitempty"`
+	Time    time.Time `json:"time"`
+	Host    string    `json:"host"`
+}
+
+// KeyRequest is what a key request carries.
+type KeyRequest struct {
+	// Name is the variable, e.g. DOTENV_PRIVATE_KEY_PRODUCTION.
+	Name string `json:"name"`
+	// Dir is the directory of the env file.
+	Dir string `json:"dir"`
+}
+
+// KeyReply is a plugin's reply to a key request. Value is the secret.
+type KeyReply struct {
+	Found    bool   `json:"found"`
+	Value    string `json:"value,omitempty"`
+	Location string `json:"location,omitempty"`
+}
+
+// request is the JSON on a plugin's stdin.
+type request struct {
+	Protocol int         `json:"protocol"`
+	Type     string      `json:"type"`
+	Event    *Event      `json:"event,omitempty"`
+	Key      *KeyRequest `json:"key,omitempty"`
+	Command  string      `json:"command,omitempty"`
+	Args     []string    `json:"args,omitempty"`
+}
+
+// Timeouts for the requests the agent waits on.
+const (
+	describeTimeout = 5 * time.Second
+	eventTimeout    = 10 * time.Second
+	keyTimeout      = 30 * time.Second
+)
+
+// Seams for tests.
+var (
+	execCommand = exec.CommandContext
+	httpClient  = &http.Client{Timeout: 60 * time.Second}
+)
+
+// Dir returns the plugins directory.
+func Dir() string {
+	return filepath.Join(paths.ConfigDir(), "plugins")
+}
+
+// List describes every plugin in Dir, sorted by name. A missing directory
+// holds none.
+func List(ctx context.Context) ([]Plugin, error) {
+	entries, err := os.ReadDir(Dir())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var list []Plugin
+	for _, e := range entries {
+		path := filepath.Join(Dir(), e.Name())
+		if strings.HasPrefix(e.Name(), tmpPrefix) || !isExecutable(path) {
+			continue
+		}
+		p := Plugin{Name: pluginName(e.Name()), Path: path}
+		p.Manifest, p.Err = describe(ctx, path)
+		list = append(list, p)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list, nil
+}
+
+// Find returns the plugin called name.
+func Find(ctx context.Context, name string) (Plugin, error) {
+	list, err := List(ctx)
+	if err != nil {
+		return Plugin{}, err
+	}
+	for _, p := range list {
+		if p.Name == name {
+			if p.Err != nil {
+				return p, fmt.Errorf("plugin %s: %w", name, p.Err)
+			}
+			return p, nil
+		}
+	}
+	return Plugin{}, fmt.Errorf("no plugin %q in %s (see 'envdrift-agent plugin list')", name, Dir())
+}
+
+// isExecutable reports whether path is a regular file the agent can run:
+// on Windows by extension, elsewhere by its execute bits.
+func isExecutable(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return false
+	}
+	if runtime.GOOS == "windows" {
+		ext := strings.ToLower(filepath.Ext(path))
+		return ext == ".exe" || ext == ".cmd" || ext == ".bat"
+	}
+	return info.Mode().Perm()&0o111 != 0
+}
+
+// pluginName strips a Windows executable extension from a file name.
+func pluginName(file string) string {
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".exe", ".cmd", ".bat":
+		return strings.TrimSuffix(file, filepath.Ext(file))
+	}
+	return file
+}
+
+// call runs the plugin at path with req and returns its stdout.
+func call(ctx context.Context, path string, req request, timeout time.Duration) ([]byte, error) {
+	req.Protocol = Protocol
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	cmd := execCommand(ctx, path, req.Type)
+	cmd.Stdin = bytes.NewReader(body)
+	var out, errOut bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &errOut
+	cmd.WaitDelay = time.Second
+	if err := cmd.Run(); err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("%s timed out after %v", req.Type, timeout)
+		}
+		if msg := strings.TrimSpace(errOut.String()); msg != "" {
+			return nil, fmt.Errorf("%s: %s", req.Type, msg)
+		}
+		return nil, fmt.Errorf("%s: %w", req.Type, err)
+	}
+	return out.Bytes(), nil
+}
+
+// describe asks the plugin at path for its manifest.
+func describe(ctx context.Context, path string) (Manifest, error) {
+	out, err := call(ctx, path, request{Type: TypeDescribe}, describeTimeout)
+	if err != nil {
+		return Manifest{}, err
+	}
+	var m Manifest
+	if err := json.Unmarshal(out, &m); err != nil {
+		return Manifest{}, fmt.Errorf("describe: the reply is not a JSON manifest: %w", err)
+	}
+	return m, nil
+}
+
+// Subscribed reports whether p receives event.
+func (p Plugin) Subscribed(event string) bool {
+	return p.Err == nil && slices.Contains(p.Manifest.Events, event)
+}
+
+// Emit sends e to every plugin in list subscribed to it, one after another,
+// and returns their failures joined.
+func Emit(ctx context.Context, list []Plugin, e Event) error {
+	var errs []error
+	for _, p := range list {
+		if !p.Subscribed(e.Name) {
+			continue
+		}
+		if _, err := call(ctx, p.Path, request{Type: TypeEvent, Event: &e}, eventTimeout); err != nil {
+			errs = append(errs, fmt.Errorf("plugin %s: %w", p.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// LookupKey asks p for a private key.
+func (p Plugin) LookupKey(ctx context.Context, req KeyRequest) (KeyReply, error) {
+	if !p.Manifest.KeySource {
+		return KeyReply{}, fmt.Errorf("plugin %s is not a key source", p.Name)
+	}
+	out, err := call(ctx, p.Path, request{Type: TypeKey, Key: &req}, keyTimeout)
+	if err != nil {
+		return KeyReply{}, err
+	}
+	var reply KeyReply
+	if err := json.Unmarshal(out, &reply); err != nil {
+		return KeyReply{}, fmt.Errorf("key: the reply is not JSON: %w", err)
+	}
+	return reply, nil
+}
+
+// Run runs one of p's commands attached to stdin, stdout and stderr, which
+// carry the request and then the terminal. The plugin reads the request as
+// its first line of stdin.
+func (p Plugin) Run(ctx context.Context, command string, args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	if !slices.Contains(p.Manifest.Commands, command) {
+		return fmt.Errorf("plugin %s has no command %q (it has: %s)", p.Name, command, strings.Join(p.Manifest.Commands, ", "))
+	}
+	body, err := json.Marshal(request{Protocol: Protocol, Type: TypeCommand, Command: command, Args: args})
+	if err != nil {
+		return err
+	}
+	cmd := execCommand(ctx, p.Path, TypeCommand)
+	cmd.Stdin = io.MultiReader(bytes.NewReader(append(body, '\n')), stdin)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("plugin %s %s: %w", p.Name, command, err)
+	}
+	return nil
+}
+
+// InstallOptions tunes Install.
+type InstallOptions struct {
+	// Name is the file name to install as; empty is the source's base name.
+	Name string
+	// SHA256, when set, is the hex SHA-256 the plugin's content must have.
+	SHA256 string
+	// Overwrite replaces an installed plugin of the same name.
+	Overwrite bool
+}
+
+// Install copies the plugin at src — a file path or an https:// URL — into
+// Dir, and describes it. A plugin that does not answer describe, or whose
+// content does not match opts.SHA256, is not installed. The describe call
+// runs on a hidden copy that keeps the name's extension, so Windows can run
+// a .cmd or .bat plugin.
+func Install(ctx context.Context, src string, opts InstallOptions) (Plugin, error) {
+	name, want := opts.Name, strings.ToLower(strings.TrimSpace(opts.SHA256))
+	if want != "" {
+		if b, err := hex.DecodeString(want); err != nil || len(b) != sha256.Size {
+			return Plugin{}, fmt.Errorf("invalid --sha256 %q: want 64 hex digits", opts.SHA256)
+		}
+	}
+	if name == "" {
+		name = filepath.Base(src)
+		if strings.HasPrefix(src, "https://") {
+			name = src[strings.LastIndex(src, "/")+1:]
+		}
+	}
+	if name == "" || name == "." || strings.ContainsAny(name, `/\`) {
+		return Plugin{}, fmt.Errorf("invalid plugin name %q (pass --name)", name)
+	}
+	var data []byte
+	var err error
+	switch {
+	case strings.HasPrefix(src, "https://"):
+		data, err = download(ctx, src)
+	case strings.Contains(src, "://"):
+		return Plugin{}, fmt.Errorf("%s: only https:// URLs and local files can be installed", src)
+	default:
+		data, err = os.ReadFile(src)
+	}
+	if err != nil {
+		return Plugin{}, err
+	}
+	if sum := sha256.Sum256(data); want != "" && hex.EncodeToString(sum[:]) != want {
+		return Plugin{}, fmt.Errorf("%s does not match --sha256 %s (its SHA-256 is %x); not installed", src, want, sum)
+	}
+
+	if err := os.MkdirAll(Dir(), 0o700); err != nil {
+		return Plugin{}, err
+	}
+	dest := filepath.Join(Dir(), name)
+	if _, err := os.Stat(dest); err == nil && !opts.Overwrite {
+		return Plugin{}, fmt.Errorf("plugin %s is already installed (pass --force to replace it)", pluginName(name))
+	}
+	tmp := filepath.Join(Dir(), tmpPrefix+name)
+	if err := os.WriteFile(tmp, data, 0o700); err != nil {
+		return Plugin{}, err
+	}
+	m, err := describe(ctx, tmp)
+	if err != nil {
+		_ = os.Remove(tmp)
+		return Plugin{}, fmt.Errorf("%s is not a working envdrift plugin: %w", src, err)
+	}
+	if err := os.Rename(tmp, dest); err != nil {
+		_ = os.Remove(tmp)
+		return Plugin{}, err
+	}
+	return Plugin{Name: pluginName(name), Path: dest, Manifest: m}, nil
+}
+
+// tmpPrefix starts the name of a plugin being installed; List skips it.
+const tmpPrefix = ".tmp-"
+
+// download fetches url.
+func download(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", url, resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 256<<20))
+}
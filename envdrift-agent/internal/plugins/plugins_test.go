@@ -0,0 +1,224 @@
+package plugins
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/jainal09/envdrift-agent/internal/paths"
+)
+
+// fakePlugin is a shell-script plugin: it answers describe with a manifest,
+// appends event requests to events.log beside it, answers key requests for
+// DOTENV_PRIVATE_KEY, and echoes a command's request line and next input.
+const fakePlugin = `#!/bin/sh
+dir=$(dirname "$0")
+case "$1" in
+describe) echo '{"name":"fake","version":"1.0.0","description":"test plugin","events":["encrypted"],"key_source":true,"commands":["hello"]}' ;;
+event) cat >> "$dir/events.log"; echo >> "$dir/events.log" ;;
+key)
+  if grep -q '"name":"DOTENV_PRIVATE_KEY"' ; then
+    echo '{"found":true,"value":"secret","location":"fake store"}'
+  else
+    echo '{"found":false}'
+  fi ;;
+command) read -r req; read -r line; echo "req=$req"; echo "line=$line" ;;
+*) echo "unknown request $1" >&2; exit 2 ;;
+esac
+`
+
+// setupHome points the config directory at a temporary one and skips on
+// Windows, where the shell-script plugins cannot run.
+func setupHome(t *testing.T) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("test plugins are POSIX shell scripts")
+	}
+	t.Setenv(paths.HomeEnv, t.TempDir())
+}
+
+// writeScript writes an executable script and returns its path.
+func writeScript(t *testing.T, dir, name, body string) string {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(body), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestListAndProtocol(t *testing.T) {
+	setupHome(t)
+	ctx := context.Background()
+	if list, err := List(ctx); err != nil || len(list) != 0 {
+		t.Fatalf("List without a plugins directory = %v, %v", list, err)
+	}
+	writeScript(t, Dir(), "fake", fakePlugin)
+	writeScript(t, Dir(), "broken", "#!/bin/sh\necho 'no manifest'\n")
+	// Not executable: not a plugin.
+	if err := os.WriteFile(filepath.Join(Dir(), "README"), []byte("notes"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 || list[0].Name != "broken" || list[1].Name != "fake" {
+		t.Fatalf("List = %+v; want broken and fake", list)
+	}
+	if list[0].Err == nil {
+		t.Error("a plugin that does not describe itself must be marked broken")
+	}
+	fake := list[1]
+	if fake.Err != nil || fake.Manifest.Version != "1.0.0" || !fake.Subscribed(EventEncrypted) || fake.Subscribed(EventFailed) {
+		t.Fatalf("fake plugin = %+v", fake)
+	}
+
+	if err := Emit(ctx, list, Event{Name: EventEncrypted, File: "/p/.env", Project: "/p"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Emit(ctx, list, Event{Name: EventFailed, File: "/p/.env", Project: "/p"}); err != nil {
+		t.Fatal(err)
+	}
+	log, _ := os.ReadFile(filepath.Join(Dir(), "events.log"))
+	if n := strings.Count(string(log), `"protocol":1`); n != 1 || !strings.Contains(string(log), `"file":"/p/.env"`) {
+		t.Errorf("events delivered = %q; want only the subscribed one", log)
+	}
+
+	reply, err := fake.LookupKey(ctx, KeyRequest{Name: "DOTENV_PRIVATE_KEY", Dir: "/p"})
+	if err != nil || !reply.Found || reply.Value != "secret" {
+		t.Errorf("LookupKey = %+v, %v", reply, err)
+	}
+	reply, err = fake.LookupKey(ctx, KeyRequest{Name: "DOTENV_PRIVATE_KEY_CI", Dir: "/p"})
+	if err != nil || reply.Found {
+		t.Errorf("LookupKey of a missing key = %+v, %v", reply, err)
+	}
+
+	var out bytes.Buffer
+	if err := fake.Run(ctx, "hello", []string{"--loud"}, strings.NewReader("typed\n"), &out, &out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), `"command":"hello","args":["--loud"]`) || !strings.Contains(out.String(), "line=typed") {
+		t.Errorf("command output = %q", out.String())
+	}
+	if err := fake.Run(ctx, "bye", nil, strings.NewReader(""), &out, &out); err == nil {
+		t.Error("a command the plugin does not declare must be refused")
+	}
+
+	if _, err := Find(ctx, "broken"); err == nil {
+		t.Error("Find of a broken plugin must fail")
+	}
+	if _, err := Find(ctx, "missing"); err == nil || !strings.Contains(err.Error(), "plugin list") {
+		t.Errorf("Find of a missing plugin = %v", err)
+	}
+}
+
+func TestInstall(t *testing.T) {
+	setupHome(t)
+	ctx := context.Background()
+	src := writeScript(t, t.TempDir(), "envdrift-fake", fakePlugin)
+
+	p, err := Install(ctx, src, InstallOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Name != "envdrift-fake" || p.Manifest.Name != "fake" || p.Path != filepath.Join(Dir(), "envdrift-fake") {
+		t.Errorf("Install = %+v", p)
+	}
+	if _, err := Install(ctx, src, InstallOptions{}); err == nil || !strings.Contains(err.Error(), "--force") {
+		t.Errorf("reinstall without overwrite = %v", err)
+	}
+	if _, err := Install(ctx, src, InstallOptions{Overwrite: true}); err != nil {
+		t.Errorf("reinstall with overwrite: %v", err)
+	}
+
+	bad := writeScript(t, t.TempDir(), "bad", "#!/bin/sh\nexit 1\n")
+	if _, err := Install(ctx, bad, InstallOptions{}); err == nil || !strings.Contains(err.Error(), "not a working") {
+		t.Errorf("Install of a non-plugin = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(Dir(), "bad")); err == nil {
+		t.Error("a plugin that fails describe must not stay installed")
+	}
+	if _, err := os.Stat(filepath.Join(Dir(), ".tmp-bad")); err == nil {
+		t.Error("a failed install must not leave its temporary file")
+	}
+	if _, err := Install(ctx, "http://example.com/p", InstallOptions{}); err == nil {
+		t.Error("plain http must be refused")
+	}
+}
+
+// TestInstallKeepsExtension pins that describe runs on a name ending in the
+// plugin's own extension, which Windows needs to run a .cmd or .bat file.
+func TestInstallKeepsExtension(t *testing.T) {
+	setupHome(t)
+	body := strings.Replace(fakePlugin, "case \"$1\" in\n", "[ \"$1\" = describe ] && basename \"$0\" >> \"$dir/probed\"\ncase \"$1\" in\n", 1)
+	src := writeScript(t, t.TempDir(), "envdrift-fake.cmd", body)
+
+	p, err := Install(context.Background(), src, InstallOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Name != "envdrift-fake" {
+		t.Errorf("Install = %+v", p)
+	}
+	probed, err := os.ReadFile(filepath.Join(Dir(), "probed"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name := strings.TrimSpace(string(probed)); filepath.Ext(name) != ".cmd" {
+		t.Errorf("describe ran on %q; want a .cmd name", name)
+	}
+}
+
+func TestInstallURL(t *testing.T) {
+	setupHome(t)
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/releases/envdrift-fake" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(fakePlugin))
+	}))
+	defer srv.Close()
+	orig := httpClient
+	httpClient = srv.Client()
+	t.Cleanup(func() { httpClient = orig })
+
+	p, err := Install(context.Background(), srv.URL+"/releases/envdrift-fake", InstallOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Name != "envdrift-fake" || p.Manifest.Version != "1.0.0" {
+		t.Errorf("Install = %+v", p)
+	}
+	sum := sha256.Sum256([]byte(fakePlugin))
+	pinned := InstallOptions{Name: "pinned", SHA256: hex.EncodeToString(sum[:])}
+	if _, err := Install(context.Background(), srv.URL+"/releases/envdrift-fake", pinned); err != nil {
+		t.Errorf("Install with the right --sha256: %v", err)
+	}
+	pinned = InstallOptions{Name: "tampered", SHA256: strings.Repeat("0", 64)}
+	if _, err := Install(context.Background(), srv.URL+"/releases/envdrift-fake", pinned); err == nil || !strings.Contains(err.Error(), "--sha256") {
+		t.Errorf("Install with a wrong --sha256 = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(Dir(), "tampered")); err == nil {
+		t.Error("a plugin failing its --sha256 must not be installed")
+	}
+	if _, err := Install(context.Background(), srv.URL+"/releases/envdrift-fake", InstallOptions{SHA256: "abc"}); err == nil {
+		t.Error("a malformed --sha256 must be refused")
+	}
+	if _, err := Install(context.Background(), srv.URL+"/missing", InstallOptions{Name: "x"}); err == nil {
+		t.Error("a 404 must fail the install")
+	}
+}
@@ -0,0 +1,122 @@
+// Package power detects whether the machine runs on battery and how full it
+// is, so the agent can defer background work on a low battery.
+//
+// Linux reads /sys/class/power_supply, macOS parses `pmset -g batt` and
+// Windows calls GetSystemPowerStatus. Anything undetectable (a desktop, an
+// unsupported platform, a failed probe) reports mains power, so detection can
+// only ever defer work when a battery is positively seen draining.
+package power
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// State is the detected power source.
+type State struct {
+	// OnBattery reports the machine running from its battery.
+	OnBattery bool
+	// Percent is the battery charge, or -1 when unknown or without battery.
+	Percent int
+}
+
+// Low reports running on battery at or below threshold percent; a threshold
+// of 0 never reports low.
+func (s State) Low(threshold int) bool {
+	return threshold > 0 && s.OnBattery && s.Percent >= 0 && s.Percent <= threshold
+}
+
+// String renders the state for status output.
+func (s State) String() string {
+	switch {
+	case !s.OnBattery:
+		return "AC power"
+	case s.Percent < 0:
+		return "battery"
+	}
+	return fmt.Sprintf("battery %d%%", s.Percent)
+}
+
+// Seams for tests.
+var (
+	sysfsDir = "/sys/class/power_supply"
+	pmset    = func(ctx context.Context) ([]byte, error) {
+		return exec.CommandContext(ctx, "pmset", "-g", "batt").Output()
+	}
+)
+
+// Detect probes the current power state.
+func Detect() State {
+	switch runtime.GOOS {
+	case "linux":
+		return detectSysfs()
+	case "darwin":
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		out, err := pmset(ctx)
+		if err != nil {
+			return State{Percent: -1}
+		}
+		return parsePmset(string(out))
+	case "windows":
+		return detectWindows()
+	}
+	return State{Percent: -1}
+}
+
+// detectSysfs reads the kernel's power supplies: a "Mains" supply online
+// means AC; otherwise a discharging "Battery" means battery power.
+func detectSysfs() State {
+	state := State{Percent: -1}
+	entries, err := os.ReadDir(sysfsDir)
+	if err != nil {
+		return state
+	}
+	read := func(dir, name string) string {
+		data, _ := os.ReadFile(filepath.Join(sysfsDir, dir, name))
+		return strings.TrimSpace(string(data))
+	}
+	mains, discharging := false, false
+	for _, e := range entries {
+		switch read(e.Name(), "type") {
+		case "Mains":
+			if read(e.Name(), "online") == "1" {
+				mains = true
+			}
+		case "Battery":
+			if read(e.Name(), "scope") == "Device" {
+				continue // a mouse or headset battery
+			}
+			if n, err := strconv.Atoi(read(e.Name(), "capacity")); err == nil {
+				state.Percent = n
+			}
+			if read(e.Name(), "status") == "Discharging" {
+				discharging = true
+			}
+		}
+	}
+	state.OnBattery = discharging && !mains
+	return state
+}
+
+var pmsetPercent = regexp.MustCompile(`(\d+)%`)
+
+// parsePmset reads `pmset -g batt`:
+//
+//	Now drawing from 'Battery Power'
+//	 -InternalBattery-0 (id=1234)	85%; discharging; 4:30 remaining present: true
+func parsePmset(out string) State {
+	state := State{Percent: -1, OnBattery: strings.Contains(out, "'Battery Power'")}
+	if m := pmsetPercent.FindStringSubmatch(out); m != nil {
+		state.Percent, _ = strconv.Atoi(m[1])
+	}
+	return state
+}
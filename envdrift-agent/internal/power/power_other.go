@@ -0,0 +1,8 @@
+//go:build !windows
+
+package power
+
+// detectWindows is only reachable on Windows.
+func detectWindows() State {
+	return State{Percent: -1}
+}
@@ -0,0 +1,73 @@
+package power
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLow(t *testing.T) {
+	tests := []struct {
+		state     State
+		threshold int
+		want      bool
+	}{
+		{State{OnBattery: true, Percent: 15}, 20, true},
+		{State{OnBattery: true, Percent: 20}, 20, true},
+		{State{OnBattery: true, Percent: 21}, 20, false},
+		{State{OnBattery: false, Percent: 5}, 20, false},
+		{State{OnBattery: true, Percent: -1}, 20, false},
+		{State{OnBattery: true, Percent: 5}, 0, false},
+	}
+	for _, tt := range tests {
+		if got := tt.state.Low(tt.threshold); got != tt.want {
+			t.Errorf("%+v.Low(%d) = %v; want %v", tt.state, tt.threshold, got, tt.want)
+		}
+	}
+}
+
+func TestParsePmset(t *testing.T) {
+	battery := "Now drawing from 'Battery Power'\n -InternalBattery-0 (id=4653155)\t18%; discharging; 1:02 remaining present: true\n"
+	if got := parsePmset(battery); !got.OnBattery || got.Percent != 18 {
+		t.Errorf("battery = %+v", got)
+	}
+	ac := "Now drawing from 'AC Power'\n -InternalBattery-0 (id=4653155)\t100%; charged; 0:00 remaining present: true\n"
+	if got := parsePmset(ac); got.OnBattery || got.Percent != 100 {
+		t.Errorf("AC = %+v", got)
+	}
+	if got := parsePmset("Now drawing from 'AC Power'\n"); got.OnBattery || got.Percent != -1 {
+		t.Errorf("desktop = %+v", got)
+	}
+}
+
+func TestDetectSysfs(t *testing.T) {
+	dir := t.TempDir()
+	orig := sysfsDir
+	sysfsDir = dir
+	t.Cleanup(func() { sysfsDir = orig })
+	supply := func(name string, files map[string]string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Join(dir, name), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		for f, v := range files {
+			if err := os.WriteFile(filepath.Join(dir, name, f), []byte(v+"\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	if got := detectSysfs(); got.OnBattery || got.Percent != -1 {
+		t.Errorf("no supplies = %+v", got)
+	}
+	supply("AC", map[string]string{"type": "Mains", "online": "0"})
+	supply("BAT0", map[string]string{"type": "Battery", "capacity": "12", "status": "Discharging"})
+	supply("hidpp_battery_0", map[string]string{"type": "Battery", "scope": "Device", "capacity": "90", "status": "Discharging"})
+	if got := detectSysfs(); !got.OnBattery || got.Percent != 12 {
+		t.Errorf("on battery = %+v", got)
+	}
+	supply("AC", map[string]string{"online": "1"})
+	if got := detectSysfs(); got.OnBattery {
+		t.Errorf("plugged in = %+v", got)
+	}
+}
@@ -0,0 +1,34 @@
+//go:build windows
+
+package power
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+var getSystemPowerStatus = syscall.NewLazyDLL("kernel32.dll").NewProc("GetSystemPowerStatus")
+
+// systemPowerStatus is SYSTEM_POWER_STATUS.
+type systemPowerStatus struct {
+	ACLineStatus        byte
+	BatteryFlag         byte
+	BatteryLifePercent  byte
+	SystemStatusFlag    byte
+	BatteryLifeTime     uint32
+	BatteryFullLifeTime uint32
+}
+
+// detectWindows calls GetSystemPowerStatus: ACLineStatus 0 is battery, and
+// a BatteryLifePercent of 255 is unknown.
+func detectWindows() State {
+	var s systemPowerStatus
+	if r, _, _ := getSystemPowerStatus.Call(uintptr(unsafe.Pointer(&s))); r == 0 {
+		return State{Percent: -1}
+	}
+	state := State{OnBattery: s.ACLineStatus == 0, Percent: -1}
+	if s.BatteryLifePercent <= 100 {
+		state.Percent = int(s.BatteryLifePercent)
+	}
+	return state
+}
@@ -2,10 +2,12 @@
 package project
 
 import (
+	"fmt"
 	"log"
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -20,6 +22,15 @@ var (
 	DefaultExclude     = []string{".env.example", ".env.sample", ".env.keys"}
 )
 
+// Symlink policies for env files that are symbolic links.
+const (
+	// SymlinksFollow treats a symlinked env file like a regular one; its
+	// target is what gets encrypted.
+	SymlinksFollow = "follow"
+	// SymlinksSkip ignores symlinked env files.
+	SymlinksSkip = "skip"
+)
+
 // idleTimeoutPattern matches duration strings like "5m", "30s", "1h", "2d"
 var idleTimeoutPattern = regexp.MustCompile(`^(\d+)(s|m|h|d)$`)
 
@@ -30,6 +41,15 @@ type GuardianConfig struct {
 	Patterns    []string      `toml:"patterns"`
 	Exclude     []string      `toml:"exclude"`
 	Notify      bool          `toml:"notify"`
+	// Symlinks is SymlinksFollow or SymlinksSkip.
+	Symlinks string `toml:"symlinks"`
+	// IgnoreGenerated skips dependency and test fixture directories and
+	// framework-generated files such as .env.local.php.
+	IgnoreGenerated bool `toml:"ignore_generated"`
+	// Debounce is the per-file window in which watcher events are
+	// coalesced. It comes from the global guardian.toml only; zero
+	// coalesces nothing.
+	Debounce time.Duration `toml:"-"`
 
 	// Raw idle_timeout string for TOML parsing
 	IdleTimeoutStr string `toml:"idle_timeout"`
@@ -37,8 +57,10 @@ type GuardianConfig struct {
 
 // envdriftConfig represents the envdrift.toml structure used by the agent.
 type envdriftConfig struct {
-	Guardian guardianToml `toml:"guardian"`
-	Vault    vaultToml    `toml:"vault"`
+	Guardian guardianToml  `toml:"guardian"`
+	Vault    vaultToml     `toml:"vault"`
+	Services []serviceToml `toml:"services"`
+	Naming   namingToml    `toml:"naming"`
 }
 
 // guardianToml is the raw TOML representation.
@@ -48,18 +70,71 @@ type guardianToml struct {
 	Patterns    []string `toml:"patterns"`
 	Exclude     []string `toml:"exclude"`
 	Notify      *bool    `toml:"notify"`
+	Symlinks    string   `toml:"symlinks"`
+	// IgnoreGenerated is a pointer so an explicit false is distinguishable
+	// from an absent key.
+	IgnoreGenerated *bool `toml:"ignore_generated"`
 }
 
+// vaultToml is the [vault] table. Provider sections are pointers so an
+// omitted provider can be inferred from the one section present, the same
+// rule the CLI's _resolve_vault_provider applies.
 type vaultToml struct {
-	Sync vaultSyncToml `toml:"sync"`
+	Provider  string              `toml:"provider"`
+	AWS       *vaultAWSToml       `toml:"aws"`
+	Azure     *vaultAzureToml     `toml:"azure"`
+	Hashicorp *vaultHashicorpToml `toml:"hashicorp"`
+	GCP       *vaultGCPToml       `toml:"gcp"`
+	// Kubernetes is agent-only; the Python CLI ignores the section.
+	Kubernetes *vaultKubernetesToml `toml:"kubernetes"`
+	Sync       vaultSyncToml        `toml:"sync"`
+}
+
+type vaultAWSToml struct {
+	Region  string `toml:"region"`
+	Profile string `toml:"profile"`
+}
+
+type vaultAzureToml struct {
+	VaultURL string `toml:"vault_url"`
+	TenantID string `toml:"tenant_id"`
+	ClientID string `toml:"client_id"`
+}
+
+type vaultHashicorpToml struct {
+	URL          string `toml:"url"`
+	MountPoint   string `toml:"mount_point"`
+	KVVersion    int    `toml:"kv_version"`
+	Namespace    string `toml:"namespace"`
+	AuthMethod   string `toml:"auth_method"`
+	RoleID       string `toml:"role_id"`
+	AppRoleMount string `toml:"approle_mount"`
+}
+
+type vaultGCPToml struct {
+	ProjectID string `toml:"project_id"`
+}
+
+type vaultKubernetesToml struct {
+	Namespace  string `toml:"namespace"`
+	Context    string `toml:"context"`
+	Kubeconfig string `toml:"kubeconfig"`
 }
 
 type vaultSyncToml struct {
-	Mappings []vaultSyncMappingToml `toml:"mappings"`
+	DefaultVaultName string                 `toml:"default_vault_name"`
+	Mappings         []vaultSyncMappingToml `toml:"mappings"`
+	// SecretName is agent-only: a secret name template mapping every
+	// [[services]] environment without an explicit mapping.
+	SecretName string `toml:"secret_name"`
 }
 
 type vaultSyncMappingToml struct {
-	EnvFile string `toml:"env_file"`
+	SecretName  string `toml:"secret_name"`
+	FolderPath  string `toml:"folder_path"`
+	Environment string `toml:"environment"`
+	VaultName   string `toml:"vault_name"`
+	EnvFile     string `toml:"env_file"`
 }
 
 // pyprojectToml extracts the [tool.envdrift] table from a pyproject.toml. The
@@ -97,7 +172,7 @@ func LoadProjectConfigWithDefaults(projectPath string, defaults *GuardianConfig)
 		defaults = DefaultGuardianConfig()
 	}
 
-	cfg, found, err := discoverEnvdriftConfig(projectPath)
+	cfg, _, found, err := discoverEnvdriftConfig(projectPath)
 	if err != nil {
 		return nil, err
 	}
@@ -105,15 +180,37 @@ func LoadProjectConfigWithDefaults(projectPath string, defaults *GuardianConfig)
 		return defaults.clone(), nil
 	}
 
-	return parseGuardianConfig(&cfg.Guardian, cfg.Vault.Sync.Mappings, defaults)
+	guardian, err := parseGuardianConfig(&cfg.Guardian, cfg.Vault.Sync.Mappings, defaults)
+	if err != nil {
+		return nil, err
+	}
+	naming, err := ParseNaming(cfg.Naming.EnvFile)
+	if err != nil {
+		return nil, err
+	}
+	// Files named by a [naming] convention are watched without listing them
+	// in patterns too, except its example and sample schemas.
+	if pattern := naming.Pattern(); pattern != "" && !slices.Contains(guardian.Patterns, pattern) {
+		guardian.Patterns = append(guardian.Patterns, pattern)
+		if strings.Contains(pattern, "*") {
+			for _, schema := range []string{"example", "sample"} {
+				if name := filepath.Base(naming.Path("", schema)); !slices.Contains(guardian.Exclude, name) {
+					guardian.Exclude = append(guardian.Exclude, name)
+				}
+			}
+		}
+	}
+	return guardian, nil
 }
 
 // discoverEnvdriftConfig mirrors the CLI's find_config walk: starting at dir
 // and moving up to (but not including) the filesystem root, return the first
-// envdrift.toml, else the first pyproject.toml containing [tool.envdrift].
+// envdrift.toml, else the first pyproject.toml containing [tool.envdrift],
+// along with the directory it was found in (relative config paths such as a
+// mapping's folder_path resolve against it).
 // A malformed pyproject.toml is skipped (like the CLI); a malformed
 // envdrift.toml is an error (pre-existing behavior).
-func discoverEnvdriftConfig(dir string) (*envdriftConfig, bool, error) {
+func discoverEnvdriftConfig(dir string) (*envdriftConfig, string, bool, error) {
 	// Match Python's Path.resolve(): absolute with symlinks resolved, so the
 	// walk sees the same ancestor chain the CLI saw at registration time.
 	if resolved, err := filepath.EvalSymlinks(dir); err == nil {
@@ -121,7 +218,7 @@ func discoverEnvdriftConfig(dir string) (*envdriftConfig, bool, error) {
 	}
 	current, err := filepath.Abs(dir)
 	if err != nil {
-		return nil, false, err
+		return nil, "", false, err
 	}
 
 	for filepath.Dir(current) != current {
@@ -130,23 +227,23 @@ func discoverEnvdriftConfig(dir string) (*envdriftConfig, bool, error) {
 		case err == nil:
 			var cfg envdriftConfig
 			if err := toml.Unmarshal(data, &cfg); err != nil {
-				return nil, false, err
+				return nil, "", false, err
 			}
-			return &cfg, true, nil
+			return &cfg, current, true, nil
 		case !os.IsNotExist(err):
 			// An existing-but-unreadable envdrift.toml is an error, not a
 			// silent skip (pre-existing behavior for the project's own file).
-			return nil, false, err
+			return nil, "", false, err
 		}
 
 		if cfg, ok := readPyprojectEnvdrift(filepath.Join(current, "pyproject.toml")); ok {
-			return cfg, true, nil
+			return cfg, current, true, nil
 		}
 
 		current = filepath.Dir(current)
 	}
 
-	return nil, false, nil
+	return nil, "", false, nil
 }
 
 // readPyprojectEnvdrift returns the [tool.envdrift] config from a
@@ -172,11 +269,13 @@ func readPyprojectEnvdrift(path string) (*envdriftConfig, bool) {
 // DefaultGuardianConfig returns a GuardianConfig with default values.
 func DefaultGuardianConfig() *GuardianConfig {
 	return &GuardianConfig{
-		Enabled:     false,
-		IdleTimeout: DefaultIdleTimeout,
-		Patterns:    DefaultPatterns,
-		Exclude:     DefaultExclude,
-		Notify:      true,
+		Enabled:         false,
+		IdleTimeout:     DefaultIdleTimeout,
+		Patterns:        DefaultPatterns,
+		Exclude:         DefaultExclude,
+		Notify:          true,
+		Symlinks:        SymlinksFollow,
+		IgnoreGenerated: true,
 	}
 }
 
@@ -220,11 +319,30 @@ func parseGuardianConfig(raw *guardianToml, vaultMappings []vaultSyncMappingToml
 		cfg.Notify = *raw.Notify
 	}
 
+	if raw.Symlinks != "" {
+		if err := ValidateSymlinks(raw.Symlinks); err != nil {
+			return nil, err
+		}
+		cfg.Symlinks = raw.Symlinks
+	}
+
+	if raw.IgnoreGenerated != nil {
+		cfg.IgnoreGenerated = *raw.IgnoreGenerated
+	}
+
 	cfg.Patterns = appendVaultEnvFilePatterns(cfg.Patterns, vaultMappings)
 
 	return cfg, nil
 }
 
+// ValidateSymlinks reports an error unless policy is a known symlink policy.
+func ValidateSymlinks(policy string) error {
+	if policy != SymlinksFollow && policy != SymlinksSkip {
+		return fmt.Errorf("guardian.symlinks: %q is not %s or %s", policy, SymlinksFollow, SymlinksSkip)
+	}
+	return nil
+}
+
 func appendVaultEnvFilePatterns(patterns []string, mappings []vaultSyncMappingToml) []string {
 	result := append([]string{}, patterns...)
 
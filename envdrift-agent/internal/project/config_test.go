@@ -149,6 +149,55 @@ idle_timeout = "1m"
 	if cfg.Notify != true {
 		t.Errorf("Expected default Notify=true, got %v", cfg.Notify)
 	}
+
+	if cfg.Symlinks != SymlinksFollow {
+		t.Errorf("Expected default Symlinks=follow, got %q", cfg.Symlinks)
+	}
+}
+
+func TestLoadProjectConfig_Symlinks(t *testing.T) {
+	tmpDir := t.TempDir()
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(tmpDir, "envdrift.toml"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("[guardian]\nsymlinks = \"skip\"\n")
+	cfg, err := LoadProjectConfig(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Symlinks != SymlinksSkip {
+		t.Errorf("Expected Symlinks=skip, got %q", cfg.Symlinks)
+	}
+
+	write("[guardian]\nsymlinks = \"maybe\"\n")
+	if _, err := LoadProjectConfig(tmpDir); err == nil {
+		t.Error("Expected an error for an unknown symlinks policy")
+	}
+}
+
+func TestLoadProjectConfig_IgnoreGenerated(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfg, err := LoadProjectConfig(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cfg.IgnoreGenerated {
+		t.Error("Expected IgnoreGenerated to default to true")
+	}
+
+	if err := os.WriteFile(filepath.Join(tmpDir, "envdrift.toml"), []byte("[guardian]\nignore_generated = false\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if cfg, err = LoadProjectConfig(tmpDir); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.IgnoreGenerated {
+		t.Error("Expected ignore_generated = false to be honored")
+	}
 }
 
 func TestLoadProjectConfig_AppendsVaultEnvFilePatterns(t *testing.T) {
@@ -0,0 +1,195 @@
+package project
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jainal09/envdrift-agent/internal/watcher"
+)
+
+// DefaultEnvFileNaming is dotenvx's convention: .env.<env>, next to a bare
+// .env for the default environment.
+const DefaultEnvFileNaming = ".env.{env}"
+
+// namingToml is the agent-only [naming] table.
+type namingToml struct {
+	// EnvFile is the env file path template, relative to the folder the
+	// files belong to, e.g. "config/{env}.env".
+	EnvFile string `toml:"env_file"`
+}
+
+// reservedEnvironments are .env.<name> files that are not an environment's
+// env file: schemas, the key store and dotenv-vault's bundle.
+var reservedEnvironments = map[string]bool{"example": true, "sample": true, "keys": true, "vault": true}
+
+// Naming is a project's env file naming convention: a slash-separated path
+// template, relative to a folder (a project, service or mapping directory),
+// with one {env} placeholder for the environment name. A bare .env is the
+// folder's default environment under every convention. The zero Naming is
+// DefaultEnvFileNaming.
+type Naming struct {
+	template string
+	parts    []string // template components; one holds {env}
+	envPart  int      // index of that component
+}
+
+// ParseNaming checks a [naming] env_file template: a relative path without
+// ".." whose components include exactly one {env}, with no other text in
+// braces.
+func ParseNaming(tmpl string) (Naming, error) {
+	if tmpl == "" {
+		tmpl = DefaultEnvFileNaming
+	}
+	if strings.Count(tmpl, "{env}") != 1 {
+		return Naming{}, fmt.Errorf("naming.env_file: %q must contain {env} exactly once", tmpl)
+	}
+	if rest := strings.Replace(tmpl, "{env}", "", 1); strings.ContainsAny(rest, "{}*?[\\") {
+		return Naming{}, fmt.Errorf("naming.env_file: %q may use no placeholder or wildcard other than {env}", tmpl)
+	}
+	if strings.HasPrefix(tmpl, "/") || filepath.IsAbs(tmpl) {
+		return Naming{}, fmt.Errorf("naming.env_file: %q must be relative to the project", tmpl)
+	}
+	n := Naming{template: tmpl, parts: strings.Split(tmpl, "/")}
+	for i, part := range n.parts {
+		if part == "" || part == "." || part == ".." {
+			return Naming{}, fmt.Errorf("naming.env_file: %q has an empty, . or .. component", tmpl)
+		}
+		if strings.Contains(part, "{env}") {
+			n.envPart = i
+		}
+	}
+	return n, nil
+}
+
+// LoadNaming returns the [naming] convention of the envdrift config
+// governing dir; DefaultEnvFileNaming without a config or a [naming] table.
+func LoadNaming(dir string) (Naming, error) {
+	cfg, _, found, err := discoverEnvdriftConfig(dir)
+	if err != nil || !found {
+		return Naming{}, err
+	}
+	return ParseNaming(cfg.Naming.EnvFile)
+}
+
+// init fills in the default convention for the zero Naming.
+func (n Naming) init() Naming {
+	if n.parts == nil {
+		n, _ = ParseNaming(DefaultEnvFileNaming)
+	}
+	return n
+}
+
+// String returns the template.
+func (n Naming) String() string {
+	return n.init().template
+}
+
+// Path returns the env file of env in folder; "" names the bare .env.
+func (n Naming) Path(folder, env string) string {
+	if env == "" {
+		return filepath.Join(folder, ".env")
+	}
+	n = n.init()
+	return filepath.Join(folder, filepath.FromSlash(strings.Replace(n.template, "{env}", env, 1)))
+}
+
+// Environment reports whether path is an env file under the convention, and
+// if so its environment and the folder it belongs to. A bare .env is the
+// default environment, reported as "". Schemas (.env.example, .env.sample),
+// the key store, a .env.vault and editors' scratch copies are not env files.
+func (n Naming) Environment(path string) (env, folder string, ok bool) {
+	n = n.init()
+	path = filepath.Clean(path)
+	base := filepath.Base(path)
+	if watcher.IsEditorTemp(base) {
+		return "", "", false
+	}
+	if base == ".env" {
+		return "", filepath.Dir(path), true
+	}
+
+	dir := path
+	for i := len(n.parts) - 1; i >= 0; i-- {
+		name := filepath.Base(dir)
+		if i == n.envPart {
+			prefix, suffix, _ := strings.Cut(n.parts[i], "{env}")
+			if len(name) <= len(prefix)+len(suffix) || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, suffix) {
+				return "", "", false
+			}
+			env = name[len(prefix) : len(name)-len(suffix)]
+		} else if name != n.parts[i] {
+			return "", "", false
+		}
+		dir = filepath.Dir(dir)
+	}
+	if reservedEnvironments[env] || strings.ContainsAny(env, `/\`) {
+		return "", "", false
+	}
+	return env, dir, true
+}
+
+// Pattern returns a base-name glob the watcher can match the convention's
+// env files with, or "" when .env* already covers them.
+func (n Naming) Pattern() string {
+	n = n.init()
+	last := n.parts[len(n.parts)-1]
+	if n.envPart == len(n.parts)-1 {
+		last = strings.Replace(last, "{env}", "*", 1)
+	}
+	if strings.HasPrefix(last, ".env") {
+		return ""
+	}
+	return last
+}
+
+// Environments lists the environments with an env file in folder, sorted;
+// the bare .env is reported as "".
+func (n Naming) Environments(folder string) []string {
+	n = n.init()
+	seen := make(map[string]bool)
+	var envs []string
+	add := func(path string) {
+		if env, f, ok := n.Environment(path); ok && f == filepath.Clean(folder) && !seen[env] {
+			seen[env] = true
+			envs = append(envs, env)
+		}
+	}
+	if info, err := os.Stat(filepath.Join(folder, ".env")); err == nil && !info.IsDir() {
+		add(filepath.Join(folder, ".env"))
+	}
+	for _, path := range n.candidates(folder) {
+		add(path)
+	}
+	sort.Strings(envs)
+	return envs
+}
+
+// candidates lists the regular files in folder at the template's depth whose
+// directories match it, leaving the name checks to Environment.
+func (n Naming) candidates(folder string) []string {
+	dirs := []string{filepath.Clean(folder)}
+	for i, part := range n.parts {
+		last := i == len(n.parts)-1
+		var next []string
+		for _, dir := range dirs {
+			if i != n.envPart {
+				path := filepath.Join(dir, part)
+				if info, err := os.Stat(path); err == nil && info.IsDir() != last {
+					next = append(next, path)
+				}
+				continue
+			}
+			entries, _ := os.ReadDir(dir)
+			for _, e := range entries {
+				if e.IsDir() != last {
+					next = append(next, filepath.Join(dir, e.Name()))
+				}
+			}
+		}
+		dirs = next
+	}
+	return dirs
+}
@@ -0,0 +1,111 @@
+package project
+
+import (
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestParseNaming(t *testing.T) {
+	for _, ok := range []string{"", ".env.{env}", "env.{env}", "config/{env}.env", "{env}/.env"} {
+		if _, err := ParseNaming(ok); err != nil {
+			t.Errorf("ParseNaming(%q): %v", ok, err)
+		}
+	}
+	for _, bad := range []string{"config.env", "{env}.{env}", "/etc/{env}.env", "../{env}.env", "config//{env}", "{service}/{env}.env", "*.{env}"} {
+		if _, err := ParseNaming(bad); err == nil {
+			t.Errorf("ParseNaming(%q) succeeded", bad)
+		}
+	}
+}
+
+func TestNamingEnvironment(t *testing.T) {
+	root := filepath.Join("/src", "app")
+	tests := []struct {
+		tmpl, path, env, folder string
+		ok                      bool
+	}{
+		{"", ".env", "", ".", true},
+		{"", ".env.production.local", "production.local", ".", true},
+		{"", ".env.example", "", "", false},
+		{"", ".env.keys", "", "", false},
+		{"", "production.env", "", "", false},
+		{"env.{env}", "env.staging", "staging", ".", true},
+		{"env.{env}", ".env", "", ".", true},
+		{"config/{env}.env", "config/production.env", "production", ".", true},
+		{"config/{env}.env", "other/production.env", "", "", false},
+		{"config/{env}.env", "config/.env", "", "config", true},
+		{"config/{env}.env", "config/sample.env", "", "", false},
+		{"{env}/app.env", "staging/app.env", "staging", ".", true},
+	}
+	for _, tt := range tests {
+		n, err := ParseNaming(tt.tmpl)
+		if err != nil {
+			t.Fatal(err)
+		}
+		env, folder, ok := n.Environment(filepath.Join(root, filepath.FromSlash(tt.path)))
+		if ok != tt.ok || env != tt.env || (ok && folder != filepath.Join(root, tt.folder)) {
+			t.Errorf("%q: Environment(%s) = %q, %q, %v; want %q, %q, %v", tt.tmpl, tt.path, env, folder, ok, tt.env, tt.folder, tt.ok)
+		}
+		if ok && env != "" {
+			if got := n.Path(filepath.Join(root, tt.folder), env); got != filepath.Join(root, filepath.FromSlash(tt.path)) {
+				t.Errorf("%q: Path(%s) = %s; want %s", tt.tmpl, env, got, tt.path)
+			}
+		}
+	}
+}
+
+func TestNamingEnvironments(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{".env", "config/production.env", "config/staging.env", "config/example.env", "config/notes.txt", "staging.env"} {
+		writeFile(t, filepath.Join(root, filepath.FromSlash(name)), "A=1\n")
+	}
+	n, _ := ParseNaming("config/{env}.env")
+	if got, want := n.Environments(root), []string{"", "production", "staging"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Environments = %q; want %q", got, want)
+	}
+	if got := (Naming{}).Environments(root); !reflect.DeepEqual(got, []string{""}) {
+		t.Errorf("default Environments = %q; want only the bare .env", got)
+	}
+}
+
+func TestLoadProjectConfig_NamingConvention(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "envdrift.toml"), `
+[naming]
+env_file = "config/{env}.env"
+
+[vault.sync]
+secret_name = "{service}-{env}"
+
+[vault.aws]
+region = "us-east-1"
+
+[[services]]
+name = "api"
+path = "api"
+`)
+	writeFile(t, filepath.Join(root, "api", "config", "staging.env"), "A=1\n")
+
+	cfg, err := LoadProjectConfig(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Contains(cfg.Patterns, "*.env") || !slices.Contains(cfg.Exclude, "example.env") {
+		t.Errorf("patterns %v, exclude %v; want the convention's files watched and its schema excluded", cfg.Patterns, cfg.Exclude)
+	}
+
+	settings, _, err := LoadVaultSettings(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(settings.Mappings) != 1 || settings.Mappings[0].SecretName != "api-staging" {
+		t.Errorf("mappings = %+v; want the convention's staging environment", settings.Mappings)
+	}
+
+	writeFile(t, filepath.Join(root, "envdrift.toml"), "[naming]\nenv_file = \"config.env\"\n")
+	if _, err := LoadProjectConfig(root); err == nil {
+		t.Error("a convention without {env} should be rejected")
+	}
+}
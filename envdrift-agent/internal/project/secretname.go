@@ -0,0 +1,95 @@
+package project
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+)
+
+// secretNamePlaceholder matches a {name} in a secret name template.
+var secretNamePlaceholder = regexp.MustCompile(`\{([a-z_]+)\}`)
+
+// ExpandSecretName fills a secret name template: {service} is the service
+// name, {env} the environment and {project} the base name of the directory
+// holding envdrift.toml. A name without placeholders is returned as is; an
+// unknown placeholder, or {service} outside any [[services]] directory, is an
+// error.
+func ExpandSecretName(tmpl, service, env, configDir string) (string, error) {
+	var err error
+	name := secretNamePlaceholder.ReplaceAllStringFunc(tmpl, func(m string) string {
+		switch key := m[1 : len(m)-1]; key {
+		case "service":
+			if service == "" && err == nil {
+				err = fmt.Errorf("secret name %q uses {service} outside any [[services]] path", tmpl)
+			}
+			return service
+		case "env":
+			return env
+		case "project":
+			return filepath.Base(configDir)
+		default:
+			if err == nil {
+				err = fmt.Errorf("secret name %q: unknown placeholder {%s} (use {service}, {env} or {project})", tmpl, key)
+			}
+			return m
+		}
+	})
+	return name, err
+}
+
+// servicesOf loads the [[services]] of an already discovered config.
+func servicesOf(cfg *envdriftConfig, dir string) ([]Service, error) {
+	if len(cfg.Services) == 0 {
+		return nil, nil
+	}
+	return LoadServices(dir)
+}
+
+// serviceMappings maps every environment of every service that no explicit
+// mapping covers to the secret the [vault.sync] secret_name template names.
+// A service's environments are those of the env files in its directory, or
+// its own Environment when it has none yet (so pull can create them).
+func serviceMappings(tmpl string, services []Service, explicit []VaultMapping, vaultName, configDir string) ([]VaultMapping, error) {
+	covered := make(map[string]bool, len(explicit))
+	for _, m := range explicit {
+		covered[m.FolderPath+"\x00"+m.Environment] = true
+	}
+	var mappings []VaultMapping
+	for _, s := range services {
+		for _, env := range serviceEnvironments(s) {
+			if covered[s.Path+"\x00"+env] {
+				continue
+			}
+			name, err := ExpandSecretName(tmpl, s.Name, env, configDir)
+			if err != nil {
+				return nil, fmt.Errorf("[vault.sync] secret_name: %w", err)
+			}
+			mappings = append(mappings, VaultMapping{
+				SecretName:  name,
+				FolderPath:  s.Path,
+				Environment: env,
+				VaultName:   vaultName,
+			})
+		}
+	}
+	return mappings, nil
+}
+
+// serviceEnvironments lists the environments of the service's env files,
+// sorted, or its own Environment when it has none yet.
+func serviceEnvironments(s Service) []string {
+	seen := make(map[string]bool)
+	var envs []string
+	for _, file := range s.EnvFiles() {
+		if env := s.EnvironmentOf(file); !seen[env] {
+			seen[env] = true
+			envs = append(envs, env)
+		}
+	}
+	if len(envs) == 0 {
+		return []string{s.Environment}
+	}
+	sort.Strings(envs)
+	return envs
+}
@@ -0,0 +1,74 @@
+package project
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExpandSecretName(t *testing.T) {
+	got, err := ExpandSecretName("{project}/{service}-{env}-dotenvx-key", "payments", "staging", "/src/shop")
+	if err != nil || got != "shop/payments-staging-dotenvx-key" {
+		t.Errorf("ExpandSecretName = %q, %v", got, err)
+	}
+	if got, err := ExpandSecretName("myapp/prod-key", "", "production", "/src/shop"); err != nil || got != "myapp/prod-key" {
+		t.Errorf("plain name = %q, %v", got, err)
+	}
+	if _, err := ExpandSecretName("{service}-key", "", "production", "/src/shop"); err == nil {
+		t.Error("{service} without a service succeeded")
+	}
+	if _, err := ExpandSecretName("{team}-key", "payments", "production", "/src/shop"); err == nil ||
+		!strings.Contains(err.Error(), "{team}") {
+		t.Errorf("unknown placeholder error = %v", err)
+	}
+}
+
+func TestLoadVaultSettings_SecretNameTemplate(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "envdrift.toml"), `
+[vault]
+provider = "aws"
+
+[vault.sync]
+secret_name = "{service}-{env}-dotenvx-key"
+
+[[vault.sync.mappings]]
+secret_name = "legacy/{service}-prod"
+folder_path = "services/payments"
+
+[[services]]
+name = "payments"
+path = "services/payments"
+
+[[services]]
+name = "billing"
+path = "services/billing"
+environment = "development"
+`)
+	payments := filepath.Join(root, "services", "payments")
+	writeFile(t, filepath.Join(payments, ".env"), "A=1\n")
+	writeFile(t, filepath.Join(payments, ".env.staging"), "A=1\n")
+	writeFile(t, filepath.Join(payments, ".env.example"), "A=\n")
+
+	s, found, err := LoadVaultSettings(root)
+	if err != nil || !found {
+		t.Fatalf("LoadVaultSettings = found %v, err %v", found, err)
+	}
+	got := make(map[string]string)
+	for _, m := range s.Mappings {
+		got[filepath.Base(m.FolderPath)+"/"+m.Environment] = m.SecretName
+	}
+	want := map[string]string{
+		"payments/production": "legacy/payments-prod",
+		"payments/staging":    "payments-staging-dotenvx-key",
+		"billing/development": "billing-development-dotenvx-key",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("mappings = %v; want %v", got, want)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s -> %q; want %q", k, got[k], v)
+		}
+	}
+}
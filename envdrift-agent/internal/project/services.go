@@ -0,0 +1,100 @@
+package project
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/jainal09/envdrift-agent/internal/paths"
+)
+
+// Service is one [[services]] entry: a monorepo subdirectory under a name,
+// so its env files are reported as "payments staging" rather than by path.
+type Service struct {
+	Name string
+	Path string // absolute, resolved against the config's directory
+	// Environment names the service's bare .env; a .env.<env> file, or one
+	// named by the project's [naming] convention, names its own.
+	Environment string
+	// Naming is the project's env file naming convention.
+	Naming Naming
+}
+
+type serviceToml struct {
+	Name        string `toml:"name"`
+	Path        string `toml:"path"`
+	Environment string `toml:"environment"`
+}
+
+// LoadServices reads the [[services]] of the envdrift config governing
+// projectPath, deepest path first so FindService picks the closest one. No
+// config or no services is an empty list. A service needs a unique name.
+func LoadServices(projectPath string) ([]Service, error) {
+	cfg, dir, found, err := discoverEnvdriftConfig(projectPath)
+	if err != nil || !found {
+		return nil, err
+	}
+	naming, err := ParseNaming(cfg.Naming.EnvFile)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	var services []Service
+	for _, s := range cfg.Services {
+		if s.Name == "" {
+			return nil, fmt.Errorf("[[services]] entry with path %q has no name", s.Path)
+		}
+		if seen[s.Name] {
+			return nil, fmt.Errorf("[[services]] name %q is used twice", s.Name)
+		}
+		seen[s.Name] = true
+		path := s.Path
+		if path == "" {
+			path = "."
+		}
+		env := s.Environment
+		if env == "" {
+			env = DefaultVaultEnvironment
+		}
+		services = append(services, Service{
+			Name:        s.Name,
+			Path:        filepath.Clean(configRelativePath(dir, path)),
+			Environment: env,
+			Naming:      naming,
+		})
+	}
+	sort.SliceStable(services, func(i, j int) bool { return len(services[i].Path) > len(services[j].Path) })
+	return services, nil
+}
+
+// FindService returns the service whose directory holds path, the deepest
+// one when services nest.
+func FindService(services []Service, path string) (Service, bool) {
+	path = filepath.Clean(path)
+	for _, s := range services {
+		if paths.Within(s.Path, path) {
+			return s, true
+		}
+	}
+	return Service{}, false
+}
+
+// EnvironmentOf names the environment of one of the service's env files:
+// the {env} of the naming convention (.env.<env> by default), or the
+// service's Environment for a bare .env.
+func (s Service) EnvironmentOf(file string) string {
+	if env, _, ok := s.Naming.Environment(file); ok && env != "" {
+		return env
+	}
+	return s.Environment
+}
+
+// EnvFiles returns the service's env files, sorted by environment with the
+// bare .env first.
+func (s Service) EnvFiles() []string {
+	var files []string
+	for _, env := range s.Naming.Environments(s.Path) {
+		files = append(files, s.Naming.Path(s.Path, env))
+	}
+	return files
+}
@@ -0,0 +1,56 @@
+package project
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadServices(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "envdrift.toml"), `
+[[services]]
+name = "payments"
+path = "services/payments"
+
+[[services]]
+name = "payments-worker"
+path = "services/payments/worker"
+environment = "development"
+`)
+
+	services, err := LoadServices(filepath.Join(root, "services"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(services) != 2 || services[0].Name != "payments-worker" {
+		t.Fatalf("services = %+v; want the deepest first", services)
+	}
+
+	s, ok := FindService(services, filepath.Join(root, "services", "payments", ".env.staging"))
+	if !ok || s.Name != "payments" || s.EnvironmentOf(".env.staging") != "staging" {
+		t.Errorf("FindService(payments/.env.staging) = %+v, %v", s, ok)
+	}
+	s, ok = FindService(services, filepath.Join(root, "services", "payments", "worker", ".env"))
+	if !ok || s.Name != "payments-worker" || s.EnvironmentOf(".env") != "development" {
+		t.Errorf("FindService(worker/.env) = %+v, %v", s, ok)
+	}
+	if _, ok := FindService(services, filepath.Join(root, "services", "paymentsx", ".env")); ok {
+		t.Error("a sibling directory sharing the prefix matched")
+	}
+	if s := services[1]; s.EnvironmentOf(".env") != DefaultVaultEnvironment {
+		t.Errorf("default environment = %q", s.EnvironmentOf(".env"))
+	}
+}
+
+func TestLoadServices_Invalid(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "envdrift.toml"), "[[services]]\npath = \"api\"\n")
+	if _, err := LoadServices(root); err == nil || !strings.Contains(err.Error(), "no name") {
+		t.Errorf("unnamed service error = %v", err)
+	}
+	writeFile(t, filepath.Join(root, "envdrift.toml"), "[[services]]\nname = \"a\"\n\n[[services]]\nname = \"a\"\n")
+	if _, err := LoadServices(root); err == nil || !strings.Contains(err.Error(), "twice") {
+		t.Errorf("duplicate service error = %v", err)
+	}
+}
@@ -0,0 +1,183 @@
+package project
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jainal09/envdrift-agent/internal/vault"
+)
+
+// DefaultVaultEnvironment is a sync mapping's environment when it sets none,
+// matching the CLI's ServiceMapping default.
+const DefaultVaultEnvironment = "production"
+
+// VaultMapping is one [[vault.sync.mappings]] entry: which vault secret holds
+// the private key for the env files in FolderPath.
+type VaultMapping struct {
+	SecretName  string
+	FolderPath  string // absolute, resolved against the config's directory
+	Environment string
+	VaultName   string
+}
+
+// VaultSettings is a project's [vault] configuration.
+type VaultSettings struct {
+	Config   vault.Config
+	Mappings []VaultMapping
+	// ConfigDir is the directory of the envdrift.toml/pyproject.toml the
+	// settings came from.
+	ConfigDir string
+}
+
+// LoadVaultSettings reads the [vault] tables of the envdrift config governing
+// projectPath. found is false when there is no config, or the config has no
+// vault provider and no sync mappings.
+func LoadVaultSettings(projectPath string) (*VaultSettings, bool, error) {
+	cfg, dir, found, err := discoverEnvdriftConfig(projectPath)
+	if err != nil || !found {
+		return nil, false, err
+	}
+
+	v := cfg.Vault
+	provider, err := resolveVaultProvider(v)
+	if err != nil {
+		return nil, false, err
+	}
+	if provider == "" && len(v.Sync.Mappings) == 0 && v.Sync.SecretName == "" {
+		return nil, false, nil
+	}
+	if provider == "" {
+		// Mappings without any provider config: the CLI falls back to azure.
+		provider = "azure"
+	}
+
+	settings := &VaultSettings{
+		Config:    vault.Config{Provider: provider},
+		ConfigDir: dir,
+	}
+	if v.AWS != nil {
+		settings.Config.AWS = vault.AWSConfig{Region: v.AWS.Region, Profile: v.AWS.Profile}
+	}
+	if v.Azure != nil {
+		settings.Config.Azure = vault.AzureConfig{
+			VaultURL: v.Azure.VaultURL,
+			TenantID: v.Azure.TenantID,
+			ClientID: v.Azure.ClientID,
+		}
+	}
+	if h := v.Hashicorp; h != nil {
+		settings.Config.Hashicorp = vault.HashicorpConfig{
+			URL:          h.URL,
+			MountPoint:   h.MountPoint,
+			KVVersion:    h.KVVersion,
+			Namespace:    h.Namespace,
+			AuthMethod:   h.AuthMethod,
+			RoleID:       h.RoleID,
+			AppRoleMount: h.AppRoleMount,
+		}
+	}
+	if v.GCP != nil {
+		settings.Config.GCP = vault.GCPConfig{ProjectID: v.GCP.ProjectID}
+	}
+	if k := v.Kubernetes; k != nil {
+		settings.Config.Kubernetes = vault.KubernetesConfig{
+			Namespace:  k.Namespace,
+			Context:    k.Context,
+			Kubeconfig: configRelativePath(dir, k.Kubeconfig),
+		}
+	}
+	services, err := servicesOf(cfg, dir)
+	if err != nil {
+		return nil, false, err
+	}
+	for _, m := range v.Sync.Mappings {
+		if m.SecretName == "" {
+			continue
+		}
+		folder := m.FolderPath
+		if folder == "" {
+			folder = "."
+		}
+		if !filepath.IsAbs(folder) {
+			folder = filepath.Join(dir, folder)
+		}
+		env := m.Environment
+		if env == "" {
+			env = DefaultVaultEnvironment
+		}
+		vaultName := m.VaultName
+		if vaultName == "" {
+			vaultName = v.Sync.DefaultVaultName
+		}
+		folder = filepath.Clean(folder)
+		service, _ := FindService(services, folder)
+		name, err := ExpandSecretName(m.SecretName, service.Name, env, dir)
+		if err != nil {
+			return nil, false, fmt.Errorf("[[vault.sync.mappings]] for %s: %w", folder, err)
+		}
+		settings.Mappings = append(settings.Mappings, VaultMapping{
+			SecretName:  name,
+			FolderPath:  folder,
+			Environment: env,
+			VaultName:   vaultName,
+		})
+	}
+	if v.Sync.SecretName != "" {
+		derived, err := serviceMappings(v.Sync.SecretName, services, settings.Mappings, v.Sync.DefaultVaultName, dir)
+		if err != nil {
+			return nil, false, err
+		}
+		settings.Mappings = append(settings.Mappings, derived...)
+	}
+	return settings, true, nil
+}
+
+// resolveVaultProvider returns the explicit [vault] provider, or the one
+// implied by the single [vault.<provider>] section present. Several sections
+// without an explicit provider are ambiguous and rejected, as in the CLI.
+func resolveVaultProvider(v vaultToml) (string, error) {
+	if v.Provider != "" {
+		return strings.ToLower(v.Provider), nil
+	}
+	var present []string
+	if v.AWS != nil {
+		present = append(present, "aws")
+	}
+	if v.Azure != nil {
+		present = append(present, "azure")
+	}
+	if v.Hashicorp != nil {
+		present = append(present, "hashicorp")
+	}
+	if v.GCP != nil {
+		present = append(present, "gcp")
+	}
+	if v.Kubernetes != nil {
+		present = append(present, "kubernetes")
+	}
+	switch len(present) {
+	case 0:
+		return "", nil
+	case 1:
+		return present[0], nil
+	default:
+		return "", fmt.Errorf("[vault] has sections for %s; set [vault] provider to pick one",
+			strings.Join(present, ", "))
+	}
+}
+
+// configRelativePath resolves a path from the config file: "~/" is the
+// user's home directory and a relative path is relative to configDir.
+func configRelativePath(configDir, path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, rest)
+		}
+	}
+	return filepath.Join(configDir, path)
+}
@@ -0,0 +1,136 @@
+package project
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadVaultSettings_AWS(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "envdrift.toml"), `
+[vault]
+provider = "aws"
+
+[vault.aws]
+region = "eu-west-1"
+profile = "corp-sso"
+
+[[vault.sync.mappings]]
+secret_name = "myapp/prod-key"
+folder_path = "services/api"
+
+[[vault.sync.mappings]]
+secret_name = "myapp/staging-key"
+folder_path = "services/api"
+environment = "staging"
+`)
+
+	s, found, err := LoadVaultSettings(filepath.Join(root, "services", "api"))
+	if err != nil || !found {
+		t.Fatalf("LoadVaultSettings = found %v, err %v", found, err)
+	}
+	if s.Config.Provider != "aws" || s.Config.AWS.Region != "eu-west-1" || s.Config.AWS.Profile != "corp-sso" {
+		t.Errorf("Config = %+v", s.Config)
+	}
+	if s.ConfigDir != root {
+		t.Errorf("ConfigDir = %q, want %q", s.ConfigDir, root)
+	}
+	if len(s.Mappings) != 2 {
+		t.Fatalf("Mappings = %+v", s.Mappings)
+	}
+	want := filepath.Join(root, "services", "api")
+	if s.Mappings[0].FolderPath != want || s.Mappings[0].Environment != DefaultVaultEnvironment {
+		t.Errorf("Mappings[0] = %+v; want folder %s, environment production", s.Mappings[0], want)
+	}
+	if s.Mappings[1].Environment != "staging" {
+		t.Errorf("Mappings[1].Environment = %q", s.Mappings[1].Environment)
+	}
+}
+
+func TestLoadVaultSettings_ProviderInference(t *testing.T) {
+	tests := []struct {
+		name    string
+		toml    string
+		want    string
+		found   bool
+		wantErr string
+	}{
+		{"single section", "[vault.aws]\nregion = \"us-east-1\"\n", "aws", true, ""},
+		{"explicit wins", "[vault]\nprovider = \"AWS\"\n[vault.azure]\nvault_url = \"https://x\"\n", "aws", true, ""},
+		{"ambiguous", "[vault.aws]\nregion = \"a\"\n[vault.gcp]\nproject_id = \"p\"\n", "", false, "set [vault] provider"},
+		{"mappings only", "[[vault.sync.mappings]]\nsecret_name = \"s\"\n", "azure", true, ""},
+		{"no vault", "[guardian]\nenabled = true\n", "", false, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFile(t, filepath.Join(dir, "envdrift.toml"), tt.toml)
+			s, found, err := LoadVaultSettings(dir)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || found != tt.found {
+				t.Fatalf("found = %v, err = %v; want found %v", found, err, tt.found)
+			}
+			if found && s.Config.Provider != tt.want {
+				t.Errorf("provider = %q, want %q", s.Config.Provider, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadVaultSettings_Pyproject(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "pyproject.toml"), `
+[tool.envdrift.vault.aws]
+profile = "dev"
+
+[[tool.envdrift.vault.sync.mappings]]
+secret_name = "k"
+`)
+	s, found, err := LoadVaultSettings(dir)
+	if err != nil || !found || s.Config.AWS.Profile != "dev" || s.Mappings[0].FolderPath != dir {
+		t.Fatalf("LoadVaultSettings = %+v, %v, %v", s, found, err)
+	}
+}
+
+func TestLoadVaultSettings_AzureTenantAndClient(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "envdrift.toml"), `
+[vault.azure]
+vault_url = "https://team.vault.azure.net/"
+tenant_id = "contoso-tenant"
+client_id = "api-identity"
+`)
+	s, found, err := LoadVaultSettings(dir)
+	if err != nil || !found {
+		t.Fatalf("LoadVaultSettings = found %v, err %v", found, err)
+	}
+	az := s.Config.Azure
+	if s.Config.Provider != "azure" || az.VaultURL != "https://team.vault.azure.net/" ||
+		az.TenantID != "contoso-tenant" || az.ClientID != "api-identity" {
+		t.Errorf("Config = %+v", s.Config)
+	}
+}
+
+func TestLoadVaultSettings_Kubernetes(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "envdrift.toml"), `
+[vault.kubernetes]
+namespace = "apps"
+context = "prod"
+kubeconfig = "deploy/kubeconfig"
+`)
+	s, found, err := LoadVaultSettings(dir)
+	if err != nil || !found || s.Config.Provider != "kubernetes" {
+		t.Fatalf("LoadVaultSettings = %+v, %v, %v", s, found, err)
+	}
+	k := s.Config.Kubernetes
+	if k.Namespace != "apps" || k.Context != "prod" || k.Kubeconfig != filepath.Join(dir, "deploy", "kubeconfig") {
+		t.Errorf("Kubernetes = %+v; want kubeconfig resolved against the config dir", k)
+	}
+}
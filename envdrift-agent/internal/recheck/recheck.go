@@ -0,0 +1,94 @@
+// Package recheck queues requests for the running guardian to re-verify the
+// env files under a directory right away, instead of when they go idle. The
+// git hooks installed by `envdrift-agent hook install` file one after a
+// checkout or merge. Requests are files in the cache directory's recheck/,
+// one per directory, holding the directory's path; the guardian watches the
+// directory and takes them.
+package recheck
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jainal09/envdrift-agent/internal/paths"
+)
+
+// requestExt marks request files; anything else in Dir (a temp file being
+// written) is ignored.
+const requestExt = ".req"
+
+// Dir returns the request directory: recheck in the cache directory.
+func Dir() string {
+	return filepath.Join(paths.CacheDir(), "recheck")
+}
+
+// Request asks the guardian to re-verify the env files under dir. Repeated
+// requests for one directory before the guardian takes them collapse into
+// one.
+func Request(dir string) error {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(Dir(), 0o755); err != nil {
+		return fmt.Errorf("create recheck directory: %w", err)
+	}
+	sum := sha256.Sum256([]byte(abs))
+	name := filepath.Join(Dir(), hex.EncodeToString(sum[:8])+requestExt)
+	// Write then rename so the guardian never reads a half-written path.
+	tmp, err := os.CreateTemp(Dir(), "request-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.WriteString(abs); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), name); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
+// Take removes the pending requests and returns their directories, sorted.
+// A missing request directory is no requests.
+func Take() ([]string, error) {
+	entries, err := os.ReadDir(Dir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var dirs []string
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), requestExt) {
+			continue
+		}
+		path := filepath.Join(Dir(), e.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		// Another taker got it first if the remove fails.
+		if err := os.Remove(path); err != nil {
+			continue
+		}
+		if dir := strings.TrimSpace(string(data)); dir != "" {
+			dirs = append(dirs, dir)
+		}
+	}
+	sort.Strings(dirs)
+	return dirs, nil
+}
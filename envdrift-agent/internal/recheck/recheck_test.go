@@ -0,0 +1,38 @@
+package recheck
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRequestTake(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	if dirs, err := Take(); err != nil || len(dirs) != 0 {
+		t.Fatalf("Take with no directory = %v, %v", dirs, err)
+	}
+
+	a, b := filepath.Join(home, "a"), filepath.Join(home, "b")
+	for _, dir := range []string{b, a, b} {
+		if err := Request(dir); err != nil {
+			t.Fatal(err)
+		}
+	}
+	dirs, err := Take()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dirs) != 2 || dirs[0] != a || dirs[1] != b {
+		t.Errorf("Take = %v; want [%s %s]", dirs, a, b)
+	}
+	if dirs, _ := Take(); len(dirs) != 0 {
+		t.Errorf("second Take = %v; want none", dirs)
+	}
+	entries, _ := os.ReadDir(Dir())
+	if len(entries) != 0 {
+		t.Errorf("%d files left in %s", len(entries), Dir())
+	}
+}
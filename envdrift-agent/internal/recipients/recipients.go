@@ -0,0 +1,244 @@
+// Package recipients reports and changes who an encrypted env file is
+// encrypted for. SOPS files list their recipients (age keys, PGP
+// fingerprints, cloud KMS keys) in plaintext metadata and can gain or lose
+// one with `sops rotate`; a dotenvx file has a single keypair, whose public
+// key is its only recipient.
+//
+// Names given to recipients (alice, ci-runner) are kept in recipients.json
+// in the config directory, mapping recipient IDs to names. It holds public
+// identifiers only.
+package recipients
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/jainal09/envdrift-agent/internal/dotenv"
+	"github.com/jainal09/envdrift-agent/internal/paths"
+)
+
+// File formats.
+const (
+	FormatSOPS    = "sops"
+	FormatDotenvx = "dotenvx"
+)
+
+// Recipient types. The SOPS ones double as the suffix of sops's --add-<type>
+// and --rm-<type> flags (with _ as -).
+const (
+	TypeAge     = "age"
+	TypePGP     = "pgp"
+	TypeKMS     = "kms"
+	TypeGCPKMS  = "gcp_kms"
+	TypeAzureKV = "azure_kv"
+	TypeDotenvx = "dotenvx"
+)
+
+// Recipient is one key a file is encrypted for.
+type Recipient struct {
+	Type string
+	ID   string
+}
+
+// ErrSingleRecipient is returned when changing the recipients of a dotenvx
+// file.
+var ErrSingleRecipient = errors.New("dotenvx files have a single keypair; share its DOTENV_PRIVATE_KEY_<ENV> (e.g. with `envdrift-agent vault push`) to grant access")
+
+// sopsGroupKey matches SOPS's flattened key-group metadata in a dotenv file,
+// e.g. sops_age__list_0__map_recipient.
+var sopsGroupKey = regexp.MustCompile(`^sops_(age|pgp|kms|gcp_kms|azure_kv)__list_(\d+)__map_([a-z_]+)$`)
+
+// pgpFingerprint matches a full PGP key fingerprint.
+var pgpFingerprint = regexp.MustCompile(`^[0-9A-Fa-f]{40}$`)
+
+// idFields names the metadata field holding each SOPS recipient type's ID.
+var idFields = map[string]string{
+	TypeAge:     "recipient",
+	TypePGP:     "fp",
+	TypeKMS:     "arn",
+	TypeGCPKMS:  "resource_id",
+	TypeAzureKV: "vault_url",
+}
+
+// Read returns the format of the encrypted env file at path and the
+// recipients it is encrypted for. A file with neither SOPS metadata nor a
+// dotenvx public key has format "".
+func Read(path string) (string, []Recipient, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", nil, err
+	}
+	entries, err := dotenv.Parse(data)
+	if err != nil {
+		return "", nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	vars := dotenv.Map(entries)
+	if _, ok := vars["sops_version"]; ok {
+		return FormatSOPS, sopsRecipients(vars), nil
+	}
+	var recipients []Recipient
+	for _, e := range entries {
+		if strings.HasPrefix(e.Key, "DOTENV_PUBLIC_KEY") && e.Value != "" {
+			recipients = append(recipients, Recipient{Type: TypeDotenvx, ID: e.Value})
+		}
+	}
+	if len(recipients) == 0 {
+		return "", nil, nil
+	}
+	return FormatDotenvx, recipients, nil
+}
+
+// sopsRecipients collects the recipients in SOPS metadata, grouped by type
+// and in list order within a type.
+func sopsRecipients(vars map[string]string) []Recipient {
+	type item struct {
+		r     Recipient
+		index string
+	}
+	var items []item
+	for key, value := range vars {
+		m := sopsGroupKey.FindStringSubmatch(key)
+		if m == nil || idFields[m[1]] != m[3] {
+			continue
+		}
+		id := value
+		if m[1] == TypeAzureKV {
+			// An Azure key is its vault URL plus name.
+			id = strings.TrimRight(value, "/") + "/keys/" + vars[fmt.Sprintf("sops_azure_kv__list_%s__map_name", m[2])]
+		}
+		items = append(items, item{Recipient{Type: m[1], ID: id}, fmt.Sprintf("%s%08s", m[1], m[2])})
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].index < items[j].index })
+	recipients := make([]Recipient, len(items))
+	for i, it := range items {
+		recipients[i] = it.r
+	}
+	return recipients
+}
+
+// DetectType guesses a recipient's type from its form: an age1... key, a
+// 40-hex-digit PGP fingerprint, an AWS KMS ARN, a GCP KMS resource ID or an
+// Azure Key Vault key URL.
+func DetectType(id string) (string, error) {
+	switch {
+	case strings.HasPrefix(id, "age1"):
+		return TypeAge, nil
+	case pgpFingerprint.MatchString(id):
+		return TypePGP, nil
+	case strings.HasPrefix(id, "arn:aws:kms:"):
+		return TypeKMS, nil
+	case strings.HasPrefix(id, "projects/") && strings.Contains(id, "/cryptoKeys/"):
+		return TypeGCPKMS, nil
+	case strings.HasPrefix(id, "https://") && strings.Contains(id, ".vault.azure.net/keys/"):
+		return TypeAzureKV, nil
+	}
+	return "", fmt.Errorf("cannot tell the type of recipient %q; pass --type (age, pgp, kms, gcp_kms, azure_kv)", id)
+}
+
+// runSOPS runs sops with args; tests replace it.
+var runSOPS = func(ctx context.Context, args ...string) error {
+	cmd := exec.CommandContext(ctx, "sops", args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		var execErr *exec.Error
+		if errors.As(err, &execErr) {
+			return errors.New("sops not found. Install it: https://github.com/getsops/sops")
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("sops %s: %s", args[0], msg)
+		}
+		return fmt.Errorf("sops %s: %w", args[0], err)
+	}
+	return nil
+}
+
+// Add re-encrypts the SOPS file at path for r as well, rotating its data key.
+func Add(ctx context.Context, path string, r Recipient) error {
+	return update(ctx, path, "--add-", r)
+}
+
+// Remove re-encrypts the SOPS file at path without r, rotating its data key
+// so r cannot read later versions.
+func Remove(ctx context.Context, path string, r Recipient) error {
+	return update(ctx, path, "--rm-", r)
+}
+
+// update runs `sops --rotate --in-place <flag><type> <id> <path>`.
+func update(ctx context.Context, path, flag string, r Recipient) error {
+	format, _, err := Read(path)
+	if err != nil {
+		return err
+	}
+	switch format {
+	case FormatDotenvx:
+		return ErrSingleRecipient
+	case FormatSOPS:
+	default:
+		return fmt.Errorf("%s is not SOPS-encrypted", path)
+	}
+	if _, ok := idFields[r.Type]; !ok {
+		return fmt.Errorf("unknown recipient type %q", r.Type)
+	}
+	return runSOPS(ctx, "--rotate", "--in-place",
+		flag+strings.ReplaceAll(r.Type, "_", "-"), r.ID,
+		"--input-type", "dotenv", "--output-type", "dotenv", path)
+}
+
+// namesMu serializes ledger updates within one process.
+var namesMu sync.Mutex
+
+// NamesPath returns recipients.json in the config directory.
+func NamesPath() string {
+	return filepath.Join(paths.ConfigDir(), "recipients.json")
+}
+
+// Names returns the recipient names, by recipient ID. A missing ledger has
+// none.
+func Names() (map[string]string, error) {
+	data, err := os.ReadFile(NamesPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return map[string]string{}, nil
+		}
+		return nil, err
+	}
+	names := map[string]string{}
+	if err := json.Unmarshal(data, &names); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", NamesPath(), err)
+	}
+	return names, nil
+}
+
+// SetName names the recipient id; an empty name forgets it.
+func SetName(id, name string) error {
+	namesMu.Lock()
+	defer namesMu.Unlock()
+	names, err := Names()
+	if err != nil {
+		return err
+	}
+	if name == "" {
+		delete(names, id)
+	} else {
+		names[id] = name
+	}
+	data, err := json.MarshalIndent(names, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(NamesPath()), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(NamesPath(), append(data, '\n'), 0o644)
+}
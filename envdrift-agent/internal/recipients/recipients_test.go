@@ -0,0 +1,122 @@
+package recipients
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const sopsFile = `DB_URL=ENC[AES256_GCM,data:abc,iv:def,tag:ghi,type:str]
+sops_age__list_0__map_recipient=age1alice
+sops_age__list_0__map_enc=-----BEGIN AGE ENCRYPTED FILE-----
+sops_age__list_1__map_recipient=age1bob
+sops_pgp__list_0__map_fp=85D77543B3D624B63CEA9E6DBC17301B491B3F21
+sops_lastmodified=2026-01-01T00:00:00Z
+sops_version=3.9.0
+`
+
+func writeEnv(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRead(t *testing.T) {
+	format, got, err := Read(writeEnv(t, sopsFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Recipient{
+		{TypeAge, "age1alice"},
+		{TypeAge, "age1bob"},
+		{TypePGP, "85D77543B3D624B63CEA9E6DBC17301B491B3F21"},
+	}
+	if format != FormatSOPS || len(got) != len(want) {
+		t.Fatalf("Read = %s %v; want sops %v", format, got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("recipient %d = %v; want %v", i, got[i], want[i])
+		}
+	}
+
+	format, got, err = Read(writeEnv(t, "DOTENV_PUBLIC_KEY_PRODUCTION=\"03abc\"\nA=\"encrypted:x\"\n"))
+	if err != nil || format != FormatDotenvx || len(got) != 1 || got[0].ID != "03abc" {
+		t.Errorf("dotenvx Read = %s %v, %v", format, got, err)
+	}
+	if format, _, _ := Read(writeEnv(t, "A=1\n")); format != "" {
+		t.Errorf("plaintext format = %q", format)
+	}
+}
+
+func TestDetectType(t *testing.T) {
+	for id, want := range map[string]string{
+		"age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p": TypeAge,
+		"85D77543B3D624B63CEA9E6DBC17301B491B3F21":                       TypePGP,
+		"arn:aws:kms:eu-west-1:123456789012:key/abc":                     TypeKMS,
+		"projects/p/locations/global/keyRings/r/cryptoKeys/k":            TypeGCPKMS,
+		"https://team.vault.azure.net/keys/sops/1":                       TypeAzureKV,
+	} {
+		if got, err := DetectType(id); err != nil || got != want {
+			t.Errorf("DetectType(%q) = %q, %v; want %q", id, got, err, want)
+		}
+	}
+	if _, err := DetectType("alice"); err == nil {
+		t.Error("DetectType(alice) succeeded")
+	}
+}
+
+func TestAddRemove(t *testing.T) {
+	var calls [][]string
+	prev := runSOPS
+	runSOPS = func(_ context.Context, args ...string) error {
+		calls = append(calls, args)
+		return nil
+	}
+	t.Cleanup(func() { runSOPS = prev })
+
+	path := writeEnv(t, sopsFile)
+	if err := Add(context.Background(), path, Recipient{TypeGCPKMS, "projects/p/cryptoKeys/k"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Remove(context.Background(), path, Recipient{TypeAge, "age1bob"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(calls) != 2 ||
+		strings.Join(calls[0][:4], " ") != "--rotate --in-place --add-gcp-kms projects/p/cryptoKeys/k" ||
+		strings.Join(calls[1][:4], " ") != "--rotate --in-place --rm-age age1bob" ||
+		calls[1][len(calls[1])-1] != path {
+		t.Errorf("sops calls = %v", calls)
+	}
+
+	dotenvx := writeEnv(t, "DOTENV_PUBLIC_KEY=\"03abc\"\nA=\"encrypted:x\"\n")
+	if err := Add(context.Background(), dotenvx, Recipient{TypeAge, "age1x"}); !errors.Is(err, ErrSingleRecipient) {
+		t.Errorf("Add to dotenvx = %v; want ErrSingleRecipient", err)
+	}
+}
+
+func TestNames(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	if err := SetName("age1alice", "alice"); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetName("age1bob", "bob"); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetName("age1bob", ""); err != nil {
+		t.Fatal(err)
+	}
+	names, err := Names()
+	if err != nil || len(names) != 1 || names["age1alice"] != "alice" {
+		t.Errorf("Names = %v, %v", names, err)
+	}
+}
@@ -0,0 +1,133 @@
+package report
+
+import (
+	"encoding/csv"
+	"html/template"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// timeLayout is how HTML and CSV reports print times.
+const timeLayout = "2006-01-02 15:04:05 MST"
+
+// writeCSV writes r as one table with the columns category, name, time,
+// path and value: a summary row per figure, then a row per violation and
+// per access.
+func writeCSV(w io.Writer, r Report) error {
+	cw := csv.NewWriter(w)
+	row := func(category, name string, at time.Time, path, value string) {
+		t := ""
+		if !at.IsZero() {
+			t = at.UTC().Format(time.RFC3339)
+		}
+		_ = cw.Write([]string{category, name, t, path, value})
+	}
+	_ = cw.Write([]string{"category", "name", "time", "path", "value"})
+	for _, s := range []struct {
+		name  string
+		value string
+	}{
+		{"from", r.From.UTC().Format(time.RFC3339)},
+		{"to", r.To.UTC().Format(time.RFC3339)},
+		{"host", r.Host},
+		{"projects", strconv.Itoa(r.Projects)},
+		{"env_files", strconv.Itoa(r.EnvFiles)},
+		{"encrypted", strconv.Itoa(r.Encrypted)},
+		{"files_protected", strconv.Itoa(r.FilesProtected)},
+		{"encryptions", strconv.Itoa(r.Encryptions)},
+		{"mean_time_to_encrypt_seconds", seconds(r.MeanTimeToEncrypt)},
+		{"max_time_to_encrypt_seconds", seconds(r.MaxTimeToEncrypt)},
+		{"violations", strconv.Itoa(len(r.Violations))},
+		{"accesses", strconv.Itoa(len(r.Accesses))},
+	} {
+		row("summary", s.name, time.Time{}, "", s.value)
+	}
+	for _, v := range r.Violations {
+		row("violation", v.Kind, v.Time, v.Path, v.Detail)
+	}
+	for _, a := range r.Accesses {
+		row("access", a.Action, a.Time, a.Path, a.Detail)
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func seconds(d Duration) string {
+	return strconv.FormatFloat(time.Duration(d).Seconds(), 'f', -1, 64)
+}
+
+// actionCount is one row of the HTML report's access summary.
+type actionCount struct {
+	Action string
+	Count  int
+}
+
+// writeHTML writes r as a self-contained HTML page.
+func writeHTML(w io.Writer, r Report) error {
+	var counts []actionCount
+	for action, n := range r.AccessCounts() {
+		counts = append(counts, actionCount{action, n})
+	}
+	sort.Slice(counts, func(i, j int) bool { return counts[i].Action < counts[j].Action })
+	return htmlReport.Execute(w, struct {
+		Report
+		Counts []actionCount
+	}{r, counts})
+}
+
+var htmlReport = template.Must(template.New("report").Funcs(template.FuncMap{
+	"when": func(t time.Time) string { return t.Local().Format(timeLayout) },
+	"day":  func(t time.Time) string { return t.Local().Format("2006-01-02") },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>EnvDrift report {{day .From}} to {{day .To}}</title>
+<style>
+body { font-family: system-ui, sans-serif; margin: 2rem auto; max-width: 64rem; color: #1f2328; }
+h1 { margin-bottom: 0.25rem; }
+.meta { color: #59636e; margin-top: 0; }
+.figures { display: flex; flex-wrap: wrap; gap: 1rem; margin: 1.5rem 0; }
+.figure { border: 1px solid #d1d9e0; border-radius: 6px; padding: 0.75rem 1rem; min-width: 10rem; }
+.figure b { display: block; font-size: 1.5rem; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 1.5rem; }
+th, td { text-align: left; padding: 0.4rem 0.6rem; border-bottom: 1px solid #d1d9e0; vertical-align: top; }
+td.path { font-family: ui-monospace, monospace; word-break: break-all; }
+.none { color: #59636e; }
+</style>
+</head>
+<body>
+<h1>EnvDrift report</h1>
+<p class="meta">{{when .From}} to {{when .To}}{{with .Host}} &middot; {{.}}{{end}} &middot; generated {{when .Generated}}</p>
+
+<div class="figures">
+<div class="figure"><b>{{.FilesProtected}}</b>files protected</div>
+<div class="figure"><b>{{.Encryptions}}</b>encryptions</div>
+<div class="figure"><b>{{if .Measured}}{{.MeanTimeToEncrypt}}{{else}}&ndash;{{end}}</b>mean time to encrypt</div>
+<div class="figure"><b>{{if .Measured}}{{.MaxTimeToEncrypt}}{{else}}&ndash;{{end}}</b>longest time to encrypt</div>
+<div class="figure"><b>{{.Encrypted}} / {{.EnvFiles}}</b>env files encrypted now, in {{.Projects}} projects</div>
+<div class="figure"><b>{{len .Violations}}</b>violations</div>
+</div>
+
+<h2>Violations</h2>
+{{if .Violations}}<table>
+<tr><th>Kind</th><th>File</th><th>Time</th><th>Detail</th></tr>
+{{range .Violations}}<tr><td>{{.Kind}}</td><td class="path">{{.Path}}</td><td>{{when .Time}}</td><td>{{.Detail}}</td></tr>
+{{end}}</table>
+{{else}}<p class="none">None: every env file is encrypted and private, and no encryption failed.</p>
+{{end}}
+<h2>Audited actions</h2>
+{{if .Accesses}}<table>
+<tr><th>Action</th><th>Count</th></tr>
+{{range .Counts}}<tr><td>{{.Action}}</td><td>{{.Count}}</td></tr>
+{{end}}</table>
+<table>
+<tr><th>Time</th><th>Action</th><th>File</th><th>Detail</th></tr>
+{{range .Accesses}}<tr><td>{{when .Time}}</td><td>{{.Action}}</td><td class="path">{{.Path}}</td><td>{{.Detail}}</td></tr>
+{{end}}</table>
+{{else}}<p class="none">None recorded in the period.</p>
+{{end}}</body>
+</html>
+`))
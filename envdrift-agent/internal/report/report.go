@@ -0,0 +1,195 @@
+// Package report renders the agent's journal, history log and a compliance
+// scan into a report for security reviews and audits: how many env files the
+// agent protected over a period, how long files sat in plaintext before it
+// encrypted them, who accessed plaintext, and what is out of compliance.
+//
+// A Report is built from data the agent already keeps — nothing is recorded
+// for it — and written as HTML, JSON or CSV. Like its sources it holds
+// paths and metadata only, never a secret value.
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jainal09/envdrift-agent/internal/compliance"
+	"github.com/jainal09/envdrift-agent/internal/history"
+	"github.com/jainal09/envdrift-agent/internal/journal"
+)
+
+// Formats a report is written in.
+const (
+	FormatHTML = "html"
+	FormatJSON = "json"
+	FormatCSV  = "csv"
+)
+
+// Formats lists the formats Write accepts.
+var Formats = []string{FormatHTML, FormatJSON, FormatCSV}
+
+// Violation kinds.
+const (
+	// ViolationPlaintext is an env file in plaintext at the time of the
+	// report.
+	ViolationPlaintext = "plaintext"
+	// ViolationExposed is an env or key file other users can read.
+	ViolationExposed = "exposed"
+	// ViolationFailed is an encryption that failed during the period.
+	ViolationFailed = "failed"
+)
+
+// Duration is a time.Duration written to JSON as seconds.
+type Duration time.Duration
+
+// MarshalJSON writes d as a number of seconds.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).Seconds())
+}
+
+// String formats d to the second.
+func (d Duration) String() string {
+	return time.Duration(d).Round(time.Second).String()
+}
+
+// Violation is one finding of the report.
+type Violation struct {
+	Kind string `json:"kind"`
+	Path string `json:"path"`
+	// Time is when it happened: the failure, the plaintext file's last
+	// change, or the scan that found a file exposed.
+	Time   time.Time `json:"time"`
+	Detail string    `json:"detail,omitempty"`
+}
+
+// Access is one plaintext access or other audited action from the history
+// log.
+type Access struct {
+	Time   time.Time `json:"time"`
+	Action string    `json:"action"`
+	Path   string    `json:"path"`
+	Detail string    `json:"detail,omitempty"`
+}
+
+// Report covers the period From to To.
+type Report struct {
+	Generated time.Time `json:"generated"`
+	Host      string    `json:"host"`
+	From      time.Time `json:"from"`
+	To        time.Time `json:"to"`
+	// Projects and EnvFiles are from the compliance scan at To; Encrypted is
+	// how many of those files were encrypted then.
+	Projects  int `json:"projects"`
+	EnvFiles  int `json:"env_files"`
+	Encrypted int `json:"encrypted"`
+	// FilesProtected is how many distinct files the agent encrypted in the
+	// period, Encryptions how many times.
+	FilesProtected int `json:"files_protected"`
+	Encryptions    int `json:"encryptions"`
+	// MeanTimeToEncrypt and MaxTimeToEncrypt measure, over the encryptions
+	// of the period whose start the journal saw, the time from a file's
+	// first change after it was last encrypted to its next encryption.
+	MeanTimeToEncrypt Duration `json:"mean_time_to_encrypt_seconds"`
+	MaxTimeToEncrypt  Duration `json:"max_time_to_encrypt_seconds"`
+	// Measured is how many encryptions those two are over.
+	Measured   int         `json:"measured"`
+	Violations []Violation `json:"violations"`
+	Accesses   []Access    `json:"accesses"`
+}
+
+// Build makes the report for the period from..to out of the journal's
+// events and the history log's entries, both oldest first, and the
+// compliance scan c taken at to.
+func Build(events []journal.Event, entries []history.Entry, c compliance.Report, from, to time.Time) Report {
+	r := Report{
+		Generated:  c.Generated,
+		Host:       c.Host,
+		From:       from,
+		To:         to,
+		Projects:   c.Projects,
+		EnvFiles:   c.EnvFiles,
+		Encrypted:  c.Encrypted,
+		Violations: []Violation{},
+		Accesses:   []Access{},
+	}
+	in := func(t time.Time) bool { return !t.Before(from) && !t.After(to) }
+
+	// changed is when each file was first modified after it was last
+	// encrypted; events before the period still set it.
+	changed := map[string]time.Time{}
+	protected := map[string]bool{}
+	var total time.Duration
+	for _, e := range events {
+		switch e.Kind {
+		case journal.KindModified:
+			if _, ok := changed[e.Path]; !ok {
+				changed[e.Path] = e.Time
+			}
+		case journal.KindEncrypted:
+			if in(e.Time) {
+				r.Encryptions++
+				protected[e.Path] = true
+				if start, ok := changed[e.Path]; ok {
+					took := e.Time.Sub(start)
+					total += took
+					r.Measured++
+					r.MaxTimeToEncrypt = max(r.MaxTimeToEncrypt, Duration(took))
+				}
+			}
+			delete(changed, e.Path)
+		case journal.KindAlready, journal.KindGone:
+			delete(changed, e.Path)
+		case journal.KindFailed:
+			if in(e.Time) {
+				r.Violations = append(r.Violations, Violation{Kind: ViolationFailed, Path: e.Path, Time: e.Time, Detail: e.Detail})
+			}
+		}
+	}
+	r.FilesProtected = len(protected)
+	if r.Measured > 0 {
+		r.MeanTimeToEncrypt = Duration(total / time.Duration(r.Measured))
+	}
+
+	for _, f := range c.Plaintext {
+		r.Violations = append(r.Violations, Violation{Kind: ViolationPlaintext, Path: f.Path, Time: f.Modified,
+			Detail: "unchanged for " + Duration(to.Sub(f.Modified)).String()})
+	}
+	for _, p := range c.Permissions {
+		r.Violations = append(r.Violations, Violation{Kind: ViolationExposed, Path: p.Path, Time: c.Generated, Detail: "mode " + p.Mode})
+	}
+	sort.SliceStable(r.Violations, func(i, j int) bool { return r.Violations[i].Kind < r.Violations[j].Kind })
+
+	for _, e := range entries {
+		if in(e.Time) {
+			r.Accesses = append(r.Accesses, Access{Time: e.Time, Action: e.Action, Path: e.Path, Detail: e.Detail})
+		}
+	}
+	return r
+}
+
+// AccessCounts returns how many accesses r has of each action.
+func (r Report) AccessCounts() map[string]int {
+	counts := map[string]int{}
+	for _, a := range r.Accesses {
+		counts[a.Action]++
+	}
+	return counts
+}
+
+// Write writes r to w in format.
+func Write(w io.Writer, r Report, format string) error {
+	switch format {
+	case FormatHTML:
+		return writeHTML(w, r)
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r)
+	case FormatCSV:
+		return writeCSV(w, r)
+	}
+	return fmt.Errorf("unsupported format %q (want %s)", format, strings.Join(Formats, ", "))
+}
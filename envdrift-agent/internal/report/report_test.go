@@ -0,0 +1,183 @@
+package report
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jainal09/envdrift-agent/internal/compliance"
+	"github.com/jainal09/envdrift-agent/internal/history"
+	"github.com/jainal09/envdrift-agent/internal/journal"
+)
+
+var now = time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+
+func testReport() Report {
+	at := func(d time.Duration) time.Time { return now.Add(-d) }
+	events := []journal.Event{
+		// Changed before the period, encrypted in it: measured from the change.
+		{Time: at(40 * 24 * time.Hour), Kind: journal.KindModified, Path: "/p/a/.env"},
+		{Time: at(40*24*time.Hour - 10*time.Minute), Kind: journal.KindModified, Path: "/p/a/.env"},
+		{Time: at(29 * 24 * time.Hour), Kind: journal.KindEncrypted, Path: "/p/a/.env"},
+		// Encrypted twice in the period.
+		{Time: at(5 * time.Hour), Kind: journal.KindModified, Path: "/p/a/.env"},
+		{Time: at(5*time.Hour - 30*time.Second), Kind: journal.KindEncrypted, Path: "/p/a/.env"},
+		// A change that turned out to be encrypted already does not count.
+		{Time: at(4 * time.Hour), Kind: journal.KindModified, Path: "/p/b/.env"},
+		{Time: at(4*time.Hour - time.Second), Kind: journal.KindAlready, Path: "/p/b/.env"},
+		{Time: at(2 * time.Hour), Kind: journal.KindModified, Path: "/p/b/.env"},
+		{Time: at(2*time.Hour - 90*time.Second), Kind: journal.KindEncrypted, Path: "/p/b/.env"},
+		// Encrypted without a change the journal saw: counted, not measured.
+		{Time: at(time.Hour), Kind: journal.KindEncrypted, Path: "/p/c/.env"},
+		{Time: at(31 * 24 * time.Hour), Kind: journal.KindFailed, Path: "/p/old/.env", Detail: "too old"},
+		{Time: at(3 * time.Hour), Kind: journal.KindFailed, Path: "/p/d/.env", Detail: "dotenvx: exit status 1"},
+	}
+	entries := []history.Entry{
+		{Time: at(60 * 24 * time.Hour), Action: "reveal", Path: "/p/a/.env"},
+		{Time: at(2 * 24 * time.Hour), Action: "reveal", Path: "/p/a/.env", Detail: "API_KEY"},
+		{Time: at(24 * time.Hour), Action: "export", Path: "/p/b/.env"},
+		{Time: at(time.Hour), Action: "reveal", Path: "/p/b/.env"},
+	}
+	scan := compliance.Report{
+		Generated: now,
+		Host:      "laptop",
+		Projects:  3,
+		EnvFiles:  5,
+		Encrypted: 4,
+		Plaintext: []compliance.File{
+			{Path: "/p/e/.env", Project: "/p/e", Modified: at(3 * time.Hour)},
+		},
+		Permissions: []compliance.Issue{{Path: "/p/a/.env.keys", Mode: "0644"}},
+	}
+	return Build(events, entries, scan, at(30*24*time.Hour), now)
+}
+
+func TestBuild(t *testing.T) {
+	r := testReport()
+
+	if r.FilesProtected != 3 || r.Encryptions != 4 {
+		t.Errorf("FilesProtected, Encryptions = %d, %d; want 3, 4", r.FilesProtected, r.Encryptions)
+	}
+	if r.Measured != 3 {
+		t.Errorf("Measured = %d; want 3", r.Measured)
+	}
+	// 11 days from the first change, 30s and 90s.
+	long := 11 * 24 * time.Hour
+	if want := Duration((long + 30*time.Second + 90*time.Second) / 3); r.MeanTimeToEncrypt != want {
+		t.Errorf("MeanTimeToEncrypt = %v; want %v", r.MeanTimeToEncrypt, want)
+	}
+	if r.MaxTimeToEncrypt != Duration(long) {
+		t.Errorf("MaxTimeToEncrypt = %v; want %v", r.MaxTimeToEncrypt, Duration(long))
+	}
+
+	var kinds, paths []string
+	for _, v := range r.Violations {
+		kinds = append(kinds, v.Kind)
+		paths = append(paths, v.Path)
+	}
+	if got, want := strings.Join(kinds, ","), "exposed,failed,plaintext"; got != want {
+		t.Errorf("violation kinds = %s; want %s", got, want)
+	}
+	if got, want := strings.Join(paths, ","), "/p/a/.env.keys,/p/d/.env,/p/e/.env"; got != want {
+		t.Errorf("violation paths = %s; want %s", got, want)
+	}
+	if d := r.Violations[2].Detail; d != "unchanged for 3h0m0s" {
+		t.Errorf("plaintext detail = %q", d)
+	}
+
+	if len(r.Accesses) != 3 {
+		t.Fatalf("Accesses = %+v; want the 3 in the period", r.Accesses)
+	}
+	if c := r.AccessCounts(); c["reveal"] != 2 || c["export"] != 1 {
+		t.Errorf("AccessCounts = %v", c)
+	}
+}
+
+func TestBuildEmpty(t *testing.T) {
+	r := Build(nil, nil, compliance.Report{Generated: now}, now.Add(-time.Hour), now)
+	if r.Measured != 0 || r.MeanTimeToEncrypt != 0 || r.Violations == nil || r.Accesses == nil {
+		t.Errorf("empty report = %+v", r)
+	}
+	var buf bytes.Buffer
+	if err := Write(&buf, r, FormatJSON); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `"violations": []`) {
+		t.Errorf("JSON lists no violations as null:\n%s", buf.String())
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, testReport(), FormatJSON); err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		FilesProtected   int     `json:"files_protected"`
+		MaxTimeToEncrypt float64 `json:"max_time_to_encrypt_seconds"`
+		Violations       []Violation
+	}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.FilesProtected != 3 || got.MaxTimeToEncrypt != (11*24*time.Hour).Seconds() || len(got.Violations) != 3 {
+		t.Errorf("JSON = %+v", got)
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, testReport(), FormatCSV); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(rows[0], ","); got != "category,name,time,path,value" {
+		t.Errorf("header = %s", got)
+	}
+	have := map[string]bool{}
+	for _, row := range rows[1:] {
+		have[strings.Join(row, ",")] = true
+	}
+	for _, want := range []string{
+		"summary,files_protected,,,3",
+		"summary,mean_time_to_encrypt_seconds,,,316840",
+		"violation,failed,2026-03-10T09:00:00Z,/p/d/.env,dotenvx: exit status 1",
+		"access,export,2026-03-09T12:00:00Z,/p/b/.env,",
+	} {
+		if !have[want] {
+			t.Errorf("CSV lacks row %q", want)
+		}
+	}
+}
+
+func TestWriteHTML(t *testing.T) {
+	r := testReport()
+	r.Accesses[0].Path = "/p/<script>/.env"
+	var buf bytes.Buffer
+	if err := Write(&buf, r, FormatHTML); err != nil {
+		t.Fatal(err)
+	}
+	html := buf.String()
+	for _, want := range []string{
+		"<b>3</b>files protected",
+		"<b>88h0m40s</b>mean time to encrypt",
+		"<td>reveal</td><td>2</td>",
+		"/p/&lt;script&gt;/.env",
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("HTML lacks %q", want)
+		}
+	}
+}
+
+func TestWriteUnknownFormat(t *testing.T) {
+	if err := Write(&bytes.Buffer{}, Report{}, "pdf"); err == nil {
+		t.Error("Write(pdf) succeeded")
+	}
+}
@@ -0,0 +1,78 @@
+// Package retention drops what the agent recorded about past activity once
+// it is older than the configured retention: history entries past [history]
+// keep, and journal events and rotated agent logs past [logs] keep. The
+// running agent applies it hourly; `envdrift-agent purge` applies it, or a
+// stricter cutoff, on demand.
+package retention
+
+import (
+	"errors"
+	"path/filepath"
+	"time"
+
+	"github.com/jainal09/envdrift-agent/internal/config"
+	"github.com/jainal09/envdrift-agent/internal/history"
+	"github.com/jainal09/envdrift-agent/internal/journal"
+	"github.com/jainal09/envdrift-agent/internal/logging"
+	"github.com/jainal09/envdrift-agent/internal/paths"
+)
+
+// Cutoff selects what Prune drops: the records from before History or Logs.
+// A zero time keeps those records.
+type Cutoff struct {
+	// History applies to the history log.
+	History time.Time
+	// Logs applies to the journal and the rotated agent logs.
+	Logs time.Time
+}
+
+// Policy returns the cutoff cfg's retention settings give at now.
+func Policy(cfg *config.Config, now time.Time) Cutoff {
+	var c Cutoff
+	if keep := cfg.History.Keep; keep > 0 {
+		c.History = now.Add(-keep)
+	}
+	if keep := cfg.Logs.Keep; keep > 0 {
+		c.Logs = now.Add(-keep)
+	}
+	return c
+}
+
+// Result counts what Prune dropped.
+type Result struct {
+	History  int
+	Journal  int
+	LogFiles int
+}
+
+// Total is everything r counts.
+func (r Result) Total() int {
+	return r.History + r.Journal + r.LogFiles
+}
+
+// AgentLog is the rotating log the installed service writes (see
+// `start --log-file`); its rotated files are what Prune removes.
+func AgentLog() string {
+	return filepath.Join(paths.LogDir(), "agent.log")
+}
+
+// Prune drops the records c selects. A part that fails does not stop the
+// others; the errors are joined.
+func Prune(c Cutoff) (Result, error) {
+	var r Result
+	var errs []error
+	if !c.History.IsZero() {
+		n, err := history.Prune(c.History)
+		r.History = n
+		errs = append(errs, err)
+	}
+	if !c.Logs.IsZero() {
+		n, err := journal.Prune(c.Logs)
+		r.Journal = n
+		errs = append(errs, err)
+		n, err = logging.PruneBackups(AgentLog(), c.Logs)
+		r.LogFiles = n
+		errs = append(errs, err)
+	}
+	return r, errors.Join(errs...)
+}
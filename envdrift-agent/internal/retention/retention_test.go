@@ -0,0 +1,62 @@
+package retention
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jainal09/envdrift-agent/internal/config"
+	"github.com/jainal09/envdrift-agent/internal/history"
+	"github.com/jainal09/envdrift-agent/internal/journal"
+)
+
+func TestPolicy(t *testing.T) {
+	now := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+	cfg := config.DefaultConfig()
+	c := Policy(cfg, now)
+	if !c.History.Equal(now.Add(-90*24*time.Hour)) || !c.Logs.Equal(now.Add(-14*24*time.Hour)) {
+		t.Errorf("default Policy = %+v", c)
+	}
+	cfg.History.Keep = 0
+	if c := Policy(cfg, now); !c.History.IsZero() {
+		t.Errorf("keep = 0: History cutoff %v; want none", c.History)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	t.Setenv("ENVDRIFT_HOME", t.TempDir())
+	now := time.Now()
+	old := now.Add(-100 * 24 * time.Hour)
+	if err := history.Record(history.Entry{Time: old, Action: history.ActionReveal, Path: "/p/.env"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := history.Record(history.Entry{Time: now, Action: history.ActionReveal, Path: "/p/.env"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := journal.Append(journal.Event{Time: old, Kind: journal.KindModified, Path: "/p/.env"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(AgentLog()), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(AgentLog()+".1", nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(AgentLog()+".1", old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the history log is selected.
+	r, err := Prune(Cutoff{History: now.Add(-time.Hour)})
+	if err != nil || r != (Result{History: 1}) {
+		t.Fatalf("Prune(history) = %+v, %v", r, err)
+	}
+	r, err = Prune(Policy(config.DefaultConfig(), now))
+	if err != nil || r != (Result{Journal: 1, LogFiles: 1}) || r.Total() != 2 {
+		t.Fatalf("Prune(defaults) = %+v, %v", r, err)
+	}
+	if entries, _ := history.Read(); len(entries) != 1 {
+		t.Errorf("history after Prune = %+v", entries)
+	}
+}
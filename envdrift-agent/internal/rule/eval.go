@@ -0,0 +1,443 @@
+package rule
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// kind is the type of a value.
+type kind int
+
+const (
+	kindNone kind = iota // the element type of []
+	kindBool
+	kindInt
+	kindString
+	kindDuration
+	kindList
+)
+
+var kindNames = map[kind]string{
+	kindBool: "bool", kindInt: "int", kindString: "string", kindDuration: "duration", kindList: "list",
+}
+
+// typ is a value's type; elem is a list's element type.
+type typ struct {
+	kind kind
+	elem kind
+}
+
+func (t typ) String() string {
+	if t.kind == kindList {
+		if t.elem == kindNone {
+			return "list"
+		}
+		return "list(" + kindNames[t.elem] + ")"
+	}
+	return kindNames[t.kind]
+}
+
+// article returns t with "a" or "an" before it.
+func (t typ) article() string {
+	if t.kind == kindInt {
+		return "an int"
+	}
+	return "a " + t.String()
+}
+
+// node is a parsed expression. check type-checks it, recording the
+// variables it reads in uses; eval computes it from the variables' values.
+type node interface {
+	check(uses map[string]bool) (typ, error)
+	eval(vars map[string]any) (any, error)
+}
+
+type litNode struct {
+	v any
+	t typ
+}
+
+func (n *litNode) check(map[string]bool) (typ, error) { return n.t, nil }
+
+func (n *litNode) eval(map[string]any) (any, error) { return n.v, nil }
+
+type varNode struct {
+	pos  int
+	name string
+}
+
+func (n *varNode) check(uses map[string]bool) (typ, error) {
+	name, ok := Variables[n.name]
+	if !ok {
+		return typ{}, errorAt(n.pos, "unknown variable %s (variables: %s)", n.name, variableNames())
+	}
+	uses[n.name] = true
+	for k, kn := range kindNames {
+		if kn == name {
+			return typ{kind: k}, nil
+		}
+	}
+	return typ{}, errorAt(n.pos, "variable %s has unknown type %s", n.name, name)
+}
+
+func (n *varNode) eval(vars map[string]any) (any, error) { return vars[n.name], nil }
+
+type unaryNode struct {
+	pos int
+	op  string
+	x   node
+}
+
+func (n *unaryNode) check(uses map[string]bool) (typ, error) {
+	t, err := n.x.check(uses)
+	if err != nil {
+		return t, err
+	}
+	switch {
+	case n.op == "!" && t.kind == kindBool,
+		n.op == "-" && (t.kind == kindInt || t.kind == kindDuration):
+		return t, nil
+	}
+	return typ{}, errorAt(n.pos, "%s cannot be applied to %s", n.op, t.article())
+}
+
+func (n *unaryNode) eval(vars map[string]any) (any, error) {
+	v, err := n.x.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	switch v := v.(type) {
+	case bool:
+		return !v, nil
+	case int64:
+		return -v, nil
+	case time.Duration:
+		return -v, nil
+	}
+	return nil, fmt.Errorf("%s of %T", n.op, v)
+}
+
+type binaryNode struct {
+	pos  int
+	op   string
+	l, r node
+}
+
+func (n *binaryNode) check(uses map[string]bool) (typ, error) {
+	lt, err := n.l.check(uses)
+	if err != nil {
+		return lt, err
+	}
+	rt, err := n.r.check(uses)
+	if err != nil {
+		return rt, err
+	}
+	mismatch := errorAt(n.pos, "%s cannot be applied to %s and %s", n.op, lt.article(), rt.article())
+	switch n.op {
+	case "&&", "||":
+		if lt.kind != kindBool || rt.kind != kindBool {
+			return typ{}, mismatch
+		}
+		return typ{kind: kindBool}, nil
+	case "in":
+		if rt.kind != kindList || (rt.elem != kindNone && rt.elem != lt.kind) {
+			return typ{}, mismatch
+		}
+		return typ{kind: kindBool}, nil
+	}
+	if lt != rt {
+		return typ{}, mismatch
+	}
+	switch n.op {
+	case "==", "!=":
+		if lt.kind != kindList {
+			return typ{kind: kindBool}, nil
+		}
+	case "<", "<=", ">", ">=":
+		if lt.kind == kindInt || lt.kind == kindString || lt.kind == kindDuration {
+			return typ{kind: kindBool}, nil
+		}
+	case "+":
+		if lt.kind == kindInt || lt.kind == kindString || lt.kind == kindDuration {
+			return lt, nil
+		}
+	case "-":
+		if lt.kind == kindInt || lt.kind == kindDuration {
+			return lt, nil
+		}
+	case "*", "/", "%":
+		if lt.kind == kindInt {
+			return lt, nil
+		}
+	}
+	return typ{}, mismatch
+}
+
+// errDivision is a division or remainder by zero.
+var errDivision = errors.New("division by zero")
+
+func (n *binaryNode) eval(vars map[string]any) (any, error) {
+	l, err := n.l.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	switch n.op {
+	case "&&":
+		if !l.(bool) {
+			return false, nil
+		}
+		return n.r.eval(vars)
+	case "||":
+		if l.(bool) {
+			return true, nil
+		}
+		return n.r.eval(vars)
+	}
+	r, err := n.r.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	switch n.op {
+	case "in":
+		for _, e := range r.([]any) {
+			if e == l {
+				return true, nil
+			}
+		}
+		return false, nil
+	case "==":
+		return l == r, nil
+	case "!=":
+		return l != r, nil
+	}
+	switch l := l.(type) {
+	case int64:
+		return intOp(n.op, l, r.(int64))
+	case time.Duration:
+		switch n.op {
+		case "+":
+			return l + r.(time.Duration), nil
+		case "-":
+			return l - r.(time.Duration), nil
+		}
+		return compare(n.op, l, r.(time.Duration)), nil
+	case string:
+		if n.op == "+" {
+			return l + r.(string), nil
+		}
+		return compare(n.op, l, r.(string)), nil
+	}
+	return nil, fmt.Errorf("%s of %T", n.op, l)
+}
+
+// intOp applies an arithmetic or ordering operator to ints.
+func intOp(op string, l, r int64) (any, error) {
+	switch op {
+	case "+":
+		return l + r, nil
+	case "-":
+		return l - r, nil
+	case "*":
+		return l * r, nil
+	case "/", "%":
+		if r == 0 {
+			return nil, errDivision
+		}
+		if op == "/" {
+			return l / r, nil
+		}
+		return l % r, nil
+	}
+	return compare(op, l, r), nil
+}
+
+// compare applies an ordering operator.
+func compare[T int64 | string | time.Duration](op string, l, r T) bool {
+	switch op {
+	case "<":
+		return l < r
+	case "<=":
+		return l <= r
+	case ">":
+		return l > r
+	}
+	return l >= r
+}
+
+type condNode struct {
+	pos     int
+	c, a, b node
+}
+
+func (n *condNode) check(uses map[string]bool) (typ, error) {
+	ct, err := n.c.check(uses)
+	if err != nil {
+		return ct, err
+	}
+	if ct.kind != kindBool {
+		return typ{}, errorAt(n.pos, "the condition of ?: is %s, not a bool", ct.article())
+	}
+	at, err := n.a.check(uses)
+	if err != nil {
+		return at, err
+	}
+	bt, err := n.b.check(uses)
+	if err != nil {
+		return bt, err
+	}
+	if at != bt {
+		return typ{}, errorAt(n.pos, "the branches of ?: are %s and %s", at.article(), bt.article())
+	}
+	return at, nil
+}
+
+func (n *condNode) eval(vars map[string]any) (any, error) {
+	c, err := n.c.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	if c.(bool) {
+		return n.a.eval(vars)
+	}
+	return n.b.eval(vars)
+}
+
+type listNode struct {
+	pos   int
+	elems []node
+}
+
+func (n *listNode) check(uses map[string]bool) (typ, error) {
+	t := typ{kind: kindList}
+	for _, e := range n.elems {
+		et, err := e.check(uses)
+		if err != nil {
+			return et, err
+		}
+		if et.kind == kindList {
+			return typ{}, errorAt(n.pos, "lists cannot hold lists")
+		}
+		if t.elem != kindNone && et.kind != t.elem {
+			return typ{}, errorAt(n.pos, "a list mixes %s and %s", kindNames[t.elem], et)
+		}
+		t.elem = et.kind
+	}
+	return t, nil
+}
+
+func (n *listNode) eval(vars map[string]any) (any, error) {
+	list := make([]any, 0, len(n.elems))
+	for _, e := range n.elems {
+		v, err := e.eval(vars)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, v)
+	}
+	return list, nil
+}
+
+// callNode is a function call, or a method call when recv is set.
+type callNode struct {
+	pos  int
+	name string
+	recv node
+	args []node
+	// re is matches' pattern, compiled once when it is a literal.
+	re *regexp.Regexp
+}
+
+// stringMethods are the methods on strings that take one string.
+var stringMethods = map[string]bool{"startsWith": true, "endsWith": true, "contains": true, "matches": true}
+
+func (n *callNode) check(uses map[string]bool) (typ, error) {
+	args := n.args
+	if n.recv != nil {
+		args = append([]node{n.recv}, n.args...)
+	}
+	types := make([]typ, len(args))
+	for i, a := range args {
+		t, err := a.check(uses)
+		if err != nil {
+			return t, err
+		}
+		types[i] = t
+	}
+	wrong := func(want string) error {
+		return errorAt(n.pos, "%s takes %s", n.name, want)
+	}
+	switch {
+	case n.recv == nil && n.name == "duration":
+		if len(types) != 1 || types[0].kind != kindString {
+			return typ{}, wrong("one string, e.g. duration(\"30m\")")
+		}
+		if lit, ok := args[0].(*litNode); ok {
+			if _, err := time.ParseDuration(lit.v.(string)); err != nil {
+				return typ{}, errorAt(n.pos, "%v", err)
+			}
+		}
+		return typ{kind: kindDuration}, nil
+	case n.name == "size":
+		if len(types) != 1 || (types[0].kind != kindString && types[0].kind != kindList) {
+			return typ{}, wrong("a string or a list")
+		}
+		return typ{kind: kindInt}, nil
+	case n.recv != nil && stringMethods[n.name]:
+		if len(types) != 2 || types[0].kind != kindString || types[1].kind != kindString {
+			return typ{}, wrong("a string, e.g. file.name." + n.name + "(\".local\")")
+		}
+		if lit, ok := args[1].(*litNode); ok && n.name == "matches" {
+			re, err := regexp.Compile(lit.v.(string))
+			if err != nil {
+				return typ{}, errorAt(n.pos, "matches: %v", err)
+			}
+			n.re = re
+		}
+		return typ{kind: kindBool}, nil
+	}
+	return typ{}, errorAt(n.pos, "unknown function %s (functions: duration, size, startsWith, endsWith, contains, matches)", n.name)
+}
+
+func (n *callNode) eval(vars map[string]any) (any, error) {
+	args := n.args
+	if n.recv != nil {
+		args = append([]node{n.recv}, n.args...)
+	}
+	vals := make([]any, len(args))
+	for i, a := range args {
+		v, err := a.eval(vars)
+		if err != nil {
+			return nil, err
+		}
+		vals[i] = v
+	}
+	switch n.name {
+	case "duration":
+		return time.ParseDuration(vals[0].(string))
+	case "size":
+		if s, ok := vals[0].(string); ok {
+			return int64(len([]rune(s))), nil
+		}
+		return int64(len(vals[0].([]any))), nil
+	}
+	s, arg := vals[0].(string), vals[1].(string)
+	switch n.name {
+	case "startsWith":
+		return strings.HasPrefix(s, arg), nil
+	case "endsWith":
+		return strings.HasSuffix(s, arg), nil
+	case "contains":
+		return strings.Contains(s, arg), nil
+	}
+	re := n.re
+	if re == nil {
+		var err error
+		if re, err = regexp.Compile(arg); err != nil {
+			return nil, fmt.Errorf("matches: %w", err)
+		}
+	}
+	return re.MatchString(s), nil
+}
@@ -0,0 +1,363 @@
+package rule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// tokKind classifies a token.
+type tokKind int
+
+const (
+	tokEOF tokKind = iota
+	tokIdent
+	tokInt
+	tokString
+	tokOp
+)
+
+// token is one lexeme and its byte offset.
+type token struct {
+	kind tokKind
+	text string
+	pos  int
+}
+
+// lexer splits an expression into tokens.
+type lexer struct {
+	src string
+	pos int
+}
+
+// twoCharOps are the operators spelled with two characters.
+var twoCharOps = []string{"==", "!=", "<=", ">=", "&&", "||"}
+
+// next returns the next token.
+func (l *lexer) next() (token, error) {
+	for l.pos < len(l.src) && strings.IndexByte(" \t\r\n", l.src[l.pos]) >= 0 {
+		l.pos++
+	}
+	start := l.pos
+	if l.pos >= len(l.src) {
+		return token{kind: tokEOF, pos: start}, nil
+	}
+	c := l.src[l.pos]
+	switch {
+	case c == '_' || unicode.IsLetter(rune(c)):
+		for l.pos < len(l.src) && (l.src[l.pos] == '_' || unicode.IsLetter(rune(l.src[l.pos])) || unicode.IsDigit(rune(l.src[l.pos]))) {
+			l.pos++
+		}
+		return token{kind: tokIdent, text: l.src[start:l.pos], pos: start}, nil
+	case c >= '0' && c <= '9':
+		for l.pos < len(l.src) && l.src[l.pos] >= '0' && l.src[l.pos] <= '9' {
+			l.pos++
+		}
+		return token{kind: tokInt, text: l.src[start:l.pos], pos: start}, nil
+	case c == '"' || c == '\'':
+		s, err := l.str(c)
+		return token{kind: tokString, text: s, pos: start}, err
+	}
+	for _, op := range twoCharOps {
+		if strings.HasPrefix(l.src[l.pos:], op) {
+			l.pos += 2
+			return token{kind: tokOp, text: op, pos: start}, nil
+		}
+	}
+	if strings.IndexByte("()[].,?:!-+*/%<>", c) >= 0 {
+		l.pos++
+		return token{kind: tokOp, text: string(c), pos: start}, nil
+	}
+	r, _ := utf8.DecodeRuneInString(l.src[l.pos:])
+	return token{}, errorAt(start, "unexpected %q", r)
+}
+
+// str reads a string literal quoted with q, decoding \\, \n, \t and escaped
+// quotes.
+func (l *lexer) str(q byte) (string, error) {
+	start := l.pos
+	l.pos++
+	var b strings.Builder
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		l.pos++
+		switch {
+		case c == q:
+			return b.String(), nil
+		case c == '\\' && l.pos < len(l.src):
+			e := l.src[l.pos]
+			l.pos++
+			switch e {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case '\\', '"', '\'':
+				b.WriteByte(e)
+			default:
+				return "", errorAt(l.pos-2, "unknown escape \\%c", e)
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", errorAt(start, "unterminated string")
+}
+
+// errorAt reports a problem at byte offset pos, as a 1-based column.
+func errorAt(pos int, format string, args ...any) error {
+	return fmt.Errorf("column %d: %s", pos+1, fmt.Sprintf(format, args...))
+}
+
+// parser is a recursive-descent parser with CEL's precedence, loosest
+// first: ?:, ||, &&, relations (== != < <= > >= in), + -, * / %, unary
+// ! -, then member access and calls.
+type parser struct {
+	lex lexer
+	tok token
+	err error
+}
+
+// next advances to the next token, keeping the first lexing error.
+func (p *parser) next() {
+	if p.err != nil {
+		return
+	}
+	p.tok, p.err = p.lex.next()
+}
+
+// is reports whether the current token is the operator or keyword s.
+func (p *parser) is(s string) bool {
+	return (p.tok.kind == tokOp || p.tok.kind == tokIdent) && p.tok.text == s
+}
+
+// expect consumes the operator s.
+func (p *parser) expect(s string) error {
+	if p.err != nil {
+		return p.err
+	}
+	if !p.is(s) {
+		return p.unexpected("expected %q", s)
+	}
+	p.next()
+	return p.err
+}
+
+// unexpected reports the current token.
+func (p *parser) unexpected(format string, args ...any) error {
+	if p.err != nil {
+		return p.err
+	}
+	found := "end of expression"
+	if p.tok.kind != tokEOF {
+		found = strconv.Quote(p.tok.text)
+	}
+	return errorAt(p.tok.pos, "%s, found %s", fmt.Sprintf(format, args...), found)
+}
+
+// parse parses the whole expression.
+func (p *parser) parse() (node, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	if p.tok.kind == tokEOF {
+		return nil, errorAt(0, "empty expression")
+	}
+	n, err := p.conditional()
+	if err != nil {
+		return nil, err
+	}
+	if p.tok.kind != tokEOF {
+		return nil, p.unexpected("expected an operator")
+	}
+	return n, nil
+}
+
+func (p *parser) conditional() (node, error) {
+	c, err := p.binary(0)
+	if err != nil || !p.is("?") {
+		return c, err
+	}
+	pos := p.tok.pos
+	p.next()
+	a, err := p.conditional()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(":"); err != nil {
+		return nil, err
+	}
+	b, err := p.conditional()
+	if err != nil {
+		return nil, err
+	}
+	return &condNode{pos: pos, c: c, a: a, b: b}, nil
+}
+
+// levels are the binary operators by precedence, loosest first.
+var levels = [][]string{
+	{"||"},
+	{"&&"},
+	{"==", "!=", "<", "<=", ">", ">=", "in"},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+// binary parses the left-associative operators of levels[level:].
+func (p *parser) binary(level int) (node, error) {
+	if level == len(levels) {
+		return p.unary()
+	}
+	l, err := p.binary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for p.err == nil {
+		op := ""
+		for _, o := range levels[level] {
+			if p.is(o) {
+				op = o
+			}
+		}
+		if op == "" {
+			break
+		}
+		pos := p.tok.pos
+		p.next()
+		r, err := p.binary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		l = &binaryNode{pos: pos, op: op, l: l, r: r}
+	}
+	return l, p.err
+}
+
+func (p *parser) unary() (node, error) {
+	if p.is("!") || p.is("-") {
+		op, pos := p.tok.text, p.tok.pos
+		p.next()
+		x, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryNode{pos: pos, op: op, x: x}, nil
+	}
+	return p.member()
+}
+
+// member parses a primary followed by method calls.
+func (p *parser) member() (node, error) {
+	n, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+	for p.err == nil && p.is(".") {
+		p.next()
+		if p.tok.kind != tokIdent {
+			return nil, p.unexpected("expected a method name")
+		}
+		name, pos := p.tok.text, p.tok.pos
+		p.next()
+		if !p.is("(") {
+			return nil, errorAt(pos, "%s: fields exist only on the variables (%s)", name, variableNames())
+		}
+		args, err := p.args()
+		if err != nil {
+			return nil, err
+		}
+		n = &callNode{pos: pos, name: name, recv: n, args: args}
+	}
+	return n, p.err
+}
+
+// args parses a parenthesized argument list.
+func (p *parser) args() ([]node, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	var args []node
+	for !p.is(")") {
+		a, err := p.conditional()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, a)
+		if !p.is(",") {
+			break
+		}
+		p.next()
+	}
+	return args, p.expect(")")
+}
+
+func (p *parser) primary() (node, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	t := p.tok
+	switch t.kind {
+	case tokInt:
+		p.next()
+		v, err := strconv.ParseInt(t.text, 10, 64)
+		if err != nil {
+			return nil, errorAt(t.pos, "%s: out of range", t.text)
+		}
+		return &litNode{v: v, t: typ{kind: kindInt}}, p.err
+	case tokString:
+		p.next()
+		return &litNode{v: t.text, t: typ{kind: kindString}}, p.err
+	case tokIdent:
+		p.next()
+		switch t.text {
+		case "true", "false":
+			return &litNode{v: t.text == "true", t: typ{kind: kindBool}}, p.err
+		}
+		if p.is("(") {
+			args, err := p.args()
+			if err != nil {
+				return nil, err
+			}
+			return &callNode{pos: t.pos, name: t.text, args: args}, nil
+		}
+		// A variable is a namespace and a field, e.g. file.age.
+		if !p.is(".") {
+			return nil, errorAt(t.pos, "unknown name %q (variables: %s)", t.text, variableNames())
+		}
+		p.next()
+		if p.tok.kind != tokIdent {
+			return nil, p.unexpected("expected a field of %s", t.text)
+		}
+		name := t.text + "." + p.tok.text
+		p.next()
+		return &varNode{pos: t.pos, name: name}, p.err
+	}
+	switch {
+	case p.is("("):
+		p.next()
+		n, err := p.conditional()
+		if err != nil {
+			return nil, err
+		}
+		return n, p.expect(")")
+	case p.is("["):
+		pos := p.tok.pos
+		p.next()
+		var elems []node
+		for !p.is("]") {
+			e, err := p.conditional()
+			if err != nil {
+				return nil, err
+			}
+			elems = append(elems, e)
+			if !p.is(",") {
+				break
+			}
+			p.next()
+		}
+		return &listNode{pos: pos, elems: elems}, p.expect("]")
+	}
+	return nil, p.unexpected("expected a value")
+}
@@ -0,0 +1,124 @@
+// Package rule evaluates [guardian] encrypt_when, an expression in a subset
+// of CEL (the Common Expression Language) that decides whether an idle
+// plaintext file is encrypted now, for cases patterns cannot express:
+//
+//	file.age > duration("30m") && !file.locked && repo.branch != "main"
+//
+// The subset has bool, int, string, duration and list values; the operators
+// ! - * / % + == != < <= > >= in && || and ?:; the functions duration(s) and
+// size(x); and the string methods startsWith, endsWith, contains and matches
+// (RE2). Expressions are type-checked when compiled, so a typo or a
+// comparison of a string with a duration is a config error rather than a
+// surprise on the next idle check. The variables are listed in Variables.
+package rule
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Variables are the names a rule can use and their types.
+var Variables = map[string]string{
+	"file.path":    "string",   // absolute path
+	"file.name":    "string",   // base name, e.g. ".env.production"
+	"file.dir":     "string",   // directory
+	"file.age":     "duration", // time since the file was last modified
+	"file.size":    "int",      // bytes
+	"file.locked":  "bool",     // open in another process
+	"repo.branch":  "string",   // current git branch; "" outside git or detached
+	"repo.root":    "string",   // git working tree; "" outside git
+	"project.path": "string",   // registered project
+	"project.name": "string",   // its base name
+	"host.name":    "string",   // this machine's hostname
+	"now.hour":     "int",      // local hour, 0-23
+	"now.weekday":  "string",   // local day, e.g. "Saturday"
+}
+
+// Facts are the values of Variables for one file.
+type Facts struct {
+	Path    string
+	Age     time.Duration
+	Size    int64
+	Locked  bool
+	Branch  string
+	Repo    string
+	Project string
+	Host    string
+	Now     time.Time
+}
+
+// values returns the variables' values for f.
+func (f Facts) values() map[string]any {
+	now := f.Now.Local()
+	return map[string]any{
+		"file.path":    f.Path,
+		"file.name":    filepath.Base(f.Path),
+		"file.dir":     filepath.Dir(f.Path),
+		"file.age":     f.Age,
+		"file.size":    f.Size,
+		"file.locked":  f.Locked,
+		"repo.branch":  f.Branch,
+		"repo.root":    f.Repo,
+		"project.path": f.Project,
+		"project.name": filepath.Base(f.Project),
+		"host.name":    f.Host,
+		"now.hour":     int64(now.Hour()),
+		"now.weekday":  now.Weekday().String(),
+	}
+}
+
+// Rule is a compiled, type-checked expression.
+type Rule struct {
+	src  string
+	root node
+	uses map[string]bool
+}
+
+// Compile parses and type-checks src, which must be a bool expression.
+func Compile(src string) (*Rule, error) {
+	p := &parser{lex: lexer{src: src}}
+	p.next()
+	root, err := p.parse()
+	if err != nil {
+		return nil, err
+	}
+	r := &Rule{src: src, root: root, uses: map[string]bool{}}
+	t, err := root.check(r.uses)
+	if err != nil {
+		return nil, err
+	}
+	if t.kind != kindBool {
+		return nil, fmt.Errorf("the expression is %s, not a bool", t.article())
+	}
+	return r, nil
+}
+
+// String returns the source expression.
+func (r *Rule) String() string { return r.src }
+
+// Uses reports whether the rule reads variable name, so callers can skip
+// facts that are costly to gather, such as file.locked.
+func (r *Rule) Uses(name string) bool { return r.uses[name] }
+
+// Eval evaluates the rule on f. An error is a run-time failure such as a
+// division by zero.
+func (r *Rule) Eval(f Facts) (bool, error) {
+	v, err := r.root.eval(f.values())
+	if err != nil {
+		return false, err
+	}
+	return v.(bool), nil
+}
+
+// variableNames lists Variables for error messages.
+func variableNames() string {
+	names := make([]string, 0, len(Variables))
+	for name := range Variables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,93 @@
+package rule
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEval(t *testing.T) {
+	facts := Facts{
+		Path:    "/work/api/.env.production",
+		Age:     45 * time.Minute,
+		Size:    120,
+		Branch:  "feature/login",
+		Repo:    "/work/api",
+		Project: "/work/api",
+		Host:    "laptop",
+		Now:     time.Date(2026, 3, 7, 14, 0, 0, 0, time.Local), // a Saturday
+	}
+	tests := map[string]bool{
+		`file.age > duration("30m") && !file.locked && repo.branch != "main"`: true,
+		`file.age > duration("1h")`:                                                   false,
+		`file.name == ".env.production"`:                                              true,
+		`file.name.endsWith(".local") || file.size >= 100`:                            true,
+		`repo.branch.startsWith("feature/") ? file.age > duration("40m") : true`:      true,
+		`repo.branch in ["main", "release"]`:                                          false,
+		`now.weekday in ["Saturday", "Sunday"] && now.hour >= 9`:                      true,
+		`file.path.matches("^/work/(api|web)/")`:                                      true,
+		`file.dir.endsWith("api") && project.name == "api"`:                           true,
+		`size(file.name) == 15 && file.name.size() == 15`:                             true,
+		`(file.size + 30) * 2 / 3 % 7 == 2`:                                           true,
+		`-file.age < duration("0s") && file.age - duration("15m") == duration("30m")`: true,
+		`host.name + "!" == 'laptop!'`:                                                true,
+		`repo.root != "" && file.name in []`:                                          false,
+	}
+	for src, want := range tests {
+		r, err := Compile(src)
+		if err != nil {
+			t.Errorf("Compile(%s): %v", src, err)
+			continue
+		}
+		got, err := r.Eval(facts)
+		if err != nil || got != want {
+			t.Errorf("%s = %v, %v; want %v", src, got, err, want)
+		}
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := map[string]string{
+		``:                                   "empty expression",
+		`file.age > "30m"`:                   "cannot be applied to a duration and a string",
+		`file.size`:                          "not a bool",
+		`file.owner == "me"`:                 "unknown variable file.owner",
+		`branch == "main"`:                   "unknown name",
+		`duration("30 minutes") < file.age`:  "duration",
+		`file.name.matches("(")`:             "matches",
+		`file.name.lower() == ".env"`:        "unknown function lower",
+		`file.locked && (repo.branch == "x"`: `expected ")"`,
+		`file.locked file.locked`:            "column 13: expected an operator",
+		`"a" in ["a", 1]`:                    "mixes",
+		`file.name == "open`:                 "unterminated string",
+		`file.locked ? 1 : "x"`:              "branches",
+		`file.locked @ true`:                 "unexpected '@'",
+	}
+	for src, want := range tests {
+		if _, err := Compile(src); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Compile(%s) = %v; want an error containing %q", src, err, want)
+		}
+	}
+}
+
+func TestUsesAndRuntimeErrors(t *testing.T) {
+	r, err := Compile(`file.size / 0 == 1 || !file.locked`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.Uses("file.locked") || r.Uses("repo.branch") {
+		t.Errorf("Uses: file.locked %v, repo.branch %v", r.Uses("file.locked"), r.Uses("repo.branch"))
+	}
+	if _, err := r.Eval(Facts{}); err == nil || !strings.Contains(err.Error(), "division by zero") {
+		t.Errorf("Eval = %v; want a division by zero", err)
+	}
+
+	// The right side of && is not evaluated once the left is false.
+	r, err = Compile(`file.size > 0 && file.size / 0 == 1`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := r.Eval(Facts{}); err != nil || got {
+		t.Errorf("short-circuit Eval = %v, %v", got, err)
+	}
+}
@@ -0,0 +1,143 @@
+// Package schedule parses the daily time windows of [notifications]
+// quiet_hours and [guardian] deep_work_hours, such as "22:00-08:00", and
+// tells whether a moment falls in one.
+//
+// Windows are wall-clock times in the local time zone: 22:00 is 22:00
+// whatever the UTC offset, so a window keeps its hours across a daylight
+// saving change, and one that crosses midnight runs into the next day. On
+// the night clocks go forward a window edge inside the skipped hour falls
+// at the first moment after it.
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Window is a daily span of local time, in minutes after midnight. End
+// before Start runs past midnight.
+type Window struct {
+	Start, End int
+}
+
+// Windows is a set of windows; a moment in any of them is in the set.
+type Windows []Window
+
+// Parse reads a window written "HH:MM-HH:MM", e.g. "22:00-08:00"; the end
+// may be "24:00".
+func Parse(s string) (Window, error) {
+	from, to, ok := strings.Cut(strings.TrimSpace(s), "-")
+	if !ok {
+		return Window{}, fmt.Errorf("%q is not a window such as \"22:00-08:00\"", s)
+	}
+	start, err := parseClock(from, false)
+	if err != nil {
+		return Window{}, fmt.Errorf("%q: %w", s, err)
+	}
+	end, err := parseClock(to, true)
+	if err != nil {
+		return Window{}, fmt.Errorf("%q: %w", s, err)
+	}
+	if start == end {
+		return Window{}, fmt.Errorf("%q: starts when it ends", s)
+	}
+	return Window{Start: start, End: end}, nil
+}
+
+// ParseAll reads each of specs with Parse.
+func ParseAll(specs []string) (Windows, error) {
+	ws := make(Windows, 0, len(specs))
+	for _, s := range specs {
+		w, err := Parse(s)
+		if err != nil {
+			return nil, err
+		}
+		ws = append(ws, w)
+	}
+	return ws, nil
+}
+
+// parseClock reads "HH:MM" as minutes after midnight; "24:00" only when
+// end is set.
+func parseClock(s string, end bool) (int, error) {
+	hh, mm, ok := strings.Cut(strings.TrimSpace(s), ":")
+	h, herr := strconv.Atoi(hh)
+	m, merr := strconv.Atoi(mm)
+	if !ok || herr != nil || merr != nil || len(mm) != 2 || h < 0 || h > 24 || m < 0 || m > 59 ||
+		(h == 24 && (!end || m != 0)) {
+		return 0, fmt.Errorf("%q is not a time of day such as \"08:00\"", strings.TrimSpace(s))
+	}
+	return h*60 + m, nil
+}
+
+// String writes w as Parse reads it.
+func (w Window) String() string {
+	return fmt.Sprintf("%02d:%02d-%02d:%02d", w.Start/60, w.Start%60, w.End/60, w.End%60)
+}
+
+// contains reports whether the minute of the day m is in w.
+func (w Window) contains(m int) bool {
+	if w.Start < w.End {
+		return m >= w.Start && m < w.End
+	}
+	return m >= w.Start || m < w.End
+}
+
+// Contains reports whether t, in local time, is in one of the windows.
+func (ws Windows) Contains(t time.Time) bool {
+	t = t.Local()
+	m := t.Hour()*60 + t.Minute()
+	for _, w := range ws {
+		if w.contains(m) {
+			return true
+		}
+	}
+	return false
+}
+
+// End returns when the windows t is in end: the first moment after t in
+// none of them, following windows that meet or overlap. It returns t when t
+// is in none.
+func (ws Windows) End(t time.Time) time.Time {
+	t = t.Local()
+	// Each pass leaves one window, so windows that together cover the whole
+	// day stop after a pass for each.
+	for i := 0; i <= len(ws); i++ {
+		if !ws.Contains(t) {
+			return t
+		}
+		m := t.Hour()*60 + t.Minute()
+		next := t
+		for _, w := range ws {
+			if !w.contains(m) {
+				continue
+			}
+			day := t
+			if w.End <= m {
+				day = t.AddDate(0, 0, 1)
+			}
+			if end := at(day, w.End); end.After(next) {
+				next = end
+			}
+		}
+		t = next
+	}
+	return t
+}
+
+// at returns the moment of day whose local wall clock reads minutes after
+// midnight (24:00 is the next midnight). When the clocks skip that time it
+// returns the moment they jump, which time.Date leaves unspecified.
+func at(day time.Time, minutes int) time.Time {
+	t := time.Date(day.Year(), day.Month(), day.Day(), minutes/60, minutes%60, 0, 0, time.Local)
+	if t.Hour()*60+t.Minute() == minutes%(24*60) {
+		return t
+	}
+	start, end := t.ZoneBounds()
+	if t.Hour()*60+t.Minute() < minutes%(24*60) {
+		return end
+	}
+	return start
+}
@@ -0,0 +1,99 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func TestParse(t *testing.T) {
+	for _, s := range []string{"22:00-08:00", " 09:30 - 12:00 ", "00:00-24:00", "23:59-00:00"} {
+		if _, err := Parse(s); err != nil {
+			t.Errorf("Parse(%q) = %v", s, err)
+		}
+	}
+	for _, s := range []string{"", "22:00", "22-08", "25:00-08:00", "08:60-09:00", "24:00-08:00", "08:00-24:30", "9:5-10:00", "08:00-08:00"} {
+		if _, err := Parse(s); err == nil {
+			t.Errorf("Parse(%q) accepted", s)
+		}
+	}
+	if w, _ := Parse("9:05-17:30"); w.String() != "09:05-17:30" {
+		t.Errorf("String() = %q", w)
+	}
+}
+
+// inZone runs the test with the local time zone set to name.
+func inZone(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prev := time.Local
+	time.Local = loc
+	t.Cleanup(func() { time.Local = prev })
+	return loc
+}
+
+func TestContainsAndEnd(t *testing.T) {
+	loc := inZone(t, "Europe/Berlin")
+	ws, err := ParseAll([]string{"22:00-08:00", "12:00-13:00"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := func(day, h, m int) time.Time { return time.Date(2026, 6, day, h, m, 0, 0, loc) }
+	for _, tc := range []struct {
+		t    time.Time
+		in   bool
+		ends time.Time
+	}{
+		{at(10, 23, 0), true, at(11, 8, 0)},
+		{at(11, 7, 59), true, at(11, 8, 0)},
+		{at(11, 8, 0), false, at(11, 8, 0)},
+		{at(11, 12, 30), true, at(11, 13, 0)},
+		{at(11, 21, 59), false, at(11, 21, 59)},
+	} {
+		if got := ws.Contains(tc.t); got != tc.in {
+			t.Errorf("Contains(%s) = %v", tc.t.Format("15:04"), got)
+		}
+		if got := ws.End(tc.t); !got.Equal(tc.ends) {
+			t.Errorf("End(%s) = %s; want %s", tc.t.Format("Jan 2 15:04"), got, tc.ends)
+		}
+	}
+	// Windows that meet are one.
+	ws, _ = ParseAll([]string{"20:00-22:00", "22:00-23:30"})
+	if got := ws.End(at(11, 21, 0)); !got.Equal(at(11, 23, 30)) {
+		t.Errorf("End of meeting windows = %s", got)
+	}
+	// UTC instants are read in local time.
+	if !ws.Contains(time.Date(2026, 6, 11, 19, 0, 0, 0, time.UTC)) { // 21:00 in Berlin
+		t.Error("Contains reads UTC times as local")
+	}
+}
+
+func TestDaylightSaving(t *testing.T) {
+	loc := inZone(t, "America/New_York")
+	ws, _ := ParseAll([]string{"22:00-08:00"})
+
+	// Clocks go back at 02:00 on Nov 1 2026: the night is an hour longer,
+	// and the window still ends at 08:00 local time.
+	start := time.Date(2026, 10, 31, 22, 0, 0, 0, loc)
+	end := ws.End(start)
+	if want := time.Date(2026, 11, 1, 8, 0, 0, 0, loc); !end.Equal(want) || end.Sub(start) != 11*time.Hour {
+		t.Errorf("End across fall back = %s (%v long); want %s, 11h", end, end.Sub(start), want)
+	}
+	// They go forward at 02:00 on Mar 8 2026: an hour shorter.
+	start = time.Date(2026, 3, 7, 22, 0, 0, 0, loc)
+	if end := ws.End(start); end.Sub(start) != 9*time.Hour || end.Hour() != 8 {
+		t.Errorf("End across spring forward = %s (%v long); want 08:00, 9h", end, end.Sub(start))
+	}
+
+	// A window edge in the skipped hour falls at 03:00.
+	ws, _ = ParseAll([]string{"01:00-02:30"})
+	if end := ws.End(time.Date(2026, 3, 8, 1, 30, 0, 0, loc)); end.Hour() != 3 || end.Minute() != 0 {
+		t.Errorf("End in the skipped hour = %s", end)
+	}
+	if ws.Contains(time.Date(2026, 3, 8, 3, 15, 0, 0, loc)) {
+		t.Error("03:15 is after a window ending at 02:30")
+	}
+}
@@ -0,0 +1,55 @@
+// Package screenlock detects whether the user's screen is locked, so
+// [guardian] deep_work_hours can let encryption run while the user is away.
+//
+// Linux asks logind for the session's LockedHint (set by GNOME, KDE and
+// most lockers that speak to logind), macOS reads CGSSessionScreenIsLocked
+// from `ioreg`, and Windows looks for LogonUI.exe, which runs while the lock
+// screen is up. Anything undetectable reports unlocked, so detection can
+// only ever let work run when a lock is positively seen.
+package screenlock
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// probeTimeout bounds the probe command.
+const probeTimeout = 3 * time.Second
+
+// runCommand is a seam for tests.
+var runCommand = func(ctx context.Context, name string, args ...string) ([]byte, error) {
+	return exec.CommandContext(ctx, name, args...).Output()
+}
+
+// Locked reports whether the screen is locked.
+func Locked() bool {
+	return detect(runtime.GOOS)
+}
+
+// detect is Locked on goos.
+func detect(goos string) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), probeTimeout)
+	defer cancel()
+	switch goos {
+	case "linux", "freebsd":
+		// A service is in no session of its own: "auto" is then the user's
+		// graphical one.
+		session := os.Getenv("XDG_SESSION_ID")
+		if session == "" {
+			session = "auto"
+		}
+		out, err := runCommand(ctx, "loginctl", "show-session", session, "-p", "LockedHint", "--value")
+		return err == nil && strings.TrimSpace(string(out)) == "yes"
+	case "darwin":
+		out, err := runCommand(ctx, "ioreg", "-n", "Root", "-d1")
+		return err == nil && strings.Contains(string(out), `"CGSSessionScreenIsLocked"=Yes`)
+	case "windows":
+		out, err := runCommand(ctx, "tasklist", "/FI", "IMAGENAME eq LogonUI.exe", "/NH")
+		return err == nil && strings.Contains(strings.ToLower(string(out)), "logonui.exe")
+	}
+	return false
+}
@@ -0,0 +1,43 @@
+package screenlock
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestDetect(t *testing.T) {
+	old := runCommand
+	t.Cleanup(func() { runCommand = old })
+	t.Setenv("XDG_SESSION_ID", "")
+
+	tests := []struct {
+		name, goos, out string
+		err             error
+		want            bool
+	}{
+		{"linux locked", "linux", "yes\n", nil, true},
+		{"linux unlocked", "linux", "no\n", nil, false},
+		{"linux no logind", "linux", "", errors.New("not found"), false},
+		{"darwin locked", "darwin", `| "CGSSessionScreenIsLocked"=Yes`, nil, true},
+		{"darwin unlocked", "darwin", `| "CGSSessionOnConsoleKey"=Yes`, nil, false},
+		{"windows locked", "windows", "LogonUI.exe   1234 Console   1   40,000 K", nil, true},
+		{"windows unlocked", "windows", "INFO: No tasks are running which match the specified criteria.", nil, false},
+		{"other", "plan9", "yes", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var args []string
+			runCommand = func(_ context.Context, name string, a ...string) ([]byte, error) {
+				args = append([]string{name}, a...)
+				return []byte(tt.out), tt.err
+			}
+			if got := detect(tt.goos); got != tt.want {
+				t.Errorf("detect(%s) = %v, want %v", tt.goos, got, tt.want)
+			}
+			if tt.goos == "linux" && (len(args) < 3 || args[2] != "auto") {
+				t.Errorf("loginctl args = %v, want session auto", args)
+			}
+		})
+	}
+}
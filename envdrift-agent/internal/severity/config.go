@@ -0,0 +1,107 @@
+package severity
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ConfigPatterns are the config files ScanConfig reads: YAML, JSON and TOML.
+var ConfigPatterns = []string{"*.yaml", "*.yml", "*.json", "*.toml"}
+
+// lockFiles are config files package managers write, full of integrity
+// hashes that look random.
+var lockFiles = map[string]bool{
+	"package-lock.json":   true,
+	"npm-shrinkwrap.json": true,
+	"composer.lock":       true,
+	"pnpm-lock.yaml":      true,
+	"deno.lock":           true,
+}
+
+// maxConfigSize is the largest config file ScanConfig reads; bigger ones are
+// data, not configuration.
+const maxConfigSize = 1 << 20
+
+// IsConfigFile reports whether ScanConfig reads the file at path.
+func IsConfigFile(path string) bool {
+	base := strings.ToLower(filepath.Base(path))
+	if lockFiles[base] {
+		return false
+	}
+	for _, p := range ConfigPatterns {
+		if ok, _ := filepath.Match(p, base); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// configEntry matches a YAML `key: value`, JSON `"key": value` or TOML
+// `key = value` line, or a YAML list item, capturing the key and the value.
+var configEntry = regexp.MustCompile(`^\s*(?:-\s+)?(?:["']?([A-Za-z0-9_.-]+)["']?\s*[:=]\s*)?(.*?)\s*,?\s*$`)
+
+// digestName matches keys whose random-looking values are checksums or
+// revisions rather than secrets.
+var digestName = regexp.MustCompile(`(?i)(sha|hash|digest|checksum|integrity|commit|revision)`)
+
+// ScanConfig returns the values in the config file at path that look like
+// secrets, Medium and above: tokens, private keys, gitleaks rule matches and
+// high-entropy strings.
+// Unlike Classify on an env file it goes by the shape of values alone,
+// since a config key such as token_url names no credential. A file SOPS
+// encrypted, or larger than 1 MiB, has none.
+func ScanConfig(path string) ([]Finding, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() > maxConfigSize {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.Contains(data, []byte("ENC[AES256_GCM,")) {
+		return nil, nil
+	}
+	var findings []Finding
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(make([]byte, 0, 64*1024), maxConfigSize)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "//") {
+			continue
+		}
+		m := configEntry.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		key, value := m[1], configValue(m[2])
+		if value == "" || strings.HasPrefix(value, "encrypted:") {
+			continue
+		}
+		f := classify("", value, line, path)
+		if f.Level < Medium || (f.Level == Medium && digestName.MatchString(key)) {
+			continue
+		}
+		f.Key, f.Line = key, n
+		findings = append(findings, f)
+	}
+	return findings, sc.Err()
+}
+
+// configValue is the value of a config entry: the text of a quoted string,
+// without what follows it on the line, or the bare value.
+func configValue(v string) string {
+	if v != "" && (v[0] == '"' || v[0] == '\'') {
+		if end := strings.IndexByte(v[1:], v[0]); end >= 0 {
+			return v[1 : end+1]
+		}
+	}
+	return v
+}
@@ -0,0 +1,75 @@
+package severity
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIsConfigFile(t *testing.T) {
+	for path, want := range map[string]bool{
+		"deploy/values.yaml":  true,
+		"app.YML":             true,
+		"settings.json":       true,
+		"pyproject.toml":      true,
+		"package-lock.json":   false,
+		"pnpm-lock.yaml":      false,
+		".env":                false,
+		"config.yaml.example": false,
+	} {
+		if got := IsConfigFile(path); got != want {
+			t.Errorf("IsConfigFile(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestScanConfig(t *testing.T) {
+	dir := t.TempDir()
+	ghToken := "ghp_" + strings.Repeat("a1B2", 9)
+	content := strings.Join([]string{
+		"# settings",
+		"name: my-service",
+		"token_url: https://auth.example.com/token",
+		"github:",
+		"  token: " + ghToken,
+		`  key: "Zx9vQ2mLp8RtY4wKc7NbJ3hF" # rotated monthly`,
+		"checksum: 8f14e45fceea167a5a36dedd4bea2543",
+		"replicas: 3",
+	}, "\n")
+	path := filepath.Join(dir, "values.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	findings, err := ScanConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(findings) != 2 {
+		t.Fatalf("findings = %+v; want the token and the key", findings)
+	}
+	if f := findings[0]; f.Key != "token" || f.Line != 5 || f.Level != High || f.Reason != "GitHub token" {
+		t.Errorf("findings[0] = %+v", f)
+	}
+	if f := findings[1]; f.Key != "key" || f.Line != 6 || f.Level != Medium {
+		t.Errorf("findings[1] = %+v", f)
+	}
+
+	// JSON and TOML entries are read the same way.
+	json := filepath.Join(dir, "settings.json")
+	if err := os.WriteFile(json, []byte("{\n  \"stripe\": \"sk_live_"+strings.Repeat("x1", 12)+"\",\n  \"debug\": true\n}\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if findings, err := ScanConfig(json); err != nil || len(findings) != 1 || findings[0].Key != "stripe" || findings[0].Line != 2 {
+		t.Errorf("ScanConfig(json) = %+v, %v", findings, err)
+	}
+
+	// A SOPS-encrypted file holds ciphertext only.
+	sops := filepath.Join(dir, "secrets.yaml")
+	if err := os.WriteFile(sops, []byte("token: ENC[AES256_GCM,data:"+ghToken+",type:str]\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if findings, err := ScanConfig(sops); err != nil || len(findings) != 0 {
+		t.Errorf("ScanConfig(sops) = %+v, %v", findings, err)
+	}
+}
@@ -0,0 +1,305 @@
+package severity
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// An organization's own detection rules can come from gitleaks rule files
+// (https://github.com/gitleaks/gitleaks, config format v8), so they are not
+// kept twice. Set once from [severity] gitleaks, a rule matching a value
+// ranks it High, with the rule's ID as the reason, ahead of the built-in
+// token formats; a rule whose ID or tags say private key ranks it Critical.
+
+// Rule is one gitleaks [[rules]] entry.
+type Rule struct {
+	ID          string
+	Description string
+	// Regex matches the secret in its line; SecretGroup is the capture
+	// group holding the secret, 0 for the only group or the whole match.
+	Regex       *regexp.Regexp
+	SecretGroup int
+	// Entropy is the least Shannon entropy the secret must have; 0 is any.
+	Entropy float64
+	// Keywords, lower-case, are the words one of which the line must hold
+	// for the rule to be tried; none tries it on every line.
+	Keywords []string
+	// Path limits the rule to the files whose path it matches; nil is all.
+	Path  *regexp.Regexp
+	Level Level
+	allow []allowlist
+}
+
+// allowlist is a gitleaks allowlist: secrets it matches are not findings.
+type allowlist struct {
+	// and needs every check set to match, where gitleaks's condition
+	// "AND" does; the default is any.
+	and       bool
+	target    string
+	regexes   []*regexp.Regexp
+	stopwords []string
+	paths     []*regexp.Regexp
+}
+
+// gitleaksFile is the part of a gitleaks config the agent reads.
+type gitleaksFile struct {
+	Extend struct {
+		Path string `toml:"path"`
+	} `toml:"extend"`
+	Rules []struct {
+		ID          string         `toml:"id"`
+		Description string         `toml:"description"`
+		Regex       string         `toml:"regex"`
+		SecretGroup int            `toml:"secretGroup"`
+		Entropy     float64        `toml:"entropy"`
+		Keywords    []string       `toml:"keywords"`
+		Path        string         `toml:"path"`
+		Tags        []string       `toml:"tags"`
+		Allowlist   *rawAllowlist  `toml:"allowlist"`
+		Allowlists  []rawAllowlist `toml:"allowlists"`
+	} `toml:"rules"`
+	Allowlist *rawAllowlist `toml:"allowlist"`
+}
+
+type rawAllowlist struct {
+	Condition   string   `toml:"condition"`
+	RegexTarget string   `toml:"regexTarget"`
+	Regexes     []string `toml:"regexes"`
+	StopWords   []string `toml:"stopwords"`
+	Paths       []string `toml:"paths"`
+}
+
+// maxExtend bounds a chain of [extend] path files.
+const maxExtend = 4
+
+// LoadGitleaks reads the gitleaks rule files at paths, following each one's
+// [extend] path. A rule with no regex (a gitleaks path-only rule, which
+// flags files by name) is skipped; one with a regex RE2 rejects is an error.
+func LoadGitleaks(paths ...string) ([]Rule, error) {
+	var rules []Rule
+	for _, path := range paths {
+		r, err := loadGitleaks(path, 0)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, r...)
+	}
+	return rules, nil
+}
+
+func loadGitleaks(path string, depth int) ([]Rule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var f gitleaksFile
+	if err := toml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	var global []allowlist
+	if f.Allowlist != nil {
+		a, err := compileAllowlist(*f.Allowlist)
+		if err != nil {
+			return nil, fmt.Errorf("%s: allowlist: %w", path, err)
+		}
+		global = append(global, a)
+	}
+	var rules []Rule
+	if base := f.Extend.Path; base != "" {
+		if depth >= maxExtend {
+			return nil, fmt.Errorf("%s: extend: more than %d files deep", path, maxExtend)
+		}
+		if !filepath.IsAbs(base) {
+			base = filepath.Join(filepath.Dir(path), base)
+		}
+		if rules, err = loadGitleaks(base, depth+1); err != nil {
+			return nil, err
+		}
+	}
+	for i, raw := range f.Rules {
+		if raw.Regex == "" {
+			continue
+		}
+		id := raw.ID
+		if id == "" {
+			id = fmt.Sprintf("rules[%d]", i)
+		}
+		re, err := regexp.Compile(raw.Regex)
+		if err != nil {
+			return nil, fmt.Errorf("%s: rule %s: %w", path, id, err)
+		}
+		r := Rule{ID: id, Description: raw.Description, Regex: re, SecretGroup: raw.SecretGroup,
+			Entropy: raw.Entropy, Level: ruleLevel(id, raw.Tags), allow: global}
+		if r.SecretGroup > re.NumSubexp() {
+			return nil, fmt.Errorf("%s: rule %s: secretGroup %d, but the regex has %d groups", path, id, r.SecretGroup, re.NumSubexp())
+		}
+		for _, k := range raw.Keywords {
+			r.Keywords = append(r.Keywords, strings.ToLower(k))
+		}
+		if raw.Path != "" {
+			if r.Path, err = regexp.Compile(raw.Path); err != nil {
+				return nil, fmt.Errorf("%s: rule %s: path: %w", path, id, err)
+			}
+		}
+		lists := raw.Allowlists
+		if raw.Allowlist != nil {
+			lists = append(lists, *raw.Allowlist)
+		}
+		for _, l := range lists {
+			a, err := compileAllowlist(l)
+			if err != nil {
+				return nil, fmt.Errorf("%s: rule %s: allowlist: %w", path, id, err)
+			}
+			r.allow = append(r.allow, a)
+		}
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
+
+// ruleLevel ranks a rule's findings: Critical for a private key rule, High
+// for the rest.
+func ruleLevel(id string, tags []string) Level {
+	for _, s := range append([]string{id}, tags...) {
+		if strings.Contains(strings.ToLower(s), "private-key") || strings.Contains(strings.ToLower(s), "private key") {
+			return Critical
+		}
+	}
+	return High
+}
+
+func compileAllowlist(raw rawAllowlist) (allowlist, error) {
+	a := allowlist{and: strings.EqualFold(raw.Condition, "AND"), target: raw.RegexTarget}
+	for _, s := range raw.Regexes {
+		re, err := regexp.Compile(s)
+		if err != nil {
+			return a, err
+		}
+		a.regexes = append(a.regexes, re)
+	}
+	for _, s := range raw.Paths {
+		re, err := regexp.Compile(s)
+		if err != nil {
+			return a, err
+		}
+		a.paths = append(a.paths, re)
+	}
+	for _, w := range raw.StopWords {
+		a.stopwords = append(a.stopwords, strings.ToLower(w))
+	}
+	return a, nil
+}
+
+// allows reports whether a lets the secret found as match in line, in the
+// file at path ("" when unknown), through.
+func (a allowlist) allows(secret, match, line, path string) bool {
+	var checks []bool
+	if len(a.regexes) > 0 {
+		target := secret
+		switch a.target {
+		case "match":
+			target = match
+		case "line":
+			target = line
+		}
+		checks = append(checks, anyMatch(a.regexes, target))
+	}
+	if len(a.stopwords) > 0 {
+		lower := strings.ToLower(secret)
+		hit := false
+		for _, w := range a.stopwords {
+			hit = hit || strings.Contains(lower, w)
+		}
+		checks = append(checks, hit)
+	}
+	if len(a.paths) > 0 && path != "" {
+		checks = append(checks, anyMatch(a.paths, path))
+	}
+	if len(checks) == 0 {
+		return false
+	}
+	for _, ok := range checks {
+		if ok != a.and {
+			return ok
+		}
+	}
+	return a.and
+}
+
+func anyMatch(res []*regexp.Regexp, s string) bool {
+	for _, re := range res {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// match returns the secret r finds in line, in the file at path ("" when
+// unknown), if any.
+func (r Rule) match(line, path string) (string, bool) {
+	if r.Path != nil && (path == "" || !r.Path.MatchString(filepath.ToSlash(path))) {
+		return "", false
+	}
+	if len(r.Keywords) > 0 {
+		lower := strings.ToLower(line)
+		found := false
+		for _, k := range r.Keywords {
+			found = found || strings.Contains(lower, k)
+		}
+		if !found {
+			return "", false
+		}
+	}
+	for _, m := range r.Regex.FindAllStringSubmatch(line, -1) {
+		secret := m[0]
+		switch {
+		case r.SecretGroup > 0:
+			secret = m[r.SecretGroup]
+		case len(m) == 2:
+			secret = m[1]
+		}
+		if secret == "" || (r.Entropy > 0 && entropy(secret) < r.Entropy) {
+			continue
+		}
+		allowed := false
+		for _, a := range r.allow {
+			allowed = allowed || a.allows(secret, m[0], line, path)
+		}
+		if !allowed {
+			return secret, true
+		}
+	}
+	return "", false
+}
+
+var (
+	rulesMu sync.RWMutex
+	rules   []Rule
+)
+
+// SetRules sets the gitleaks rules Classify and ScanConfig apply, as
+// LoadGitleaks returned them; nil applies none.
+func SetRules(r []Rule) {
+	rulesMu.Lock()
+	defer rulesMu.Unlock()
+	rules = append([]Rule(nil), r...)
+}
+
+// matchRules returns the finding of the first rule that matches line.
+func matchRules(line, path string) (Finding, bool) {
+	rulesMu.RLock()
+	defer rulesMu.RUnlock()
+	for _, r := range rules {
+		if _, ok := r.match(line, path); ok {
+			return Finding{Level: r.Level, Reason: "gitleaks rule " + r.ID}, true
+		}
+	}
+	return Finding{}, false
+}
@@ -0,0 +1,118 @@
+package severity
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const baseRules = `
+[[rules]]
+id = "acme-private-key"
+regex = '''ACMEPRIV-[A-Z0-9]{16}'''
+`
+
+const orgRules = `
+title = "org rules"
+
+[extend]
+path = "base.toml"
+
+[allowlist]
+stopwords = ["example"]
+
+[[rules]]
+id = "acme-api-token"
+description = "Acme API token"
+regex = '''(?i)acme[_-]?token\s*[:=]\s*["']?([a-z0-9]{24})'''
+secretGroup = 1
+entropy = 3.0
+keywords = ["acme"]
+
+[[rules]]
+id = "internal-id"
+regex = '''INT-(\d{8})'''
+[[rules.allowlists]]
+regexes = ['''^0+$''']
+
+[[rules]]
+id = "pkcs12-file"
+path = '''\.p12$'''
+`
+
+func writeRules(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "base.toml"), []byte(baseRules), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "gitleaks.toml")
+	if err := os.WriteFile(path, []byte(orgRules), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadGitleaks(t *testing.T) {
+	rules, err := LoadGitleaks(writeRules(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, r := range rules {
+		ids = append(ids, r.ID)
+	}
+	// The path-only rule flags files by name, which the agent does not do.
+	if got := strings.Join(ids, ","); got != "acme-private-key,acme-api-token,internal-id" {
+		t.Errorf("rules = %s", got)
+	}
+	if rules[0].Level != Critical || rules[1].Level != High {
+		t.Errorf("levels = %v, %v; want critical, high", rules[0].Level, rules[1].Level)
+	}
+
+	bad := filepath.Join(t.TempDir(), "bad.toml")
+	if err := os.WriteFile(bad, []byte("[[rules]]\nid = \"x\"\nregex = '''(?<=a)b'''\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadGitleaks(bad); err == nil || !strings.Contains(err.Error(), "rule x") {
+		t.Errorf("a regex RE2 rejects: %v", err)
+	}
+}
+
+func TestClassifyGitleaks(t *testing.T) {
+	rules, err := LoadGitleaks(writeRules(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetRules(rules)
+	t.Cleanup(func() { SetRules(nil) })
+
+	for _, tc := range []struct {
+		key, value string
+		level      Level
+		reason     string
+	}{
+		{"ACME_TOKEN", "q7w3e9r1t5y8u2i4o6p0a1s3", High, "gitleaks rule acme-api-token"},
+		// Below the rule's entropy, or a stopword: the built-in checks decide.
+		{"ACME_TOKEN", "aaaaaaaaaaaaaaaaaaaaaaaa", High, "named as a credential"},
+		{"ACME_TOKEN", "example1example2example3", High, "named as a credential"},
+		{"SIGNING", "ACMEPRIV-0123456789ABCDEF", Critical, "gitleaks rule acme-private-key"},
+		{"REF", "INT-12345678", High, "gitleaks rule internal-id"},
+		{"REF", "INT-00000000", Low, "plain config"}, // the rule's allowlist
+	} {
+		f := Classify(tc.key, tc.value)
+		if f.Level != tc.level || f.Reason != tc.reason || f.Key != tc.key {
+			t.Errorf("Classify(%s=%s) = %+v; want %v %q", tc.key, tc.value, f, tc.level, tc.reason)
+		}
+	}
+
+	// Config files are matched line by line.
+	path := filepath.Join(t.TempDir(), "app.yaml")
+	if err := os.WriteFile(path, []byte("acme:\n  acme_token: q7w3e9r1t5y8u2i4o6p0a1s3\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if findings, err := ScanConfig(path); err != nil || len(findings) != 1 || findings[0].Reason != "gitleaks rule acme-api-token" {
+		t.Errorf("ScanConfig = %+v, %v", findings, err)
+	}
+}
@@ -0,0 +1,205 @@
+// Package severity ranks plaintext env values by how much harm leaking them
+// would do — a private key over an API token over a generic high-entropy
+// string over plain configuration — so the notifications and CI failures a
+// plaintext file causes can be proportionate to what it holds. It looks at
+// names and the shape of values only; nothing it returns holds a value.
+package severity
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/jainal09/envdrift-agent/internal/encrypt"
+)
+
+// Level is the severity of a plaintext value, ordered so a greater Level is
+// more severe.
+type Level int
+
+// The levels, least severe first.
+const (
+	// Low is plain configuration: LOG_LEVEL=debug, PORT=8080.
+	Low Level = iota + 1
+	// Medium is a generic high-entropy string that may be a secret.
+	Medium
+	// High is an API token or a credential named as one.
+	High
+	// Critical is a private key.
+	Critical
+)
+
+// Names are the levels' names, least severe first, as configured.
+var Names = []string{"low", "medium", "high", "critical"}
+
+// String returns the level's name, e.g. "high".
+func (l Level) String() string {
+	if l < Low || l > Critical {
+		return "none"
+	}
+	return Names[l-Low]
+}
+
+// Describe names what a value of level l is, e.g. "API token".
+func (l Level) Describe() string {
+	switch l {
+	case Critical:
+		return "private key"
+	case High:
+		return "API token"
+	case Medium:
+		return "high-entropy string"
+	}
+	return "plain config"
+}
+
+// Parse returns the level named s.
+func Parse(s string) (Level, error) {
+	for i, name := range Names {
+		if strings.EqualFold(s, name) {
+			return Low + Level(i), nil
+		}
+	}
+	return 0, fmt.Errorf("%q is not one of %s", s, strings.Join(Names, ", "))
+}
+
+// Finding is one plaintext variable and its severity.
+type Finding struct {
+	Key   string
+	Level Level
+	// Reason says what gave the level away, e.g. "GitHub token".
+	Reason string
+	// Line is where a config file holds the value (see ScanConfig); 0 for
+	// an env file's variables.
+	Line int
+}
+
+// privateKeyValue matches the private keys a value can hold: PEM and
+// OpenSSH blocks, age identities and dotenvx private keys.
+var privateKeyValue = regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY( BLOCK)?-----|AGE-SECRET-KEY-1[0-9A-Z]{20,}`)
+
+// tokens are the API token formats providers document, by their prefixes.
+var tokens = []struct {
+	name string
+	re   *regexp.Regexp
+}{
+	{"AWS access key", regexp.MustCompile(`^(AKIA|ASIA)[0-9A-Z]{16}$`)},
+	{"GitHub token", regexp.MustCompile(`^(gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{40,})$`)},
+	{"GitLab token", regexp.MustCompile(`^glpat-[A-Za-z0-9_-]{20,}$`)},
+	{"Slack token", regexp.MustCompile(`^xox[abposr]-[A-Za-z0-9-]{10,}$`)},
+	{"Stripe key", regexp.MustCompile(`^[sr]k_live_[A-Za-z0-9]{16,}$`)},
+	{"Google API key", regexp.MustCompile(`^AIza[0-9A-Za-z_-]{35}$`)},
+	{"npm token", regexp.MustCompile(`^npm_[A-Za-z0-9]{36}$`)},
+	{"SendGrid key", regexp.MustCompile(`^SG\.[A-Za-z0-9_-]{16,}\.[A-Za-z0-9_-]{16,}$`)},
+	{"API key", regexp.MustCompile(`^sk-[A-Za-z0-9_-]{20,}$`)},
+	{"JSON web token", regexp.MustCompile(`^eyJ[A-Za-z0-9_-]{8,}\.eyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]+$`)},
+	{"password in a URL", regexp.MustCompile(`^[a-z][a-z0-9+.-]*://[^/:@\s]+:[^/@\s]+@`)},
+}
+
+// credentialName matches variable names that say they hold a credential.
+var credentialName = regexp.MustCompile(`(?i)(PASSWORD|PASSWD|SECRET|TOKEN|API_?KEY|ACCESS_KEY|CREDENTIAL)`)
+
+// tokenShape is the alphabet of keys and tokens: base64, URL-safe base64
+// and hex.
+var tokenShape = regexp.MustCompile(`^[A-Za-z0-9+/=_-]+$`)
+
+// Classify returns the severity of the plaintext value of variable key.
+func Classify(key, value string) Finding {
+	return classify(key, value, key+"="+value, "")
+}
+
+// classify is Classify for the value on line in the file at path ("" when
+// unknown), which the gitleaks rules (see SetRules) match against.
+func classify(key, value, line, path string) Finding {
+	f := Finding{Key: key}
+	switch {
+	case privateKeyValue.MatchString(value):
+		f.Level, f.Reason = Critical, "private key"
+		return f
+	case strings.Contains(strings.ToUpper(key), "PRIVATE_KEY") && len(value) >= 32:
+		f.Level, f.Reason = Critical, "named as a private key"
+		return f
+	}
+	if r, ok := matchRules(line, path); ok {
+		r.Key = key
+		return r
+	}
+	for _, t := range tokens {
+		if t.re.MatchString(value) {
+			f.Level, f.Reason = High, t.name
+			return f
+		}
+	}
+	switch {
+	case credentialName.MatchString(key) && len(value) >= 8 && !plainScalar(value):
+		f.Level, f.Reason = High, "named as a credential"
+	case highEntropy(value):
+		f.Level, f.Reason = Medium, "high-entropy string"
+	default:
+		f.Level, f.Reason = Low, "plain config"
+	}
+	return f
+}
+
+// plainScalar reports whether value is a boolean or a number, which no
+// credential is.
+func plainScalar(value string) bool {
+	switch strings.ToLower(value) {
+	case "true", "false", "yes", "no", "on", "off":
+		return true
+	}
+	return strings.Trim(value, "0123456789.-") == ""
+}
+
+// highEntropy reports whether value looks random: token-shaped, mixing
+// letters and digits, and carrying at least 3.5 bits per character over 16
+// characters or more (3 for hex, whose alphabet is smaller).
+func highEntropy(value string) bool {
+	if len(value) < 16 || !tokenShape.MatchString(value) ||
+		!strings.ContainsAny(value, "0123456789") || strings.Trim(value, "0123456789") == "" {
+		return false
+	}
+	bits := 3.5
+	if strings.Trim(strings.ToLower(value), "0123456789abcdef") == "" {
+		bits = 3.0
+	}
+	return entropy(value) >= bits
+}
+
+// entropy is value's Shannon entropy in bits per byte.
+func entropy(value string) float64 {
+	var counts [256]int
+	for i := 0; i < len(value); i++ {
+		counts[value[i]]++
+	}
+	var h float64
+	for _, c := range counts {
+		if c > 0 {
+			p := float64(c) / float64(len(value))
+			h -= p * math.Log2(p)
+		}
+	}
+	return h
+}
+
+// File classifies the plaintext values of the env file at path and returns
+// the most severe finding; a plaintext file is Low at least, even with no
+// values. findings holds every plaintext variable, most severe first.
+func File(path string) (worst Finding, findings []Finding, err error) {
+	worst = Finding{Level: Low, Reason: "plain config"}
+	values, err := encrypt.PlaintextValues(path)
+	if err != nil {
+		return worst, nil, err
+	}
+	for _, v := range values {
+		f := classify(v.Key, v.Value, v.Key+"="+v.Value, path)
+		if f.Level > worst.Level || worst.Key == "" {
+			worst = f
+		}
+		findings = append(findings, f)
+	}
+	sort.SliceStable(findings, func(i, j int) bool { return findings[i].Level > findings[j].Level })
+	return worst, findings, nil
+}
@@ -0,0 +1,137 @@
+package systemlog
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// journaldSocket is where journald accepts its native protocol; a seam for
+// tests.
+var journaldSocket = "/run/systemd/journal/socket"
+
+// identifier is the SYSLOG_IDENTIFIER of every entry, so
+// `journalctl -t envdrift-agent` finds them outside the service unit too.
+const identifier = "envdrift-agent"
+
+// Syslog priorities journald understands.
+const (
+	priErr     = 3
+	priWarning = 4
+	priInfo    = 6
+)
+
+// Journald writes entries to the systemd journal over its native protocol,
+// one datagram per entry. It is an io.Writer for the standard logger: each
+// Write is one MESSAGE.
+type Journald struct {
+	conn net.Conn
+}
+
+// journald is the journal Emit and Record also write to, once set by
+// UseJournald.
+var (
+	journaldMu sync.RWMutex
+	journald   *Journald
+)
+
+// OpenJournald connects to the journal socket. It fails where systemd is not
+// running, e.g. in most containers.
+func OpenJournald() (*Journald, error) {
+	conn, err := net.Dial("unixgram", journaldSocket)
+	if err != nil {
+		return nil, err
+	}
+	return &Journald{conn: conn}, nil
+}
+
+// UseJournald makes Emit and Record write to j as well; nil stops them.
+func UseJournald(j *Journald) {
+	journaldMu.Lock()
+	journald = j
+	journaldMu.Unlock()
+}
+
+// Write sends p, less its trailing newline, as one informational entry.
+func (j *Journald) Write(p []byte) (int, error) {
+	msg := strings.TrimSuffix(string(p), "\n")
+	if err := j.Send(priInfo, msg, nil); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Send writes one entry with message, priority and the given fields. Field
+// names must be upper case letters, digits and underscores, as journald
+// requires.
+func (j *Journald) Send(priority int, message string, fields map[string]string) error {
+	var buf bytes.Buffer
+	writeField(&buf, "MESSAGE", message)
+	writeField(&buf, "PRIORITY", strconv.Itoa(priority))
+	writeField(&buf, "SYSLOG_IDENTIFIER", identifier)
+	for _, name := range sortedKeys(fields) {
+		if fields[name] != "" {
+			writeField(&buf, name, fields[name])
+		}
+	}
+	_, err := j.conn.Write(buf.Bytes())
+	return err
+}
+
+// Close closes the connection.
+func (j *Journald) Close() error {
+	return j.conn.Close()
+}
+
+// writeField appends NAME=value, or for a value spanning lines the
+// protocol's length-prefixed form.
+func writeField(buf *bytes.Buffer, name, value string) {
+	if !strings.Contains(value, "\n") {
+		buf.WriteString(name + "=" + value + "\n")
+		return
+	}
+	buf.WriteString(name + "\n")
+	_ = binary.Write(buf, binary.LittleEndian, uint64(len(value)))
+	buf.WriteString(value + "\n")
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// priority maps a Level to its syslog priority.
+func (l Level) priority() int {
+	switch l {
+	case Error:
+		return priErr
+	case Warning:
+		return priWarning
+	}
+	return priInfo
+}
+
+// toJournald writes ev to the journal, if one is in use, with EVENT=, FILE=,
+// PROJECT= and RESULT= fields.
+func toJournald(ev Event) error {
+	journaldMu.RLock()
+	j := journald
+	journaldMu.RUnlock()
+	if j == nil {
+		return nil
+	}
+	return j.Send(ev.Level.priority(), ev.text(), map[string]string{
+		"EVENT":   ev.Kind,
+		"FILE":    ev.Path,
+		"PROJECT": ev.Project,
+		"RESULT":  ev.Result,
+	})
+}
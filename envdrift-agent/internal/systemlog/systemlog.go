@@ -0,0 +1,119 @@
+// Package systemlog sends the agent's significant events — the guardian
+// starting and stopping, encryption failures, rejected managed policies — to
+// the operating system's log, so enterprise log collection picks them up
+// without tailing the agent's own log file.
+//
+// On Windows events go to the Windows Event Log under the EnvDrift source,
+// and on macOS to the unified logging system under the com.envdrift.agent
+// subsystem, where Console.app and `log stream` show them. On Linux, with
+// guardian.journald set, they go to the systemd journal as structured
+// entries, together with the routine per-file decisions (Record) and the
+// agent's log lines. Delivery is best effort: a failure is logged once and
+// never stops the agent.
+package systemlog
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+)
+
+// Source is the name events are logged under in the Windows Event Log.
+const Source = "EnvDrift"
+
+// Subsystem is the macOS unified logging subsystem of the agent's events.
+const Subsystem = "com.envdrift.agent"
+
+// Level is an event's severity.
+type Level int
+
+const (
+	Info Level = iota
+	Warning
+	Error
+)
+
+// Kinds of event. Each has a fixed Windows event ID (see eventID).
+const (
+	KindStart           = "service-start"
+	KindStop            = "service-stop"
+	KindEncryptFailed   = "encrypt-failed"
+	KindPolicyViolation = "policy-violation"
+)
+
+// Event is one significant event.
+type Event struct {
+	Level Level
+	Kind  string
+	// Path is the env file concerned, if any, and Project its project.
+	Path    string
+	Project string
+	// Result is the outcome of a file decision (e.g. "success", "deferred").
+	Result  string
+	Message string
+}
+
+// eventID is the Windows event ID of kind; EventCreate-registered sources
+// accept 1-1000.
+func eventID(kind string) uint32 {
+	switch kind {
+	case KindStart:
+		return 100
+	case KindStop:
+		return 101
+	case KindEncryptFailed:
+		return 200
+	case KindPolicyViolation:
+		return 300
+	}
+	return 1
+}
+
+// category is the macOS unified logging category of kind.
+func category(kind string) string {
+	switch kind {
+	case KindStart, KindStop:
+		return "service"
+	case KindPolicyViolation:
+		return "policy"
+	}
+	return "encryption"
+}
+
+// text renders ev as one line for backends without structured fields.
+func (ev Event) text() string {
+	if ev.Path == "" {
+		return ev.Message
+	}
+	return fmt.Sprintf("%s: %s", ev.Path, ev.Message)
+}
+
+// write is the platform backend; a seam for tests.
+var write = platformWrite
+
+// warnOnce keeps a broken system log from adding a line to every event.
+var warnOnce sync.Once
+
+// Emit sends a significant event to the system log and, when in use, the
+// systemd journal; an event about a file is left out of the latter, which
+// has it from Record already.
+func Emit(ev Event) {
+	err := write(ev)
+	if ev.Path == "" {
+		err = errors.Join(err, toJournald(ev))
+	}
+	report(err)
+}
+
+// Record sends a routine event, such as one file decision, to the systemd
+// journal when in use; it is too frequent for the Windows Event Log.
+func Record(ev Event) {
+	report(toJournald(ev))
+}
+
+func report(err error) {
+	if err != nil {
+		warnOnce.Do(func() { log.Printf("Writing to the system log: %v", err) })
+	}
+}
@@ -0,0 +1,61 @@
+//go:build darwin && cgo
+
+package systemlog
+
+/*
+#include <os/log.h>
+#include <stdlib.h>
+
+static os_log_t envdrift_log_create(const char *subsystem, const char *category) {
+	return os_log_create(subsystem, category);
+}
+
+// os_log_with_type wants a literal format, so it cannot be called from Go.
+// level is a Level: 2 is Error.
+static void envdrift_log(os_log_t log, int level, const char *msg) {
+	os_log_type_t type = OS_LOG_TYPE_DEFAULT;
+	if (level == 2) {
+		type = OS_LOG_TYPE_ERROR;
+	}
+	os_log_with_type(log, type, "%{public}s", msg);
+}
+*/
+import "C"
+
+import (
+	"sync"
+	"unsafe"
+)
+
+var (
+	logsMu sync.Mutex
+	logs   = map[string]C.os_log_t{}
+)
+
+// platformWrite sends ev to the unified logging system under Subsystem, in
+// the category of its kind. Errors are logged at the error type, the rest at
+// the default type, which macOS keeps in the persistent store (info would
+// only live in memory).
+func platformWrite(ev Event) error {
+	category := category(ev.Kind)
+	logsMu.Lock()
+	l, ok := logs[category]
+	if !ok {
+		sub, cat := C.CString(Subsystem), C.CString(category)
+		l = C.envdrift_log_create(sub, cat)
+		C.free(unsafe.Pointer(sub))
+		C.free(unsafe.Pointer(cat))
+		logs[category] = l
+	}
+	logsMu.Unlock()
+
+	msg := C.CString(ev.text())
+	defer C.free(unsafe.Pointer(msg))
+	C.envdrift_log(l, C.int(ev.Level), msg)
+	return nil
+}
+
+// Register is only needed on Windows.
+func Register() error {
+	return nil
+}
@@ -0,0 +1,26 @@
+//go:build darwin && !cgo
+
+package systemlog
+
+import (
+	"fmt"
+	"os/exec"
+)
+
+// platformWrite hands ev to logger(1) when the agent is built without cgo,
+// which os_log needs. The entry reaches the unified log without the
+// subsystem and category; filter on the process (logger) and the
+// envdrift-agent tag instead.
+func platformWrite(ev Event) error {
+	priority := "user.notice"
+	if ev.Level == Error {
+		priority = "user.err"
+	}
+	msg := fmt.Sprintf("[%s] %s", category(ev.Kind), ev.text())
+	return exec.Command("logger", "-t", identifier, "-p", priority, msg).Run()
+}
+
+// Register is only needed on Windows.
+func Register() error {
+	return nil
+}
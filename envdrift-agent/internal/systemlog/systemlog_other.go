@@ -0,0 +1,13 @@
+//go:build !windows && !darwin
+
+package systemlog
+
+// platformWrite has no system log to write to on this platform.
+func platformWrite(ev Event) error {
+	return nil
+}
+
+// Register is only needed on Windows.
+func Register() error {
+	return nil
+}
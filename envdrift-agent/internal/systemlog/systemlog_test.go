@@ -0,0 +1,95 @@
+package systemlog
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"net"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestEmit(t *testing.T) {
+	origWrite, origOut, origFlags := write, log.Writer(), log.Flags()
+	var logged bytes.Buffer
+	log.SetOutput(&logged)
+	log.SetFlags(0)
+	t.Cleanup(func() {
+		write, warnOnce = origWrite, sync.Once{}
+		log.SetOutput(origOut)
+		log.SetFlags(origFlags)
+	})
+
+	var got []Event
+	write = func(ev Event) error { got = append(got, ev); return nil }
+	ev := Event{Level: Error, Kind: KindEncryptFailed, Path: "/src/api/.env", Message: "encryption failed: exit status 1"}
+	Emit(ev)
+	if len(got) != 1 || got[0] != ev {
+		t.Fatalf("written = %+v", got)
+	}
+	if ev.text() != "/src/api/.env: encryption failed: exit status 1" {
+		t.Errorf("text = %q", ev.text())
+	}
+	if eventID(KindEncryptFailed) == eventID(KindStart) || eventID(KindPolicyViolation) > 1000 {
+		t.Error("event IDs must be distinct and within EventCreate's 1-1000")
+	}
+	if category(KindStop) != "service" || category(KindEncryptFailed) != "encryption" || category(KindPolicyViolation) != "policy" {
+		t.Error("unexpected os_log categories")
+	}
+
+	// A broken system log is reported once, not on every event.
+	write = func(Event) error { return errors.New("access denied") }
+	Emit(ev)
+	Emit(ev)
+	if n := strings.Count(logged.String(), "access denied"); n != 1 {
+		t.Errorf("logged the failure %d times:\n%s", n, logged.String())
+	}
+}
+
+func TestJournald(t *testing.T) {
+	sock := filepath.Join(t.TempDir(), "journal.sock")
+	server, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: sock, Net: "unixgram"})
+	if err != nil {
+		t.Skipf("unix datagram sockets unavailable: %v", err)
+	}
+	defer server.Close()
+	origSocket := journaldSocket
+	journaldSocket = sock
+	t.Cleanup(func() { journaldSocket = origSocket; UseJournald(nil) })
+
+	j, err := OpenJournald()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer j.Close()
+	UseJournald(j)
+	read := func() string {
+		t.Helper()
+		buf := make([]byte, 4096)
+		_ = server.SetReadDeadline(time.Now().Add(2 * time.Second))
+		n, err := server.Read(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(buf[:n])
+	}
+
+	Record(Event{Level: Warning, Kind: "deferred", Path: "/src/api/.env", Project: "/src/api",
+		Result: "deferred", Message: "deferred: open in another process"})
+	want := "MESSAGE=/src/api/.env: deferred: open in another process\nPRIORITY=4\nSYSLOG_IDENTIFIER=envdrift-agent\n" +
+		"EVENT=deferred\nFILE=/src/api/.env\nPROJECT=/src/api\nRESULT=deferred\n"
+	if got := read(); got != want {
+		t.Errorf("entry =\n%q\nwant\n%q", got, want)
+	}
+
+	// A value spanning lines uses the length-prefixed form.
+	if _, err := j.Write([]byte("panic:\ngoroutine 1\n")); err != nil {
+		t.Fatal(err)
+	}
+	if got := read(); !strings.HasPrefix(got, "MESSAGE\n\x12\x00\x00\x00\x00\x00\x00\x00panic:\ngoroutine 1\nPRIORITY=6\n") {
+		t.Errorf("multi-line entry = %q", got)
+	}
+}
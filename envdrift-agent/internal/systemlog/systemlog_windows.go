@@ -0,0 +1,45 @@
+//go:build windows
+
+package systemlog
+
+import (
+	"strings"
+	"sync"
+
+	"golang.org/x/sys/windows/svc/eventlog"
+)
+
+var (
+	openOnce sync.Once
+	elog     *eventlog.Log
+	openErr  error
+)
+
+// platformWrite reports ev to the Application event log under Source. An
+// unregistered source still logs there; Event Viewer then only lacks the
+// message template around the text.
+func platformWrite(ev Event) error {
+	openOnce.Do(func() { elog, openErr = eventlog.Open(Source) })
+	if openErr != nil {
+		return openErr
+	}
+	id := eventID(ev.Kind)
+	switch ev.Level {
+	case Error:
+		return elog.Error(id, ev.text())
+	case Warning:
+		return elog.Warning(id, ev.text())
+	}
+	return elog.Info(id, ev.text())
+}
+
+// Register registers Source with the Application event log, using
+// EventCreate.exe's message file. It needs administrator rights; an already
+// registered source is not an error.
+func Register() error {
+	err := eventlog.InstallAsEventCreate(Source, eventlog.Error|eventlog.Warning|eventlog.Info)
+	if err != nil && strings.Contains(err.Error(), "already exists") {
+		return nil
+	}
+	return err
+}
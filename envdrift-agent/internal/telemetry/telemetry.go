@@ -0,0 +1,214 @@
+// Package telemetry keeps opt-in usage counts in telemetry.json in the state
+// directory.
+//
+// Counts are buffered locally per day and only leave the machine when an
+// endpoint is configured; `envdrift-agent telemetry show` prints exactly what
+// would be sent. Nothing here records a path, a variable name or a value.
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jainal09/envdrift-agent/internal/paths"
+)
+
+// Counter names.
+const (
+	// Encryptions is a file the guardian encrypted after it went idle.
+	Encryptions = "encryptions"
+)
+
+const (
+	dayLayout = "2006-01-02"
+	// retainDays bounds the local buffer when nothing is ever sent.
+	retainDays = 30
+)
+
+// httpClient posts reports; tests swap it for a stub server's client.
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+// state is the telemetry.json document.
+type state struct {
+	InstallID string `json:"install_id"`
+	// Days maps a local date (2006-01-02) to its counters.
+	Days map[string]map[string]int `json:"days,omitempty"`
+	// SentThrough is the last date already delivered to the endpoint.
+	SentThrough string `json:"sent_through,omitempty"`
+}
+
+// Report is the payload `telemetry show` prints and Send posts.
+type Report struct {
+	InstallID string                    `json:"install_id"`
+	Version   string                    `json:"version"`
+	OS        string                    `json:"os"`
+	Arch      string                    `json:"arch"`
+	Days      map[string]map[string]int `json:"days"`
+}
+
+// Store is the local telemetry buffer at Path.
+type Store struct {
+	Path string
+	mu   sync.Mutex
+}
+
+// DefaultPath returns telemetry.json in the state directory.
+func DefaultPath() string {
+	return filepath.Join(paths.StateDir(), "telemetry.json")
+}
+
+// Count adds one to counter name for the day of now, dropping days older
+// than the retention window.
+func (s *Store) Count(name string, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st, err := s.load()
+	if err != nil {
+		return err
+	}
+	if st.InstallID == "" {
+		// A random ID, created with the first count, only groups one
+		// install's reports; it is derived from nothing on the machine.
+		id := make([]byte, 16)
+		if _, err := rand.Read(id); err != nil {
+			return err
+		}
+		st.InstallID = hex.EncodeToString(id)
+	}
+	day := now.Format(dayLayout)
+	if st.Days[day] == nil {
+		st.Days[day] = make(map[string]int)
+	}
+	st.Days[day][name]++
+
+	oldest := now.AddDate(0, 0, -retainDays).Format(dayLayout)
+	for d := range st.Days {
+		if d < oldest {
+			delete(st.Days, d)
+		}
+	}
+	return s.save(st)
+}
+
+// Unsent returns the counts not yet sent for days before the day of before;
+// the current day is still being counted. An empty Days means nothing is due.
+func (s *Store) Unsent(version string, before time.Time) (*Report, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	r := &Report{
+		InstallID: st.InstallID,
+		Version:   version,
+		OS:        runtime.GOOS,
+		Arch:      runtime.GOARCH,
+		Days:      make(map[string]map[string]int),
+	}
+	cutoff := before.Format(dayLayout)
+	for d, counts := range st.Days {
+		if d < cutoff && d > st.SentThrough {
+			r.Days[d] = counts
+		}
+	}
+	return r, nil
+}
+
+// MarkSent records r's days as delivered and drops them from the buffer.
+func (s *Store) MarkSent(r *Report) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st, err := s.load()
+	if err != nil {
+		return err
+	}
+	for _, d := range r.days() {
+		delete(st.Days, d)
+		if d > st.SentThrough {
+			st.SentThrough = d
+		}
+	}
+	return s.save(st)
+}
+
+// Send posts r as JSON to endpoint.
+func Send(ctx context.Context, endpoint string, r *Report) error {
+	body, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("telemetry endpoint returned %s", resp.Status)
+	}
+	return nil
+}
+
+// days returns the report's dates in order.
+func (r *Report) days() []string {
+	days := make([]string, 0, len(r.Days))
+	for d := range r.Days {
+		days = append(days, d)
+	}
+	sort.Strings(days)
+	return days
+}
+
+// load reads the buffer; a missing file is an empty one. Callers hold s.mu.
+func (s *Store) load() (*state, error) {
+	st := &state{}
+	data, err := os.ReadFile(s.Path)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, st); err != nil {
+			return nil, fmt.Errorf("parse %s: %w", s.Path, err)
+		}
+	case !os.IsNotExist(err):
+		return nil, err
+	}
+	if st.Days == nil {
+		st.Days = make(map[string]map[string]int)
+	}
+	return st, nil
+}
+
+// save writes st through a temp file so a crash never leaves a torn buffer.
+// Callers hold s.mu.
+func (s *Store) save(st *state) error {
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.Path), 0o755); err != nil {
+		return err
+	}
+	tmp := s.Path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.Path)
+}
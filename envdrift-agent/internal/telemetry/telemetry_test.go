@@ -0,0 +1,102 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCountAndUnsent(t *testing.T) {
+	s := &Store{Path: filepath.Join(t.TempDir(), "telemetry.json")}
+	day1 := time.Date(2026, 3, 1, 10, 0, 0, 0, time.Local)
+	day2 := day1.AddDate(0, 0, 1)
+
+	r, err := s.Unsent("1.0", day2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Days) != 0 || r.InstallID != "" {
+		t.Errorf("empty store reported %+v", r)
+	}
+
+	for _, now := range []time.Time{day1, day1, day2} {
+		if err := s.Count(Encryptions, now); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r, err = s.Unsent("1.0", day2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.InstallID == "" || r.Version != "1.0" {
+		t.Errorf("report header = %+v", r)
+	}
+	if len(r.Days) != 1 || r.Days["2026-03-01"][Encryptions] != 2 {
+		t.Errorf("unsent days = %v; want only the completed day with 2 encryptions", r.Days)
+	}
+
+	if err := s.MarkSent(r); err != nil {
+		t.Fatal(err)
+	}
+	r, err = s.Unsent("1.0", day2.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Days) != 1 || r.Days["2026-03-02"][Encryptions] != 1 {
+		t.Errorf("after MarkSent unsent days = %v; want only 2026-03-02", r.Days)
+	}
+}
+
+func TestCountDropsOldDays(t *testing.T) {
+	s := &Store{Path: filepath.Join(t.TempDir(), "telemetry.json")}
+	old := time.Date(2026, 1, 1, 12, 0, 0, 0, time.Local)
+	if err := s.Count(Encryptions, old); err != nil {
+		t.Fatal(err)
+	}
+	now := old.AddDate(0, 0, retainDays+1)
+	if err := s.Count(Encryptions, now); err != nil {
+		t.Fatal(err)
+	}
+	r, err := s.Unsent("dev", now.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := r.Days["2026-01-01"]; ok || len(r.Days) != 1 {
+		t.Errorf("days = %v; want the old day dropped", r.Days)
+	}
+}
+
+func TestSend(t *testing.T) {
+	var got Report
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("request = %s %s", r.Method, r.Header.Get("Content-Type"))
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer srv.Close()
+	orig := httpClient
+	httpClient = srv.Client()
+	t.Cleanup(func() { httpClient = orig })
+
+	r := &Report{InstallID: "abc", Version: "1.0", Days: map[string]map[string]int{"2026-03-01": {Encryptions: 3}}}
+	if err := Send(context.Background(), srv.URL, r); err != nil {
+		t.Fatal(err)
+	}
+	if got.InstallID != "abc" || got.Days["2026-03-01"][Encryptions] != 3 {
+		t.Errorf("server received %+v", got)
+	}
+
+	srv.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	if err := Send(context.Background(), srv.URL, r); err == nil {
+		t.Error("Send succeeded against a 503")
+	}
+}
@@ -0,0 +1,194 @@
+// Package ui formats command output for where it goes: color, table headers
+// and progress spinners on a terminal, plain aligned text for pipes and
+// scripts. Color is also off with NO_COLOR (https://no-color.org), TERM=dumb
+// or plain output mode (see output.Plain).
+package ui
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jainal09/envdrift-agent/internal/output"
+)
+
+// Color is an SGR color or style.
+type Color string
+
+// Colors Paint understands.
+const (
+	Bold   Color = "1"
+	Dim    Color = "2"
+	Red    Color = "31"
+	Green  Color = "32"
+	Yellow Color = "33"
+)
+
+// spinnerInterval is how often a spinner advances.
+const spinnerInterval = 100 * time.Millisecond
+
+// Seams for tests: terminal detection and the environment.
+var (
+	isTerminal = func(f *os.File) bool {
+		fi, err := f.Stat()
+		return err == nil && fi.Mode()&os.ModeCharDevice != 0
+	}
+	getenv = os.Getenv
+)
+
+// UI writes to one output.
+type UI struct {
+	w     io.Writer
+	tty   bool
+	color bool
+}
+
+// New returns a UI for w, detecting whether w is a terminal.
+func New(w io.Writer) *UI {
+	f, ok := output.Raw(w).(*os.File)
+	tty := ok && isTerminal(f)
+	return &UI{
+		w:     w,
+		tty:   tty,
+		color: tty && getenv("NO_COLOR") == "" && getenv("TERM") != "dumb" && !output.Plain(),
+	}
+}
+
+// TTY reports whether the output is a terminal.
+func (u *UI) TTY() bool {
+	return u.tty
+}
+
+// Paint returns s in color c when color is on, s unchanged otherwise.
+func (u *UI) Paint(c Color, s string) string {
+	if !u.color {
+		return s
+	}
+	return "\x1b[" + string(c) + "m" + s + "\x1b[0m"
+}
+
+// Table writes rows in aligned columns. header is written first on a
+// terminal only, so piped output is just the rows. Cells must not be
+// painted: tabwriter would count the escapes as width.
+func (u *UI) Table(header []string, rows [][]string) {
+	tw := tabwriter.NewWriter(u.w, 0, 0, 2, ' ', 0)
+	if u.tty && len(header) > 0 {
+		fmt.Fprintln(tw, strings.Join(header, "\t"))
+	}
+	for _, r := range rows {
+		fmt.Fprintln(tw, strings.Join(r, "\t"))
+	}
+	_ = tw.Flush()
+}
+
+// Spinner shows msg with an animated spinner until Stop. It is a no-op off
+// a terminal and in plain output mode, where redrawn lines are noise.
+type Spinner struct {
+	stop chan struct{}
+	done sync.WaitGroup
+}
+
+// Spinner starts a spinner. Nothing else may write to the UI's output until
+// Stop returns.
+func (u *UI) Spinner(msg string) *Spinner {
+	s := &Spinner{}
+	if !u.tty || output.Plain() {
+		return s
+	}
+	s.stop = make(chan struct{})
+	s.done.Add(1)
+	go func() {
+		defer s.done.Done()
+		frames := []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+		ticker := time.NewTicker(spinnerInterval)
+		defer ticker.Stop()
+		for i := 0; ; i++ {
+			fmt.Fprintf(u.w, "\r%s %s", frames[i%len(frames)], msg)
+			select {
+			case <-s.stop:
+				// Clear the line for the output that follows.
+				fmt.Fprintf(u.w, "\r\x1b[K")
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return s
+}
+
+// Stop removes the spinner.
+func (s *Spinner) Stop() {
+	if s.stop == nil {
+		return
+	}
+	close(s.stop)
+	s.done.Wait()
+	s.stop = nil
+}
+
+// progressLogInterval spaces the progress lines written off a terminal.
+const progressLogInterval = 5 * time.Second
+
+// barWidth is the number of cells in a progress bar.
+const barWidth = 24
+
+// now is a seam for tests.
+var now = time.Now
+
+// Bar reports the progress of a bulk operation: a bar redrawn in place with
+// counts and an ETA on a terminal, a line at most every
+// progressLogInterval otherwise (pipes, log files, plain output mode).
+type Bar struct {
+	u      *UI
+	label  string
+	total  int
+	redraw bool
+	start  time.Time
+	logged time.Time
+}
+
+// Bar starts progress reporting for total items. Nothing else may write to
+// the UI's output until Finish.
+func (u *UI) Bar(label string, total int) *Bar {
+	t := now()
+	return &Bar{u: u, label: label, total: total, redraw: u.tty && !output.Plain(), start: t, logged: t}
+}
+
+// Set reports that done of the items are finished.
+func (b *Bar) Set(done int) {
+	t := now()
+	if b.redraw {
+		filled := barWidth
+		if b.total > 0 {
+			filled = barWidth * done / b.total
+		}
+		fmt.Fprintf(b.u.w, "\r%s [%s%s] %d/%d  %s\x1b[K", b.label,
+			strings.Repeat("#", filled), strings.Repeat("-", barWidth-filled), done, b.total, b.eta(done, t))
+		return
+	}
+	if t.Sub(b.logged) < progressLogInterval || done >= b.total {
+		return
+	}
+	b.logged = t
+	fmt.Fprintf(b.u.w, "%s: %d/%d (%d%%), %s\n", b.label, done, b.total, 100*done/b.total, b.eta(done, t))
+}
+
+// Finish removes a terminal bar so a summary can follow.
+func (b *Bar) Finish() {
+	if b.redraw {
+		fmt.Fprint(b.u.w, "\r\x1b[K")
+	}
+}
+
+// eta estimates the time left from the average pace so far.
+func (b *Bar) eta(done int, t time.Time) string {
+	if done == 0 || done >= b.total {
+		return "ETA --"
+	}
+	left := t.Sub(b.start) / time.Duration(done) * time.Duration(b.total-done)
+	return "ETA " + left.Round(time.Second).String()
+}
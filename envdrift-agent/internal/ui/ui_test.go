@@ -0,0 +1,128 @@
+package ui
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeTerminal returns a file standing in for a terminal and a function
+// reading back what was written to it.
+func fakeTerminal(t *testing.T, env map[string]string) (*os.File, func() string) {
+	t.Helper()
+	f, err := os.Create(filepath.Join(t.TempDir(), "tty"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	origTerm, origEnv := isTerminal, getenv
+	isTerminal = func(*os.File) bool { return true }
+	getenv = func(name string) string { return env[name] }
+	t.Cleanup(func() {
+		isTerminal, getenv = origTerm, origEnv
+		_ = f.Close()
+	})
+	return f, func() string {
+		data, err := os.ReadFile(f.Name())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+}
+
+func TestPaint(t *testing.T) {
+	f, _ := fakeTerminal(t, nil)
+	if got := New(f).Paint(Green, "true"); got != "\x1b[32mtrue\x1b[0m" {
+		t.Errorf("terminal Paint = %q", got)
+	}
+	if got := New(&bytes.Buffer{}).Paint(Green, "true"); got != "true" {
+		t.Errorf("pipe Paint = %q; want no color", got)
+	}
+
+	f, _ = fakeTerminal(t, map[string]string{"NO_COLOR": "1"})
+	if got := New(f).Paint(Green, "true"); got != "true" {
+		t.Errorf("NO_COLOR Paint = %q; want no color", got)
+	}
+}
+
+func TestTable(t *testing.T) {
+	rows := [][]string{{"api", "production", "plaintext"}, {"payments-service", "staging", "encrypted"}}
+
+	var buf bytes.Buffer
+	New(&buf).Table([]string{"SERVICE", "ENV", "STATE"}, rows)
+	want := "api               production  plaintext\npayments-service  staging     encrypted\n"
+	if buf.String() != want {
+		t.Errorf("piped table = %q; want %q", buf.String(), want)
+	}
+
+	f, read := fakeTerminal(t, nil)
+	New(f).Table([]string{"SERVICE", "ENV", "STATE"}, rows)
+	if got := read(); !strings.HasPrefix(got, "SERVICE           ENV         STATE\n") {
+		t.Errorf("terminal table = %q; want a header", got)
+	}
+}
+
+func TestSpinner(t *testing.T) {
+	var buf bytes.Buffer
+	New(&buf).Spinner("Reading...").Stop()
+	if buf.Len() != 0 {
+		t.Errorf("spinner wrote %q to a pipe", buf.String())
+	}
+
+	f, read := fakeTerminal(t, nil)
+	s := New(f).Spinner("Reading...")
+	s.Stop()
+	s.Stop()
+	if got := read(); !strings.Contains(got, "Reading...") || !strings.HasSuffix(got, "\r\x1b[K") {
+		t.Errorf("terminal spinner = %q; want the message, then the line cleared", got)
+	}
+}
+
+// fakeClock replaces now with a clock advanced by the returned function.
+func fakeClock(t *testing.T) func(time.Duration) {
+	t.Helper()
+	orig := now
+	cur := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	now = func() time.Time { return cur }
+	t.Cleanup(func() { now = orig })
+	return func(d time.Duration) { cur = cur.Add(d) }
+}
+
+func TestBar(t *testing.T) {
+	t.Run("log lines off a terminal", func(t *testing.T) {
+		advance := fakeClock(t)
+		var buf bytes.Buffer
+		bar := New(&buf).Bar("Encrypting", 10)
+		advance(time.Second)
+		bar.Set(1)
+		advance(4 * time.Second)
+		bar.Set(5)
+		advance(time.Second)
+		bar.Set(6)
+		advance(10 * time.Second)
+		bar.Set(10)
+		bar.Finish()
+		if got, want := buf.String(), "Encrypting: 5/10 (50%), ETA 5s\n"; got != want {
+			t.Fatalf("output = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("redrawn on a terminal", func(t *testing.T) {
+		advance := fakeClock(t)
+		f, read := fakeTerminal(t, nil)
+		bar := New(f).Bar("Encrypting", 4)
+		advance(2 * time.Second)
+		bar.Set(1)
+		bar.Finish()
+		got := read()
+		if !strings.Contains(got, "\rEncrypting [######------------------] 1/4  ETA 6s") {
+			t.Fatalf("bar not drawn: %q", got)
+		}
+		if !strings.HasSuffix(got, "\r\x1b[K") {
+			t.Fatalf("bar not cleared: %q", got)
+		}
+	})
+}
@@ -0,0 +1,60 @@
+package update
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Install methods. A binary from a package manager must be updated through
+// it: overwriting it would break the manager's bookkeeping and be undone by
+// its next upgrade.
+const (
+	MethodManual = "manual"
+	MethodBrew   = "brew"
+	MethodScoop  = "scoop"
+	MethodDeb    = "deb"
+)
+
+// dpkgInfoDir is where dpkg lists each package's files; tests replace it.
+var dpkgInfoDir = "/var/lib/dpkg/info"
+
+// DetectMethod reports how the binary at exe was installed. built is the
+// method stamped in at build time (-X ...cmd.InstallMethod=brew), which
+// packagers set and which wins; otherwise the executable's location decides.
+func DetectMethod(built, exe string) string {
+	switch built {
+	case MethodBrew, MethodScoop, MethodDeb, MethodManual:
+		return built
+	}
+	// Either separator, so a Windows path classifies the same everywhere.
+	slashed := strings.ReplaceAll(exe, `\`, "/")
+	switch {
+	case strings.Contains(slashed, "/Cellar/") || strings.Contains(slashed, "/.linuxbrew/"):
+		return MethodBrew
+	case strings.Contains(strings.ToLower(slashed), "/scoop/apps/"):
+		return MethodScoop
+	}
+	if list, err := os.ReadFile(filepath.Join(dpkgInfoDir, "envdrift-agent.list")); err == nil {
+		for _, line := range strings.Split(string(list), "\n") {
+			if line == slashed {
+				return MethodDeb
+			}
+		}
+	}
+	return MethodManual
+}
+
+// UpgradeCommand is how to update a binary installed by method, or "" when
+// self-update is the way.
+func UpgradeCommand(method string) string {
+	switch method {
+	case MethodBrew:
+		return "brew upgrade envdrift-agent"
+	case MethodScoop:
+		return "scoop update envdrift-agent"
+	case MethodDeb:
+		return "sudo apt update && sudo apt install --only-upgrade envdrift-agent"
+	}
+	return ""
+}
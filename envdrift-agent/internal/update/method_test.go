@@ -0,0 +1,38 @@
+package update
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDetectMethod(t *testing.T) {
+	dir := t.TempDir()
+	orig := dpkgInfoDir
+	dpkgInfoDir = dir
+	t.Cleanup(func() { dpkgInfoDir = orig })
+	if err := os.WriteFile(filepath.Join(dir, "envdrift-agent.list"), []byte("/.\n/usr/bin\n/usr/bin/envdrift-agent\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		built, exe, want string
+	}{
+		{"", "/opt/homebrew/Cellar/envdrift-agent/1.1.4/bin/envdrift-agent", MethodBrew},
+		{"", "/home/linuxbrew/.linuxbrew/bin/envdrift-agent", MethodBrew},
+		{"", `C:\Users\me\scoop\apps\envdrift-agent\current\envdrift-agent.exe`, MethodScoop},
+		{"", "/usr/bin/envdrift-agent", MethodDeb},
+		{"", "/usr/local/bin/envdrift-agent", MethodManual},
+		{"brew", "/usr/local/bin/envdrift-agent", MethodBrew},
+		{"manual", "/opt/homebrew/Cellar/envdrift-agent/1.1.4/bin/envdrift-agent", MethodManual},
+		{"bogus", "/usr/local/bin/envdrift-agent", MethodManual},
+	}
+	for _, tt := range tests {
+		if got := DetectMethod(tt.built, filepath.FromSlash(tt.exe)); got != tt.want {
+			t.Errorf("DetectMethod(%q, %q) = %q; want %q", tt.built, tt.exe, got, tt.want)
+		}
+	}
+	if UpgradeCommand(MethodManual) != "" || UpgradeCommand(MethodBrew) == "" {
+		t.Error("UpgradeCommand: want a command for managed installs only")
+	}
+}
@@ -0,0 +1,244 @@
+// Package update finds agent releases on GitHub and replaces the running
+// binary with one, for `envdrift-agent self-update`.
+//
+// Releases are the repository's agent-v* tags; pre-releases (a version with a
+// "-", e.g. 1.3.0-beta.1) are only offered on the beta channel. Downloads are
+// verified against the release's checksums.txt before anything is replaced.
+package update
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Update channels.
+const (
+	// ChannelStable offers full releases only.
+	ChannelStable = "stable"
+	// ChannelBeta also offers pre-releases.
+	ChannelBeta = "beta"
+)
+
+const (
+	repo      = "jainal09/envdrift"
+	tagPrefix = "agent-v"
+	checksums = "checksums.txt"
+)
+
+// apiBase and httpClient reach GitHub; tests point them at a stub server.
+var (
+	apiBase    = "https://api.github.com"
+	httpClient = &http.Client{Timeout: 5 * time.Minute}
+)
+
+// ValidateChannel reports an error for anything but stable or beta.
+func ValidateChannel(channel string) error {
+	if channel != ChannelStable && channel != ChannelBeta {
+		return fmt.Errorf("invalid update channel %q (want %q or %q)", channel, ChannelStable, ChannelBeta)
+	}
+	return nil
+}
+
+// Release is one agent release.
+type Release struct {
+	Version    string
+	Prerelease bool
+	// Notes is the release body, shown before applying.
+	Notes string
+	// Assets maps an asset file name to its download URL.
+	Assets map[string]string
+}
+
+// AssetName is the release binary for goos/goarch, as agent-release.yml
+// names it.
+func AssetName(goos, goarch string) string {
+	name := "envdrift-agent-" + goos + "-" + goarch
+	if goos == "windows" {
+		name += ".exe"
+	}
+	return name
+}
+
+type githubRelease struct {
+	TagName    string        `json:"tag_name"`
+	Body       string        `json:"body"`
+	Draft      bool          `json:"draft"`
+	Prerelease bool          `json:"prerelease"`
+	Assets     []githubAsset `json:"assets"`
+}
+
+type githubAsset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+}
+
+// Latest returns the newest agent release on channel. The repository also
+// publishes CLI and extension releases, so "latest" is computed from the
+// agent-v* tags rather than GitHub's /releases/latest.
+func Latest(ctx context.Context, channel string) (*Release, error) {
+	if err := ValidateChannel(channel); err != nil {
+		return nil, err
+	}
+	resp, err := get(ctx, apiBase+"/repos/"+repo+"/releases?per_page=100")
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	var list []githubRelease
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return nil, fmt.Errorf("parse release list: %w", err)
+	}
+
+	var best *Release
+	var bestVersion version
+	for _, r := range list {
+		if r.Draft || !strings.HasPrefix(r.TagName, tagPrefix) {
+			continue
+		}
+		v, err := parseVersion(strings.TrimPrefix(r.TagName, tagPrefix))
+		if err != nil {
+			continue
+		}
+		pre := r.Prerelease || len(v.pre) > 0
+		if pre && channel != ChannelBeta {
+			continue
+		}
+		if best != nil && v.compare(bestVersion) <= 0 {
+			continue
+		}
+		best = &Release{
+			Version:    strings.TrimPrefix(r.TagName, tagPrefix),
+			Prerelease: pre,
+			Notes:      strings.TrimSpace(r.Body),
+			Assets:     make(map[string]string, len(r.Assets)),
+		}
+		for _, a := range r.Assets {
+			best.Assets[a.Name] = a.URL
+		}
+		bestVersion = v
+	}
+	if best == nil {
+		return nil, fmt.Errorf("no %s agent release found", channel)
+	}
+	return best, nil
+}
+
+// Apply downloads rel's binary for this platform, verifies it against the
+// release checksums and replaces the executable at exe with it. The old
+// binary is only touched once the new one is complete and verified.
+func Apply(ctx context.Context, rel *Release, exe string) error {
+	name := AssetName(runtime.GOOS, runtime.GOARCH)
+	url, ok := rel.Assets[name]
+	if !ok {
+		return fmt.Errorf("release %s has no %s binary", rel.Version, name)
+	}
+	sumsURL, ok := rel.Assets[checksums]
+	if !ok {
+		return fmt.Errorf("release %s has no %s; refusing an unverified binary", rel.Version, checksums)
+	}
+	want, err := expectedSum(ctx, sumsURL, name)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(exe), ".envdrift-agent-update-*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if err := download(ctx, url, tmp, want); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o755); err != nil {
+		return err
+	}
+	return replaceExecutable(tmp.Name(), exe)
+}
+
+// replaceExecutable renames src over exe. Windows cannot replace a running
+// executable but can rename it, so the old binary is moved aside first (and
+// removed by the next update).
+func replaceExecutable(src, exe string) error {
+	if runtime.GOOS != "windows" {
+		return os.Rename(src, exe)
+	}
+	old := exe + ".old"
+	_ = os.Remove(old)
+	if err := os.Rename(exe, old); err != nil {
+		return err
+	}
+	if err := os.Rename(src, exe); err != nil {
+		_ = os.Rename(old, exe)
+		return err
+	}
+	return nil
+}
+
+// expectedSum reads name's SHA-256 from a sha256sum-format checksums file.
+func expectedSum(ctx context.Context, url, name string) (string, error) {
+	resp, err := get(ctx, url)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == name {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("%s lists no checksum for %s", checksums, name)
+}
+
+// download writes url to w and checks the content against sum.
+func download(ctx context.Context, url string, w io.Writer, sum string) error {
+	resp, err := get(ctx, url)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(w, h), resp.Body); err != nil {
+		return err
+	}
+	if got := hex.EncodeToString(h.Sum(nil)); got != sum {
+		return errors.New("downloaded binary does not match its published checksum")
+	}
+	return nil
+}
+
+func get(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return resp, nil
+}
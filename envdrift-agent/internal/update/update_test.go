@@ -0,0 +1,138 @@
+package update
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.2.3", "1.2.3", 0},
+		{"v1.2.3", "1.2.3", 0},
+		{"1.2.3", "1.10.0", -1},
+		{"2.0.0", "1.99.99", 1},
+		{"1.3.0-beta.1", "1.3.0", -1},
+		{"1.3.0-beta.2", "1.3.0-beta.10", -1},
+		{"1.3.0-alpha", "1.3.0-beta", -1},
+		{"1.3.0-beta", "1.3.0-beta.1", -1},
+		{"1.3.0-1", "1.3.0-beta", -1},
+		{"1.3.0+build.5", "1.3.0", 0},
+	}
+	for _, tt := range tests {
+		got, err := Compare(tt.a, tt.b)
+		if err != nil || got != tt.want {
+			t.Errorf("Compare(%q, %q) = %d, %v; want %d", tt.a, tt.b, got, err, tt.want)
+		}
+	}
+	for _, bad := range []string{"dev", "1.2", "1.2.x", "1.2.3-"} {
+		if _, err := Compare(bad, "1.0.0"); err == nil {
+			t.Errorf("Compare(%q) accepted an invalid version", bad)
+		}
+	}
+}
+
+// fakeGitHub serves a release list with a binary and checksums for each
+// release.
+func fakeGitHub(t *testing.T, binary []byte, releases ...githubRelease) {
+	t.Helper()
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	sum := sha256.Sum256(binary)
+	asset := AssetName(runtime.GOOS, runtime.GOARCH)
+	for i := range releases {
+		r := &releases[i]
+		r.Assets = append(r.Assets,
+			githubAsset{asset, srv.URL + "/dl/" + r.TagName + "/" + asset},
+			githubAsset{checksums, srv.URL + "/dl/" + r.TagName + "/" + checksums},
+		)
+	}
+	mux.HandleFunc("/repos/"+repo+"/releases", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(releases)
+	})
+	mux.HandleFunc("/dl/", func(w http.ResponseWriter, r *http.Request) {
+		if filepath.Base(r.URL.Path) == checksums {
+			fmt.Fprintf(w, "%s  %s\n", hex.EncodeToString(sum[:]), asset)
+			return
+		}
+		_, _ = w.Write(binary)
+	})
+
+	origBase, origClient := apiBase, httpClient
+	apiBase, httpClient = srv.URL, srv.Client()
+	t.Cleanup(func() { apiBase, httpClient = origBase, origClient })
+}
+
+func TestLatestHonorsChannel(t *testing.T) {
+	fakeGitHub(t, []byte("bin"),
+		githubRelease{TagName: "agent-v1.1.4", Body: "stable notes"},
+		githubRelease{TagName: "agent-v1.2.0-beta.1", Prerelease: true, Body: "beta notes"},
+		githubRelease{TagName: "agent-v9.9.9", Draft: true},
+		githubRelease{TagName: "vscode-v5.0.0"},
+		githubRelease{TagName: "agent-v1.0.0"},
+	)
+
+	rel, err := Latest(context.Background(), ChannelStable)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rel.Version != "1.1.4" || rel.Prerelease || rel.Notes != "stable notes" {
+		t.Errorf("stable = %+v", rel)
+	}
+	rel, err = Latest(context.Background(), ChannelBeta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rel.Version != "1.2.0-beta.1" || !rel.Prerelease {
+		t.Errorf("beta = %+v", rel)
+	}
+	if _, err := Latest(context.Background(), "nightly"); err == nil {
+		t.Error("Latest accepted an unknown channel")
+	}
+}
+
+func TestApplyVerifiesAndReplaces(t *testing.T) {
+	fakeGitHub(t, []byte("new binary"), githubRelease{TagName: "agent-v1.2.0"})
+	rel, err := Latest(context.Background(), ChannelStable)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exe := filepath.Join(t.TempDir(), "envdrift-agent")
+	if err := os.WriteFile(exe, []byte("old binary"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := Apply(context.Background(), rel, exe); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(exe); string(data) != "new binary" {
+		t.Errorf("executable = %q after Apply", data)
+	}
+
+	// A binary that does not match checksums.txt leaves the old one alone.
+	asset := AssetName(runtime.GOOS, runtime.GOARCH)
+	rel.Assets[asset] = rel.Assets[checksums]
+	if err := Apply(context.Background(), rel, exe); err == nil {
+		t.Fatal("Apply accepted a binary with the wrong checksum")
+	}
+	if data, _ := os.ReadFile(exe); string(data) != "new binary" {
+		t.Errorf("executable = %q after a failed Apply", data)
+	}
+	entries, _ := os.ReadDir(filepath.Dir(exe))
+	if len(entries) != 1 {
+		t.Errorf("failed Apply left %d files beside the executable", len(entries))
+	}
+}
@@ -0,0 +1,102 @@
+package update
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// version is a parsed semantic version (MAJOR.MINOR.PATCH[-PRERELEASE]);
+// build metadata is ignored.
+type version struct {
+	core [3]int
+	pre  []string
+}
+
+// parseVersion accepts "1.2.3", "v1.2.3" and "1.2.3-beta.1".
+func parseVersion(s string) (version, error) {
+	var v version
+	s = strings.TrimPrefix(s, "v")
+	s, _, _ = strings.Cut(s, "+")
+	core, pre, hasPre := strings.Cut(s, "-")
+	parts := strings.Split(core, ".")
+	if len(parts) != 3 {
+		return v, fmt.Errorf("invalid version %q", s)
+	}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return v, fmt.Errorf("invalid version %q", s)
+		}
+		v.core[i] = n
+	}
+	if hasPre {
+		if pre == "" {
+			return v, fmt.Errorf("invalid version %q", s)
+		}
+		v.pre = strings.Split(pre, ".")
+	}
+	return v, nil
+}
+
+// Compare orders two versions by semver precedence: -1 if a < b, 0 if equal,
+// +1 if a > b. A pre-release sorts before its release (1.2.0-beta.1 < 1.2.0).
+func Compare(a, b string) (int, error) {
+	va, err := parseVersion(a)
+	if err != nil {
+		return 0, err
+	}
+	vb, err := parseVersion(b)
+	if err != nil {
+		return 0, err
+	}
+	return va.compare(vb), nil
+}
+
+func (v version) compare(o version) int {
+	for i := range v.core {
+		if c := cmpInt(v.core[i], o.core[i]); c != 0 {
+			return c
+		}
+	}
+	switch {
+	case len(v.pre) == 0 && len(o.pre) == 0:
+		return 0
+	case len(v.pre) == 0:
+		return 1
+	case len(o.pre) == 0:
+		return -1
+	}
+	for i := 0; i < len(v.pre) && i < len(o.pre); i++ {
+		if c := comparePre(v.pre[i], o.pre[i]); c != 0 {
+			return c
+		}
+	}
+	return cmpInt(len(v.pre), len(o.pre))
+}
+
+// comparePre orders pre-release identifiers: numeric ones numerically and
+// below alphanumeric ones, which compare as strings.
+func comparePre(a, b string) int {
+	na, errA := strconv.Atoi(a)
+	nb, errB := strconv.Atoi(b)
+	switch {
+	case errA == nil && errB == nil:
+		return cmpInt(na, nb)
+	case errA == nil:
+		return -1
+	case errB == nil:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+func cmpInt(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,160 @@
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// AWSConfig configures the AWS Secrets Manager provider ([vault.aws]).
+type AWSConfig struct {
+	// Region overrides the region the credential chain would pick.
+	Region string
+	// Profile selects a named profile from ~/.aws/config — an SSO profile,
+	// an assume-role profile (role_arn + source_profile), etc.
+	Profile string
+}
+
+// AWS stores secrets in AWS Secrets Manager through the `aws` CLI.
+//
+// Going through the CLI gives the agent the full AWS default credential
+// chain — environment variables, SSO sessions (`aws sso login`), assumed
+// roles, web identity, and container/IMDS instance credentials — exactly as
+// the user's shell resolves it, instead of only static access keys.
+type AWS struct {
+	cfg AWSConfig
+}
+
+// NewAWS returns an AWS Secrets Manager provider.
+func NewAWS(cfg AWSConfig) *AWS {
+	return &AWS{cfg: cfg}
+}
+
+// Name implements Provider.
+func (a *AWS) Name() string { return "aws" }
+
+// GetSecret implements Provider.
+func (a *AWS) GetSecret(ctx context.Context, name string) (string, error) {
+	out, err := a.run(ctx, nil, "get-secret-value", "--secret-id", name,
+		"--query", "SecretString", "--output", "text")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(out), "\r\n"), nil
+}
+
+// DescribeSecret implements Describer from describe-secret, which never
+// returns the value. The version is the ID staged AWSCURRENT.
+func (a *AWS) DescribeSecret(ctx context.Context, name string) (SecretInfo, error) {
+	out, err := a.run(ctx, nil, "describe-secret", "--secret-id", name, "--output", "json")
+	if err != nil {
+		return SecretInfo{}, err
+	}
+	var desc struct {
+		CreatedDate        json.RawMessage     `json:"CreatedDate"`
+		LastChangedDate    json.RawMessage     `json:"LastChangedDate"`
+		VersionIdsToStages map[string][]string `json:"VersionIdsToStages"`
+	}
+	if err := json.Unmarshal(out, &desc); err != nil {
+		return SecretInfo{}, fmt.Errorf("aws: decode describe-secret %s: %w", name, err)
+	}
+	info := SecretInfo{Created: awsTime(desc.CreatedDate), Updated: awsTime(desc.LastChangedDate)}
+	for id, stages := range desc.VersionIdsToStages {
+		for _, stage := range stages {
+			if stage == "AWSCURRENT" {
+				info.Version = id
+			}
+		}
+	}
+	return info, nil
+}
+
+// awsTime parses a CLI timestamp: ISO 8601 from CLI v2, epoch seconds from
+// v1 or cli_timestamp_format = none.
+func awsTime(raw json.RawMessage) time.Time {
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		t, _ := time.Parse(time.RFC3339Nano, s)
+		return t
+	}
+	var secs float64
+	if json.Unmarshal(raw, &secs) == nil && secs > 0 {
+		return time.Unix(0, int64(secs*float64(time.Second)))
+	}
+	return time.Time{}
+}
+
+// SetSecret implements Provider. The value is passed through a file:// URI —
+// stdin where the OS has /dev/stdin, otherwise a 0600 temp file removed right
+// after — so the secret never appears in the process list.
+func (a *AWS) SetSecret(ctx context.Context, name, value string) error {
+	uri, stdin, cleanup, err := secretStringURI(value)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	_, err = a.run(ctx, stdin, "put-secret-value", "--secret-id", name, "--secret-string", uri)
+	if errors.Is(err, ErrNotFound) {
+		_, err = a.run(ctx, stdin, "create-secret", "--name", name, "--secret-string", uri)
+	}
+	return err
+}
+
+// run invokes `aws secretsmanager <args...>` with the configured profile and
+// region, mapping ResourceNotFoundException to ErrNotFound.
+func (a *AWS) run(ctx context.Context, stdin []byte, args ...string) ([]byte, error) {
+	full := append([]string{"secretsmanager"}, args...)
+	if a.cfg.Region != "" {
+		full = append(full, "--region", a.cfg.Region)
+	}
+	if a.cfg.Profile != "" {
+		full = append(full, "--profile", a.cfg.Profile)
+	}
+
+	stdout, stderr, err := runCLI(ctx, stdin, "aws", full...)
+	if err != nil {
+		msg := strings.TrimSpace(string(stderr))
+		var execErr *exec.Error
+		switch {
+		case errors.As(err, &execErr):
+			return nil, errors.New("aws CLI not found. Install it: https://aws.amazon.com/cli/")
+		case strings.Contains(msg, "ResourceNotFoundException"):
+			return nil, fmt.Errorf("aws: %w", ErrNotFound)
+		case msg != "":
+			return nil, fmt.Errorf("aws secretsmanager %s: %s", args[0], msg)
+		default:
+			return nil, fmt.Errorf("aws secretsmanager %s: %w", args[0], err)
+		}
+	}
+	return stdout, nil
+}
+
+// secretStringURI prepares a file:// argument carrying value for the aws CLI.
+func secretStringURI(value string) (uri string, stdin []byte, cleanup func(), err error) {
+	if runtime.GOOS != "windows" {
+		return "file:///dev/stdin", []byte(value), func() {}, nil
+	}
+	f, err := os.CreateTemp("", "envdrift-secret-*")
+	if err != nil {
+		return "", nil, nil, err
+	}
+	cleanup = func() { _ = os.Remove(f.Name()) }
+	if _, err := f.WriteString(value); err != nil {
+		_ = f.Close()
+		cleanup()
+		return "", nil, nil, err
+	}
+	if err := f.Close(); err != nil {
+		cleanup()
+		return "", nil, nil, err
+	}
+	return "file://" + filepath.ToSlash(f.Name()), nil, cleanup, nil
+}
@@ -0,0 +1,141 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// cliCall records one faked runCLI invocation.
+type cliCall struct {
+	name  string
+	args  []string
+	stdin string
+}
+
+// fakeCLI replaces runCLI with respond and returns the recorded calls.
+func fakeCLI(t *testing.T, respond func(args []string) (string, string, error)) *[]cliCall {
+	t.Helper()
+	orig := runCLI
+	t.Cleanup(func() { runCLI = orig })
+	var calls []cliCall
+	runCLI = func(_ context.Context, stdin []byte, name string, args ...string) ([]byte, []byte, error) {
+		calls = append(calls, cliCall{name: name, args: args, stdin: string(stdin)})
+		out, errOut, err := respond(args)
+		return []byte(out), []byte(errOut), err
+	}
+	return &calls
+}
+
+func hasArgPair(args []string, flag, value string) bool {
+	for i := 0; i+1 < len(args); i++ {
+		if args[i] == flag && args[i+1] == value {
+			return true
+		}
+	}
+	return false
+}
+
+func TestAWSGetSecretPassesProfileAndRegion(t *testing.T) {
+	calls := fakeCLI(t, func([]string) (string, string, error) {
+		return "abc123\n", "", nil
+	})
+	a := NewAWS(AWSConfig{Region: "eu-west-1", Profile: "corp-sso"})
+
+	got, err := a.GetSecret(context.Background(), "myapp/prod")
+	if err != nil || got != "abc123" {
+		t.Fatalf("GetSecret = %q, %v", got, err)
+	}
+	c := (*calls)[0]
+	if c.name != "aws" || c.args[0] != "secretsmanager" || c.args[1] != "get-secret-value" {
+		t.Fatalf("unexpected invocation: %s %v", c.name, c.args)
+	}
+	for flag, value := range map[string]string{"--secret-id": "myapp/prod", "--profile": "corp-sso", "--region": "eu-west-1"} {
+		if !hasArgPair(c.args, flag, value) {
+			t.Errorf("args %v missing %s %s", c.args, flag, value)
+		}
+	}
+}
+
+func TestAWSDefaultChainOmitsProfileAndRegion(t *testing.T) {
+	calls := fakeCLI(t, func([]string) (string, string, error) { return "k", "", nil })
+	if _, err := NewAWS(AWSConfig{}).GetSecret(context.Background(), "s"); err != nil {
+		t.Fatal(err)
+	}
+	for _, a := range (*calls)[0].args {
+		if a == "--profile" || a == "--region" {
+			t.Errorf("no profile/region configured, but args contain %s", a)
+		}
+	}
+}
+
+func TestAWSErrors(t *testing.T) {
+	fakeCLI(t, func([]string) (string, string, error) {
+		return "", "An error occurred (ResourceNotFoundException) when calling the GetSecretValue operation", errors.New("exit status 254")
+	})
+	if _, err := NewAWS(AWSConfig{}).GetSecret(context.Background(), "s"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("ResourceNotFoundException should map to ErrNotFound, got %v", err)
+	}
+
+	fakeCLI(t, func([]string) (string, string, error) {
+		return "", "", &exec.Error{Name: "aws", Err: exec.ErrNotFound}
+	})
+	if _, err := NewAWS(AWSConfig{}).GetSecret(context.Background(), "s"); err == nil || !strings.Contains(err.Error(), "aws CLI not found") {
+		t.Errorf("missing CLI should say so, got %v", err)
+	}
+
+	fakeCLI(t, func([]string) (string, string, error) {
+		return "", "Error when retrieving token from sso: Token has expired", errors.New("exit status 255")
+	})
+	if _, err := NewAWS(AWSConfig{}).GetSecret(context.Background(), "s"); err == nil || !strings.Contains(err.Error(), "Token has expired") {
+		t.Errorf("CLI stderr should be surfaced, got %v", err)
+	}
+}
+
+func TestAWSSetSecretCreatesWhenMissing(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("stdin transport is used on non-Windows only")
+	}
+	calls := fakeCLI(t, func(args []string) (string, string, error) {
+		if args[1] == "put-secret-value" {
+			return "", "ResourceNotFoundException", errors.New("exit status 254")
+		}
+		return "{}", "", nil
+	})
+
+	if err := NewAWS(AWSConfig{Profile: "p"}).SetSecret(context.Background(), "myapp/prod", "abc123"); err != nil {
+		t.Fatal(err)
+	}
+	if len(*calls) != 2 || (*calls)[1].args[1] != "create-secret" {
+		t.Fatalf("expected put then create, got %+v", *calls)
+	}
+	for _, c := range *calls {
+		if c.stdin != "abc123" || !hasArgPair(c.args, "--secret-string", "file:///dev/stdin") {
+			t.Errorf("secret must travel over stdin, got args %v stdin %q", c.args, c.stdin)
+		}
+		for _, a := range c.args {
+			if a == "abc123" {
+				t.Errorf("secret value leaked into argv: %v", c.args)
+			}
+		}
+	}
+}
+
+func TestAWSDescribeSecret(t *testing.T) {
+	fakeCLI(t, func(args []string) (string, string, error) {
+		return `{"Name": "myapp/prod",
+			"CreatedDate": "2026-01-02T03:04:05.123000+00:00",
+			"LastChangedDate": 1767409200.5,
+			"VersionIdsToStages": {"old-id": ["AWSPREVIOUS"], "cur-id": ["AWSCURRENT"]}}`, "", nil
+	})
+	info, err := NewAWS(AWSConfig{}).DescribeSecret(context.Background(), "myapp/prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Version != "cur-id" || info.Created.Year() != 2026 || info.Updated.Unix() != 1767409200 {
+		t.Errorf("DescribeSecret = %+v", info)
+	}
+}
@@ -0,0 +1,400 @@
+package vault
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AzureConfig configures the Azure Key Vault provider ([vault.azure]).
+type AzureConfig struct {
+	VaultURL string
+	// TenantID pins the Entra ID tenant; it overrides AZURE_TENANT_ID and is
+	// passed to `az account get-access-token --tenant`.
+	TenantID string
+	// ClientID selects the app registration (with AZURE_CLIENT_SECRET) or the
+	// user-assigned managed identity; it overrides AZURE_CLIENT_ID.
+	ClientID string
+}
+
+const (
+	azureAPIVersion = "7.4"
+	azureResource   = "https://vault.azure.net"
+)
+
+var (
+	// imdsEndpoint is the Azure Instance Metadata Service token endpoint.
+	imdsEndpoint = "http://169.254.169.254/metadata/identity/oauth2/token"
+
+	// imdsTimeout bounds the instance metadata probes (Azure IMDS, the GCE
+	// metadata server) so machines outside the cloud fail over quickly.
+	imdsTimeout = time.Second
+)
+
+// Azure stores secrets in Azure Key Vault over its REST API.
+//
+// Tokens come from the same ordered chain as the SDK's DefaultAzureCredential
+// the Python CLI uses — environment service principal, workload identity,
+// managed identity, then the Azure CLI login — so a config works identically
+// with both tools.
+type Azure struct {
+	cfg AzureConfig
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+// NewAzure returns an Azure Key Vault provider.
+func NewAzure(cfg AzureConfig) *Azure {
+	cfg.VaultURL = strings.TrimRight(cfg.VaultURL, "/")
+	return &Azure{cfg: cfg}
+}
+
+// Name implements Provider.
+func (a *Azure) Name() string { return "azure" }
+
+// GetSecret implements Provider.
+func (a *Azure) GetSecret(ctx context.Context, name string) (string, error) {
+	body, err := a.do(ctx, http.MethodGet, name, nil)
+	if err != nil {
+		return "", err
+	}
+	var bundle struct {
+		Value string `json:"value"`
+	}
+	if err := json.Unmarshal(body, &bundle); err != nil {
+		return "", fmt.Errorf("azure: decode secret %s: %w", name, err)
+	}
+	return bundle.Value, nil
+}
+
+// DescribeSecret implements Describer from the secret bundle's id (whose last
+// segment is the version) and attributes.
+func (a *Azure) DescribeSecret(ctx context.Context, name string) (SecretInfo, error) {
+	body, err := a.do(ctx, http.MethodGet, name, nil)
+	if err != nil {
+		return SecretInfo{}, err
+	}
+	var bundle struct {
+		ID         string `json:"id"`
+		Attributes struct {
+			Created int64 `json:"created"`
+			Updated int64 `json:"updated"`
+		} `json:"attributes"`
+	}
+	if err := json.Unmarshal(body, &bundle); err != nil {
+		return SecretInfo{}, fmt.Errorf("azure: decode secret %s: %w", name, err)
+	}
+	info := SecretInfo{Version: bundle.ID[strings.LastIndex(bundle.ID, "/")+1:]}
+	if bundle.Attributes.Created > 0 {
+		info.Created = time.Unix(bundle.Attributes.Created, 0)
+	}
+	if bundle.Attributes.Updated > 0 {
+		info.Updated = time.Unix(bundle.Attributes.Updated, 0)
+	}
+	return info, nil
+}
+
+// SetSecret implements Provider; Key Vault creates the secret or adds a new
+// version.
+func (a *Azure) SetSecret(ctx context.Context, name, value string) error {
+	payload, err := json.Marshal(map[string]string{"value": value})
+	if err != nil {
+		return err
+	}
+	_, err = a.do(ctx, http.MethodPut, name, payload)
+	return err
+}
+
+// do sends one authenticated Key Vault secrets request.
+func (a *Azure) do(ctx context.Context, method, name string, payload []byte) ([]byte, error) {
+	token, err := a.accessToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+	u := a.cfg.VaultURL + "/secrets/" + url.PathEscape(name) + "?api-version=" + azureAPIVersion
+	req, err := http.NewRequestWithContext(ctx, method, u, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("azure key vault: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, fmt.Errorf("azure: %w", ErrNotFound)
+	case resp.StatusCode >= 300:
+		return nil, fmt.Errorf("azure key vault %s %s: %s", method, name, azureErrorMessage(resp.Status, body))
+	}
+	return body, nil
+}
+
+// azureErrorMessage extracts Key Vault's error.message, falling back to the
+// HTTP status.
+func azureErrorMessage(status string, body []byte) string {
+	var e struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(body, &e) == nil && e.Error.Message != "" {
+		return status + ": " + e.Error.Message
+	}
+	return status
+}
+
+// azureCredential is one link of the token chain. It returns errNoCredential
+// (wrapped with a reason) when it does not apply on this machine.
+type azureCredential struct {
+	name  string
+	token func(a *Azure, ctx context.Context) (string, time.Time, error)
+}
+
+var errNoCredential = errors.New("credential unavailable")
+
+// azureChain is DefaultAzureCredential's order, minus the interactive and
+// IDE-specific credentials a background agent cannot use.
+var azureChain = []azureCredential{
+	{"environment", (*Azure).environmentToken},
+	{"workload identity", (*Azure).workloadIdentityToken},
+	{"managed identity", (*Azure).managedIdentityToken},
+	{"azure cli", (*Azure).cliToken},
+}
+
+// accessToken returns a cached Key Vault token or walks the chain for one. A
+// credential that applies but fails stops the walk, like the SDK.
+func (a *Azure) accessToken(ctx context.Context) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.token != "" && time.Until(a.expires) > time.Minute {
+		return a.token, nil
+	}
+
+	var reasons []string
+	for _, c := range azureChain {
+		token, expires, err := c.token(a, ctx)
+		if errors.Is(err, errNoCredential) {
+			reasons = append(reasons, c.name+": "+err.Error())
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("azure %s authentication failed: %w", c.name, err)
+		}
+		a.token, a.expires = token, expires
+		return token, nil
+	}
+	return "", fmt.Errorf("no Azure credential available (%s)", strings.Join(reasons, "; "))
+}
+
+func (a *Azure) tenantID() string {
+	if a.cfg.TenantID != "" {
+		return a.cfg.TenantID
+	}
+	return os.Getenv("AZURE_TENANT_ID")
+}
+
+func (a *Azure) clientID() string {
+	if a.cfg.ClientID != "" {
+		return a.cfg.ClientID
+	}
+	return os.Getenv("AZURE_CLIENT_ID")
+}
+
+// environmentToken is the client-secret service principal from
+// AZURE_CLIENT_SECRET plus the tenant and client IDs.
+func (a *Azure) environmentToken(ctx context.Context) (string, time.Time, error) {
+	secret := os.Getenv("AZURE_CLIENT_SECRET")
+	if secret == "" || a.tenantID() == "" || a.clientID() == "" {
+		return "", time.Time{}, fmt.Errorf("%w: AZURE_TENANT_ID, AZURE_CLIENT_ID and AZURE_CLIENT_SECRET not all set", errNoCredential)
+	}
+	return a.entraToken(ctx, url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {a.clientID()},
+		"client_secret": {secret},
+		"scope":         {azureResource + "/.default"},
+	})
+}
+
+// workloadIdentityToken exchanges the federated token file AKS workload
+// identity mounts (AZURE_FEDERATED_TOKEN_FILE) for a Key Vault token.
+func (a *Azure) workloadIdentityToken(ctx context.Context) (string, time.Time, error) {
+	file := os.Getenv("AZURE_FEDERATED_TOKEN_FILE")
+	if file == "" || a.tenantID() == "" || a.clientID() == "" {
+		return "", time.Time{}, fmt.Errorf("%w: AZURE_FEDERATED_TOKEN_FILE, tenant and client IDs not all set", errNoCredential)
+	}
+	assertion, err := os.ReadFile(file)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return a.entraToken(ctx, url.Values{
+		"grant_type":            {"client_credentials"},
+		"client_id":             {a.clientID()},
+		"client_assertion_type": {"urn:ietf:params:oauth:client-assertion-type:jwt-bearer"},
+		"client_assertion":      {strings.TrimSpace(string(assertion))},
+		"scope":                 {azureResource + "/.default"},
+	})
+}
+
+// entraToken posts a client-credentials grant to the tenant's token endpoint
+// (AZURE_AUTHORITY_HOST selects a sovereign cloud).
+func (a *Azure) entraToken(ctx context.Context, form url.Values) (string, time.Time, error) {
+	authority := os.Getenv("AZURE_AUTHORITY_HOST")
+	if authority == "" {
+		authority = "https://login.microsoftonline.com"
+	}
+	endpoint := strings.TrimRight(authority, "/") + "/" + url.PathEscape(a.tenantID()) + "/oauth2/v2.0/token"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return fetchToken(httpClient, req)
+}
+
+// managedIdentityToken asks the App Service/Functions identity endpoint when
+// present, otherwise IMDS. AZURE_CLIENT_ID (or client_id) selects a
+// user-assigned identity.
+func (a *Azure) managedIdentityToken(ctx context.Context) (string, time.Time, error) {
+	q := url.Values{"resource": {azureResource}}
+	if id := a.clientID(); id != "" {
+		q.Set("client_id", id)
+	}
+
+	if endpoint, header := os.Getenv("IDENTITY_ENDPOINT"), os.Getenv("IDENTITY_HEADER"); endpoint != "" && header != "" {
+		q.Set("api-version", "2019-08-01")
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+"?"+q.Encode(), nil)
+		if err != nil {
+			return "", time.Time{}, err
+		}
+		req.Header.Set("X-IDENTITY-HEADER", header)
+		return fetchToken(httpClient, req)
+	}
+
+	q.Set("api-version", "2018-02-01")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, imdsEndpoint+"?"+q.Encode(), nil)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	req.Header.Set("Metadata", "true")
+	probe := *httpClient
+	probe.Timeout = imdsTimeout
+	resp, err := probe.Do(req)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("%w: instance metadata service unreachable", errNoCredential)
+	}
+	if resp.StatusCode == http.StatusBadRequest {
+		// IMDS answers 400 when the VM has no (matching) identity assigned.
+		resp.Body.Close()
+		return "", time.Time{}, fmt.Errorf("%w: no managed identity assigned", errNoCredential)
+	}
+	return decodeToken(resp)
+}
+
+// cliToken uses the signed-in Azure CLI (`az login`).
+func (a *Azure) cliToken(ctx context.Context) (string, time.Time, error) {
+	args := []string{"account", "get-access-token", "--resource", azureResource, "--output", "json"}
+	if t := a.cfg.TenantID; t != "" {
+		args = append(args, "--tenant", t)
+	}
+	stdout, stderr, err := runCLI(ctx, nil, "az", args...)
+	if err != nil {
+		var execErr *exec.Error
+		if errors.As(err, &execErr) {
+			return "", time.Time{}, fmt.Errorf("%w: az not installed", errNoCredential)
+		}
+		msg := strings.TrimSpace(string(stderr))
+		if strings.Contains(msg, "az login") {
+			return "", time.Time{}, fmt.Errorf("%w: not logged in (run 'az login')", errNoCredential)
+		}
+		if msg != "" {
+			return "", time.Time{}, errors.New(msg)
+		}
+		return "", time.Time{}, err
+	}
+
+	var tok struct {
+		AccessToken string `json:"accessToken"`
+		ExpiresOn   int64  `json:"expires_on"`
+	}
+	if err := json.Unmarshal(stdout, &tok); err != nil || tok.AccessToken == "" {
+		return "", time.Time{}, errors.New("unexpected az get-access-token output")
+	}
+	expires := time.Now().Add(5 * time.Minute)
+	if tok.ExpiresOn > 0 {
+		expires = time.Unix(tok.ExpiresOn, 0)
+	}
+	return tok.AccessToken, expires, nil
+}
+
+// fetchToken sends req and decodes an OAuth token response.
+func fetchToken(client *http.Client, req *http.Request) (string, time.Time, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return decodeToken(resp)
+}
+
+// decodeToken reads access_token and its expiry from an Entra ID or managed
+// identity response (expires_in and expires_on arrive as numbers or strings).
+func decodeToken(resp *http.Response) (string, time.Time, error) {
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	var tok struct {
+		AccessToken      string          `json:"access_token"`
+		ExpiresIn        json.RawMessage `json:"expires_in"`
+		ExpiresOn        json.RawMessage `json:"expires_on"`
+		ErrorDescription string          `json:"error_description"`
+	}
+	_ = json.Unmarshal(body, &tok)
+	if resp.StatusCode >= 300 || tok.AccessToken == "" {
+		if tok.ErrorDescription != "" {
+			return "", time.Time{}, fmt.Errorf("%s: %s", resp.Status, tok.ErrorDescription)
+		}
+		return "", time.Time{}, fmt.Errorf("token request failed: %s", resp.Status)
+	}
+
+	expires := time.Now().Add(5 * time.Minute)
+	if n, ok := jsonNumber(tok.ExpiresOn); ok {
+		expires = time.Unix(n, 0)
+	} else if n, ok := jsonNumber(tok.ExpiresIn); ok {
+		expires = time.Now().Add(time.Duration(n) * time.Second)
+	}
+	return tok.AccessToken, expires, nil
+}
+
+// jsonNumber parses a JSON number or numeric string.
+func jsonNumber(raw json.RawMessage) (int64, bool) {
+	s := strings.Trim(string(raw), `"`)
+	if s == "" {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	return n, err == nil
+}
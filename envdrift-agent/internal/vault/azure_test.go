@@ -0,0 +1,167 @@
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// fakeAzure serves the Entra ID token endpoint, IMDS and Key Vault secrets
+// from one test server, and points the provider's seams at it.
+type fakeAzure struct {
+	*httptest.Server
+	secrets  map[string]string
+	tokenReq []string // path?form of every token request
+	auth     []string // Authorization headers seen by Key Vault
+}
+
+func newFakeAzure(t *testing.T, imds bool) *fakeAzure {
+	t.Helper()
+	f := &fakeAzure{secrets: map[string]string{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/tenant-from-config/oauth2/v2.0/token", func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		f.tokenReq = append(f.tokenReq, r.URL.Path+"?"+r.PostForm.Encode())
+		_, _ = io.WriteString(w, `{"access_token":"sp-token","expires_in":3600}`)
+	})
+	mux.HandleFunc("/imds", func(w http.ResponseWriter, r *http.Request) {
+		if !imds || r.Header.Get("Metadata") != "true" {
+			http.Error(w, "no identity", http.StatusBadRequest)
+			return
+		}
+		f.tokenReq = append(f.tokenReq, r.URL.Path+"?"+r.URL.RawQuery)
+		_, _ = io.WriteString(w, `{"access_token":"mi-token","expires_on":"9999999999"}`)
+	})
+	mux.HandleFunc("/secrets/", func(w http.ResponseWriter, r *http.Request) {
+		f.auth = append(f.auth, r.Header.Get("Authorization"))
+		name := strings.TrimPrefix(r.URL.Path, "/secrets/")
+		switch r.Method {
+		case http.MethodGet:
+			v, ok := f.secrets[name]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = io.WriteString(w, `{"error":{"code":"SecretNotFound","message":"not found"}}`)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]string{"value": v})
+		case http.MethodPut:
+			var body map[string]string
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			f.secrets[name] = body["value"]
+			_, _ = io.WriteString(w, `{}`)
+		}
+	})
+	f.Server = httptest.NewServer(mux)
+	t.Cleanup(f.Close)
+
+	origClient, origIMDS := httpClient, imdsEndpoint
+	httpClient, imdsEndpoint = f.Client(), f.URL+"/imds"
+	t.Cleanup(func() { httpClient, imdsEndpoint = origClient, origIMDS })
+
+	t.Setenv("AZURE_AUTHORITY_HOST", f.URL)
+	for _, k := range []string{"AZURE_TENANT_ID", "AZURE_CLIENT_ID", "AZURE_CLIENT_SECRET",
+		"AZURE_FEDERATED_TOKEN_FILE", "IDENTITY_ENDPOINT", "IDENTITY_HEADER"} {
+		t.Setenv(k, "")
+	}
+	return f
+}
+
+// noAzureCLI makes the az CLI look uninstalled.
+func noAzureCLI(t *testing.T) {
+	fakeCLI(t, func([]string) (string, string, error) {
+		return "", "", &exec.Error{Name: "az", Err: exec.ErrNotFound}
+	})
+}
+
+func TestAzureEnvironmentCredentialUsesConfiguredTenant(t *testing.T) {
+	f := newFakeAzure(t, false)
+	noAzureCLI(t)
+	f.secrets["myapp-prod"] = "abc123"
+	t.Setenv("AZURE_TENANT_ID", "tenant-from-env")
+	t.Setenv("AZURE_CLIENT_ID", "client-from-env")
+	t.Setenv("AZURE_CLIENT_SECRET", "s3cret")
+
+	a := NewAzure(AzureConfig{VaultURL: f.URL + "/", TenantID: "tenant-from-config"})
+	got, err := a.GetSecret(context.Background(), "myapp-prod")
+	if err != nil || got != "abc123" {
+		t.Fatalf("GetSecret = %q, %v", got, err)
+	}
+	if len(f.tokenReq) != 1 || !strings.Contains(f.tokenReq[0], "client_id=client-from-env") {
+		t.Errorf("token requests = %v; want one client-credentials grant for the env client", f.tokenReq)
+	}
+	if f.auth[0] != "Bearer sp-token" {
+		t.Errorf("Authorization = %q", f.auth[0])
+	}
+
+	// The token is cached for the provider's lifetime.
+	if _, err := a.GetSecret(context.Background(), "myapp-prod"); err != nil {
+		t.Fatal(err)
+	}
+	if len(f.tokenReq) != 1 {
+		t.Errorf("token fetched %d times, want 1", len(f.tokenReq))
+	}
+}
+
+func TestAzureManagedIdentityWithClientID(t *testing.T) {
+	f := newFakeAzure(t, true)
+	noAzureCLI(t)
+
+	a := NewAzure(AzureConfig{VaultURL: f.URL, ClientID: "user-assigned-id"})
+	if err := a.SetSecret(context.Background(), "k", "v1"); err != nil {
+		t.Fatal(err)
+	}
+	if f.secrets["k"] != "v1" {
+		t.Errorf("secret not stored: %v", f.secrets)
+	}
+	if len(f.tokenReq) != 1 || !strings.Contains(f.tokenReq[0], "client_id=user-assigned-id") {
+		t.Errorf("IMDS request = %v; want the configured client_id", f.tokenReq)
+	}
+	if f.auth[0] != "Bearer mi-token" {
+		t.Errorf("Authorization = %q", f.auth[0])
+	}
+}
+
+func TestAzureCLIFallbackAndNotFound(t *testing.T) {
+	f := newFakeAzure(t, false)
+	calls := fakeCLI(t, func([]string) (string, string, error) {
+		return `{"accessToken":"cli-token","expires_on":9999999999}`, "", nil
+	})
+
+	a := NewAzure(AzureConfig{VaultURL: f.URL, TenantID: "t1"})
+	if _, err := a.GetSecret(context.Background(), "missing"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("missing secret should be ErrNotFound, got %v", err)
+	}
+	if f.auth[0] != "Bearer cli-token" {
+		t.Errorf("Authorization = %q", f.auth[0])
+	}
+	c := (*calls)[0]
+	if c.name != "az" || !hasArgPair(c.args, "--tenant", "t1") || !hasArgPair(c.args, "--resource", azureResource) {
+		t.Errorf("az invocation = %s %v", c.name, c.args)
+	}
+}
+
+func TestAzureNoCredential(t *testing.T) {
+	f := newFakeAzure(t, false)
+	fakeCLI(t, func([]string) (string, string, error) {
+		return "", "ERROR: Please run 'az login' to setup account.", errors.New("exit status 1")
+	})
+
+	_, err := NewAzure(AzureConfig{VaultURL: f.URL}).GetSecret(context.Background(), "k")
+	if err == nil {
+		t.Fatal("expected an error without credentials")
+	}
+	for _, want := range []string{"no Azure credential available", "environment", "managed identity", "az login"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q should mention %q", err, want)
+		}
+	}
+	if len(f.auth) != 0 {
+		t.Error("Key Vault must not be called without a token")
+	}
+}
@@ -0,0 +1,274 @@
+package vault
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Cache keeps secrets read through the providers it wraps for TTL, so that
+// resolving the same key again soon — exec after exec, or several files in
+// one command — neither waits on the network nor spends the vault's request
+// quota. Entries live in memory and, when Dir is set, also on disk sealed
+// with AES-256-GCM under the key in KeyPath, which is created on first use.
+// A zero TTL caches nothing.
+type Cache struct {
+	TTL time.Duration
+	// Dir holds one sealed file per cached secret; empty keeps the cache in
+	// memory only.
+	Dir string
+	// KeyPath is the file holding the disk cache's key, kept apart from Dir.
+	KeyPath string
+
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+	key     []byte
+}
+
+// cacheEntry is a cached secret; it is also what a disk file seals.
+type cacheEntry struct {
+	Value   string    `json:"value"`
+	Expires time.Time `json:"expires"`
+}
+
+// cacheNow is the clock cache expiry uses; tests replace it.
+var cacheNow = time.Now
+
+// Wrap returns p reading through c: a secret read within TTL of the last
+// read of it from the same vault is answered from the cache. Writing a
+// secret drops its cached value.
+func (c *Cache) Wrap(cfg Config, p Provider) Provider {
+	return &cached{Provider: p, cache: c, scope: cacheScope(cfg)}
+}
+
+// Refresh returns p always reading from the vault, but storing what it reads
+// in c and dropping a secret's cached value when it is written. Commands
+// that must see the vault's current value, such as vault pull, use it so
+// that later cached reads start from that value.
+func (c *Cache) Refresh(cfg Config, p Provider) Provider {
+	return &cached{Provider: p, cache: c, scope: cacheScope(cfg), refresh: true}
+}
+
+// cacheScope identifies the vault cfg points at, so the same secret name in
+// two accounts or clusters is cached apart.
+func cacheScope(cfg Config) string {
+	data, _ := json.Marshal(cfg)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// cacheID names a secret in the cache. It is a digest, so neither memory
+// keys nor file names reveal the vault or secret names.
+func cacheID(scope, name string) string {
+	sum := sha256.Sum256([]byte(scope + "\x00" + name))
+	return hex.EncodeToString(sum[:])
+}
+
+// get returns the unexpired cached value for id.
+func (c *Cache) get(id string) (string, bool) {
+	if c.TTL <= 0 {
+		return "", false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := cacheNow()
+	if e, ok := c.entries[id]; ok && now.Before(e.Expires) {
+		return e.Value, true
+	}
+	if c.Dir == "" {
+		return "", false
+	}
+	e, err := c.readDisk(id)
+	if err != nil || !now.Before(e.Expires) {
+		return "", false
+	}
+	c.remember(id, e)
+	return e.Value, true
+}
+
+// put caches value for id. A disk cache that cannot be written only costs
+// the next command a vault read, so its errors are dropped.
+func (c *Cache) put(id, value string) {
+	if c.TTL <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e := cacheEntry{Value: value, Expires: cacheNow().Add(c.TTL)}
+	c.remember(id, e)
+	if c.Dir != "" {
+		_ = c.writeDisk(id, e)
+	}
+}
+
+// drop forgets id in memory and on disk.
+func (c *Cache) drop(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, id)
+	if c.Dir != "" {
+		_ = os.Remove(filepath.Join(c.Dir, id))
+	}
+}
+
+func (c *Cache) remember(id string, e cacheEntry) {
+	if c.entries == nil {
+		c.entries = make(map[string]cacheEntry)
+	}
+	c.entries[id] = e
+}
+
+// readDisk opens the sealed file for id. A file that does not open — written
+// under another key, or altered — is treated as a miss.
+func (c *Cache) readDisk(id string) (cacheEntry, error) {
+	data, err := os.ReadFile(filepath.Join(c.Dir, id))
+	if err != nil {
+		return cacheEntry{}, err
+	}
+	aead, err := c.aead(false)
+	if err != nil {
+		return cacheEntry{}, err
+	}
+	n := aead.NonceSize()
+	if len(data) < n {
+		return cacheEntry{}, errors.New("truncated cache entry")
+	}
+	plaintext, err := aead.Open(nil, data[:n], data[n:], []byte(id))
+	if err != nil {
+		return cacheEntry{}, err
+	}
+	var e cacheEntry
+	err = json.Unmarshal(plaintext, &e)
+	return e, err
+}
+
+// writeDisk seals e into the file for id, binding id as additional data so
+// an entry cannot be moved to answer for another secret.
+func (c *Cache) writeDisk(id string, e cacheEntry) error {
+	aead, err := c.aead(true)
+	if err != nil {
+		return err
+	}
+	plaintext, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(c.Dir, 0o700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(c.Dir, ".tmp-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(aead.Seal(nonce, nonce, plaintext, []byte(id)))
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(c.Dir, id))
+}
+
+// aead returns the disk cache cipher, loading its key, or with create
+// generating it when there is none yet.
+func (c *Cache) aead(create bool) (cipher.AEAD, error) {
+	if c.key == nil {
+		key, err := loadCacheKey(c.KeyPath, create)
+		if err != nil {
+			return nil, err
+		}
+		c.key = key
+	}
+	block, err := aes.NewCipher(c.key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// loadCacheKey reads the hex key at path, or with create writes a new one
+// readable only by the user.
+func loadCacheKey(path string, create bool) ([]byte, error) {
+	if path == "" {
+		return nil, errors.New("no vault cache key path")
+	}
+	data, err := os.ReadFile(path)
+	if err == nil {
+		key, err := hex.DecodeString(string(data))
+		if err != nil || len(key) != 32 {
+			return nil, fmt.Errorf("%s is not a vault cache key", path)
+		}
+		return key, nil
+	}
+	if !os.IsNotExist(err) || !create {
+		return nil, err
+	}
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, []byte(hex.EncodeToString(key)), 0o600); err != nil {
+		return nil, err
+	}
+	return key, os.Chmod(path, 0o600)
+}
+
+// cached is a Provider reading through a Cache.
+type cached struct {
+	Provider
+	cache   *Cache
+	scope   string
+	refresh bool
+}
+
+func (c *cached) GetSecret(ctx context.Context, name string) (string, error) {
+	id := cacheID(c.scope, name)
+	if !c.refresh {
+		if value, ok := c.cache.get(id); ok {
+			return value, nil
+		}
+	}
+	value, err := c.Provider.GetSecret(ctx, name)
+	switch {
+	case errors.Is(err, ErrNotFound):
+		c.cache.drop(id)
+	case err == nil:
+		c.cache.put(id, value)
+	}
+	return value, err
+}
+
+func (c *cached) SetSecret(ctx context.Context, name, value string) error {
+	// Drop the entry even when the write fails: the vault may have stored it.
+	defer c.cache.drop(cacheID(c.scope, name))
+	return c.Provider.SetSecret(ctx, name, value)
+}
+
+// DescribeSecret keeps the wrapped provider's metadata available to
+// Describe.
+func (c *cached) DescribeSecret(ctx context.Context, name string) (SecretInfo, error) {
+	d, ok := c.Provider.(Describer)
+	if !ok {
+		return SecretInfo{}, errors.New("no metadata available")
+	}
+	return d.DescribeSecret(ctx, name)
+}
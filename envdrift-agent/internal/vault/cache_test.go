@@ -0,0 +1,160 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// countingProvider is an in-memory store counting its reads.
+type countingProvider struct {
+	secrets map[string]string
+	reads   int
+}
+
+func (*countingProvider) Name() string { return "aws" }
+
+func (c *countingProvider) GetSecret(_ context.Context, name string) (string, error) {
+	c.reads++
+	v, ok := c.secrets[name]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return v, nil
+}
+
+func (c *countingProvider) SetSecret(_ context.Context, name, value string) error {
+	c.secrets[name] = value
+	return nil
+}
+
+// fakeClock makes cacheNow return *now for the test.
+func fakeClock(t *testing.T) *time.Time {
+	now := time.Date(2026, 1, 2, 15, 4, 0, 0, time.UTC)
+	orig := cacheNow
+	cacheNow = func() time.Time { return now }
+	t.Cleanup(func() { cacheNow = orig })
+	return &now
+}
+
+func TestCacheMemory(t *testing.T) {
+	now := fakeClock(t)
+	ctx := context.Background()
+	store := &countingProvider{secrets: map[string]string{"app/prod": "k1"}}
+	cfg := Config{Provider: "aws", AWS: AWSConfig{Region: "eu-west-1"}}
+	cache := &Cache{TTL: time.Minute}
+	p := cache.Wrap(cfg, store)
+
+	for range 3 {
+		if v, err := p.GetSecret(ctx, "app/prod"); err != nil || v != "k1" {
+			t.Fatalf("GetSecret = %q, %v", v, err)
+		}
+	}
+	if store.reads != 1 {
+		t.Errorf("%d vault reads for 3 lookups within the TTL; want 1", store.reads)
+	}
+
+	// Another account's secret of the same name is its own entry.
+	other := cache.Wrap(Config{Provider: "aws", AWS: AWSConfig{Region: "us-east-1"}}, store)
+	if _, err := other.GetSecret(ctx, "app/prod"); err != nil || store.reads != 2 {
+		t.Errorf("a different vault was answered from the cache (%d reads, %v)", store.reads, err)
+	}
+
+	*now = now.Add(time.Minute)
+	if _, err := p.GetSecret(ctx, "app/prod"); err != nil || store.reads != 3 {
+		t.Errorf("an expired entry was not read again (%d reads, %v)", store.reads, err)
+	}
+
+	// A write, here through a Refresh provider as vault push uses, drops
+	// the entry.
+	if err := cache.Refresh(cfg, store).SetSecret(ctx, "app/prod", "k2"); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := p.GetSecret(ctx, "app/prod"); v != "k2" {
+		t.Errorf("GetSecret after a push = %q; want the pushed value", v)
+	}
+
+	// Refresh always reads and updates what cached reads see.
+	store.secrets["app/prod"] = "k3"
+	reads := store.reads
+	if v, _ := cache.Refresh(cfg, store).GetSecret(ctx, "app/prod"); v != "k3" || store.reads != reads+1 {
+		t.Errorf("Refresh read %q with %d reads; want the vault's value", v, store.reads-reads)
+	}
+	if v, _ := p.GetSecret(ctx, "app/prod"); v != "k3" {
+		t.Errorf("GetSecret after a refresh = %q", v)
+	}
+
+	// Missing secrets are not cached.
+	for range 2 {
+		if _, err := p.GetSecret(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("missing secret: %v", err)
+		}
+	}
+	if store.reads != reads+3 {
+		t.Errorf("a missing secret was cached")
+	}
+}
+
+func TestCacheDisk(t *testing.T) {
+	now := fakeClock(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+	keyPath := filepath.Join(t.TempDir(), "vault-cache.key")
+	store := &countingProvider{secrets: map[string]string{"app/prod": "DOTENV_PRIVATE_KEY_PRODUCTION=secret-key"}}
+	cfg := Config{Provider: "aws"}
+
+	first := &Cache{TTL: time.Minute, Dir: dir, KeyPath: keyPath}
+	if _, err := first.Wrap(cfg, store).GetSecret(ctx, "app/prod"); err != nil {
+		t.Fatal(err)
+	}
+
+	// A later command reads the entry from disk.
+	second := &Cache{TTL: time.Minute, Dir: dir, KeyPath: keyPath}
+	if v, err := second.Wrap(cfg, store).GetSecret(ctx, "app/prod"); err != nil || v != store.secrets["app/prod"] || store.reads != 1 {
+		t.Errorf("disk cache: %q, %v after %d reads", v, err, store.reads)
+	}
+
+	files, _ := os.ReadDir(dir)
+	if len(files) != 1 {
+		t.Fatalf("%d cache files; want 1", len(files))
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, files[0].Name()))
+	if strings.Contains(string(data), "secret-key") || strings.Contains(files[0].Name(), "app") {
+		t.Error("the disk cache holds the secret or its name in the clear")
+	}
+	if info, err := os.Stat(keyPath); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("cache key: %v, %v", info, err)
+	}
+
+	// Under another key the entry does not open and is a miss.
+	foreign := &Cache{TTL: time.Minute, Dir: dir, KeyPath: filepath.Join(t.TempDir(), "other.key")}
+	if err := os.WriteFile(foreign.KeyPath, []byte(strings.Repeat("ab", 32)), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := foreign.Wrap(cfg, store).GetSecret(ctx, "app/prod"); err != nil || store.reads != 2 {
+		t.Errorf("an entry sealed under another key was used (%d reads, %v)", store.reads, err)
+	}
+
+	*now = now.Add(2 * time.Minute)
+	third := &Cache{TTL: time.Minute, Dir: dir, KeyPath: keyPath}
+	if _, err := third.Wrap(cfg, store).GetSecret(ctx, "app/prod"); err != nil || store.reads != 3 {
+		t.Errorf("an expired disk entry was used (%d reads, %v)", store.reads, err)
+	}
+}
+
+func TestCacheOff(t *testing.T) {
+	store := &countingProvider{secrets: map[string]string{"s": "v"}}
+	p := (&Cache{}).Wrap(Config{}, store)
+	for range 2 {
+		if _, err := p.GetSecret(context.Background(), "s"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if store.reads != 2 {
+		t.Errorf("a zero TTL cached a secret")
+	}
+}
@@ -0,0 +1,385 @@
+package vault
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// GCPConfig configures the Google Secret Manager provider ([vault.gcp]).
+type GCPConfig struct {
+	ProjectID string
+}
+
+const gcpScope = "https://www.googleapis.com/auth/cloud-platform"
+
+var (
+	// gcpEndpoint is the Secret Manager API base URL.
+	gcpEndpoint = "https://secretmanager.googleapis.com/v1"
+
+	// gcpMetadataHost is the GCE metadata server (GCE_METADATA_HOST overrides
+	// it, as in Google's client libraries).
+	gcpMetadataHost = "metadata.google.internal"
+)
+
+// gcpQualifiedName matches projects/<P>/secrets/<S>[/versions/<V>].
+var gcpQualifiedName = regexp.MustCompile(`^projects/([^/]+)/secrets/([^/]+)(?:/versions/([^/]+))?$`)
+
+// GCP stores secrets in Google Secret Manager over its REST API.
+//
+// Credentials are Application Default Credentials, looked up in the same
+// order as Google's libraries (and so the Python CLI): the file named by
+// GOOGLE_APPLICATION_CREDENTIALS, gcloud's application-default login, then
+// the metadata server on GCE/GKE/Cloud Run.
+type GCP struct {
+	cfg GCPConfig
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+// NewGCP returns a Google Secret Manager provider.
+func NewGCP(cfg GCPConfig) *GCP {
+	return &GCP{cfg: cfg}
+}
+
+// Name implements Provider.
+func (g *GCP) Name() string { return "gcp" }
+
+// GetSecret implements Provider. name is a bare secret ID (latest version
+// in the configured project) or projects/<P>/secrets/<S>[/versions/<V>].
+func (g *GCP) GetSecret(ctx context.Context, name string) (string, error) {
+	project, secret, version, err := g.resolve(name)
+	if err != nil {
+		return "", err
+	}
+	if version == "" {
+		version = "latest"
+	}
+	var resp struct {
+		Payload struct {
+			Data string `json:"data"`
+		} `json:"payload"`
+	}
+	path := "projects/" + project + "/secrets/" + secret + "/versions/" + version + ":access"
+	if err := g.call(ctx, http.MethodGet, path, nil, &resp); err != nil {
+		return "", err
+	}
+	data, err := base64.StdEncoding.DecodeString(resp.Payload.Data)
+	if err != nil {
+		return "", fmt.Errorf("gcp: decode %s: %w", name, err)
+	}
+	return string(data), nil
+}
+
+// DescribeSecret implements Describer from the version resource (name and
+// createTime), without accessing its payload.
+func (g *GCP) DescribeSecret(ctx context.Context, name string) (SecretInfo, error) {
+	project, secret, version, err := g.resolve(name)
+	if err != nil {
+		return SecretInfo{}, err
+	}
+	if version == "" {
+		version = "latest"
+	}
+	var resp struct {
+		Name       string    `json:"name"`
+		CreateTime time.Time `json:"createTime"`
+	}
+	path := "projects/" + project + "/secrets/" + secret + "/versions/" + version
+	if err := g.call(ctx, http.MethodGet, path, nil, &resp); err != nil {
+		return SecretInfo{}, err
+	}
+	return SecretInfo{Version: resp.Name[strings.LastIndex(resp.Name, "/")+1:], Updated: resp.CreateTime}, nil
+}
+
+// SetSecret implements Provider: it creates the secret (automatic
+// replication) when missing and adds a new version holding value.
+func (g *GCP) SetSecret(ctx context.Context, name, value string) error {
+	project, secret, version, err := g.resolve(name)
+	if err != nil {
+		return err
+	}
+	if version != "" {
+		return fmt.Errorf("gcp: cannot write to a specific version (%s)", name)
+	}
+	body := map[string]any{"payload": map[string]string{
+		"data": base64.StdEncoding.EncodeToString([]byte(value)),
+	}}
+	secretPath := "projects/" + project + "/secrets/" + secret
+	err = g.call(ctx, http.MethodPost, secretPath+":addVersion", body, nil)
+	if !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	create := map[string]any{"replication": map[string]any{"automatic": map[string]any{}}}
+	if err := g.call(ctx, http.MethodPost, "projects/"+project+"/secrets?secretId="+url.QueryEscape(secret), create, nil); err != nil {
+		return err
+	}
+	return g.call(ctx, http.MethodPost, secretPath+":addVersion", body, nil)
+}
+
+// resolve splits name into project, secret and optional version. A
+// fully-qualified name must target the configured project, like the CLI's
+// cross-project guard.
+func (g *GCP) resolve(name string) (project, secret, version string, err error) {
+	if g.cfg.ProjectID == "" {
+		return "", "", "", errors.New("gcp vault provider needs [vault.gcp] project_id")
+	}
+	if !strings.HasPrefix(name, "projects/") {
+		if name == "" || strings.Contains(name, "/") {
+			return "", "", "", fmt.Errorf("gcp: invalid secret name %q", name)
+		}
+		return g.cfg.ProjectID, name, "", nil
+	}
+	m := gcpQualifiedName.FindStringSubmatch(name)
+	if m == nil {
+		return "", "", "", fmt.Errorf("gcp: malformed secret resource name %q", name)
+	}
+	if m[1] != g.cfg.ProjectID {
+		return "", "", "", fmt.Errorf("gcp: secret %q targets project %q, but [vault.gcp] project_id is %q",
+			name, m[1], g.cfg.ProjectID)
+	}
+	return m[1], m[2], m[3], nil
+}
+
+// call sends an authenticated Secret Manager request.
+func (g *GCP) call(ctx context.Context, method, path string, body, out any) error {
+	token, err := g.accessToken(ctx)
+	if err != nil {
+		return err
+	}
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, gcpEndpoint+"/"+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("gcp secret manager: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return fmt.Errorf("gcp: %w", ErrNotFound)
+	case resp.StatusCode >= 300:
+		return fmt.Errorf("gcp secret manager %s: %s", path, gcpErrorMessage(resp.Status, data))
+	}
+	if out != nil {
+		if err := json.Unmarshal(data, out); err != nil {
+			return fmt.Errorf("gcp secret manager %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// gcpErrorMessage extracts error.message from a Google API error body.
+func gcpErrorMessage(status string, body []byte) string {
+	var e struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(body, &e) == nil && e.Error.Message != "" {
+		return status + ": " + e.Error.Message
+	}
+	return status
+}
+
+// accessToken returns a cached token or obtains one from ADC.
+func (g *GCP) accessToken(ctx context.Context) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.token != "" && time.Until(g.expires) > time.Minute {
+		return g.token, nil
+	}
+
+	token, expires, err := adcToken(ctx)
+	if err != nil {
+		return "", err
+	}
+	g.token, g.expires = token, expires
+	return token, nil
+}
+
+// adcCredentials is the subset of an ADC JSON file the agent understands.
+type adcCredentials struct {
+	Type         string `json:"type"`
+	ClientEmail  string `json:"client_email"`
+	PrivateKey   string `json:"private_key"`
+	PrivateKeyID string `json:"private_key_id"`
+	TokenURI     string `json:"token_uri"`
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// adcToken walks the Application Default Credentials order.
+func adcToken(ctx context.Context) (string, time.Time, error) {
+	if path := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS"); path != "" {
+		return tokenFromADCFile(ctx, path)
+	}
+	if path := wellKnownADCFile(); path != "" {
+		if _, err := os.Stat(path); err == nil {
+			return tokenFromADCFile(ctx, path)
+		}
+	}
+	token, expires, err := metadataToken(ctx)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("no Google credentials found (set GOOGLE_APPLICATION_CREDENTIALS or run 'gcloud auth application-default login'): %w", err)
+	}
+	return token, expires, nil
+}
+
+// wellKnownADCFile is where `gcloud auth application-default login` writes.
+func wellKnownADCFile() string {
+	dir := os.Getenv("CLOUDSDK_CONFIG")
+	if dir == "" {
+		if runtime.GOOS == "windows" {
+			dir = filepath.Join(os.Getenv("APPDATA"), "gcloud")
+		} else {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return ""
+			}
+			dir = filepath.Join(home, ".config", "gcloud")
+		}
+	}
+	return filepath.Join(dir, "application_default_credentials.json")
+}
+
+// tokenFromADCFile exchanges a service account key or a gcloud user login
+// for an access token.
+func tokenFromADCFile(ctx context.Context, path string) (string, time.Time, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("read Google credentials: %w", err)
+	}
+	var creds adcCredentials
+	if err := json.Unmarshal(data, &creds); err != nil {
+		return "", time.Time{}, fmt.Errorf("parse Google credentials %s: %w", path, err)
+	}
+	tokenURI := creds.TokenURI
+	if tokenURI == "" {
+		tokenURI = "https://oauth2.googleapis.com/token"
+	}
+
+	var form url.Values
+	switch creds.Type {
+	case "service_account":
+		assertion, err := serviceAccountJWT(creds, tokenURI)
+		if err != nil {
+			return "", time.Time{}, err
+		}
+		form = url.Values{
+			"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+			"assertion":  {assertion},
+		}
+	case "authorized_user":
+		form = url.Values{
+			"grant_type":    {"refresh_token"},
+			"client_id":     {creds.ClientID},
+			"client_secret": {creds.ClientSecret},
+			"refresh_token": {creds.RefreshToken},
+		}
+	default:
+		return "", time.Time{}, fmt.Errorf("unsupported Google credential type %q in %s", creds.Type, path)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return fetchToken(httpClient, req)
+}
+
+// serviceAccountJWT builds the RS256-signed assertion for the JWT bearer
+// grant.
+func serviceAccountJWT(creds adcCredentials, aud string) (string, error) {
+	block, _ := pem.Decode([]byte(creds.PrivateKey))
+	if block == nil {
+		return "", errors.New("service account private_key is not PEM")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		if parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes); err != nil {
+			return "", fmt.Errorf("parse service account private_key: %w", err)
+		}
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return "", errors.New("service account private_key is not an RSA key")
+	}
+
+	now := time.Now()
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT", "kid": creds.PrivateKeyID})
+	claims, _ := json.Marshal(map[string]any{
+		"iss":   creds.ClientEmail,
+		"scope": gcpScope,
+		"aud":   aud,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	enc := base64.RawURLEncoding
+	signing := enc.EncodeToString(header) + "." + enc.EncodeToString(claims)
+	sum := sha256.Sum256([]byte(signing))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, sum[:])
+	if err != nil {
+		return "", err
+	}
+	return signing + "." + enc.EncodeToString(sig), nil
+}
+
+// metadataToken asks the metadata server for the attached service
+// account's token.
+func metadataToken(ctx context.Context) (string, time.Time, error) {
+	host := os.Getenv("GCE_METADATA_HOST")
+	if host == "" {
+		host = gcpMetadataHost
+	}
+	u := "http://" + host + "/computeMetadata/v1/instance/service-accounts/default/token?scopes=" + url.QueryEscape(gcpScope)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	probe := *httpClient
+	probe.Timeout = imdsTimeout
+	return fetchToken(&probe, req)
+}
@@ -0,0 +1,201 @@
+package vault
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeGCP serves an OAuth token endpoint and the Secret Manager API.
+type fakeGCP struct {
+	*httptest.Server
+	key      *rsa.PrivateKey
+	versions map[string][]string // secret path -> payloads
+	grants   []string
+}
+
+func newFakeGCP(t *testing.T) *fakeGCP {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeGCP{key: key, versions: map[string][]string{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		f.grants = append(f.grants, r.PostForm.Get("grant_type"))
+		if a := r.PostForm.Get("assertion"); a != "" && !f.verifyJWT(a) {
+			http.Error(w, `{"error_description":"bad signature"}`, http.StatusBadRequest)
+			return
+		}
+		_, _ = io.WriteString(w, `{"access_token":"gcp-token","expires_in":3600}`)
+	})
+	mux.HandleFunc("/v1/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer gcp-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		path := strings.TrimPrefix(r.URL.Path, "/v1/")
+		switch {
+		case strings.HasSuffix(path, ":access"):
+			secret, version, _ := strings.Cut(strings.TrimSuffix(path, ":access"), "/versions/")
+			vs := f.versions[secret]
+			if len(vs) == 0 {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = io.WriteString(w, `{"error":{"message":"Secret not found"}}`)
+				return
+			}
+			data := vs[len(vs)-1]
+			if version != "latest" {
+				data = vs[0]
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"payload": map[string]string{
+				"data": base64.StdEncoding.EncodeToString([]byte(data)),
+			}})
+		case strings.HasSuffix(path, ":addVersion"):
+			secret := strings.TrimSuffix(path, ":addVersion")
+			if _, ok := f.versions[secret]; !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			var body struct {
+				Payload struct{ Data string } `json:"payload"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			data, _ := base64.StdEncoding.DecodeString(body.Payload.Data)
+			f.versions[secret] = append(f.versions[secret], string(data))
+			_, _ = io.WriteString(w, `{}`)
+		case strings.HasSuffix(path, "/secrets"):
+			f.versions[path+"/"+r.URL.Query().Get("secretId")] = []string{}
+			_, _ = io.WriteString(w, `{}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	f.Server = httptest.NewServer(mux)
+	t.Cleanup(f.Close)
+
+	origClient, origEndpoint := httpClient, gcpEndpoint
+	httpClient, gcpEndpoint = f.Client(), f.URL+"/v1"
+	t.Cleanup(func() { httpClient, gcpEndpoint = origClient, origEndpoint })
+	t.Setenv("CLOUDSDK_CONFIG", t.TempDir())
+	t.Setenv("GCE_METADATA_HOST", strings.TrimPrefix(f.URL, "http://")+"/no-metadata")
+	return f
+}
+
+func (f *fakeGCP) verifyJWT(token string) bool {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return false
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return false
+	}
+	sum := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	return rsa.VerifyPKCS1v15(&f.key.PublicKey, crypto.SHA256, sum[:], sig) == nil
+}
+
+// serviceAccountFile writes a service account key file for f's key.
+func (f *fakeGCP) serviceAccountFile(t *testing.T) string {
+	t.Helper()
+	der, _ := x509.MarshalPKCS8PrivateKey(f.key)
+	creds, _ := json.Marshal(map[string]string{
+		"type":         "service_account",
+		"client_email": "agent@proj.iam.gserviceaccount.com",
+		"private_key":  string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		"token_uri":    f.URL + "/token",
+	})
+	path := filepath.Join(t.TempDir(), "sa.json")
+	if err := os.WriteFile(path, creds, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestGCPServiceAccountRoundTrip(t *testing.T) {
+	f := newFakeGCP(t)
+	t.Setenv("GOOGLE_APPLICATION_CREDENTIALS", f.serviceAccountFile(t))
+	g := NewGCP(GCPConfig{ProjectID: "proj"})
+	ctx := context.Background()
+
+	if _, err := g.GetSecret(ctx, "api-key"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("missing secret should be ErrNotFound, got %v", err)
+	}
+	if err := g.SetSecret(ctx, "api-key", "v1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.SetSecret(ctx, "api-key", "v2"); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := g.GetSecret(ctx, "api-key"); err != nil || got != "v2" {
+		t.Errorf("latest = %q, %v", got, err)
+	}
+	if got, err := g.GetSecret(ctx, "projects/proj/secrets/api-key/versions/1"); err != nil || got != "v1" {
+		t.Errorf("pinned version = %q, %v", got, err)
+	}
+	if len(f.grants) != 1 || f.grants[0] != "urn:ietf:params:oauth:grant-type:jwt-bearer" {
+		t.Errorf("token grants = %v; want one cached JWT bearer grant", f.grants)
+	}
+}
+
+func TestGCPAuthorizedUserFromWellKnownFile(t *testing.T) {
+	f := newFakeGCP(t)
+	t.Setenv("GOOGLE_APPLICATION_CREDENTIALS", "")
+	creds := `{"type":"authorized_user","client_id":"c","client_secret":"s","refresh_token":"r","token_uri":"` + f.URL + `/token"}`
+	if err := os.WriteFile(filepath.Join(os.Getenv("CLOUDSDK_CONFIG"), "application_default_credentials.json"), []byte(creds), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	f.versions["projects/proj/secrets/k"] = []string{"abc"}
+
+	if got, err := NewGCP(GCPConfig{ProjectID: "proj"}).GetSecret(context.Background(), "k"); err != nil || got != "abc" {
+		t.Fatalf("GetSecret = %q, %v", got, err)
+	}
+	if f.grants[0] != "refresh_token" {
+		t.Errorf("grant = %q, want refresh_token", f.grants[0])
+	}
+}
+
+func TestGCPNameValidation(t *testing.T) {
+	newFakeGCP(t)
+	g := NewGCP(GCPConfig{ProjectID: "proj"})
+	for name, want := range map[string]string{
+		"projects/other/secrets/k": "targets project",
+		"projects/proj/other/k":    "malformed",
+		"a/b":                      "invalid secret name",
+	} {
+		if _, err := g.GetSecret(context.Background(), name); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("GetSecret(%q) error = %v, want %q", name, err, want)
+		}
+	}
+	if err := g.SetSecret(context.Background(), "projects/proj/secrets/k/versions/3", "v"); err == nil {
+		t.Error("writing to a pinned version should fail")
+	}
+	if _, err := NewGCP(GCPConfig{}).GetSecret(context.Background(), "k"); err == nil || !strings.Contains(err.Error(), "project_id") {
+		t.Errorf("missing project should name project_id, got %v", err)
+	}
+}
+
+func TestGCPNoCredentials(t *testing.T) {
+	newFakeGCP(t)
+	t.Setenv("GOOGLE_APPLICATION_CREDENTIALS", "")
+	_, err := NewGCP(GCPConfig{ProjectID: "proj"}).GetSecret(context.Background(), "k")
+	if err == nil || !strings.Contains(err.Error(), "application-default login") {
+		t.Errorf("expected an ADC hint, got %v", err)
+	}
+}
@@ -0,0 +1,389 @@
+package vault
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HashicorpConfig configures the HashiCorp Vault provider ([vault.hashicorp]).
+// Credentials never come from the config file: the token is VAULT_TOKEN and
+// the AppRole secret ID is VAULT_SECRET_ID, as in the Python CLI.
+type HashicorpConfig struct {
+	// URL is the Vault address; VAULT_ADDR when empty.
+	URL string
+	// MountPoint is the KV engine mount (default "secret").
+	MountPoint string
+	// KVVersion is 1 or 2; 0 detects it from the mount's options.
+	KVVersion int
+	// Namespace is the Vault Enterprise namespace; VAULT_NAMESPACE when empty.
+	Namespace string
+	// AuthMethod is "token" (default) or "approle".
+	AuthMethod string
+	// RoleID is the AppRole role ID; VAULT_ROLE_ID when empty.
+	RoleID string
+	// AppRoleMount is the AppRole auth mount (default "approle").
+	AppRoleMount string
+}
+
+// renewBefore is how close to expiry a token is renewed (or, for AppRole,
+// replaced by a fresh login).
+const renewBefore = time.Minute
+
+// Hashicorp stores secrets in a HashiCorp Vault KV engine over its HTTP API.
+type Hashicorp struct {
+	cfg HashicorpConfig
+
+	mu        sync.Mutex
+	token     string
+	renewable bool
+	expires   time.Time // zero for tokens without a TTL (e.g. root)
+	kvVersion int
+}
+
+// NewHashicorp returns a HashiCorp Vault provider, filling unset fields from
+// the standard VAULT_* environment variables and defaults.
+func NewHashicorp(cfg HashicorpConfig) *Hashicorp {
+	if cfg.URL == "" {
+		cfg.URL = os.Getenv("VAULT_ADDR")
+	}
+	cfg.URL = strings.TrimRight(cfg.URL, "/")
+	if cfg.MountPoint == "" {
+		cfg.MountPoint = "secret"
+	}
+	cfg.MountPoint = strings.Trim(cfg.MountPoint, "/")
+	if cfg.Namespace == "" {
+		cfg.Namespace = os.Getenv("VAULT_NAMESPACE")
+	}
+	if cfg.AuthMethod == "" {
+		cfg.AuthMethod = "token"
+	}
+	if cfg.RoleID == "" {
+		cfg.RoleID = os.Getenv("VAULT_ROLE_ID")
+	}
+	if cfg.AppRoleMount == "" {
+		cfg.AppRoleMount = "approle"
+	}
+	return &Hashicorp{cfg: cfg, kvVersion: cfg.KVVersion}
+}
+
+// Name implements Provider.
+func (h *Hashicorp) Name() string { return "hashicorp" }
+
+// GetSecret implements Provider. A KV entry with a single "value" field
+// returns that field; one with a single DOTENV_PRIVATE_KEY_* field returns
+// the KEY=value line; anything else is returned as JSON for KeyMaterial.
+func (h *Hashicorp) GetSecret(ctx context.Context, name string) (string, error) {
+	version, err := h.engineVersion(ctx)
+	if err != nil {
+		return "", err
+	}
+	var resp struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := h.call(ctx, http.MethodGet, h.secretPath(version, name), nil, &resp); err != nil {
+		return "", err
+	}
+
+	data := resp.Data
+	if version == 2 {
+		var inner struct {
+			Data json.RawMessage `json:"data"`
+		}
+		if err := json.Unmarshal(resp.Data, &inner); err != nil {
+			return "", fmt.Errorf("hashicorp: decode %s: %w", name, err)
+		}
+		data = inner.Data
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil || fields == nil {
+		// KV v2 returns data: null for a deleted (soft-deleted) version.
+		return "", fmt.Errorf("hashicorp: %w", ErrNotFound)
+	}
+	return kvValue(fields), nil
+}
+
+// SetSecret implements Provider, storing value under the "value" field.
+func (h *Hashicorp) SetSecret(ctx context.Context, name, value string) error {
+	version, err := h.engineVersion(ctx)
+	if err != nil {
+		return err
+	}
+	var body any = map[string]string{"value": value}
+	if version == 2 {
+		body = map[string]any{"data": body}
+	}
+	return h.call(ctx, http.MethodPost, h.secretPath(version, name), body, nil)
+}
+
+// DescribeSecret implements Describer from the KV v2 metadata endpoint; KV v1
+// keeps no versions or timestamps, so it reports nothing.
+func (h *Hashicorp) DescribeSecret(ctx context.Context, name string) (SecretInfo, error) {
+	version, err := h.engineVersion(ctx)
+	if err != nil || version != 2 {
+		return SecretInfo{}, err
+	}
+	var resp struct {
+		Data struct {
+			CurrentVersion int       `json:"current_version"`
+			CreatedTime    time.Time `json:"created_time"`
+			UpdatedTime    time.Time `json:"updated_time"`
+		} `json:"data"`
+	}
+	path := h.cfg.MountPoint + "/metadata/" + strings.TrimLeft(name, "/")
+	if err := h.call(ctx, http.MethodGet, path, nil, &resp); err != nil {
+		return SecretInfo{}, err
+	}
+	info := SecretInfo{Created: resp.Data.CreatedTime, Updated: resp.Data.UpdatedTime}
+	if resp.Data.CurrentVersion > 0 {
+		info.Version = strconv.Itoa(resp.Data.CurrentVersion)
+	}
+	return info, nil
+}
+
+func (h *Hashicorp) secretPath(version int, name string) string {
+	name = strings.TrimLeft(name, "/")
+	if version == 2 {
+		return h.cfg.MountPoint + "/data/" + name
+	}
+	return h.cfg.MountPoint + "/" + name
+}
+
+// engineVersion returns the configured KV version or detects it from the
+// mount; a mount the token may not inspect is assumed to be KV v2, the
+// default engine of current Vault servers.
+func (h *Hashicorp) engineVersion(ctx context.Context) (int, error) {
+	h.mu.Lock()
+	version := h.kvVersion
+	h.mu.Unlock()
+	if version == 1 || version == 2 {
+		return version, nil
+	}
+	if version != 0 {
+		return 0, fmt.Errorf("hashicorp: kv_version must be 1 or 2, got %d", version)
+	}
+
+	var resp struct {
+		Data struct {
+			Options map[string]string `json:"options"`
+		} `json:"data"`
+	}
+	version = 2
+	if err := h.call(ctx, http.MethodGet, "sys/internal/ui/mounts/"+h.cfg.MountPoint, nil, &resp); err == nil {
+		if resp.Data.Options["version"] == "1" || resp.Data.Options["version"] == "" {
+			version = 1
+		}
+	} else if !errors.Is(err, ErrNotFound) && !strings.Contains(err.Error(), "permission denied") {
+		return 0, err
+	}
+
+	h.mu.Lock()
+	h.kvVersion = version
+	h.mu.Unlock()
+	return version, nil
+}
+
+// call sends an authenticated request to /v1/<path>, decoding the JSON
+// response into out when non-nil.
+func (h *Hashicorp) call(ctx context.Context, method, path string, body, out any) error {
+	if h.cfg.URL == "" {
+		return errors.New("hashicorp vault address not set ([vault.hashicorp] url or VAULT_ADDR)")
+	}
+	token, err := h.ensureToken(ctx)
+	if err != nil {
+		return err
+	}
+	return h.request(ctx, method, path, token, body, out)
+}
+
+// request is call without token management (used by the auth calls).
+func (h *Hashicorp) request(ctx context.Context, method, path, token string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, h.cfg.URL+"/v1/"+path, reader)
+	if err != nil {
+		return err
+	}
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	if h.cfg.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", h.cfg.Namespace)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("hashicorp vault: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return fmt.Errorf("hashicorp: %w", ErrNotFound)
+	case resp.StatusCode >= 300:
+		return fmt.Errorf("hashicorp vault %s %s: %s", method, path, vaultErrors(resp.Status, data))
+	}
+	if out != nil && len(data) > 0 {
+		if err := json.Unmarshal(data, out); err != nil {
+			return fmt.Errorf("hashicorp vault %s %s: %w", method, path, err)
+		}
+	}
+	return nil
+}
+
+// vaultErrors joins Vault's {"errors": [...]} list, falling back to the
+// HTTP status.
+func vaultErrors(status string, body []byte) string {
+	var e struct {
+		Errors []string `json:"errors"`
+	}
+	if json.Unmarshal(body, &e) == nil && len(e.Errors) > 0 {
+		return status + ": " + strings.Join(e.Errors, "; ")
+	}
+	return status
+}
+
+// authResponse is the "auth" block of login and renew-self responses.
+type authResponse struct {
+	Auth struct {
+		ClientToken   string `json:"client_token"`
+		LeaseDuration int64  `json:"lease_duration"`
+		Renewable     bool   `json:"renewable"`
+	} `json:"auth"`
+}
+
+// ensureToken returns a usable token, logging in on first use and renewing
+// a renewable token that is about to expire. An AppRole token that can no
+// longer be renewed is replaced by a fresh login.
+func (h *Hashicorp) ensureToken(ctx context.Context) (string, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.token == "" {
+		if err := h.login(ctx); err != nil {
+			return "", err
+		}
+	}
+	if h.expires.IsZero() || time.Until(h.expires) > renewBefore {
+		return h.token, nil
+	}
+
+	if h.renewable && time.Now().Before(h.expires) {
+		var auth authResponse
+		if err := h.request(ctx, http.MethodPost, "auth/token/renew-self", h.token, map[string]string{}, &auth); err == nil {
+			h.setAuth(auth, h.token)
+			return h.token, nil
+		}
+	}
+	if h.cfg.AuthMethod == "approle" {
+		if err := h.login(ctx); err != nil {
+			return "", err
+		}
+	}
+	return h.token, nil
+}
+
+// login obtains the initial token for the configured auth method.
+func (h *Hashicorp) login(ctx context.Context) error {
+	switch h.cfg.AuthMethod {
+	case "token":
+		token := os.Getenv("VAULT_TOKEN")
+		if token == "" {
+			return errors.New("no Vault token provided. Set the VAULT_TOKEN environment variable")
+		}
+		h.token = token
+		// Learn the TTL so renewal can kick in; a token that may not look
+		// itself up is simply used as-is.
+		var self struct {
+			Data struct {
+				TTL       int64 `json:"ttl"`
+				Renewable bool  `json:"renewable"`
+			} `json:"data"`
+		}
+		if err := h.request(ctx, http.MethodGet, "auth/token/lookup-self", token, nil, &self); err == nil && self.Data.TTL > 0 {
+			h.renewable = self.Data.Renewable
+			h.expires = time.Now().Add(time.Duration(self.Data.TTL) * time.Second)
+		}
+		return nil
+
+	case "approle":
+		secretID := os.Getenv("VAULT_SECRET_ID")
+		if h.cfg.RoleID == "" || secretID == "" {
+			return errors.New("approle auth needs a role ID ([vault.hashicorp] role_id or VAULT_ROLE_ID) and VAULT_SECRET_ID")
+		}
+		var auth authResponse
+		err := h.request(ctx, http.MethodPost, "auth/"+strings.Trim(h.cfg.AppRoleMount, "/")+"/login", "",
+			map[string]string{"role_id": h.cfg.RoleID, "secret_id": secretID}, &auth)
+		if err != nil {
+			return fmt.Errorf("approle login: %w", err)
+		}
+		if auth.Auth.ClientToken == "" {
+			return errors.New("approle login returned no token")
+		}
+		h.setAuth(auth, "")
+		return nil
+
+	default:
+		return fmt.Errorf("unsupported hashicorp auth_method %q (want token or approle)", h.cfg.AuthMethod)
+	}
+}
+
+// setAuth records a login or renewal response; renewals keep the current
+// token when the response omits it.
+func (h *Hashicorp) setAuth(auth authResponse, current string) {
+	h.token = auth.Auth.ClientToken
+	if h.token == "" {
+		h.token = current
+	}
+	h.renewable = auth.Auth.Renewable
+	h.expires = time.Time{}
+	if auth.Auth.LeaseDuration > 0 {
+		h.expires = time.Now().Add(time.Duration(auth.Auth.LeaseDuration) * time.Second)
+	}
+}
+
+// kvValue renders KV data as one string, mirroring the CLI's
+// _coerce_secret_value.
+func kvValue(fields map[string]any) string {
+	if v, ok := fields["value"]; ok && len(fields) == 1 {
+		if s, ok := v.(string); ok {
+			return s
+		}
+		b, _ := json.Marshal(v)
+		return string(b)
+	}
+	var line string
+	matches := 0
+	for k, v := range fields {
+		if s, ok := v.(string); ok && privateKeyLine.MatchString(k+"="+s) && !strings.ContainsAny(k, "=") {
+			line = k + "=" + s
+			matches++
+		}
+	}
+	if matches == 1 {
+		return line
+	}
+	b, _ := json.Marshal(fields)
+	return string(b)
+}
@@ -0,0 +1,126 @@
+//go:build integration
+
+// Integration tests against a dev-mode Vault server, e.g. the one in
+// tests/docker-compose.test.yml:
+//
+//	docker compose -f tests/docker-compose.test.yml up -d vault
+//	go test -tags integration ./internal/vault/
+//
+// VAULT_ADDR defaults to http://127.0.0.1:8200 and the root token to
+// test-root-token, matching that compose file.
+package vault
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+)
+
+func devVault(t *testing.T) (addr, root string) {
+	t.Helper()
+	addr, root = os.Getenv("VAULT_ADDR"), os.Getenv("VAULT_TOKEN")
+	if addr == "" {
+		addr = "http://127.0.0.1:8200"
+	}
+	if root == "" {
+		root = "test-root-token"
+	}
+	resp, err := http.Get(addr + "/v1/sys/health")
+	if err != nil {
+		t.Skipf("dev Vault not reachable at %s: %v", addr, err)
+	}
+	resp.Body.Close()
+	t.Setenv("VAULT_ADDR", addr)
+	t.Setenv("VAULT_TOKEN", root)
+	t.Setenv("VAULT_NAMESPACE", "")
+	return addr, root
+}
+
+// vaultAdmin issues a root-token request, ignoring "already exists" errors
+// so tests can be re-run against the same server.
+func vaultAdmin(t *testing.T, addr, root, method, path string, body any) map[string]any {
+	t.Helper()
+	payload, _ := json.Marshal(body)
+	req, _ := http.NewRequest(method, addr+"/v1/"+path, bytes.NewReader(payload))
+	req.Header.Set("X-Vault-Token", root)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var out map[string]any
+	_ = json.NewDecoder(resp.Body).Decode(&out)
+	return out
+}
+
+func TestIntegrationHashicorpKVv2(t *testing.T) {
+	devVault(t)
+	name := "envdrift-agent-it/" + time.Now().Format("150405.000000")
+	h := NewHashicorp(HashicorpConfig{})
+	ctx := context.Background()
+
+	if err := h.SetSecret(ctx, name, "DOTENV_PRIVATE_KEY_PRODUCTION=abc123"); err != nil {
+		t.Fatal(err)
+	}
+	raw, err := h.GetSecret(ctx, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key, err := KeyMaterial(raw, "DOTENV_PRIVATE_KEY_PRODUCTION"); err != nil || key != "abc123" {
+		t.Errorf("round trip = %q, %v", key, err)
+	}
+	if h.kvVersion != 2 {
+		t.Errorf("dev server's secret/ mount detected as kv v%d, want v2", h.kvVersion)
+	}
+}
+
+func TestIntegrationHashicorpKVv1(t *testing.T) {
+	addr, root := devVault(t)
+	vaultAdmin(t, addr, root, http.MethodPost, "sys/mounts/kv1-it", map[string]any{
+		"type": "kv", "options": map[string]string{"version": "1"},
+	})
+
+	h := NewHashicorp(HashicorpConfig{MountPoint: "kv1-it"})
+	ctx := context.Background()
+	if err := h.SetSecret(ctx, "app/key", "v1-value"); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := h.GetSecret(ctx, "app/key"); err != nil || got != "v1-value" {
+		t.Errorf("GetSecret = %q, %v", got, err)
+	}
+	if h.kvVersion != 1 {
+		t.Errorf("kv1-it detected as kv v%d, want v1", h.kvVersion)
+	}
+}
+
+func TestIntegrationHashicorpAppRole(t *testing.T) {
+	addr, root := devVault(t)
+	vaultAdmin(t, addr, root, http.MethodPost, "sys/auth/approle", map[string]string{"type": "approle"})
+	vaultAdmin(t, addr, root, http.MethodPut, "sys/policies/acl/envdrift-it", map[string]string{
+		"policy": `path "secret/data/envdrift-agent-it/*" { capabilities = ["create", "update", "read"] }`,
+	})
+	vaultAdmin(t, addr, root, http.MethodPost, "auth/approle/role/envdrift-it", map[string]any{
+		"token_policies": []string{"envdrift-it"}, "token_ttl": "1h",
+	})
+	roleID := vaultAdmin(t, addr, root, http.MethodGet, "auth/approle/role/envdrift-it/role-id", nil)
+	secretID := vaultAdmin(t, addr, root, http.MethodPost, "auth/approle/role/envdrift-it/secret-id", map[string]any{})
+	t.Setenv("VAULT_TOKEN", "")
+	t.Setenv("VAULT_ROLE_ID", roleID["data"].(map[string]any)["role_id"].(string))
+	t.Setenv("VAULT_SECRET_ID", secretID["data"].(map[string]any)["secret_id"].(string))
+
+	h := NewHashicorp(HashicorpConfig{AuthMethod: "approle", KVVersion: 2})
+	ctx := context.Background()
+	if err := h.SetSecret(ctx, "envdrift-agent-it/approle", "k"); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := h.GetSecret(ctx, "envdrift-agent-it/approle"); err != nil || got != "k" {
+		t.Errorf("GetSecret = %q, %v", got, err)
+	}
+	if !h.renewable || h.expires.IsZero() {
+		t.Errorf("approle token should be renewable with a TTL, got renewable=%v expires=%v", h.renewable, h.expires)
+	}
+}
@@ -0,0 +1,217 @@
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeHashicorp is a minimal Vault HTTP API: one KV mount at "secret/"
+// (v1 or v2), token lookup/renewal and AppRole login.
+type fakeHashicorp struct {
+	*httptest.Server
+	kvVersion string // "1" or "2"
+	ttl       int64  // lookup-self/login TTL in seconds
+	mu        sync.Mutex
+	kv        map[string]map[string]any
+	calls     []string // METHOD path [namespace]
+	renewals  int
+	logins    int
+}
+
+func newFakeHashicorp(t *testing.T, kvVersion string) *fakeHashicorp {
+	t.Helper()
+	f := &fakeHashicorp{kvVersion: kvVersion, kv: map[string]map[string]any{}}
+	f.Server = httptest.NewServer(http.HandlerFunc(f.serve))
+	t.Cleanup(f.Close)
+
+	orig := httpClient
+	httpClient = f.Client()
+	t.Cleanup(func() { httpClient = orig })
+	for _, k := range []string{"VAULT_ADDR", "VAULT_TOKEN", "VAULT_NAMESPACE", "VAULT_ROLE_ID", "VAULT_SECRET_ID"} {
+		t.Setenv(k, "")
+	}
+	return f
+}
+
+func (f *fakeHashicorp) serve(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	path := strings.TrimPrefix(r.URL.Path, "/v1/")
+	call := r.Method + " " + path
+	if ns := r.Header.Get("X-Vault-Namespace"); ns != "" {
+		call += " [" + ns + "]"
+	}
+	f.calls = append(f.calls, call)
+
+	var body map[string]any
+	_ = json.NewDecoder(r.Body).Decode(&body)
+	reply := func(v any) { _ = json.NewEncoder(w).Encode(v) }
+
+	switch {
+	case path == "auth/approle/login":
+		if body["role_id"] != "role" || body["secret_id"] != "sid" {
+			w.WriteHeader(http.StatusBadRequest)
+			reply(map[string]any{"errors": []string{"invalid role or secret ID"}})
+			return
+		}
+		f.logins++
+		reply(map[string]any{"auth": map[string]any{"client_token": "approle-token", "lease_duration": f.ttl, "renewable": true}})
+		return
+	case path == "auth/token/lookup-self":
+		reply(map[string]any{"data": map[string]any{"ttl": f.ttl, "renewable": f.ttl > 0}})
+		return
+	case path == "auth/token/renew-self":
+		f.renewals++
+		reply(map[string]any{"auth": map[string]any{"client_token": r.Header.Get("X-Vault-Token"), "lease_duration": 3600, "renewable": true}})
+		return
+	case path == "sys/internal/ui/mounts/secret":
+		opts := map[string]any{}
+		if f.kvVersion == "2" {
+			opts["version"] = "2"
+		}
+		reply(map[string]any{"data": map[string]any{"type": "kv", "options": opts}})
+		return
+	}
+
+	if r.Header.Get("X-Vault-Token") == "" {
+		w.WriteHeader(http.StatusForbidden)
+		reply(map[string]any{"errors": []string{"permission denied"}})
+		return
+	}
+	name := strings.TrimPrefix(path, "secret/")
+	if f.kvVersion == "2" {
+		if !strings.HasPrefix(name, "data/") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		name = strings.TrimPrefix(name, "data/")
+	}
+	switch r.Method {
+	case http.MethodGet:
+		data, ok := f.kv[name]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			reply(map[string]any{"errors": []string{}})
+			return
+		}
+		if f.kvVersion == "2" {
+			reply(map[string]any{"data": map[string]any{"data": data, "metadata": map[string]any{"version": 1}}})
+		} else {
+			reply(map[string]any{"data": data})
+		}
+	case http.MethodPost:
+		if f.kvVersion == "2" {
+			body, _ = body["data"].(map[string]any)
+		}
+		f.kv[name] = body
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func TestHashicorpKVVersions(t *testing.T) {
+	for _, version := range []string{"1", "2"} {
+		t.Run("v"+version, func(t *testing.T) {
+			f := newFakeHashicorp(t, version)
+			t.Setenv("VAULT_TOKEN", "root")
+			h := NewHashicorp(HashicorpConfig{URL: f.URL})
+			ctx := context.Background()
+
+			if _, err := h.GetSecret(ctx, "myapp/prod"); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("missing secret should be ErrNotFound, got %v", err)
+			}
+			if err := h.SetSecret(ctx, "myapp/prod", "abc123"); err != nil {
+				t.Fatal(err)
+			}
+			got, err := h.GetSecret(ctx, "myapp/prod")
+			if err != nil || got != "abc123" {
+				t.Fatalf("GetSecret = %q, %v", got, err)
+			}
+			if h.kvVersion != map[string]int{"1": 1, "2": 2}[version] {
+				t.Errorf("detected kv version %d, want %s", h.kvVersion, version)
+			}
+		})
+	}
+}
+
+func TestHashicorpNamespaceHeader(t *testing.T) {
+	f := newFakeHashicorp(t, "2")
+	t.Setenv("VAULT_TOKEN", "root")
+	t.Setenv("VAULT_ADDR", f.URL)
+	t.Setenv("VAULT_NAMESPACE", "from-env")
+
+	h := NewHashicorp(HashicorpConfig{Namespace: "team-a", KVVersion: 2})
+	if err := h.SetSecret(context.Background(), "k", "v"); err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range f.calls {
+		if !strings.HasSuffix(c, "[team-a]") {
+			t.Errorf("call %q missing the configured namespace", c)
+		}
+	}
+}
+
+func TestHashicorpAppRoleLoginAndRenewal(t *testing.T) {
+	f := newFakeHashicorp(t, "2")
+	f.ttl = 30 // inside renewBefore, so the next use renews
+	t.Setenv("VAULT_SECRET_ID", "sid")
+
+	h := NewHashicorp(HashicorpConfig{URL: f.URL, AuthMethod: "approle", RoleID: "role"})
+	ctx := context.Background()
+	if err := h.SetSecret(ctx, "k", "v"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := h.GetSecret(ctx, "k"); err != nil {
+		t.Fatal(err)
+	}
+	if f.logins != 1 || f.renewals == 0 {
+		t.Errorf("logins = %d, renewals = %d; want one login then renewal", f.logins, f.renewals)
+	}
+	if h.token != "approle-token" {
+		t.Errorf("token = %q", h.token)
+	}
+}
+
+func TestHashicorpAuthErrors(t *testing.T) {
+	f := newFakeHashicorp(t, "2")
+	if _, err := NewHashicorp(HashicorpConfig{URL: f.URL}).GetSecret(context.Background(), "k"); err == nil ||
+		!strings.Contains(err.Error(), "VAULT_TOKEN") {
+		t.Errorf("missing token should name VAULT_TOKEN, got %v", err)
+	}
+
+	t.Setenv("VAULT_SECRET_ID", "wrong")
+	_, err := NewHashicorp(HashicorpConfig{URL: f.URL, AuthMethod: "approle", RoleID: "role"}).GetSecret(context.Background(), "k")
+	if err == nil || !strings.Contains(err.Error(), "invalid role or secret ID") {
+		t.Errorf("bad approle login should surface Vault's error, got %v", err)
+	}
+	if strings.Contains(err.Error(), "wrong") {
+		t.Errorf("error leaks the secret ID: %v", err)
+	}
+
+	if _, err := NewHashicorp(HashicorpConfig{}).GetSecret(context.Background(), "k"); err == nil ||
+		!strings.Contains(err.Error(), "VAULT_ADDR") {
+		t.Errorf("missing address should name VAULT_ADDR, got %v", err)
+	}
+}
+
+func TestKVValue(t *testing.T) {
+	tests := []struct {
+		fields map[string]any
+		want   string
+	}{
+		{map[string]any{"value": "abc"}, "abc"},
+		{map[string]any{"value": 42.0}, "42"},
+		{map[string]any{"DOTENV_PRIVATE_KEY_PROD": "abc"}, "DOTENV_PRIVATE_KEY_PROD=abc"},
+		{map[string]any{"a": "1", "b": "2"}, `{"a":"1","b":"2"}`},
+	}
+	for _, tt := range tests {
+		if got := kvValue(tt.fields); got != tt.want {
+			t.Errorf("kvValue(%v) = %q, want %q", tt.fields, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package vault
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// KubernetesConfig configures the Kubernetes Secret provider
+// ([vault.kubernetes]). Empty fields fall back to kubectl's own defaults:
+// $KUBECONFIG or ~/.kube/config, its current context, and that context's
+// namespace.
+type KubernetesConfig struct {
+	Namespace  string
+	Context    string
+	Kubeconfig string
+}
+
+// Kubernetes stores keys in Kubernetes Secrets through `kubectl`, so every
+// kubeconfig auth plugin (exec credentials, OIDC, cloud IAM) works as it does
+// for the user.
+//
+// A DOTENV_PRIVATE_KEY_<ENV> line is stored under its own data key, so a
+// workload can consume the Secret directly (envFrom: secretRef) and one
+// Secret can hold the keys of several environments; any other value is
+// stored under "value".
+type Kubernetes struct {
+	cfg KubernetesConfig
+}
+
+// NewKubernetes returns a Kubernetes Secret provider.
+func NewKubernetes(cfg KubernetesConfig) *Kubernetes {
+	return &Kubernetes{cfg: cfg}
+}
+
+// Name implements Provider.
+func (k *Kubernetes) Name() string { return "kubernetes" }
+
+// k8sSecret is the part of a v1 Secret the provider reads and writes.
+type k8sSecret struct {
+	APIVersion string            `json:"apiVersion"`
+	Kind       string            `json:"kind"`
+	Metadata   k8sMeta           `json:"metadata"`
+	Type       string            `json:"type,omitempty"`
+	Data       map[string]string `json:"data"`
+}
+
+type k8sMeta struct {
+	Name              string            `json:"name"`
+	Namespace         string            `json:"namespace,omitempty"`
+	Labels            map[string]string `json:"labels,omitempty"`
+	ResourceVersion   string            `json:"resourceVersion,omitempty"`
+	CreationTimestamp *time.Time        `json:"creationTimestamp,omitempty"`
+}
+
+// GetSecret implements Provider. The data keys are rendered like a KV
+// entry: a lone "value" or DOTENV_PRIVATE_KEY_* key as its value or line,
+// several keys as a JSON document for KeyMaterial to pick from.
+func (k *Kubernetes) GetSecret(ctx context.Context, name string) (string, error) {
+	secret, err := k.get(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	fields := make(map[string]any, len(secret.Data))
+	for key, enc := range secret.Data {
+		v, err := base64.StdEncoding.DecodeString(enc)
+		if err != nil {
+			return "", fmt.Errorf("kubernetes: decode %s/%s: %w", name, key, err)
+		}
+		fields[key] = string(v)
+	}
+	if len(fields) == 0 {
+		return "", fmt.Errorf("kubernetes: secret %s has no data: %w", name, ErrNotFound)
+	}
+	return kvValue(fields), nil
+}
+
+// DescribeSecret implements Describer. Secrets carry no history, so the
+// version is the object's resourceVersion, which changes on every write.
+func (k *Kubernetes) DescribeSecret(ctx context.Context, name string) (SecretInfo, error) {
+	secret, err := k.get(ctx, name)
+	if err != nil {
+		return SecretInfo{}, err
+	}
+	info := SecretInfo{Version: secret.Metadata.ResourceVersion}
+	if secret.Metadata.CreationTimestamp != nil {
+		info.Created = *secret.Metadata.CreationTimestamp
+	}
+	return info, nil
+}
+
+// SetSecret implements Provider, creating the Secret or updating the one
+// data key the value belongs to (other keys are kept). The manifest goes to
+// `kubectl apply` on stdin, never in argv.
+func (k *Kubernetes) SetSecret(ctx context.Context, name, value string) error {
+	secret, err := k.get(ctx, name)
+	if errors.Is(err, ErrNotFound) {
+		secret = &k8sSecret{Type: "Opaque"}
+	} else if err != nil {
+		return err
+	}
+
+	field, v := "value", value
+	if m := privateKeyLine.FindStringSubmatch(strings.TrimSpace(value)); m != nil {
+		field, v = strings.ToUpper(m[1]), m[2]
+	}
+
+	secret.APIVersion, secret.Kind = "v1", "Secret"
+	secret.Metadata = k8sMeta{
+		Name:      name,
+		Namespace: k.cfg.Namespace,
+		Labels:    map[string]string{"app.kubernetes.io/managed-by": "envdrift-agent"},
+	}
+	if secret.Data == nil {
+		secret.Data = map[string]string{}
+	}
+	secret.Data[field] = base64.StdEncoding.EncodeToString([]byte(v))
+
+	manifest, err := json.Marshal(secret)
+	if err != nil {
+		return err
+	}
+	_, err = k.kubectl(ctx, manifest, "apply", "-f", "-")
+	return err
+}
+
+// get fetches the named Secret.
+func (k *Kubernetes) get(ctx context.Context, name string) (*k8sSecret, error) {
+	out, err := k.kubectl(ctx, nil, "get", "secret", name, "-o", "json")
+	if err != nil {
+		return nil, err
+	}
+	var secret k8sSecret
+	if err := json.Unmarshal(out, &secret); err != nil {
+		return nil, fmt.Errorf("kubernetes: decode secret %s: %w", name, err)
+	}
+	return &secret, nil
+}
+
+// kubectl runs kubectl with the configured kubeconfig, context and
+// namespace, mapping NotFound to ErrNotFound.
+func (k *Kubernetes) kubectl(ctx context.Context, stdin []byte, args ...string) ([]byte, error) {
+	var full []string
+	if k.cfg.Kubeconfig != "" {
+		full = append(full, "--kubeconfig", k.cfg.Kubeconfig)
+	}
+	if k.cfg.Context != "" {
+		full = append(full, "--context", k.cfg.Context)
+	}
+	if k.cfg.Namespace != "" {
+		full = append(full, "--namespace", k.cfg.Namespace)
+	}
+	full = append(full, args...)
+
+	stdout, stderr, err := runCLI(ctx, stdin, "kubectl", full...)
+	if err != nil {
+		msg := strings.TrimSpace(string(stderr))
+		var execErr *exec.Error
+		switch {
+		case errors.As(err, &execErr):
+			return nil, errors.New("kubectl not found. Install it: https://kubernetes.io/docs/tasks/tools/")
+		case strings.Contains(msg, "(NotFound)"):
+			return nil, fmt.Errorf("kubernetes: %w", ErrNotFound)
+		case msg != "":
+			return nil, fmt.Errorf("kubectl %s: %s", args[0], msg)
+		default:
+			return nil, fmt.Errorf("kubectl %s: %w", args[0], err)
+		}
+	}
+	return stdout, nil
+}
@@ -0,0 +1,113 @@
+package vault
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// fakeKubectl stores Secrets in memory behind the runCLI seam.
+func fakeKubectl(t *testing.T, secrets map[string]map[string]string) *[]cliCall {
+	t.Helper()
+	var calls *[]cliCall
+	calls = fakeCLI(t, func(args []string) (string, string, error) {
+		stdin := (*calls)[len(*calls)-1].stdin
+		for i, a := range args {
+			switch a {
+			case "get":
+				name := args[i+2]
+				data, ok := secrets[name]
+				if !ok {
+					return "", `Error from server (NotFound): secrets "` + name + `" not found`, errors.New("exit status 1")
+				}
+				enc := map[string]string{}
+				for k, v := range data {
+					enc[k] = base64.StdEncoding.EncodeToString([]byte(v))
+				}
+				out, _ := json.Marshal(map[string]any{"metadata": map[string]string{"name": name, "resourceVersion": "7"}, "data": enc})
+				return string(out), "", nil
+			case "apply":
+				var s k8sSecret
+				if err := json.Unmarshal([]byte(stdin), &s); err != nil {
+					return "", "bad manifest", err
+				}
+				data := map[string]string{}
+				for k, v := range s.Data {
+					b, _ := base64.StdEncoding.DecodeString(v)
+					data[k] = string(b)
+				}
+				secrets[s.Metadata.Name] = data
+				return "secret/" + s.Metadata.Name + " configured", "", nil
+			}
+		}
+		return "", "unexpected", errors.New("unexpected kubectl call")
+	})
+	return calls
+}
+
+func TestKubernetesSetAndGet(t *testing.T) {
+	secrets := map[string]map[string]string{}
+	calls := fakeKubectl(t, secrets)
+	k := NewKubernetes(KubernetesConfig{Namespace: "apps", Context: "prod-cluster", Kubeconfig: "/k/config"})
+	ctx := context.Background()
+
+	if _, err := k.GetSecret(ctx, "myapp-keys"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("missing secret should be ErrNotFound, got %v", err)
+	}
+	if err := k.SetSecret(ctx, "myapp-keys", "DOTENV_PRIVATE_KEY_PRODUCTION=abc"); err != nil {
+		t.Fatal(err)
+	}
+	if err := k.SetSecret(ctx, "myapp-keys", "DOTENV_PRIVATE_KEY_STAGING=def"); err != nil {
+		t.Fatal(err)
+	}
+	if got := secrets["myapp-keys"]; got["DOTENV_PRIVATE_KEY_PRODUCTION"] != "abc" || got["DOTENV_PRIVATE_KEY_STAGING"] != "def" {
+		t.Errorf("stored data = %v; want both environments under their own keys", got)
+	}
+
+	raw, err := k.GetSecret(ctx, "myapp-keys")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key, err := KeyMaterial(raw, "DOTENV_PRIVATE_KEY_STAGING"); err != nil || key != "def" {
+		t.Errorf("KeyMaterial(staging) = %q, %v", key, err)
+	}
+
+	for _, c := range *calls {
+		if c.name != "kubectl" || !hasArgPair(c.args, "--namespace", "apps") ||
+			!hasArgPair(c.args, "--context", "prod-cluster") || !hasArgPair(c.args, "--kubeconfig", "/k/config") {
+			t.Errorf("kubectl call missing config flags: %v", c.args)
+		}
+		if strings.Contains(strings.Join(c.args, " "), "abc") {
+			t.Errorf("key leaked into argv: %v", c.args)
+		}
+	}
+}
+
+func TestKubernetesPlainValue(t *testing.T) {
+	secrets := map[string]map[string]string{}
+	fakeKubectl(t, secrets)
+	k := NewKubernetes(KubernetesConfig{})
+	if err := k.SetSecret(context.Background(), "s", "bare-key"); err != nil {
+		t.Fatal(err)
+	}
+	if secrets["s"]["value"] != "bare-key" {
+		t.Errorf("plain value should be stored under \"value\", got %v", secrets["s"])
+	}
+	if got, err := k.GetSecret(context.Background(), "s"); err != nil || got != "bare-key" {
+		t.Errorf("GetSecret = %q, %v", got, err)
+	}
+}
+
+func TestKubernetesMissingKubectl(t *testing.T) {
+	fakeCLI(t, func([]string) (string, string, error) {
+		return "", "", &exec.Error{Name: "kubectl", Err: exec.ErrNotFound}
+	})
+	if _, err := NewKubernetes(KubernetesConfig{}).GetSecret(context.Background(), "s"); err == nil ||
+		!strings.Contains(err.Error(), "kubectl not found") {
+		t.Errorf("expected a missing-kubectl error, got %v", err)
+	}
+}
@@ -0,0 +1,99 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// requestRates is the sustained rate, in requests per second, Limit allows
+// each provider: comfortably inside the request quotas the services publish
+// for a single account or vault, and for the providers driven through a
+// command-line tool also a bound on how many of its processes start at once.
+var requestRates = map[string]float64{
+	"aws":        20,
+	"azure":      20,
+	"gcp":        5,
+	"hashicorp":  50,
+	"kubernetes": 5,
+}
+
+// defaultRequestRate applies to a provider missing from requestRates.
+const defaultRequestRate = 10
+
+// Limit wraps p so that its requests, however many goroutines share it, are
+// spaced to the provider's request rate instead of tripping its throttling.
+func Limit(p Provider) Provider {
+	rate := requestRates[p.Name()]
+	if rate == 0 {
+		rate = defaultRequestRate
+	}
+	return limitEvery(p, time.Duration(float64(time.Second)/rate))
+}
+
+// limitEvery wraps p so its requests start at least interval apart.
+func limitEvery(p Provider, interval time.Duration) *limited {
+	return &limited{Provider: p, interval: interval}
+}
+
+// limited is a Provider whose requests wait their turn.
+type limited struct {
+	Provider
+	interval time.Duration
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+// wait blocks until the caller's request may start, or ctx ends.
+func (l *limited) wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	at := l.next
+	if at.Before(now) {
+		at = now
+	}
+	l.next = at.Add(l.interval)
+	l.mu.Unlock()
+
+	d := time.Until(at)
+	if d <= 0 {
+		return nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (l *limited) GetSecret(ctx context.Context, name string) (string, error) {
+	if err := l.wait(ctx); err != nil {
+		return "", err
+	}
+	return l.Provider.GetSecret(ctx, name)
+}
+
+func (l *limited) SetSecret(ctx context.Context, name, value string) error {
+	if err := l.wait(ctx); err != nil {
+		return err
+	}
+	return l.Provider.SetSecret(ctx, name, value)
+}
+
+// DescribeSecret keeps the wrapped provider's metadata available to
+// Describe.
+func (l *limited) DescribeSecret(ctx context.Context, name string) (SecretInfo, error) {
+	d, ok := l.Provider.(Describer)
+	if !ok {
+		return SecretInfo{}, errors.New("no metadata available")
+	}
+	if err := l.wait(ctx); err != nil {
+		return SecretInfo{}, err
+	}
+	return d.DescribeSecret(ctx, name)
+}
@@ -0,0 +1,70 @@
+package vault
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// stubProvider records when each request started.
+type stubProvider struct {
+	mu     sync.Mutex
+	starts []time.Time
+}
+
+func (*stubProvider) Name() string { return "stub" }
+
+func (s *stubProvider) GetSecret(context.Context, string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.starts = append(s.starts, time.Now())
+	return "v", nil
+}
+
+func (s *stubProvider) SetSecret(context.Context, string, string) error { return nil }
+
+func TestLimitSpacesConcurrentRequests(t *testing.T) {
+	stub := &stubProvider{}
+	const interval = 20 * time.Millisecond
+	p := limitEvery(stub, interval)
+
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := p.GetSecret(context.Background(), "s"); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if len(stub.starts) != 5 {
+		t.Fatalf("%d requests; want 5", len(stub.starts))
+	}
+	if span := stub.starts[4].Sub(stub.starts[0]); span < 4*interval-5*time.Millisecond {
+		t.Errorf("5 requests started within %v; want them about %v apart", span, interval)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	slow := limitEvery(stub, time.Hour)
+	slow.next = time.Now().Add(time.Hour)
+	if _, err := slow.GetSecret(ctx, "s"); err == nil {
+		t.Error("a request waiting its turn must end with its context")
+	}
+}
+
+func TestLimitRates(t *testing.T) {
+	if got := Limit(&stubProvider{}).(*limited).interval; got != time.Second/defaultRequestRate {
+		t.Errorf("unknown provider interval = %v; want the default rate", got)
+	}
+	aws := Limit(NewAWS(AWSConfig{}))
+	if got := aws.(*limited).interval; got != 50*time.Millisecond {
+		t.Errorf("aws interval = %v; want 50ms", got)
+	}
+	if aws.Name() != "aws" {
+		t.Errorf("Name = %q; want the wrapped provider's", aws.Name())
+	}
+}
@@ -0,0 +1,216 @@
+// Package vault reads and writes dotenvx private keys in external secret
+// stores, configured by the same [vault] tables of envdrift.toml the Python
+// CLI reads so one config serves both tools.
+package vault
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned when the named secret does not exist.
+var ErrNotFound = errors.New("secret not found")
+
+// Provider is one secret store backend.
+type Provider interface {
+	// Name is the provider's [vault] provider value (aws, azure, ...).
+	Name() string
+	// GetSecret returns the current value of the named secret, or an error
+	// wrapping ErrNotFound.
+	GetSecret(ctx context.Context, name string) (string, error)
+	// SetSecret creates the secret or stores a new version of it.
+	SetSecret(ctx context.Context, name, value string) error
+}
+
+// SecretInfo is non-secret metadata about a stored secret, shown when the
+// local and vault keys disagree so the user can tell which side is newer.
+type SecretInfo struct {
+	Version string
+	Created time.Time
+	Updated time.Time
+}
+
+// String renders the known fields, e.g. "version 3, updated 2026-01-02 15:04 UTC".
+func (i SecretInfo) String() string {
+	var parts []string
+	if i.Version != "" {
+		parts = append(parts, "version "+i.Version)
+	}
+	if !i.Created.IsZero() {
+		parts = append(parts, "created "+i.Created.UTC().Format("2006-01-02 15:04 MST"))
+	}
+	if !i.Updated.IsZero() {
+		parts = append(parts, "updated "+i.Updated.UTC().Format("2006-01-02 15:04 MST"))
+	}
+	if len(parts) == 0 {
+		return "no metadata available"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Describer is implemented by providers that can report SecretInfo.
+type Describer interface {
+	DescribeSecret(ctx context.Context, name string) (SecretInfo, error)
+}
+
+// Describe returns p's metadata for name. The metadata only informs a
+// decision, so a provider without it, or a failed lookup, yields a zero
+// SecretInfo rather than an error.
+func Describe(ctx context.Context, p Provider, name string) SecretInfo {
+	d, ok := p.(Describer)
+	if !ok {
+		return SecretInfo{}
+	}
+	info, err := d.DescribeSecret(ctx, name)
+	if err != nil {
+		return SecretInfo{}
+	}
+	return info
+}
+
+// Config selects and configures a provider.
+type Config struct {
+	Provider   string
+	AWS        AWSConfig
+	Azure      AzureConfig
+	Hashicorp  HashicorpConfig
+	GCP        GCPConfig
+	Kubernetes KubernetesConfig
+}
+
+// New returns the provider cfg selects.
+func New(cfg Config) (Provider, error) {
+	switch cfg.Provider {
+	case "aws":
+		return NewAWS(cfg.AWS), nil
+	case "azure":
+		if cfg.Azure.VaultURL == "" {
+			return nil, errors.New("azure vault provider needs [vault.azure] vault_url")
+		}
+		return NewAzure(cfg.Azure), nil
+	case "hashicorp":
+		return NewHashicorp(cfg.Hashicorp), nil
+	case "gcp":
+		return NewGCP(cfg.GCP), nil
+	case "kubernetes":
+		return NewKubernetes(cfg.Kubernetes), nil
+	case "":
+		return nil, errors.New("no vault provider configured ([vault] provider)")
+	default:
+		return nil, fmt.Errorf("unsupported vault provider %q", cfg.Provider)
+	}
+}
+
+// httpClient is used by the providers that talk to REST APIs directly; tests
+// point it at a fake server.
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+// runCLI runs a provider's command-line tool with optional stdin and returns
+// stdout and stderr separately. It is a seam so tests can fake the tools.
+var runCLI = func(ctx context.Context, stdin []byte, name string, args ...string) (stdout, stderr []byte, err error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+	var out, errOut bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &errOut
+	err = cmd.Run()
+	return out.Bytes(), errOut.Bytes(), err
+}
+
+// privateKeyLine matches a `DOTENV_PRIVATE_KEY[_<ENV>]=<value>` line, in any case.
+var privateKeyLine = regexp.MustCompile(`(?i)^(DOTENV_PRIVATE_KEY(?:_[A-Za-z0-9_]+)?)=(.*)$`)
+
+// KeyMaterial reduces a raw vault secret to the bare private key stored for
+// keyName, accepting the shapes the CLI's keymaterial.py accepts: a bare key,
+// a `DOTENV_PRIVATE_KEY_<ENV>=<key>` line, a multi-line .env.keys blob, or a
+// JSON key/value document. A key labelled for a different environment, or a
+// layout with no usable key, is an error naming the layout — never the
+// secret values.
+func KeyMaterial(raw, keyName string) (string, error) {
+	v := stripQuotes(strings.TrimSpace(raw))
+
+	if strings.HasPrefix(v, "{") {
+		return keyFromJSON(v, keyName)
+	}
+	if strings.Contains(v, "\n") {
+		return keyFromBlob(v, keyName)
+	}
+	if m := privateKeyLine.FindStringSubmatch(v); m != nil {
+		if !strings.EqualFold(m[1], keyName) {
+			return "", fmt.Errorf("secret holds %s, not %s", strings.ToUpper(m[1]), keyName)
+		}
+		v = stripQuotes(strings.TrimSpace(m[2]))
+	}
+	return validateKey(v)
+}
+
+// keyFromBlob extracts keyName's line from a .env.keys-style document.
+func keyFromBlob(text, keyName string) (string, error) {
+	var labels []string
+	for _, line := range strings.Split(text, "\n") {
+		line = stripQuotes(strings.TrimSpace(line))
+		m := privateKeyLine.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		labels = append(labels, strings.ToUpper(m[1]))
+		if strings.EqualFold(m[1], keyName) {
+			return validateKey(stripQuotes(strings.TrimSpace(m[2])))
+		}
+	}
+	if len(labels) == 0 {
+		return "", errors.New("secret is a multi-line document with no DOTENV_PRIVATE_KEY line")
+	}
+	return "", fmt.Errorf("secret is a keys document (%s) without %s", strings.Join(labels, ", "), keyName)
+}
+
+// keyFromJSON extracts keyName's field from a JSON key/value document.
+func keyFromJSON(text, keyName string) (string, error) {
+	var doc map[string]any
+	if err := json.Unmarshal([]byte(text), &doc); err != nil {
+		return "", errors.New("secret starts with '{' but is not a JSON key/value document")
+	}
+	fields := make([]string, 0, len(doc))
+	for k, val := range doc {
+		fields = append(fields, k)
+		s, ok := val.(string)
+		if ok && strings.EqualFold(k, keyName) {
+			return KeyMaterial(s, keyName)
+		}
+	}
+	if s, ok := doc["value"].(string); ok && len(doc) == 1 {
+		return KeyMaterial(s, keyName)
+	}
+	sort.Strings(fields)
+	return "", fmt.Errorf("secret is a JSON document (fields: %s) without a %s field", strings.Join(fields, ", "), keyName)
+}
+
+// validateKey is the final shape check: one non-empty token.
+func validateKey(v string) (string, error) {
+	if v == "" {
+		return "", errors.New("secret holds an empty key")
+	}
+	if strings.ContainsAny(v, " \t\r\n=") {
+		return "", errors.New("secret does not look like a single dotenvx private key")
+	}
+	return v, nil
+}
+
+// stripQuotes removes one layer of matching single or double quotes.
+func stripQuotes(v string) string {
+	if len(v) >= 2 && (v[0] == '"' || v[0] == '\'') && v[len(v)-1] == v[0] {
+		return v[1 : len(v)-1]
+	}
+	return v
+}
@@ -0,0 +1,85 @@
+package vault
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestKeyMaterialShapes(t *testing.T) {
+	const name = "DOTENV_PRIVATE_KEY_PRODUCTION"
+	tests := map[string]string{
+		"bare":          "abc123",
+		"quoted":        `"abc123"`,
+		"line":          "DOTENV_PRIVATE_KEY_PRODUCTION=abc123",
+		"quoted line":   `DOTENV_PRIVATE_KEY_PRODUCTION="abc123"`,
+		"keys blob":     "#/ private keys /\nDOTENV_PRIVATE_KEY_STAGING=other\nDOTENV_PRIVATE_KEY_PRODUCTION=\"abc123\"\n",
+		"json field":    `{"DOTENV_PRIVATE_KEY_PRODUCTION": "abc123", "DOTENV_PRIVATE_KEY_CI": "x"}`,
+		"json value":    `{"value": "abc123"}`,
+		"padded":        "  abc123\n",
+		"lowercase key": "dotenv_private_key_production=abc123",
+	}
+	for label, raw := range tests {
+		got, err := KeyMaterial(raw, name)
+		if err != nil || got != "abc123" {
+			t.Errorf("%s: KeyMaterial = %q, %v; want abc123", label, got, err)
+		}
+	}
+}
+
+func TestKeyMaterialRejectsWrongLayouts(t *testing.T) {
+	const name = "DOTENV_PRIVATE_KEY_PRODUCTION"
+	tests := map[string]string{
+		"other env line":  "DOTENV_PRIVATE_KEY_STAGING=secretvalue",
+		"blob without":    "DOTENV_PRIVATE_KEY_STAGING=secretvalue\nDOTENV_PRIVATE_KEY_CI=secretvalue\n",
+		"plain document":  "hello\nworld",
+		"json without":    `{"token": "secretvalue"}`,
+		"broken json":     `{"token": `,
+		"empty":           "   ",
+		"spaces in value": "not a key",
+	}
+	for label, raw := range tests {
+		_, err := KeyMaterial(raw, name)
+		if err == nil {
+			t.Errorf("%s: expected an error", label)
+			continue
+		}
+		if strings.Contains(err.Error(), "secretvalue") {
+			t.Errorf("%s: error leaks the secret: %v", label, err)
+		}
+	}
+}
+
+func TestNewProvider(t *testing.T) {
+	p, err := New(Config{Provider: "aws", AWS: AWSConfig{Region: "eu-west-1"}})
+	if err != nil || p.Name() != "aws" {
+		t.Fatalf("New(aws) = %v, %v", p, err)
+	}
+	if _, err := New(Config{}); err == nil {
+		t.Error("empty provider should fail")
+	}
+	if _, err := New(Config{Provider: "keepass"}); err == nil || !strings.Contains(err.Error(), "unsupported") {
+		t.Errorf("unknown provider should be unsupported, got %v", err)
+	}
+}
+
+// plainProvider has no DescribeSecret.
+type plainProvider struct{}
+
+func (plainProvider) Name() string                                      { return "plain" }
+func (plainProvider) GetSecret(context.Context, string) (string, error) { return "", nil }
+func (plainProvider) SetSecret(context.Context, string, string) error   { return nil }
+
+func TestSecretInfo(t *testing.T) {
+	info := SecretInfo{Version: "3", Updated: time.Date(2026, 1, 2, 15, 4, 0, 0, time.UTC)}
+	if got := info.String(); got != "version 3, updated 2026-01-02 15:04 UTC" {
+		t.Errorf("String() = %q", got)
+	}
+	if got := Describe(context.Background(), plainProvider{}, "x"); got != (SecretInfo{}) {
+		t.Errorf("Describe without Describer = %+v", got)
+	}
+	if got := (SecretInfo{}).String(); got != "no metadata available" {
+		t.Errorf("empty String() = %q", got)
+	}
+}
@@ -0,0 +1,285 @@
+// Package vaultsync keeps local dotenvx private keys in step with the
+// project's configured vault, so a key rotated centrally reaches every agent
+// without anyone running a pull by hand.
+//
+// A local key is only replaced when it is unchanged since the last sync (its
+// fingerprint matches the recorded one). A local key that was edited or
+// generated since then and differs from the vault is a conflict: it is left
+// alone and reported, since overwriting it could strand files encrypted with
+// it.
+package vaultsync
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/jainal09/envdrift-agent/internal/keys"
+	"github.com/jainal09/envdrift-agent/internal/paths"
+	"github.com/jainal09/envdrift-agent/internal/project"
+	"github.com/jainal09/envdrift-agent/internal/vault"
+)
+
+// Outcome is what a sync did with one key.
+type Outcome string
+
+const (
+	// Unchanged means the local key already matches the vault.
+	Unchanged Outcome = "unchanged"
+	// Created means the key was missing locally and has been written.
+	Created Outcome = "created"
+	// Updated means the vault key changed (rotated) and replaced the local one.
+	Updated Outcome = "updated"
+	// Conflict means both sides changed; the local key was left untouched.
+	Conflict Outcome = "conflict"
+	// Missing means the mapped secret does not exist in the vault.
+	Missing Outcome = "missing"
+	// Failed means the key could not be synced; Result.Err says why.
+	Failed Outcome = "failed"
+)
+
+// Result is the outcome for one mapped key.
+type Result struct {
+	Dir        string
+	KeyName    string
+	SecretName string
+	Outcome    Outcome
+	Err        error
+	// Remote describes the vault secret when Outcome is Conflict.
+	Remote vault.SecretInfo
+}
+
+// Syncer pulls mapped keys from a project's vault into a local store.
+type Syncer struct {
+	// Target is the local store: keys.SourceDotenvKeys or keys.SourceKeychain.
+	Target string
+	// StatePath holds the fingerprints of the last synced keys.
+	StatePath string
+	// NewProvider builds the vault client; vault.New when nil.
+	NewProvider func(vault.Config) (vault.Provider, error)
+	// Resolve decides a conflict: true replaces the local key with the
+	// vault's (the result becomes Updated). Nil leaves every conflict alone.
+	// Calls are never concurrent.
+	Resolve func(Result) bool
+	// Workers is how many keys are fetched at a time; DefaultWorkers when
+	// below 1.
+	Workers int
+
+	mu sync.Mutex
+}
+
+// DefaultWorkers is how many mapped keys a sync, pull or push handles at a
+// time. The provider's request rate (vault.Limit) still spaces the requests.
+const DefaultWorkers = 8
+
+// DefaultStatePath returns vault-sync.json in the state directory.
+func DefaultStatePath() string {
+	return filepath.Join(paths.StateDir(), "vault-sync.json")
+}
+
+// SyncProject syncs every [[vault.sync.mappings]] entry whose folder is
+// projectPath or below it. A project without vault config yields no results.
+func (s *Syncer) SyncProject(ctx context.Context, projectPath string) ([]Result, error) {
+	settings, found, err := project.LoadVaultSettings(projectPath)
+	if err != nil || !found {
+		return nil, err
+	}
+	root, err := filepath.Abs(projectPath)
+	if err != nil {
+		return nil, err
+	}
+
+	mappings := Under(root, settings.Mappings)
+	if len(mappings) == 0 {
+		return nil, nil
+	}
+
+	newProvider := s.NewProvider
+	if newProvider == nil {
+		newProvider = vault.New
+	}
+	provider, err := newProvider(settings.Config)
+	if err != nil {
+		return nil, err
+	}
+	provider = vault.Limit(provider)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state := s.loadState()
+	results := make([]Result, len(mappings))
+	var local sync.Mutex
+	ForEach(ctx, mappings, s.Workers, func(i int, m project.VaultMapping) {
+		results[i] = s.syncKey(ctx, provider, m, state, &local)
+	})
+	for i, m := range mappings {
+		if results[i].Outcome == "" {
+			// Not started before ctx ended.
+			results[i] = Result{Dir: m.FolderPath, KeyName: keys.KeyName(m.Environment), SecretName: m.SecretName, Outcome: Failed, Err: ctx.Err()}
+		}
+	}
+	if err := s.saveState(state); err != nil {
+		return results, fmt.Errorf("save vault sync state: %w", err)
+	}
+	return results, nil
+}
+
+// Under returns the mappings whose folder is root or inside it.
+func Under(root string, mappings []project.VaultMapping) []project.VaultMapping {
+	var under []project.VaultMapping
+	for _, m := range mappings {
+		if paths.Within(root, m.FolderPath) {
+			under = append(under, m)
+		}
+	}
+	return under
+}
+
+// ForEach calls fn with each mapping and its index, workers at a time
+// (DefaultWorkers when below 1), and returns once every call has. Mappings
+// not started when ctx ends are skipped.
+func ForEach(ctx context.Context, mappings []project.VaultMapping, workers int, fn func(int, project.VaultMapping)) {
+	if workers < 1 {
+		workers = DefaultWorkers
+	}
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for range min(workers, len(mappings)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				fn(i, mappings[i])
+			}
+		}()
+	}
+	defer wg.Wait()
+	defer close(next)
+	for i := range mappings {
+		select {
+		case next <- i:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// syncKey reconciles one mapping, updating state on success. The vault is
+// read concurrently with other keys; local holds the local store, state
+// and Resolve for the rest.
+func (s *Syncer) syncKey(ctx context.Context, provider vault.Provider, m project.VaultMapping, state map[string]string, local *sync.Mutex) Result {
+	res := Result{Dir: m.FolderPath, KeyName: keys.KeyName(m.Environment), SecretName: m.SecretName}
+	fail := func(err error) Result {
+		res.Outcome, res.Err = Failed, err
+		return res
+	}
+
+	raw, err := provider.GetSecret(ctx, m.SecretName)
+	if errors.Is(err, vault.ErrNotFound) {
+		res.Outcome = Missing
+		return res
+	}
+	if err != nil {
+		return fail(err)
+	}
+	remote, err := vault.KeyMaterial(raw, res.KeyName)
+	if err != nil {
+		return fail(fmt.Errorf("%s: %w", m.SecretName, err))
+	}
+
+	local.Lock()
+	defer local.Unlock()
+	current, haveLocal, err := keys.LocalKey(ctx, s.Target, m.FolderPath, res.KeyName)
+	if err != nil {
+		return fail(err)
+	}
+
+	id := stateKey(m.FolderPath, res.KeyName)
+	switch {
+	case haveLocal && current == remote:
+		res.Outcome = Unchanged
+	case haveLocal && state[id] != fingerprint(current) && !s.resolve(ctx, provider, &res):
+		res.Outcome = Conflict
+		return res
+	default:
+		if err := keys.StoreLocalKey(ctx, s.Target, m.FolderPath, res.KeyName, remote); err != nil {
+			return fail(err)
+		}
+		res.Outcome = Created
+		if haveLocal {
+			res.Outcome = Updated
+		}
+	}
+	state[id] = fingerprint(remote)
+	return res
+}
+
+// resolve fills in the vault metadata for a conflicting key and asks Resolve
+// whether the vault side wins.
+func (s *Syncer) resolve(ctx context.Context, provider vault.Provider, res *Result) bool {
+	res.Remote = vault.Describe(ctx, provider, res.SecretName)
+	if s.Resolve == nil {
+		return false
+	}
+	c := *res
+	c.Outcome = Conflict
+	return s.Resolve(c)
+}
+
+func stateKey(dir, keyName string) string {
+	return filepath.Clean(dir) + "|" + keyName
+}
+
+// fingerprint identifies a key value without storing it.
+func fingerprint(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
+
+// loadState reads the fingerprint map; a missing or corrupt file is an empty
+// state, which only makes the next sync more conservative (differing local
+// keys become conflicts).
+func (s *Syncer) loadState() map[string]string {
+	state := map[string]string{}
+	data, err := os.ReadFile(s.StatePath)
+	if err != nil {
+		return state
+	}
+	_ = json.Unmarshal(data, &state)
+	return state
+}
+
+func (s *Syncer) saveState(state map[string]string) error {
+	if err := os.MkdirAll(filepath.Dir(s.StatePath), 0700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.StatePath, data, 0600)
+}
+
+// Record marks value as the last synced key for keyName in dir, as after a
+// manual pull, so later syncs treat it as unchanged locally.
+func (s *Syncer) Record(dir, keyName, value string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state := s.loadState()
+	state[stateKey(dir, keyName)] = fingerprint(value)
+	return s.saveState(state)
+}
+
+// Synced reports whether value is the key last recorded for keyName in dir,
+// i.e. whichever side holds it has not changed since the last sync.
+func (s *Syncer) Synced(dir, keyName, value string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.loadState()[stateKey(dir, keyName)] == fingerprint(value)
+}
@@ -0,0 +1,265 @@
+package vaultsync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jainal09/envdrift-agent/internal/vault"
+)
+
+// memoryVault is an in-memory vault.Provider.
+type memoryVault map[string]string
+
+func (memoryVault) Name() string { return "aws" }
+
+func (m memoryVault) GetSecret(_ context.Context, name string) (string, error) {
+	v, ok := m[name]
+	if !ok {
+		return "", vault.ErrNotFound
+	}
+	return v, nil
+}
+
+func (m memoryVault) SetSecret(_ context.Context, name, value string) error {
+	m[name] = value
+	return nil
+}
+
+// newProject writes an envdrift.toml mapping myapp/prod and myapp/staging to
+// the project root and returns the project dir and a syncer over store.
+func newProject(t *testing.T, store memoryVault) (string, *Syncer) {
+	t.Helper()
+	dir := t.TempDir()
+	toml := `
+[vault.aws]
+region = "us-east-1"
+
+[[vault.sync.mappings]]
+secret_name = "myapp/prod"
+folder_path = "."
+
+[[vault.sync.mappings]]
+secret_name = "myapp/staging"
+folder_path = "."
+environment = "staging"
+
+[[vault.sync.mappings]]
+secret_name = "other/prod"
+folder_path = "../elsewhere"
+`
+	if err := os.WriteFile(filepath.Join(dir, "envdrift.toml"), []byte(toml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s := &Syncer{
+		Target:      "dotenv_keys",
+		StatePath:   filepath.Join(t.TempDir(), "vault-sync.json"),
+		NewProvider: func(vault.Config) (vault.Provider, error) { return store, nil },
+	}
+	return dir, s
+}
+
+func outcomes(t *testing.T, s *Syncer, dir string) map[string]Outcome {
+	t.Helper()
+	results, err := s.SyncProject(context.Background(), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]Outcome{}
+	for _, r := range results {
+		got[r.KeyName] = r.Outcome
+	}
+	return got
+}
+
+func readKeys(t *testing.T, dir string) string {
+	t.Helper()
+	data, _ := os.ReadFile(filepath.Join(dir, ".env.keys"))
+	return string(data)
+}
+
+func writeKeysFile(t *testing.T, dir, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, ".env.keys"), []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSyncCreatesThenFollowsRotation(t *testing.T) {
+	store := memoryVault{"myapp/prod": "prod-v1"}
+	dir, s := newProject(t, store)
+
+	got := outcomes(t, s, dir)
+	if got["DOTENV_PRIVATE_KEY_PRODUCTION"] != Created || got["DOTENV_PRIVATE_KEY_STAGING"] != Missing {
+		t.Fatalf("first pass = %v", got)
+	}
+	if len(got) != 2 {
+		t.Errorf("mapping outside the project was synced: %v", got)
+	}
+	if !strings.Contains(readKeys(t, dir), "DOTENV_PRIVATE_KEY_PRODUCTION=prod-v1") {
+		t.Fatalf(".env.keys = %q", readKeys(t, dir))
+	}
+
+	if got := outcomes(t, s, dir); got["DOTENV_PRIVATE_KEY_PRODUCTION"] != Unchanged {
+		t.Errorf("second pass = %v, want unchanged", got)
+	}
+
+	store["myapp/prod"] = "prod-v2" // rotated centrally
+	if got := outcomes(t, s, dir); got["DOTENV_PRIVATE_KEY_PRODUCTION"] != Updated {
+		t.Errorf("rotation pass = %v, want updated", got)
+	}
+	if !strings.Contains(readKeys(t, dir), "DOTENV_PRIVATE_KEY_PRODUCTION=prod-v2") {
+		t.Errorf("rotated key not written: %q", readKeys(t, dir))
+	}
+
+	state, _ := os.ReadFile(s.StatePath)
+	if strings.Contains(string(state), "prod-v") {
+		t.Errorf("state file must hold fingerprints, not keys: %s", state)
+	}
+}
+
+func TestSyncConflictLeavesLocalKey(t *testing.T) {
+	store := memoryVault{"myapp/prod": "prod-v1"}
+	dir, s := newProject(t, store)
+	outcomes(t, s, dir)
+
+	// The key changes on both sides: re-generated locally and rotated in vault.
+	writeKeysFile(t, dir, "DOTENV_PRIVATE_KEY_PRODUCTION=local-new\n")
+	store["myapp/prod"] = "prod-v2"
+	if got := outcomes(t, s, dir); got["DOTENV_PRIVATE_KEY_PRODUCTION"] != Conflict {
+		t.Fatalf("pass = %v, want conflict", got)
+	}
+	if !strings.Contains(readKeys(t, dir), "local-new") {
+		t.Errorf("conflicting local key was overwritten: %q", readKeys(t, dir))
+	}
+
+	// A key that existed before any sync and differs is also a conflict.
+	dir2, s2 := newProject(t, memoryVault{"myapp/prod": "prod-v1"})
+	writeKeysFile(t, dir2, "DOTENV_PRIVATE_KEY_PRODUCTION=pre-existing\n")
+	if got := outcomes(t, s2, dir2); got["DOTENV_PRIVATE_KEY_PRODUCTION"] != Conflict {
+		t.Errorf("unsynced differing key = %v, want conflict", got)
+	}
+
+	// Recording the local key (as after a manual pull/push) resolves it.
+	if err := s.Record(dir, "DOTENV_PRIVATE_KEY_PRODUCTION", "local-new"); err != nil {
+		t.Fatal(err)
+	}
+	if got := outcomes(t, s, dir); got["DOTENV_PRIVATE_KEY_PRODUCTION"] != Updated {
+		t.Errorf("after Record = %v, want updated", got)
+	}
+}
+
+func TestSyncResolveReplacesConflictingKey(t *testing.T) {
+	dir, s := newProject(t, memoryVault{"myapp/prod": "prod-v1"})
+	writeKeysFile(t, dir, "DOTENV_PRIVATE_KEY_PRODUCTION=pre-existing\n")
+	var asked []Result
+	s.Resolve = func(r Result) bool {
+		asked = append(asked, r)
+		return true
+	}
+	if got := outcomes(t, s, dir); got["DOTENV_PRIVATE_KEY_PRODUCTION"] != Updated {
+		t.Fatalf("pass = %v, want updated", got)
+	}
+	if len(asked) != 1 || asked[0].Outcome != Conflict || asked[0].SecretName != "myapp/prod" {
+		t.Errorf("Resolve calls = %+v", asked)
+	}
+	if !strings.Contains(readKeys(t, dir), "DOTENV_PRIVATE_KEY_PRODUCTION=prod-v1") {
+		t.Errorf("resolved key not written: %q", readKeys(t, dir))
+	}
+}
+
+func TestSyncProjectWithoutVault(t *testing.T) {
+	s := &Syncer{Target: "dotenv_keys", StatePath: filepath.Join(t.TempDir(), "s.json")}
+	results, err := s.SyncProject(context.Background(), t.TempDir())
+	if err != nil || len(results) != 0 {
+		t.Errorf("SyncProject = %v, %v; want nothing", results, err)
+	}
+}
+
+// slowVault answers after a delay, recording how many requests overlapped;
+// the secret named fail errors.
+type slowVault struct {
+	mu           sync.Mutex
+	active, peak int
+	delay        time.Duration
+	fail         string
+}
+
+func (*slowVault) Name() string { return "hashicorp" }
+
+func (v *slowVault) GetSecret(_ context.Context, name string) (string, error) {
+	v.mu.Lock()
+	v.active++
+	v.peak = max(v.peak, v.active)
+	v.mu.Unlock()
+	time.Sleep(v.delay)
+	v.mu.Lock()
+	v.active--
+	v.mu.Unlock()
+	if name == v.fail {
+		return "", errors.New("permission denied")
+	}
+	return "key-" + name, nil
+}
+
+func (*slowVault) SetSecret(context.Context, string, string) error { return nil }
+
+// TestSyncManyServices covers a monorepo: the services' keys are fetched
+// concurrently, each lands in its own folder, and one failing secret leaves
+// the rest synced.
+func TestSyncManyServices(t *testing.T) {
+	root := t.TempDir()
+	toml := "[vault.hashicorp]\nurl = \"http://vault\"\n"
+	const services = 10
+	for i := range services {
+		name := fmt.Sprintf("svc%d", i)
+		if err := os.Mkdir(filepath.Join(root, name), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		toml += fmt.Sprintf("\n[[vault.sync.mappings]]\nsecret_name = %q\nfolder_path = %q\n", name, name)
+	}
+	if err := os.WriteFile(filepath.Join(root, "envdrift.toml"), []byte(toml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	store := &slowVault{delay: 100 * time.Millisecond, fail: "svc3"}
+	s := &Syncer{
+		Target:      "dotenv_keys",
+		StatePath:   filepath.Join(t.TempDir(), "vault-sync.json"),
+		NewProvider: func(vault.Config) (vault.Provider, error) { return store, nil },
+	}
+
+	results, err := s.SyncProject(context.Background(), root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != services {
+		t.Fatalf("%d results; want %d", len(results), services)
+	}
+	for i, r := range results {
+		name := fmt.Sprintf("svc%d", i)
+		if r.SecretName != name {
+			t.Errorf("result %d is for %s; want mapping order", i, r.SecretName)
+		}
+		if name == store.fail {
+			if r.Outcome != Failed || r.Err == nil {
+				t.Errorf("%s = %s, %v; want failed", name, r.Outcome, r.Err)
+			}
+			continue
+		}
+		if r.Outcome != Created {
+			t.Errorf("%s = %s, %v; want created", name, r.Outcome, r.Err)
+		}
+		if !strings.Contains(readKeys(t, filepath.Join(root, name)), "=key-"+name) {
+			t.Errorf("%s key not written", name)
+		}
+	}
+	if store.peak < 2 {
+		t.Errorf("at most %d request at a time; want the services fetched concurrently", store.peak)
+	}
+}
@@ -0,0 +1,35 @@
+package watcher
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Editor scratch files that can match an env pattern such as ".env*" but are
+// never the user's env file: they are written beside it during a save and
+// then renamed over it or deleted, so encrypting one would race the editor.
+var (
+	// editorTempSuffixes: vim swap files (.env.swp, .swo, .swx), backups
+	// (.env~), generic temp files (.env.tmp) and JetBrains safe-write files.
+	editorTempSuffixes = []string{"~", ".swp", ".swo", ".swx", ".tmp", "___jb_tmp___", "___jb_old___"}
+	// editorTempPrefixes: Emacs lock (.#.env) and auto-save (#.env#) files.
+	editorTempPrefixes = []string{".#", "#"}
+)
+
+// IsEditorTemp reports whether the base name is an editor's scratch file.
+// Vim probes a directory's writability with a file named 4913 (then 5036,
+// 5159, ...), so an all-digit name counts too.
+func IsEditorTemp(base string) bool {
+	lower := strings.ToLower(base)
+	for _, suffix := range editorTempSuffixes {
+		if strings.HasSuffix(lower, suffix) {
+			return true
+		}
+	}
+	for _, prefix := range editorTempPrefixes {
+		if strings.HasPrefix(lower, prefix) {
+			return true
+		}
+	}
+	return base != "" && strings.IndexFunc(base, func(r rune) bool { return !unicode.IsDigit(r) }) < 0
+}
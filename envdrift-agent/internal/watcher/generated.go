@@ -0,0 +1,44 @@
+package watcher
+
+import "strings"
+
+// Files other tools generate that can match an env pattern such as ".env*"
+// but are not the user's env file: encrypting one breaks the tool that reads
+// it, or is undone by the next install or build.
+var (
+	// generatedDirs hold installed dependencies (node_modules, vendor,
+	// Python's site-packages) and test fixtures (testdata, fixtures,
+	// __fixtures__). Framework build output such as Next.js's .next, which
+	// copies .env.production.local into the build, or .nuxt and .svelte-kit,
+	// is in hidden directories and skipped already.
+	generatedDirs = map[string]bool{
+		"node_modules":     true,
+		"bower_components": true,
+		"vendor":           true,
+		"site-packages":    true,
+		"testdata":         true,
+		"fixtures":         true,
+		"__fixtures__":     true,
+	}
+	// generatedSuffixes: Symfony's `composer dump-env` compiles the .env
+	// files into .env.local.php, a PHP array dotenvx cannot parse.
+	generatedSuffixes = []string{".php"}
+)
+
+// IsGeneratedDir reports whether a directory base name holds dependencies or
+// test fixtures rather than the project's own env files.
+func IsGeneratedDir(name string) bool {
+	return generatedDirs[strings.ToLower(name)]
+}
+
+// IsGenerated reports whether the base name is a file a framework generates
+// from the env files.
+func IsGenerated(base string) bool {
+	lower := strings.ToLower(base)
+	for _, suffix := range generatedSuffixes {
+		if strings.HasSuffix(lower, suffix) {
+			return true
+		}
+	}
+	return false
+}
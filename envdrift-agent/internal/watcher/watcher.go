@@ -2,14 +2,23 @@
 package watcher
 
 import (
+	"errors"
+	"fmt"
 	"log"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
+	"unicode"
 
 	"github.com/fsnotify/fsnotify"
+
+	"github.com/jainal09/envdrift-agent/internal/clock"
+	"github.com/jainal09/envdrift-agent/internal/longpath"
+	"github.com/jainal09/envdrift-agent/internal/wsl"
 )
 
 // FileEvent represents a file change event
@@ -31,6 +40,58 @@ type Watcher struct {
 	closeEventsOnce sync.Once
 	mu              sync.RWMutex
 	lastMod         map[string]time.Time
+	// skipSymlinks ignores env files that are symbolic links; by default
+	// they are followed and reported like regular files.
+	skipSymlinks bool
+	// ignoreGenerated skips dependency and test fixture directories and the
+	// files frameworks generate from env files (see generated.go).
+	ignoreGenerated bool
+	// foldCase matches patterns case-insensitively, set when a watched
+	// directory is on a case-insensitive filesystem (APFS, NTFS defaults),
+	// where .ENV.KEYS is the same file as .env.keys.
+	foldCase bool
+	// debounce is the per-file coalescing window: an event (re)arms the
+	// file's timer in pending and one FileEvent is sent once the file has
+	// been quiet that long. Zero reports every event as it arrives.
+	debounce time.Duration
+	pending  map[string]*pendingEvent
+	// clock times the debounce windows.
+	clock clock.Clock
+	// due carries paths whose window closed to run(), which stays the only
+	// sender on events.
+	due chan string
+	// polled are the watch roots on Windows drives under WSL, which get no
+	// inotify events for changes made from Windows and are scanned every
+	// pollInterval instead; seen holds what the last scan found, and polls
+	// carries the changes to run().
+	polled   map[string]bool
+	seen     map[string]fileStamp
+	pollOnce sync.Once
+	polls    chan polledChange
+}
+
+// fileStamp is what a poll compares to spot a change.
+type fileStamp struct {
+	mod  time.Time
+	size int64
+}
+
+// polledChange is a change a poll found.
+type polledChange struct {
+	path string
+	op   fsnotify.Op
+}
+
+// Seams for tests: which directories are polled, and how often.
+var (
+	onWindowsDrive = wsl.OnWindowsDrive
+	pollInterval   = 2 * time.Second
+)
+
+// pendingEvent is a file event held back for the coalescing window.
+type pendingEvent struct {
+	timer clock.Timer
+	op    fsnotify.Op
 }
 
 // New creates and returns a Watcher configured with the provided filename include patterns, exclude patterns, and recursion setting.
@@ -49,6 +110,12 @@ func New(patterns, exclude []string, recursive bool) (*Watcher, error) {
 		events:    make(chan FileEvent, 100),
 		done:      make(chan struct{}),
 		lastMod:   make(map[string]time.Time),
+		pending:   make(map[string]*pendingEvent),
+		clock:     clock.Real{},
+		due:       make(chan string),
+		polled:    make(map[string]bool),
+		seen:      make(map[string]fileStamp),
+		polls:     make(chan polledChange),
 	}, nil
 }
 
@@ -57,13 +124,81 @@ func (w *Watcher) Events() <-chan FileEvent {
 	return w.events
 }
 
-// AddDirectory adds a directory to watch
+// SetSkipSymlinks makes the watcher ignore env files that are symbolic links
+// instead of following them. Call it before Start.
+func (w *Watcher) SetSkipSymlinks(skip bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.skipSymlinks = skip
+}
+
+// SetIgnoreGenerated makes the watcher skip dependency and test fixture
+// directories and framework-generated files. Call it before Start.
+func (w *Watcher) SetIgnoreGenerated(ignore bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.ignoreGenerated = ignore
+}
+
+// SetDebounce sets the per-file coalescing window. Every write, create or
+// rename onto a file within d of the previous one is folded into a single
+// FileEvent, so an editor's save — often a burst of writes, or a temp file
+// renamed over the original — is reported once. Call it before Start.
+func (w *Watcher) SetDebounce(d time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.debounce = d
+}
+
+// SetClock replaces the clock timing the debounce windows, for tests. Call
+// it before Start.
+func (w *Watcher) SetClock(c clock.Clock) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.clock = c
+}
+
+// AddDirectory adds a directory to watch. A directory on a Windows drive
+// under WSL is polled instead.
 func (w *Watcher) AddDirectory(dir string) error {
 	dir = expandPath(dir)
+	if caseInsensitive(dir) {
+		w.mu.Lock()
+		w.foldCase = true
+		w.mu.Unlock()
+	}
+	if onWindowsDrive(dir) {
+		w.addPolled(dir)
+		return nil
+	}
 	if w.recursive {
 		return w.addRecursive(dir)
 	}
-	return w.fsWatcher.Add(dir)
+	return w.add(longpath.Extended(dir))
+}
+
+// add registers path with fsnotify. kqueue, its backend on macOS and the BSDs,
+// holds a descriptor for every file in a watched directory, so a large tree
+// can exhaust them; that is reported with the limits to raise rather than as
+// a bare "too many open files".
+func (w *Watcher) add(path string) error {
+	err := w.fsWatcher.Add(path)
+	if errors.Is(err, syscall.EMFILE) || errors.Is(err, syscall.ENFILE) {
+		if kqueue(runtime.GOOS) {
+			return fmt.Errorf("watch %s: %w (kqueue needs one descriptor per watched file: raise `ulimit -n`, kern.maxfilesperproc on FreeBSD or the login class openfiles on OpenBSD, or watch fewer directories)", path, err)
+		}
+		return fmt.Errorf("watch %s: %w", path, err)
+	}
+	return err
+}
+
+// kqueue reports whether fsnotify watches with kqueue on goos.
+func kqueue(goos string) bool {
+	switch goos {
+	case "darwin", "freebsd", "openbsd", "netbsd", "dragonfly":
+		return true
+	}
+	return false
 }
 
 // addRecursive walks dir and registers every directory except hidden ones
@@ -83,19 +218,120 @@ func (w *Watcher) addRecursive(dir string) error {
 		// Compare the cleaned path against the cleaned root so a dotted root
 		// passed with a trailing slash or otherwise non-clean form (e.g.
 		// "~/.dotfiles/") still matches the root and isn't SkipDir'd.
-		if filepath.Clean(path) != root && isHiddenName(info.Name()) {
-			return filepath.SkipDir // Skip nested hidden directories
+		if filepath.Clean(path) != root && w.skipDir(info.Name()) {
+			return filepath.SkipDir // Skip nested hidden and generated directories
 		}
-		return w.fsWatcher.Add(path)
+		// fsnotify's Win32 calls need the \\?\ form past MAX_PATH; events then
+		// carry it too, and handleEvent strips it again.
+		return w.add(longpath.Extended(path))
 	})
 }
 
+// addPolled makes dir a polled root. The files already there are the
+// baseline, as fsnotify would only report later changes.
+func (w *Watcher) addPolled(dir string) {
+	root := filepath.Clean(dir)
+	stamps := w.stamps(root)
+	w.mu.Lock()
+	already := w.polled[root]
+	w.polled[root] = true
+	for path, st := range stamps {
+		w.seen[path] = st
+	}
+	w.mu.Unlock()
+	if already {
+		return
+	}
+	log.Printf("Polling %s every %s: it is on a Windows drive, which sends WSL no change events", root, pollInterval)
+	w.pollOnce.Do(func() { go w.pollLoop() })
+}
+
+// pollLoop scans the polled roots every pollInterval until Stop.
+func (w *Watcher) pollLoop() {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-ticker.C:
+			w.poll()
+		}
+	}
+}
+
+// poll scans every polled root and hands run() each file that appeared or
+// whose modification time or size changed since the last scan.
+func (w *Watcher) poll() {
+	w.mu.RLock()
+	roots := make([]string, 0, len(w.polled))
+	for root := range w.polled {
+		roots = append(roots, root)
+	}
+	w.mu.RUnlock()
+
+	current := make(map[string]fileStamp)
+	for _, root := range roots {
+		for path, st := range w.stamps(root) {
+			current[path] = st
+		}
+	}
+	w.mu.Lock()
+	var changes []polledChange
+	for path, st := range current {
+		if prev, ok := w.seen[path]; !ok {
+			changes = append(changes, polledChange{path, fsnotify.Create})
+		} else if prev != st {
+			changes = append(changes, polledChange{path, fsnotify.Write})
+		}
+	}
+	w.seen = current
+	w.mu.Unlock()
+
+	for _, c := range changes {
+		select {
+		case w.polls <- c:
+		case <-w.done:
+			return
+		}
+	}
+}
+
+// stamps returns the stamp of every file under root the watcher reports.
+func (w *Watcher) stamps(root string) map[string]fileStamp {
+	stamps := make(map[string]fileStamp)
+	for _, path := range w.Scan(root) {
+		if info, err := os.Stat(path); err == nil {
+			stamps[path] = fileStamp{mod: info.ModTime(), size: info.Size()}
+		}
+	}
+	return stamps
+}
+
 // isHiddenName reports whether a directory base name denotes a hidden directory
 // ("." prefix), treating the current-dir entry "." as not hidden.
 func isHiddenName(name string) bool {
 	return name != "." && strings.HasPrefix(name, ".")
 }
 
+// skipDir reports whether the walks skip a directory below a root: hidden
+// ones always, and generated ones when they are ignored.
+func (w *Watcher) skipDir(name string) bool {
+	w.mu.RLock()
+	ignoreGenerated := w.ignoreGenerated
+	w.mu.RUnlock()
+	return isHiddenName(name) || (ignoreGenerated && IsGeneratedDir(name))
+}
+
+// ignored reports whether base is never an env file, whatever the patterns
+// say: an editor's scratch file, or, when they are ignored, a generated one.
+func (w *Watcher) ignored(base string) bool {
+	w.mu.RLock()
+	ignoreGenerated := w.ignoreGenerated
+	w.mu.RUnlock()
+	return IsEditorTemp(base) || (ignoreGenerated && IsGenerated(base))
+}
+
 // Start begins watching for file changes
 func (w *Watcher) Start() {
 	go w.run()
@@ -110,6 +346,12 @@ func (w *Watcher) Stop() {
 	// spurious "already closed" and isn't safe for concurrent calls).
 	w.stopOnce.Do(func() {
 		close(w.done)
+		w.mu.Lock()
+		for path, p := range w.pending {
+			p.timer.Stop()
+			delete(w.pending, path)
+		}
+		w.mu.Unlock()
 		if err := w.fsWatcher.Close(); err != nil {
 			log.Printf("Watcher close error: %v", err)
 		}
@@ -129,6 +371,18 @@ func (w *Watcher) run() {
 				return
 			}
 			w.handleEvent(event)
+		case c := <-w.polls:
+			if !w.coalesce(c.path, c.op) {
+				w.emit(c.path, c.op)
+			}
+		case path := <-w.due:
+			w.mu.Lock()
+			p, ok := w.pending[path]
+			delete(w.pending, path)
+			w.mu.Unlock()
+			if ok {
+				w.emit(path, p.op)
+			}
 		case err, ok := <-w.fsWatcher.Errors:
 			if !ok {
 				return
@@ -145,13 +399,22 @@ func (w *Watcher) closeEvents() {
 }
 
 func (w *Watcher) handleEvent(event fsnotify.Event) {
-	// Only care about writes and creates
+	path := longpath.Strip(event.Name)
+
+	// Only care about writes and creates, and about a rename or remove of a
+	// name that already holds a file again: an atomic save renames a temp
+	// file over the original, which some platforms (kqueue on macOS) report
+	// only as the original name going away. The file now at the name is the
+	// saved one.
 	if event.Op&(fsnotify.Write|fsnotify.Create) == 0 {
-		return
+		if event.Op&(fsnotify.Rename|fsnotify.Remove) == 0 {
+			return
+		}
+		if _, err := os.Lstat(path); err != nil {
+			return
+		}
 	}
 
-	path := event.Name
-
 	// If a new (non-hidden) directory was created, start watching it recursively
 	// so that .env files created beneath it later are not missed (#348 G2). Do
 	// this before the pattern filter, since a directory name won't match .env*.
@@ -163,14 +426,54 @@ func (w *Watcher) handleEvent(event fsnotify.Event) {
 		}
 	}
 
-	// Must match an include pattern and not be excluded.
-	if !baseMatchesAny(path, w.patterns) || baseMatchesAny(path, w.exclude) {
+	w.mu.RLock()
+	fold := w.foldCase
+	w.mu.RUnlock()
+
+	// Must match an include pattern and not be excluded. Editor scratch
+	// files (.env.swp, .env~, 4913) and generated ones (.env.local.php) are
+	// never env files.
+	if !baseMatchesAny(path, w.patterns, fold) || baseMatchesAny(path, w.exclude, fold) ||
+		w.ignored(filepath.Base(path)) {
 		return
 	}
 
-	// Get file info for mod time
-	info, err := os.Stat(path)
-	if err != nil {
+	if !w.coalesce(path, event.Op) {
+		w.emit(path, event.Op)
+	}
+}
+
+// coalesce holds the event for path back for the debounce window, restarting
+// the window when one is already pending. It reports false when coalescing
+// is off and the caller should emit right away.
+func (w *Watcher) coalesce(path string, op fsnotify.Op) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.debounce <= 0 {
+		return false
+	}
+	if p, ok := w.pending[path]; ok {
+		p.op |= op
+		p.timer.Reset(w.debounce)
+		return true
+	}
+	w.pending[path] = &pendingEvent{
+		op: op,
+		timer: w.clock.AfterFunc(w.debounce, func() {
+			select {
+			case w.due <- path:
+			case <-w.done:
+			}
+		}),
+	}
+	return true
+}
+
+// emit reports a change to path if it is still a regular file (or, unless
+// symlinks are skipped, a link to one).
+func (w *Watcher) emit(path string, op fsnotify.Op) {
+	info, ok := w.regularFile(path)
+	if !ok {
 		return
 	}
 
@@ -184,15 +487,70 @@ func (w *Watcher) handleEvent(event fsnotify.Event) {
 	case w.events <- FileEvent{
 		Path:      path,
 		ModTime:   info.ModTime(),
-		Operation: event.Op.String(),
+		Operation: op.String(),
 	}:
 	case <-w.done:
 	}
 }
 
+// regularFile stats path for reporting. Lstat first so the symlink policy
+// sees the link itself, then Stat for the target: only regular files are
+// reported, so a directory named .env.d or a dangling link never reaches
+// encryption.
+func (w *Watcher) regularFile(path string) (os.FileInfo, bool) {
+	w.mu.RLock()
+	skipSymlinks := w.skipSymlinks
+	w.mu.RUnlock()
+
+	linfo, err := os.Lstat(path)
+	if err != nil || (skipSymlinks && linfo.Mode()&os.ModeSymlink != 0) {
+		return nil, false
+	}
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return nil, false
+	}
+	return info, true
+}
+
+// Scan returns the files under dir the watcher would report a change to:
+// those matching its patterns and not its excludes, skipping nested hidden
+// and ignored generated directories (and everything below dir's top level
+// when not recursive). It finds files that arrived without an event the
+// guardian acted on, such as a branch switch that checked out a plaintext
+// .env.
+func (w *Watcher) Scan(dir string) []string {
+	w.mu.RLock()
+	fold := w.foldCase
+	w.mu.RUnlock()
+
+	root := filepath.Clean(expandPath(dir))
+	var files []string
+	_ = filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return nil // Skip inaccessible entries
+		}
+		if d.IsDir() {
+			if path != root && (!w.recursive || w.skipDir(d.Name())) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !baseMatchesAny(path, w.patterns, fold) || baseMatchesAny(path, w.exclude, fold) ||
+			w.ignored(d.Name()) {
+			return nil
+		}
+		if _, ok := w.regularFile(path); ok {
+			files = append(files, path)
+		}
+		return nil
+	})
+	return files
+}
+
 // shouldWatchNewDir reports whether the create event should trigger a recursive
 // AddDirectory of event.Name: only in recursive mode, only for create events,
-// and never for hidden directories. AddDirectory exempts its own (possibly
+// and never for hidden or ignored generated directories. AddDirectory exempts its own (possibly
 // dotted) root from the hidden-dir skip so a registered ~/.dotfiles is watched,
 // but that exemption would also make a runtime-created hidden dir its own root
 // and watch it — so hidden names are filtered out here before re-entering
@@ -201,20 +559,43 @@ func (w *Watcher) shouldWatchNewDir(event fsnotify.Event) bool {
 	if !w.recursive || event.Op&fsnotify.Create == 0 {
 		return false
 	}
-	return !isHiddenName(filepath.Base(event.Name))
+	return !w.skipDir(filepath.Base(event.Name))
 }
 
 // baseMatchesAny reports whether path's base name matches any of the glob
 // patterns. Shared by the include- and exclude-pattern checks so the two no
-// longer duplicate the base/loop/filepath.Match logic.
-func baseMatchesAny(path string, patterns []string) bool {
+// longer duplicate the base/loop/filepath.Match logic. With fold, both sides
+// are lowercased first, for case-insensitive filesystems.
+func baseMatchesAny(path string, patterns []string, fold bool) bool {
+	return matchingPattern(path, patterns, fold) != ""
+}
+
+// matchingPattern returns the first of patterns path's base name matches, or
+// "" when none does; fold as for baseMatchesAny.
+func matchingPattern(path string, patterns []string, fold bool) string {
 	base := filepath.Base(path)
+	if fold {
+		base = strings.ToLower(base)
+	}
 	for _, pattern := range patterns {
-		if matched, _ := filepath.Match(pattern, base); matched {
-			return true
+		p := pattern
+		if fold {
+			p = strings.ToLower(p)
+		}
+		if matched, _ := filepath.Match(p, base); matched {
+			return pattern
 		}
 	}
-	return false
+	return ""
+}
+
+// Match returns the include pattern and the exclude pattern path's base name
+// matches, "" for none, as a watcher with patterns and exclude would match
+// it (case-insensitively on a case-insensitive filesystem). The file is
+// reported when include is set and excluded is not.
+func Match(path string, patterns, exclude []string) (include, excluded string) {
+	fold := caseInsensitive(filepath.Dir(path))
+	return matchingPattern(path, patterns, fold), matchingPattern(path, exclude, fold)
 }
 
 // LastModified returns the last modification time for a file
@@ -224,6 +605,41 @@ func (w *Watcher) LastModified(path string) time.Time {
 	return w.lastMod[path]
 }
 
+// caseInsensitive reports whether dir is on a case-insensitive filesystem, by
+// looking the nearest path element with letters up under a case-swapped name.
+// If no element has letters it falls back to the platform default
+// (case-insensitive on macOS and Windows).
+func caseInsensitive(dir string) bool {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return runtime.GOOS == "darwin" || runtime.GOOS == "windows"
+	}
+	for p := dir; filepath.Dir(p) != p; p = filepath.Dir(p) {
+		base := filepath.Base(p)
+		swapped := swapCase(base)
+		if swapped == base {
+			continue
+		}
+		orig, err := os.Stat(p)
+		if err != nil {
+			break
+		}
+		other, err := os.Stat(filepath.Join(filepath.Dir(p), swapped))
+		return err == nil && os.SameFile(orig, other)
+	}
+	return runtime.GOOS == "darwin" || runtime.GOOS == "windows"
+}
+
+// swapCase inverts the case of every letter in s.
+func swapCase(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsUpper(r) {
+			return unicode.ToLower(r)
+		}
+		return unicode.ToUpper(r)
+	}, s)
+}
+
 // expandPath expands a leading "~/" in path to the current user's home directory.
 // If path does not start with "~/", it is returned unchanged.
 func expandPath(path string) string {
@@ -7,6 +7,9 @@ import (
 	"strconv"
 	"testing"
 	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/jainal09/envdrift-agent/internal/clock"
 )
 
 func TestNew(t *testing.T) {
@@ -33,7 +36,7 @@ func assertBaseMatchCases(t *testing.T, patterns []string, label string, cases [
 	t.Helper()
 	for _, tt := range cases {
 		t.Run(tt.path, func(t *testing.T) {
-			if got := baseMatchesAny(tt.path, patterns); got != tt.expected {
+			if got := baseMatchesAny(tt.path, patterns, false); got != tt.expected {
 				t.Errorf("baseMatchesAny(%q, %s) = %v, expected %v", tt.path, label, got, tt.expected)
 			}
 		})
@@ -66,6 +69,343 @@ func TestIsExcluded(t *testing.T) {
 	})
 }
 
+func TestBaseMatchesAnyFoldCase(t *testing.T) {
+	tests := []struct {
+		path     string
+		patterns []string
+		fold     bool
+		expected bool
+	}{
+		{".ENV.KEYS", []string{".env.keys"}, true, true},
+		{".Env.Local", []string{".env*"}, true, true},
+		{".ENV", []string{".env*"}, false, false},
+		{"CONFIG.ENV", []string{"*.env"}, true, true},
+	}
+	for _, tt := range tests {
+		if got := baseMatchesAny(tt.path, tt.patterns, tt.fold); got != tt.expected {
+			t.Errorf("baseMatchesAny(%q, %v, fold=%v) = %v, expected %v", tt.path, tt.patterns, tt.fold, got, tt.expected)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	dir := t.TempDir()
+	patterns, exclude := []string{"*.env", ".env*"}, []string{".env.example"}
+	tests := []struct {
+		name, include, excluded string
+	}{
+		{".env.local", ".env*", ""},
+		{"app.env", "*.env", ""},
+		{".env.example", ".env*", ".env.example"},
+		{"README.md", "", ""},
+	}
+	for _, tt := range tests {
+		include, excluded := Match(filepath.Join(dir, tt.name), patterns, exclude)
+		if include != tt.include || excluded != tt.excluded {
+			t.Errorf("Match(%s) = %q, %q; want %q, %q", tt.name, include, excluded, tt.include, tt.excluded)
+		}
+	}
+}
+
+// TestCaseInsensitiveMatchesFilesystem checks the detection against the
+// filesystem the test runs on: case-sensitive on typical Linux, insensitive on
+// default macOS (APFS) and Windows (NTFS) volumes.
+func TestCaseInsensitiveMatchesFilesystem(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "Probe")
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	orig, _ := os.Stat(dir)
+	other, err := os.Stat(filepath.Join(filepath.Dir(dir), "pROBE"))
+	want := err == nil && os.SameFile(orig, other)
+
+	if got := caseInsensitive(dir); got != want {
+		t.Errorf("caseInsensitive(%q) = %v; the filesystem says %v", dir, got, want)
+	}
+}
+
+// TestHandleEventFileKinds covers what reaches the events channel: regular
+// files and (by default) symlinks to them, but never directories matching the
+// pattern, dangling links, or links when symlinks are skipped.
+func TestHandleEventFileKinds(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "shared.env")
+	if err := os.WriteFile(target, []byte("A=1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(dir, ".env")
+	if err := os.Symlink(target, link); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+	dangling := filepath.Join(dir, ".env.gone")
+	if err := os.Symlink(filepath.Join(dir, "missing"), dangling); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, ".env.d"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	emitted := func(w *Watcher, path string) bool {
+		w.handleEvent(fsnotify.Event{Name: path, Op: fsnotify.Write})
+		select {
+		case <-w.Events():
+			return true
+		default:
+			return false
+		}
+	}
+
+	w, _ := New([]string{".env*"}, nil, false)
+	defer w.Stop()
+	if !emitted(w, link) {
+		t.Error("a symlinked env file must be followed by default")
+	}
+	for _, path := range []string{dangling, filepath.Join(dir, ".env.d")} {
+		if emitted(w, path) {
+			t.Errorf("%s is not a regular file and must not be reported", path)
+		}
+	}
+
+	w.SetSkipSymlinks(true)
+	if emitted(w, link) {
+		t.Error("symlinks = skip must ignore a symlinked env file")
+	}
+}
+
+// TestHandleEventCoalesces checks that a burst of events for one file within
+// the debounce window, like an editor's write-temp-then-rename save, is
+// reported as a single event once the file goes quiet.
+func TestHandleEventCoalesces(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, ".env")
+	if err := os.WriteFile(path, []byte("A=1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	w, _ := New([]string{".env*"}, nil, false)
+	defer w.Stop()
+	fake := clock.NewFake(time.Now())
+	w.SetClock(fake)
+	w.SetDebounce(2 * time.Second)
+	w.Start()
+
+	w.handleEvent(fsnotify.Event{Name: path, Op: fsnotify.Create})
+	for i := 0; i < 3; i++ {
+		fake.Advance(time.Second)
+		w.handleEvent(fsnotify.Event{Name: path, Op: fsnotify.Write})
+	}
+	if n := fake.Pending(); n != 1 {
+		t.Fatalf("%d debounce timers for one file; want 1", n)
+	}
+	fake.Advance(2*time.Second - time.Nanosecond)
+	select {
+	case ev := <-w.Events():
+		t.Fatalf("event %+v before the file went quiet", ev)
+	default:
+	}
+
+	fake.Advance(time.Nanosecond)
+	select {
+	case ev := <-w.Events():
+		if ev.Path != path || ev.Operation != "CREATE|WRITE" {
+			t.Errorf("coalesced event = %+v", ev)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no event after the debounce window")
+	}
+	if n := fake.Pending(); n != 0 {
+		t.Errorf("%d debounce timers left after the event", n)
+	}
+	fake.Advance(2 * time.Second)
+	select {
+	case ev := <-w.Events():
+		t.Errorf("second event %+v for one burst", ev)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+// TestPolledDirectory: a directory on a Windows drive under WSL is scanned
+// instead of watched, reporting new and modified env files but not the ones
+// already there when it was added.
+func TestPolledDirectory(t *testing.T) {
+	origDrive, origInterval := onWindowsDrive, pollInterval
+	t.Cleanup(func() { onWindowsDrive, pollInterval = origDrive, origInterval })
+	onWindowsDrive = func(string) bool { return true }
+	pollInterval = 20 * time.Millisecond
+
+	dir := t.TempDir()
+	existing := filepath.Join(dir, ".env")
+	if err := os.WriteFile(existing, []byte("A=1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	w, err := New([]string{".env*"}, nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Stop()
+	if err := w.AddDirectory(dir); err != nil {
+		t.Fatal(err)
+	}
+	if len(w.fsWatcher.WatchList()) != 0 {
+		t.Errorf("polled directory also watched: %v", w.fsWatcher.WatchList())
+	}
+	w.Start()
+
+	next := func() FileEvent {
+		t.Helper()
+		select {
+		case ev := <-w.Events():
+			return ev
+		case <-time.After(2 * time.Second):
+			t.Fatal("no event from the poll")
+		}
+		return FileEvent{}
+	}
+	created := filepath.Join(dir, "sub", ".env.local")
+	if err := os.MkdirAll(filepath.Dir(created), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(created, []byte("B=1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if ev := next(); ev.Path != created || ev.Operation != fsnotify.Create.String() {
+		t.Errorf("event = %+v, want CREATE of %s", ev, created)
+	}
+	if err := os.WriteFile(existing, []byte("A=22\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if ev := next(); ev.Path != existing || ev.Operation != fsnotify.Write.String() {
+		t.Errorf("event = %+v, want WRITE of %s", ev, existing)
+	}
+}
+
+func TestIsEditorTemp(t *testing.T) {
+	for name, want := range map[string]bool{
+		".env.swp":               true,
+		".env.SWO":               true,
+		".env~":                  true,
+		".env.tmp":               true,
+		".env___jb_tmp___":       true,
+		".#.env":                 true,
+		"#.env#":                 true,
+		"4913":                   true,
+		".env":                   false,
+		".env.production":        false,
+		".env.local":             false,
+		"config.env":             false,
+		".env.2024":              false,
+		".env.template-override": false,
+	} {
+		if got := IsEditorTemp(name); got != want {
+			t.Errorf("IsEditorTemp(%q) = %v; want %v", name, got, want)
+		}
+	}
+}
+
+// TestHandleEventAtomicSave checks that editor scratch files are never
+// reported, and that a rename or remove of a watched name is reported when a
+// file is back at that name (the target of an atomic save).
+func TestHandleEventAtomicSave(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, ".env")
+	if err := os.WriteFile(path, []byte("A=1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	swap := filepath.Join(dir, ".env.swp")
+	if err := os.WriteFile(swap, []byte("b0VIM"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	w, _ := New([]string{"*"}, nil, false)
+	defer w.Stop()
+	emitted := func(name string, op fsnotify.Op) bool {
+		w.handleEvent(fsnotify.Event{Name: name, Op: op})
+		select {
+		case <-w.Events():
+			return true
+		default:
+			return false
+		}
+	}
+
+	if emitted(swap, fsnotify.Write) {
+		t.Error("a vim swap file was reported")
+	}
+	if !emitted(path, fsnotify.Rename) {
+		t.Error("a file renamed over .env was not reported")
+	}
+	if emitted(filepath.Join(dir, ".env.gone"), fsnotify.Rename) {
+		t.Error("a name renamed away was reported")
+	}
+	if emitted(path, fsnotify.Chmod) {
+		t.Error("a chmod was reported")
+	}
+}
+
+func TestScan(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{".env", ".env.example", ".env.swp", "sub/.env.local", ".hidden/.env", "README.md"} {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("A=1\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	w, _ := New([]string{".env*"}, []string{".env.example"}, true)
+	defer w.Stop()
+	got := w.Scan(root)
+	want := []string{filepath.Join(root, ".env"), filepath.Join(root, "sub", ".env.local")}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("Scan = %v; want %v", got, want)
+	}
+}
+
+func TestScanIgnoreGenerated(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{".env", ".env.local.php", "node_modules/pkg/.env", "testdata/.env.test", "api/vendor/lib/.env", "api/.env"} {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("A=1\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	w, _ := New([]string{".env*"}, nil, true)
+	defer w.Stop()
+	if got := w.Scan(root); len(got) != 6 {
+		t.Errorf("Scan without ignore_generated = %v; want every file", got)
+	}
+	w.SetIgnoreGenerated(true)
+	got := w.Scan(root)
+	want := []string{filepath.Join(root, ".env"), filepath.Join(root, "api", ".env")}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("Scan = %v; want %v", got, want)
+	}
+}
+
+func TestIsGenerated(t *testing.T) {
+	for name, want := range map[string]bool{
+		".env.local.php": true,
+		".ENV.PHP":       true,
+		".env":           false,
+		".env.local":     false,
+	} {
+		if got := IsGenerated(name); got != want {
+			t.Errorf("IsGenerated(%q) = %v; want %v", name, got, want)
+		}
+	}
+	for name, want := range map[string]bool{"node_modules": true, "testdata": true, "vendor": true, "src": false, "config": false} {
+		if got := IsGeneratedDir(name); got != want {
+			t.Errorf("IsGeneratedDir(%q) = %v; want %v", name, got, want)
+		}
+	}
+}
+
 func TestExpandPath(t *testing.T) {
 	home, _ := os.UserHomeDir()
 
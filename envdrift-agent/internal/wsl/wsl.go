@@ -0,0 +1,131 @@
+// Package wsl recognises the Windows Subsystem for Linux, where the agent runs
+// as a Linux program but the user's editor, desktop and often their files are
+// on the Windows side.
+//
+// Files under /mnt/c and the other Windows drives sit on a 9p (WSL 2) or
+// drvfs (WSL 1) mount that delivers no inotify events for changes made from
+// Windows, so the watcher polls them; notifications go to Windows as toasts
+// through powershell.exe; and lock detection only sees Linux processes.
+// Limitations lists what that means for the user. Everything here reports
+// false or nothing outside WSL.
+package wsl
+
+import (
+	"bufio"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Seams for tests.
+var (
+	goos       = runtime.GOOS
+	getenv     = os.Getenv
+	osRelease  = "/proc/sys/kernel/osrelease"
+	mountsFile = "/proc/self/mounts"
+	lookPath   = exec.LookPath
+	systemPwsh = "/mnt/c/Windows/System32/WindowsPowerShell/v1.0/powershell.exe"
+	systemdDir = "/run/systemd/system"
+)
+
+// Detect reports whether the agent runs inside WSL: WSL sets WSL_DISTRO_NAME
+// for login shells, and its kernels name Microsoft in their release, which
+// also covers services started without that variable.
+func Detect() bool {
+	if goos != "linux" {
+		return false
+	}
+	if getenv("WSL_DISTRO_NAME") != "" {
+		return true
+	}
+	data, err := os.ReadFile(osRelease)
+	return err == nil && strings.Contains(strings.ToLower(string(data)), "microsoft")
+}
+
+// Distro returns the WSL distribution name, or "WSL" when it is not known.
+func Distro() string {
+	if name := getenv("WSL_DISTRO_NAME"); name != "" {
+		return name
+	}
+	return "WSL"
+}
+
+// windowsFS are the filesystem types WSL mounts Windows drives with.
+var windowsFS = map[string]bool{"9p": true, "drvfs": true}
+
+// OnWindowsDrive reports whether path is on a Windows drive mounted into WSL,
+// such as /mnt/c, wherever wsl.conf's automount root puts them.
+func OnWindowsDrive(path string) bool {
+	if !Detect() {
+		return false
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	f, err := os.Open(mountsFile)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		// device mountpoint fstype options dump pass; spaces in the mount
+		// point are written as \040.
+		fields := strings.Fields(sc.Text())
+		if len(fields) < 3 || !windowsFS[fields[2]] {
+			continue
+		}
+		mount := strings.ReplaceAll(fields[1], `\040`, " ")
+		if abs == mount || strings.HasPrefix(abs, strings.TrimSuffix(mount, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// PowerShell returns the Windows powershell.exe reachable through WSL's
+// interop, or "" when interop or the Windows PATH is turned off in wsl.conf
+// and it is not at its usual location either.
+func PowerShell() string {
+	if !Detect() {
+		return ""
+	}
+	if path, err := lookPath("powershell.exe"); err == nil {
+		return path
+	}
+	if _, err := os.Stat(systemPwsh); err == nil {
+		return systemPwsh
+	}
+	return ""
+}
+
+// Systemd reports whether the distribution boots with systemd, which WSL
+// only does when wsl.conf sets [boot] systemd=true.
+func Systemd() bool {
+	info, err := os.Stat(systemdDir)
+	return err == nil && info.IsDir()
+}
+
+// Limitations describes how the agent behaves differently in this WSL
+// distribution, one sentence each, for status and bug reports.
+func Limitations() []string {
+	if !Detect() {
+		return nil
+	}
+	lines := []string{
+		"Windows drives (/mnt/c, ...) are polled for changes, so encryption there can lag by a few seconds",
+		"lock detection only sees Linux processes: save and close files in Windows editors before the idle timeout",
+	}
+	if PowerShell() == "" {
+		lines = append(lines, "powershell.exe is unreachable (WSL interop is off), so there are no desktop notifications")
+	} else {
+		lines = append(lines, "notifications appear as Windows toasts through powershell.exe")
+	}
+	if !Systemd() {
+		lines = append(lines, "systemd is off, so `install` cannot start the agent at boot: set [boot] systemd=true in /etc/wsl.conf, or run `envdrift-agent start`")
+	}
+	return lines
+}
@@ -0,0 +1,102 @@
+package wsl
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeWSL points the seams at a temp directory: a kernel release naming
+// Microsoft, or not, and a mount table with C: and a spaced drive.
+func fakeWSL(t *testing.T, release string) string {
+	t.Helper()
+	dir := t.TempDir()
+	origOS, origEnv, origRel, origMounts, origPwsh, origSystemd := goos, getenv, osRelease, mountsFile, systemPwsh, systemdDir
+	t.Cleanup(func() {
+		goos, getenv, osRelease, mountsFile, systemPwsh, systemdDir = origOS, origEnv, origRel, origMounts, origPwsh, origSystemd
+	})
+	goos = "linux"
+	getenv = func(string) string { return "" }
+	osRelease = filepath.Join(dir, "osrelease")
+	mountsFile = filepath.Join(dir, "mounts")
+	systemPwsh = filepath.Join(dir, "powershell.exe")
+	systemdDir = filepath.Join(dir, "systemd")
+	t.Setenv("PATH", dir)
+	write := func(name, data string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("osrelease", release+"\n")
+	write("mounts", "/dev/sdc / ext4 rw 0 0\n"+
+		"C:\\134 /mnt/c 9p rw,aname=drvfs;path=C:\\ 0 0\n"+
+		"D:\\134 /mnt/my\\040drive drvfs rw 0 0\n")
+	return dir
+}
+
+func TestDetect(t *testing.T) {
+	fakeWSL(t, "5.15.153.1-microsoft-standard-WSL2")
+	if !Detect() {
+		t.Error("Detect() = false for a Microsoft kernel")
+	}
+
+	fakeWSL(t, "6.8.0-45-generic")
+	if Detect() {
+		t.Error("Detect() = true for a stock kernel")
+	}
+	getenv = func(k string) string { return map[string]string{"WSL_DISTRO_NAME": "Ubuntu"}[k] }
+	if !Detect() || Distro() != "Ubuntu" {
+		t.Errorf("Detect() = %v, Distro() = %q with WSL_DISTRO_NAME", Detect(), Distro())
+	}
+	goos = "windows"
+	if Detect() {
+		t.Error("Detect() = true on Windows itself")
+	}
+}
+
+func TestOnWindowsDrive(t *testing.T) {
+	fakeWSL(t, "microsoft")
+	for path, want := range map[string]bool{
+		"/mnt/c":                  true,
+		"/mnt/c/Users/me/project": true,
+		"/mnt/my drive/app":       true,
+		"/mnt/cdrom":              false,
+		"/home/me/project":        false,
+	} {
+		if got := OnWindowsDrive(path); got != want {
+			t.Errorf("OnWindowsDrive(%q) = %v, want %v", path, got, want)
+		}
+	}
+
+	fakeWSL(t, "generic")
+	if OnWindowsDrive("/mnt/c/Users") {
+		t.Error("OnWindowsDrive() = true outside WSL")
+	}
+}
+
+func TestLimitations(t *testing.T) {
+	dir := fakeWSL(t, "microsoft")
+	lines := strings.Join(Limitations(), "\n")
+	for _, want := range []string{"polled", "Linux processes", "unreachable", "systemd=true"} {
+		if !strings.Contains(lines, want) {
+			t.Errorf("Limitations() lacks %q:\n%s", want, lines)
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "powershell.exe"), nil, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "systemd"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	lines = strings.Join(Limitations(), "\n")
+	if !strings.Contains(lines, "Windows toasts") || strings.Contains(lines, "systemd") {
+		t.Errorf("Limitations() with interop and systemd:\n%s", lines)
+	}
+
+	fakeWSL(t, "generic")
+	if got := Limitations(); got != nil {
+		t.Errorf("Limitations() outside WSL = %q", got)
+	}
+}
@@ -0,0 +1,210 @@
+// Package envdrift is the public Go API of the EnvDrift agent, for tools that
+// embed its checks without running the agent: a deploy CLI that refuses to
+// ship a plaintext env file, an internal platform that audits repositories,
+// a build step that encrypts what it generated.
+//
+//	cfg, err := envdrift.LoadConfig(dir)
+//	report, err := envdrift.Check(dir, cfg)
+//	if report.Drifted() {
+//		return fmt.Errorf("plaintext env files: %v", report.Plaintext())
+//	}
+//
+// The package wraps the agent's internal packages behind types of its own,
+// so the agent's internals can change without breaking callers. It is
+// versioned with the github.com/jainal09/envdrift-agent module: within a
+// major version exported names are only added, never changed or removed,
+// and APIVersion records the additions. Encrypting needs the envdrift CLI
+// (pip install envdrift) on PATH, as the agent does.
+package envdrift
+
+import (
+	"context"
+	"path/filepath"
+	"time"
+
+	"github.com/jainal09/envdrift-agent/internal/encrypt"
+	"github.com/jainal09/envdrift-agent/internal/project"
+	"github.com/jainal09/envdrift-agent/internal/watcher"
+)
+
+// APIVersion is the version of this package's API: the minor number grows
+// with each addition.
+const APIVersion = "1.2"
+
+// ErrEnvdriftNotFound is returned by Encrypt when the envdrift CLI is not
+// installed.
+var ErrEnvdriftNotFound = encrypt.ErrEnvdriftNotFound
+
+// Config is a project's guardian settings, from the [guardian] table of the
+// envdrift.toml (or pyproject.toml [tool.envdrift]) governing it.
+type Config struct {
+	// Enabled is whether the project opted in to auto-encryption.
+	Enabled bool
+	// IdleTimeout is how long a file may stay in plaintext after its last
+	// change before the agent encrypts it.
+	IdleTimeout time.Duration
+	// Patterns are the env file names watched, e.g. ".env*".
+	Patterns []string
+	// Exclude are the names never encrypted, e.g. ".env.example".
+	Exclude []string
+	// FollowSymlinks encrypts the target of a symlinked env file; otherwise
+	// symlinks are skipped.
+	FollowSymlinks bool
+}
+
+// DefaultConfig returns the settings of a project without a [guardian]
+// table.
+func DefaultConfig() Config {
+	return fromProject(project.DefaultGuardianConfig())
+}
+
+// LoadConfig reads the settings of the project at dir, walking up to the
+// nearest envdrift config like the CLI does. A project without one has
+// DefaultConfig.
+func LoadConfig(dir string) (Config, error) {
+	cfg, err := project.LoadProjectConfig(dir)
+	if err != nil {
+		return Config{}, err
+	}
+	return fromProject(cfg), nil
+}
+
+// fromProject converts the internal project settings.
+func fromProject(cfg *project.GuardianConfig) Config {
+	return Config{
+		Enabled:        cfg.Enabled,
+		IdleTimeout:    cfg.IdleTimeout,
+		Patterns:       append([]string(nil), cfg.Patterns...),
+		Exclude:        append([]string(nil), cfg.Exclude...),
+		FollowSymlinks: cfg.Symlinks != project.SymlinksSkip,
+	}
+}
+
+// Matches reports whether cfg covers path: its name matches a pattern and
+// no exclude.
+func (c Config) Matches(path string) bool {
+	include, excluded := watcher.Match(path, c.Patterns, c.Exclude)
+	return include != "" && excluded == ""
+}
+
+// newWatcher builds the internal watcher for cfg.
+func (c Config) newWatcher() (*watcher.Watcher, error) {
+	w, err := watcher.New(c.Patterns, c.Exclude, true)
+	if err != nil {
+		return nil, err
+	}
+	w.SetSkipSymlinks(!c.FollowSymlinks)
+	return w, nil
+}
+
+// IsEncrypted reports whether the env file at path is fully encrypted: it
+// has ciphertext (dotenvx or SOPS) and no plaintext secret value.
+func IsEncrypted(path string) (bool, error) {
+	return encrypt.IsEncrypted(path)
+}
+
+// SetPlaintextAllowed sets the variables, by name or glob (FEATURE_*), that
+// are meant to stay plaintext, like [guardian] plaintext_allowed: IsEncrypted
+// and Classify do not count their values as plaintext secrets, and Encrypt
+// leaves them readable in dotenvx files. nil allows none.
+func SetPlaintextAllowed(patterns []string) {
+	encrypt.SetPlaintextAllowed(patterns)
+}
+
+// State is what Classify found in an env file.
+type State = encrypt.State
+
+// The states of an env file.
+const (
+	// Plaintext has no ciphertext value.
+	Plaintext = encrypt.Plaintext
+	// Encrypted has ciphertext and no plaintext secret value.
+	Encrypted = encrypt.Encrypted
+	// Partial mixes ciphertext with plaintext secret values.
+	Partial = encrypt.Partial
+	// Template is plaintext named as a committed template, e.g.
+	// .env.example.
+	Template = encrypt.Template
+	// Binary is not text.
+	Binary = encrypt.Binary
+)
+
+// Providers of ciphertext values.
+const (
+	ProviderDotenvx = encrypt.ProviderDotenvx
+	ProviderSOPS    = encrypt.ProviderSOPS
+)
+
+// FileState classifies an env file: its State and, when it holds
+// ciphertext, the Provider (ProviderDotenvx or ProviderSOPS) that made it.
+type FileState = encrypt.FileState
+
+// Classify reads the env file at path and reports its state, the finer
+// grain of IsEncrypted: IsEncrypted is true exactly when the state is
+// Encrypted.
+func Classify(path string) (FileState, error) {
+	return encrypt.Classify(path)
+}
+
+// Encrypt encrypts the env file at path with `envdrift encrypt`, the way the
+// agent does. ctx bounds the subprocess.
+func Encrypt(ctx context.Context, path string) error {
+	if !encrypt.IsEnvdriftAvailable() {
+		return ErrEnvdriftNotFound
+	}
+	return encrypt.EncryptSilentContext(ctx, path)
+}
+
+// FileStatus is one env file in a Report.
+type FileStatus struct {
+	Path      string
+	Encrypted bool
+	// State is the file's classification; Encrypted is State == Encrypted.
+	State FileState
+	// Err is why the file could not be read; Encrypted is then false.
+	Err error
+}
+
+// Report is the result of Check.
+type Report struct {
+	Dir   string
+	Files []FileStatus
+}
+
+// Plaintext returns the files that are not fully encrypted.
+func (r Report) Plaintext() []string {
+	var paths []string
+	for _, f := range r.Files {
+		if !f.Encrypted {
+			paths = append(paths, f.Path)
+		}
+	}
+	return paths
+}
+
+// Drifted reports whether any file has drifted from encrypted.
+func (r Report) Drifted() bool {
+	return len(r.Plaintext()) > 0
+}
+
+// Check finds the env files under dir that cfg covers, skipping hidden
+// directories as the agent does, and reports whether each is encrypted. It
+// does not consult cfg.Enabled: a caller checking a project wants the answer
+// whether or not the agent enforces it.
+func Check(dir string, cfg Config) (Report, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return Report{}, err
+	}
+	w, err := cfg.newWatcher()
+	if err != nil {
+		return Report{}, err
+	}
+	defer w.Stop()
+	report := Report{Dir: dir}
+	for _, path := range w.Scan(dir) {
+		fs, err := encrypt.Classify(path)
+		report.Files = append(report.Files, FileStatus{Path: path, Encrypted: err == nil && fs.State == Encrypted, State: fs, Err: err})
+	}
+	return report, nil
+}
@@ -0,0 +1,169 @@
+package envdrift
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jainal09/envdrift-agent/internal/vault"
+)
+
+// writeFile writes content to dir/name, creating directories.
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	cfg, err := LoadConfig(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Enabled || len(cfg.Patterns) == 0 || !cfg.FollowSymlinks {
+		t.Errorf("config without envdrift.toml = %+v; want the defaults", cfg)
+	}
+	writeFile(t, dir, "envdrift.toml", "[guardian]\nenabled = true\nidle_timeout = \"10m\"\npatterns = [\".env*\"]\nexclude = [\".env.example\"]\nsymlinks = \"skip\"\n")
+	if cfg, err = LoadConfig(dir); err != nil {
+		t.Fatal(err)
+	}
+	if !cfg.Enabled || cfg.IdleTimeout != 10*time.Minute || cfg.FollowSymlinks {
+		t.Errorf("config = %+v", cfg)
+	}
+	if !cfg.Matches(filepath.Join(dir, ".env.production")) || cfg.Matches(filepath.Join(dir, ".env.example")) {
+		t.Error("Matches must follow the patterns and excludes")
+	}
+}
+
+func TestCheck(t *testing.T) {
+	dir := t.TempDir()
+	plain := writeFile(t, dir, "api/.env", "SECRET=plaintext\n")
+	writeFile(t, dir, "web/.env.production", "DOTENV_PUBLIC_KEY_PRODUCTION=\"03ab\"\nSECRET=\"encrypted:BDx\"\n")
+	writeFile(t, dir, ".env.example", "SECRET=changeme\n")
+	writeFile(t, dir, ".git/.env", "SECRET=hidden\n")
+
+	cfg := DefaultConfig()
+	cfg.Exclude = []string{".env.example"}
+	report, err := Check(dir, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Files) != 2 {
+		t.Fatalf("Check found %+v; want api/.env and web/.env.production", report.Files)
+	}
+	if !report.Drifted() || len(report.Plaintext()) != 1 || report.Plaintext()[0] != plain {
+		t.Errorf("Plaintext = %v; want %s", report.Plaintext(), plain)
+	}
+	if ok, err := IsEncrypted(plain); err != nil || ok {
+		t.Errorf("IsEncrypted(plaintext) = %v, %v", ok, err)
+	}
+	for _, f := range report.Files {
+		if f.Path != plain && f.State != (FileState{State: Encrypted, Provider: ProviderDotenvx}) {
+			t.Errorf("State of %s = %+v; want encrypted by dotenvx", f.Path, f.State)
+		}
+	}
+}
+
+func TestClassify(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, dir, ".env", "SECRET=\"encrypted:BDx\"\nADDED=plaintext\n")
+	fs, err := Classify(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fs.State != Partial || fs.Provider != ProviderDotenvx || fs.State.String() != "partial" {
+		t.Errorf("Classify = %+v; want partial, dotenvx", fs)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	dir := t.TempDir()
+	w, err := Watch(dir, DefaultConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	writeFile(t, dir, ".env", "SECRET=plaintext\n")
+	select {
+	case e := <-w.Events():
+		// Compare names: the temp dir may be reached through a symlink.
+		if filepath.Base(e.Path) != ".env" {
+			t.Errorf("event for %s; want .env", e.Path)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("no event for a new env file")
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	for range w.Events() {
+	}
+}
+
+// memoryVault is an in-memory vault.Provider.
+type memoryVault map[string]string
+
+func (memoryVault) Name() string { return "aws" }
+
+func (m memoryVault) GetSecret(_ context.Context, name string) (string, error) {
+	v, ok := m[name]
+	if !ok {
+		return "", vault.ErrNotFound
+	}
+	return v, nil
+}
+
+func (m memoryVault) SetSecret(_ context.Context, name, value string) error {
+	m[name] = value
+	return nil
+}
+
+func TestVault(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := OpenVault(dir); !errors.Is(err, ErrNoVault) {
+		t.Fatalf("OpenVault without [vault] = %v; want ErrNoVault", err)
+	}
+
+	store := memoryVault{"myapp/prod": "DOTENV_PRIVATE_KEY_PRODUCTION=abc123"}
+	orig := newProvider
+	newProvider = func(vault.Config) (vault.Provider, error) { return store, nil }
+	t.Cleanup(func() { newProvider = orig })
+	writeFile(t, dir, "envdrift.toml", `
+[vault]
+provider = "aws"
+
+[[vault.sync.mappings]]
+secret_name = "myapp/prod"
+folder_path = "."
+`)
+	v, err := OpenVault(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if key, err := v.PrivateKey(ctx, filepath.Join(dir, ".env.production")); err != nil || key != "abc123" {
+		t.Errorf("PrivateKey = %q, %v", key, err)
+	}
+	if _, err := v.PrivateKey(ctx, filepath.Join(dir, ".env.staging")); err == nil {
+		t.Error("PrivateKey of an unmapped environment must fail")
+	}
+	if err := v.SetSecret(ctx, "other", "x"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := v.GetSecret(ctx, "missing"); !errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("GetSecret(missing) = %v; want ErrSecretNotFound", err)
+	}
+	if v.Provider() != "aws" {
+		t.Errorf("Provider = %q", v.Provider())
+	}
+}
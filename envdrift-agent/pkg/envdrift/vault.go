@@ -0,0 +1,82 @@
+package envdrift
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/jainal09/envdrift-agent/internal/keys"
+	"github.com/jainal09/envdrift-agent/internal/project"
+	"github.com/jainal09/envdrift-agent/internal/vault"
+)
+
+// ErrSecretNotFound is returned when a secret does not exist in the vault.
+var ErrSecretNotFound = vault.ErrNotFound
+
+// ErrNoVault is returned by OpenVault for a project whose envdrift config
+// has no [vault] provider.
+var ErrNoVault = errors.New("no [vault] provider configured")
+
+// Vault is the secret store a project's [vault] tables configure (AWS
+// Secrets Manager, Azure Key Vault, HashiCorp Vault, GCP Secret Manager, ...),
+// through the same settings the CLI and the agent use.
+type Vault struct {
+	provider vault.Provider
+	settings *project.VaultSettings
+}
+
+// newProvider is a seam for tests.
+var newProvider = vault.New
+
+// OpenVault opens the vault configured for the project at dir.
+func OpenVault(dir string) (*Vault, error) {
+	settings, found, err := project.LoadVaultSettings(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !found || settings.Config.Provider == "" {
+		return nil, ErrNoVault
+	}
+	p, err := newProvider(settings.Config)
+	if err != nil {
+		return nil, err
+	}
+	return &Vault{provider: p, settings: settings}, nil
+}
+
+// Provider names the backend, e.g. "aws".
+func (v *Vault) Provider() string {
+	return v.provider.Name()
+}
+
+// GetSecret returns the named secret, or an error wrapping
+// ErrSecretNotFound.
+func (v *Vault) GetSecret(ctx context.Context, name string) (string, error) {
+	return v.provider.GetSecret(ctx, name)
+}
+
+// SetSecret creates the named secret or stores a new version of it.
+func (v *Vault) SetSecret(ctx context.Context, name, value string) error {
+	return v.provider.SetSecret(ctx, name, value)
+}
+
+// PrivateKey returns the dotenvx private key for the env file at path from
+// the secret its [[vault.sync.mappings]] entry names, as `keys whereis`
+// resolves it. The value is a secret: never log it.
+func (v *Vault) PrivateKey(ctx context.Context, path string) (string, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	keyName := keys.KeyNameForFile(path)
+	m, ok := keys.VaultMapping(v.settings, filepath.Dir(path), keyName)
+	if !ok {
+		return "", fmt.Errorf("no [[vault.sync.mappings]] entry for %s", path)
+	}
+	raw, err := v.provider.GetSecret(ctx, m.SecretName)
+	if err != nil {
+		return "", err
+	}
+	return vault.KeyMaterial(raw, keyName)
+}
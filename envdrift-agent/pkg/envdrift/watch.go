@@ -0,0 +1,71 @@
+package envdrift
+
+import (
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/jainal09/envdrift-agent/internal/watcher"
+)
+
+// Event is a change that left an env file in place: created, written or
+// renamed into place.
+type Event struct {
+	Path string
+	// Op names the change, e.g. "WRITE" or "CREATE".
+	Op      string
+	ModTime time.Time
+}
+
+// Watcher reports changes to the env files under a directory.
+type Watcher struct {
+	w      *watcher.Watcher
+	events chan Event
+	done   chan struct{}
+	once   sync.Once
+}
+
+// Watch starts watching the env files under dir that cfg covers,
+// recursively and skipping hidden directories, until Close.
+func Watch(dir string, cfg Config) (*Watcher, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	w, err := cfg.newWatcher()
+	if err != nil {
+		return nil, err
+	}
+	if err := w.AddDirectory(dir); err != nil {
+		w.Stop()
+		return nil, err
+	}
+	pw := &Watcher{w: w, events: make(chan Event), done: make(chan struct{})}
+	w.Start()
+	go pw.forward()
+	return pw, nil
+}
+
+// forward converts the internal events until the watcher stops.
+func (w *Watcher) forward() {
+	defer close(w.events)
+	for e := range w.w.Events() {
+		select {
+		case w.events <- Event{Path: e.Path, Op: e.Operation, ModTime: e.ModTime}:
+		case <-w.done:
+			return
+		}
+	}
+}
+
+// Events delivers the changes. It is closed after Close.
+func (w *Watcher) Events() <-chan Event {
+	return w.events
+}
+
+// Close stops watching. It is safe to call more than once.
+func (w *Watcher) Close() error {
+	w.once.Do(func() { close(w.done) })
+	w.w.Stop()
+	return nil
+}
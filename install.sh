@@ -1,5 +1,5 @@
 #!/bin/sh
-# envdrift universal installer for macOS and Linux
+# envdrift universal installer for macOS, Linux, FreeBSD and OpenBSD
 # Usage: curl -sSL https://raw.githubusercontent.com/jainal09/envdrift/main/install.sh | sh
 #    or: curl -sSL https://raw.githubusercontent.com/jainal09/envdrift/main/install.sh | sh -s -- [options]
 #
@@ -168,6 +168,7 @@ detect_platform() {
     case "${os}" in
         darwin) os="darwin" ;;
         linux)  os="linux"  ;;
+        freebsd|openbsd) ;;
         *)      die "Unsupported OS: ${os}" ;;
     esac
 
@@ -177,6 +178,11 @@ detect_platform() {
         *)              die "Unsupported architecture: ${arch}" ;;
     esac
 
+    # OpenBSD is built for amd64 only
+    if [ "${os}" = "openbsd" ] && [ "${arch}" != "amd64" ]; then
+        die "Unsupported platform: envdrift-agent is built for OpenBSD on amd64 only (found ${arch})"
+    fi
+
     PLATFORM="${os}-${arch}"
     info "Detected platform: ${PLATFORM}"
 }
@@ -404,6 +410,8 @@ verify_checksum() {
         actual="$(sha256sum "${file}" | awk '{print $1}')"
     elif command -v shasum >/dev/null 2>&1; then
         actual="$(shasum -a 256 "${file}" | awk '{print $1}')"
+    elif command -v sha256 >/dev/null 2>&1; then
+        actual="$(sha256 -q "${file}")"
     else
         die "No sha256sum or shasum found — cannot verify the agent binary. Install one or pass --insecure-skip-checksum."
     fi